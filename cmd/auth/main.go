@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/LeoUraltsev/auth-service/internal/app"
 	"github.com/LeoUraltsev/auth-service/internal/app/logger"
 	"github.com/LeoUraltsev/auth-service/internal/config"
+	"github.com/google/uuid"
 	"log/slog"
 	"os"
 )
 
+const usage = `usage:
+  auth                запустить сервис
+  auth audit verify   проверить цепочку журнала аудита`
+
 func main() {
 	cfg, err := config.NewConfig("./config/config.local.yaml", "prod.env")
 	if err != nil {
@@ -28,8 +35,41 @@ func main() {
 		cfg,
 	)
 
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(a, os.Args[1:]))
+	}
+
 	err = a.Run()
 	if err != nil {
 		os.Exit(1)
 	}
 }
+
+func runCommand(a *app.App, args []string) int {
+	if len(args) == 2 && args[0] == "audit" && args[1] == "verify" {
+		return auditVerify(a)
+	}
+	fmt.Fprintln(os.Stderr, usage)
+	return 2
+}
+
+func auditVerify(a *app.App) int {
+	res, err := a.VerifyAudit(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit verify: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("checked events: %d\n", res.CheckedEvents)
+	fmt.Printf("checked checkpoints: %d\n", res.CheckedCheckpoints)
+	if res.Valid() {
+		fmt.Printf("chain is valid, head seq %d hash %s\n", res.LastSeq, res.LastHash)
+		return 0
+	}
+	fmt.Printf("chain is broken at seq %d", res.Break.Seq)
+	if res.Break.EventID != uuid.Nil {
+		fmt.Printf(" (event %s)", res.Break.EventID)
+	}
+	fmt.Printf(": %s\n", res.Break.Reason)
+	return 1
+}
@@ -0,0 +1,128 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: auth/introspection.proto
+
+package authapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TokenIntrospectionService_IntrospectToken_FullMethodName = "/auth.TokenIntrospectionService/IntrospectToken"
+)
+
+// TokenIntrospectionServiceClient is the client API for TokenIntrospectionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TokenIntrospectionService проверка токенов для сервисов, которые не могут проверить JWT сами.
+// Вызывать может только сервисный аккаунт или клиент OAuth, HTTP аналог - POST /introspect (RFC 7662)
+type TokenIntrospectionServiceClient interface {
+	IntrospectToken(ctx context.Context, in *IntrospectTokenRequest, opts ...grpc.CallOption) (*IntrospectTokenResponse, error)
+}
+
+type tokenIntrospectionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTokenIntrospectionServiceClient(cc grpc.ClientConnInterface) TokenIntrospectionServiceClient {
+	return &tokenIntrospectionServiceClient{cc}
+}
+
+func (c *tokenIntrospectionServiceClient) IntrospectToken(ctx context.Context, in *IntrospectTokenRequest, opts ...grpc.CallOption) (*IntrospectTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IntrospectTokenResponse)
+	err := c.cc.Invoke(ctx, TokenIntrospectionService_IntrospectToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TokenIntrospectionServiceServer is the server API for TokenIntrospectionService service.
+// All implementations must embed UnimplementedTokenIntrospectionServiceServer
+// for forward compatibility.
+//
+// TokenIntrospectionService проверка токенов для сервисов, которые не могут проверить JWT сами.
+// Вызывать может только сервисный аккаунт или клиент OAuth, HTTP аналог - POST /introspect (RFC 7662)
+type TokenIntrospectionServiceServer interface {
+	IntrospectToken(context.Context, *IntrospectTokenRequest) (*IntrospectTokenResponse, error)
+	mustEmbedUnimplementedTokenIntrospectionServiceServer()
+}
+
+// UnimplementedTokenIntrospectionServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTokenIntrospectionServiceServer struct{}
+
+func (UnimplementedTokenIntrospectionServiceServer) IntrospectToken(context.Context, *IntrospectTokenRequest) (*IntrospectTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IntrospectToken not implemented")
+}
+func (UnimplementedTokenIntrospectionServiceServer) mustEmbedUnimplementedTokenIntrospectionServiceServer() {
+}
+func (UnimplementedTokenIntrospectionServiceServer) testEmbeddedByValue() {}
+
+// UnsafeTokenIntrospectionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TokenIntrospectionServiceServer will
+// result in compilation errors.
+type UnsafeTokenIntrospectionServiceServer interface {
+	mustEmbedUnimplementedTokenIntrospectionServiceServer()
+}
+
+func RegisterTokenIntrospectionServiceServer(s grpc.ServiceRegistrar, srv TokenIntrospectionServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTokenIntrospectionServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TokenIntrospectionService_ServiceDesc, srv)
+}
+
+func _TokenIntrospectionService_IntrospectToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IntrospectTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TokenIntrospectionServiceServer).IntrospectToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TokenIntrospectionService_IntrospectToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TokenIntrospectionServiceServer).IntrospectToken(ctx, req.(*IntrospectTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TokenIntrospectionService_ServiceDesc is the grpc.ServiceDesc for TokenIntrospectionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TokenIntrospectionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "auth.TokenIntrospectionService",
+	HandlerType: (*TokenIntrospectionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "IntrospectToken",
+			Handler:    _TokenIntrospectionService_IntrospectToken_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "auth/introspection.proto",
+}
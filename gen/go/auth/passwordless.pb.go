@@ -0,0 +1,280 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: auth/passwordless.proto
+
+package authapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StartPasswordlessLoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartPasswordlessLoginRequest) Reset() {
+	*x = StartPasswordlessLoginRequest{}
+	mi := &file_auth_passwordless_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartPasswordlessLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartPasswordlessLoginRequest) ProtoMessage() {}
+
+func (x *StartPasswordlessLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_passwordless_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartPasswordlessLoginRequest.ProtoReflect.Descriptor instead.
+func (*StartPasswordlessLoginRequest) Descriptor() ([]byte, []int) {
+	return file_auth_passwordless_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartPasswordlessLoginRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type StartPasswordlessLoginResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartPasswordlessLoginResponse) Reset() {
+	*x = StartPasswordlessLoginResponse{}
+	mi := &file_auth_passwordless_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartPasswordlessLoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartPasswordlessLoginResponse) ProtoMessage() {}
+
+func (x *StartPasswordlessLoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_passwordless_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartPasswordlessLoginResponse.ProtoReflect.Descriptor instead.
+func (*StartPasswordlessLoginResponse) Descriptor() ([]byte, []int) {
+	return file_auth_passwordless_proto_rawDescGZIP(), []int{1}
+}
+
+// CompletePasswordlessLoginRequest передается link из ссылки или email и code из письма
+type CompletePasswordlessLoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Link          string                 `protobuf:"bytes,1,opt,name=link,proto3" json:"link,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Code          string                 `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompletePasswordlessLoginRequest) Reset() {
+	*x = CompletePasswordlessLoginRequest{}
+	mi := &file_auth_passwordless_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompletePasswordlessLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompletePasswordlessLoginRequest) ProtoMessage() {}
+
+func (x *CompletePasswordlessLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_passwordless_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompletePasswordlessLoginRequest.ProtoReflect.Descriptor instead.
+func (*CompletePasswordlessLoginRequest) Descriptor() ([]byte, []int) {
+	return file_auth_passwordless_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CompletePasswordlessLoginRequest) GetLink() string {
+	if x != nil {
+		return x.Link
+	}
+	return ""
+}
+
+func (x *CompletePasswordlessLoginRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *CompletePasswordlessLoginRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type CompletePasswordlessLoginResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompletePasswordlessLoginResponse) Reset() {
+	*x = CompletePasswordlessLoginResponse{}
+	mi := &file_auth_passwordless_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompletePasswordlessLoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompletePasswordlessLoginResponse) ProtoMessage() {}
+
+func (x *CompletePasswordlessLoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_passwordless_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompletePasswordlessLoginResponse.ProtoReflect.Descriptor instead.
+func (*CompletePasswordlessLoginResponse) Descriptor() ([]byte, []int) {
+	return file_auth_passwordless_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CompletePasswordlessLoginResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+var File_auth_passwordless_proto protoreflect.FileDescriptor
+
+const file_auth_passwordless_proto_rawDesc = "" +
+	"\n" +
+	"\x17auth/passwordless.proto\x12\x04auth\"5\n" +
+	"\x1dStartPasswordlessLoginRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\" \n" +
+	"\x1eStartPasswordlessLoginResponse\"`\n" +
+	" CompletePasswordlessLoginRequest\x12\x12\n" +
+	"\x04link\x18\x01 \x01(\tR\x04link\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\"9\n" +
+	"!CompletePasswordlessLoginResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token2\xe8\x01\n" +
+	"\x13PasswordlessService\x12c\n" +
+	"\x16StartPasswordlessLogin\x12#.auth.StartPasswordlessLoginRequest\x1a$.auth.StartPasswordlessLoginResponse\x12l\n" +
+	"\x19CompletePasswordlessLogin\x12&.auth.CompletePasswordlessLoginRequest\x1a'.auth.CompletePasswordlessLoginResponseB9Z7github.com/LeoUraltsev/auth-service/gen/go/auth;authapib\x06proto3"
+
+var (
+	file_auth_passwordless_proto_rawDescOnce sync.Once
+	file_auth_passwordless_proto_rawDescData []byte
+)
+
+func file_auth_passwordless_proto_rawDescGZIP() []byte {
+	file_auth_passwordless_proto_rawDescOnce.Do(func() {
+		file_auth_passwordless_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_auth_passwordless_proto_rawDesc), len(file_auth_passwordless_proto_rawDesc)))
+	})
+	return file_auth_passwordless_proto_rawDescData
+}
+
+var file_auth_passwordless_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_auth_passwordless_proto_goTypes = []any{
+	(*StartPasswordlessLoginRequest)(nil),     // 0: auth.StartPasswordlessLoginRequest
+	(*StartPasswordlessLoginResponse)(nil),    // 1: auth.StartPasswordlessLoginResponse
+	(*CompletePasswordlessLoginRequest)(nil),  // 2: auth.CompletePasswordlessLoginRequest
+	(*CompletePasswordlessLoginResponse)(nil), // 3: auth.CompletePasswordlessLoginResponse
+}
+var file_auth_passwordless_proto_depIdxs = []int32{
+	0, // 0: auth.PasswordlessService.StartPasswordlessLogin:input_type -> auth.StartPasswordlessLoginRequest
+	2, // 1: auth.PasswordlessService.CompletePasswordlessLogin:input_type -> auth.CompletePasswordlessLoginRequest
+	1, // 2: auth.PasswordlessService.StartPasswordlessLogin:output_type -> auth.StartPasswordlessLoginResponse
+	3, // 3: auth.PasswordlessService.CompletePasswordlessLogin:output_type -> auth.CompletePasswordlessLoginResponse
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_auth_passwordless_proto_init() }
+func file_auth_passwordless_proto_init() {
+	if File_auth_passwordless_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_auth_passwordless_proto_rawDesc), len(file_auth_passwordless_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_auth_passwordless_proto_goTypes,
+		DependencyIndexes: file_auth_passwordless_proto_depIdxs,
+		MessageInfos:      file_auth_passwordless_proto_msgTypes,
+	}.Build()
+	File_auth_passwordless_proto = out.File
+	file_auth_passwordless_proto_goTypes = nil
+	file_auth_passwordless_proto_depIdxs = nil
+}
@@ -0,0 +1,191 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: auth/passwords.proto
+
+package authapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ChangeTemporaryPasswordRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Email             string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	TemporaryPassword string                 `protobuf:"bytes,2,opt,name=temporary_password,json=temporaryPassword,proto3" json:"temporary_password,omitempty"`
+	NewPassword       string                 `protobuf:"bytes,3,opt,name=new_password,json=newPassword,proto3" json:"new_password,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ChangeTemporaryPasswordRequest) Reset() {
+	*x = ChangeTemporaryPasswordRequest{}
+	mi := &file_auth_passwords_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeTemporaryPasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeTemporaryPasswordRequest) ProtoMessage() {}
+
+func (x *ChangeTemporaryPasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_passwords_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeTemporaryPasswordRequest.ProtoReflect.Descriptor instead.
+func (*ChangeTemporaryPasswordRequest) Descriptor() ([]byte, []int) {
+	return file_auth_passwords_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ChangeTemporaryPasswordRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *ChangeTemporaryPasswordRequest) GetTemporaryPassword() string {
+	if x != nil {
+		return x.TemporaryPassword
+	}
+	return ""
+}
+
+func (x *ChangeTemporaryPasswordRequest) GetNewPassword() string {
+	if x != nil {
+		return x.NewPassword
+	}
+	return ""
+}
+
+type ChangeTemporaryPasswordResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeTemporaryPasswordResponse) Reset() {
+	*x = ChangeTemporaryPasswordResponse{}
+	mi := &file_auth_passwords_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeTemporaryPasswordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeTemporaryPasswordResponse) ProtoMessage() {}
+
+func (x *ChangeTemporaryPasswordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_passwords_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeTemporaryPasswordResponse.ProtoReflect.Descriptor instead.
+func (*ChangeTemporaryPasswordResponse) Descriptor() ([]byte, []int) {
+	return file_auth_passwords_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ChangeTemporaryPasswordResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+var File_auth_passwords_proto protoreflect.FileDescriptor
+
+const file_auth_passwords_proto_rawDesc = "" +
+	"\n" +
+	"\x14auth/passwords.proto\x12\x04auth\"\x88\x01\n" +
+	"\x1eChangeTemporaryPasswordRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12-\n" +
+	"\x12temporary_password\x18\x02 \x01(\tR\x11temporaryPassword\x12!\n" +
+	"\fnew_password\x18\x03 \x01(\tR\vnewPassword\"7\n" +
+	"\x1fChangeTemporaryPasswordResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token2y\n" +
+	"\x0fPasswordService\x12f\n" +
+	"\x17ChangeTemporaryPassword\x12$.auth.ChangeTemporaryPasswordRequest\x1a%.auth.ChangeTemporaryPasswordResponseB9Z7github.com/LeoUraltsev/auth-service/gen/go/auth;authapib\x06proto3"
+
+var (
+	file_auth_passwords_proto_rawDescOnce sync.Once
+	file_auth_passwords_proto_rawDescData []byte
+)
+
+func file_auth_passwords_proto_rawDescGZIP() []byte {
+	file_auth_passwords_proto_rawDescOnce.Do(func() {
+		file_auth_passwords_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_auth_passwords_proto_rawDesc), len(file_auth_passwords_proto_rawDesc)))
+	})
+	return file_auth_passwords_proto_rawDescData
+}
+
+var file_auth_passwords_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_auth_passwords_proto_goTypes = []any{
+	(*ChangeTemporaryPasswordRequest)(nil),  // 0: auth.ChangeTemporaryPasswordRequest
+	(*ChangeTemporaryPasswordResponse)(nil), // 1: auth.ChangeTemporaryPasswordResponse
+}
+var file_auth_passwords_proto_depIdxs = []int32{
+	0, // 0: auth.PasswordService.ChangeTemporaryPassword:input_type -> auth.ChangeTemporaryPasswordRequest
+	1, // 1: auth.PasswordService.ChangeTemporaryPassword:output_type -> auth.ChangeTemporaryPasswordResponse
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_auth_passwords_proto_init() }
+func file_auth_passwords_proto_init() {
+	if File_auth_passwords_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_auth_passwords_proto_rawDesc), len(file_auth_passwords_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_auth_passwords_proto_goTypes,
+		DependencyIndexes: file_auth_passwords_proto_depIdxs,
+		MessageInfos:      file_auth_passwords_proto_msgTypes,
+	}.Build()
+	File_auth_passwords_proto = out.File
+	file_auth_passwords_proto_goTypes = nil
+	file_auth_passwords_proto_depIdxs = nil
+}
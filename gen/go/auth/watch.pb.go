@@ -0,0 +1,303 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: auth/watch.proto
+
+package authapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type UserChange_Type int32
+
+const (
+	UserChange_TYPE_UNSPECIFIED UserChange_Type = 0
+	UserChange_TYPE_CREATED     UserChange_Type = 1
+	UserChange_TYPE_UPDATED     UserChange_Type = 2
+	UserChange_TYPE_DELETED     UserChange_Type = 3
+)
+
+// Enum value maps for UserChange_Type.
+var (
+	UserChange_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_CREATED",
+		2: "TYPE_UPDATED",
+		3: "TYPE_DELETED",
+	}
+	UserChange_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_CREATED":     1,
+		"TYPE_UPDATED":     2,
+		"TYPE_DELETED":     3,
+	}
+)
+
+func (x UserChange_Type) Enum() *UserChange_Type {
+	p := new(UserChange_Type)
+	*p = x
+	return p
+}
+
+func (x UserChange_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (UserChange_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_auth_watch_proto_enumTypes[0].Descriptor()
+}
+
+func (UserChange_Type) Type() protoreflect.EnumType {
+	return &file_auth_watch_proto_enumTypes[0]
+}
+
+func (x UserChange_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use UserChange_Type.Descriptor instead.
+func (UserChange_Type) EnumDescriptor() ([]byte, []int) {
+	return file_auth_watch_proto_rawDescGZIP(), []int{1, 0}
+}
+
+type WatchUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromPosition  int64                  `protobuf:"varint,1,opt,name=from_position,json=fromPosition,proto3" json:"from_position,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchUsersRequest) Reset() {
+	*x = WatchUsersRequest{}
+	mi := &file_auth_watch_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchUsersRequest) ProtoMessage() {}
+
+func (x *WatchUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_watch_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchUsersRequest.ProtoReflect.Descriptor instead.
+func (*WatchUsersRequest) Descriptor() ([]byte, []int) {
+	return file_auth_watch_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WatchUsersRequest) GetFromPosition() int64 {
+	if x != nil {
+		return x.FromPosition
+	}
+	return 0
+}
+
+type UserChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Position      int64                  `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
+	Type          UserChange_Type        `protobuf:"varint,2,opt,name=type,proto3,enum=auth.UserChange_Type" json:"type,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,5,opt,name=email,proto3" json:"email,omitempty"`
+	Role          string                 `protobuf:"bytes,6,opt,name=role,proto3" json:"role,omitempty"`
+	IsActive      bool                   `protobuf:"varint,7,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserChange) Reset() {
+	*x = UserChange{}
+	mi := &file_auth_watch_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserChange) ProtoMessage() {}
+
+func (x *UserChange) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_watch_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserChange.ProtoReflect.Descriptor instead.
+func (*UserChange) Descriptor() ([]byte, []int) {
+	return file_auth_watch_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UserChange) GetPosition() int64 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *UserChange) GetType() UserChange_Type {
+	if x != nil {
+		return x.Type
+	}
+	return UserChange_TYPE_UNSPECIFIED
+}
+
+func (x *UserChange) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UserChange) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UserChange) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UserChange) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *UserChange) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *UserChange) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+var File_auth_watch_proto protoreflect.FileDescriptor
+
+const file_auth_watch_proto_rawDesc = "" +
+	"\n" +
+	"\x10auth/watch.proto\x12\x04auth\x1a\x1fgoogle/protobuf/timestamp.proto\"8\n" +
+	"\x11WatchUsersRequest\x12#\n" +
+	"\rfrom_position\x18\x01 \x01(\x03R\ffromPosition\"\xd8\x02\n" +
+	"\n" +
+	"UserChange\x12\x1a\n" +
+	"\bposition\x18\x01 \x01(\x03R\bposition\x12)\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x15.auth.UserChange.TypeR\x04type\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x05 \x01(\tR\x05email\x12\x12\n" +
+	"\x04role\x18\x06 \x01(\tR\x04role\x12\x1b\n" +
+	"\tis_active\x18\a \x01(\bR\bisActive\x12;\n" +
+	"\voccurred_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\"R\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fTYPE_CREATED\x10\x01\x12\x10\n" +
+	"\fTYPE_UPDATED\x10\x02\x12\x10\n" +
+	"\fTYPE_DELETED\x10\x032M\n" +
+	"\x10UserWatchService\x129\n" +
+	"\n" +
+	"WatchUsers\x12\x17.auth.WatchUsersRequest\x1a\x10.auth.UserChange0\x01B9Z7github.com/LeoUraltsev/auth-service/gen/go/auth;authapib\x06proto3"
+
+var (
+	file_auth_watch_proto_rawDescOnce sync.Once
+	file_auth_watch_proto_rawDescData []byte
+)
+
+func file_auth_watch_proto_rawDescGZIP() []byte {
+	file_auth_watch_proto_rawDescOnce.Do(func() {
+		file_auth_watch_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_auth_watch_proto_rawDesc), len(file_auth_watch_proto_rawDesc)))
+	})
+	return file_auth_watch_proto_rawDescData
+}
+
+var file_auth_watch_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_auth_watch_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_auth_watch_proto_goTypes = []any{
+	(UserChange_Type)(0),          // 0: auth.UserChange.Type
+	(*WatchUsersRequest)(nil),     // 1: auth.WatchUsersRequest
+	(*UserChange)(nil),            // 2: auth.UserChange
+	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
+}
+var file_auth_watch_proto_depIdxs = []int32{
+	0, // 0: auth.UserChange.type:type_name -> auth.UserChange.Type
+	3, // 1: auth.UserChange.occurred_at:type_name -> google.protobuf.Timestamp
+	1, // 2: auth.UserWatchService.WatchUsers:input_type -> auth.WatchUsersRequest
+	2, // 3: auth.UserWatchService.WatchUsers:output_type -> auth.UserChange
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_auth_watch_proto_init() }
+func file_auth_watch_proto_init() {
+	if File_auth_watch_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_auth_watch_proto_rawDesc), len(file_auth_watch_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_auth_watch_proto_goTypes,
+		DependencyIndexes: file_auth_watch_proto_depIdxs,
+		EnumInfos:         file_auth_watch_proto_enumTypes,
+		MessageInfos:      file_auth_watch_proto_msgTypes,
+	}.Build()
+	File_auth_watch_proto = out.File
+	file_auth_watch_proto_goTypes = nil
+	file_auth_watch_proto_depIdxs = nil
+}
@@ -2,19 +2,43 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"github.com/LeoUraltsev/auth-service/internal/app/grpc"
+	"github.com/LeoUraltsev/auth-service/internal/app/http"
 	"github.com/LeoUraltsev/auth-service/internal/app/postgres"
 	"github.com/LeoUraltsev/auth-service/internal/application"
 	"github.com/LeoUraltsev/auth-service/internal/config"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/gateway"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/passwordless"
+	"github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/auditsign"
 	"github.com/LeoUraltsev/auth-service/internal/infrastructure/hasher"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/httpapi"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/interceptors"
 	"github.com/LeoUraltsev/auth-service/internal/infrastructure/jwt"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/mail"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/oidc"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/policy"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/storage/pgnotify"
 	"github.com/LeoUraltsev/auth-service/internal/infrastructure/storage/pgtx"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/webhook"
 	"log/slog"
+	nethttp "net/http"
+	"net/url"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 )
 
+// providerTimeout таймаут запросов к внешним OIDC провайдерам
+const providerTimeout = 10 * time.Second
+
 type App struct {
 	log *slog.Logger
 	cfg *config.Config
@@ -42,13 +66,151 @@ func (a *App) Run() error {
 
 	//userStorage := pgUserStorage.NewUsersStorage(pg, log)
 	hash := hasher.NewHasher()
-	tg := jwt.NewToken(log, a.cfg)
+	signingKey, err := a.signingKey()
+	if err != nil {
+		log.Error("failed to load jwt signing key", slog.String("error", err.Error()))
+		pg.Close()
+		return err
+	}
+	tg := jwt.NewToken(log, a.cfg).WithSigningKey(signingKey)
 
 	uofUserStorage := pgtx.NewStorageUnitOfWork(pg, log)
 
-	userService := application.NewUserService(uofUserStorage, hash, hash, tg, log)
+	inviteOnly, err := a.inviteOnly()
+	if err != nil {
+		log.Error("failed to load registration mode", slog.String("error", err.Error()))
+		pg.Close()
+		return err
+	}
+	groupsClaim, err := a.groupsClaim()
+	if err != nil {
+		log.Error("failed to load groups claim", slog.String("error", err.Error()))
+		pg.Close()
+		return err
+	}
+	mailSender, err := mail.NewFileSender(a.cfg.Mail.Dir, a.cfg.Mail.From, log)
+	if err != nil {
+		log.Error("failed to init mail sender", slog.String("error", err.Error()))
+		pg.Close()
+		return err
+	}
+	emailChangeService := application.NewEmailChangeService(
+		uofUserStorage,
+		mailSender,
+		application.EmailChangeLinks{ConfirmURL: a.cfg.EmailChange.ConfirmURL, CancelURL: a.cfg.EmailChange.CancelURL},
+		a.cfg.EmailChange.TTL,
+		log,
+	)
+	userService := application.NewUserService(uofUserStorage, hash, hash, tg, log).
+		WithInviteOnly(inviteOnly).
+		WithGroupsClaim(groupsClaim).
+		WithEmailChange(emailChangeService)
+	passwordlessSettings, err := a.passwordless()
+	if err != nil {
+		log.Error("failed to load passwordless login", slog.String("error", err.Error()))
+		pg.Close()
+		return err
+	}
+	passwordlessService := application.NewPasswordlessService(uofUserStorage, mailSender, tg, passwordlessSettings, log).
+		WithGroupsClaim(groupsClaim)
+	invitationService := application.NewInvitationService(uofUserStorage, userService, a.cfg.Registration.InvitationTTL, log)
+	webhookService := application.NewWebhookService(uofUserStorage, log)
+
+	webhookDispatcher := application.NewWebhookDispatcher(
+		uofUserStorage,
+		webhook.NewHTTPSender(log, a.cfg.Webhooks.Timeout),
+		webhooks.RetryPolicy{
+			MaxAttempts: a.cfg.Webhooks.MaxAttempts,
+			BaseDelay:   a.cfg.Webhooks.BackoffBase,
+			MaxDelay:    a.cfg.Webhooks.BackoffMax,
+		},
+		a.cfg.Webhooks.PollInterval,
+		a.cfg.Webhooks.BatchSize,
+		a.cfg.Webhooks.Lease,
+		log,
+	)
+
+	changesListener := pgnotify.NewListener(a.cfg.Postgres.DSN, pgtx.ChangesChannel, log)
+	watchService := application.NewWatchService(
+		uofUserStorage,
+		changesListener,
+		a.cfg.Watch.PollInterval,
+		a.cfg.Watch.BatchSize,
+		log,
+	)
+
+	auditSigner, err := a.auditSigner()
+	if err != nil {
+		log.Error("failed to load audit checkpoint key", slog.String("error", err.Error()))
+		pg.Close()
+		return err
+	}
+	auditService := application.NewAuditService(uofUserStorage, auditSigner, log)
+
+	oauthService := application.NewOAuthService(
+		uofUserStorage,
+		hash,
+		tg,
+		a.cfg.OAuth.CodeTTL,
+		a.cfg.OAuth.RefreshTokenTTL,
+		log,
+	).WithGroupsClaim(groupsClaim)
+
+	federationService := application.NewFederationService(
+		uofUserStorage,
+		a.identityProviders(),
+		hash,
+		a.cfg.OAuth.CodeTTL,
+		a.cfg.Federation.LoginTTL,
+		log,
+	)
+
+	serviceAccountService := application.NewServiceAccountService(uofUserStorage, log)
+	organizationService := application.NewOrganizationService(uofUserStorage, log)
+	apiKeyService := application.NewAPIKeyService(uofUserStorage, log)
+	introspectionService := application.NewIntrospectionService(uofUserStorage, tg, log)
+
+	extAuthzRules, err := routeRules(a.cfg.ExtAuthz.Rules)
+	if err != nil {
+		log.Error("failed to load ext_authz rules", slog.String("error", err.Error()))
+		pg.Close()
+		return err
+	}
+	forwardAuthHosts, err := a.forwardAuthHosts()
+	if err != nil {
+		log.Error("failed to load forward_auth hosts", slog.String("error", err.Error()))
+		pg.Close()
+		return err
+	}
+	schema, err := relationsSchema(a.cfg.Relations)
+	if err != nil {
+		log.Error("failed to load relations schema", slog.String("error", err.Error()))
+		pg.Close()
+		return err
+	}
+	relationshipService := application.NewRelationshipService(uofUserStorage, schema, log)
+	groupService := application.NewGroupService(uofUserStorage, log)
+	userAdminService := application.NewUserAdminService(uofUserStorage, hash, log)
+	dataExportService := application.NewDataExportService(uofUserStorage, a.cfg.Exports.SyncMaxEvents, a.cfg.Exports.ArchiveTTL, log)
+	sessionCookie := httpapi.SessionCookie{
+		Name:   a.cfg.ForwardAuth.CookieName,
+		Domain: a.cfg.ForwardAuth.CookieDomain,
+	}
+
+	var policyEvaluator interceptors.PolicyEvaluator
+	var policyEngine *policy.Engine
+	if a.cfg.Policy.Path != "" {
+		policyEngine, err = policy.NewEngine(a.cfg.Policy.Path, a.cfg.Policy.ReloadInterval, log)
+		if err != nil {
+			log.Error("failed to load policies", slog.String("error", err.Error()))
+			pg.Close()
+			return err
+		}
+		policyEvaluator = policyEngine
+	}
 
-	rpc := grpc.NewApp(userService, log, tg, a.cfg.GRPC.Address)
+	rpc := grpc.NewApp(userService, webhookService, watchService, auditService, oauthService, federationService, serviceAccountService, apiKeyService, introspectionService, organizationService, invitationService, relationshipService, groupService, userAdminService, dataExportService, emailChangeService, passwordlessService, a.cfg.ExtAuthz.Enabled, extAuthzRules, log, tg, policyEvaluator, a.cfg.GRPC.Address)
+	httpServer := http.NewApp(oauthService, federationService, introspectionService, tg, tg, forwardAuthHosts, sessionCookie, a.cfg.OAuth.Issuer, log, a.cfg.HTTP.Address)
 
 	chErrRpc := make(chan error)
 	go func() {
@@ -59,16 +221,86 @@ func (a *App) Run() error {
 		}
 	}()
 
+	chErrHTTP := make(chan error)
+	go func() {
+		defer close(chErrHTTP)
+		if err := httpServer.Start(); err != nil {
+			chErrHTTP <- err
+		}
+	}()
+
 	wg := &sync.WaitGroup{}
 
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+	workers := &sync.WaitGroup{}
+	dataExporter := application.NewDataExporter(
+		uofUserStorage,
+		a.cfg.Exports.ArchiveTTL,
+		a.cfg.Exports.PollInterval,
+		a.cfg.Exports.BatchSize,
+		log,
+	)
+	workers.Add(3)
+	go func() {
+		defer workers.Done()
+		webhookDispatcher.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		changesListener.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		dataExporter.Run(workersCtx)
+	}()
+	if policyEngine != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			policyEngine.Run(workersCtx)
+		}()
+	}
+	if auditSigner != nil {
+		auditCheckpointer := application.NewAuditCheckpointer(uofUserStorage, auditSigner, a.cfg.Audit.CheckpointInterval, log)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			auditCheckpointer.Run(workersCtx)
+		}()
+	} else {
+		log.Warn("audit checkpoint key is not set, checkpoints are disabled")
+	}
+	if a.cfg.Retention.DeletedUsers > 0 {
+		userRetention := application.NewUserRetention(
+			uofUserStorage,
+			a.cfg.Retention.DeletedUsers,
+			a.cfg.Retention.Interval,
+			a.cfg.Retention.BatchSize,
+			log,
+		)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			userRetention.Run(workersCtx)
+		}()
+	} else {
+		log.Warn("retention period is not set, deleted users are kept forever")
+	}
+
 	select {
 	case <-ctx.Done():
 		log.Info("shutting down app")
-		wg.Add(2)
+		workers.Wait()
+		wg.Add(3)
 		go func() {
 			defer wg.Done()
 			rpc.Stop()
 		}()
+		go func() {
+			defer wg.Done()
+			httpServer.Stop()
+		}()
 		go func() {
 			defer wg.Done()
 			pg.Close()
@@ -76,11 +308,179 @@ func (a *App) Run() error {
 		wg.Wait()
 		log.Info("app stopped")
 	case err := <-chErrRpc:
+		stopWorkers()
+		workers.Wait()
+		httpServer.Stop()
 		if err != nil {
 			pg.Close()
 			return err
 		}
+	case err := <-chErrHTTP:
+		if err != nil {
+			log.Error("http server failed", slog.String("error", err.Error()))
+		}
+		stopWorkers()
+		workers.Wait()
+		rpc.Stop()
+		pg.Close()
+		return err
 	}
 
 	return nil
 }
+
+// VerifyAudit проверяет цепочку аудита без запуска сервера
+func (a *App) VerifyAudit(ctx context.Context) (audit.VerifyResult, error) {
+	pg, err := postgres.NewPostgresPool(ctx, a.log, a.cfg.Postgres.DSN)
+	if err != nil {
+		return audit.VerifyResult{}, err
+	}
+	defer pg.Close()
+
+	signer, err := a.auditSigner()
+	if err != nil {
+		return audit.VerifyResult{}, err
+	}
+	if signer == nil {
+		a.log.Warn("audit checkpoint key is not set, checkpoint signatures are not verified")
+	}
+
+	auditService := application.NewAuditService(pgtx.NewStorageUnitOfWork(pg, a.log), signer, a.log)
+	return auditService.VerifyChain(ctx)
+}
+
+// identityProviders провайдеры из federation.providers, callback живет на HTTP сервере
+func (a *App) identityProviders() []identity.Provider {
+	client := &nethttp.Client{Timeout: providerTimeout}
+	issuer := strings.TrimSuffix(a.cfg.OAuth.Issuer, "/")
+	providers := make([]identity.Provider, 0, len(a.cfg.Federation.Providers))
+	for _, cfg := range a.cfg.Federation.Providers {
+		redirectURL := issuer + "/federated/" + url.PathEscape(cfg.Name) + "/callback"
+		providers = append(providers, oidc.NewProvider(cfg, redirectURL, client, a.log))
+	}
+	return providers
+}
+
+// forwardAuthHosts правила сайтов за nginx и Traefik
+func (a *App) forwardAuthHosts() (gateway.Hosts, error) {
+	fallback, err := routeRules(a.cfg.ForwardAuth.Rules)
+	if err != nil {
+		return gateway.Hosts{}, err
+	}
+	hosts := make([]gateway.Host, 0, len(a.cfg.ForwardAuth.Hosts))
+	for _, cfg := range a.cfg.ForwardAuth.Hosts {
+		rules, err := routeRules(cfg.Rules)
+		if err != nil {
+			return gateway.Hosts{}, fmt.Errorf("host %q: %w", cfg.Host, err)
+		}
+		host, err := gateway.NewHost(cfg.Host, cfg.LoginRedirect, rules)
+		if err != nil {
+			return gateway.Hosts{}, fmt.Errorf("host %q: %w", cfg.Host, err)
+		}
+		hosts = append(hosts, host)
+	}
+	return gateway.NewHosts(hosts, fallback), nil
+}
+
+func routeRules(cfgs []config.RouteRuleConfig) (gateway.Rules, error) {
+	rules := make(gateway.Rules, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		rule, err := gateway.NewRule(cfg.PathPrefix, cfg.Methods, cfg.Public, cfg.Roles)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", cfg.PathPrefix, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func relationsSchema(cfg config.RelationsConfig) (*relations.Schema, error) {
+	namespaces := make([]relations.Namespace, 0, len(cfg.Namespaces))
+	for _, ns := range cfg.Namespaces {
+		rels := make([]relations.Relation, 0, len(ns.Relations))
+		for _, r := range ns.Relations {
+			inherit := make([]relations.Inherit, 0, len(r.Inherit))
+			for _, i := range r.Inherit {
+				inherit = append(inherit, relations.Inherit{Tupleset: i.Tupleset, Relation: i.Relation})
+			}
+			rels = append(rels, relations.Relation{Name: r.Name, Computed: r.Computed, Inherit: inherit})
+		}
+		namespaces = append(namespaces, relations.Namespace{Name: ns.Name, Relations: rels})
+	}
+	return relations.NewSchema(namespaces)
+}
+
+// signingKey ключ ID токенов, без jwt.signing_key_path генерируется временный
+func (a *App) signingKey() (*jwt.SigningKey, error) {
+	switch a.cfg.JWT.AccessTokenAlg {
+	case jwt.AlgHS256:
+	case jwt.AlgRS256:
+		if a.cfg.JWT.SigningKeyPath == "" {
+			// токены RS256 проверяют другие сервисы, временный ключ сменится при перезапуске
+			a.log.Warn("access tokens are signed with ephemeral key, set jwt.signing_key_path")
+		}
+	default:
+		return nil, fmt.Errorf("jwt access token alg %q is not supported", a.cfg.JWT.AccessTokenAlg)
+	}
+	if a.cfg.JWT.SigningKeyPath == "" {
+		a.log.Warn("jwt signing key is not set, using ephemeral key for id tokens")
+		return jwt.GenerateSigningKey()
+	}
+	return jwt.LoadSigningKey(a.cfg.JWT.SigningKeyPath)
+}
+
+func (a *App) inviteOnly() (bool, error) {
+	switch a.cfg.Registration.Mode {
+	case config.RegistrationOpen:
+		return false, nil
+	case config.RegistrationInviteOnly:
+		return true, nil
+	default:
+		return false, fmt.Errorf("registration mode %q is not supported", a.cfg.Registration.Mode)
+	}
+}
+
+// groupsClaim включен ли claim групп в токенах пользователей
+func (a *App) groupsClaim() (bool, error) {
+	if a.cfg.Groups.ClaimName == "" {
+		return false, nil
+	}
+	if err := jwt.ValidateGroupsClaim(a.cfg.Groups.ClaimName); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// passwordless slug организаций приводятся к нижнему регистру, как в заголовке x-organization
+func (a *App) passwordless() (application.PasswordlessSettings, error) {
+	cfg := a.cfg.Passwordless
+	if cfg.TTL <= 0 {
+		return application.PasswordlessSettings{}, passwordless.ErrTTLInvalid
+	}
+	if cfg.MaxAttempts <= 0 {
+		return application.PasswordlessSettings{}, passwordless.ErrAttemptsInvalid
+	}
+	orgs := make([]string, 0, len(cfg.Organizations))
+	for _, org := range cfg.Organizations {
+		slug, err := organizations.NewSlug(org)
+		if err != nil {
+			return application.PasswordlessSettings{}, fmt.Errorf("passwordless organization %q: %w", org, err)
+		}
+		orgs = append(orgs, slug)
+	}
+	return application.PasswordlessSettings{
+		Enabled:       cfg.Enabled,
+		Organizations: orgs,
+		LinkURL:       cfg.LinkURL,
+		TTL:           cfg.TTL,
+		MaxAttempts:   cfg.MaxAttempts,
+	}, nil
+}
+
+// auditSigner nil, если ключ не задан
+func (a *App) auditSigner() (audit.Signer, error) {
+	if a.cfg.Audit.CheckpointKey == "" {
+		return nil, nil
+	}
+	return auditsign.NewEd25519Signer(a.cfg.Audit.CheckpointKey)
+}
@@ -2,13 +2,17 @@ package grpc
 
 import (
 	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/gateway"
 	userGrpc "github.com/LeoUraltsev/auth-service/internal/infrastructure/grpc"
 	"github.com/LeoUraltsev/auth-service/internal/infrastructure/interceptors"
 	"google.golang.org/grpc"
 	"log/slog"
 	"net"
+	"time"
 )
 
+const gracefulStopTimeout = 10 * time.Second
+
 type App struct {
 	log           *slog.Logger
 	gRPC          *grpc.Server
@@ -16,15 +20,61 @@ type App struct {
 	address       string
 }
 
-func NewApp(service application.UserService, log *slog.Logger, tokenVerifier interceptors.TokenVerifier, address string) *App {
+func NewApp(
+	service application.UserService,
+	webhookService application.WebhookService,
+	watchService application.WatchService,
+	auditService application.AuditService,
+	oauthService application.OAuthService,
+	federationService application.FederationService,
+	serviceAccountService application.ServiceAccountService,
+	apiKeyService application.APIKeyService,
+	introspectionService application.IntrospectionService,
+	organizationService application.OrganizationService,
+	invitationService application.InvitationService,
+	relationshipService application.RelationshipService,
+	groupService application.GroupService,
+	userAdminService application.UserAdminService,
+	dataExportService application.DataExportService,
+	emailChangeService application.EmailChangeService,
+	passwordlessService application.PasswordlessService,
+	extAuthzEnabled bool,
+	extAuthzRules gateway.Rules,
+	log *slog.Logger,
+	tokenVerifier interceptors.TokenVerifier,
+	policyEvaluator interceptors.PolicyEvaluator,
+	address string,
+) *App {
 
-	i := interceptors.New(log, tokenVerifier)
+	i := interceptors.New(log, tokenVerifier, apiKeyService, introspectionService).WithPolicy(policyEvaluator)
 
 	gRPC := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(i.RequestID, i.Auth),
+		grpc.ChainUnaryInterceptor(i.RequestID, i.Auth, i.Policy),
+		grpc.ChainStreamInterceptor(i.RequestIDStream, i.AuthStream, i.PolicyStream),
 	)
 
 	userGrpc.Register(gRPC, service, log)
+	userGrpc.RegisterWebhooks(gRPC, webhookService, log)
+	userGrpc.RegisterWatch(gRPC, watchService, log)
+	userGrpc.RegisterAudit(gRPC, auditService, log)
+	userGrpc.RegisterOAuthClients(gRPC, oauthService, log)
+	userGrpc.RegisterOIDC(gRPC, oauthService, log)
+	userGrpc.RegisterIdentities(gRPC, federationService, log)
+	userGrpc.RegisterServiceAccounts(gRPC, serviceAccountService, log)
+	userGrpc.RegisterAPIKeys(gRPC, apiKeyService, log)
+	userGrpc.RegisterIntrospection(gRPC, introspectionService, log)
+	userGrpc.RegisterOrganizations(gRPC, organizationService, service, log)
+	userGrpc.RegisterInvitations(gRPC, invitationService, log)
+	userGrpc.RegisterRelationships(gRPC, relationshipService, log)
+	userGrpc.RegisterGroups(gRPC, groupService, log)
+	userGrpc.RegisterUserAdmin(gRPC, userAdminService, log)
+	userGrpc.RegisterPasswords(gRPC, service, log)
+	userGrpc.RegisterDataExports(gRPC, dataExportService, log)
+	userGrpc.RegisterEmailChange(gRPC, emailChangeService, log)
+	userGrpc.RegisterPasswordless(gRPC, passwordlessService, log)
+	if extAuthzEnabled {
+		userGrpc.RegisterExtAuthz(gRPC, tokenVerifier, introspectionService, extAuthzRules, log)
+	}
 	return &App{
 		log:           log,
 		gRPC:          gRPC,
@@ -47,8 +97,20 @@ func (a *App) Start() error {
 	return nil
 }
 
+// Stop ждет завершения текущих вызовов, долгие потоки (WatchUsers) обрываются по таймауту
 func (a *App) Stop() {
 	a.log.Info("shutting down grpc server")
-	a.gRPC.GracefulStop()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		a.gRPC.GracefulStop()
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(gracefulStopTimeout):
+		a.log.Warn("graceful stop timed out, closing remaining streams")
+		a.gRPC.Stop()
+		<-stopped
+	}
 	a.log.Info("grpc server stopped")
 }
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/gateway"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/httpapi"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/interceptors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+type App struct {
+	log    *slog.Logger
+	server *http.Server
+}
+
+func NewApp(
+	oauthService application.OAuthService,
+	federationService application.FederationService,
+	introspectionService application.IntrospectionService,
+	tokenVerifier interceptors.TokenVerifier,
+	keys httpapi.KeySet,
+	forwardAuthHosts gateway.Hosts,
+	sessionCookie httpapi.SessionCookie,
+	issuer string,
+	log *slog.Logger,
+	address string,
+) *App {
+	mux := http.NewServeMux()
+	httpapi.RegisterOAuth(mux, oauthService, federationService, log)
+	httpapi.RegisterOIDC(mux, oauthService, tokenVerifier, keys, issuer, log)
+	httpapi.RegisterIntrospection(mux, introspectionService, log)
+	httpapi.RegisterForwardAuth(mux, oauthService, tokenVerifier, introspectionService, forwardAuthHosts, sessionCookie, issuer, log)
+
+	return &App{
+		log: log,
+		server: &http.Server{
+			Addr:              address,
+			Handler:           httpapi.RequestContext(log, mux),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+func (a *App) Start() error {
+	a.log.Info("http server listening on ", slog.String("addr", a.server.Addr))
+	err := a.server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (a *App) Stop() {
+	a.log.Info("shutting down http server")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := a.server.Shutdown(ctx); err != nil {
+		a.log.Warn("http server shutdown timed out", slog.String("error", err.Error()))
+		_ = a.server.Close()
+	}
+	a.log.Info("http server stopped")
+}
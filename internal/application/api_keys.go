@@ -0,0 +1,204 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+// APIKeyService личные ключи пользователя для скриптов и CLI, каждый управляет только своими ключами
+type APIKeyService interface {
+	// CreateAPIKey возвращает ключ и его значение, значение показывается один раз.
+	// Ключ не может получить scope шире, чем у учетных данных, которыми он создается
+	CreateAPIKey(ctx context.Context, name string, scope []string, expiresAt *time.Time) (*apikeys.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]*apikeys.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	// AuthenticateAPIKey проверяет ключ из заголовка ApiKey и отмечает время использования
+	AuthenticateAPIKey(ctx context.Context, key string) (apikeys.Credentials, error)
+}
+
+type APIKeyServiceHandler struct {
+	uof UnitOfWork
+	log *slog.Logger
+}
+
+func NewAPIKeyService(uof UnitOfWork, log *slog.Logger) *APIKeyServiceHandler {
+	return &APIKeyServiceHandler{
+		uof: uof,
+		log: log,
+	}
+}
+
+func (s *APIKeyServiceHandler) CreateAPIKey(ctx context.Context, name string, scope []string, expiresAt *time.Time) (*apikeys.APIKey, string, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("creating api key")
+
+	userID, err := keyOwnerFromContext(ctx)
+	if err != nil {
+		log.Warn("failed to create api key", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+
+	requested := oauth.ParseScope(oauth.Scope(scope).String())
+	if current := scopeFromContext(ctx); len(current) > 0 {
+		if len(requested) == 0 {
+			requested = current
+		}
+		if !current.Contains(requested) {
+			log.Warn("failed to create api key", slog.String("error", oauth.ErrInsufficientScope.Error()))
+			return nil, "", oauth.ErrInsufficientScope
+		}
+	}
+
+	key, value, err := apikeys.CreateAPIKey(userID, name, requested, expiresAt)
+	if err != nil {
+		log.Warn("failed to create api key", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+
+	err = s.uof.Execute(ctx, func(store Store) error {
+		if err := store.APIKeys().Save(ctx, key); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, apiKeyAudit(audit.ActionAPIKeyCreated, key.ID()), nil)
+	})
+	if err != nil {
+		log.Warn("failed to create api key", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+
+	log.Info("api key created", slog.String("api_key_id", key.ID().String()))
+	return key, value, nil
+}
+
+func (s *APIKeyServiceHandler) ListAPIKeys(ctx context.Context) ([]*apikeys.APIKey, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting api keys")
+
+	userID, err := keyOwnerFromContext(ctx)
+	if err != nil {
+		log.Warn("failed to get api keys", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var keys []*apikeys.APIKey
+	err = s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		keys, err = store.APIKeys().ListByUser(ctx, userID)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get api keys", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *APIKeyServiceHandler) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("api_key_id", id.String()))
+	log.Info("revoking api key")
+
+	userID, err := keyOwnerFromContext(ctx)
+	if err != nil {
+		log.Warn("failed to revoke api key", slog.String("error", err.Error()))
+		return err
+	}
+
+	entry := apiKeyAudit(audit.ActionAPIKeyRevoked, id)
+	err = s.uof.Execute(ctx, func(store Store) error {
+		key, err := store.APIKeys().Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		// чужой ключ не отличается от несуществующего
+		if key.UserID() != userID {
+			return apikeys.ErrNotFound
+		}
+		if err = key.Revoke(time.Now().UTC()); err != nil {
+			return err
+		}
+		if err = store.APIKeys().Save(ctx, key); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to revoke api key", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	log.Info("api key revoked")
+	return nil
+}
+
+func (s *APIKeyServiceHandler) AuthenticateAPIKey(ctx context.Context, key string) (apikeys.Credentials, error) {
+	log := logger.LogWithContext(ctx, s.log)
+
+	prefix, err := apikeys.ParsePrefix(key)
+	if err != nil {
+		log.Warn("failed to authenticate api key", slog.String("error", err.Error()))
+		return apikeys.Credentials{}, err
+	}
+	log = log.With(slog.String("api_key_prefix", prefix))
+
+	var creds apikeys.Credentials
+	err = s.uof.Execute(ctx, func(store Store) error {
+		k, err := store.APIKeys().GetByPrefix(ctx, prefix)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if err = k.Verify(key, now); err != nil {
+			return err
+		}
+
+		usr, err := store.Users().Get(ctx, users.AnyTenant, k.UserID())
+		if err != nil {
+			return err
+		}
+		if usr.CanAuthenticate(time.Now().UTC()) != nil {
+			return oauth.ErrUserNotActive
+		}
+
+		creds = apikeys.Credentials{
+			KeyID:    k.ID(),
+			UserID:   usr.ID(),
+			TenantID: usr.TenantID(),
+			Role:     usr.Role(),
+			Scope:    k.Scope(),
+		}
+		if !k.Touch(now) {
+			return nil
+		}
+		return store.APIKeys().Save(ctx, k)
+	})
+	if err != nil {
+		log.Warn("failed to authenticate api key", slog.String("error", err.Error()))
+		return apikeys.Credentials{}, err
+	}
+	return creds, nil
+}
+
+// keyOwnerFromContext ключи выпускаются только для пользователей, не для сервисных аккаунтов
+func keyOwnerFromContext(ctx context.Context) (uuid.UUID, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil || principalFromContext(ctx) != users.PrincipalUser {
+		return uuid.Nil, ErrPermissionDenied
+	}
+	return userID, nil
+}
+
+func apiKeyAudit(action audit.Action, id uuid.UUID) auditEntry {
+	return auditEntry{
+		action:     action,
+		targetType: audit.TargetAPIKey,
+		targetID:   id.String(),
+	}
+}
@@ -0,0 +1,133 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	mockapikeys "github.com/LeoUraltsev/auth-service/internal/domain/apikeys/mocks"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/helper/ctxkeys"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyServiceHandler_CreateAPIKey(t *testing.T) {
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	user, _ := users.CreateUser(organizations.DefaultID, "name", email, pass)
+
+	cases := []struct {
+		name      string
+		principal string
+		ctxScope  string
+		scope     []string
+		want      oauth.Scope
+		wantErr   error
+	}{
+		{name: "unrestricted", scope: []string{"users.read"}, want: oauth.Scope{"users.read"}},
+		{name: "inherits scope", ctxScope: "users.read", want: oauth.Scope{"users.read"}},
+		{name: "wider scope", ctxScope: "users.read", scope: []string{"users.write"}, wantErr: oauth.ErrInsufficientScope},
+		{name: "service account", principal: "service_account", wantErr: ErrPermissionDenied},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockapikeys.NewMockRepository(ctrl)
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			if tt.wantErr == nil {
+				repository.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+				auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+			}
+			service := NewAPIKeyService(testUnitOfWork{store: testStore{apiKeys: repository, audit: auditRepository}}, log)
+
+			ctx := authverify.NewContext(context.Background(), &authverify.Principal{UserID: user.ID(), Scope: oauth.ParseScope(tt.ctxScope), Type: tt.principal})
+			key, value, err := service.CreateAPIKey(ctx, "ci", tt.scope, nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, key.Scope())
+			assert.NoError(t, key.Verify(value, time.Now().UTC()))
+		})
+	}
+}
+
+func TestAPIKeyServiceHandler_AuthenticateAPIKey(t *testing.T) {
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	user, _ := users.CreateUser(organizations.DefaultID, "name", email, pass)
+
+	key, value, err := apikeys.CreateAPIKey(user.ID(), "ci", oauth.Scope{"users.read"}, nil)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	repository := mockapikeys.NewMockRepository(ctrl)
+	repository.EXPECT().GetByPrefix(gomock.Any(), key.Prefix()).Return(key, nil).Times(3)
+	// время использования сохраняется только при первом входе
+	repository.EXPECT().Save(gomock.Any(), key).Return(nil).Times(1)
+	userRepository := mockusers.NewMockUserRepository(ctrl)
+	userRepository.EXPECT().Get(gomock.Any(), users.AnyTenant, user.ID()).Return(user, nil).Times(2)
+
+	service := NewAPIKeyService(testUnitOfWork{store: testStore{apiKeys: repository, users: userRepository}}, log)
+
+	for range 2 {
+		creds, err := service.AuthenticateAPIKey(context.Background(), value)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID(), creds.UserID)
+		assert.Equal(t, users.RoleUser, creds.Role)
+		assert.Equal(t, oauth.Scope{"users.read"}, creds.Scope)
+	}
+	assert.NotNil(t, key.LastUsedAt())
+
+	_, err = service.AuthenticateAPIKey(context.Background(), value[:len(value)-1])
+	assert.ErrorIs(t, err, apikeys.ErrKeyNotValid)
+}
+
+// TestAPIKeyServiceHandler_AuthenticateAPIKey_tenantAdmin ключ администратора организации действует только в ней,
+// заголовок x-organization его не переносит в другую организацию и не делает администратором платформы
+func TestAPIKeyServiceHandler_AuthenticateAPIKey_tenantAdmin(t *testing.T) {
+	email, _ := users.NewEmail("admin@acme.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	tenantID := uuid.New()
+	admin, _ := users.NewUser(uuid.New(), tenantID, "admin", email, pass, users.RoleAdmin, users.StatusActive, time.Now(), time.Now())
+	key, value, err := apikeys.CreateAPIKey(admin.ID(), "ci", nil, nil)
+	require.NoError(t, err)
+	foreignID := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	repository := mockapikeys.NewMockRepository(ctrl)
+	repository.EXPECT().GetByPrefix(gomock.Any(), key.Prefix()).Return(key, nil)
+	repository.EXPECT().Save(gomock.Any(), key).Return(nil)
+	userRepository := mockusers.NewMockUserRepository(ctrl)
+	userRepository.EXPECT().Get(gomock.Any(), users.AnyTenant, admin.ID()).Return(admin, nil)
+	// пользователь другой организации ищется в организации ключа
+	userRepository.EXPECT().Get(gomock.Any(), tenantID, foreignID).Return(nil, users.ErrUserNotFound)
+	uof := testUnitOfWork{store: testStore{apiKeys: repository, users: userRepository}}
+
+	creds, err := NewAPIKeyService(uof, log).AuthenticateAPIKey(context.Background(), value)
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, creds.TenantID)
+
+	ctx := context.WithValue(context.Background(), ctxkeys.Organization, organizations.DefaultID.String())
+	ctx = authverify.NewContext(ctx, &authverify.Principal{
+		UserID:   creds.UserID,
+		TenantID: creds.TenantID,
+		Role:     creds.Role.String(),
+		Type:     users.PrincipalUser.String(),
+	})
+
+	_, err = NewUserService(uof, nil, nil, nil, log).GetUser(ctx, foreignID)
+	assert.ErrorIs(t, err, users.ErrUserNotFound)
+	_, err = NewWebhookService(uof, log).ListWebhooks(ctx)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
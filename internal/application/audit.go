@@ -0,0 +1,193 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+// auditVerifyBatchSize сколько записей читается за одну транзакцию при проверке цепочки
+const auditVerifyBatchSize = 1000
+
+type AuditService interface {
+	ListAuditEvents(ctx context.Context, filter audit.Filter) ([]*audit.Event, error)
+	VerifyAuditChain(ctx context.Context) (audit.VerifyResult, error)
+}
+
+type AuditServiceHandler struct {
+	uof    UnitOfWork
+	signer audit.Signer
+	log    *slog.Logger
+}
+
+// NewAuditService signer может быть nil, тогда подписи контрольных точек не проверяются
+func NewAuditService(uof UnitOfWork, signer audit.Signer, log *slog.Logger) *AuditServiceHandler {
+	return &AuditServiceHandler{
+		uof:    uof,
+		signer: signer,
+		log:    log,
+	}
+}
+
+func (s *AuditServiceHandler) ListAuditEvents(ctx context.Context, filter audit.Filter) ([]*audit.Event, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting audit events")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to get audit events", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if filter.Limit <= 0 || filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	var events []*audit.Event
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		events, err = store.Audit().List(ctx, filter)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get audit events", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (s *AuditServiceHandler) VerifyAuditChain(ctx context.Context) (audit.VerifyResult, error) {
+	log := logger.LogWithContext(ctx, s.log)
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to verify audit chain", slog.String("error", err.Error()))
+		return audit.VerifyResult{}, err
+	}
+
+	return s.VerifyChain(ctx)
+}
+
+// VerifyChain проходит цепочку целиком и возвращает первый разрыв.
+// Права не проверяются, метод вызывается и из командной строки
+func (s *AuditServiceHandler) VerifyChain(ctx context.Context) (audit.VerifyResult, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("verifying audit chain")
+
+	var checkpoints []*audit.Checkpoint
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		checkpoints, err = store.Audit().ListCheckpoints(ctx)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get audit checkpoints", slog.String("error", err.Error()))
+		return audit.VerifyResult{}, err
+	}
+
+	verifier := audit.NewChainVerifier(checkpoints, s.signer)
+	var afterSeq int64
+	for {
+		var batch []*audit.Event
+		err = s.uof.Execute(ctx, func(store Store) error {
+			var err error
+			batch, err = store.Audit().ListChain(ctx, afterSeq, auditVerifyBatchSize)
+			return err
+		})
+		if err != nil {
+			log.Warn("failed to get audit chain", slog.String("error", err.Error()))
+			return audit.VerifyResult{}, err
+		}
+
+		for _, event := range batch {
+			if !verifier.Next(event) {
+				return s.verified(log, verifier.Finish()), nil
+			}
+			afterSeq = event.Seq()
+		}
+		if len(batch) < auditVerifyBatchSize {
+			return s.verified(log, verifier.Finish()), nil
+		}
+	}
+}
+
+func (s *AuditServiceHandler) verified(log *slog.Logger, res audit.VerifyResult) audit.VerifyResult {
+	if res.Valid() {
+		log.Info("audit chain is valid",
+			slog.Int64("events", res.CheckedEvents),
+			slog.Int64("checkpoints", res.CheckedCheckpoints),
+		)
+		return res
+	}
+	log.Error("audit chain is broken",
+		slog.Int64("seq", res.Break.Seq),
+		slog.String("reason", res.Break.Reason),
+	)
+	return res
+}
+
+type auditEntry struct {
+	action     audit.Action
+	targetType audit.TargetType
+	targetID   string
+	// actorID если не задан, берется пользователь из контекста
+	actorID uuid.UUID
+}
+
+func userAudit(action audit.Action, userID uuid.UUID) auditEntry {
+	e := auditEntry{
+		action:     action,
+		targetType: audit.TargetUser,
+	}
+	if userID != uuid.Nil {
+		e.targetID = userID.String()
+	}
+	return e
+}
+
+// recordAudit пишет событие в той же транзакции, что и само действие
+func recordAudit(ctx context.Context, store Store, entry auditEntry, opErr error) error {
+	actorID := entry.actorID
+	if ctxUserID, err := userIDFromContext(ctx); err == nil {
+		actorID = ctxUserID
+	}
+
+	outcome := audit.OutcomeSuccess
+	var reason string
+	if opErr != nil {
+		outcome = audit.OutcomeFailure
+		reason = opErr.Error()
+	}
+
+	event, err := audit.CreateEvent(
+		entry.action,
+		actorID,
+		entry.targetType,
+		entry.targetID,
+		requestIDFromContext(ctx),
+		peerIPFromContext(ctx),
+		outcome,
+		reason,
+	)
+	if err != nil {
+		return err
+	}
+	return store.Audit().Append(ctx, event)
+}
+
+// recordAuditFailure транзакция неудачного действия откатывается, поэтому отказ пишется отдельной транзакцией
+func recordAuditFailure(ctx context.Context, uof UnitOfWork, log *slog.Logger, entry auditEntry, opErr error) {
+	err := uof.Execute(ctx, func(store Store) error {
+		return recordAudit(ctx, store, entry, opErr)
+	})
+	if err != nil {
+		log.Error("failed to record audit event",
+			slog.String("action", entry.action.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
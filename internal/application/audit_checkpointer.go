@@ -0,0 +1,76 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"log/slog"
+	"time"
+)
+
+// AuditCheckpointer периодически подписывает голову цепочки аудита
+type AuditCheckpointer struct {
+	uof      UnitOfWork
+	signer   audit.Signer
+	interval time.Duration
+	log      *slog.Logger
+}
+
+func NewAuditCheckpointer(uof UnitOfWork, signer audit.Signer, interval time.Duration, log *slog.Logger) *AuditCheckpointer {
+	return &AuditCheckpointer{
+		uof:      uof,
+		signer:   signer,
+		interval: interval,
+		log:      log,
+	}
+}
+
+func (c *AuditCheckpointer) Run(ctx context.Context) {
+	c.log.Info("audit checkpointer started", slog.Duration("interval", c.interval))
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			c.log.Info("audit checkpointer stopped")
+			return
+		case <-ticker.C:
+			checkpoint, err := c.Checkpoint(ctx)
+			if err != nil {
+				c.log.Error("failed to create audit checkpoint", slog.String("error", err.Error()))
+				continue
+			}
+			if checkpoint != nil {
+				c.log.Info("audit checkpoint created", slog.Int64("seq", checkpoint.Seq()))
+			}
+		}
+	}
+}
+
+// Checkpoint подписывает голову цепочки, если после прошлой контрольной точки появились записи.
+// Возвращает nil, если подписывать нечего
+func (c *AuditCheckpointer) Checkpoint(ctx context.Context) (*audit.Checkpoint, error) {
+	var checkpoint *audit.Checkpoint
+	err := c.uof.Execute(ctx, func(store Store) error {
+		repo := store.Audit()
+		head, err := repo.Head(ctx)
+		if err != nil || head == nil {
+			return err
+		}
+		last, err := repo.LastCheckpoint(ctx)
+		if err != nil {
+			return err
+		}
+		if last != nil && last.Seq() >= head.Seq() {
+			return nil
+		}
+		checkpoint, err = audit.CreateCheckpoint(head, c.signer)
+		if err != nil {
+			return err
+		}
+		return repo.SaveCheckpoint(ctx, checkpoint)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
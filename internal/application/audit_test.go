@@ -0,0 +1,146 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/helper/ctxkeys"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestUserServiceHandler_Login_audit(t *testing.T) {
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	user, _ := users.CreateUser(organizations.DefaultID, "name", email, pass)
+
+	requestID := uuid.New()
+	ctx := context.WithValue(context.Background(), ctxkeys.RequestID, requestID)
+	ctx = context.WithValue(ctx, ctxkeys.PeerIP, "10.0.0.1")
+
+	cases := []struct {
+		name        string
+		verified    bool
+		wantErr     bool
+		wantOutcome audit.Outcome
+	}{
+		{
+			name:        "success",
+			verified:    true,
+			wantOutcome: audit.OutcomeSuccess,
+		},
+		{
+			name:        "wrong password",
+			verified:    false,
+			wantErr:     true,
+			wantOutcome: audit.OutcomeFailure,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockusers.NewMockUserRepository(ctrl)
+			repository.EXPECT().GetByEmail(gomock.Any(), organizations.DefaultID, email).Return(user, nil)
+
+			passwordVerifier := mockusers.NewMockPasswordVerifier(ctrl)
+			passwordVerifier.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(tt.verified, nil)
+
+			tokenGenerator := mockusers.NewMockTokenGenerator(ctrl)
+			tokenGenerator.EXPECT().GenerateToken(user.ID(), organizations.DefaultID, users.RoleUser, nil).Return("token", nil).AnyTimes()
+
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			auditRepository.EXPECT().
+				Append(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, e *audit.Event) error {
+					assert.Equal(t, audit.ActionLogin, e.Action())
+					assert.Equal(t, tt.wantOutcome, e.Outcome())
+					assert.Equal(t, user.ID().String(), e.TargetID())
+					assert.Equal(t, requestID.String(), e.RequestID())
+					assert.Equal(t, "10.0.0.1", e.PeerIP())
+					return nil
+				})
+
+			uof := testUnitOfWork{store: testStore{users: repository, audit: auditRepository}}
+			service := NewUserService(uof, nil, passwordVerifier, tokenGenerator, log)
+
+			_, err := service.Login(ctx, "user@example.com", "password")
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestUserServiceHandler_DeleteUser_auditFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	target := uuid.New()
+	actor := uuid.New()
+
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().
+		Append(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, e *audit.Event) error {
+			assert.Equal(t, audit.ActionUserDeleted, e.Action())
+			assert.Equal(t, audit.OutcomeFailure, e.Outcome())
+			assert.Equal(t, actor, e.ActorID())
+			assert.Equal(t, target.String(), e.TargetID())
+			assert.NotEmpty(t, e.Reason())
+			return nil
+		})
+
+	service := NewUserService(testUnitOfWork{store: testStore{audit: auditRepository}}, nil, nil, nil, log)
+	ctx := authverify.NewContext(context.Background(), &authverify.Principal{UserID: actor})
+
+	err := service.DeleteUser(ctx, target)
+	assert.Error(t, err, "deleting another user should fail")
+}
+
+func TestAuditServiceHandler_ListAuditEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().
+		List(gomock.Any(), audit.Filter{Action: audit.ActionLogin, Limit: maxListLimit}).
+		Return([]*audit.Event{}, nil)
+
+	service := NewAuditService(testUnitOfWork{store: testStore{audit: auditRepository}}, nil, log)
+
+	_, err := service.ListAuditEvents(adminContext(), audit.Filter{Action: audit.ActionLogin})
+	assert.NoError(t, err)
+
+	_, err = service.ListAuditEvents(context.Background(), audit.Filter{})
+	assert.True(t, errors.Is(err, ErrPermissionDenied))
+}
+
+func TestAuditServiceHandler_VerifyAuditChain(t *testing.T) {
+	chain := make([]*audit.Event, 0, 3)
+	var prevHash string
+	for i := 1; i <= 3; i++ {
+		e, _ := audit.CreateEvent(audit.ActionLogin, uuid.Nil, audit.TargetUser, "", "", "", audit.OutcomeSuccess, "")
+		_ = e.Seal(prevHash)
+		e.SetSeq(int64(i))
+		prevHash = e.Hash()
+		chain = append(chain, e)
+	}
+
+	ctrl := gomock.NewController(t)
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().ListCheckpoints(gomock.Any()).Return(nil, nil)
+	auditRepository.EXPECT().ListChain(gomock.Any(), int64(0), auditVerifyBatchSize).Return(chain, nil)
+
+	service := NewAuditService(testUnitOfWork{store: testStore{audit: auditRepository}}, nil, log)
+
+	res, err := service.VerifyAuditChain(adminContext())
+	assert.NoError(t, err)
+	assert.True(t, res.Valid())
+	assert.Equal(t, int64(3), res.CheckedEvents)
+	assert.Equal(t, chain[2].Hash(), res.LastHash)
+
+	_, err = service.VerifyAuditChain(context.Background())
+	assert.True(t, errors.Is(err, ErrPermissionDenied))
+}
@@ -0,0 +1,109 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/ctxkeys"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+)
+
+func userIDFromContext(ctx context.Context) (uuid.UUID, error) {
+	p, ok := authverify.FromContext(ctx)
+	if !ok || p.UserID == uuid.Nil {
+		return uuid.Nil, errors.New("user id not found in context")
+	}
+	return p.UserID, nil
+}
+
+// tenantFromContext организация запроса: из токена, иначе из заголовка x-organization (id или slug),
+// иначе организация по умолчанию. Токены без организации выданы до ее появления или не пользователям
+func tenantFromContext(ctx context.Context, store Store) (uuid.UUID, error) {
+	if p, ok := authverify.FromContext(ctx); ok && p.TenantID != uuid.Nil {
+		return p.TenantID, nil
+	}
+	org, _ := ctx.Value(ctxkeys.Organization).(string)
+	if org == "" {
+		return organizations.DefaultID, nil
+	}
+	if id, err := uuid.Parse(org); err == nil {
+		o, err := store.Organizations().Get(ctx, id)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return o.ID(), nil
+	}
+	slug, err := organizations.NewSlug(org)
+	if err != nil {
+		return uuid.Nil, organizations.ErrOrganizationNotFound
+	}
+	o, err := store.Organizations().GetBySlug(ctx, slug)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return o.ID(), nil
+}
+
+// requirePlatformAdmin admin организации по умолчанию, администраторы других организаций управляют только своей
+func requirePlatformAdmin(ctx context.Context) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if p, ok := authverify.FromContext(ctx); ok && p.TenantID != uuid.Nil && p.TenantID != organizations.DefaultID {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+func roleFromContext(ctx context.Context) (users.Role, error) {
+	p, ok := authverify.FromContext(ctx)
+	if !ok {
+		return "", errors.New("role not found in context")
+	}
+	return users.NewRole(p.Role)
+}
+
+func requireAdmin(ctx context.Context) error {
+	role, err := roleFromContext(ctx)
+	if err != nil {
+		return ErrPermissionDenied
+	}
+	if role != users.RoleAdmin {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, ok := ctx.Value(ctxkeys.RequestID).(uuid.UUID)
+	if !ok {
+		return ""
+	}
+	return id.String()
+}
+
+func peerIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(ctxkeys.PeerIP).(string)
+	return ip
+}
+
+// scopeFromContext scope токена OAuth, у токенов Login scope нет
+func scopeFromContext(ctx context.Context) oauth.Scope {
+	p, ok := authverify.FromContext(ctx)
+	if !ok || len(p.Scope) == 0 {
+		return nil
+	}
+	return oauth.Scope(p.Scope)
+}
+
+// principalFromContext тип владельца токена, без значения в контексте считается пользователем
+func principalFromContext(ctx context.Context) users.PrincipalType {
+	p, ok := authverify.FromContext(ctx)
+	if !ok || p.Type == "" {
+		return users.PrincipalUser
+	}
+	return users.PrincipalType(p.Type)
+}
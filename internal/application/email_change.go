@@ -0,0 +1,268 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/domain/emailchanges"
+	"github.com/LeoUraltsev/auth-service/internal/domain/mail"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// EmailChangeService смена email с подтверждением. Ссылка подтверждения уходит на новый адрес,
+// уведомление со ссылкой отмены - на старый, адрес меняется только после подтверждения
+type EmailChangeService interface {
+	// RequestEmailChange отменяет предыдущий незавершенный запрос пользователя
+	RequestEmailChange(ctx context.Context, email string) error
+	// ConfirmEmailChange и CancelEmailChange вызываются по токену из письма без токена доступа
+	ConfirmEmailChange(ctx context.Context, token string) error
+	CancelEmailChange(ctx context.Context, token string) error
+}
+
+// EmailChangeLinks адреса страниц, на которые ведут ссылки из писем, токен передается параметром token
+type EmailChangeLinks struct {
+	ConfirmURL string
+	CancelURL  string
+}
+
+type EmailChangeServiceHandler struct {
+	uof    UnitOfWork
+	sender mail.Sender
+	links  EmailChangeLinks
+	ttl    time.Duration
+	log    *slog.Logger
+}
+
+func NewEmailChangeService(uof UnitOfWork, sender mail.Sender, links EmailChangeLinks, ttl time.Duration, log *slog.Logger) *EmailChangeServiceHandler {
+	return &EmailChangeServiceHandler{
+		uof:    uof,
+		sender: sender,
+		links:  links,
+		ttl:    ttl,
+		log:    log,
+	}
+}
+
+func (s *EmailChangeServiceHandler) RequestEmailChange(ctx context.Context, email string) error {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("requesting email change")
+
+	userID, err := userIDFromContext(ctx)
+	if err != nil || principalFromContext(ctx) != users.PrincipalUser {
+		log.Warn("failed to request email change", slog.String("error", ErrPermissionDenied.Error()))
+		return ErrPermissionDenied
+	}
+	newEmail, err := users.NewEmail(email)
+	if err != nil {
+		log.Warn("failed to request email change", slog.String("error", err.Error()))
+		return err
+	}
+
+	entry := userAudit(audit.ActionEmailChangeRequested, userID)
+	var pending pendingEmailChange
+	err = s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		u, err := store.Users().Get(ctx, tenantID, userID)
+		if err != nil {
+			return err
+		}
+		if !u.IsActive() {
+			return users.ErrUserNotActive
+		}
+		if pending, err = s.start(ctx, store, u, newEmail); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to request email change", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	if err = s.notify(ctx, pending); err != nil {
+		log.Error("failed to send email change mail", slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("email change requested", slog.String("email_change_id", pending.change.ID().String()))
+	return nil
+}
+
+func (s *EmailChangeServiceHandler) ConfirmEmailChange(ctx context.Context, token string) error {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("confirming email change")
+
+	if err := emailchanges.ValidToken(token, emailchanges.ConfirmPrefix); err != nil {
+		log.Warn("failed to confirm email change", slog.String("error", err.Error()))
+		return err
+	}
+
+	var entry auditEntry
+	err := s.uof.Execute(ctx, func(store Store) error {
+		change, err := store.EmailChanges().GetByConfirmHash(ctx, emailchanges.HashToken(token))
+		if err != nil {
+			return err
+		}
+		entry = userAudit(audit.ActionEmailChanged, change.UserID())
+		entry.actorID = change.UserID()
+
+		now := time.Now().UTC()
+		if err = change.Confirm(now); err != nil {
+			return err
+		}
+		u, err := store.Users().Get(ctx, change.TenantID(), change.UserID())
+		if err != nil {
+			return err
+		}
+		if u.Email() != change.OldEmail() {
+			return emailchanges.ErrStale
+		}
+		// адрес мог занять другой пользователь, пока письмо шло. Гонку двух подтверждений
+		// одного адреса закрывает уникальный индекс
+		exists, err := store.Users().ExistsByEmail(ctx, u.TenantID(), change.NewEmail())
+		if err != nil {
+			return err
+		}
+		if exists {
+			return users.ErrEmailAlreadyExists
+		}
+		if err = u.UpdateEmail(change.NewEmail()); err != nil {
+			return err
+		}
+		if err = store.EmailChanges().Save(ctx, change); err != nil {
+			return err
+		}
+		if err = store.Users().Save(ctx, u); err != nil {
+			return err
+		}
+		if err = publishUserChange(ctx, store, changes.TypeUpdated, u); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to confirm email change", slog.String("error", err.Error()))
+		if entry.action != "" {
+			recordAuditFailure(ctx, s.uof, log, entry, err)
+		}
+		return err
+	}
+
+	log.Info("email changed", slog.String("user_id", entry.targetID))
+	return nil
+}
+
+func (s *EmailChangeServiceHandler) CancelEmailChange(ctx context.Context, token string) error {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("cancelling email change")
+
+	if err := emailchanges.ValidToken(token, emailchanges.CancelPrefix); err != nil {
+		log.Warn("failed to cancel email change", slog.String("error", err.Error()))
+		return err
+	}
+
+	err := s.uof.Execute(ctx, func(store Store) error {
+		change, err := store.EmailChanges().GetByCancelHash(ctx, emailchanges.HashToken(token))
+		if err != nil {
+			return err
+		}
+		if err = change.Cancel(time.Now().UTC()); err != nil {
+			return err
+		}
+		if err = store.EmailChanges().Save(ctx, change); err != nil {
+			return err
+		}
+		entry := userAudit(audit.ActionEmailChangeCancelled, change.UserID())
+		entry.actorID = change.UserID()
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to cancel email change", slog.String("error", err.Error()))
+		return err
+	}
+
+	log.Info("email change cancelled")
+	return nil
+}
+
+// pendingEmailChange запрос с токенами, письма по нему отправляются после фиксации транзакции
+type pendingEmailChange struct {
+	change *emailchanges.Change
+	tokens emailchanges.Tokens
+}
+
+// start создает запрос на смену адреса в транзакции вызывающего. Занятость адреса проверяется
+// сразу, чтобы не отправлять заведомо бесполезное письмо, и еще раз при подтверждении
+func (s *EmailChangeServiceHandler) start(ctx context.Context, store Store, u *users.User, email users.Email) (pendingEmailChange, error) {
+	change, tokens, err := emailchanges.CreateChange(u, email, s.ttl)
+	if err != nil {
+		return pendingEmailChange{}, err
+	}
+	exists, err := store.Users().ExistsByEmail(ctx, u.TenantID(), email)
+	if err != nil {
+		return pendingEmailChange{}, err
+	}
+	if exists {
+		return pendingEmailChange{}, users.ErrEmailAlreadyExists
+	}
+	if err = store.EmailChanges().CancelPending(ctx, u.ID(), change.CreatedAt()); err != nil {
+		return pendingEmailChange{}, err
+	}
+	if err = store.EmailChanges().Save(ctx, change); err != nil {
+		return pendingEmailChange{}, err
+	}
+	return pendingEmailChange{change: change, tokens: tokens}, nil
+}
+
+func (s *EmailChangeServiceHandler) notify(ctx context.Context, pending pendingEmailChange) error {
+	change := pending.change
+	confirmURL, err := linkWithToken(s.links.ConfirmURL, pending.tokens.Confirm)
+	if err != nil {
+		return err
+	}
+	cancelURL, err := linkWithToken(s.links.CancelURL, pending.tokens.Cancel)
+	if err != nil {
+		return err
+	}
+	expires := change.ExpiresAt().Format(time.RFC1123)
+
+	err = s.sender.Send(ctx, mail.Message{
+		To:      change.NewEmail(),
+		Subject: "Подтвердите новый адрес",
+		Body: fmt.Sprintf("Чтобы сделать %s адресом вашей учетной записи, перейдите по ссылке:\n%s\n\nСсылка действует до %s.\n",
+			change.NewEmail().String(), confirmURL, expires),
+	})
+	if err != nil {
+		return err
+	}
+	return s.sender.Send(ctx, mail.Message{
+		To:      change.OldEmail(),
+		Subject: "Запрошена смена адреса",
+		Body: fmt.Sprintf("Для вашей учетной записи запрошена смена адреса на %s.\nЕсли это были не вы, отмените смену:\n%s\n",
+			change.NewEmail().String(), cancelURL),
+	})
+}
+
+// linkWithToken добавляет токен параметром token к адресу страницы
+func linkWithToken(base string, token string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", errors.New("link base url must be absolute")
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
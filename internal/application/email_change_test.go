@@ -0,0 +1,212 @@
+package application
+
+import (
+	"context"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	mockchanges "github.com/LeoUraltsev/auth-service/internal/domain/changes/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/emailchanges"
+	mockemailchanges "github.com/LeoUraltsev/auth-service/internal/domain/emailchanges/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/mail"
+	mockmail "github.com/LeoUraltsev/auth-service/internal/domain/mail/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	mockwebhooks "github.com/LeoUraltsev/auth-service/internal/domain/webhooks/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type emailChangeMocks struct {
+	users  *mockusers.MockUserRepository
+	emails *mockemailchanges.MockRepository
+	sender *mockmail.MockSender
+}
+
+var testEmailChangeLinks = EmailChangeLinks{
+	ConfirmURL: "https://app.example.com/email/confirm",
+	CancelURL:  "https://app.example.com/email/cancel",
+}
+
+func newEmailChangeStore(t *testing.T, prepare func(m emailChangeMocks)) (testStore, *mockmail.MockSender) {
+	ctrl := gomock.NewController(t)
+	m := emailChangeMocks{
+		users:  mockusers.NewMockUserRepository(ctrl),
+		emails: mockemailchanges.NewMockRepository(ctrl),
+		sender: mockmail.NewMockSender(ctrl),
+	}
+	changeRepository := mockchanges.NewMockRepository(ctrl)
+	changeRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	webhookRepository := mockwebhooks.NewMockRepository(ctrl)
+	webhookRepository.EXPECT().ListSubscriptionsByEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	prepare(m)
+
+	return testStore{
+		users:    m.users,
+		emails:   m.emails,
+		changes:  changeRepository,
+		webhooks: webhookRepository,
+		audit:    auditRepository,
+	}, m.sender
+}
+
+// tokenFromMail достает токен из ссылки в теле письма
+func tokenFromMail(t *testing.T, message mail.Message) string {
+	for _, field := range strings.Fields(message.Body) {
+		if strings.HasPrefix(field, "https://") {
+			link, err := url.Parse(field)
+			require.NoError(t, err)
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("mail has no link: %s", message.Body)
+	return ""
+}
+
+func TestEmailChangeServiceHandler_RequestEmailChange(t *testing.T) {
+	u := testUser(t)
+	newEmail, _ := users.NewEmail("new@gmail.com")
+	var saved *emailchanges.Change
+	sent := make(map[string]mail.Message)
+	store, sender := newEmailChangeStore(t, func(m emailChangeMocks) {
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+		m.users.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, newEmail).Return(false, nil)
+		m.emails.EXPECT().CancelPending(gomock.Any(), u.ID(), gomock.Any()).Return(nil)
+		m.emails.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *emailchanges.Change) error {
+			saved = c
+			return nil
+		})
+		m.sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, message mail.Message) error {
+			sent[message.To.String()] = message
+			return nil
+		}).Times(2)
+	})
+	service := NewEmailChangeService(testUnitOfWork{store: store}, sender, testEmailChangeLinks, time.Hour, log)
+
+	require.NoError(t, service.RequestEmailChange(userContext(u.ID()), "new@gmail.com"))
+	assert.Equal(t, "user@gmail.com", u.Email().String(), "email changes only after confirmation")
+	require.NotNil(t, saved)
+
+	confirm := tokenFromMail(t, sent["new@gmail.com"])
+	assert.Equal(t, emailchanges.HashToken(confirm), saved.ConfirmHash())
+	assert.True(t, strings.Contains(sent["new@gmail.com"].Body, testEmailChangeLinks.ConfirmURL))
+	cancel := tokenFromMail(t, sent["user@gmail.com"])
+	assert.Equal(t, emailchanges.HashToken(cancel), saved.CancelHash())
+}
+
+func TestEmailChangeServiceHandler_RequestEmailChange_taken(t *testing.T) {
+	u := testUser(t)
+	store, sender := newEmailChangeStore(t, func(m emailChangeMocks) {
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+		m.users.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, gomock.Any()).Return(true, nil)
+	})
+	service := NewEmailChangeService(testUnitOfWork{store: store}, sender, testEmailChangeLinks, time.Hour, log)
+
+	err := service.RequestEmailChange(userContext(u.ID()), "taken@gmail.com")
+	assert.ErrorIs(t, err, users.ErrEmailAlreadyExists)
+}
+
+func TestEmailChangeServiceHandler_ConfirmEmailChange(t *testing.T) {
+	newEmail, _ := users.NewEmail("new@gmail.com")
+
+	cases := []struct {
+		name    string
+		prepare func(m emailChangeMocks, u *users.User)
+		wantErr error
+	}{
+		{
+			name: "ok",
+			prepare: func(m emailChangeMocks, u *users.User) {
+				m.users.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, newEmail).Return(false, nil)
+				m.emails.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+				m.users.EXPECT().Save(gomock.Any(), u).Return(nil)
+			},
+		},
+		{
+			name: "email taken meanwhile",
+			prepare: func(m emailChangeMocks, u *users.User) {
+				m.users.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, newEmail).Return(true, nil)
+			},
+			wantErr: users.ErrEmailAlreadyExists,
+		},
+		{
+			name: "email changed by admin",
+			prepare: func(m emailChangeMocks, u *users.User) {
+				other, _ := users.NewEmail("other@gmail.com")
+				require.NoError(t, u.ReassignEmail(other, time.Now().UTC()))
+			},
+			wantErr: emailchanges.ErrStale,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			u := testUser(t)
+			change, tokens, err := emailchanges.CreateChange(u, newEmail, time.Hour)
+			require.NoError(t, err)
+			store, sender := newEmailChangeStore(t, func(m emailChangeMocks) {
+				m.emails.EXPECT().GetByConfirmHash(gomock.Any(), emailchanges.HashToken(tokens.Confirm)).Return(change, nil)
+				m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+				tt.prepare(m, u)
+			})
+			service := NewEmailChangeService(testUnitOfWork{store: store}, sender, testEmailChangeLinks, time.Hour, log)
+
+			err = service.ConfirmEmailChange(context.Background(), tokens.Confirm)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.NotEqual(t, newEmail, u.Email())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, newEmail, u.Email())
+			assert.Equal(t, emailchanges.StatusConfirmed, change.Status(time.Now().UTC()))
+		})
+	}
+}
+
+func TestEmailChangeServiceHandler_ConfirmEmailChange_cancelToken(t *testing.T) {
+	store, sender := newEmailChangeStore(t, func(m emailChangeMocks) {})
+	service := NewEmailChangeService(testUnitOfWork{store: store}, sender, testEmailChangeLinks, time.Hour, log)
+
+	err := service.ConfirmEmailChange(context.Background(), emailchanges.CancelPrefix+"abc")
+	assert.ErrorIs(t, err, emailchanges.ErrTokenNotValid)
+}
+
+func TestEmailChangeServiceHandler_CancelEmailChange(t *testing.T) {
+	u := testUser(t)
+	newEmail, _ := users.NewEmail("new@gmail.com")
+	change, tokens, err := emailchanges.CreateChange(u, newEmail, time.Hour)
+	require.NoError(t, err)
+	store, sender := newEmailChangeStore(t, func(m emailChangeMocks) {
+		m.emails.EXPECT().GetByCancelHash(gomock.Any(), emailchanges.HashToken(tokens.Cancel)).Return(change, nil)
+		m.emails.EXPECT().Save(gomock.Any(), change).Return(nil)
+	})
+	service := NewEmailChangeService(testUnitOfWork{store: store}, sender, testEmailChangeLinks, time.Hour, log)
+
+	require.NoError(t, service.CancelEmailChange(context.Background(), tokens.Cancel))
+	assert.Equal(t, emailchanges.StatusCancelled, change.Status(time.Now().UTC()))
+}
+
+func TestUserServiceHandler_UpdateUser_email(t *testing.T) {
+	u := testUser(t)
+	store, sender := newEmailChangeStore(t, func(m emailChangeMocks) {
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+		m.users.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, gomock.Any()).Return(false, nil)
+		m.emails.EXPECT().CancelPending(gomock.Any(), u.ID(), gomock.Any()).Return(nil)
+		m.emails.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+		m.users.EXPECT().Save(gomock.Any(), u).Return(nil)
+		m.sender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	})
+	uof := testUnitOfWork{store: store}
+	service := NewUserService(uof, nil, nil, nil, log).
+		WithEmailChange(NewEmailChangeService(uof, sender, testEmailChangeLinks, time.Hour, log))
+
+	require.NoError(t, service.UpdateUser(userContext(u.ID()), u.ID(), "", "new@gmail.com", ""))
+	assert.Equal(t, "user@gmail.com", u.Email().String())
+}
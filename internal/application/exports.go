@@ -0,0 +1,318 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/exports"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+// auditPageSize события аудита читаются страницами, чтобы не держать в памяти лишний буфер запроса
+const auditPageSize = 500
+
+// DataExportService выгрузка персональных данных пользователя в JSON архив.
+// Небольшой архив собирается сразу, большой - в фоне, готовность проверяется через GetExportJob
+type DataExportService interface {
+	ExportMyData(ctx context.Context) (*exports.Job, error)
+	// ExportUserData выгрузка данных пользователя организации, доступна только admin
+	ExportUserData(ctx context.Context, userID uuid.UUID) (*exports.Job, error)
+	// GetExportJob задача доступна пользователю, чьи данные выгружаются, и admin
+	GetExportJob(ctx context.Context, id uuid.UUID) (*exports.Job, error)
+}
+
+type DataExportServiceHandler struct {
+	uof UnitOfWork
+	// syncMaxEvents при большем числе событий аудита архив собирается в фоне
+	syncMaxEvents int
+	archiveTTL    time.Duration
+	log           *slog.Logger
+}
+
+func NewDataExportService(uof UnitOfWork, syncMaxEvents int, archiveTTL time.Duration, log *slog.Logger) *DataExportServiceHandler {
+	return &DataExportServiceHandler{
+		uof:           uof,
+		syncMaxEvents: syncMaxEvents,
+		archiveTTL:    archiveTTL,
+		log:           log,
+	}
+}
+
+func (s *DataExportServiceHandler) ExportMyData(ctx context.Context) (*exports.Job, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil || principalFromContext(ctx) != users.PrincipalUser {
+		logger.LogWithContext(ctx, s.log).Warn("failed to export user data", slog.String("error", ErrPermissionDenied.Error()))
+		return nil, ErrPermissionDenied
+	}
+	return s.export(ctx, userID)
+}
+
+func (s *DataExportServiceHandler) ExportUserData(ctx context.Context, userID uuid.UUID) (*exports.Job, error) {
+	if err := requireAdmin(ctx); err != nil {
+		log := logger.LogWithContext(ctx, s.log).With(slog.String("user_id", userID.String()))
+		log.Warn("failed to export user data", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionDataExported, userID), err)
+		return nil, err
+	}
+	return s.export(ctx, userID)
+}
+
+func (s *DataExportServiceHandler) GetExportJob(ctx context.Context, id uuid.UUID) (*exports.Job, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("export_id", id.String()))
+	log.Info("getting export job")
+
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		log.Warn("failed to get export job", slog.String("error", ErrPermissionDenied.Error()))
+		return nil, ErrPermissionDenied
+	}
+
+	var job *exports.Job
+	err = s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		job, err = store.Exports().Get(ctx, tenantID, id)
+		if err != nil {
+			return err
+		}
+		// чужая задача не отличается от несуществующей
+		if job.UserID() != userID && requireAdmin(ctx) != nil {
+			return exports.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn("failed to get export job", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *DataExportServiceHandler) export(ctx context.Context, userID uuid.UUID) (*exports.Job, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("user_id", userID.String()))
+	log.Info("exporting user data")
+
+	requestedBy, _ := userIDFromContext(ctx)
+	entry := userAudit(audit.ActionDataExported, userID)
+	var job *exports.Job
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		u, err := store.Users().Get(ctx, tenantID, userID)
+		if err != nil {
+			return err
+		}
+		job, err = exports.CreateJob(u.TenantID(), u.ID(), requestedBy)
+		if err != nil {
+			return err
+		}
+		data, complete, err := collectUserData(ctx, store, u, s.syncMaxEvents)
+		if err != nil {
+			return err
+		}
+		if complete {
+			now := time.Now().UTC()
+			archive, err := exports.BuildArchive(data, now)
+			if err != nil {
+				return err
+			}
+			if err = job.Complete(archive, s.archiveTTL, now); err != nil {
+				return err
+			}
+		}
+		if err = store.Exports().Save(ctx, job); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to export user data", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return nil, err
+	}
+
+	log.Info("user data exported", slog.String("export_id", job.ID().String()), slog.String("status", job.Status().String()))
+	return job, nil
+}
+
+// DataExporter собирает архивы отложенных выгрузок и удаляет истекшие
+type DataExporter struct {
+	uof        UnitOfWork
+	archiveTTL time.Duration
+	interval   time.Duration
+	batchSize  int
+	log        *slog.Logger
+}
+
+func NewDataExporter(uof UnitOfWork, archiveTTL time.Duration, interval time.Duration, batchSize int, log *slog.Logger) *DataExporter {
+	return &DataExporter{
+		uof:        uof,
+		archiveTTL: archiveTTL,
+		interval:   interval,
+		batchSize:  batchSize,
+		log:        log,
+	}
+}
+
+func (e *DataExporter) Run(ctx context.Context) {
+	e.log.Info("data exporter started", slog.Duration("interval", e.interval))
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			e.log.Info("data exporter stopped")
+			return
+		case <-ticker.C:
+			n, err := e.ProcessPending(ctx, time.Now().UTC())
+			if err != nil {
+				e.log.Error("failed to process export jobs", slog.String("error", err.Error()))
+				continue
+			}
+			if n > 0 {
+				e.log.Info("export jobs processed", slog.Int("count", n))
+			}
+		}
+	}
+}
+
+// ProcessPending удаляет истекшие архивы и собирает до batchSize задач, каждую в своей транзакции.
+// Задача блокируется на время транзакции, поэтому несколько экземпляров сервиса не соберут ее дважды
+func (e *DataExporter) ProcessPending(ctx context.Context, now time.Time) (int, error) {
+	err := e.uof.Execute(ctx, func(store Store) error {
+		deleted, err := store.Exports().DeleteExpired(ctx, now)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			e.log.Info("expired export archives deleted", slog.Int64("count", deleted))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var processed int
+	for processed < e.batchSize {
+		ok, err := e.processNext(ctx, now)
+		if err != nil {
+			return processed, err
+		}
+		if !ok {
+			break
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// processNext false, если ждущих задач нет. Задача, которую не удалось собрать, помечается неудачной
+// в отдельной транзакции, иначе она возвращалась бы в очередь на каждом запуске и задерживала остальные
+func (e *DataExporter) processNext(ctx context.Context, now time.Time) (bool, error) {
+	var job *exports.Job
+	var buildErr error
+	err := e.uof.Execute(ctx, func(store Store) error {
+		jobs, err := store.Exports().ListPending(ctx, 1)
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+		job = jobs[0]
+		if buildErr = e.build(ctx, store, job, now); buildErr != nil {
+			return buildErr
+		}
+		return store.Exports().Save(ctx, job)
+	})
+	if job == nil || buildErr == nil {
+		return job != nil, err
+	}
+
+	e.log.Error("failed to build export archive",
+		slog.String("export_id", job.ID().String()), slog.String("error", buildErr.Error()))
+	err = e.uof.Execute(ctx, func(store Store) error {
+		failed, err := store.Exports().Get(ctx, job.TenantID(), job.ID())
+		if err != nil {
+			return err
+		}
+		// задачу успел собрать другой экземпляр
+		if errors.Is(failed.Fail(buildErr.Error(), now), exports.ErrNotPending) {
+			return nil
+		}
+		return store.Exports().Save(ctx, failed)
+	})
+	return true, err
+}
+
+func (e *DataExporter) build(ctx context.Context, store Store, job *exports.Job, now time.Time) error {
+	log := e.log.With(slog.String("export_id", job.ID().String()), slog.String("user_id", job.UserID().String()))
+
+	u, err := store.Users().Get(ctx, job.TenantID(), job.UserID())
+	if errors.Is(err, users.ErrUserNotFound) {
+		// пользователя стерли, пока задача ждала очереди
+		log.Warn("user not found, export job failed")
+		return job.Fail(err.Error(), now)
+	}
+	if err != nil {
+		return err
+	}
+	data, _, err := collectUserData(ctx, store, u, 0)
+	if err != nil {
+		return err
+	}
+	archive, err := exports.BuildArchive(data, now)
+	if err != nil {
+		return err
+	}
+	log.Info("export archive built", slog.Int("size", len(archive)))
+	return job.Complete(archive, e.archiveTTL, now)
+}
+
+// collectUserData читает данные пользователя для архива. maxEvents > 0 ограничивает число событий аудита,
+// при превышении complete false и данные неполные
+func collectUserData(ctx context.Context, store Store, u *users.User, maxEvents int) (exports.Data, bool, error) {
+	data := exports.Data{User: u}
+	var err error
+	if data.Identities, err = store.Identities().ListByUser(ctx, u.ID()); err != nil {
+		return exports.Data{}, false, err
+	}
+	if data.RefreshTokens, err = store.OAuth().ListUserRefreshTokens(ctx, u.ID()); err != nil {
+		return exports.Data{}, false, err
+	}
+	if data.APIKeys, err = store.APIKeys().ListByUser(ctx, u.ID()); err != nil {
+		return exports.Data{}, false, err
+	}
+
+	// события, которые совершил пользователь, и события над ним; действие над собой попадает в обе выборки
+	seen := make(map[uuid.UUID]struct{})
+	for _, filter := range []audit.Filter{{ActorID: u.ID()}, {TargetID: u.ID().String()}} {
+		for filter.Offset = 0; ; filter.Offset += auditPageSize {
+			filter.Limit = auditPageSize
+			page, err := store.Audit().List(ctx, filter)
+			if err != nil {
+				return exports.Data{}, false, err
+			}
+			for _, event := range page {
+				if _, ok := seen[event.ID()]; ok {
+					continue
+				}
+				seen[event.ID()] = struct{}{}
+				data.AuditEvents = append(data.AuditEvents, event)
+				if maxEvents > 0 && len(data.AuditEvents) > maxEvents {
+					return data, false, nil
+				}
+			}
+			if len(page) < auditPageSize {
+				break
+			}
+		}
+	}
+	return data, true, nil
+}
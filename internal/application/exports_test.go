@@ -0,0 +1,237 @@
+package application
+
+import (
+	"context"
+	"errors"
+	mockapikeys "github.com/LeoUraltsev/auth-service/internal/domain/apikeys/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/exports"
+	mockexports "github.com/LeoUraltsev/auth-service/internal/domain/exports/mocks"
+	mockidentity "github.com/LeoUraltsev/auth-service/internal/domain/identity/mocks"
+	mockoauth "github.com/LeoUraltsev/auth-service/internal/domain/oauth/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+type exportMocks struct {
+	users   *mockusers.MockUserRepository
+	exports *mockexports.MockRepository
+	audit   *mockaudit.MockRepository
+}
+
+// newExportStore у пользователя нет учетных записей, сессий и ключей, события аудита задает prepare
+func newExportStore(t *testing.T, prepare func(m exportMocks)) testStore {
+	ctrl := gomock.NewController(t)
+	m := exportMocks{
+		users:   mockusers.NewMockUserRepository(ctrl),
+		exports: mockexports.NewMockRepository(ctrl),
+		audit:   mockaudit.NewMockRepository(ctrl),
+	}
+	identityRepository := mockidentity.NewMockRepository(ctrl)
+	identityRepository.EXPECT().ListByUser(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	oauthRepository := mockoauth.NewMockRepository(ctrl)
+	oauthRepository.EXPECT().ListUserRefreshTokens(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	apiKeyRepository := mockapikeys.NewMockRepository(ctrl)
+	apiKeyRepository.EXPECT().ListByUser(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	m.audit.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	prepare(m)
+
+	return testStore{
+		users:      m.users,
+		exports:    m.exports,
+		audit:      m.audit,
+		identities: identityRepository,
+		oauth:      oauthRepository,
+		apiKeys:    apiKeyRepository,
+	}
+}
+
+func userContext(id uuid.UUID) context.Context {
+	return authverify.NewContext(context.Background(), &authverify.Principal{UserID: id, Role: "user"})
+}
+
+func auditEvents(t *testing.T, userID uuid.UUID, n int) []*audit.Event {
+	res := make([]*audit.Event, 0, n)
+	for i := 0; i < n; i++ {
+		e, err := audit.CreateEvent(audit.ActionLogin, userID, audit.TargetUser, userID.String(), "", "", audit.OutcomeSuccess, "")
+		require.NoError(t, err)
+		res = append(res, e)
+	}
+	return res
+}
+
+func TestDataExportServiceHandler_ExportMyData(t *testing.T) {
+	u := testUser(t)
+	cases := []struct {
+		name       string
+		events     int
+		wantStatus exports.Status
+	}{
+		{name: "small archive is built inline", events: 2, wantStatus: exports.StatusCompleted},
+		{name: "large archive is built in background", events: 3, wantStatus: exports.StatusPending},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			events := auditEvents(t, u.ID(), tt.events)
+			var saved *exports.Job
+			store := newExportStore(t, func(m exportMocks) {
+				m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+				m.audit.EXPECT().List(gomock.Any(), audit.Filter{ActorID: u.ID(), Limit: auditPageSize}).Return(events, nil)
+				m.audit.EXPECT().List(gomock.Any(), audit.Filter{TargetID: u.ID().String(), Limit: auditPageSize}).Return(events, nil).MaxTimes(1)
+				m.exports.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, j *exports.Job) error {
+					saved = j
+					return nil
+				})
+			})
+			service := NewDataExportService(testUnitOfWork{store: store}, 2, time.Hour, log)
+
+			job, err := service.ExportMyData(userContext(u.ID()))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, job.Status())
+			assert.Equal(t, saved, job)
+			assert.Equal(t, u.ID(), job.UserID())
+			assert.Equal(t, u.ID(), job.RequestedBy())
+			if tt.wantStatus == exports.StatusCompleted {
+				assert.Contains(t, string(job.Archive()), u.Email().String())
+			} else {
+				assert.Empty(t, job.Archive())
+			}
+		})
+	}
+}
+
+func TestDataExportServiceHandler_ExportMyData_serviceAccount(t *testing.T) {
+	store := newExportStore(t, func(m exportMocks) {})
+	service := NewDataExportService(testUnitOfWork{store: store}, 100, time.Hour, log)
+
+	ctx := authverify.NewContext(context.Background(), &authverify.Principal{UserID: uuid.New(), Type: string(users.PrincipalServiceAccount)})
+	_, err := service.ExportMyData(ctx)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestDataExportServiceHandler_ExportUserData_notAdmin(t *testing.T) {
+	store := newExportStore(t, func(m exportMocks) {})
+	service := NewDataExportService(testUnitOfWork{store: store}, 100, time.Hour, log)
+
+	_, err := service.ExportUserData(userContext(uuid.New()), uuid.New())
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestDataExportServiceHandler_GetExportJob(t *testing.T) {
+	owner := uuid.New()
+	job, err := exports.CreateJob(organizations.DefaultID, owner, owner)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{name: "owner", ctx: userContext(owner)},
+		{name: "admin", ctx: authverify.NewContext(context.Background(), &authverify.Principal{UserID: uuid.New(), Role: "admin"})},
+		{name: "other user", ctx: userContext(uuid.New()), wantErr: exports.ErrNotFound},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newExportStore(t, func(m exportMocks) {
+				m.exports.EXPECT().Get(gomock.Any(), organizations.DefaultID, job.ID()).Return(job, nil)
+			})
+			service := NewDataExportService(testUnitOfWork{store: store}, 100, time.Hour, log)
+
+			got, err := service.GetExportJob(tt.ctx, job.ID())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, job, got)
+		})
+	}
+}
+
+func TestDataExporter_ProcessPending(t *testing.T) {
+	u := testUser(t)
+	now := time.Now().UTC()
+	ready, err := exports.CreateJob(organizations.DefaultID, u.ID(), u.ID())
+	require.NoError(t, err)
+	orphan, err := exports.CreateJob(organizations.DefaultID, uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	store := newExportStore(t, func(m exportMocks) {
+		m.exports.EXPECT().DeleteExpired(gomock.Any(), now).Return(int64(1), nil)
+		gomock.InOrder(
+			m.exports.EXPECT().ListPending(gomock.Any(), 1).Return([]*exports.Job{ready}, nil),
+			m.exports.EXPECT().ListPending(gomock.Any(), 1).Return([]*exports.Job{orphan}, nil),
+			m.exports.EXPECT().ListPending(gomock.Any(), 1).Return(nil, nil),
+		)
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, orphan.UserID()).Return(nil, users.ErrUserNotFound)
+		m.audit.EXPECT().List(gomock.Any(), gomock.Any()).Return(auditEvents(t, u.ID(), 3), nil).Times(2)
+		m.exports.EXPECT().Save(gomock.Any(), ready).Return(nil)
+		m.exports.EXPECT().Save(gomock.Any(), orphan).Return(nil)
+	})
+	exporter := NewDataExporter(testUnitOfWork{store: store}, time.Hour, time.Minute, 10, log)
+
+	n, err := exporter.ProcessPending(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, exports.StatusCompleted, ready.Status())
+	assert.NotEmpty(t, ready.Archive())
+	assert.Equal(t, now.Add(time.Hour), *ready.ExpiresAt())
+	assert.Equal(t, exports.StatusFailed, orphan.Status())
+}
+
+// TestDataExporter_ProcessPending_buildFailed задача, которую не удалось собрать, не задерживает следующие
+func TestDataExporter_ProcessPending_buildFailed(t *testing.T) {
+	u := testUser(t)
+	now := time.Now().UTC()
+	broken, err := exports.CreateJob(organizations.DefaultID, uuid.New(), uuid.New())
+	require.NoError(t, err)
+	ready, err := exports.CreateJob(organizations.DefaultID, u.ID(), u.ID())
+	require.NoError(t, err)
+
+	store := newExportStore(t, func(m exportMocks) {
+		m.exports.EXPECT().DeleteExpired(gomock.Any(), now).Return(int64(0), nil)
+		gomock.InOrder(
+			m.exports.EXPECT().ListPending(gomock.Any(), 1).Return([]*exports.Job{broken}, nil),
+			m.exports.EXPECT().ListPending(gomock.Any(), 1).Return([]*exports.Job{ready}, nil),
+			m.exports.EXPECT().ListPending(gomock.Any(), 1).Return(nil, nil),
+		)
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, broken.UserID()).Return(nil, errors.New("db is down"))
+		m.exports.EXPECT().Get(gomock.Any(), organizations.DefaultID, broken.ID()).Return(broken, nil)
+		m.exports.EXPECT().Save(gomock.Any(), broken).Return(nil)
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+		m.audit.EXPECT().List(gomock.Any(), gomock.Any()).Return(auditEvents(t, u.ID(), 3), nil).Times(2)
+		m.exports.EXPECT().Save(gomock.Any(), ready).Return(nil)
+	})
+	exporter := NewDataExporter(testUnitOfWork{store: store}, time.Hour, time.Minute, 10, log)
+
+	n, err := exporter.ProcessPending(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, exports.StatusFailed, broken.Status())
+	assert.Equal(t, "db is down", broken.Failure())
+	assert.Equal(t, exports.StatusCompleted, ready.Status())
+}
+
+func TestDataExporter_ProcessPending_error(t *testing.T) {
+	store := newExportStore(t, func(m exportMocks) {
+		m.exports.EXPECT().DeleteExpired(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("db is down"))
+	})
+	exporter := NewDataExporter(testUnitOfWork{store: store}, time.Hour, time.Minute, 10, log)
+
+	n, err := exporter.ProcessPending(context.Background(), time.Now().UTC())
+	assert.Error(t, err)
+	assert.Zero(t, n)
+}
@@ -0,0 +1,351 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+type FederationService interface {
+	// Providers имена настроенных провайдеров для страницы входа
+	Providers() []string
+	// StartLogin проверяет запрос /authorize и возвращает адрес провайдера и state,
+	// state нужно привязать к браузеру пользователя
+	StartLogin(ctx context.Context, provider string, req oauth.AuthorizationRequest) (string, string, error)
+	// CompleteLogin обрабатывает callback провайдера и выдает код по исходному запросу /authorize.
+	// Запрос возвращается и при ошибке, если вход найден, чтобы отдать ошибку клиенту
+	CompleteLogin(ctx context.Context, provider string, state string, code string) (oauth.AuthorizationRequest, string, error)
+	// ListIdentities userID uuid.Nil - текущий пользователь, чужие учетные записи видит только admin
+	ListIdentities(ctx context.Context, userID uuid.UUID) ([]*identity.Identity, error)
+	UnlinkIdentity(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+}
+
+type FederationServiceHandler struct {
+	uof            UnitOfWork
+	providers      map[string]identity.Provider
+	names          []string
+	passwordHasher users.PasswordHasher
+	codeTTL        time.Duration
+	loginTTL       time.Duration
+	log            *slog.Logger
+}
+
+func NewFederationService(
+	uof UnitOfWork,
+	providers []identity.Provider,
+	passwordHasher users.PasswordHasher,
+	codeTTL time.Duration,
+	loginTTL time.Duration,
+	log *slog.Logger,
+) *FederationServiceHandler {
+	s := &FederationServiceHandler{
+		uof:            uof,
+		providers:      make(map[string]identity.Provider, len(providers)),
+		passwordHasher: passwordHasher,
+		codeTTL:        codeTTL,
+		loginTTL:       loginTTL,
+		log:            log,
+	}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+		s.names = append(s.names, p.Name())
+	}
+	return s
+}
+
+func (s *FederationServiceHandler) Providers() []string {
+	return s.names
+}
+
+func (s *FederationServiceHandler) StartLogin(ctx context.Context, provider string, req oauth.AuthorizationRequest) (string, string, error) {
+	log := logger.LogWithContext(ctx, s.log).With(
+		slog.String("provider", provider),
+		slog.String("client_id", req.ClientID),
+	)
+	log.Info("starting federated login")
+
+	p, ok := s.providers[provider]
+	if !ok {
+		log.Warn("failed to start federated login", slog.String("error", identity.ErrProviderNotFound.Error()))
+		return "", "", identity.ErrProviderNotFound
+	}
+
+	var login *identity.Login
+	var state string
+	err := s.uof.Execute(ctx, func(store Store) error {
+		client, err := store.OAuth().GetClient(ctx, req.ClientID)
+		if err != nil {
+			return err
+		}
+		if _, err = client.ValidateAuthorization(req); err != nil {
+			return err
+		}
+
+		login, state, err = identity.CreateLogin(provider, req, s.loginTTL)
+		if err != nil {
+			return err
+		}
+		return store.Identities().SaveLogin(ctx, login)
+	})
+	if err != nil {
+		log.Warn("failed to start federated login", slog.String("error", err.Error()))
+		return "", "", err
+	}
+
+	redirectURL, err := p.AuthCodeURL(ctx, state, login.Nonce(), login.CodeChallenge())
+	if err != nil {
+		log.Warn("failed to start federated login", slog.String("error", err.Error()))
+		return "", "", err
+	}
+	return redirectURL, state, nil
+}
+
+func (s *FederationServiceHandler) CompleteLogin(ctx context.Context, provider string, state string, code string) (oauth.AuthorizationRequest, string, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("provider", provider))
+	log.Info("completing federated login")
+
+	p, ok := s.providers[provider]
+	if !ok {
+		log.Warn("failed to complete federated login", slog.String("error", identity.ErrProviderNotFound.Error()))
+		return oauth.AuthorizationRequest{}, "", identity.ErrProviderNotFound
+	}
+
+	// вход удаляется сразу и отдельной транзакцией, повторный callback с тем же state не пройдет
+	var login *identity.Login
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		login, err = store.Identities().TakeLogin(ctx, oauth.HashToken(state))
+		if err != nil {
+			return err
+		}
+		return login.Check(provider, time.Now().UTC())
+	})
+	if err != nil {
+		log.Warn("failed to complete federated login", slog.String("error", err.Error()))
+		return oauth.AuthorizationRequest{}, "", err
+	}
+	req := login.Request()
+	log = log.With(slog.String("client_id", req.ClientID))
+
+	if code == "" {
+		log.Warn("failed to complete federated login", slog.String("error", identity.ErrProviderRejected.Error()))
+		return req, "", identity.ErrProviderRejected
+	}
+	claims, err := p.Exchange(ctx, code, login.CodeVerifier(), login.Nonce())
+	if err != nil {
+		log.Warn("failed to complete federated login", slog.String("error", err.Error()))
+		return req, "", err
+	}
+
+	var authCode string
+	var userID uuid.UUID
+	err = s.uof.Execute(ctx, func(store Store) error {
+		client, err := store.OAuth().GetClient(ctx, req.ClientID)
+		if err != nil {
+			return err
+		}
+		scope, err := client.ValidateAuthorization(req)
+		if err != nil {
+			return err
+		}
+
+		usr, err := s.resolveUser(ctx, store, provider, claims)
+		if err != nil {
+			return err
+		}
+		userID = usr.ID()
+		if usr.CanAuthenticate(time.Now().UTC()) != nil {
+			return oauth.ErrUserNotActive
+		}
+
+		var c *oauth.AuthorizationCode
+		auth := oauth.Authentication{Time: time.Now().UTC(), ACR: identity.ACRFederated}
+		c, authCode, err = oauth.CreateAuthorizationCode(req, usr.ID(), scope, auth, s.codeTTL)
+		if err != nil {
+			return err
+		}
+		if err = store.OAuth().SaveCode(ctx, c); err != nil {
+			return err
+		}
+
+		entry := userAudit(audit.ActionLogin, usr.ID())
+		entry.actorID = usr.ID()
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to complete federated login", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionLogin, userID), err)
+		return req, "", err
+	}
+	return req, authCode, nil
+}
+
+func (s *FederationServiceHandler) ListIdentities(ctx context.Context, userID uuid.UUID) ([]*identity.Identity, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting identities")
+
+	target, err := targetUser(ctx, userID)
+	if err != nil {
+		log.Warn("failed to get identities", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var identities []*identity.Identity
+	err = s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		identities, err = store.Identities().ListByUser(ctx, target)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get identities", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (s *FederationServiceHandler) UnlinkIdentity(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("identity_id", id.String()))
+	log.Info("unlinking identity")
+
+	target, err := targetUser(ctx, userID)
+	if err != nil {
+		log.Warn("failed to unlink identity", slog.String("error", err.Error()))
+		return err
+	}
+
+	entry := userAudit(audit.ActionIdentityUnlinked, target)
+	err = s.uof.Execute(ctx, func(store Store) error {
+		if err := store.Identities().Delete(ctx, target, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to unlink identity", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+	log.Info("identity unlinked")
+	return nil
+}
+
+// resolveUser находит пользователя по привязанной учетной записи. Новая учетная запись
+// привязывается к пользователю с тем же подтвержденным email, если его нет - пользователь создается.
+// Вход через провайдеров работает в организации по умолчанию
+func (s *FederationServiceHandler) resolveUser(ctx context.Context, store Store, provider string, claims identity.Claims) (*users.User, error) {
+	linked, err := store.Identities().GetBySubject(ctx, provider, claims.Subject)
+	if err == nil {
+		return store.Users().Get(ctx, users.AnyTenant, linked.UserID())
+	}
+	if !errors.Is(err, identity.ErrIdentityNotFound) {
+		return nil, err
+	}
+
+	if !claims.EmailVerified {
+		return nil, identity.ErrEmailNotVerified
+	}
+	email, err := users.NewEmail(claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := store.Users().ExistsByEmail(ctx, organizations.DefaultID, email)
+	if err != nil {
+		return nil, err
+	}
+	var usr *users.User
+	if exists {
+		usr, err = store.Users().GetByEmail(ctx, organizations.DefaultID, email)
+	} else {
+		usr, err = s.provision(ctx, store, email, claims)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err = identity.CreateIdentity(usr.ID(), provider, claims)
+	if err != nil {
+		return nil, err
+	}
+	if err = store.Identities().Save(ctx, linked); err != nil {
+		return nil, err
+	}
+	entry := userAudit(audit.ActionIdentityLinked, usr.ID())
+	entry.actorID = usr.ID()
+	if err = recordAudit(ctx, store, entry, nil); err != nil {
+		return nil, err
+	}
+	return usr, nil
+}
+
+// provision создает пользователя со случайным паролем, войти по паролю он сможет после его смены
+func (s *FederationServiceHandler) provision(ctx context.Context, store Store, email users.Email, claims identity.Claims) (*users.User, error) {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(email.String(), "@")
+	}
+	n, err := users.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	password := make([]byte, 32)
+	if _, err = rand.Read(password); err != nil {
+		return nil, err
+	}
+	hash, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+	p, err := users.NewPassword(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	usr, err := users.CreateUser(organizations.DefaultID, n, email, p)
+	if err != nil {
+		return nil, err
+	}
+	if err = store.Users().Save(ctx, usr); err != nil {
+		return nil, err
+	}
+	if err = publishUserChange(ctx, store, changes.TypeCreated, usr); err != nil {
+		return nil, err
+	}
+	entry := userAudit(audit.ActionUserCreated, usr.ID())
+	entry.actorID = usr.ID()
+	if err = recordAudit(ctx, store, entry, nil); err != nil {
+		return nil, err
+	}
+	return usr, nil
+}
+
+// targetUser uuid.Nil означает текущего пользователя, с чужим id нужна роль admin.
+// У сервисного аккаунта своих данных пользователя нет, id нужно передавать всегда
+func targetUser(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	ctxUserID, err := userIDFromContext(ctx)
+	if err != nil {
+		return uuid.Nil, ErrPermissionDenied
+	}
+	isUser := principalFromContext(ctx) == users.PrincipalUser
+	if isUser && (userID == uuid.Nil || userID == ctxUserID) {
+		return ctxUserID, nil
+	}
+	if userID == uuid.Nil {
+		return uuid.Nil, ErrPermissionDenied
+	}
+	if err = requireAdmin(ctx); err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
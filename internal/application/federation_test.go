@@ -0,0 +1,218 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	mockchanges "github.com/LeoUraltsev/auth-service/internal/domain/changes/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	mockidentity "github.com/LeoUraltsev/auth-service/internal/domain/identity/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	mockoauth "github.com/LeoUraltsev/auth-service/internal/domain/oauth/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	mockwebhooks "github.com/LeoUraltsev/auth-service/internal/domain/webhooks/mocks"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestFederationServiceHandler_CompleteLogin(t *testing.T) {
+	sum := sha256.Sum256([]byte("dBjftJeZ4CVP-mJ92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+	client, _, err := oauth.CreateClient(
+		"app",
+		[]string{"https://app.example.com/cb"},
+		[]oauth.GrantType{oauth.GrantAuthorizationCode},
+		oauth.Scope{"openid"},
+		false,
+	)
+	require.NoError(t, err)
+	req := oauth.AuthorizationRequest{
+		ResponseType:        oauth.ResponseTypeCode,
+		ClientID:            client.ClientID(),
+		RedirectURI:         "https://app.example.com/cb",
+		Scope:               "openid",
+		State:               "client-state",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: oauth.CodeChallengeS256,
+	}
+
+	email, _ := users.NewEmail("jane@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	user, _ := users.CreateUser(organizations.DefaultID, "jane", email, pass)
+	claims := identity.Claims{Subject: "idp-subject", Email: "jane@example.com", EmailVerified: true, Name: "Jane"}
+
+	type mocks struct {
+		identities *mockidentity.MockRepository
+		users      *mockusers.MockUserRepository
+		hasher     *mockusers.MockPasswordHasher
+		changes    *mockchanges.MockRepository
+		webhooks   *mockwebhooks.MockRepository
+	}
+
+	cases := []struct {
+		name    string
+		claims  identity.Claims
+		expired bool
+		setup   func(m mocks)
+		wantErr error
+	}{
+		{
+			name:   "linked identity",
+			claims: claims,
+			setup: func(m mocks) {
+				linked := identity.NewIdentity(uuid.New(), user.ID(), "corp", claims.Subject, claims.Email, time.Now())
+				m.identities.EXPECT().GetBySubject(gomock.Any(), "corp", claims.Subject).Return(linked, nil)
+				m.users.EXPECT().Get(gomock.Any(), users.AnyTenant, user.ID()).Return(user, nil)
+			},
+		},
+		{
+			name:   "links existing user by email",
+			claims: claims,
+			setup: func(m mocks) {
+				m.identities.EXPECT().GetBySubject(gomock.Any(), "corp", claims.Subject).Return(nil, identity.ErrIdentityNotFound)
+				m.users.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, email).Return(true, nil)
+				m.users.EXPECT().GetByEmail(gomock.Any(), organizations.DefaultID, email).Return(user, nil)
+				m.identities.EXPECT().
+					Save(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, i *identity.Identity) error {
+						assert.Equal(t, user.ID(), i.UserID())
+						assert.Equal(t, claims.Subject, i.Subject())
+						return nil
+					})
+			},
+		},
+		{
+			name:   "provisions new user",
+			claims: claims,
+			setup: func(m mocks) {
+				m.identities.EXPECT().GetBySubject(gomock.Any(), "corp", claims.Subject).Return(nil, identity.ErrIdentityNotFound)
+				m.users.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, email).Return(false, nil)
+				m.hasher.EXPECT().Hash(gomock.Any()).Return([]byte("random-hash"), nil)
+				m.users.EXPECT().
+					Save(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, u *users.User) error {
+						assert.Equal(t, "Jane", u.Name().String())
+						assert.Equal(t, email, u.Email())
+						return nil
+					})
+				m.changes.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+				m.webhooks.EXPECT().ListSubscriptionsByEvent(gomock.Any(), gomock.Any()).Return(nil, nil)
+				m.identities.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:   "email not verified",
+			claims: identity.Claims{Subject: "idp-subject", Email: "jane@example.com"},
+			setup: func(m mocks) {
+				m.identities.EXPECT().GetBySubject(gomock.Any(), "corp", claims.Subject).Return(nil, identity.ErrIdentityNotFound)
+			},
+			wantErr: identity.ErrEmailNotVerified,
+		},
+		{
+			name:    "expired login",
+			expired: true,
+			wantErr: identity.ErrLoginExpired,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mocks{
+				identities: mockidentity.NewMockRepository(ctrl),
+				users:      mockusers.NewMockUserRepository(ctrl),
+				hasher:     mockusers.NewMockPasswordHasher(ctrl),
+				changes:    mockchanges.NewMockRepository(ctrl),
+				webhooks:   mockwebhooks.NewMockRepository(ctrl),
+			}
+
+			ttl := time.Minute
+			if tt.expired {
+				ttl = -time.Minute
+			}
+			login, state, err := identity.CreateLogin("corp", req, ttl)
+			require.NoError(t, err)
+			m.identities.EXPECT().TakeLogin(gomock.Any(), oauth.HashToken(state)).Return(login, nil)
+
+			provider := mockidentity.NewMockProvider(ctrl)
+			provider.EXPECT().Name().Return("corp").AnyTimes()
+			oauthRepository := mockoauth.NewMockRepository(ctrl)
+			if !tt.expired {
+				provider.EXPECT().Exchange(gomock.Any(), "idp-code", login.CodeVerifier(), login.Nonce()).Return(tt.claims, nil)
+				oauthRepository.EXPECT().GetClient(gomock.Any(), client.ClientID()).Return(client, nil)
+			}
+			if tt.wantErr == nil {
+				oauthRepository.EXPECT().
+					SaveCode(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, code *oauth.AuthorizationCode) error {
+						assert.Equal(t, identity.ACRFederated, code.Auth().ACR)
+						return nil
+					})
+			}
+			if tt.setup != nil {
+				tt.setup(m)
+			}
+
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			uof := testUnitOfWork{store: testStore{
+				users:      m.users,
+				webhooks:   m.webhooks,
+				changes:    m.changes,
+				audit:      auditRepository,
+				oauth:      oauthRepository,
+				identities: m.identities,
+			}}
+			service := NewFederationService(uof, []identity.Provider{provider}, m.hasher, time.Minute, time.Minute, log)
+
+			gotReq, code, err := service.CompleteLogin(context.Background(), "corp", state, "idp-code")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, req, gotReq)
+			assert.NotEmpty(t, code)
+		})
+	}
+}
+
+func TestFederationServiceHandler_ListIdentities(t *testing.T) {
+	self := uuid.New()
+	other := uuid.New()
+
+	cases := []struct {
+		name    string
+		role    string
+		userID  uuid.UUID
+		want    uuid.UUID
+		wantErr error
+	}{
+		{name: "self", role: "user", want: self},
+		{name: "other as user", role: "user", userID: other, wantErr: ErrPermissionDenied},
+		{name: "other as admin", role: "admin", userID: other, want: other},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockidentity.NewMockRepository(ctrl)
+			if tt.wantErr == nil {
+				repository.EXPECT().ListByUser(gomock.Any(), tt.want).Return(nil, nil)
+			}
+			service := NewFederationService(testUnitOfWork{store: testStore{identities: repository}}, nil, nil, time.Minute, time.Minute, log)
+
+			ctx := authverify.NewContext(context.Background(), &authverify.Principal{UserID: self, Role: tt.role})
+			_, err := service.ListIdentities(ctx, tt.userID)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/groups"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+// GroupService группы пользователей организации. Управляет группами admin организации,
+// ListUserGroups доступен также самому пользователю
+type GroupService interface {
+	CreateGroup(ctx context.Context, name string, description string) (*groups.Group, error)
+	GetGroup(ctx context.Context, id uuid.UUID) (*groups.Group, error)
+	ListGroups(ctx context.Context, limit int, offset int) ([]*groups.Group, error)
+	// UpdateGroup пустые поля не меняются
+	UpdateGroup(ctx context.Context, id uuid.UUID, name string, description string) (*groups.Group, error)
+	DeleteGroup(ctx context.Context, id uuid.UUID) error
+	// AddGroupMember добавляет пользователя или вложенную группу, цикл из групп не допускается
+	AddGroupMember(ctx context.Context, groupID uuid.UUID, member groups.Member) error
+	RemoveGroupMember(ctx context.Context, groupID uuid.UUID, member groups.Member) error
+	ListGroupMembers(ctx context.Context, groupID uuid.UUID, limit int, offset int) ([]groups.Member, error)
+	// ListUserGroups группы пользователя с учетом вложенности
+	ListUserGroups(ctx context.Context, userID uuid.UUID) ([]*groups.Group, error)
+}
+
+type GroupServiceHandler struct {
+	uof UnitOfWork
+	log *slog.Logger
+}
+
+func NewGroupService(uof UnitOfWork, log *slog.Logger) *GroupServiceHandler {
+	return &GroupServiceHandler{
+		uof: uof,
+		log: log,
+	}
+}
+
+func (s *GroupServiceHandler) CreateGroup(ctx context.Context, name string, description string) (*groups.Group, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("creating group")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to create group", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var group *groups.Group
+	entry := auditEntry{action: audit.ActionGroupCreated, targetType: audit.TargetGroup}
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		group, err = groups.CreateGroup(tenantID, name, description)
+		if err != nil {
+			return err
+		}
+		entry.targetID = group.ID().String()
+		if err = checkUniqueGroupName(ctx, store, group); err != nil {
+			return err
+		}
+		if err = store.Groups().Save(ctx, group); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to create group", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return nil, err
+	}
+
+	log.Info("group created", slog.String("group_id", group.ID().String()))
+	return group, nil
+}
+
+func (s *GroupServiceHandler) GetGroup(ctx context.Context, id uuid.UUID) (*groups.Group, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("group_id", id.String()))
+	log.Info("getting group")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to get group", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var group *groups.Group
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		group, err = getGroup(ctx, store, id)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get group", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *GroupServiceHandler) ListGroups(ctx context.Context, limit int, offset int) ([]*groups.Group, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting groups")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to get groups", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var list []*groups.Group
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		list, err = store.Groups().List(ctx, tenantID, limit, offset)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get groups", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *GroupServiceHandler) UpdateGroup(ctx context.Context, id uuid.UUID, name string, description string) (*groups.Group, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("group_id", id.String()))
+	log.Info("updating group")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to update group", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var group *groups.Group
+	entry := groupAudit(audit.ActionGroupUpdated, id)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		group, err = getGroup(ctx, store, id)
+		if err != nil {
+			return err
+		}
+		if err = group.Update(name, description); err != nil {
+			return err
+		}
+		if err = checkUniqueGroupName(ctx, store, group); err != nil {
+			return err
+		}
+		if err = store.Groups().Save(ctx, group); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to update group", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return nil, err
+	}
+
+	log.Info("group updated")
+	return group, nil
+}
+
+func (s *GroupServiceHandler) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("group_id", id.String()))
+	log.Info("deleting group")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to delete group", slog.String("error", err.Error()))
+		return err
+	}
+
+	entry := groupAudit(audit.ActionGroupDeleted, id)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		if err = store.Groups().Delete(ctx, tenantID, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to delete group", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	log.Info("group deleted")
+	return nil
+}
+
+func (s *GroupServiceHandler) AddGroupMember(ctx context.Context, groupID uuid.UUID, member groups.Member) error {
+	log := logger.LogWithContext(ctx, s.log).With(
+		slog.String("group_id", groupID.String()),
+		slog.String("member_type", member.Type.String()),
+		slog.String("member_id", member.ID.String()),
+	)
+	log.Info("adding group member")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to add group member", slog.String("error", err.Error()))
+		return err
+	}
+
+	entry := groupAudit(audit.ActionGroupMemberAdded, groupID)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		group, err := getGroup(ctx, store, groupID)
+		if err != nil {
+			return err
+		}
+		switch member.Type {
+		case groups.MemberUser:
+			if _, err = store.Users().Get(ctx, group.TenantID(), member.ID); err != nil {
+				return err
+			}
+		case groups.MemberGroup:
+			if _, err = store.Groups().Get(ctx, group.TenantID(), member.ID); err != nil {
+				return err
+			}
+			if err = store.Groups().LockMembership(ctx, group.TenantID()); err != nil {
+				return err
+			}
+			if err = groups.CheckCycle(ctx, store.Groups(), group.TenantID(), groupID, member.ID); err != nil {
+				return err
+			}
+		default:
+			return groups.ErrMemberTypeNotValid
+		}
+		if err = store.Groups().AddMember(ctx, groupID, member); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to add group member", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	log.Info("group member added")
+	return nil
+}
+
+func (s *GroupServiceHandler) RemoveGroupMember(ctx context.Context, groupID uuid.UUID, member groups.Member) error {
+	log := logger.LogWithContext(ctx, s.log).With(
+		slog.String("group_id", groupID.String()),
+		slog.String("member_type", member.Type.String()),
+		slog.String("member_id", member.ID.String()),
+	)
+	log.Info("removing group member")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to remove group member", slog.String("error", err.Error()))
+		return err
+	}
+
+	entry := groupAudit(audit.ActionGroupMemberRemoved, groupID)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		if _, err := getGroup(ctx, store, groupID); err != nil {
+			return err
+		}
+		if err := store.Groups().RemoveMember(ctx, groupID, member); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to remove group member", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	log.Info("group member removed")
+	return nil
+}
+
+func (s *GroupServiceHandler) ListGroupMembers(ctx context.Context, groupID uuid.UUID, limit int, offset int) ([]groups.Member, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("group_id", groupID.String()))
+	log.Info("getting group members")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to get group members", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var list []groups.Member
+	err := s.uof.Execute(ctx, func(store Store) error {
+		if _, err := getGroup(ctx, store, groupID); err != nil {
+			return err
+		}
+		var err error
+		list, err = store.Groups().ListMembers(ctx, groupID, limit, offset)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get group members", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *GroupServiceHandler) ListUserGroups(ctx context.Context, userID uuid.UUID) ([]*groups.Group, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("user_id", userID.String()))
+	log.Info("getting user groups")
+
+	if callerID, err := userIDFromContext(ctx); err != nil || callerID != userID {
+		if err = requireAdmin(ctx); err != nil {
+			log.Warn("failed to get user groups", slog.String("error", err.Error()))
+			return nil, err
+		}
+	}
+
+	var list []*groups.Group
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		if _, err = store.Users().Get(ctx, tenantID, userID); err != nil {
+			return err
+		}
+		list, err = userGroups(ctx, store, tenantID, userID)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get user groups", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return list, nil
+}
+
+// userGroups группы пользователя, в которые он входит напрямую или через вложенные группы
+func userGroups(ctx context.Context, store Store, tenantID uuid.UUID, userID uuid.UUID) ([]*groups.Group, error) {
+	ids, err := groups.Ancestors(ctx, store.Groups(), tenantID, groups.Member{Type: groups.MemberUser, ID: userID})
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []*groups.Group{}, nil
+	}
+	return store.Groups().ListByIDs(ctx, tenantID, ids)
+}
+
+// tokenGroups имена групп для claim токена, nil если claim групп выключен
+func tokenGroups(ctx context.Context, store Store, enabled bool, tenantID uuid.UUID, userID uuid.UUID) ([]string, error) {
+	if !enabled {
+		return nil, nil
+	}
+	list, err := userGroups(ctx, store, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(list))
+	for _, g := range list {
+		names = append(names, g.Name())
+	}
+	return names, nil
+}
+
+func getGroup(ctx context.Context, store Store, id uuid.UUID) (*groups.Group, error) {
+	tenantID, err := tenantFromContext(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return store.Groups().Get(ctx, tenantID, id)
+}
+
+// checkUniqueGroupName имя занято другой группой организации
+func checkUniqueGroupName(ctx context.Context, store Store, group *groups.Group) error {
+	existing, err := store.Groups().GetByName(ctx, group.TenantID(), group.Name())
+	if errors.Is(err, groups.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID() != group.ID() {
+		return groups.ErrNameAlreadyExists
+	}
+	return nil
+}
+
+func groupAudit(action audit.Action, id uuid.UUID) auditEntry {
+	return auditEntry{
+		action:     action,
+		targetType: audit.TargetGroup,
+		targetID:   id.String(),
+	}
+}
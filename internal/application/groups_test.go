@@ -0,0 +1,124 @@
+package application
+
+import (
+	"context"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/groups"
+	mockgroups "github.com/LeoUraltsev/auth-service/internal/domain/groups/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestGroupServiceHandler_AddGroupMember(t *testing.T) {
+	dev, _ := groups.CreateGroup(organizations.DefaultID, "dev", "")
+	backend, _ := groups.CreateGroup(organizations.DefaultID, "backend", "")
+	userID := uuid.New()
+
+	cases := []struct {
+		name    string
+		member  groups.Member
+		prepare func(g *mockgroups.MockRepository, u *mockusers.MockUserRepository)
+		wantErr error
+	}{
+		{
+			name:   "user",
+			member: groups.Member{Type: groups.MemberUser, ID: userID},
+			prepare: func(g *mockgroups.MockRepository, u *mockusers.MockUserRepository) {
+				u.EXPECT().Get(gomock.Any(), organizations.DefaultID, userID).Return(&users.User{}, nil)
+				g.EXPECT().AddMember(gomock.Any(), dev.ID(), groups.Member{Type: groups.MemberUser, ID: userID}).Return(nil)
+			},
+		},
+		{
+			name:   "unknown user",
+			member: groups.Member{Type: groups.MemberUser, ID: userID},
+			prepare: func(g *mockgroups.MockRepository, u *mockusers.MockUserRepository) {
+				u.EXPECT().Get(gomock.Any(), organizations.DefaultID, userID).Return(nil, users.ErrUserNotFound)
+			},
+			wantErr: users.ErrUserNotFound,
+		},
+		{
+			name:   "nested group",
+			member: groups.Member{Type: groups.MemberGroup, ID: backend.ID()},
+			prepare: func(g *mockgroups.MockRepository, u *mockusers.MockUserRepository) {
+				g.EXPECT().Get(gomock.Any(), organizations.DefaultID, backend.ID()).Return(backend, nil)
+				// блокировка берется до проверки цикла
+				gomock.InOrder(
+					g.EXPECT().LockMembership(gomock.Any(), organizations.DefaultID).Return(nil),
+					g.EXPECT().ListParents(gomock.Any(), organizations.DefaultID, groups.Member{Type: groups.MemberGroup, ID: dev.ID()}).Return(nil, nil),
+				)
+				g.EXPECT().AddMember(gomock.Any(), dev.ID(), groups.Member{Type: groups.MemberGroup, ID: backend.ID()}).Return(nil)
+			},
+		},
+		{
+			name:   "cycle",
+			member: groups.Member{Type: groups.MemberGroup, ID: backend.ID()},
+			prepare: func(g *mockgroups.MockRepository, u *mockusers.MockUserRepository) {
+				g.EXPECT().Get(gomock.Any(), organizations.DefaultID, backend.ID()).Return(backend, nil)
+				g.EXPECT().LockMembership(gomock.Any(), organizations.DefaultID).Return(nil)
+				// dev уже входит в backend
+				g.EXPECT().ListParents(gomock.Any(), organizations.DefaultID, groups.Member{Type: groups.MemberGroup, ID: dev.ID()}).
+					Return([]uuid.UUID{backend.ID()}, nil)
+				g.EXPECT().ListParents(gomock.Any(), organizations.DefaultID, groups.Member{Type: groups.MemberGroup, ID: backend.ID()}).Return(nil, nil)
+			},
+			wantErr: groups.ErrCycle,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			groupRepository := mockgroups.NewMockRepository(ctrl)
+			groupRepository.EXPECT().Get(gomock.Any(), organizations.DefaultID, dev.ID()).Return(dev, nil)
+			userRepository := mockusers.NewMockUserRepository(ctrl)
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			tt.prepare(groupRepository, userRepository)
+
+			service := NewGroupService(testUnitOfWork{store: testStore{groups: groupRepository, users: userRepository, audit: auditRepository}}, log)
+
+			err := service.AddGroupMember(adminContext(), dev.ID(), tt.member)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGroupServiceHandler_ListUserGroups(t *testing.T) {
+	userID := uuid.New()
+	self := authverify.NewContext(context.Background(), &authverify.Principal{UserID: userID, Role: "user"})
+	other := authverify.NewContext(context.Background(), &authverify.Principal{UserID: uuid.New(), Role: "user"})
+	dev, _ := groups.CreateGroup(organizations.DefaultID, "dev", "")
+	engineering, _ := groups.CreateGroup(organizations.DefaultID, "engineering", "")
+
+	ctrl := gomock.NewController(t)
+	groupRepository := mockgroups.NewMockRepository(ctrl)
+	groupRepository.EXPECT().ListParents(gomock.Any(), organizations.DefaultID, groups.Member{Type: groups.MemberUser, ID: userID}).
+		Return([]uuid.UUID{dev.ID()}, nil)
+	groupRepository.EXPECT().ListParents(gomock.Any(), organizations.DefaultID, groups.Member{Type: groups.MemberGroup, ID: dev.ID()}).
+		Return([]uuid.UUID{engineering.ID()}, nil)
+	groupRepository.EXPECT().ListParents(gomock.Any(), organizations.DefaultID, groups.Member{Type: groups.MemberGroup, ID: engineering.ID()}).
+		Return(nil, nil)
+	groupRepository.EXPECT().ListByIDs(gomock.Any(), organizations.DefaultID, []uuid.UUID{dev.ID(), engineering.ID()}).
+		Return([]*groups.Group{dev, engineering}, nil)
+	userRepository := mockusers.NewMockUserRepository(ctrl)
+	userRepository.EXPECT().Get(gomock.Any(), organizations.DefaultID, userID).Return(&users.User{}, nil)
+
+	service := NewGroupService(testUnitOfWork{store: testStore{groups: groupRepository, users: userRepository}}, log)
+
+	_, err := service.ListUserGroups(other, userID)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	list, err := service.ListUserGroups(self, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []*groups.Group{dev, engineering}, list)
+}
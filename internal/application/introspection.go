@@ -0,0 +1,219 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+// ErrTokenNotActive токен подписан верно, но больше не действует. Причина наружу не отдается
+var ErrTokenNotActive = errors.New("token is not active")
+
+// IntrospectionService проверка токенов для сервисов, которые не могут проверить JWT сами (RFC 7662).
+// Недействующий токен не ошибка, возвращается Introspection с Active false
+type IntrospectionService interface {
+	// Introspect для HTTP, вызывающий аутентифицируется как конфиденциальный клиент OAuth или сервисный аккаунт
+	Introspect(ctx context.Context, clientID string, clientSecret string, token string) (oauth.Introspection, error)
+	// IntrospectToken для gRPC, вызывающий берется из контекста и должен быть сервисом, а не пользователем
+	IntrospectToken(ctx context.Context, token string) (oauth.Introspection, error)
+	// VerifySession для интерцептора и шлюзов, подпись и срок токена уже проверены.
+	// ErrTokenNotActive, если владелец не может входить или токен отозван принудительным выходом
+	VerifySession(ctx context.Context, token oauth.AccessToken) error
+}
+
+type IntrospectionServiceHandler struct {
+	uof      UnitOfWork
+	verifier oauth.TokenVerifier
+	log      *slog.Logger
+}
+
+func NewIntrospectionService(uof UnitOfWork, verifier oauth.TokenVerifier, log *slog.Logger) *IntrospectionServiceHandler {
+	return &IntrospectionServiceHandler{
+		uof:      uof,
+		verifier: verifier,
+		log:      log,
+	}
+}
+
+func (s *IntrospectionServiceHandler) Introspect(ctx context.Context, clientID string, clientSecret string, token string) (oauth.Introspection, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("client_id", clientID))
+	log.Info("introspecting token")
+
+	err := s.uof.Execute(ctx, func(store Store) error {
+		return authenticateServiceClient(ctx, store, clientID, clientSecret)
+	})
+	if err != nil {
+		log.Warn("failed to introspect token", slog.String("error", err.Error()))
+		return oauth.Introspection{}, err
+	}
+	return s.introspect(ctx, log, token)
+}
+
+func (s *IntrospectionServiceHandler) IntrospectToken(ctx context.Context, token string) (oauth.Introspection, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("introspecting token")
+
+	if principalFromContext(ctx) == users.PrincipalUser {
+		log.Warn("failed to introspect token", slog.String("error", ErrPermissionDenied.Error()))
+		return oauth.Introspection{}, ErrPermissionDenied
+	}
+	return s.introspect(ctx, log, token)
+}
+
+func (s *IntrospectionServiceHandler) VerifySession(ctx context.Context, token oauth.AccessToken) error {
+	return s.uof.Execute(ctx, func(store Store) error {
+		return checkAccessToken(ctx, store, token)
+	})
+}
+
+func (s *IntrospectionServiceHandler) introspect(ctx context.Context, log *slog.Logger, token string) (oauth.Introspection, error) {
+	var res oauth.Introspection
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		if _, prefixErr := apikeys.ParsePrefix(token); prefixErr == nil {
+			res, err = introspectAPIKey(ctx, store, token)
+		} else {
+			res, err = s.introspectAccessToken(ctx, store, token)
+		}
+		return err
+	})
+	if errors.Is(err, ErrTokenNotActive) {
+		log.Info("token is not active")
+		return oauth.Introspection{}, nil
+	}
+	if err != nil {
+		log.Warn("failed to introspect token", slog.String("error", err.Error()))
+		return oauth.Introspection{}, err
+	}
+	return res, nil
+}
+
+func (s *IntrospectionServiceHandler) introspectAccessToken(ctx context.Context, store Store, token string) (oauth.Introspection, error) {
+	t, err := s.verifier.VerifyAccessToken(token)
+	if err != nil {
+		return oauth.Introspection{}, ErrTokenNotActive
+	}
+	if err = checkAccessToken(ctx, store, t); err != nil {
+		return oauth.Introspection{}, err
+	}
+	return oauth.NewIntrospection(t), nil
+}
+
+// checkAccessToken кроме подписи и срока проверяет, что владелец токена еще может входить,
+// а вход, по которому выдан токен, не отозван
+func checkAccessToken(ctx context.Context, store Store, t oauth.AccessToken) error {
+	switch t.Principal {
+	case users.PrincipalUser:
+		usr, err := store.Users().Get(ctx, users.AnyTenant, t.UserID)
+		if err != nil {
+			return notActiveIfMissing(err)
+		}
+		if usr.CanAuthenticate(time.Now().UTC()) != nil || usr.TokenRevoked(t.IssuedAt) {
+			return ErrTokenNotActive
+		}
+	case users.PrincipalServiceAccount:
+		account, err := store.ServiceAccounts().Get(ctx, t.UserID)
+		if err != nil {
+			return notActiveIfMissing(err)
+		}
+		if account.IsDisabled() {
+			return ErrTokenNotActive
+		}
+	case users.PrincipalClient:
+		if _, err := store.OAuth().GetClient(ctx, t.ClientID); err != nil {
+			return notActiveIfMissing(err)
+		}
+	default:
+		return ErrTokenNotActive
+	}
+
+	if t.SessionID != uuid.Nil {
+		revoked, err := store.OAuth().IsFamilyRevoked(ctx, t.SessionID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrTokenNotActive
+		}
+	}
+	return nil
+}
+
+func introspectAPIKey(ctx context.Context, store Store, token string) (oauth.Introspection, error) {
+	prefix, err := apikeys.ParsePrefix(token)
+	if err != nil {
+		return oauth.Introspection{}, ErrTokenNotActive
+	}
+	key, err := store.APIKeys().GetByPrefix(ctx, prefix)
+	if err != nil {
+		return oauth.Introspection{}, notActiveIfMissing(err)
+	}
+	if err = key.Verify(token, time.Now().UTC()); err != nil {
+		return oauth.Introspection{}, ErrTokenNotActive
+	}
+	usr, err := store.Users().Get(ctx, users.AnyTenant, key.UserID())
+	if err != nil {
+		return oauth.Introspection{}, notActiveIfMissing(err)
+	}
+	if usr.CanAuthenticate(time.Now().UTC()) != nil {
+		return oauth.Introspection{}, ErrTokenNotActive
+	}
+
+	res := oauth.Introspection{
+		Active:    true,
+		TokenID:   key.ID().String(),
+		TokenType: oauth.TokenTypeAPIKey,
+		Subject:   usr.ID().String(),
+		Scope:     key.Scope(),
+		Principal: users.PrincipalUser,
+		IssuedAt:  key.CreatedAt(),
+	}
+	if key.ExpiresAt() != nil {
+		res.ExpiresAt = *key.ExpiresAt()
+	}
+	return res, nil
+}
+
+// authenticateServiceClient сервисный аккаунт или конфиденциальный клиент OAuth
+func authenticateServiceClient(ctx context.Context, store Store, clientID string, clientSecret string) error {
+	if !serviceaccounts.IsClientID(clientID) {
+		client, err := authenticateClient(ctx, store, clientID, clientSecret)
+		if err != nil {
+			return err
+		}
+		if !client.IsConfidential() {
+			return oauth.ErrClientAuthFailed
+		}
+		return nil
+	}
+
+	account, err := store.ServiceAccounts().GetByClientID(ctx, clientID)
+	if errors.Is(err, serviceaccounts.ErrNotFound) {
+		return oauth.ErrClientAuthFailed
+	}
+	if err != nil {
+		return err
+	}
+	if err = account.Authenticate(clientSecret, time.Now().UTC()); err != nil {
+		return oauth.ErrClientAuthFailed
+	}
+	return nil
+}
+
+// notActiveIfMissing удаленный владелец токена делает токен недействующим, остальные ошибки отдаются как есть
+func notActiveIfMissing(err error) error {
+	if errors.Is(err, users.ErrUserNotFound) ||
+		errors.Is(err, serviceaccounts.ErrNotFound) ||
+		errors.Is(err, oauth.ErrClientNotFound) ||
+		errors.Is(err, apikeys.ErrNotFound) {
+		return ErrTokenNotActive
+	}
+	return err
+}
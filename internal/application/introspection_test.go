@@ -0,0 +1,178 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	mockapikeys "github.com/LeoUraltsev/auth-service/internal/domain/apikeys/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	mockoauth "github.com/LeoUraltsev/auth-service/internal/domain/oauth/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+type tokenVerifierFunc func(token string) (oauth.AccessToken, error)
+
+func (f tokenVerifierFunc) VerifyAccessToken(token string) (oauth.AccessToken, error) {
+	return f(token)
+}
+
+func TestIntrospectionServiceHandler_IntrospectToken(t *testing.T) {
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	active, _ := users.CreateUser(organizations.DefaultID, "name", email, pass)
+	blocked, _ := users.NewUser(uuid.New(), organizations.DefaultID, "blocked", email, pass, users.RoleUser, users.StatusDeleted, time.Now(), time.Now())
+	sessionID := uuid.New()
+
+	token := func(userID uuid.UUID) oauth.AccessToken {
+		return oauth.AccessToken{
+			ID:        "jti",
+			Subject:   userID.String(),
+			UserID:    userID,
+			ClientID:  "app",
+			Scope:     oauth.Scope{"openid"},
+			Principal: users.PrincipalUser,
+			SessionID: sessionID,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	type mocks struct {
+		users *mockusers.MockUserRepository
+		oauth *mockoauth.MockRepository
+	}
+
+	cases := []struct {
+		name       string
+		token      oauth.AccessToken
+		verifyErr  error
+		setup      func(m mocks)
+		wantActive bool
+	}{
+		{
+			name:  "active",
+			token: token(active.ID()),
+			setup: func(m mocks) {
+				m.users.EXPECT().Get(gomock.Any(), users.AnyTenant, active.ID()).Return(active, nil)
+				m.oauth.EXPECT().IsFamilyRevoked(gomock.Any(), sessionID).Return(false, nil)
+			},
+			wantActive: true,
+		},
+		{
+			name:  "session revoked",
+			token: token(active.ID()),
+			setup: func(m mocks) {
+				m.users.EXPECT().Get(gomock.Any(), users.AnyTenant, active.ID()).Return(active, nil)
+				m.oauth.EXPECT().IsFamilyRevoked(gomock.Any(), sessionID).Return(true, nil)
+			},
+		},
+		{
+			name:  "user blocked",
+			token: token(blocked.ID()),
+			setup: func(m mocks) {
+				m.users.EXPECT().Get(gomock.Any(), users.AnyTenant, blocked.ID()).Return(blocked, nil)
+			},
+		},
+		{
+			name:  "user deleted",
+			token: token(active.ID()),
+			setup: func(m mocks) {
+				m.users.EXPECT().Get(gomock.Any(), users.AnyTenant, active.ID()).Return(nil, users.ErrUserNotFound)
+			},
+		},
+		{
+			name:      "signature or expiry",
+			verifyErr: errors.New("token expired"),
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mocks{
+				users: mockusers.NewMockUserRepository(ctrl),
+				oauth: mockoauth.NewMockRepository(ctrl),
+			}
+			if tt.setup != nil {
+				tt.setup(m)
+			}
+			verifier := tokenVerifierFunc(func(string) (oauth.AccessToken, error) {
+				return tt.token, tt.verifyErr
+			})
+			service := NewIntrospectionService(testUnitOfWork{store: testStore{users: m.users, oauth: m.oauth}}, verifier, log)
+
+			ctx := authverify.NewContext(context.Background(), &authverify.Principal{Type: "service_account"})
+			res, err := service.IntrospectToken(ctx, "token")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantActive, res.Active)
+			if !tt.wantActive {
+				assert.Equal(t, oauth.Introspection{}, res)
+				return
+			}
+			assert.Equal(t, active.ID().String(), res.Subject)
+			assert.Equal(t, sessionID.String(), res.SessionID)
+			assert.Equal(t, oauth.TokenTypeBearer, res.TokenType)
+		})
+	}
+}
+
+func TestIntrospectionServiceHandler_IntrospectToken_apiKey(t *testing.T) {
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	user, _ := users.CreateUser(organizations.DefaultID, "name", email, pass)
+	key, value, err := apikeys.CreateAPIKey(user.ID(), "ci", oauth.Scope{"users.read"}, nil)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	repository := mockapikeys.NewMockRepository(ctrl)
+	repository.EXPECT().GetByPrefix(gomock.Any(), key.Prefix()).Return(key, nil)
+	userRepository := mockusers.NewMockUserRepository(ctrl)
+	userRepository.EXPECT().Get(gomock.Any(), users.AnyTenant, user.ID()).Return(user, nil)
+
+	service := NewIntrospectionService(testUnitOfWork{store: testStore{apiKeys: repository, users: userRepository}}, nil, log)
+	ctx := authverify.NewContext(context.Background(), &authverify.Principal{Type: "client"})
+	res, err := service.IntrospectToken(ctx, value)
+	require.NoError(t, err)
+	assert.True(t, res.Active)
+	assert.Equal(t, oauth.TokenTypeAPIKey, res.TokenType)
+	assert.Equal(t, oauth.Scope{"users.read"}, res.Scope)
+	assert.True(t, res.ExpiresAt.IsZero())
+}
+
+func TestIntrospectionServiceHandler_IntrospectToken_userDenied(t *testing.T) {
+	service := NewIntrospectionService(testUnitOfWork{}, nil, log)
+	_, err := service.IntrospectToken(context.Background(), "token")
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestIntrospectionServiceHandler_VerifySession(t *testing.T) {
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	usr, _ := users.CreateUser(organizations.DefaultID, "name", email, pass)
+	loggedOutAt := time.Now().UTC()
+	usr.ForceLogout(loggedOutAt)
+
+	ctrl := gomock.NewController(t)
+	userRepo := mockusers.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().Get(gomock.Any(), users.AnyTenant, usr.ID()).Return(usr, nil).Times(2)
+	service := NewIntrospectionService(testUnitOfWork{store: testStore{users: userRepo}}, nil, log)
+
+	token := oauth.AccessToken{
+		UserID:    usr.ID(),
+		Principal: users.PrincipalUser,
+		IssuedAt:  loggedOutAt.Add(-time.Minute),
+		ExpiresAt: loggedOutAt.Add(time.Hour),
+	}
+	assert.ErrorIs(t, service.VerifySession(context.Background(), token), ErrTokenNotActive)
+
+	token.IssuedAt = loggedOutAt.Add(time.Second)
+	assert.NoError(t, service.VerifySession(context.Background(), token))
+}
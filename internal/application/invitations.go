@@ -0,0 +1,261 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/domain/invitations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+// InvitationService приглашения в организацию. Приглашают admin организации, принять может любой с кодом
+type InvitationService interface {
+	// CreateInvitation возвращает приглашение и код, код показывается один раз
+	CreateInvitation(ctx context.Context, email string, role string) (*invitations.Invitation, string, error)
+	// ListInvitations приглашения организации, которые еще можно принять
+	ListInvitations(ctx context.Context, limit int, offset int) ([]*invitations.Invitation, error)
+	RevokeInvitation(ctx context.Context, id uuid.UUID) error
+	// AcceptInvitation создает пользователя с ролью из приглашения. Если пользователь с этим email
+	// уже есть в организации, password должен быть его паролем, аккаунт получает роль из приглашения
+	AcceptInvitation(ctx context.Context, code string, name string, password string) (uuid.UUID, error)
+}
+
+type InvitationServiceHandler struct {
+	uof   UnitOfWork
+	users *UserServiceHandler
+	ttl   time.Duration
+	log   *slog.Logger
+}
+
+func NewInvitationService(uof UnitOfWork, userService *UserServiceHandler, ttl time.Duration, log *slog.Logger) *InvitationServiceHandler {
+	return &InvitationServiceHandler{
+		uof:   uof,
+		users: userService,
+		ttl:   ttl,
+		log:   log,
+	}
+}
+
+func (s *InvitationServiceHandler) CreateInvitation(ctx context.Context, email string, role string) (*invitations.Invitation, string, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("creating invitation")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to create invitation", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+	invitedBy, err := userIDFromContext(ctx)
+	if err != nil {
+		log.Warn("failed to create invitation", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+	e, err := users.NewEmail(email)
+	if err != nil {
+		log.Warn("failed to create invitation", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+	r, err := users.NewRole(role)
+	if err != nil {
+		log.Warn("failed to create invitation", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+
+	var invitation *invitations.Invitation
+	var code string
+	entry := auditEntry{action: audit.ActionInvitationCreated, targetType: audit.TargetInvitation}
+	err = s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		invitation, code, err = invitations.CreateInvitation(tenantID, e, r, invitedBy, s.ttl)
+		if err != nil {
+			return err
+		}
+		entry.targetID = invitation.ID().String()
+		if err = store.Invitations().Save(ctx, invitation); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to create invitation", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return nil, "", err
+	}
+
+	log.Info("invitation created", slog.String("invitation_id", invitation.ID().String()))
+	return invitation, code, nil
+}
+
+func (s *InvitationServiceHandler) ListInvitations(ctx context.Context, limit int, offset int) ([]*invitations.Invitation, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting invitations")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to get invitations", slog.String("error", err.Error()))
+		return nil, err
+	}
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var list []*invitations.Invitation
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		list, err = store.Invitations().ListPending(ctx, tenantID, time.Now().UTC(), limit, offset)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get invitations", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *InvitationServiceHandler) RevokeInvitation(ctx context.Context, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("invitation_id", id.String()))
+	log.Info("revoking invitation")
+
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to revoke invitation", slog.String("error", err.Error()))
+		return err
+	}
+
+	entry := invitationAudit(audit.ActionInvitationRevoked, id)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		invitation, err := store.Invitations().Get(ctx, tenantID, id)
+		if err != nil {
+			return err
+		}
+		if err = invitation.Revoke(time.Now().UTC()); err != nil {
+			return err
+		}
+		if err = store.Invitations().Save(ctx, invitation); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to revoke invitation", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	log.Info("invitation revoked")
+	return nil
+}
+
+func (s *InvitationServiceHandler) AcceptInvitation(ctx context.Context, code string, name string, password string) (uuid.UUID, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("accepting invitation")
+
+	if err := invitations.ValidCode(code); err != nil {
+		log.Warn("failed to accept invitation", slog.String("error", err.Error()))
+		return uuid.Nil, err
+	}
+
+	var user *users.User
+	entry := auditEntry{action: audit.ActionInvitationAccepted, targetType: audit.TargetInvitation}
+	err := s.uof.Execute(ctx, func(store Store) error {
+		invitation, err := store.Invitations().GetByCodeHash(ctx, invitations.HashCode(code))
+		if errors.Is(err, invitations.ErrNotFound) {
+			return invitations.ErrCodeNotValid
+		}
+		if err != nil {
+			return err
+		}
+		entry.targetID = invitation.ID().String()
+		now := time.Now().UTC()
+		if status := invitation.Status(now); status != invitations.StatusPending {
+			if status == invitations.StatusExpired {
+				return invitations.ErrExpired
+			}
+			return invitations.ErrNotPending
+		}
+
+		user, err = store.Users().GetByEmail(ctx, invitation.TenantID(), invitation.Email())
+		switch {
+		case errors.Is(err, users.ErrUserNotFound):
+			user, err = s.users.createUser(ctx, store, invitation.TenantID(), name, invitation.Email().String(), password, invitation.Role())
+			if err != nil {
+				return err
+			}
+			created := userAudit(audit.ActionUserCreated, user.ID())
+			created.actorID = user.ID()
+			if err = recordAudit(ctx, store, created, nil); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			if err = s.linkUser(ctx, store, user, password, invitation.Role()); err != nil {
+				return err
+			}
+		}
+
+		if err = invitation.Accept(user.ID(), now); err != nil {
+			return err
+		}
+		if err = store.Invitations().Save(ctx, invitation); err != nil {
+			return err
+		}
+		entry.actorID = user.ID()
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to accept invitation", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return uuid.Nil, err
+	}
+
+	log.Info("invitation accepted", slog.String("user_id", user.ID().String()))
+	return user.ID(), nil
+}
+
+// linkUser принимает приглашение существующим аккаунтом: владелец подтверждает его паролем
+func (s *InvitationServiceHandler) linkUser(ctx context.Context, store Store, user *users.User, password string, role users.Role) error {
+	if !user.IsActive() {
+		return users.ErrInvalidCredentials
+	}
+	verify, err := s.users.passwordVerifier.Verify(user.Password().Hash(), []byte(password))
+	if err != nil {
+		return err
+	}
+	if !verify {
+		return users.ErrInvalidCredentials
+	}
+	if user.Role() == role {
+		return nil
+	}
+	if err = user.UpdateRole(role); err != nil {
+		return err
+	}
+	if err = store.Users().Save(ctx, user); err != nil {
+		return err
+	}
+	return publishUserChange(ctx, store, changes.TypeUpdated, user)
+}
+
+func invitationAudit(action audit.Action, id uuid.UUID) auditEntry {
+	return auditEntry{
+		action:     action,
+		targetType: audit.TargetInvitation,
+		targetID:   id.String(),
+	}
+}
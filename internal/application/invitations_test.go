@@ -0,0 +1,157 @@
+package application
+
+import (
+	"context"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	mockchanges "github.com/LeoUraltsev/auth-service/internal/domain/changes/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/invitations"
+	mockinvitations "github.com/LeoUraltsev/auth-service/internal/domain/invitations/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	mockwebhooks "github.com/LeoUraltsev/auth-service/internal/domain/webhooks/mocks"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestInvitationServiceHandler_CreateInvitation(t *testing.T) {
+	adminID := uuid.New()
+	admin := authverify.NewContext(context.Background(), &authverify.Principal{UserID: adminID, Role: "admin", TenantID: organizations.DefaultID})
+	user := authverify.NewContext(context.Background(), &authverify.Principal{UserID: uuid.New(), Role: "user", TenantID: organizations.DefaultID})
+
+	ctrl := gomock.NewController(t)
+	repository := mockinvitations.NewMockRepository(ctrl)
+	repository.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, i *invitations.Invitation) error {
+		assert.Equal(t, organizations.DefaultID, i.TenantID())
+		assert.Equal(t, adminID, i.InvitedBy())
+		assert.Equal(t, users.RoleAdmin, i.Role())
+		return nil
+	})
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	service := NewInvitationService(testUnitOfWork{store: testStore{invites: repository, audit: auditRepository}}, nil, time.Hour, log)
+
+	_, _, err := service.CreateInvitation(user, "new@example.com", "user")
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	_, _, err = service.CreateInvitation(admin, "new@example.com", "root")
+	assert.ErrorIs(t, err, users.ErrRoleNotValid)
+
+	invitation, code, err := service.CreateInvitation(admin, "new@example.com", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, invitations.HashCode(code), invitation.CodeHash())
+}
+
+func TestInvitationServiceHandler_AcceptInvitation(t *testing.T) {
+	tenantID := uuid.New()
+	email, _ := users.NewEmail("invited@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	existing, err := users.CreateUser(tenantID, "name", email, pass)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		exists   bool
+		verified bool
+		revoked  bool
+		wantErr  error
+	}{
+		{name: "new user"},
+		{name: "existing user", exists: true, verified: true},
+		{name: "existing user wrong password", exists: true, wantErr: users.ErrInvalidCredentials},
+		{name: "revoked", revoked: true, wantErr: invitations.ErrNotPending},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			invitation, code, err := invitations.CreateInvitation(tenantID, email, users.RoleAdmin, uuid.New(), time.Hour)
+			require.NoError(t, err)
+			if tt.revoked {
+				require.NoError(t, invitation.Revoke(time.Now().UTC()))
+			}
+
+			ctrl := gomock.NewController(t)
+			repository := mockinvitations.NewMockRepository(ctrl)
+			repository.EXPECT().GetByCodeHash(gomock.Any(), invitations.HashCode(code)).Return(invitation, nil)
+			if tt.wantErr == nil {
+				repository.EXPECT().Save(gomock.Any(), invitation).Return(nil)
+			}
+
+			userRepository := mockusers.NewMockUserRepository(ctrl)
+			if tt.exists {
+				userRepository.EXPECT().GetByEmail(gomock.Any(), tenantID, email).Return(existing, nil)
+			} else {
+				userRepository.EXPECT().GetByEmail(gomock.Any(), tenantID, email).Return(nil, users.ErrUserNotFound).AnyTimes()
+				userRepository.EXPECT().ExistsByEmail(gomock.Any(), tenantID, email).Return(false, nil).AnyTimes()
+			}
+			userRepository.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *users.User) error {
+				assert.Equal(t, tenantID, u.TenantID())
+				assert.Equal(t, users.RoleAdmin, u.Role())
+				return nil
+			}).AnyTimes()
+
+			passwordHasher := mockusers.NewMockPasswordHasher(ctrl)
+			passwordHasher.EXPECT().Hash(gomock.Any()).Return([]byte("hashpassword"), nil).AnyTimes()
+			passwordVerifier := mockusers.NewMockPasswordVerifier(ctrl)
+			passwordVerifier.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(tt.verified, nil).AnyTimes()
+
+			changeRepository := mockchanges.NewMockRepository(ctrl)
+			changeRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			webhookRepository := mockwebhooks.NewMockRepository(ctrl)
+			webhookRepository.EXPECT().ListSubscriptionsByEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			uof := testUnitOfWork{store: testStore{
+				users:    userRepository,
+				invites:  repository,
+				changes:  changeRepository,
+				webhooks: webhookRepository,
+				audit:    auditRepository,
+			}}
+			userService := NewUserService(uof, passwordHasher, passwordVerifier, nil, log)
+			service := NewInvitationService(uof, userService, time.Hour, log)
+
+			userID, err := service.AcceptInvitation(context.Background(), code, "name", "password")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.exists {
+				assert.Equal(t, existing.ID(), userID)
+				assert.Equal(t, users.RoleAdmin, existing.Role())
+			}
+			assert.Equal(t, userID, invitation.AcceptedBy())
+		})
+	}
+}
+
+func TestInvitationServiceHandler_AcceptInvitation_unknownCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repository := mockinvitations.NewMockRepository(ctrl)
+	repository.EXPECT().GetByCodeHash(gomock.Any(), gomock.Any()).Return(nil, invitations.ErrNotFound)
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	service := NewInvitationService(testUnitOfWork{store: testStore{invites: repository, audit: auditRepository}}, nil, time.Hour, log)
+
+	_, err := service.AcceptInvitation(context.Background(), "malformed", "name", "password")
+	assert.ErrorIs(t, err, invitations.ErrCodeNotValid)
+	_, err = service.AcceptInvitation(context.Background(), invitations.CodePrefix+"unknown", "name", "password")
+	assert.ErrorIs(t, err, invitations.ErrCodeNotValid)
+}
+
+func TestUserServiceHandler_CreateUser_inviteOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repository := mockusers.NewMockUserRepository(ctrl)
+
+	service := NewUserService(testUnitOfWork{store: testStore{users: repository}}, nil, nil, nil, log).WithInviteOnly(true)
+	_, err := service.CreateUser(context.Background(), "name", "user@example.com", "password")
+	assert.ErrorIs(t, err, ErrRegistrationClosed)
+}
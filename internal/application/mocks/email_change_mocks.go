@@ -0,0 +1,83 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./email_change.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./email_change.go -destination=./mocks/email_change_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEmailChangeService is a mock of EmailChangeService interface.
+type MockEmailChangeService struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailChangeServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockEmailChangeServiceMockRecorder is the mock recorder for MockEmailChangeService.
+type MockEmailChangeServiceMockRecorder struct {
+	mock *MockEmailChangeService
+}
+
+// NewMockEmailChangeService creates a new mock instance.
+func NewMockEmailChangeService(ctrl *gomock.Controller) *MockEmailChangeService {
+	mock := &MockEmailChangeService{ctrl: ctrl}
+	mock.recorder = &MockEmailChangeServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailChangeService) EXPECT() *MockEmailChangeServiceMockRecorder {
+	return m.recorder
+}
+
+// CancelEmailChange mocks base method.
+func (m *MockEmailChangeService) CancelEmailChange(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelEmailChange", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelEmailChange indicates an expected call of CancelEmailChange.
+func (mr *MockEmailChangeServiceMockRecorder) CancelEmailChange(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelEmailChange", reflect.TypeOf((*MockEmailChangeService)(nil).CancelEmailChange), ctx, token)
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockEmailChangeService) ConfirmEmailChange(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockEmailChangeServiceMockRecorder) ConfirmEmailChange(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockEmailChangeService)(nil).ConfirmEmailChange), ctx, token)
+}
+
+// RequestEmailChange mocks base method.
+func (m *MockEmailChangeService) RequestEmailChange(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailChange", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestEmailChange indicates an expected call of RequestEmailChange.
+func (mr *MockEmailChangeServiceMockRecorder) RequestEmailChange(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailChange", reflect.TypeOf((*MockEmailChangeService)(nil).RequestEmailChange), ctx, email)
+}
@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./exports.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./exports.go -destination=./mocks/exports_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+
+	exports "github.com/LeoUraltsev/auth-service/internal/domain/exports"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDataExportService is a mock of DataExportService interface.
+type MockDataExportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDataExportServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDataExportServiceMockRecorder is the mock recorder for MockDataExportService.
+type MockDataExportServiceMockRecorder struct {
+	mock *MockDataExportService
+}
+
+// NewMockDataExportService creates a new mock instance.
+func NewMockDataExportService(ctrl *gomock.Controller) *MockDataExportService {
+	mock := &MockDataExportService{ctrl: ctrl}
+	mock.recorder = &MockDataExportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDataExportService) EXPECT() *MockDataExportServiceMockRecorder {
+	return m.recorder
+}
+
+// ExportMyData mocks base method.
+func (m *MockDataExportService) ExportMyData(ctx context.Context) (*exports.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportMyData", ctx)
+	ret0, _ := ret[0].(*exports.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportMyData indicates an expected call of ExportMyData.
+func (mr *MockDataExportServiceMockRecorder) ExportMyData(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportMyData", reflect.TypeOf((*MockDataExportService)(nil).ExportMyData), ctx)
+}
+
+// ExportUserData mocks base method.
+func (m *MockDataExportService) ExportUserData(ctx context.Context, userID uuid.UUID) (*exports.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, userID)
+	ret0, _ := ret[0].(*exports.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockDataExportServiceMockRecorder) ExportUserData(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockDataExportService)(nil).ExportUserData), ctx, userID)
+}
+
+// GetExportJob mocks base method.
+func (m *MockDataExportService) GetExportJob(ctx context.Context, id uuid.UUID) (*exports.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExportJob", ctx, id)
+	ret0, _ := ret[0].(*exports.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExportJob indicates an expected call of GetExportJob.
+func (mr *MockDataExportServiceMockRecorder) GetExportJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExportJob", reflect.TypeOf((*MockDataExportService)(nil).GetExportJob), ctx, id)
+}
@@ -0,0 +1,119 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./federation.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./federation.go -destination=./mocks/federation_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+
+	identity "github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	oauth "github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFederationService is a mock of FederationService interface.
+type MockFederationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockFederationServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockFederationServiceMockRecorder is the mock recorder for MockFederationService.
+type MockFederationServiceMockRecorder struct {
+	mock *MockFederationService
+}
+
+// NewMockFederationService creates a new mock instance.
+func NewMockFederationService(ctrl *gomock.Controller) *MockFederationService {
+	mock := &MockFederationService{ctrl: ctrl}
+	mock.recorder = &MockFederationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFederationService) EXPECT() *MockFederationServiceMockRecorder {
+	return m.recorder
+}
+
+// CompleteLogin mocks base method.
+func (m *MockFederationService) CompleteLogin(ctx context.Context, provider, state, code string) (oauth.AuthorizationRequest, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteLogin", ctx, provider, state, code)
+	ret0, _ := ret[0].(oauth.AuthorizationRequest)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CompleteLogin indicates an expected call of CompleteLogin.
+func (mr *MockFederationServiceMockRecorder) CompleteLogin(ctx, provider, state, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteLogin", reflect.TypeOf((*MockFederationService)(nil).CompleteLogin), ctx, provider, state, code)
+}
+
+// ListIdentities mocks base method.
+func (m *MockFederationService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]*identity.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIdentities", ctx, userID)
+	ret0, _ := ret[0].([]*identity.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIdentities indicates an expected call of ListIdentities.
+func (mr *MockFederationServiceMockRecorder) ListIdentities(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIdentities", reflect.TypeOf((*MockFederationService)(nil).ListIdentities), ctx, userID)
+}
+
+// Providers mocks base method.
+func (m *MockFederationService) Providers() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Providers")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Providers indicates an expected call of Providers.
+func (mr *MockFederationServiceMockRecorder) Providers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Providers", reflect.TypeOf((*MockFederationService)(nil).Providers))
+}
+
+// StartLogin mocks base method.
+func (m *MockFederationService) StartLogin(ctx context.Context, provider string, req oauth.AuthorizationRequest) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartLogin", ctx, provider, req)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartLogin indicates an expected call of StartLogin.
+func (mr *MockFederationServiceMockRecorder) StartLogin(ctx, provider, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartLogin", reflect.TypeOf((*MockFederationService)(nil).StartLogin), ctx, provider, req)
+}
+
+// UnlinkIdentity mocks base method.
+func (m *MockFederationService) UnlinkIdentity(ctx context.Context, userID, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlinkIdentity", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlinkIdentity indicates an expected call of UnlinkIdentity.
+func (mr *MockFederationServiceMockRecorder) UnlinkIdentity(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlinkIdentity", reflect.TypeOf((*MockFederationService)(nil).UnlinkIdentity), ctx, userID, id)
+}
@@ -0,0 +1,175 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./groups.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./groups.go -destination=./mocks/groups_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+
+	groups "github.com/LeoUraltsev/auth-service/internal/domain/groups"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockGroupService is a mock of GroupService interface.
+type MockGroupService struct {
+	ctrl     *gomock.Controller
+	recorder *MockGroupServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockGroupServiceMockRecorder is the mock recorder for MockGroupService.
+type MockGroupServiceMockRecorder struct {
+	mock *MockGroupService
+}
+
+// NewMockGroupService creates a new mock instance.
+func NewMockGroupService(ctrl *gomock.Controller) *MockGroupService {
+	mock := &MockGroupService{ctrl: ctrl}
+	mock.recorder = &MockGroupServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGroupService) EXPECT() *MockGroupServiceMockRecorder {
+	return m.recorder
+}
+
+// AddGroupMember mocks base method.
+func (m *MockGroupService) AddGroupMember(ctx context.Context, groupID uuid.UUID, member groups.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGroupMember", ctx, groupID, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddGroupMember indicates an expected call of AddGroupMember.
+func (mr *MockGroupServiceMockRecorder) AddGroupMember(ctx, groupID, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupMember", reflect.TypeOf((*MockGroupService)(nil).AddGroupMember), ctx, groupID, member)
+}
+
+// CreateGroup mocks base method.
+func (m *MockGroupService) CreateGroup(ctx context.Context, name, description string) (*groups.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, name, description)
+	ret0, _ := ret[0].(*groups.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockGroupServiceMockRecorder) CreateGroup(ctx, name, description any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockGroupService)(nil).CreateGroup), ctx, name, description)
+}
+
+// DeleteGroup mocks base method.
+func (m *MockGroupService) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroup", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroup indicates an expected call of DeleteGroup.
+func (mr *MockGroupServiceMockRecorder) DeleteGroup(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockGroupService)(nil).DeleteGroup), ctx, id)
+}
+
+// GetGroup mocks base method.
+func (m *MockGroupService) GetGroup(ctx context.Context, id uuid.UUID) (*groups.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", ctx, id)
+	ret0, _ := ret[0].(*groups.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockGroupServiceMockRecorder) GetGroup(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockGroupService)(nil).GetGroup), ctx, id)
+}
+
+// ListGroupMembers mocks base method.
+func (m *MockGroupService) ListGroupMembers(ctx context.Context, groupID uuid.UUID, limit, offset int) ([]groups.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroupMembers", ctx, groupID, limit, offset)
+	ret0, _ := ret[0].([]groups.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroupMembers indicates an expected call of ListGroupMembers.
+func (mr *MockGroupServiceMockRecorder) ListGroupMembers(ctx, groupID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroupMembers", reflect.TypeOf((*MockGroupService)(nil).ListGroupMembers), ctx, groupID, limit, offset)
+}
+
+// ListGroups mocks base method.
+func (m *MockGroupService) ListGroups(ctx context.Context, limit, offset int) ([]*groups.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroups", ctx, limit, offset)
+	ret0, _ := ret[0].([]*groups.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockGroupServiceMockRecorder) ListGroups(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockGroupService)(nil).ListGroups), ctx, limit, offset)
+}
+
+// ListUserGroups mocks base method.
+func (m *MockGroupService) ListUserGroups(ctx context.Context, userID uuid.UUID) ([]*groups.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserGroups", ctx, userID)
+	ret0, _ := ret[0].([]*groups.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserGroups indicates an expected call of ListUserGroups.
+func (mr *MockGroupServiceMockRecorder) ListUserGroups(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserGroups", reflect.TypeOf((*MockGroupService)(nil).ListUserGroups), ctx, userID)
+}
+
+// RemoveGroupMember mocks base method.
+func (m *MockGroupService) RemoveGroupMember(ctx context.Context, groupID uuid.UUID, member groups.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveGroupMember", ctx, groupID, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveGroupMember indicates an expected call of RemoveGroupMember.
+func (mr *MockGroupServiceMockRecorder) RemoveGroupMember(ctx, groupID, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveGroupMember", reflect.TypeOf((*MockGroupService)(nil).RemoveGroupMember), ctx, groupID, member)
+}
+
+// UpdateGroup mocks base method.
+func (m *MockGroupService) UpdateGroup(ctx context.Context, id uuid.UUID, name, description string) (*groups.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGroup", ctx, id, name, description)
+	ret0, _ := ret[0].(*groups.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateGroup indicates an expected call of UpdateGroup.
+func (mr *MockGroupServiceMockRecorder) UpdateGroup(ctx, id, name, description any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroup", reflect.TypeOf((*MockGroupService)(nil).UpdateGroup), ctx, id, name, description)
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./introspection.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./introspection.go -destination=./mocks/introspection_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+
+	oauth "github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIntrospectionService is a mock of IntrospectionService interface.
+type MockIntrospectionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIntrospectionServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockIntrospectionServiceMockRecorder is the mock recorder for MockIntrospectionService.
+type MockIntrospectionServiceMockRecorder struct {
+	mock *MockIntrospectionService
+}
+
+// NewMockIntrospectionService creates a new mock instance.
+func NewMockIntrospectionService(ctrl *gomock.Controller) *MockIntrospectionService {
+	mock := &MockIntrospectionService{ctrl: ctrl}
+	mock.recorder = &MockIntrospectionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIntrospectionService) EXPECT() *MockIntrospectionServiceMockRecorder {
+	return m.recorder
+}
+
+// Introspect mocks base method.
+func (m *MockIntrospectionService) Introspect(ctx context.Context, clientID, clientSecret, token string) (oauth.Introspection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect", ctx, clientID, clientSecret, token)
+	ret0, _ := ret[0].(oauth.Introspection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockIntrospectionServiceMockRecorder) Introspect(ctx, clientID, clientSecret, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockIntrospectionService)(nil).Introspect), ctx, clientID, clientSecret, token)
+}
+
+// IntrospectToken mocks base method.
+func (m *MockIntrospectionService) IntrospectToken(ctx context.Context, token string) (oauth.Introspection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IntrospectToken", ctx, token)
+	ret0, _ := ret[0].(oauth.Introspection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IntrospectToken indicates an expected call of IntrospectToken.
+func (mr *MockIntrospectionServiceMockRecorder) IntrospectToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IntrospectToken", reflect.TypeOf((*MockIntrospectionService)(nil).IntrospectToken), ctx, token)
+}
+
+// VerifySession mocks base method.
+func (m *MockIntrospectionService) VerifySession(ctx context.Context, token oauth.AccessToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySession", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifySession indicates an expected call of VerifySession.
+func (mr *MockIntrospectionServiceMockRecorder) VerifySession(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySession", reflect.TypeOf((*MockIntrospectionService)(nil).VerifySession), ctx, token)
+}
@@ -0,0 +1,103 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./invitations.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./invitations.go -destination=./mocks/invitations_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+
+	invitations "github.com/LeoUraltsev/auth-service/internal/domain/invitations"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInvitationService is a mock of InvitationService interface.
+type MockInvitationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockInvitationServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockInvitationServiceMockRecorder is the mock recorder for MockInvitationService.
+type MockInvitationServiceMockRecorder struct {
+	mock *MockInvitationService
+}
+
+// NewMockInvitationService creates a new mock instance.
+func NewMockInvitationService(ctrl *gomock.Controller) *MockInvitationService {
+	mock := &MockInvitationService{ctrl: ctrl}
+	mock.recorder = &MockInvitationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvitationService) EXPECT() *MockInvitationServiceMockRecorder {
+	return m.recorder
+}
+
+// AcceptInvitation mocks base method.
+func (m *MockInvitationService) AcceptInvitation(ctx context.Context, code, name, password string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvitation", ctx, code, name, password)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptInvitation indicates an expected call of AcceptInvitation.
+func (mr *MockInvitationServiceMockRecorder) AcceptInvitation(ctx, code, name, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvitation", reflect.TypeOf((*MockInvitationService)(nil).AcceptInvitation), ctx, code, name, password)
+}
+
+// CreateInvitation mocks base method.
+func (m *MockInvitationService) CreateInvitation(ctx context.Context, email, role string) (*invitations.Invitation, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvitation", ctx, email, role)
+	ret0, _ := ret[0].(*invitations.Invitation)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateInvitation indicates an expected call of CreateInvitation.
+func (mr *MockInvitationServiceMockRecorder) CreateInvitation(ctx, email, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvitation", reflect.TypeOf((*MockInvitationService)(nil).CreateInvitation), ctx, email, role)
+}
+
+// ListInvitations mocks base method.
+func (m *MockInvitationService) ListInvitations(ctx context.Context, limit, offset int) ([]*invitations.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvitations", ctx, limit, offset)
+	ret0, _ := ret[0].([]*invitations.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvitations indicates an expected call of ListInvitations.
+func (mr *MockInvitationServiceMockRecorder) ListInvitations(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvitations", reflect.TypeOf((*MockInvitationService)(nil).ListInvitations), ctx, limit, offset)
+}
+
+// RevokeInvitation mocks base method.
+func (m *MockInvitationService) RevokeInvitation(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeInvitation", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeInvitation indicates an expected call of RevokeInvitation.
+func (mr *MockInvitationServiceMockRecorder) RevokeInvitation(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInvitation", reflect.TypeOf((*MockInvitationService)(nil).RevokeInvitation), ctx, id)
+}
@@ -0,0 +1,177 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./oauth.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./oauth.go -destination=./mocks/oauth_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+
+	oauth "github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOAuthService is a mock of OAuthService interface.
+type MockOAuthService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockOAuthServiceMockRecorder is the mock recorder for MockOAuthService.
+type MockOAuthServiceMockRecorder struct {
+	mock *MockOAuthService
+}
+
+// NewMockOAuthService creates a new mock instance.
+func NewMockOAuthService(ctrl *gomock.Controller) *MockOAuthService {
+	mock := &MockOAuthService{ctrl: ctrl}
+	mock.recorder = &MockOAuthServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthService) EXPECT() *MockOAuthServiceMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockOAuthService) Authorize(ctx context.Context, req oauth.AuthorizationRequest, email, password string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, req, email, password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockOAuthServiceMockRecorder) Authorize(ctx, req, email, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockOAuthService)(nil).Authorize), ctx, req, email, password)
+}
+
+// CreateClient mocks base method.
+func (m *MockOAuthService) CreateClient(ctx context.Context, name string, redirectURIs, grantTypes, scope []string, confidential bool) (*oauth.Client, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClient", ctx, name, redirectURIs, grantTypes, scope, confidential)
+	ret0, _ := ret[0].(*oauth.Client)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateClient indicates an expected call of CreateClient.
+func (mr *MockOAuthServiceMockRecorder) CreateClient(ctx, name, redirectURIs, grantTypes, scope, confidential any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClient", reflect.TypeOf((*MockOAuthService)(nil).CreateClient), ctx, name, redirectURIs, grantTypes, scope, confidential)
+}
+
+// DeleteClient mocks base method.
+func (m *MockOAuthService) DeleteClient(ctx context.Context, clientID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteClient", ctx, clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteClient indicates an expected call of DeleteClient.
+func (mr *MockOAuthServiceMockRecorder) DeleteClient(ctx, clientID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteClient", reflect.TypeOf((*MockOAuthService)(nil).DeleteClient), ctx, clientID)
+}
+
+// ListClients mocks base method.
+func (m *MockOAuthService) ListClients(ctx context.Context, limit, offset int) ([]*oauth.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClients", ctx, limit, offset)
+	ret0, _ := ret[0].([]*oauth.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClients indicates an expected call of ListClients.
+func (mr *MockOAuthServiceMockRecorder) ListClients(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClients", reflect.TypeOf((*MockOAuthService)(nil).ListClients), ctx, limit, offset)
+}
+
+// Revoke mocks base method.
+func (m *MockOAuthService) Revoke(ctx context.Context, clientID, clientSecret, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, clientID, clientSecret, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockOAuthServiceMockRecorder) Revoke(ctx, clientID, clientSecret, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockOAuthService)(nil).Revoke), ctx, clientID, clientSecret, token)
+}
+
+// SessionLogin mocks base method.
+func (m *MockOAuthService) SessionLogin(ctx context.Context, email, password string) (*oauth.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SessionLogin", ctx, email, password)
+	ret0, _ := ret[0].(*oauth.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SessionLogin indicates an expected call of SessionLogin.
+func (mr *MockOAuthServiceMockRecorder) SessionLogin(ctx, email, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SessionLogin", reflect.TypeOf((*MockOAuthService)(nil).SessionLogin), ctx, email, password)
+}
+
+// Token mocks base method.
+func (m *MockOAuthService) Token(ctx context.Context, req oauth.TokenRequest) (*oauth.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Token", ctx, req)
+	ret0, _ := ret[0].(*oauth.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Token indicates an expected call of Token.
+func (mr *MockOAuthServiceMockRecorder) Token(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockOAuthService)(nil).Token), ctx, req)
+}
+
+// UserInfo mocks base method.
+func (m *MockOAuthService) UserInfo(ctx context.Context) (oauth.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserInfo", ctx)
+	ret0, _ := ret[0].(oauth.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserInfo indicates an expected call of UserInfo.
+func (mr *MockOAuthServiceMockRecorder) UserInfo(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserInfo", reflect.TypeOf((*MockOAuthService)(nil).UserInfo), ctx)
+}
+
+// ValidateAuthorization mocks base method.
+func (m *MockOAuthService) ValidateAuthorization(ctx context.Context, req oauth.AuthorizationRequest) (*oauth.Client, oauth.Scope, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateAuthorization", ctx, req)
+	ret0, _ := ret[0].(*oauth.Client)
+	ret1, _ := ret[1].(oauth.Scope)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ValidateAuthorization indicates an expected call of ValidateAuthorization.
+func (mr *MockOAuthServiceMockRecorder) ValidateAuthorization(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateAuthorization", reflect.TypeOf((*MockOAuthService)(nil).ValidateAuthorization), ctx, req)
+}
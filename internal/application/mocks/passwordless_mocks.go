@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./passwordless.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./passwordless.go -destination=./mocks/passwordless_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPasswordlessService is a mock of PasswordlessService interface.
+type MockPasswordlessService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordlessServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPasswordlessServiceMockRecorder is the mock recorder for MockPasswordlessService.
+type MockPasswordlessServiceMockRecorder struct {
+	mock *MockPasswordlessService
+}
+
+// NewMockPasswordlessService creates a new mock instance.
+func NewMockPasswordlessService(ctrl *gomock.Controller) *MockPasswordlessService {
+	mock := &MockPasswordlessService{ctrl: ctrl}
+	mock.recorder = &MockPasswordlessServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordlessService) EXPECT() *MockPasswordlessServiceMockRecorder {
+	return m.recorder
+}
+
+// CompletePasswordlessLogin mocks base method.
+func (m *MockPasswordlessService) CompletePasswordlessLogin(ctx context.Context, link, email, code string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompletePasswordlessLogin", ctx, link, email, code)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompletePasswordlessLogin indicates an expected call of CompletePasswordlessLogin.
+func (mr *MockPasswordlessServiceMockRecorder) CompletePasswordlessLogin(ctx, link, email, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompletePasswordlessLogin", reflect.TypeOf((*MockPasswordlessService)(nil).CompletePasswordlessLogin), ctx, link, email, code)
+}
+
+// StartPasswordlessLogin mocks base method.
+func (m *MockPasswordlessService) StartPasswordlessLogin(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartPasswordlessLogin", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartPasswordlessLogin indicates an expected call of StartPasswordlessLogin.
+func (mr *MockPasswordlessServiceMockRecorder) StartPasswordlessLogin(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartPasswordlessLogin", reflect.TypeOf((*MockPasswordlessService)(nil).StartPasswordlessLogin), ctx, email)
+}
@@ -0,0 +1,120 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./relations.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./relations.go -destination=./mocks/relations_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+
+	relations "github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRelationshipService is a mock of RelationshipService interface.
+type MockRelationshipService struct {
+	ctrl     *gomock.Controller
+	recorder *MockRelationshipServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockRelationshipServiceMockRecorder is the mock recorder for MockRelationshipService.
+type MockRelationshipServiceMockRecorder struct {
+	mock *MockRelationshipService
+}
+
+// NewMockRelationshipService creates a new mock instance.
+func NewMockRelationshipService(ctrl *gomock.Controller) *MockRelationshipService {
+	mock := &MockRelationshipService{ctrl: ctrl}
+	mock.recorder = &MockRelationshipServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRelationshipService) EXPECT() *MockRelationshipServiceMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockRelationshipService) Check(ctx context.Context, object relations.Object, relation string, subject relations.Subject, token string) (bool, relations.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, object, relation, subject, token)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(relations.Revision)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockRelationshipServiceMockRecorder) Check(ctx, object, relation, subject, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockRelationshipService)(nil).Check), ctx, object, relation, subject, token)
+}
+
+// DeleteRelationships mocks base method.
+func (m *MockRelationshipService) DeleteRelationships(ctx context.Context, tuples []relations.Tuple) (relations.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRelationships", ctx, tuples)
+	ret0, _ := ret[0].(relations.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRelationships indicates an expected call of DeleteRelationships.
+func (mr *MockRelationshipServiceMockRecorder) DeleteRelationships(ctx, tuples any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRelationships", reflect.TypeOf((*MockRelationshipService)(nil).DeleteRelationships), ctx, tuples)
+}
+
+// Expand mocks base method.
+func (m *MockRelationshipService) Expand(ctx context.Context, object relations.Object, relation, token string) (*relations.Node, relations.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Expand", ctx, object, relation, token)
+	ret0, _ := ret[0].(*relations.Node)
+	ret1, _ := ret[1].(relations.Revision)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Expand indicates an expected call of Expand.
+func (mr *MockRelationshipServiceMockRecorder) Expand(ctx, object, relation, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Expand", reflect.TypeOf((*MockRelationshipService)(nil).Expand), ctx, object, relation, token)
+}
+
+// LookupResources mocks base method.
+func (m *MockRelationshipService) LookupResources(ctx context.Context, objectType, relation string, subject relations.Subject, token string, limit int) ([]string, relations.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookupResources", ctx, objectType, relation, subject, token, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(relations.Revision)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LookupResources indicates an expected call of LookupResources.
+func (mr *MockRelationshipServiceMockRecorder) LookupResources(ctx, objectType, relation, subject, token, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupResources", reflect.TypeOf((*MockRelationshipService)(nil).LookupResources), ctx, objectType, relation, subject, token, limit)
+}
+
+// WriteRelationships mocks base method.
+func (m *MockRelationshipService) WriteRelationships(ctx context.Context, tuples []relations.Tuple) (relations.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteRelationships", ctx, tuples)
+	ret0, _ := ret[0].(relations.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteRelationships indicates an expected call of WriteRelationships.
+func (mr *MockRelationshipServiceMockRecorder) WriteRelationships(ctx, tuples any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteRelationships", reflect.TypeOf((*MockRelationshipService)(nil).WriteRelationships), ctx, tuples)
+}
@@ -0,0 +1,104 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./service_accounts.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./service_accounts.go -destination=./mocks/service_accounts_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	serviceaccounts "github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockServiceAccountService is a mock of ServiceAccountService interface.
+type MockServiceAccountService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceAccountServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockServiceAccountServiceMockRecorder is the mock recorder for MockServiceAccountService.
+type MockServiceAccountServiceMockRecorder struct {
+	mock *MockServiceAccountService
+}
+
+// NewMockServiceAccountService creates a new mock instance.
+func NewMockServiceAccountService(ctrl *gomock.Controller) *MockServiceAccountService {
+	mock := &MockServiceAccountService{ctrl: ctrl}
+	mock.recorder = &MockServiceAccountServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceAccountService) EXPECT() *MockServiceAccountServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateServiceAccount mocks base method.
+func (m *MockServiceAccountService) CreateServiceAccount(ctx context.Context, name, role string) (*serviceaccounts.ServiceAccount, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceAccount", ctx, name, role)
+	ret0, _ := ret[0].(*serviceaccounts.ServiceAccount)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateServiceAccount indicates an expected call of CreateServiceAccount.
+func (mr *MockServiceAccountServiceMockRecorder) CreateServiceAccount(ctx, name, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceAccount", reflect.TypeOf((*MockServiceAccountService)(nil).CreateServiceAccount), ctx, name, role)
+}
+
+// DisableServiceAccount mocks base method.
+func (m *MockServiceAccountService) DisableServiceAccount(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableServiceAccount", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableServiceAccount indicates an expected call of DisableServiceAccount.
+func (mr *MockServiceAccountServiceMockRecorder) DisableServiceAccount(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableServiceAccount", reflect.TypeOf((*MockServiceAccountService)(nil).DisableServiceAccount), ctx, id)
+}
+
+// ListServiceAccounts mocks base method.
+func (m *MockServiceAccountService) ListServiceAccounts(ctx context.Context, limit, offset int) ([]*serviceaccounts.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListServiceAccounts", ctx, limit, offset)
+	ret0, _ := ret[0].([]*serviceaccounts.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListServiceAccounts indicates an expected call of ListServiceAccounts.
+func (mr *MockServiceAccountServiceMockRecorder) ListServiceAccounts(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListServiceAccounts", reflect.TypeOf((*MockServiceAccountService)(nil).ListServiceAccounts), ctx, limit, offset)
+}
+
+// RotateServiceAccountSecret mocks base method.
+func (m *MockServiceAccountService) RotateServiceAccountSecret(ctx context.Context, id uuid.UUID, gracePeriod time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateServiceAccountSecret", ctx, id, gracePeriod)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateServiceAccountSecret indicates an expected call of RotateServiceAccountSecret.
+func (mr *MockServiceAccountServiceMockRecorder) RotateServiceAccountSecret(ctx, id, gracePeriod any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateServiceAccountSecret", reflect.TypeOf((*MockServiceAccountService)(nil).RotateServiceAccountSecret), ctx, id, gracePeriod)
+}
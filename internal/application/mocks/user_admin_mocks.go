@@ -0,0 +1,127 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./user_admin.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./user_admin.go -destination=./mocks/user_admin_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserAdminService is a mock of UserAdminService interface.
+type MockUserAdminService struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserAdminServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockUserAdminServiceMockRecorder is the mock recorder for MockUserAdminService.
+type MockUserAdminServiceMockRecorder struct {
+	mock *MockUserAdminService
+}
+
+// NewMockUserAdminService creates a new mock instance.
+func NewMockUserAdminService(ctrl *gomock.Controller) *MockUserAdminService {
+	mock := &MockUserAdminService{ctrl: ctrl}
+	mock.recorder = &MockUserAdminServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserAdminService) EXPECT() *MockUserAdminServiceMockRecorder {
+	return m.recorder
+}
+
+// EraseUser mocks base method.
+func (m *MockUserAdminService) EraseUser(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EraseUser indicates an expected call of EraseUser.
+func (mr *MockUserAdminServiceMockRecorder) EraseUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUser", reflect.TypeOf((*MockUserAdminService)(nil).EraseUser), ctx, id)
+}
+
+// ForceLogout mocks base method.
+func (m *MockUserAdminService) ForceLogout(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceLogout", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForceLogout indicates an expected call of ForceLogout.
+func (mr *MockUserAdminServiceMockRecorder) ForceLogout(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceLogout", reflect.TypeOf((*MockUserAdminService)(nil).ForceLogout), ctx, id)
+}
+
+// ReactivateUser mocks base method.
+func (m *MockUserAdminService) ReactivateUser(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReactivateUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReactivateUser indicates an expected call of ReactivateUser.
+func (mr *MockUserAdminServiceMockRecorder) ReactivateUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReactivateUser", reflect.TypeOf((*MockUserAdminService)(nil).ReactivateUser), ctx, id)
+}
+
+// ReassignEmail mocks base method.
+func (m *MockUserAdminService) ReassignEmail(ctx context.Context, id uuid.UUID, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReassignEmail", ctx, id, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReassignEmail indicates an expected call of ReassignEmail.
+func (mr *MockUserAdminServiceMockRecorder) ReassignEmail(ctx, id, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReassignEmail", reflect.TypeOf((*MockUserAdminService)(nil).ReassignEmail), ctx, id, email)
+}
+
+// SetTemporaryPassword mocks base method.
+func (m *MockUserAdminService) SetTemporaryPassword(ctx context.Context, id uuid.UUID, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTemporaryPassword", ctx, id, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTemporaryPassword indicates an expected call of SetTemporaryPassword.
+func (mr *MockUserAdminServiceMockRecorder) SetTemporaryPassword(ctx, id, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTemporaryPassword", reflect.TypeOf((*MockUserAdminService)(nil).SetTemporaryPassword), ctx, id, password)
+}
+
+// SuspendUser mocks base method.
+func (m *MockUserAdminService) SuspendUser(ctx context.Context, id uuid.UUID, reason string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendUser", ctx, id, reason, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockUserAdminServiceMockRecorder) SuspendUser(ctx, id, reason, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockUserAdminService)(nil).SuspendUser), ctx, id, reason, until)
+}
@@ -42,6 +42,21 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// ChangeTemporaryPassword mocks base method.
+func (m *MockUserService) ChangeTemporaryPassword(ctx context.Context, email, temporaryPassword, newPassword string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeTemporaryPassword", ctx, email, temporaryPassword, newPassword)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeTemporaryPassword indicates an expected call of ChangeTemporaryPassword.
+func (mr *MockUserServiceMockRecorder) ChangeTemporaryPassword(ctx, email, temporaryPassword, newPassword any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeTemporaryPassword", reflect.TypeOf((*MockUserService)(nil).ChangeTemporaryPassword), ctx, email, temporaryPassword, newPassword)
+}
+
 // CreateUser mocks base method.
 func (m *MockUserService) CreateUser(ctx context.Context, name, email, password string) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./watch.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./watch.go -destination=./mocks/watch_mocks.go
+//
+
+// Package mock_application is a generated GoMock package.
+package mock_application
+
+import (
+	context "context"
+	reflect "reflect"
+
+	changes "github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWatchService is a mock of WatchService interface.
+type MockWatchService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWatchServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockWatchServiceMockRecorder is the mock recorder for MockWatchService.
+type MockWatchServiceMockRecorder struct {
+	mock *MockWatchService
+}
+
+// NewMockWatchService creates a new mock instance.
+func NewMockWatchService(ctrl *gomock.Controller) *MockWatchService {
+	mock := &MockWatchService{ctrl: ctrl}
+	mock.recorder = &MockWatchServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWatchService) EXPECT() *MockWatchServiceMockRecorder {
+	return m.recorder
+}
+
+// WatchUsers mocks base method.
+func (m *MockWatchService) WatchUsers(ctx context.Context, fromPosition int64, send func(*changes.Change) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchUsers", ctx, fromPosition, send)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchUsers indicates an expected call of WatchUsers.
+func (mr *MockWatchServiceMockRecorder) WatchUsers(ctx, fromPosition, send any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchUsers", reflect.TypeOf((*MockWatchService)(nil).WatchUsers), ctx, fromPosition, send)
+}
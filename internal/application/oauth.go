@@ -0,0 +1,681 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+type OAuthService interface {
+	CreateClient(ctx context.Context, name string, redirectURIs []string, grantTypes []string, scope []string, confidential bool) (*oauth.Client, string, error)
+	ListClients(ctx context.Context, limit int, offset int) ([]*oauth.Client, error)
+	DeleteClient(ctx context.Context, clientID string) error
+
+	// ValidateAuthorization проверяет запрос до показа страницы входа
+	ValidateAuthorization(ctx context.Context, req oauth.AuthorizationRequest) (*oauth.Client, oauth.Scope, error)
+	// Authorize проверяет учетные данные пользователя и выдает код авторизации
+	Authorize(ctx context.Context, req oauth.AuthorizationRequest, email string, password string) (string, error)
+	// SessionLogin вход через страницу forward-auth, токен доступа хранится в cookie браузерной сессии
+	SessionLogin(ctx context.Context, email string, password string) (*oauth.TokenResponse, error)
+	Token(ctx context.Context, req oauth.TokenRequest) (*oauth.TokenResponse, error)
+	// Revoke отзывает refresh токен вместе с семейством, неизвестный токен не считается ошибкой (RFC 7009)
+	Revoke(ctx context.Context, clientID string, clientSecret string, token string) error
+	// UserInfo claims пользователя из контекста, отфильтрованные по scope его токена
+	UserInfo(ctx context.Context) (oauth.UserInfo, error)
+}
+
+type OAuthServiceHandler struct {
+	uof              UnitOfWork
+	passwordVerifier users.PasswordVerifier
+	tokens           oauth.TokenIssuer
+	codeTTL          time.Duration
+	refreshTTL       time.Duration
+	groupsClaim      bool
+	log              *slog.Logger
+}
+
+func NewOAuthService(
+	uof UnitOfWork,
+	passwordVerifier users.PasswordVerifier,
+	tokens oauth.TokenIssuer,
+	codeTTL time.Duration,
+	refreshTTL time.Duration,
+	log *slog.Logger,
+) *OAuthServiceHandler {
+	return &OAuthServiceHandler{
+		uof:              uof,
+		passwordVerifier: passwordVerifier,
+		tokens:           tokens,
+		codeTTL:          codeTTL,
+		refreshTTL:       refreshTTL,
+		log:              log,
+	}
+}
+
+// WithGroupsClaim токены доступа пользователей получают имена их групп
+func (s *OAuthServiceHandler) WithGroupsClaim(enabled bool) *OAuthServiceHandler {
+	s.groupsClaim = enabled
+	return s
+}
+
+func (s *OAuthServiceHandler) CreateClient(
+	ctx context.Context,
+	name string,
+	redirectURIs []string,
+	grantTypes []string,
+	scope []string,
+	confidential bool,
+) (*oauth.Client, string, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("creating oauth client")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to create oauth client", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+
+	grants := make([]oauth.GrantType, 0, len(grantTypes))
+	for _, g := range grantTypes {
+		grant, err := oauth.NewGrantType(g)
+		if err != nil {
+			log.Warn("failed to create oauth client", slog.String("grant_type", g), slog.String("error", err.Error()))
+			return nil, "", err
+		}
+		grants = append(grants, grant)
+	}
+
+	client, secret, err := oauth.CreateClient(name, redirectURIs, grants, oauth.ParseScope(oauth.Scope(scope).String()), confidential)
+	if err != nil {
+		log.Warn("failed to create oauth client", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+
+	err = s.uof.Execute(ctx, func(store Store) error {
+		return store.OAuth().SaveClient(ctx, client)
+	})
+	if err != nil {
+		log.Warn("failed to save oauth client", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+
+	log.Info("oauth client created", slog.String("client_id", client.ClientID()))
+	return client, secret, nil
+}
+
+func (s *OAuthServiceHandler) ListClients(ctx context.Context, limit int, offset int) ([]*oauth.Client, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting oauth clients")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to get oauth clients", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var clients []*oauth.Client
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		clients, err = store.OAuth().ListClients(ctx, limit, offset)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get oauth clients", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return clients, nil
+}
+
+func (s *OAuthServiceHandler) DeleteClient(ctx context.Context, clientID string) error {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("client_id", clientID))
+	log.Info("deleting oauth client")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to delete oauth client", slog.String("error", err.Error()))
+		return err
+	}
+
+	err := s.uof.Execute(ctx, func(store Store) error {
+		return store.OAuth().DeleteClient(ctx, clientID)
+	})
+	if err != nil {
+		log.Warn("failed to delete oauth client", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *OAuthServiceHandler) ValidateAuthorization(ctx context.Context, req oauth.AuthorizationRequest) (*oauth.Client, oauth.Scope, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("client_id", req.ClientID))
+
+	var client *oauth.Client
+	var scope oauth.Scope
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		client, err = store.OAuth().GetClient(ctx, req.ClientID)
+		if err != nil {
+			return err
+		}
+		scope, err = client.ValidateAuthorization(req)
+		return err
+	})
+	if err != nil {
+		log.Warn("invalid authorization request", slog.String("error", err.Error()))
+		return client, nil, err
+	}
+	return client, scope, nil
+}
+
+func (s *OAuthServiceHandler) Authorize(ctx context.Context, req oauth.AuthorizationRequest, email string, password string) (string, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("client_id", req.ClientID))
+	log.Info("authorizing oauth client")
+
+	var code string
+	var userID uuid.UUID
+	err := s.uof.Execute(ctx, func(store Store) error {
+		client, err := store.OAuth().GetClient(ctx, req.ClientID)
+		if err != nil {
+			return err
+		}
+		scope, err := client.ValidateAuthorization(req)
+		if err != nil {
+			return err
+		}
+
+		usr, err := s.verifyCredentials(ctx, store, email, password)
+		if usr != nil {
+			userID = usr.ID()
+		}
+		if err != nil {
+			return err
+		}
+
+		var authCode *oauth.AuthorizationCode
+		auth := oauth.Authentication{Time: time.Now().UTC(), ACR: oauth.ACRPassword}
+		authCode, code, err = oauth.CreateAuthorizationCode(req, usr.ID(), scope, auth, s.codeTTL)
+		if err != nil {
+			return err
+		}
+		if err = store.OAuth().SaveCode(ctx, authCode); err != nil {
+			return err
+		}
+
+		entry := userAudit(audit.ActionLogin, usr.ID())
+		entry.actorID = usr.ID()
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to authorize oauth client", slog.String("error", err.Error()))
+		if errors.Is(err, users.ErrInvalidCredentials) {
+			recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionLogin, userID), err)
+		}
+		return "", err
+	}
+	return code, nil
+}
+
+func (s *OAuthServiceHandler) SessionLogin(ctx context.Context, email string, password string) (*oauth.TokenResponse, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("creating browser session")
+
+	var res *oauth.TokenResponse
+	var userID uuid.UUID
+	err := s.uof.Execute(ctx, func(store Store) error {
+		usr, err := s.verifyCredentials(ctx, store, email, password)
+		if usr != nil {
+			userID = usr.ID()
+		}
+		if err != nil {
+			return err
+		}
+
+		groupNames, err := tokenGroups(ctx, store, s.groupsClaim, usr.TenantID(), usr.ID())
+		if err != nil {
+			return err
+		}
+		accessToken, expiresIn, err := s.tokens.IssueAccessToken(oauth.AccessGrant{
+			UserID:    usr.ID(),
+			TenantID:  usr.TenantID(),
+			Role:      usr.Role(),
+			Principal: users.PrincipalUser,
+			Groups:    groupNames,
+		})
+		if err != nil {
+			return err
+		}
+		res = &oauth.TokenResponse{
+			AccessToken: accessToken,
+			ExpiresIn:   expiresIn,
+		}
+
+		entry := userAudit(audit.ActionLogin, usr.ID())
+		entry.actorID = usr.ID()
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to create browser session", slog.String("error", err.Error()))
+		if errors.Is(err, users.ErrInvalidCredentials) {
+			recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionLogin, userID), err)
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *OAuthServiceHandler) Token(ctx context.Context, req oauth.TokenRequest) (*oauth.TokenResponse, error) {
+	log := logger.LogWithContext(ctx, s.log).With(
+		slog.String("client_id", req.ClientID),
+		slog.String("grant_type", req.GrantType),
+	)
+	log.Info("issuing oauth token")
+
+	grant, err := oauth.NewGrantType(req.GrantType)
+	if err != nil {
+		log.Warn("failed to issue oauth token", slog.String("error", err.Error()))
+		return nil, err
+	}
+	if grant == oauth.GrantClientCredentials && serviceaccounts.IsClientID(req.ClientID) {
+		return s.serviceAccountToken(ctx, log, req)
+	}
+
+	var res *oauth.TokenResponse
+	// reused заполняется, если код или refresh токен предъявлен повторно
+	var reused *tokenReuse
+	err = s.uof.Execute(ctx, func(store Store) error {
+		client, err := authenticateClient(ctx, store, req.ClientID, req.ClientSecret)
+		if err != nil {
+			return err
+		}
+		if err = client.AllowsGrant(grant); err != nil {
+			return err
+		}
+
+		switch grant {
+		case oauth.GrantAuthorizationCode:
+			res, reused, err = s.exchangeCode(ctx, store, client, req)
+		case oauth.GrantRefreshToken:
+			res, reused, err = s.refresh(ctx, store, client, req)
+		case oauth.GrantClientCredentials:
+			res, err = s.clientCredentials(client, req)
+		}
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to issue oauth token", slog.String("error", err.Error()))
+		if reused != nil {
+			s.revokeReused(ctx, log, reused)
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *OAuthServiceHandler) Revoke(ctx context.Context, clientID string, clientSecret string, token string) error {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("client_id", clientID))
+	log.Info("revoking oauth token")
+
+	err := s.uof.Execute(ctx, func(store Store) error {
+		if _, err := authenticateClient(ctx, store, clientID, clientSecret); err != nil {
+			return err
+		}
+
+		repo := store.OAuth()
+		refreshToken, err := repo.GetRefreshToken(ctx, oauth.HashToken(token))
+		if errors.Is(err, oauth.ErrRefreshTokenNotFound) {
+			// токены доступа не хранятся и истекают сами, неизвестный токен игнорируется
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if refreshToken.ClientID() != clientID {
+			return nil
+		}
+		return s.revokeFamily(ctx, store, refreshToken.FamilyID(), refreshToken.UserID())
+	})
+	if err != nil {
+		log.Warn("failed to revoke oauth token", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *OAuthServiceHandler) UserInfo(ctx context.Context) (oauth.UserInfo, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting user info")
+
+	userID, err := userIDFromContext(ctx)
+	if err != nil || principalFromContext(ctx) != users.PrincipalUser {
+		log.Warn("failed to get user info", slog.String("error", oauth.ErrInsufficientScope.Error()))
+		return oauth.UserInfo{}, oauth.ErrInsufficientScope
+	}
+	scope := scopeFromContext(ctx)
+	if err = oauth.RequireOpenID(scope); err != nil {
+		log.Warn("failed to get user info", slog.String("error", err.Error()))
+		return oauth.UserInfo{}, err
+	}
+
+	var info oauth.UserInfo
+	err = s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		usr, err := store.Users().Get(ctx, tenantID, userID)
+		if err != nil {
+			return err
+		}
+		if usr.CanAuthenticate(time.Now().UTC()) != nil {
+			return oauth.ErrUserNotActive
+		}
+		info = oauth.NewUserInfo(usr, scope)
+		return nil
+	})
+	if err != nil {
+		log.Warn("failed to get user info", slog.String("error", err.Error()))
+		return oauth.UserInfo{}, err
+	}
+	return info, nil
+}
+
+func (s *OAuthServiceHandler) exchangeCode(
+	ctx context.Context,
+	store Store,
+	client *oauth.Client,
+	req oauth.TokenRequest,
+) (*oauth.TokenResponse, *tokenReuse, error) {
+	repo := store.OAuth()
+	code, err := repo.GetCode(ctx, oauth.HashToken(req.Code))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = code.Redeem(client.ClientID(), req.RedirectURI, req.CodeVerifier, time.Now().UTC())
+	if errors.Is(err, oauth.ErrCodeUsed) {
+		return nil, &tokenReuse{familyID: code.FamilyID(), userID: code.UserID()}, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = repo.SaveCode(ctx, code); err != nil {
+		return nil, nil, err
+	}
+
+	res, err := s.issue(ctx, store, client, code.UserID(), code.Scope(), code.FamilyID(), code.Auth(), code.Nonce())
+	return res, nil, err
+}
+
+func (s *OAuthServiceHandler) refresh(
+	ctx context.Context,
+	store Store,
+	client *oauth.Client,
+	req oauth.TokenRequest,
+) (*oauth.TokenResponse, *tokenReuse, error) {
+	repo := store.OAuth()
+	current, err := repo.GetRefreshToken(ctx, oauth.HashToken(req.RefreshToken))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC()
+	err = current.Use(client.ClientID(), now)
+	if errors.Is(err, oauth.ErrRefreshTokenRevoked) {
+		return nil, &tokenReuse{familyID: current.FamilyID(), userID: current.UserID()}, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scope := current.Scope()
+	if requested := oauth.ParseScope(req.Scope); len(requested) > 0 {
+		if !scope.Contains(requested) {
+			return nil, nil, oauth.ErrScopeNotAllowed
+		}
+		scope = requested
+	}
+
+	current.Revoke(now)
+	if err = repo.SaveRefreshToken(ctx, current); err != nil {
+		return nil, nil, err
+	}
+
+	// nonce при обновлении не передается (OIDC Core 12.2)
+	res, err := s.issue(ctx, store, client, current.UserID(), scope, current.FamilyID(), current.Auth(), "")
+	return res, nil, err
+}
+
+func (s *OAuthServiceHandler) clientCredentials(client *oauth.Client, req oauth.TokenRequest) (*oauth.TokenResponse, error) {
+	if !client.IsConfidential() {
+		return nil, oauth.ErrPublicClientCredentials
+	}
+	scope, err := client.ResolveScope(oauth.ParseScope(req.Scope))
+	if err != nil {
+		return nil, err
+	}
+	accessToken, expiresIn, err := s.tokens.IssueAccessToken(oauth.AccessGrant{
+		ClientID:  client.ClientID(),
+		Scope:     scope,
+		Principal: users.PrincipalClient,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &oauth.TokenResponse{
+		AccessToken: accessToken,
+		ExpiresIn:   expiresIn,
+		Scope:       scope,
+	}, nil
+}
+
+// serviceAccountToken выдает токен сервисному аккаунту. Причина отказа пишется в лог и аудит,
+// клиенту всегда возвращается ErrClientAuthFailed
+func (s *OAuthServiceHandler) serviceAccountToken(ctx context.Context, log *slog.Logger, req oauth.TokenRequest) (*oauth.TokenResponse, error) {
+	if len(oauth.ParseScope(req.Scope)) > 0 {
+		log.Warn("failed to issue oauth token", slog.String("error", oauth.ErrScopeNotAllowed.Error()))
+		return nil, oauth.ErrScopeNotAllowed
+	}
+
+	var res *oauth.TokenResponse
+	var accountID uuid.UUID
+	err := s.uof.Execute(ctx, func(store Store) error {
+		account, err := store.ServiceAccounts().GetByClientID(ctx, req.ClientID)
+		if err != nil {
+			return err
+		}
+		accountID = account.ID()
+		if err = account.Authenticate(req.ClientSecret, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		accessToken, expiresIn, err := s.tokens.IssueAccessToken(oauth.AccessGrant{
+			UserID:    account.ID(),
+			Role:      account.Role(),
+			ClientID:  account.ClientID(),
+			Principal: users.PrincipalServiceAccount,
+		})
+		if err != nil {
+			return err
+		}
+		res = &oauth.TokenResponse{
+			AccessToken: accessToken,
+			ExpiresIn:   expiresIn,
+		}
+
+		entry := serviceAccountAudit(audit.ActionLogin, account.ID())
+		entry.actorID = account.ID()
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to issue oauth token", slog.String("error", err.Error()))
+		if accountID != uuid.Nil {
+			recordAuditFailure(ctx, s.uof, log, serviceAccountAudit(audit.ActionLogin, accountID), err)
+		}
+		if errors.Is(err, serviceaccounts.ErrNotFound) ||
+			errors.Is(err, serviceaccounts.ErrAuthFailed) ||
+			errors.Is(err, serviceaccounts.ErrDisabled) {
+			return nil, oauth.ErrClientAuthFailed
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// issue выдает токен доступа пользователю, ID токен при scope openid
+// и refresh токен, если клиенту разрешен refresh_token
+func (s *OAuthServiceHandler) issue(
+	ctx context.Context,
+	store Store,
+	client *oauth.Client,
+	userID uuid.UUID,
+	scope oauth.Scope,
+	familyID uuid.UUID,
+	auth oauth.Authentication,
+	nonce string,
+) (*oauth.TokenResponse, error) {
+	// userID из кода авторизации или refresh токена, выданных этому пользователю
+	usr, err := store.Users().Get(ctx, users.AnyTenant, userID)
+	if err != nil {
+		return nil, err
+	}
+	if usr.CanAuthenticate(time.Now().UTC()) != nil {
+		return nil, oauth.ErrUserNotActive
+	}
+
+	groupNames, err := tokenGroups(ctx, store, s.groupsClaim, usr.TenantID(), usr.ID())
+	if err != nil {
+		return nil, err
+	}
+	accessToken, expiresIn, err := s.tokens.IssueAccessToken(oauth.AccessGrant{
+		UserID:    usr.ID(),
+		TenantID:  usr.TenantID(),
+		Role:      usr.Role(),
+		ClientID:  client.ClientID(),
+		Scope:     scope,
+		Principal: users.PrincipalUser,
+		SessionID: familyID,
+		Groups:    groupNames,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &oauth.TokenResponse{
+		AccessToken: accessToken,
+		ExpiresIn:   expiresIn,
+		Scope:       scope,
+	}
+
+	if oauth.RequireOpenID(scope) == nil {
+		res.IDToken, err = s.tokens.IssueIDToken(oauth.NewIDToken(usr, client.ClientID(), nonce, auth, scope))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if client.AllowsGrant(oauth.GrantRefreshToken) != nil {
+		return res, nil
+	}
+	refreshToken, token, err := oauth.CreateRefreshToken(familyID, client.ClientID(), usr.ID(), scope, auth, s.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	if err = store.OAuth().SaveRefreshToken(ctx, refreshToken); err != nil {
+		return nil, err
+	}
+	res.RefreshToken = token
+	return res, nil
+}
+
+func authenticateClient(ctx context.Context, store Store, clientID string, clientSecret string) (*oauth.Client, error) {
+	if clientID == "" {
+		return nil, oauth.ErrClientAuthFailed
+	}
+	client, err := store.OAuth().GetClient(ctx, clientID)
+	if errors.Is(err, oauth.ErrClientNotFound) {
+		return nil, oauth.ErrClientAuthFailed
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = client.Authenticate(clientSecret); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// verifyCredentials возвращает пользователя, даже если пароль неверный, чтобы связать отказ с аккаунтом
+func (s *OAuthServiceHandler) verifyCredentials(ctx context.Context, store Store, email string, password string) (*users.User, error) {
+	e, err := users.NewEmail(email)
+	if err != nil {
+		return nil, users.ErrInvalidCredentials
+	}
+	p, err := users.NewPassword([]byte(password))
+	if err != nil {
+		return nil, users.ErrInvalidCredentials
+	}
+
+	tenantID, err := tenantFromContext(ctx, store)
+	if err != nil {
+		return nil, users.ErrInvalidCredentials
+	}
+	usr, err := store.Users().GetByEmail(ctx, tenantID, e)
+	if err != nil {
+		return nil, users.ErrInvalidCredentials
+	}
+	if usr.IsDeleted() {
+		return usr, users.ErrInvalidCredentials
+	}
+
+	verify, err := s.passwordVerifier.Verify(usr.Password().Hash(), p.Hash())
+	if err != nil || !verify {
+		return usr, users.ErrInvalidCredentials
+	}
+	if err = usr.CanAuthenticate(time.Now().UTC()); err != nil {
+		return usr, oauth.ErrUserNotActive
+	}
+	if usr.Access().PasswordChangeRequired {
+		return usr, users.ErrPasswordChangeRequired
+	}
+	return usr, nil
+}
+
+func (s *OAuthServiceHandler) revokeFamily(ctx context.Context, store Store, familyID uuid.UUID, userID uuid.UUID) error {
+	if err := store.OAuth().RevokeRefreshTokens(ctx, familyID, time.Now().UTC()); err != nil {
+		return err
+	}
+	return recordAudit(ctx, store, auditEntry{
+		action:     audit.ActionTokenRevoked,
+		targetType: audit.TargetToken,
+		targetID:   familyID.String(),
+		actorID:    userID,
+	}, nil)
+}
+
+// tokenReuse повторно предъявленный код или refresh токен, признак утечки
+type tokenReuse struct {
+	familyID uuid.UUID
+	userID   uuid.UUID
+}
+
+// revokeReused транзакция обмена откатывается, поэтому семейство отзывается отдельной транзакцией
+func (s *OAuthServiceHandler) revokeReused(ctx context.Context, log *slog.Logger, reused *tokenReuse) {
+	log = log.With(slog.String("family_id", reused.familyID.String()))
+	log.Warn("oauth grant reused, revoking token family")
+	err := s.uof.Execute(ctx, func(store Store) error {
+		return s.revokeFamily(ctx, store, reused.familyID, reused.userID)
+	})
+	if err != nil {
+		log.Error("failed to revoke token family", slog.String("error", err.Error()))
+	}
+}
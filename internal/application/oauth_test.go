@@ -0,0 +1,231 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	mockoauth "github.com/LeoUraltsev/auth-service/internal/domain/oauth/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+type tokenIssuerFunc func(grant oauth.AccessGrant) (string, time.Duration, error)
+
+func (f tokenIssuerFunc) IssueAccessToken(grant oauth.AccessGrant) (string, time.Duration, error) {
+	return f(grant)
+}
+
+func (f tokenIssuerFunc) IssueIDToken(token oauth.IDToken) (string, error) {
+	return "id:" + token.Audience + ":" + token.Nonce, nil
+}
+
+func TestOAuthServiceHandler_Token_authorizationCode(t *testing.T) {
+	const verifier = "dBjftJeZ4CVP-mJ92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	user, _ := users.CreateUser(organizations.DefaultID, "name", email, pass)
+
+	client, _, err := oauth.CreateClient(
+		"app",
+		[]string{"https://app.example.com/cb"},
+		[]oauth.GrantType{oauth.GrantAuthorizationCode, oauth.GrantRefreshToken},
+		oauth.Scope{"openid", "profile"},
+		false,
+	)
+	require.NoError(t, err)
+
+	authRequest := oauth.AuthorizationRequest{
+		ResponseType:        oauth.ResponseTypeCode,
+		ClientID:            client.ClientID(),
+		RedirectURI:         "https://app.example.com/cb",
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: oauth.CodeChallengeS256,
+		Nonce:               "n-0S6_WzA2Mj",
+	}
+	auth := oauth.Authentication{Time: time.Now().UTC(), ACR: oauth.ACRPassword}
+	code, rawCode, err := oauth.CreateAuthorizationCode(authRequest, user.ID(), oauth.Scope{"openid", "profile"}, auth, time.Minute)
+	require.NoError(t, err)
+
+	tokenRequest := oauth.TokenRequest{
+		GrantType:    oauth.GrantAuthorizationCode.String(),
+		ClientID:     client.ClientID(),
+		Code:         rawCode,
+		RedirectURI:  authRequest.RedirectURI,
+		CodeVerifier: verifier,
+	}
+
+	issuer := tokenIssuerFunc(func(grant oauth.AccessGrant) (string, time.Duration, error) {
+		assert.Equal(t, user.ID(), grant.UserID)
+		assert.Equal(t, client.ClientID(), grant.ClientID)
+		return "access", time.Hour, nil
+	})
+
+	t.Run("exchange", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mockoauth.NewMockRepository(ctrl)
+		repository.EXPECT().GetClient(gomock.Any(), client.ClientID()).Return(client, nil)
+		repository.EXPECT().GetCode(gomock.Any(), oauth.HashToken(rawCode)).Return(code, nil)
+		repository.EXPECT().SaveCode(gomock.Any(), code).Return(nil)
+		repository.EXPECT().
+			SaveRefreshToken(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, token *oauth.RefreshToken) error {
+				assert.Equal(t, code.FamilyID(), token.FamilyID())
+				assert.Equal(t, auth, token.Auth())
+				return nil
+			})
+
+		userRepository := mockusers.NewMockUserRepository(ctrl)
+		userRepository.EXPECT().Get(gomock.Any(), users.AnyTenant, user.ID()).Return(user, nil)
+
+		uof := testUnitOfWork{store: testStore{users: userRepository, oauth: repository}}
+		service := NewOAuthService(uof, nil, issuer, time.Minute, time.Hour, log)
+
+		res, err := service.Token(context.Background(), tokenRequest)
+		require.NoError(t, err)
+		assert.Equal(t, "access", res.AccessToken)
+		assert.NotEmpty(t, res.RefreshToken)
+		assert.Equal(t, "id:"+client.ClientID()+":"+authRequest.Nonce, res.IDToken)
+		assert.NotNil(t, code.UsedAt())
+	})
+
+	t.Run("reused code revokes issued tokens", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mockoauth.NewMockRepository(ctrl)
+		repository.EXPECT().GetClient(gomock.Any(), client.ClientID()).Return(client, nil)
+		repository.EXPECT().GetCode(gomock.Any(), oauth.HashToken(rawCode)).Return(code, nil)
+		repository.EXPECT().RevokeRefreshTokens(gomock.Any(), code.FamilyID(), gomock.Any()).Return(nil)
+
+		auditRepository := mockaudit.NewMockRepository(ctrl)
+		auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+
+		uof := testUnitOfWork{store: testStore{oauth: repository, audit: auditRepository}}
+		service := NewOAuthService(uof, nil, issuer, time.Minute, time.Hour, log)
+
+		_, err := service.Token(context.Background(), tokenRequest)
+		assert.ErrorIs(t, err, oauth.ErrCodeUsed)
+	})
+}
+
+func TestOAuthServiceHandler_Token_clientCredentials(t *testing.T) {
+	confidential, secret, err := oauth.CreateClient("worker", nil, []oauth.GrantType{oauth.GrantClientCredentials}, oauth.Scope{"users.read"}, true)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		secret  string
+		scope   string
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			secret: secret,
+		},
+		{
+			name:    "wrong secret",
+			secret:  "cs_wrong",
+			wantErr: oauth.ErrClientAuthFailed,
+		},
+		{
+			name:    "scope not allowed",
+			secret:  secret,
+			scope:   "users.write",
+			wantErr: oauth.ErrScopeNotAllowed,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockoauth.NewMockRepository(ctrl)
+			repository.EXPECT().GetClient(gomock.Any(), confidential.ClientID()).Return(confidential, nil)
+
+			issuer := tokenIssuerFunc(func(grant oauth.AccessGrant) (string, time.Duration, error) {
+				assert.Equal(t, confidential.ClientID(), grant.ClientID)
+				assert.Empty(t, grant.UserID)
+				assert.Equal(t, users.PrincipalClient, grant.Principal)
+				return "access", time.Hour, nil
+			})
+			service := NewOAuthService(testUnitOfWork{store: testStore{oauth: repository}}, nil, issuer, time.Minute, time.Hour, log)
+
+			res, err := service.Token(context.Background(), oauth.TokenRequest{
+				GrantType:    oauth.GrantClientCredentials.String(),
+				ClientID:     confidential.ClientID(),
+				ClientSecret: tt.secret,
+				Scope:        tt.scope,
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Empty(t, res.RefreshToken)
+			assert.Equal(t, oauth.Scope{"users.read"}, res.Scope)
+		})
+	}
+}
+
+func TestOAuthServiceHandler_UserInfo(t *testing.T) {
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	user, _ := users.CreateUser(organizations.DefaultID, "name", email, pass)
+
+	cases := []struct {
+		name    string
+		scope   string
+		want    map[string]any
+		wantErr error
+	}{
+		{
+			name:  "openid only",
+			scope: "openid",
+			want:  map[string]any{"sub": user.ID().String()},
+		},
+		{
+			name:  "profile and email",
+			scope: "openid profile email",
+			want: map[string]any{
+				"sub":            user.ID().String(),
+				"name":           "name",
+				"email":          "user@example.com",
+				"email_verified": false,
+			},
+		},
+		{
+			name:    "without openid",
+			scope:   "profile",
+			wantErr: oauth.ErrInsufficientScope,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			userRepository := mockusers.NewMockUserRepository(ctrl)
+			if tt.wantErr == nil {
+				userRepository.EXPECT().Get(gomock.Any(), organizations.DefaultID, user.ID()).Return(user, nil)
+			}
+			service := NewOAuthService(testUnitOfWork{store: testStore{users: userRepository}}, nil, nil, time.Minute, time.Hour, log)
+
+			ctx := authverify.NewContext(context.Background(), &authverify.Principal{UserID: user.ID(), Scope: oauth.ParseScope(tt.scope)})
+			info, err := service.UserInfo(ctx)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, info.Claims())
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+// OrganizationService управление организациями, все методы только для администраторов платформы
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, slug string, name string) (*organizations.Organization, error)
+	GetOrganization(ctx context.Context, id uuid.UUID) (*organizations.Organization, error)
+	ListOrganizations(ctx context.Context, limit int, offset int) ([]*organizations.Organization, error)
+	// UpdateOrganization пустые поля не меняются
+	UpdateOrganization(ctx context.Context, id uuid.UUID, slug string, name string) (*organizations.Organization, error)
+	// DeleteOrganization удаляет только организацию без пользователей
+	DeleteOrganization(ctx context.Context, id uuid.UUID) error
+}
+
+type OrganizationServiceHandler struct {
+	uof UnitOfWork
+	log *slog.Logger
+}
+
+func NewOrganizationService(uof UnitOfWork, log *slog.Logger) *OrganizationServiceHandler {
+	return &OrganizationServiceHandler{
+		uof: uof,
+		log: log,
+	}
+}
+
+func (s *OrganizationServiceHandler) CreateOrganization(ctx context.Context, slug string, name string) (*organizations.Organization, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("creating organization")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to create organization", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	organization, err := organizations.CreateOrganization(slug, name)
+	if err != nil {
+		log.Warn("failed to create organization", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	entry := organizationAudit(audit.ActionOrganizationCreated, organization.ID())
+	err = s.uof.Execute(ctx, func(store Store) error {
+		if err := checkUniqueSlug(ctx, store, organization); err != nil {
+			return err
+		}
+		if err := store.Organizations().Save(ctx, organization); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to create organization", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return nil, err
+	}
+
+	log.Info("organization created", slog.String("slug", organization.Slug()))
+	return organization, nil
+}
+
+func (s *OrganizationServiceHandler) GetOrganization(ctx context.Context, id uuid.UUID) (*organizations.Organization, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("organization_id", id.String()))
+	log.Info("getting organization")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to get organization", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var organization *organizations.Organization
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		organization, err = store.Organizations().Get(ctx, id)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get organization", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return organization, nil
+}
+
+func (s *OrganizationServiceHandler) ListOrganizations(ctx context.Context, limit int, offset int) ([]*organizations.Organization, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting organizations")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to get organizations", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var list []*organizations.Organization
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		list, err = store.Organizations().List(ctx, limit, offset)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get organizations", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *OrganizationServiceHandler) UpdateOrganization(ctx context.Context, id uuid.UUID, slug string, name string) (*organizations.Organization, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("organization_id", id.String()))
+	log.Info("updating organization")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to update organization", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var organization *organizations.Organization
+	entry := organizationAudit(audit.ActionOrganizationUpdated, id)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		organization, err = store.Organizations().Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = organization.Update(slug, name); err != nil {
+			return err
+		}
+		if err = checkUniqueSlug(ctx, store, organization); err != nil {
+			return err
+		}
+		if err = store.Organizations().Save(ctx, organization); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to update organization", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return nil, err
+	}
+
+	log.Info("organization updated")
+	return organization, nil
+}
+
+func (s *OrganizationServiceHandler) DeleteOrganization(ctx context.Context, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("organization_id", id.String()))
+	log.Info("deleting organization")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to delete organization", slog.String("error", err.Error()))
+		return err
+	}
+	if id == organizations.DefaultID {
+		return organizations.ErrDefaultOrganization
+	}
+
+	entry := organizationAudit(audit.ActionOrganizationDeleted, id)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		members, err := store.Users().GetAll(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(members) > 0 {
+			return organizations.ErrOrganizationNotEmpty
+		}
+		if err = store.Organizations().Delete(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to delete organization", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	log.Info("organization deleted")
+	return nil
+}
+
+// checkUniqueSlug slug занят другой организацией
+func checkUniqueSlug(ctx context.Context, store Store, organization *organizations.Organization) error {
+	existing, err := store.Organizations().GetBySlug(ctx, organization.Slug())
+	if errors.Is(err, organizations.ErrOrganizationNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID() != organization.ID() {
+		return organizations.ErrSlugAlreadyExists
+	}
+	return nil
+}
+
+func organizationAudit(action audit.Action, id uuid.UUID) auditEntry {
+	return auditEntry{
+		action:     action,
+		targetType: audit.TargetOrganization,
+		targetID:   id.String(),
+	}
+}
@@ -0,0 +1,185 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	mockorganizations "github.com/LeoUraltsev/auth-service/internal/domain/organizations/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/helper/ctxkeys"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestOrganizationServiceHandler_CreateOrganization(t *testing.T) {
+	existing, err := organizations.CreateOrganization("taken", "Taken")
+	require.NoError(t, err)
+	tenantAdmin := authverify.NewContext(context.Background(), &authverify.Principal{Role: "admin", TenantID: uuid.New()})
+
+	cases := []struct {
+		name    string
+		ctx     context.Context
+		slug    string
+		wantErr error
+	}{
+		{name: "platform admin", ctx: adminContext(), slug: "acme"},
+		{name: "tenant admin", ctx: tenantAdmin, slug: "acme", wantErr: ErrPermissionDenied},
+		{name: "slug taken", ctx: adminContext(), slug: "taken", wantErr: organizations.ErrSlugAlreadyExists},
+		{name: "slug not valid", ctx: adminContext(), slug: "acme corp", wantErr: organizations.ErrSlugNotValid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockorganizations.NewMockRepository(ctrl)
+			repository.EXPECT().GetBySlug(gomock.Any(), "taken").Return(existing, nil).AnyTimes()
+			repository.EXPECT().GetBySlug(gomock.Any(), "acme").Return(nil, organizations.ErrOrganizationNotFound).AnyTimes()
+			if tt.wantErr == nil {
+				repository.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+			}
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			service := NewOrganizationService(testUnitOfWork{store: testStore{orgs: repository, audit: auditRepository}}, log)
+			organization, err := service.CreateOrganization(tt.ctx, tt.slug, "Acme")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.slug, organization.Slug())
+		})
+	}
+}
+
+func TestOrganizationServiceHandler_DeleteOrganization(t *testing.T) {
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	empty := uuid.New()
+	occupied := uuid.New()
+	member, err := users.CreateUser(occupied, "name", email, pass)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		id      uuid.UUID
+		wantErr error
+	}{
+		{name: "empty", id: empty},
+		{name: "has users", id: occupied, wantErr: organizations.ErrOrganizationNotEmpty},
+		{name: "default", id: organizations.DefaultID, wantErr: organizations.ErrDefaultOrganization},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			userRepository := mockusers.NewMockUserRepository(ctrl)
+			userRepository.EXPECT().GetAll(gomock.Any(), empty).Return(nil, nil).AnyTimes()
+			userRepository.EXPECT().GetAll(gomock.Any(), occupied).Return([]*users.User{member}, nil).AnyTimes()
+			repository := mockorganizations.NewMockRepository(ctrl)
+			if tt.wantErr == nil {
+				repository.EXPECT().Delete(gomock.Any(), tt.id).Return(nil)
+			}
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			uof := testUnitOfWork{store: testStore{users: userRepository, orgs: repository, audit: auditRepository}}
+			err := NewOrganizationService(uof, log).DeleteOrganization(adminContext(), tt.id)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUserServiceHandler_Login_organization(t *testing.T) {
+	organization, err := organizations.CreateOrganization("acme", "Acme")
+	require.NoError(t, err)
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	user, err := users.CreateUser(organization.ID(), "name", email, pass)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name         string
+		organization string
+		wantErr      error
+	}{
+		{name: "by slug", organization: "acme"},
+		{name: "by id", organization: organization.ID().String()},
+		{name: "unknown", organization: "other", wantErr: users.ErrInvalidCredentials},
+		{name: "not valid slug", organization: "acme corp", wantErr: users.ErrInvalidCredentials},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockorganizations.NewMockRepository(ctrl)
+			repository.EXPECT().GetBySlug(gomock.Any(), "acme").Return(organization, nil).AnyTimes()
+			repository.EXPECT().GetBySlug(gomock.Any(), "other").Return(nil, organizations.ErrOrganizationNotFound).AnyTimes()
+			repository.EXPECT().Get(gomock.Any(), organization.ID()).Return(organization, nil).AnyTimes()
+
+			userRepository := mockusers.NewMockUserRepository(ctrl)
+			userRepository.EXPECT().GetByEmail(gomock.Any(), organization.ID(), email).Return(user, nil).AnyTimes()
+			passwordVerifier := mockusers.NewMockPasswordVerifier(ctrl)
+			passwordVerifier.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+			tokenGenerator := mockusers.NewMockTokenGenerator(ctrl)
+			tokenGenerator.EXPECT().GenerateToken(user.ID(), organization.ID(), users.RoleUser, nil).Return("token", nil).AnyTimes()
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			uof := testUnitOfWork{store: testStore{users: userRepository, orgs: repository, audit: auditRepository}}
+			service := NewUserService(uof, nil, passwordVerifier, tokenGenerator, log)
+
+			ctx := context.WithValue(context.Background(), ctxkeys.Organization, tt.organization)
+			token, err := service.Login(ctx, "user@example.com", "password")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "token", token)
+		})
+	}
+}
+
+// TestPlatformResources_tenantAdmin подписки, лента изменений, аудит, OAuth клиенты и сервисные аккаунты
+// общие для всех организаций, администратору организации они недоступны
+func TestPlatformResources_tenantAdmin(t *testing.T) {
+	ctx := authverify.NewContext(context.Background(), &authverify.Principal{Role: "admin", TenantID: uuid.New()})
+	uof := testUnitOfWork{}
+
+	calls := map[string]func() error{
+		"webhooks": func() error {
+			_, err := NewWebhookService(uof, log).ListWebhooks(ctx)
+			return err
+		},
+		"watch": func() error {
+			return NewWatchService(uof, testNotifier{}, time.Hour, 10, log).
+				WatchUsers(ctx, 0, func(*changes.Change) error { return nil })
+		},
+		"audit": func() error {
+			_, err := NewAuditService(uof, nil, log).ListAuditEvents(ctx, audit.Filter{})
+			return err
+		},
+		"oauth clients": func() error {
+			_, err := NewOAuthService(uof, nil, nil, time.Minute, time.Hour, log).ListClients(ctx, 10, 0)
+			return err
+		},
+		"service accounts": func() error {
+			_, err := NewServiceAccountService(uof, log).ListServiceAccounts(ctx, 10, 0)
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, call(), ErrPermissionDenied)
+		})
+	}
+}
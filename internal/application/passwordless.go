@@ -0,0 +1,283 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/mail"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/passwordless"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// PasswordlessService вход без пароля: на email приходят ссылка и одноразовый код,
+// любой из них обменивается на тот же токен доступа, что выдает Login
+type PasswordlessService interface {
+	// StartPasswordlessLogin не сообщает, есть ли пользователь с таким email, письмо уходит только существующему
+	StartPasswordlessLogin(ctx context.Context, email string) error
+	// CompletePasswordlessLogin по ссылке передается link, по коду из письма email и code
+	CompletePasswordlessLogin(ctx context.Context, link string, email string, code string) (string, error)
+}
+
+// PasswordlessSettings Enabled включает вход во всех организациях, Organizations - только в перечисленных по slug
+type PasswordlessSettings struct {
+	Enabled       bool
+	Organizations []string
+	LinkURL       string
+	TTL           time.Duration
+	MaxAttempts   int
+}
+
+type PasswordlessServiceHandler struct {
+	uof         UnitOfWork
+	sender      mail.Sender
+	tokenGen    users.TokenGenerator
+	settings    PasswordlessSettings
+	groupsClaim bool
+	log         *slog.Logger
+}
+
+func NewPasswordlessService(
+	uof UnitOfWork,
+	sender mail.Sender,
+	tokenGen users.TokenGenerator,
+	settings PasswordlessSettings,
+	log *slog.Logger,
+) *PasswordlessServiceHandler {
+	return &PasswordlessServiceHandler{
+		uof:      uof,
+		sender:   sender,
+		tokenGen: tokenGen,
+		settings: settings,
+		log:      log,
+	}
+}
+
+// WithGroupsClaim токены совпадают с токенами Login, поэтому настройка должна быть той же
+func (s *PasswordlessServiceHandler) WithGroupsClaim(enabled bool) *PasswordlessServiceHandler {
+	s.groupsClaim = enabled
+	return s
+}
+
+func (s *PasswordlessServiceHandler) StartPasswordlessLogin(ctx context.Context, email string) error {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("starting passwordless login")
+
+	e, err := users.NewEmail(email)
+	if err != nil {
+		log.Warn("failed to start passwordless login", slog.String("error", err.Error()))
+		return err
+	}
+
+	var challenge *passwordless.Challenge
+	var secrets passwordless.Secrets
+	var userID uuid.UUID
+	err = s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		if err = s.enabled(ctx, store, tenantID); err != nil {
+			return err
+		}
+		u, err := store.Users().GetByEmail(ctx, tenantID, e)
+		if errors.Is(err, users.ErrUserNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if u.CanAuthenticate(now) != nil {
+			return nil
+		}
+		userID = u.ID()
+
+		challenge, secrets, err = passwordless.CreateChallenge(u, s.settings.TTL, s.settings.MaxAttempts)
+		if err != nil {
+			return err
+		}
+		// новый вход отменяет предыдущий, действует только последнее письмо
+		previous, err := store.Passwordless().GetLatest(ctx, u.ID())
+		if err != nil && !errors.Is(err, passwordless.ErrNotFound) {
+			return err
+		}
+		if previous != nil {
+			challenge.ContinueAttempts(previous, now)
+		}
+		if previous != nil && previous.Revoke(now) == nil {
+			if err = store.Passwordless().Save(ctx, previous); err != nil {
+				return err
+			}
+		}
+		if err = store.Passwordless().Save(ctx, challenge); err != nil {
+			return err
+		}
+		entry := userAudit(audit.ActionPasswordlessRequested, u.ID())
+		entry.actorID = u.ID()
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to start passwordless login", slog.String("error", err.Error()))
+		if userID != uuid.Nil {
+			recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionPasswordlessRequested, userID), err)
+		}
+		if errors.Is(err, organizations.ErrOrganizationNotFound) {
+			return nil
+		}
+		return err
+	}
+	if challenge == nil {
+		log.Info("passwordless login skipped, user not found or not active")
+		return nil
+	}
+
+	if err = s.notify(ctx, e, challenge, secrets); err != nil {
+		log.Error("failed to send passwordless login mail", slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("passwordless login started", slog.String("challenge_id", challenge.ID().String()))
+	return nil
+}
+
+func (s *PasswordlessServiceHandler) CompletePasswordlessLogin(ctx context.Context, link string, email string, code string) (string, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("completing passwordless login")
+
+	var token string
+	var userID uuid.UUID
+	// неверный код сохраняется вместе со счетчиком попыток, поэтому транзакция фиксируется
+	// и ошибка возвращается после нее
+	var consumeErr error
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var challenge *passwordless.Challenge
+		var err error
+		now := time.Now().UTC()
+		if link != "" {
+			if challenge, err = s.byLink(ctx, store, link); err != nil {
+				return err
+			}
+			consumeErr = challenge.ConsumeLink(now)
+		} else {
+			if challenge, err = s.byEmail(ctx, store, email); err != nil {
+				return err
+			}
+			// по коду нельзя узнать, есть ли пользователь и запрашивал ли он вход
+			if consumeErr = challenge.ConsumeCode(code, now); consumeErr != nil {
+				log.Warn("passwordless code rejected", slog.String("error", consumeErr.Error()))
+				consumeErr = users.ErrInvalidCredentials
+			}
+		}
+		userID = challenge.UserID()
+		if err = store.Passwordless().Save(ctx, challenge); err != nil {
+			return err
+		}
+		if consumeErr != nil {
+			return nil
+		}
+
+		if err = s.enabled(ctx, store, challenge.TenantID()); err != nil {
+			return err
+		}
+		usr, err := store.Users().Get(ctx, challenge.TenantID(), challenge.UserID())
+		if err != nil {
+			return err
+		}
+		// временный пароль к входу без пароля отношения не имеет, проверяется только статус
+		err = usr.CanAuthenticate(now)
+		if errors.Is(err, users.ErrUserNotActive) {
+			return users.ErrInvalidCredentials
+		}
+		if err != nil {
+			return err
+		}
+		token, err = issueLoginToken(ctx, store, s.tokenGen, s.groupsClaim, usr)
+		return err
+	})
+	if err == nil {
+		err = consumeErr
+	}
+	if err != nil {
+		log.Warn("failed to complete passwordless login", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionLogin, userID), err)
+		return "", err
+	}
+
+	log.Info("passwordless login completed", slog.String("user_id", userID.String()))
+	return token, nil
+}
+
+func (s *PasswordlessServiceHandler) byLink(ctx context.Context, store Store, link string) (*passwordless.Challenge, error) {
+	if err := passwordless.ValidLink(link); err != nil {
+		return nil, err
+	}
+	challenge, err := store.Passwordless().GetByLinkHash(ctx, passwordless.HashLink(link))
+	if errors.Is(err, passwordless.ErrNotFound) {
+		return nil, passwordless.ErrLinkNotValid
+	}
+	return challenge, err
+}
+
+// byEmail последний вход пользователя организации из контекста, любые промахи не отличаются от неверного кода
+func (s *PasswordlessServiceHandler) byEmail(ctx context.Context, store Store, email string) (*passwordless.Challenge, error) {
+	e, err := users.NewEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := tenantFromContext(ctx, store)
+	if errors.Is(err, organizations.ErrOrganizationNotFound) {
+		return nil, users.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	u, err := store.Users().GetByEmail(ctx, tenantID, e)
+	if errors.Is(err, users.ErrUserNotFound) {
+		return nil, users.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := store.Passwordless().GetLatest(ctx, u.ID())
+	if errors.Is(err, passwordless.ErrNotFound) {
+		return nil, users.ErrInvalidCredentials
+	}
+	return challenge, err
+}
+
+// enabled вход без пароля включен глобально или для организации
+func (s *PasswordlessServiceHandler) enabled(ctx context.Context, store Store, tenantID uuid.UUID) error {
+	if s.settings.Enabled {
+		return nil
+	}
+	if len(s.settings.Organizations) == 0 {
+		return passwordless.ErrDisabled
+	}
+	org, err := store.Organizations().Get(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(s.settings.Organizations, org.Slug()) {
+		return passwordless.ErrDisabled
+	}
+	return nil
+}
+
+func (s *PasswordlessServiceHandler) notify(ctx context.Context, to users.Email, challenge *passwordless.Challenge, secrets passwordless.Secrets) error {
+	linkURL, err := linkWithToken(s.settings.LinkURL, secrets.Link)
+	if err != nil {
+		return err
+	}
+	return s.sender.Send(ctx, mail.Message{
+		To:      to,
+		Subject: "Вход без пароля",
+		Body: fmt.Sprintf("Чтобы войти, перейдите по ссылке:\n%s\n\nили введите код: %s\n\nСсылка и код действуют до %s. Если вы не запрашивали вход, проигнорируйте письмо.\n",
+			linkURL, secrets.Code, challenge.ExpiresAt().Format(time.RFC1123)),
+	})
+}
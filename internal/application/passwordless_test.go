@@ -0,0 +1,199 @@
+package application
+
+import (
+	"context"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/mail"
+	mockmail "github.com/LeoUraltsev/auth-service/internal/domain/mail/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	mockorganizations "github.com/LeoUraltsev/auth-service/internal/domain/organizations/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/passwordless"
+	mockpasswordless "github.com/LeoUraltsev/auth-service/internal/domain/passwordless/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type passwordlessMocks struct {
+	users    *mockusers.MockUserRepository
+	logins   *mockpasswordless.MockRepository
+	orgs     *mockorganizations.MockRepository
+	sender   *mockmail.MockSender
+	tokenGen *mockusers.MockTokenGenerator
+}
+
+var testPasswordlessSettings = PasswordlessSettings{
+	Enabled:     true,
+	LinkURL:     "https://app.example.com/login/link",
+	TTL:         10 * time.Minute,
+	MaxAttempts: 3,
+}
+
+func newPasswordlessService(t *testing.T, settings PasswordlessSettings, prepare func(m passwordlessMocks)) *PasswordlessServiceHandler {
+	ctrl := gomock.NewController(t)
+	m := passwordlessMocks{
+		users:    mockusers.NewMockUserRepository(ctrl),
+		logins:   mockpasswordless.NewMockRepository(ctrl),
+		orgs:     mockorganizations.NewMockRepository(ctrl),
+		sender:   mockmail.NewMockSender(ctrl),
+		tokenGen: mockusers.NewMockTokenGenerator(ctrl),
+	}
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	prepare(m)
+
+	store := testStore{users: m.users, logins: m.logins, orgs: m.orgs, audit: auditRepository}
+	return NewPasswordlessService(testUnitOfWork{store: store}, m.sender, m.tokenGen, settings, log)
+}
+
+func TestPasswordlessServiceHandler_StartPasswordlessLogin(t *testing.T) {
+	u := testUser(t)
+	previous, _, err := passwordless.CreateChallenge(u, time.Minute, 3)
+	require.NoError(t, err)
+	var saved *passwordless.Challenge
+	var sent mail.Message
+	service := newPasswordlessService(t, testPasswordlessSettings, func(m passwordlessMocks) {
+		m.users.EXPECT().GetByEmail(gomock.Any(), organizations.DefaultID, u.Email()).Return(u, nil)
+		m.logins.EXPECT().GetLatest(gomock.Any(), u.ID()).Return(previous, nil)
+		m.logins.EXPECT().Save(gomock.Any(), previous).Return(nil)
+		m.logins.EXPECT().Save(gomock.Any(), gomock.Not(previous)).DoAndReturn(func(_ context.Context, c *passwordless.Challenge) error {
+			saved = c
+			return nil
+		})
+		m.sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, message mail.Message) error {
+			sent = message
+			return nil
+		})
+	})
+
+	require.NoError(t, service.StartPasswordlessLogin(context.Background(), "user@gmail.com"))
+	assert.Equal(t, passwordless.StatusConsumed, previous.Status(time.Now().UTC()), "previous login is revoked")
+	require.NotNil(t, saved)
+	assert.Equal(t, u.Email(), sent.To)
+	assert.Equal(t, passwordless.HashLink(tokenFromMail(t, sent)), saved.LinkHash())
+	assert.Regexp(t, regexp.MustCompile(`код: \d{6}`), sent.Body)
+}
+
+func TestPasswordlessServiceHandler_StartPasswordlessLogin_unknownEmail(t *testing.T) {
+	service := newPasswordlessService(t, testPasswordlessSettings, func(m passwordlessMocks) {
+		m.users.EXPECT().GetByEmail(gomock.Any(), organizations.DefaultID, gomock.Any()).Return(nil, users.ErrUserNotFound)
+	})
+
+	assert.NoError(t, service.StartPasswordlessLogin(context.Background(), "nobody@gmail.com"))
+}
+
+func TestPasswordlessServiceHandler_StartPasswordlessLogin_disabled(t *testing.T) {
+	settings := testPasswordlessSettings
+	settings.Enabled = false
+	settings.Organizations = []string{"acme"}
+	service := newPasswordlessService(t, settings, func(m passwordlessMocks) {
+		m.orgs.EXPECT().Get(gomock.Any(), organizations.DefaultID).
+			Return(organizations.NewOrganization(organizations.DefaultID, organizations.DefaultSlug, "default", time.Now(), time.Now()), nil)
+	})
+
+	err := service.StartPasswordlessLogin(context.Background(), "user@gmail.com")
+	assert.ErrorIs(t, err, passwordless.ErrDisabled)
+}
+
+func TestPasswordlessServiceHandler_CompletePasswordlessLogin_code(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    func(secrets passwordless.Secrets) string
+		wantErr error
+	}{
+		{name: "ok", code: func(s passwordless.Secrets) string { return s.Code }},
+		{name: "wrong code", code: func(s passwordless.Secrets) string { return "wrong" }, wantErr: users.ErrInvalidCredentials},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			u := testUser(t)
+			challenge, secrets, err := passwordless.CreateChallenge(u, time.Minute, 3)
+			require.NoError(t, err)
+			service := newPasswordlessService(t, testPasswordlessSettings, func(m passwordlessMocks) {
+				m.users.EXPECT().GetByEmail(gomock.Any(), organizations.DefaultID, u.Email()).Return(u, nil)
+				m.logins.EXPECT().GetLatest(gomock.Any(), u.ID()).Return(challenge, nil)
+				// попытка сохраняется и с неверным кодом
+				m.logins.EXPECT().Save(gomock.Any(), challenge).Return(nil)
+				if tt.wantErr == nil {
+					m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+					m.tokenGen.EXPECT().GenerateToken(u.ID(), organizations.DefaultID, u.Role(), nil).Return("token", nil)
+				}
+			})
+
+			token, err := service.CompletePasswordlessLogin(context.Background(), "", "user@gmail.com", tt.code(secrets))
+			assert.Equal(t, 1, challenge.Attempts())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "token", token)
+		})
+	}
+}
+
+func TestPasswordlessServiceHandler_CompletePasswordlessLogin_link(t *testing.T) {
+	u := testUser(t)
+	challenge, secrets, err := passwordless.CreateChallenge(u, time.Minute, 3)
+	require.NoError(t, err)
+	service := newPasswordlessService(t, testPasswordlessSettings, func(m passwordlessMocks) {
+		m.logins.EXPECT().GetByLinkHash(gomock.Any(), passwordless.HashLink(secrets.Link)).Return(challenge, nil).Times(2)
+		m.logins.EXPECT().Save(gomock.Any(), challenge).Return(nil).Times(2)
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+		m.tokenGen.EXPECT().GenerateToken(u.ID(), organizations.DefaultID, u.Role(), nil).Return("token", nil)
+	})
+
+	token, err := service.CompletePasswordlessLogin(context.Background(), secrets.Link, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "token", token)
+
+	_, err = service.CompletePasswordlessLogin(context.Background(), secrets.Link, "", "")
+	assert.ErrorIs(t, err, passwordless.ErrConsumed, "link works once")
+}
+
+func TestPasswordlessServiceHandler_restartKeepsAttempts(t *testing.T) {
+	u := testUser(t)
+	var latest *passwordless.Challenge
+	var sent mail.Message
+	service := newPasswordlessService(t, testPasswordlessSettings, func(m passwordlessMocks) {
+		m.users.EXPECT().GetByEmail(gomock.Any(), organizations.DefaultID, u.Email()).Return(u, nil).AnyTimes()
+		m.logins.EXPECT().GetLatest(gomock.Any(), u.ID()).DoAndReturn(func(context.Context, uuid.UUID) (*passwordless.Challenge, error) {
+			if latest == nil {
+				return nil, passwordless.ErrNotFound
+			}
+			return latest, nil
+		}).AnyTimes()
+		m.logins.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *passwordless.Challenge) error {
+			if latest == nil || c.CreatedAt().After(latest.CreatedAt()) {
+				latest = c
+			}
+			return nil
+		}).AnyTimes()
+		m.sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, message mail.Message) error {
+			sent = message
+			return nil
+		}).Times(2)
+	})
+	ctx := context.Background()
+
+	require.NoError(t, service.StartPasswordlessLogin(ctx, "user@gmail.com"))
+	for range testPasswordlessSettings.MaxAttempts {
+		_, err := service.CompletePasswordlessLogin(ctx, "", "user@gmail.com", "000000x")
+		assert.ErrorIs(t, err, users.ErrInvalidCredentials)
+	}
+
+	// новый запрос не дает новых попыток, даже верный код из нового письма уже не принимается
+	require.NoError(t, service.StartPasswordlessLogin(ctx, "user@gmail.com"))
+	code := regexp.MustCompile(`код: (\d{6})`).FindStringSubmatch(sent.Body)
+	require.Len(t, code, 2)
+	_, err := service.CompletePasswordlessLogin(ctx, "", "user@gmail.com", code[1])
+	assert.ErrorIs(t, err, users.ErrInvalidCredentials)
+	assert.Equal(t, testPasswordlessSettings.MaxAttempts, latest.Attempts())
+}
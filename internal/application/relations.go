@@ -0,0 +1,243 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+// lookupPageSize по столько объектов проверяет LookupResources за один запрос к хранилищу
+const lookupPageSize = 100
+
+// RelationshipService отношения объектов в стиле Zanzibar. Записи меняют admin, проверять может
+// любой аутентифицированный вызов. token - токен согласованности из ответа записи, пустой - последнее состояние.
+// Токен несет общую ревизию хранилища: чтение с ним видит эту запись и все, что было зафиксировано до нее в любой организации
+type RelationshipService interface {
+	WriteRelationships(ctx context.Context, tuples []relations.Tuple) (relations.Revision, error)
+	DeleteRelationships(ctx context.Context, tuples []relations.Tuple) (relations.Revision, error)
+	Check(ctx context.Context, object relations.Object, relation string, subject relations.Subject, token string) (bool, relations.Revision, error)
+	Expand(ctx context.Context, object relations.Object, relation string, token string) (*relations.Node, relations.Revision, error)
+	// LookupResources id объектов типа objectType, к которым у subject есть relation
+	LookupResources(ctx context.Context, objectType string, relation string, subject relations.Subject, token string, limit int) ([]string, relations.Revision, error)
+}
+
+type RelationshipServiceHandler struct {
+	uof    UnitOfWork
+	schema *relations.Schema
+	log    *slog.Logger
+}
+
+func NewRelationshipService(uof UnitOfWork, schema *relations.Schema, log *slog.Logger) *RelationshipServiceHandler {
+	return &RelationshipServiceHandler{
+		uof:    uof,
+		schema: schema,
+		log:    log,
+	}
+}
+
+func (s *RelationshipServiceHandler) WriteRelationships(ctx context.Context, tuples []relations.Tuple) (relations.Revision, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("writing relationships", slog.Int("count", len(tuples)))
+
+	if err := s.validateWrite(ctx, tuples); err != nil {
+		log.Warn("failed to write relationships", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	var revision relations.Revision
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		revision, err = store.Relations().Write(ctx, tenantID, tuples)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to write relationships", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return revision, nil
+}
+
+func (s *RelationshipServiceHandler) DeleteRelationships(ctx context.Context, tuples []relations.Tuple) (relations.Revision, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("deleting relationships", slog.Int("count", len(tuples)))
+
+	if err := s.validateWrite(ctx, tuples); err != nil {
+		log.Warn("failed to delete relationships", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	var revision relations.Revision
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		revision, err = store.Relations().Delete(ctx, tenantID, tuples)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to delete relationships", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return revision, nil
+}
+
+func (s *RelationshipServiceHandler) Check(
+	ctx context.Context,
+	object relations.Object,
+	relation string,
+	subject relations.Subject,
+	token string,
+) (bool, relations.Revision, error) {
+	log := logger.LogWithContext(ctx, s.log)
+
+	var allowed bool
+	var revision relations.Revision
+	err := s.uof.Execute(ctx, func(store Store) error {
+		reader, rev, err := s.reader(ctx, store, token)
+		if err != nil {
+			return err
+		}
+		revision = rev
+		allowed, err = s.schema.Check(ctx, reader, object, relation, subject)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to check relationship", slog.String("error", err.Error()))
+		return false, 0, err
+	}
+	log.Debug("relationship checked", slog.String("object", object.String()), slog.String("relation", relation),
+		slog.String("subject", subject.String()), slog.Bool("allowed", allowed))
+	return allowed, revision, nil
+}
+
+func (s *RelationshipServiceHandler) Expand(ctx context.Context, object relations.Object, relation string, token string) (*relations.Node, relations.Revision, error) {
+	log := logger.LogWithContext(ctx, s.log)
+
+	var node *relations.Node
+	var revision relations.Revision
+	err := s.uof.Execute(ctx, func(store Store) error {
+		reader, rev, err := s.reader(ctx, store, token)
+		if err != nil {
+			return err
+		}
+		revision = rev
+		node, err = s.schema.Expand(ctx, reader, object, relation)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to expand relationship", slog.String("error", err.Error()))
+		return nil, 0, err
+	}
+	return node, revision, nil
+}
+
+func (s *RelationshipServiceHandler) LookupResources(
+	ctx context.Context,
+	objectType string,
+	relation string,
+	subject relations.Subject,
+	token string,
+	limit int,
+) ([]string, relations.Revision, error) {
+	log := logger.LogWithContext(ctx, s.log)
+
+	if err := s.schema.ValidateRelation(objectType, relation); err != nil {
+		log.Warn("failed to lookup resources", slog.String("error", err.Error()))
+		return nil, 0, err
+	}
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	ids := make([]string, 0)
+	var revision relations.Revision
+	err := s.uof.Execute(ctx, func(store Store) error {
+		reader, rev, err := s.reader(ctx, store, token)
+		if err != nil {
+			return err
+		}
+		revision = rev
+		after := ""
+		for len(ids) < limit {
+			page, err := store.Relations().ListObjectIDs(ctx, reader.tenantID, objectType, after, lookupPageSize)
+			if err != nil {
+				return err
+			}
+			for _, id := range page {
+				allowed, err := s.schema.Check(ctx, reader, relations.Object{Type: objectType, ID: id}, relation, subject)
+				if err != nil {
+					return err
+				}
+				if allowed {
+					ids = append(ids, id)
+				}
+				if len(ids) == limit {
+					break
+				}
+			}
+			if len(page) < lookupPageSize {
+				break
+			}
+			after = page[len(page)-1]
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn("failed to lookup resources", slog.String("error", err.Error()))
+		return nil, 0, err
+	}
+	return ids, revision, nil
+}
+
+func (s *RelationshipServiceHandler) validateWrite(ctx context.Context, tuples []relations.Tuple) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if len(tuples) == 0 {
+		return relations.ErrTuplesRequired
+	}
+	if len(tuples) > relations.MaxWriteTuples {
+		return relations.ErrTooManyTuples
+	}
+	for _, t := range tuples {
+		if err := s.schema.Validate(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reader чтение записей организации вызывающего. Общая ревизия хранилища должна быть не старее токена
+func (s *RelationshipServiceHandler) reader(ctx context.Context, store Store, token string) (*tenantRelations, relations.Revision, error) {
+	wanted, err := relations.ParseToken(token)
+	if err != nil {
+		return nil, 0, err
+	}
+	tenantID, err := tenantFromContext(ctx, store)
+	if err != nil {
+		return nil, 0, err
+	}
+	current, err := store.Relations().Revision(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if current < wanted {
+		return nil, 0, relations.ErrRevisionNotReached
+	}
+	return &tenantRelations{repo: store.Relations(), tenantID: tenantID}, current, nil
+}
+
+type tenantRelations struct {
+	repo     relations.Repository
+	tenantID uuid.UUID
+}
+
+func (r *tenantRelations) Read(ctx context.Context, object relations.Object, relation string) ([]relations.Tuple, error) {
+	return r.repo.Read(ctx, r.tenantID, object, relation)
+}
@@ -0,0 +1,122 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	mockrelations "github.com/LeoUraltsev/auth-service/internal/domain/relations/mocks"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func testRelationsSchema(t *testing.T) *relations.Schema {
+	schema, err := relations.NewSchema([]relations.Namespace{
+		{Name: "user"},
+		{Name: "document", Relations: []relations.Relation{
+			{Name: "owner"},
+			{Name: "viewer", Computed: []string{"owner"}},
+		}},
+	})
+	require.NoError(t, err)
+	return schema
+}
+
+func TestRelationshipServiceHandler_WriteRelationships(t *testing.T) {
+	tuple, err := relations.ParseTuple("document:readme#owner@user:alice")
+	require.NoError(t, err)
+	unknown, err := relations.ParseTuple("document:readme#admin@user:alice")
+	require.NoError(t, err)
+	user := authverify.NewContext(context.Background(), &authverify.Principal{UserID: uuid.New(), Role: "user"})
+
+	ctrl := gomock.NewController(t)
+	repository := mockrelations.NewMockRepository(ctrl)
+	repository.EXPECT().Write(gomock.Any(), organizations.DefaultID, []relations.Tuple{tuple}).Return(relations.Revision(7), nil)
+
+	service := NewRelationshipService(testUnitOfWork{store: testStore{relations: repository}}, testRelationsSchema(t), log)
+
+	_, err = service.WriteRelationships(user, []relations.Tuple{tuple})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	_, err = service.WriteRelationships(adminContext(), nil)
+	assert.ErrorIs(t, err, relations.ErrTuplesRequired)
+	_, err = service.WriteRelationships(adminContext(), []relations.Tuple{unknown})
+	assert.ErrorIs(t, err, relations.ErrRelationNotFound)
+
+	revision, err := service.WriteRelationships(adminContext(), []relations.Tuple{tuple})
+	require.NoError(t, err)
+	assert.Equal(t, relations.Revision(7), revision)
+}
+
+func TestRelationshipServiceHandler_Check(t *testing.T) {
+	tuple, err := relations.ParseTuple("document:readme#owner@user:alice")
+	require.NoError(t, err)
+	readme := relations.Object{Type: "document", ID: "readme"}
+
+	cases := []struct {
+		name    string
+		token   string
+		current relations.Revision
+		want    bool
+		wantErr error
+	}{
+		{name: "latest", current: 3, want: true},
+		{name: "token reached", token: relations.Revision(3).Token(), current: 3, want: true},
+		{name: "token ahead", token: relations.Revision(4).Token(), current: 3, wantErr: relations.ErrRevisionNotReached},
+		{name: "bad token", token: "???", current: 3, wantErr: relations.ErrTokenNotValid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockrelations.NewMockRepository(ctrl)
+			repository.EXPECT().Revision(gomock.Any()).Return(tt.current, nil).AnyTimes()
+			repository.EXPECT().Read(gomock.Any(), organizations.DefaultID, readme, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ uuid.UUID, _ relations.Object, relation string) ([]relations.Tuple, error) {
+					if relation == "owner" {
+						return []relations.Tuple{tuple}, nil
+					}
+					return nil, nil
+				}).AnyTimes()
+
+			service := NewRelationshipService(testUnitOfWork{store: testStore{relations: repository}}, testRelationsSchema(t), log)
+
+			allowed, revision, err := service.Check(adminContext(), readme, "viewer", tuple.Subject, tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, allowed)
+			assert.Equal(t, tt.current, revision)
+		})
+	}
+}
+
+func TestRelationshipServiceHandler_LookupResources(t *testing.T) {
+	alice := relations.Subject{Object: relations.Object{Type: "user", ID: "alice"}}
+
+	ctrl := gomock.NewController(t)
+	repository := mockrelations.NewMockRepository(ctrl)
+	repository.EXPECT().Revision(gomock.Any()).Return(relations.Revision(1), nil)
+	repository.EXPECT().ListObjectIDs(gomock.Any(), organizations.DefaultID, "document", "", lookupPageSize).
+		Return([]string{"a", "b", "c"}, nil)
+	repository.EXPECT().Read(gomock.Any(), organizations.DefaultID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, object relations.Object, relation string) ([]relations.Tuple, error) {
+			if object.ID != "b" && relation == "owner" {
+				return []relations.Tuple{{Object: object, Relation: relation, Subject: alice}}, nil
+			}
+			return nil, nil
+		}).AnyTimes()
+
+	service := NewRelationshipService(testUnitOfWork{store: testStore{relations: repository}}, testRelationsSchema(t), log)
+
+	ids, _, err := service.LookupResources(adminContext(), "document", "viewer", alice, "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, ids)
+
+	_, _, err = service.LookupResources(adminContext(), "document", "admin", alice, "", 0)
+	assert.ErrorIs(t, err, relations.ErrRelationNotFound)
+}
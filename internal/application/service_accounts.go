@@ -0,0 +1,170 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+// ServiceAccountService управление сервисными аккаунтами, все методы только для admin организации по умолчанию.
+// Токены аккаунты получают через /token с grant_type=client_credentials
+type ServiceAccountService interface {
+	// CreateServiceAccount возвращает аккаунт и секрет, секрет показывается один раз
+	CreateServiceAccount(ctx context.Context, name string, role string) (*serviceaccounts.ServiceAccount, string, error)
+	ListServiceAccounts(ctx context.Context, limit int, offset int) ([]*serviceaccounts.ServiceAccount, error)
+	// RotateServiceAccountSecret выпускает новый секрет, старые действуют еще gracePeriod
+	RotateServiceAccountSecret(ctx context.Context, id uuid.UUID, gracePeriod time.Duration) (string, error)
+	DisableServiceAccount(ctx context.Context, id uuid.UUID) error
+}
+
+type ServiceAccountServiceHandler struct {
+	uof UnitOfWork
+	log *slog.Logger
+}
+
+func NewServiceAccountService(uof UnitOfWork, log *slog.Logger) *ServiceAccountServiceHandler {
+	return &ServiceAccountServiceHandler{
+		uof: uof,
+		log: log,
+	}
+}
+
+func (s *ServiceAccountServiceHandler) CreateServiceAccount(ctx context.Context, name string, role string) (*serviceaccounts.ServiceAccount, string, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("creating service account")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to create service account", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+
+	account, secret, err := serviceaccounts.CreateServiceAccount(name, users.Role(role))
+	if err != nil {
+		log.Warn("failed to create service account", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+
+	err = s.uof.Execute(ctx, func(store Store) error {
+		if err := store.ServiceAccounts().Save(ctx, account); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, serviceAccountAudit(audit.ActionServiceAccountCreated, account.ID()), nil)
+	})
+	if err != nil {
+		log.Warn("failed to create service account", slog.String("error", err.Error()))
+		return nil, "", err
+	}
+
+	log.Info("service account created", slog.String("client_id", account.ClientID()))
+	return account, secret, nil
+}
+
+func (s *ServiceAccountServiceHandler) ListServiceAccounts(ctx context.Context, limit int, offset int) ([]*serviceaccounts.ServiceAccount, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting service accounts")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to get service accounts", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var accounts []*serviceaccounts.ServiceAccount
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		accounts, err = store.ServiceAccounts().List(ctx, limit, offset)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get service accounts", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (s *ServiceAccountServiceHandler) RotateServiceAccountSecret(ctx context.Context, id uuid.UUID, gracePeriod time.Duration) (string, error) {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("service_account_id", id.String()))
+	log.Info("rotating service account secret")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to rotate service account secret", slog.String("error", err.Error()))
+		return "", err
+	}
+
+	var secret string
+	entry := serviceAccountAudit(audit.ActionServiceAccountRotated, id)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		account, err := store.ServiceAccounts().Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		secret, err = account.RotateSecret(gracePeriod)
+		if err != nil {
+			return err
+		}
+		if err = store.ServiceAccounts().Save(ctx, account); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to rotate service account secret", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return "", err
+	}
+
+	log.Info("service account secret rotated")
+	return secret, nil
+}
+
+func (s *ServiceAccountServiceHandler) DisableServiceAccount(ctx context.Context, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("service_account_id", id.String()))
+	log.Info("disabling service account")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to disable service account", slog.String("error", err.Error()))
+		return err
+	}
+
+	entry := serviceAccountAudit(audit.ActionServiceAccountDisabled, id)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		account, err := store.ServiceAccounts().Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = account.Disable(); err != nil {
+			return err
+		}
+		if err = store.ServiceAccounts().Save(ctx, account); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to disable service account", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	log.Info("service account disabled")
+	return nil
+}
+
+func serviceAccountAudit(action audit.Action, id uuid.UUID) auditEntry {
+	return auditEntry{
+		action:     action,
+		targetType: audit.TargetServiceAccount,
+		targetID:   id.String(),
+	}
+}
@@ -0,0 +1,113 @@
+package application
+
+import (
+	"context"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
+	mockserviceaccounts "github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestOAuthServiceHandler_Token_serviceAccount(t *testing.T) {
+	account, secret, err := serviceaccounts.CreateServiceAccount("billing", users.RoleAdmin)
+	require.NoError(t, err)
+	disabled, disabledSecret, err := serviceaccounts.CreateServiceAccount("legacy", users.RoleUser)
+	require.NoError(t, err)
+	require.NoError(t, disabled.Disable())
+
+	cases := []struct {
+		name    string
+		account *serviceaccounts.ServiceAccount
+		secret  string
+		scope   string
+		wantErr error
+	}{
+		{name: "valid", account: account, secret: secret},
+		{name: "wrong secret", account: account, secret: "sas_wrong", wantErr: oauth.ErrClientAuthFailed},
+		{name: "disabled", account: disabled, secret: disabledSecret, wantErr: oauth.ErrClientAuthFailed},
+		{name: "scope", account: account, secret: secret, scope: "openid", wantErr: oauth.ErrScopeNotAllowed},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockserviceaccounts.NewMockRepository(ctrl)
+			if tt.scope == "" {
+				repository.EXPECT().GetByClientID(gomock.Any(), tt.account.ClientID()).Return(tt.account, nil)
+			}
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			issuer := tokenIssuerFunc(func(grant oauth.AccessGrant) (string, time.Duration, error) {
+				assert.Equal(t, tt.account.ID(), grant.UserID)
+				assert.Equal(t, tt.account.Role(), grant.Role)
+				assert.Equal(t, users.PrincipalServiceAccount, grant.Principal)
+				return "access", time.Hour, nil
+			})
+			uof := testUnitOfWork{store: testStore{accounts: repository, audit: auditRepository}}
+			service := NewOAuthService(uof, nil, issuer, time.Minute, time.Hour, log)
+
+			res, err := service.Token(context.Background(), oauth.TokenRequest{
+				GrantType:    oauth.GrantClientCredentials.String(),
+				ClientID:     tt.account.ClientID(),
+				ClientSecret: tt.secret,
+				Scope:        tt.scope,
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "access", res.AccessToken)
+			assert.Empty(t, res.RefreshToken)
+		})
+	}
+}
+
+func TestServiceAccountServiceHandler_RotateServiceAccountSecret(t *testing.T) {
+	account, old, err := serviceaccounts.CreateServiceAccount("billing", users.RoleUser)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		role    string
+		wantErr error
+	}{
+		{name: "admin", role: "admin"},
+		{name: "user", role: "user", wantErr: ErrPermissionDenied},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockserviceaccounts.NewMockRepository(ctrl)
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			if tt.wantErr == nil {
+				repository.EXPECT().Get(gomock.Any(), account.ID()).Return(account, nil)
+				repository.EXPECT().Save(gomock.Any(), account).Return(nil)
+				auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+			}
+			uof := testUnitOfWork{store: testStore{accounts: repository, audit: auditRepository}}
+			service := NewServiceAccountService(uof, log)
+
+			ctx := authverify.NewContext(context.Background(), &authverify.Principal{UserID: uuid.New(), Role: tt.role})
+			secret, err := service.RotateServiceAccountSecret(ctx, account.ID(), time.Hour)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			now := time.Now().UTC()
+			assert.NoError(t, account.Authenticate(old, now))
+			assert.NoError(t, account.Authenticate(secret, now))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/domain/emailchanges"
+	"github.com/LeoUraltsev/auth-service/internal/domain/exports"
+	"github.com/LeoUraltsev/auth-service/internal/domain/groups"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/domain/invitations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/passwordless"
+	"github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+)
+
+// Store репозитории, работающие в рамках одной транзакции
+type Store interface {
+	Users() users.UserRepository
+	Webhooks() webhooks.Repository
+	Changes() changes.Repository
+	Audit() audit.Repository
+	OAuth() oauth.Repository
+	Identities() identity.Repository
+	ServiceAccounts() serviceaccounts.Repository
+	APIKeys() apikeys.Repository
+	Organizations() organizations.Repository
+	Invitations() invitations.Repository
+	Relations() relations.Repository
+	Groups() groups.Repository
+	Exports() exports.Repository
+	EmailChanges() emailchanges.Repository
+	Passwordless() passwordless.Repository
+}
+
+type UnitOfWork interface {
+	Execute(ctx context.Context, fn func(store Store) error) error
+}
@@ -3,24 +3,34 @@ package application
 import (
 	"context"
 	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
 	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
 	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
 	"github.com/google/uuid"
 	"log/slog"
 	"strings"
+	"time"
 )
 
-type UnitOfWork interface {
-	Execute(ctx context.Context, fn func(repository users.UserRepository) error) error
-}
+var (
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrRegistrationClosed регистрация только по приглашениям
+	ErrRegistrationClosed = errors.New("registration is invite only")
+)
 
 type UserService interface {
 	CreateUser(ctx context.Context, name string, email string, password string) (uuid.UUID, error)
 	GetUser(ctx context.Context, id uuid.UUID) (*users.User, error)
 	GetListUsers(ctx context.Context) ([]*users.User, error)
+	// UpdateUser новый email не применяется сразу, а запускает смену с подтверждением, см. EmailChangeService
 	UpdateUser(ctx context.Context, id uuid.UUID, name string, email string, password string) error
 	DeleteUser(ctx context.Context, id uuid.UUID) error
 	Login(ctx context.Context, email string, password string) (string, error)
+	// ChangeTemporaryPassword заменяет пароль, выданный администратором, и выполняет вход
+	ChangeTemporaryPassword(ctx context.Context, email string, temporaryPassword string, newPassword string) (string, error)
 }
 
 type UserServiceHandler struct {
@@ -28,6 +38,9 @@ type UserServiceHandler struct {
 	passwordHasher   users.PasswordHasher
 	passwordVerifier users.PasswordVerifier
 	tokenGen         users.TokenGenerator
+	inviteOnly       bool
+	groupsClaim      bool
+	emailChange      *EmailChangeServiceHandler
 	log              *slog.Logger
 }
 
@@ -47,66 +60,131 @@ func NewUserService(
 	}
 }
 
+// WithInviteOnly закрывает открытую регистрацию через CreateUser, пользователи появляются только по приглашениям
+func (s *UserServiceHandler) WithInviteOnly(inviteOnly bool) *UserServiceHandler {
+	s.inviteOnly = inviteOnly
+	return s
+}
+
+// WithGroupsClaim токены Login получают имена групп пользователя
+func (s *UserServiceHandler) WithGroupsClaim(enabled bool) *UserServiceHandler {
+	s.groupsClaim = enabled
+	return s
+}
+
+// WithEmailChange новый email из UpdateUser применяется после подтверждения по ссылке из письма
+func (s *UserServiceHandler) WithEmailChange(emailChange *EmailChangeServiceHandler) *UserServiceHandler {
+	s.emailChange = emailChange
+	return s
+}
+
 func (s *UserServiceHandler) CreateUser(ctx context.Context, name string, email string, password string) (uuid.UUID, error) {
 	log := logger.LogWithContext(ctx, s.log)
 	log.Info("creating user")
+	if s.inviteOnly {
+		log.Warn("failed to create user", slog.String("error", ErrRegistrationClosed.Error()))
+		return uuid.Nil, ErrRegistrationClosed
+	}
 	var user *users.User
-	err := s.uof.Execute(ctx, func(repo users.UserRepository) error {
-		n, err := users.NewName(name)
-		if err != nil {
-			log.Warn("failed to create user", slog.String("name", name), slog.String("error", err.Error()))
-			return err
-		}
-		e, err := users.NewEmail(email)
-		if err != nil {
-			log.Warn("failed to create user", slog.String("email", email), slog.String("error", err.Error()))
-			return err
-		}
-		if err = s.checkUniqueEmail(ctx, e); err != nil {
-			log.Warn("failed to create user", slog.String("email", email), slog.String("error", err.Error()))
-			return err
-		}
-
-		if strings.TrimSpace(password) == "" {
-			log.Warn("failed to create user", slog.String("error", users.ErrPasswordRequired.Error()))
-		}
-		hashPassword, err := s.hashPassword([]byte(password))
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
 		if err != nil {
 			log.Warn("failed to create user", slog.String("error", err.Error()))
 			return err
 		}
-		p, err := users.NewPassword(hashPassword)
+		user, err = s.createUser(ctx, store, tenantID, name, email, password, users.RoleUser)
 		if err != nil {
-			log.Warn("failed to create user", slog.String("error", err.Error()))
 			return err
 		}
-		user, err = users.CreateUser(n, e, p)
-		if err != nil {
-			log.Warn("failed to create user", slog.Any("user", &user), slog.String("error", err.Error()))
-			return err
-		}
-
-		if err := repo.Save(ctx, user); err != nil {
-			log.Warn("failed to create user", slog.Any("user", user), slog.String("error", err.Error()))
+		entry := userAudit(audit.ActionUserCreated, user.ID())
+		entry.actorID = user.ID()
+		if err := recordAudit(ctx, store, entry, nil); err != nil {
+			log.Warn("failed to record audit event", slog.String("error", err.Error()))
 			return err
 		}
 		log.Info("user created")
 		return nil
 	})
 	if err != nil {
+		recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionUserCreated, uuid.Nil), err)
 		return uuid.Nil, err
 	}
 	return user.ID(), nil
 }
 
+// createUser проверяет данные, сохраняет пользователя организации tenantID и публикует изменение.
+// Общий путь для CreateUser и принятия приглашения, аудит пишет вызывающий
+func (s *UserServiceHandler) createUser(
+	ctx context.Context,
+	store Store,
+	tenantID uuid.UUID,
+	name string,
+	email string,
+	password string,
+	role users.Role,
+) (*users.User, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	n, err := users.NewName(name)
+	if err != nil {
+		log.Warn("failed to create user", slog.String("name", name), slog.String("error", err.Error()))
+		return nil, err
+	}
+	e, err := users.NewEmail(email)
+	if err != nil {
+		log.Warn("failed to create user", slog.String("email", email), slog.String("error", err.Error()))
+		return nil, err
+	}
+	if err = s.checkUniqueEmail(ctx, tenantID, e); err != nil {
+		log.Warn("failed to create user", slog.String("email", email), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if strings.TrimSpace(password) == "" {
+		log.Warn("failed to create user", slog.String("error", users.ErrPasswordRequired.Error()))
+	}
+	hashPassword, err := s.hashPassword([]byte(password))
+	if err != nil {
+		log.Warn("failed to create user", slog.String("error", err.Error()))
+		return nil, err
+	}
+	p, err := users.NewPassword(hashPassword)
+	if err != nil {
+		log.Warn("failed to create user", slog.String("error", err.Error()))
+		return nil, err
+	}
+	user, err := users.CreateUser(tenantID, n, e, p)
+	if err != nil {
+		log.Warn("failed to create user", slog.Any("user", &user), slog.String("error", err.Error()))
+		return nil, err
+	}
+	if err = user.UpdateRole(role); err != nil {
+		log.Warn("failed to create user", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if err := store.Users().Save(ctx, user); err != nil {
+		log.Warn("failed to create user", slog.Any("user", user), slog.String("error", err.Error()))
+		return nil, err
+	}
+	if err := publishUserChange(ctx, store, changes.TypeCreated, user); err != nil {
+		log.Warn("failed to publish user event", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return user, nil
+}
+
 func (s *UserServiceHandler) GetUser(ctx context.Context, id uuid.UUID) (*users.User, error) {
 	log := logger.LogWithContext(ctx, s.log)
 	log.Info("getting user")
 
 	var user *users.User
-	err := s.uof.Execute(ctx, func(repo users.UserRepository) error {
-		var err error
-		user, err = repo.Get(ctx, id)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		repo := store.Users()
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		user, err = repo.Get(ctx, tenantID, id)
 		if err != nil {
 			log.Warn("failed to get user", slog.String("id", id.String()))
 			return err
@@ -125,8 +203,13 @@ func (s *UserServiceHandler) GetListUsers(ctx context.Context) ([]*users.User, e
 	var u []*users.User
 	log.Info("getting all users")
 
-	err := s.uof.Execute(ctx, func(repo users.UserRepository) error {
-		u, err := repo.GetAll(ctx)
+	err := s.uof.Execute(ctx, func(store Store) error {
+		repo := store.Users()
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		u, err = repo.GetAll(ctx, tenantID)
 		if err != nil {
 			log.Warn("failed to get all users", slog.String("error", err.Error()))
 			return err
@@ -147,8 +230,10 @@ func (s *UserServiceHandler) UpdateUser(ctx context.Context, id uuid.UUID, name
 	log.Info("updating user")
 
 	var u *users.User
+	var pending *pendingEmailChange
 
-	err := s.uof.Execute(ctx, func(repo users.UserRepository) error {
+	err := s.uof.Execute(ctx, func(store Store) error {
+		repo := store.Users()
 		ctxUserID, err := userIDFromContext(ctx)
 		if err != nil {
 			log.Error("user id missing in context", slog.String("id", id.String()))
@@ -161,7 +246,11 @@ func (s *UserServiceHandler) UpdateUser(ctx context.Context, id uuid.UUID, name
 		}
 
 		log.Info("getting user")
-		u, err = repo.Get(ctx, id)
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		u, err = repo.Get(ctx, tenantID, id)
 		if err != nil {
 			log.Warn("failed to get user", slog.String("id", id.String()))
 			return err
@@ -192,10 +281,22 @@ func (s *UserServiceHandler) UpdateUser(ctx context.Context, id uuid.UUID, name
 				log.Warn("failed to update user email", slog.String("email", email), slog.String("error", err.Error()))
 				return err
 			}
-			if err := u.UpdateEmail(newEmail); err != nil {
-				log.Warn("failed to update user email", slog.String("email", email), slog.String("error", err.Error()))
+			if newEmail != u.Email() {
+				if s.emailChange == nil {
+					return errors.New("email change is not configured")
+				}
+				p, err := s.emailChange.start(ctx, store, u, newEmail)
+				if err != nil {
+					log.Warn("failed to request email change", slog.String("error", err.Error()))
+					return err
+				}
+				pending = &p
+				if err := recordAudit(ctx, store, userAudit(audit.ActionEmailChangeRequested, id), nil); err != nil {
+					log.Warn("failed to record audit event", slog.String("error", err.Error()))
+					return err
+				}
+				log.Debug("email change requested")
 			}
-			log.Debug("success updating user email")
 		}
 
 		if password != "" {
@@ -215,6 +316,10 @@ func (s *UserServiceHandler) UpdateUser(ctx context.Context, id uuid.UUID, name
 				log.Warn("failed to update user password", slog.String("error", err.Error()))
 				return err
 			}
+			if err := recordAudit(ctx, store, userAudit(audit.ActionPasswordChanged, id), nil); err != nil {
+				log.Warn("failed to record audit event", slog.String("error", err.Error()))
+				return err
+			}
 			log.Debug("success updating user password")
 		}
 
@@ -223,14 +328,32 @@ func (s *UserServiceHandler) UpdateUser(ctx context.Context, id uuid.UUID, name
 			log.Warn("failed to update user", slog.String("error", err.Error()))
 			return err
 		}
+		if err = publishUserChange(ctx, store, changes.TypeUpdated, u); err != nil {
+			log.Warn("failed to publish user event", slog.String("error", err.Error()))
+			return err
+		}
+		if err = recordAudit(ctx, store, userAudit(audit.ActionUserUpdated, id), nil); err != nil {
+			log.Warn("failed to record audit event", slog.String("error", err.Error()))
+			return err
+		}
 		log.Info("user updated")
 		return nil
 	})
 
 	if err != nil {
+		recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionUserUpdated, id), err)
+		if password != "" {
+			recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionPasswordChanged, id), err)
+		}
 		return err
 	}
 
+	if pending != nil {
+		if err = s.emailChange.notify(ctx, *pending); err != nil {
+			log.Error("failed to send email change mail", slog.String("error", err.Error()))
+			return err
+		}
+	}
 	return nil
 }
 
@@ -240,7 +363,8 @@ func (s *UserServiceHandler) DeleteUser(ctx context.Context, id uuid.UUID) error
 
 	var u *users.User
 
-	err := s.uof.Execute(ctx, func(repo users.UserRepository) error {
+	err := s.uof.Execute(ctx, func(store Store) error {
+		repo := store.Users()
 		ctxUserID, err := userIDFromContext(ctx)
 		if err != nil {
 			log.Error("user id missing in context", slog.String("id", id.String()))
@@ -252,7 +376,11 @@ func (s *UserServiceHandler) DeleteUser(ctx context.Context, id uuid.UUID) error
 			return errors.New("user id mismatch token user")
 		}
 
-		u, err = repo.Get(ctx, id)
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		u, err = repo.Get(ctx, tenantID, id)
 		if err != nil {
 			log.Warn("failed to get user", slog.String("id", id.String()))
 			return err
@@ -263,7 +391,7 @@ func (s *UserServiceHandler) DeleteUser(ctx context.Context, id uuid.UUID) error
 			return errors.New("user isnt active")
 		}
 
-		err = u.Delete()
+		err = u.Delete(time.Now().UTC())
 		if err != nil {
 			log.Warn("failed to delete user", slog.String("id", id.String()))
 			return err
@@ -273,11 +401,20 @@ func (s *UserServiceHandler) DeleteUser(ctx context.Context, id uuid.UUID) error
 			log.Warn("failed to delete user", slog.String("id", id.String()))
 			return err
 		}
+		if err = publishUserChange(ctx, store, changes.TypeDeleted, u); err != nil {
+			log.Warn("failed to publish user event", slog.String("error", err.Error()))
+			return err
+		}
+		if err = recordAudit(ctx, store, userAudit(audit.ActionUserDeleted, id), nil); err != nil {
+			log.Warn("failed to record audit event", slog.String("error", err.Error()))
+			return err
+		}
 		log.Info("user deleted")
 		return nil
 	})
 
 	if err != nil {
+		recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionUserDeleted, id), err)
 		return err
 	}
 
@@ -285,8 +422,12 @@ func (s *UserServiceHandler) DeleteUser(ctx context.Context, id uuid.UUID) error
 }
 
 func (s *UserServiceHandler) Login(ctx context.Context, email string, password string) (string, error) {
+	log := logger.LogWithContext(ctx, s.log)
 	var token string
-	err := s.uof.Execute(ctx, func(repo users.UserRepository) error {
+	// userID известен, если пользователь найден, чтобы связать неудачные попытки входа с аккаунтом
+	var userID uuid.UUID
+	err := s.uof.Execute(ctx, func(store Store) error {
+		repo := store.Users()
 		e, err := users.NewEmail(email)
 		if err != nil {
 			return err
@@ -296,10 +437,23 @@ func (s *UserServiceHandler) Login(ctx context.Context, email string, password s
 			return err
 		}
 
-		usr, err := repo.GetByEmail(ctx, e)
+		tenantID, err := tenantFromContext(ctx, store)
+		// неизвестная организация не отличается от неверного пароля
+		if errors.Is(err, organizations.ErrOrganizationNotFound) {
+			return users.ErrInvalidCredentials
+		}
+		if err != nil {
+			return err
+		}
+		usr, err := repo.GetByEmail(ctx, tenantID, e)
+		// неизвестный email не отличается от неверного пароля
+		if errors.Is(err, users.ErrUserNotFound) {
+			return users.ErrInvalidCredentials
+		}
 		if err != nil {
 			return err
 		}
+		userID = usr.ID()
 
 		verify, err := s.passwordVerifier.Verify(usr.Password().Hash(), p.Hash())
 		if err != nil {
@@ -308,22 +462,149 @@ func (s *UserServiceHandler) Login(ctx context.Context, email string, password s
 		if !verify {
 			return users.ErrInvalidCredentials
 		}
-		token, err = s.tokenGen.GenerateToken(usr.ID())
+		// удаленный пользователь не отличается от неверного пароля
+		err = usr.CanAuthenticate(time.Now().UTC())
+		if errors.Is(err, users.ErrUserNotActive) {
+			return users.ErrInvalidCredentials
+		}
 		if err != nil {
 			return err
 		}
-		return nil
+		if usr.Access().PasswordChangeRequired {
+			return users.ErrPasswordChangeRequired
+		}
+		token, err = issueLoginToken(ctx, store, s.tokenGen, s.groupsClaim, usr)
+		return err
+	})
+	if err != nil {
+		recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionLogin, userID), err)
+		return "", err
+	}
+
+	return token, nil
+}
+
+// issueLoginToken выдает токен доступа после проверки учетных данных и записывает вход в аудит,
+// общий для всех способов входа
+func issueLoginToken(ctx context.Context, store Store, tokenGen users.TokenGenerator, groupsClaim bool, usr *users.User) (string, error) {
+	groupNames, err := tokenGroups(ctx, store, groupsClaim, usr.TenantID(), usr.ID())
+	if err != nil {
+		return "", err
+	}
+	token, err := tokenGen.GenerateToken(usr.ID(), usr.TenantID(), usr.Role(), groupNames)
+	if err != nil {
+		return "", err
+	}
+	entry := userAudit(audit.ActionLogin, usr.ID())
+	entry.actorID = usr.ID()
+	if err = recordAudit(ctx, store, entry, nil); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *UserServiceHandler) ChangeTemporaryPassword(
+	ctx context.Context,
+	email string,
+	temporaryPassword string,
+	newPassword string,
+) (string, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("changing temporary password")
+
+	var token string
+	var userID uuid.UUID
+	err := s.uof.Execute(ctx, func(store Store) error {
+		repo := store.Users()
+		e, err := users.NewEmail(email)
+		if err != nil {
+			return err
+		}
+		if _, err = users.NewPassword([]byte(newPassword)); err != nil {
+			return err
+		}
+
+		tenantID, err := tenantFromContext(ctx, store)
+		if errors.Is(err, organizations.ErrOrganizationNotFound) {
+			return users.ErrInvalidCredentials
+		}
+		if err != nil {
+			return err
+		}
+		usr, err := repo.GetByEmail(ctx, tenantID, e)
+		if errors.Is(err, users.ErrUserNotFound) {
+			return users.ErrInvalidCredentials
+		}
+		if err != nil {
+			return err
+		}
+		userID = usr.ID()
+
+		verify, err := s.passwordVerifier.Verify(usr.Password().Hash(), []byte(temporaryPassword))
+		if err != nil {
+			return err
+		}
+		if !verify {
+			return users.ErrInvalidCredentials
+		}
+		err = usr.CanAuthenticate(time.Now().UTC())
+		if errors.Is(err, users.ErrUserNotActive) {
+			return users.ErrInvalidCredentials
+		}
+		if err != nil {
+			return err
+		}
+		if !usr.Access().PasswordChangeRequired {
+			return users.ErrPasswordChangeNotRequired
+		}
+		if newPassword == temporaryPassword {
+			return users.ErrPasswordNotChanged
+		}
+
+		hash, err := s.hashPassword([]byte(newPassword))
+		if err != nil {
+			return err
+		}
+		p, err := users.NewPassword(hash)
+		if err != nil {
+			return err
+		}
+		if err = usr.UpdatePassword(p); err != nil {
+			return err
+		}
+		if err = repo.Save(ctx, usr); err != nil {
+			return err
+		}
+		if err = publishUserChange(ctx, store, changes.TypeUpdated, usr); err != nil {
+			return err
+		}
+
+		groupNames, err := tokenGroups(ctx, store, s.groupsClaim, usr.TenantID(), usr.ID())
+		if err != nil {
+			return err
+		}
+		token, err = s.tokenGen.GenerateToken(usr.ID(), usr.TenantID(), usr.Role(), groupNames)
+		if err != nil {
+			return err
+		}
+		entry := userAudit(audit.ActionPasswordChanged, usr.ID())
+		entry.actorID = usr.ID()
+		return recordAudit(ctx, store, entry, nil)
 	})
 	if err != nil {
+		log.Warn("failed to change temporary password", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, userAudit(audit.ActionPasswordChanged, userID), err)
 		return "", err
 	}
 
+	log.Info("temporary password changed", slog.String("user_id", userID.String()))
 	return token, nil
 }
 
-func (s *UserServiceHandler) checkUniqueEmail(ctx context.Context, email users.Email) error {
-	err := s.uof.Execute(ctx, func(repo users.UserRepository) error {
-		exists, err := repo.ExistsByEmail(ctx, email)
+func (s *UserServiceHandler) checkUniqueEmail(ctx context.Context, tenantID uuid.UUID, email users.Email) error {
+	err := s.uof.Execute(ctx, func(store Store) error {
+		repo := store.Users()
+		exists, err := repo.ExistsByEmail(ctx, tenantID, email)
 		if err != nil {
 			return err
 		}
@@ -344,11 +625,26 @@ func (s *UserServiceHandler) hashPassword(password []byte) ([]byte, error) {
 	return s.passwordHasher.Hash(password)
 }
 
-// todo: возможно нужен пакет для хранения констант-ключей
-func userIDFromContext(ctx context.Context) (uuid.UUID, error) {
-	u, ok := ctx.Value("user_id").(uuid.UUID)
-	if !ok {
-		return uuid.Nil, errors.New("user id not found in context")
+var webhookEvents = map[changes.Type]webhooks.EventType{
+	changes.TypeCreated: webhooks.EventUserCreated,
+	changes.TypeUpdated: webhooks.EventUserUpdated,
+	changes.TypeDeleted: webhooks.EventUserDeleted,
+}
+
+// publishUserChange фиксирует изменение пользователя в той же транзакции, что и repo.Save:
+// запись в ленту изменений и доставки вебхуков
+func publishUserChange(ctx context.Context, store Store, changeType changes.Type, user *users.User) error {
+	change, err := changes.CreateUserChange(changeType, user)
+	if err != nil {
+		return err
 	}
-	return u, nil
+	if err = store.Changes().Append(ctx, change); err != nil {
+		return err
+	}
+
+	event, err := webhooks.NewUserEvent(webhookEvents[changeType], user)
+	if err != nil {
+		return err
+	}
+	return enqueueWebhooks(ctx, store.Webhooks(), event)
 }
@@ -0,0 +1,203 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+// UserAdminService управление пользователями организации, доступно только admin.
+// Правила переходов проверяет агрегат users.User
+type UserAdminService interface {
+	// SuspendUser блокирует вход и завершает сессии, until нулевой для бессрочной блокировки
+	SuspendUser(ctx context.Context, id uuid.UUID, reason string, until time.Time) error
+	// ReactivateUser снимает блокировку, восстанавливает удаленного пользователя
+	ReactivateUser(ctx context.Context, id uuid.UUID) error
+	// ForceLogout отзывает refresh токены и все выданные ранее access токены
+	ForceLogout(ctx context.Context, id uuid.UUID) error
+	// SetTemporaryPassword пароль придется сменить при следующем входе
+	SetTemporaryPassword(ctx context.Context, id uuid.UUID, password string) error
+	ReassignEmail(ctx context.Context, id uuid.UUID, email string) error
+	// EraseUser немедленно стирает персональные данные без срока хранения, отменить нельзя
+	EraseUser(ctx context.Context, id uuid.UUID) error
+}
+
+type UserAdminServiceHandler struct {
+	uof            UnitOfWork
+	passwordHasher users.PasswordHasher
+	log            *slog.Logger
+}
+
+func NewUserAdminService(uof UnitOfWork, passwordHasher users.PasswordHasher, log *slog.Logger) *UserAdminServiceHandler {
+	return &UserAdminServiceHandler{
+		uof:            uof,
+		passwordHasher: passwordHasher,
+		log:            log,
+	}
+}
+
+func (s *UserAdminServiceHandler) SuspendUser(ctx context.Context, id uuid.UUID, reason string, until time.Time) error {
+	return s.modify(ctx, id, audit.ActionUserSuspended, func(store Store, u *users.User, now time.Time) error {
+		if err := u.Suspend(reason, until, now); err != nil {
+			return err
+		}
+		return store.OAuth().RevokeUserRefreshTokens(ctx, u.ID(), now)
+	})
+}
+
+func (s *UserAdminServiceHandler) ReactivateUser(ctx context.Context, id uuid.UUID) error {
+	return s.modify(ctx, id, audit.ActionUserReactivated, func(store Store, u *users.User, now time.Time) error {
+		// пока пользователь был удален, его email мог занять другой
+		if u.IsDeleted() {
+			exists, err := store.Users().ExistsByEmail(ctx, u.TenantID(), u.Email())
+			if err != nil {
+				return err
+			}
+			if exists {
+				return users.ErrEmailAlreadyExists
+			}
+		}
+		return u.Reactivate(now)
+	})
+}
+
+func (s *UserAdminServiceHandler) ForceLogout(ctx context.Context, id uuid.UUID) error {
+	return s.modify(ctx, id, audit.ActionSessionsRevoked, func(store Store, u *users.User, now time.Time) error {
+		u.ForceLogout(now)
+		return store.OAuth().RevokeUserRefreshTokens(ctx, u.ID(), now)
+	})
+}
+
+func (s *UserAdminServiceHandler) SetTemporaryPassword(ctx context.Context, id uuid.UUID, password string) error {
+	if _, err := users.NewPassword([]byte(password)); err != nil {
+		return err
+	}
+	hash, err := s.passwordHasher.Hash([]byte(password))
+	if err != nil {
+		return err
+	}
+	p, err := users.NewPassword(hash)
+	if err != nil {
+		return err
+	}
+	return s.modify(ctx, id, audit.ActionPasswordReset, func(store Store, u *users.User, now time.Time) error {
+		if err := u.SetTemporaryPassword(p, now); err != nil {
+			return err
+		}
+		return store.OAuth().RevokeUserRefreshTokens(ctx, u.ID(), now)
+	})
+}
+
+func (s *UserAdminServiceHandler) ReassignEmail(ctx context.Context, id uuid.UUID, email string) error {
+	e, err := users.NewEmail(email)
+	if err != nil {
+		return err
+	}
+	return s.modify(ctx, id, audit.ActionEmailReassigned, func(store Store, u *users.User, now time.Time) error {
+		if u.Email() == e {
+			return nil
+		}
+		exists, err := store.Users().ExistsByEmail(ctx, u.TenantID(), e)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return users.ErrEmailAlreadyExists
+		}
+		if err = u.ReassignEmail(e, now); err != nil {
+			return err
+		}
+		return store.OAuth().RevokeUserRefreshTokens(ctx, u.ID(), now)
+	})
+}
+
+func (s *UserAdminServiceHandler) EraseUser(ctx context.Context, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log).With(slog.String("user_id", id.String()))
+	log.Info("erasing user")
+
+	entry := userAudit(audit.ActionUserErased, id)
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to erase user", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		u, err := store.Users().Get(ctx, tenantID, id)
+		if err != nil {
+			return err
+		}
+		if err = eraseUser(ctx, store, u, time.Now().UTC()); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to erase user", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	log.Info("user erased")
+	return nil
+}
+
+// modify загружает пользователя организации, применяет действие и сохраняет результат
+// в одной транзакции с лентой изменений и аудитом
+func (s *UserAdminServiceHandler) modify(
+	ctx context.Context,
+	id uuid.UUID,
+	action audit.Action,
+	apply func(store Store, u *users.User, now time.Time) error,
+) error {
+	log := logger.LogWithContext(ctx, s.log).With(
+		slog.String("user_id", id.String()),
+		slog.String("action", action.String()),
+	)
+	log.Info("managing user")
+
+	entry := userAudit(action, id)
+	if err := requireAdmin(ctx); err != nil {
+		log.Warn("failed to manage user", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	err := s.uof.Execute(ctx, func(store Store) error {
+		tenantID, err := tenantFromContext(ctx, store)
+		if err != nil {
+			return err
+		}
+		u, err := store.Users().Get(ctx, tenantID, id)
+		if err != nil {
+			return err
+		}
+		if err = apply(store, u, time.Now().UTC()); err != nil {
+			return err
+		}
+		if err = store.Users().Save(ctx, u); err != nil {
+			return err
+		}
+		if err = publishUserChange(ctx, store, changes.TypeUpdated, u); err != nil {
+			return err
+		}
+		return recordAudit(ctx, store, entry, nil)
+	})
+	if err != nil {
+		log.Warn("failed to manage user", slog.String("error", err.Error()))
+		recordAuditFailure(ctx, s.uof, log, entry, err)
+		return err
+	}
+
+	log.Info("user managed")
+	return nil
+}
@@ -0,0 +1,176 @@
+package application
+
+import (
+	"context"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	mockchanges "github.com/LeoUraltsev/auth-service/internal/domain/changes/mocks"
+	mockoauth "github.com/LeoUraltsev/auth-service/internal/domain/oauth/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	mockwebhooks "github.com/LeoUraltsev/auth-service/internal/domain/webhooks/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+type userAdminMocks struct {
+	users  *mockusers.MockUserRepository
+	oauth  *mockoauth.MockRepository
+	hasher *mockusers.MockPasswordHasher
+}
+
+func newUserAdminService(t *testing.T, prepare func(m userAdminMocks)) *UserAdminServiceHandler {
+	ctrl := gomock.NewController(t)
+	m := userAdminMocks{
+		users:  mockusers.NewMockUserRepository(ctrl),
+		oauth:  mockoauth.NewMockRepository(ctrl),
+		hasher: mockusers.NewMockPasswordHasher(ctrl),
+	}
+	changeRepository := mockchanges.NewMockRepository(ctrl)
+	changeRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	webhookRepository := mockwebhooks.NewMockRepository(ctrl)
+	webhookRepository.EXPECT().ListSubscriptionsByEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	prepare(m)
+
+	store := testStore{
+		users:    m.users,
+		oauth:    m.oauth,
+		changes:  changeRepository,
+		webhooks: webhookRepository,
+		audit:    auditRepository,
+	}
+	return NewUserAdminService(testUnitOfWork{store: store}, m.hasher, log)
+}
+
+func testUser(t *testing.T) *users.User {
+	email, _ := users.NewEmail("user@gmail.com")
+	password, _ := users.NewPassword([]byte("hash"))
+	u, err := users.CreateUser(organizations.DefaultID, "user", email, password)
+	require.NoError(t, err)
+	return u
+}
+
+func TestUserAdminServiceHandler_SuspendUser(t *testing.T) {
+	u := testUser(t)
+	service := newUserAdminService(t, func(m userAdminMocks) {
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+		m.oauth.EXPECT().RevokeUserRefreshTokens(gomock.Any(), u.ID(), gomock.Any()).Return(nil)
+		m.users.EXPECT().Save(gomock.Any(), u).Return(nil)
+	})
+
+	until := time.Now().Add(time.Hour)
+	err := service.SuspendUser(adminContext(), u.ID(), "spam", until)
+	require.NoError(t, err)
+	assert.True(t, u.IsSuspended(time.Now()))
+	assert.Equal(t, "spam", u.Access().Suspension.Reason)
+	assert.ErrorIs(t, u.CanAuthenticate(time.Now()), users.ErrUserSuspended)
+}
+
+func TestUserAdminServiceHandler_SuspendUser_notAdmin(t *testing.T) {
+	u := testUser(t)
+	service := newUserAdminService(t, func(m userAdminMocks) {})
+
+	err := service.SuspendUser(context.Background(), u.ID(), "spam", time.Time{})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestUserAdminServiceHandler_ReactivateUser(t *testing.T) {
+	cases := []struct {
+		name        string
+		prepare     func(u *users.User)
+		emailExists bool
+		wantErr     error
+	}{
+		{
+			name:    "suspended",
+			prepare: func(u *users.User) { _ = u.Suspend("spam", time.Time{}, time.Now().UTC()) },
+		},
+		{
+			name:    "deleted",
+			prepare: func(u *users.User) { _ = u.Delete(time.Now().UTC()) },
+		},
+		{
+			name:        "deleted email taken",
+			prepare:     func(u *users.User) { _ = u.Delete(time.Now().UTC()) },
+			emailExists: true,
+			wantErr:     users.ErrEmailAlreadyExists,
+		},
+		{
+			name:    "already active",
+			prepare: func(u *users.User) {},
+			wantErr: users.ErrUserAlreadyActive,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			u := testUser(t)
+			tt.prepare(u)
+			deleted := u.IsDeleted()
+			service := newUserAdminService(t, func(m userAdminMocks) {
+				m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+				if deleted {
+					m.users.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, u.Email()).Return(tt.emailExists, nil)
+				}
+				if tt.wantErr == nil {
+					m.users.EXPECT().Save(gomock.Any(), u).Return(nil)
+				}
+			})
+
+			err := service.ReactivateUser(adminContext(), u.ID())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NoError(t, u.CanAuthenticate(time.Now()))
+		})
+	}
+}
+
+func TestUserAdminServiceHandler_SetTemporaryPassword(t *testing.T) {
+	u := testUser(t)
+	service := newUserAdminService(t, func(m userAdminMocks) {
+		m.hasher.EXPECT().Hash([]byte("temporary")).Return([]byte("temporary-hash"), nil)
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+		m.oauth.EXPECT().RevokeUserRefreshTokens(gomock.Any(), u.ID(), gomock.Any()).Return(nil)
+		m.users.EXPECT().Save(gomock.Any(), u).Return(nil)
+	})
+
+	err := service.SetTemporaryPassword(adminContext(), u.ID(), "temporary")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("temporary-hash"), u.Password().Hash())
+	assert.True(t, u.Access().PasswordChangeRequired)
+}
+
+func TestUserAdminServiceHandler_ReassignEmail(t *testing.T) {
+	taken, _ := users.NewEmail("taken@gmail.com")
+	free, _ := users.NewEmail("free@gmail.com")
+
+	t.Run("success", func(t *testing.T) {
+		u := testUser(t)
+		service := newUserAdminService(t, func(m userAdminMocks) {
+			m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+			m.users.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, free).Return(false, nil)
+			m.oauth.EXPECT().RevokeUserRefreshTokens(gomock.Any(), u.ID(), gomock.Any()).Return(nil)
+			m.users.EXPECT().Save(gomock.Any(), u).Return(nil)
+		})
+		require.NoError(t, service.ReassignEmail(adminContext(), u.ID(), free.String()))
+		assert.Equal(t, free, u.Email())
+		assert.True(t, u.TokenRevoked(time.Now().Add(-time.Minute)))
+	})
+
+	t.Run("email taken", func(t *testing.T) {
+		u := testUser(t)
+		service := newUserAdminService(t, func(m userAdminMocks) {
+			m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+			m.users.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, taken).Return(true, nil)
+		})
+		assert.ErrorIs(t, service.ReassignEmail(adminContext(), u.ID(), taken.String()), users.ErrEmailAlreadyExists)
+	})
+}
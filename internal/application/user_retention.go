@@ -0,0 +1,141 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"log/slog"
+	"time"
+)
+
+// UserRetention стирает персональные данные пользователей, удаленных дольше срока хранения
+type UserRetention struct {
+	uof       UnitOfWork
+	period    time.Duration
+	interval  time.Duration
+	batchSize int
+	log       *slog.Logger
+}
+
+func NewUserRetention(uof UnitOfWork, period time.Duration, interval time.Duration, batchSize int, log *slog.Logger) *UserRetention {
+	return &UserRetention{
+		uof:       uof,
+		period:    period,
+		interval:  interval,
+		batchSize: batchSize,
+		log:       log,
+	}
+}
+
+func (r *UserRetention) Run(ctx context.Context) {
+	r.log.Info("user retention started", slog.Duration("period", r.period), slog.Duration("interval", r.interval))
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			r.log.Info("user retention stopped")
+			return
+		case <-ticker.C:
+			erased, err := r.Purge(ctx, time.Now().UTC())
+			if err != nil {
+				r.log.Error("failed to purge deleted users", slog.String("error", err.Error()))
+				continue
+			}
+			if erased > 0 {
+				r.log.Info("deleted users erased", slog.Int("count", erased))
+			}
+		}
+	}
+}
+
+// Purge стирает одну пачку пользователей, удаленных раньше now - period. Каждый пользователь
+// стирается в своей транзакции, ошибка одного не останавливает остальных
+func (r *UserRetention) Purge(ctx context.Context, now time.Time) (int, error) {
+	before := now.Add(-r.period)
+	var expired []*users.User
+	err := r.uof.Execute(ctx, func(store Store) error {
+		var err error
+		expired, err = store.Users().ListDeletedBefore(ctx, before, r.batchSize)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	erased := 0
+	for _, u := range expired {
+		log := r.log.With(slog.String("user_id", u.ID().String()))
+		entry := userAudit(audit.ActionUserErased, u.ID())
+		done := false
+		err = r.uof.Execute(ctx, func(store Store) error {
+			// пользователя могли восстановить после выборки
+			current, err := store.Users().Get(ctx, users.AnyTenant, u.ID())
+			if err != nil {
+				return err
+			}
+			if !current.IsDeleted() || !current.StatusChangedAt().Before(before) {
+				return nil
+			}
+			if err = eraseUser(ctx, store, current, now); err != nil {
+				return err
+			}
+			done = true
+			return recordAudit(ctx, store, entry, nil)
+		})
+		if err != nil {
+			log.Error("failed to erase user", slog.String("error", err.Error()))
+			recordAuditFailure(ctx, r.uof, log, entry, err)
+			continue
+		}
+		if done {
+			erased++
+		}
+	}
+	return erased, nil
+}
+
+// eraseUser стирает персональные данные пользователя и удаляет все, чем можно войти от его имени:
+// refresh токены, API ключи и связанные учетные записи, а также готовые выгрузки данных. Ссылки по id в аудите, группах и отношениях остаются
+func eraseUser(ctx context.Context, store Store, u *users.User, at time.Time) error {
+	// о мягком удалении подписчики уже знают
+	notify := !u.IsDeleted()
+	if err := u.Erase(at); err != nil {
+		return err
+	}
+	if err := store.Users().Save(ctx, u); err != nil {
+		return err
+	}
+	if err := store.OAuth().DeleteUserTokens(ctx, u.ID()); err != nil {
+		return err
+	}
+	if err := store.APIKeys().DeleteByUser(ctx, u.ID()); err != nil {
+		return err
+	}
+	if err := store.Identities().DeleteByUser(ctx, u.ID()); err != nil {
+		return err
+	}
+	if err := store.Exports().DeleteByUser(ctx, u.ID()); err != nil {
+		return err
+	}
+	if err := store.EmailChanges().DeleteByUser(ctx, u.ID()); err != nil {
+		return err
+	}
+	if err := store.Passwordless().DeleteByUser(ctx, u.ID()); err != nil {
+		return err
+	}
+	if err := store.Invitations().ScrubAccepted(ctx, u.ID()); err != nil {
+		return err
+	}
+	if err := store.Changes().ScrubUser(ctx, u.ID()); err != nil {
+		return err
+	}
+	if err := store.Webhooks().ScrubUserDeliveries(ctx, u.ID()); err != nil {
+		return err
+	}
+	if notify {
+		return publishUserChange(ctx, store, changes.TypeDeleted, u)
+	}
+	return nil
+}
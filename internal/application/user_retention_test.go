@@ -0,0 +1,121 @@
+package application
+
+import (
+	"context"
+	mockapikeys "github.com/LeoUraltsev/auth-service/internal/domain/apikeys/mocks"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	mockchanges "github.com/LeoUraltsev/auth-service/internal/domain/changes/mocks"
+	mockemailchanges "github.com/LeoUraltsev/auth-service/internal/domain/emailchanges/mocks"
+	mockexports "github.com/LeoUraltsev/auth-service/internal/domain/exports/mocks"
+	mockidentity "github.com/LeoUraltsev/auth-service/internal/domain/identity/mocks"
+	mockinvitations "github.com/LeoUraltsev/auth-service/internal/domain/invitations/mocks"
+	mockoauth "github.com/LeoUraltsev/auth-service/internal/domain/oauth/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	mockpasswordless "github.com/LeoUraltsev/auth-service/internal/domain/passwordless/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	mockwebhooks "github.com/LeoUraltsev/auth-service/internal/domain/webhooks/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+type erasureMocks struct {
+	users    *mockusers.MockUserRepository
+	changes  *mockchanges.MockRepository
+	webhooks *mockwebhooks.MockRepository
+}
+
+// newErasureStore ожидает удаление токенов, ключей и связанных учетных записей пользователей из erased
+func newErasureStore(t *testing.T, erased []uuid.UUID, prepare func(m erasureMocks)) testStore {
+	ctrl := gomock.NewController(t)
+	m := erasureMocks{
+		users:    mockusers.NewMockUserRepository(ctrl),
+		changes:  mockchanges.NewMockRepository(ctrl),
+		webhooks: mockwebhooks.NewMockRepository(ctrl),
+	}
+	oauthRepository := mockoauth.NewMockRepository(ctrl)
+	apiKeyRepository := mockapikeys.NewMockRepository(ctrl)
+	identityRepository := mockidentity.NewMockRepository(ctrl)
+	invitationRepository := mockinvitations.NewMockRepository(ctrl)
+	exportRepository := mockexports.NewMockRepository(ctrl)
+	emailChangeRepository := mockemailchanges.NewMockRepository(ctrl)
+	passwordlessRepository := mockpasswordless.NewMockRepository(ctrl)
+	for _, id := range erased {
+		m.users.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+		oauthRepository.EXPECT().DeleteUserTokens(gomock.Any(), id).Return(nil)
+		apiKeyRepository.EXPECT().DeleteByUser(gomock.Any(), id).Return(nil)
+		identityRepository.EXPECT().DeleteByUser(gomock.Any(), id).Return(nil)
+		exportRepository.EXPECT().DeleteByUser(gomock.Any(), id).Return(nil)
+		emailChangeRepository.EXPECT().DeleteByUser(gomock.Any(), id).Return(nil)
+		passwordlessRepository.EXPECT().DeleteByUser(gomock.Any(), id).Return(nil)
+		invitationRepository.EXPECT().ScrubAccepted(gomock.Any(), id).Return(nil)
+		m.changes.EXPECT().ScrubUser(gomock.Any(), id).Return(nil)
+		m.webhooks.EXPECT().ScrubUserDeliveries(gomock.Any(), id).Return(nil)
+	}
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	prepare(m)
+
+	return testStore{
+		users:      m.users,
+		oauth:      oauthRepository,
+		apiKeys:    apiKeyRepository,
+		identities: identityRepository,
+		invites:    invitationRepository,
+		exports:    exportRepository,
+		emails:     emailChangeRepository,
+		logins:     passwordlessRepository,
+		changes:    m.changes,
+		webhooks:   m.webhooks,
+		audit:      auditRepository,
+	}
+}
+
+func TestUserRetention_Purge(t *testing.T) {
+	now := time.Now().UTC()
+	expired := testUser(t)
+	require.NoError(t, expired.Delete(now.Add(-48*time.Hour)))
+	restored := testUser(t)
+	require.NoError(t, restored.Delete(now.Add(-48*time.Hour)))
+	require.NoError(t, restored.Restore(now.Add(-time.Hour)))
+
+	store := newErasureStore(t, []uuid.UUID{expired.ID()}, func(m erasureMocks) {
+		m.users.EXPECT().ListDeletedBefore(gomock.Any(), now.Add(-24*time.Hour), 10).
+			Return([]*users.User{expired, restored}, nil)
+		m.users.EXPECT().Get(gomock.Any(), users.AnyTenant, expired.ID()).Return(expired, nil)
+		// восстановлен после выборки, данные не стираются
+		m.users.EXPECT().Get(gomock.Any(), users.AnyTenant, restored.ID()).Return(restored, nil)
+	})
+
+	retention := NewUserRetention(testUnitOfWork{store: store}, 24*time.Hour, time.Hour, 10, log)
+	erased, err := retention.Purge(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, erased)
+	assert.True(t, expired.IsErased())
+	assert.Empty(t, expired.Email().String())
+	assert.True(t, restored.IsActive())
+}
+
+func TestUserAdminServiceHandler_EraseUser(t *testing.T) {
+	u := testUser(t)
+	store := newErasureStore(t, []uuid.UUID{u.ID()}, func(m erasureMocks) {
+		m.users.EXPECT().Get(gomock.Any(), organizations.DefaultID, u.ID()).Return(u, nil)
+		// пользователь не был удален, подписчики получают user.deleted
+		m.changes.EXPECT().Append(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *changes.Change) error {
+			assert.Equal(t, changes.TypeDeleted, c.Type())
+			assert.Empty(t, c.Email())
+			return nil
+		})
+		m.webhooks.EXPECT().ListSubscriptionsByEvent(gomock.Any(), gomock.Any()).Return(nil, nil)
+	})
+
+	service := NewUserAdminService(testUnitOfWork{store: store}, nil, log)
+	require.NoError(t, service.EraseUser(adminContext(), u.ID()))
+	assert.True(t, u.IsErased())
+	assert.Empty(t, u.Name())
+}
@@ -2,13 +2,32 @@ package application
 
 import (
 	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	mockaudit "github.com/LeoUraltsev/auth-service/internal/domain/audit/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	mockchanges "github.com/LeoUraltsev/auth-service/internal/domain/changes/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/emailchanges"
+	"github.com/LeoUraltsev/auth-service/internal/domain/exports"
+	"github.com/LeoUraltsev/auth-service/internal/domain/groups"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/domain/invitations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/passwordless"
+	"github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
 	"github.com/LeoUraltsev/auth-service/internal/domain/users"
 	mockusers "github.com/LeoUraltsev/auth-service/internal/domain/users/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	mockwebhooks "github.com/LeoUraltsev/auth-service/internal/domain/webhooks/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 )
 
 // todo: все тесты, cover >80%
@@ -18,6 +37,93 @@ import (
 
 var log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
+type testStore struct {
+	users      users.UserRepository
+	webhooks   webhooks.Repository
+	changes    changes.Repository
+	audit      audit.Repository
+	oauth      oauth.Repository
+	identities identity.Repository
+	accounts   serviceaccounts.Repository
+	apiKeys    apikeys.Repository
+	orgs       organizations.Repository
+	invites    invitations.Repository
+	relations  relations.Repository
+	groups     groups.Repository
+	exports    exports.Repository
+	emails     emailchanges.Repository
+	logins     passwordless.Repository
+}
+
+func (s testStore) Users() users.UserRepository {
+	return s.users
+}
+
+func (s testStore) Webhooks() webhooks.Repository {
+	return s.webhooks
+}
+
+func (s testStore) Changes() changes.Repository {
+	return s.changes
+}
+
+func (s testStore) Audit() audit.Repository {
+	return s.audit
+}
+
+func (s testStore) OAuth() oauth.Repository {
+	return s.oauth
+}
+
+func (s testStore) Identities() identity.Repository {
+	return s.identities
+}
+
+func (s testStore) ServiceAccounts() serviceaccounts.Repository {
+	return s.accounts
+}
+
+func (s testStore) APIKeys() apikeys.Repository {
+	return s.apiKeys
+}
+
+func (s testStore) Organizations() organizations.Repository {
+	return s.orgs
+}
+
+func (s testStore) Invitations() invitations.Repository {
+	return s.invites
+}
+
+func (s testStore) Relations() relations.Repository {
+	return s.relations
+}
+
+func (s testStore) Groups() groups.Repository {
+	return s.groups
+}
+
+func (s testStore) Exports() exports.Repository {
+	return s.exports
+}
+
+func (s testStore) EmailChanges() emailchanges.Repository {
+	return s.emails
+}
+
+func (s testStore) Passwordless() passwordless.Repository {
+	return s.logins
+}
+
+// testUnitOfWork выполняет fn без транзакции поверх переданных моков
+type testUnitOfWork struct {
+	store testStore
+}
+
+func (u testUnitOfWork) Execute(_ context.Context, fn func(store Store) error) error {
+	return fn(u.store)
+}
+
 func TestUserServiceHandler_CreateUser(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
@@ -27,6 +133,21 @@ func TestUserServiceHandler_CreateUser(t *testing.T) {
 	passwordHasher := mockusers.NewMockPasswordHasher(ctrl)
 	passwordVerifier := mockusers.NewMockPasswordVerifier(ctrl)
 	tokenGenerator := mockusers.NewMockTokenGenerator(ctrl)
+	webhookRepository := mockwebhooks.NewMockRepository(ctrl)
+	webhookRepository.EXPECT().
+		ListSubscriptionsByEvent(context.Background(), webhooks.EventUserCreated).
+		Return(nil, nil).
+		AnyTimes()
+	changeRepository := mockchanges.NewMockRepository(ctrl)
+	changeRepository.EXPECT().
+		Append(context.Background(), gomock.Any()).
+		Return(nil).
+		AnyTimes()
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().
+		Append(context.Background(), gomock.Any()).
+		Return(nil).
+		AnyTimes()
 	type fields struct {
 		save         *gomock.Call
 		checkEmail   *gomock.Call
@@ -53,7 +174,7 @@ func TestUserServiceHandler_CreateUser(t *testing.T) {
 					Save(context.Background(), gomock.Any()).Return(nil).
 					AnyTimes(),
 				checkEmail: repository.EXPECT().
-					ExistsByEmail(context.Background(), gomock.Any()).
+					ExistsByEmail(context.Background(), organizations.DefaultID, gomock.Any()).
 					Return(false, nil).
 					AnyTimes(),
 				passwordHash: passwordHasher.EXPECT().
@@ -61,7 +182,7 @@ func TestUserServiceHandler_CreateUser(t *testing.T) {
 					Return([]byte("hashpassword"), nil).
 					AnyTimes(),
 				passwordVer: passwordVerifier.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes(),
-				tokenGen:    tokenGenerator.EXPECT().GenerateToken(gomock.Any()).Return("token", nil).AnyTimes(),
+				tokenGen:    tokenGenerator.EXPECT().GenerateToken(gomock.Any(), organizations.DefaultID, gomock.Any(), nil).Return("token", nil).AnyTimes(),
 			},
 			args: args{
 				name:     "testname",
@@ -72,7 +193,13 @@ func TestUserServiceHandler_CreateUser(t *testing.T) {
 	}
 
 	for _, tt := range cases {
-		service := NewUserService(repository, passwordHasher, passwordVerifier, tokenGenerator, log)
+		uof := testUnitOfWork{store: testStore{
+			users:    repository,
+			webhooks: webhookRepository,
+			changes:  changeRepository,
+			audit:    auditRepository,
+		}}
+		service := NewUserService(uof, passwordHasher, passwordVerifier, tokenGenerator, log)
 		uuid, err := service.CreateUser(context.Background(), tt.args.name, tt.args.email, tt.args.password)
 
 		assert.NoError(t, err, "should not error")
@@ -88,13 +215,13 @@ func TestUserServiceHandler_checkUniqueEmail(t *testing.T) {
 	repository := mockusers.NewMockUserRepository(ctrl)
 
 	repository.EXPECT().
-		ExistsByEmail(context.Background(), gomock.Any()).
+		ExistsByEmail(context.Background(), organizations.DefaultID, gomock.Any()).
 		Return(false, nil).
 		AnyTimes()
 	email, err := users.NewEmail("email@gmail.com")
 	assert.NoError(t, err, "should not error")
-	service := NewUserService(repository, nil, nil, nil, log)
-	err = service.checkUniqueEmail(context.Background(), email)
+	service := NewUserService(testUnitOfWork{store: testStore{users: repository}}, nil, nil, nil, log)
+	err = service.checkUniqueEmail(context.Background(), organizations.DefaultID, email)
 	assert.NoError(t, err, "should not error")
 }
 
@@ -102,13 +229,13 @@ func TestUserServiceHandler_checkUniqueEmail_failed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	repository := mockusers.NewMockUserRepository(ctrl)
 	repository.EXPECT().
-		ExistsByEmail(context.Background(), gomock.Any()).
+		ExistsByEmail(context.Background(), organizations.DefaultID, gomock.Any()).
 		Return(true, nil).
 		AnyTimes()
 	email, err := users.NewEmail("email@gmail.com")
 	assert.NoError(t, err, "should not error")
-	service := NewUserService(repository, nil, nil, nil, log)
-	err = service.checkUniqueEmail(context.Background(), email)
+	service := NewUserService(testUnitOfWork{store: testStore{users: repository}}, nil, nil, nil, log)
+	err = service.checkUniqueEmail(context.Background(), organizations.DefaultID, email)
 	assert.Error(t, err, "should error")
 }
 
@@ -116,17 +243,121 @@ func TestUserServiceHandler_GetUser(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	email, _ := users.NewEmail("success@email.ru")
 	pass, _ := users.NewPassword([]byte("hashpassword"))
-	user, err := users.CreateUser("name", email, pass)
+	user, err := users.CreateUser(organizations.DefaultID, "name", email, pass)
 	assert.NoError(t, err, "should not error")
 
 	repository := mockusers.NewMockUserRepository(ctrl)
 	repository.EXPECT().
-		Get(context.Background(), gomock.Any()).
+		Get(context.Background(), organizations.DefaultID, gomock.Any()).
 		Return(user, nil)
 
-	service := NewUserService(repository, nil, nil, nil, log)
+	service := NewUserService(testUnitOfWork{store: testStore{users: repository}}, nil, nil, nil, log)
 
 	u, err := service.GetUser(context.Background(), user.ID())
 	assert.Equal(t, user, u, "should return user")
 	assert.NoError(t, err, "should not error")
 }
+
+func TestUserServiceHandler_CreateUser_publishesChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repository := mockusers.NewMockUserRepository(ctrl)
+	repository.EXPECT().ExistsByEmail(gomock.Any(), organizations.DefaultID, gomock.Any()).Return(false, nil)
+	repository.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+	passwordHasher := mockusers.NewMockPasswordHasher(ctrl)
+	passwordHasher.EXPECT().Hash(gomock.Any()).Return([]byte("hashpassword"), nil)
+
+	url, _ := webhooks.NewURL("https://example.com/hook")
+	secret, _ := webhooks.NewSecret("0123456789abcdef")
+	subscription, err := webhooks.CreateSubscription(url, []webhooks.EventType{webhooks.EventAll}, secret)
+	assert.NoError(t, err)
+
+	webhookRepository := mockwebhooks.NewMockRepository(ctrl)
+	webhookRepository.EXPECT().
+		ListSubscriptionsByEvent(gomock.Any(), webhooks.EventUserCreated).
+		Return([]*webhooks.Subscription{subscription}, nil)
+	webhookRepository.EXPECT().
+		SaveDelivery(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, d *webhooks.Delivery) error {
+			assert.Equal(t, subscription.ID(), d.SubscriptionID())
+			assert.Equal(t, webhooks.EventUserCreated, d.EventType())
+			assert.Equal(t, webhooks.DeliveryPending, d.Status())
+			return nil
+		})
+
+	changeRepository := mockchanges.NewMockRepository(ctrl)
+	changeRepository.EXPECT().
+		Append(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, c *changes.Change) error {
+			assert.Equal(t, changes.TypeCreated, c.Type())
+			return nil
+		})
+
+	auditRepository := mockaudit.NewMockRepository(ctrl)
+	auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+
+	uof := testUnitOfWork{store: testStore{
+		users:    repository,
+		webhooks: webhookRepository,
+		changes:  changeRepository,
+		audit:    auditRepository,
+	}}
+	service := NewUserService(uof, passwordHasher, nil, nil, log)
+	_, err = service.CreateUser(context.Background(), "testname", "test@mail.ru", "testtest")
+	assert.NoError(t, err)
+}
+
+func TestUserServiceHandler_ChangeTemporaryPassword(t *testing.T) {
+	cases := []struct {
+		name        string
+		temporary   bool
+		newPassword string
+		wantErr     error
+	}{
+		{name: "success", temporary: true, newPassword: "new-password"},
+		{name: "not required", newPassword: "new-password", wantErr: users.ErrPasswordChangeNotRequired},
+		{name: "same password", temporary: true, newPassword: "temporary", wantErr: users.ErrPasswordNotChanged},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			u := testUser(t)
+			if tt.temporary {
+				password, _ := users.NewPassword([]byte("temporary-hash"))
+				require.NoError(t, u.SetTemporaryPassword(password, time.Now().UTC()))
+			}
+
+			ctrl := gomock.NewController(t)
+			repository := mockusers.NewMockUserRepository(ctrl)
+			repository.EXPECT().GetByEmail(gomock.Any(), organizations.DefaultID, u.Email()).Return(u, nil)
+			passwordVerifier := mockusers.NewMockPasswordVerifier(ctrl)
+			passwordVerifier.EXPECT().Verify(gomock.Any(), []byte("temporary")).Return(true, nil)
+			passwordHasher := mockusers.NewMockPasswordHasher(ctrl)
+			tokenGenerator := mockusers.NewMockTokenGenerator(ctrl)
+			changeRepository := mockchanges.NewMockRepository(ctrl)
+			webhookRepository := mockwebhooks.NewMockRepository(ctrl)
+			auditRepository := mockaudit.NewMockRepository(ctrl)
+			auditRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+			if tt.wantErr == nil {
+				passwordHasher.EXPECT().Hash([]byte(tt.newPassword)).Return([]byte("new-hash"), nil)
+				repository.EXPECT().Save(gomock.Any(), u).Return(nil)
+				changeRepository.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+				webhookRepository.EXPECT().ListSubscriptionsByEvent(gomock.Any(), webhooks.EventUserUpdated).Return(nil, nil)
+				tokenGenerator.EXPECT().GenerateToken(u.ID(), organizations.DefaultID, u.Role(), nil).Return("token", nil)
+			}
+
+			store := testStore{users: repository, changes: changeRepository, webhooks: webhookRepository, audit: auditRepository}
+			service := NewUserService(testUnitOfWork{store: store}, passwordHasher, passwordVerifier, tokenGenerator, log)
+
+			token, err := service.ChangeTemporaryPassword(context.Background(), u.Email().String(), "temporary", tt.newPassword)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "token", token)
+			assert.False(t, u.Access().PasswordChangeRequired)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"log/slog"
+	"time"
+)
+
+type WatchService interface {
+	WatchUsers(ctx context.Context, fromPosition int64, send func(change *changes.Change) error) error
+}
+
+type WatchServiceHandler struct {
+	uof          UnitOfWork
+	notifier     changes.Notifier
+	pollInterval time.Duration
+	batchSize    int
+	log          *slog.Logger
+}
+
+func NewWatchService(
+	uof UnitOfWork,
+	notifier changes.Notifier,
+	pollInterval time.Duration,
+	batchSize int,
+	log *slog.Logger,
+) *WatchServiceHandler {
+	return &WatchServiceHandler{
+		uof:          uof,
+		notifier:     notifier,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		log:          log,
+	}
+}
+
+// WatchUsers отправляет изменения с позицией больше fromPosition, пока клиент не отключится.
+// Если fromPosition не задан, отправляются только изменения, появившиеся после подключения.
+// Уведомления лишь будят цикл, пропущенное уведомление догоняется опросом раз в pollInterval
+func (s *WatchServiceHandler) WatchUsers(ctx context.Context, fromPosition int64, send func(change *changes.Change) error) error {
+	log := logger.LogWithContext(ctx, s.log)
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to watch users", slog.String("error", err.Error()))
+		return err
+	}
+
+	notifications, unsubscribe := s.notifier.Subscribe()
+	defer unsubscribe()
+
+	position := fromPosition
+	if position <= 0 {
+		err := s.uof.Execute(ctx, func(store Store) error {
+			var err error
+			position, err = store.Changes().LastPosition(ctx)
+			return err
+		})
+		if err != nil {
+			log.Warn("failed to get last change position", slog.String("error", err.Error()))
+			return err
+		}
+	}
+	log.Info("watching users", slog.Int64("position", position))
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		position, err = s.sendSince(ctx, position, send)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			log.Warn("failed to send user changes", slog.String("error", err.Error()))
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("watch finished", slog.Int64("position", position))
+			return nil
+		case <-notifications:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *WatchServiceHandler) sendSince(ctx context.Context, position int64, send func(change *changes.Change) error) (int64, error) {
+	for {
+		var batch []*changes.Change
+		err := s.uof.Execute(ctx, func(store Store) error {
+			var err error
+			batch, err = store.Changes().ListSince(ctx, position, s.batchSize)
+			return err
+		})
+		if err != nil {
+			return position, err
+		}
+
+		for _, change := range batch {
+			if err = send(change); err != nil {
+				return position, err
+			}
+			position = change.Position()
+		}
+
+		if len(batch) < s.batchSize {
+			return position, nil
+		}
+	}
+}
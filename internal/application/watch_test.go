@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	mockchanges "github.com/LeoUraltsev/auth-service/internal/domain/changes/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+type testNotifier struct {
+	ch chan struct{}
+}
+
+func (n testNotifier) Subscribe() (<-chan struct{}, func()) {
+	return n.ch, func() {}
+}
+
+func testChange(position int64) *changes.Change {
+	c, _ := changes.NewChange(position, changes.TypeUpdated, uuid.New(), "name", "user@example.com", "user", true, time.Now())
+	return c
+}
+
+func TestWatchServiceHandler_WatchUsers_resume(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repository := mockchanges.NewMockRepository(ctrl)
+	notifier := testNotifier{ch: make(chan struct{}, 1)}
+
+	gomock.InOrder(
+		// догоняем историю пачками по две записи
+		repository.EXPECT().ListSince(gomock.Any(), int64(5), 2).Return([]*changes.Change{testChange(6), testChange(7)}, nil),
+		repository.EXPECT().ListSince(gomock.Any(), int64(7), 2).Return([]*changes.Change{testChange(9)}, nil),
+		// после уведомления читаем с последней отправленной позиции
+		repository.EXPECT().ListSince(gomock.Any(), int64(9), 2).Return([]*changes.Change{testChange(10)}, nil),
+	)
+
+	service := NewWatchService(testUnitOfWork{store: testStore{changes: repository}}, notifier, time.Hour, 2, log)
+
+	ctx, cancel := context.WithCancel(adminContext())
+	defer cancel()
+
+	var got []int64
+	err := service.WatchUsers(ctx, 5, func(change *changes.Change) error {
+		got = append(got, change.Position())
+		switch change.Position() {
+		case 9:
+			notifier.ch <- struct{}{}
+		case 10:
+			cancel()
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{6, 7, 9, 10}, got)
+}
+
+func TestWatchServiceHandler_WatchUsers_fromHead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repository := mockchanges.NewMockRepository(ctrl)
+	repository.EXPECT().LastPosition(gomock.Any()).Return(int64(42), nil)
+	repository.EXPECT().ListSince(gomock.Any(), int64(42), 10).Return(nil, nil)
+
+	service := NewWatchService(testUnitOfWork{store: testStore{changes: repository}}, testNotifier{ch: make(chan struct{})}, time.Hour, 10, log)
+
+	ctx, cancel := context.WithTimeout(adminContext(), 50*time.Millisecond)
+	defer cancel()
+	err := service.WatchUsers(ctx, 0, func(*changes.Change) error {
+		t.Fatal("no changes expected")
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestWatchServiceHandler_WatchUsers_notAdmin(t *testing.T) {
+	service := NewWatchService(testUnitOfWork{}, testNotifier{}, time.Hour, 10, log)
+	err := service.WatchUsers(context.Background(), 0, func(*changes.Change) error { return nil })
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
@@ -0,0 +1,249 @@
+package application
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+const maxListLimit = 100
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, url string, events []string, secret string) (*webhooks.Subscription, error)
+	ListWebhooks(ctx context.Context) ([]*webhooks.Subscription, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, filter webhooks.DeliveryFilter) ([]*webhooks.Delivery, error)
+	ListDeliveryAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*webhooks.Attempt, error)
+	RetryDelivery(ctx context.Context, deliveryID uuid.UUID) error
+}
+
+type WebhookServiceHandler struct {
+	uof UnitOfWork
+	log *slog.Logger
+}
+
+func NewWebhookService(uof UnitOfWork, log *slog.Logger) *WebhookServiceHandler {
+	return &WebhookServiceHandler{
+		uof: uof,
+		log: log,
+	}
+}
+
+// CreateWebhook создает подписку, если секрет не передан - генерирует его
+func (s *WebhookServiceHandler) CreateWebhook(ctx context.Context, url string, events []string, secret string) (*webhooks.Subscription, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("creating webhook")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to create webhook", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	u, err := webhooks.NewURL(url)
+	if err != nil {
+		log.Warn("failed to create webhook", slog.String("url", url), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	eventTypes := make([]webhooks.EventType, 0, len(events))
+	for _, e := range events {
+		eventType, err := webhooks.NewEventType(e)
+		if err != nil {
+			log.Warn("failed to create webhook", slog.String("event", e), slog.String("error", err.Error()))
+			return nil, err
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	var sec webhooks.Secret
+	if secret == "" {
+		sec, err = webhooks.GenerateSecret()
+	} else {
+		sec, err = webhooks.NewSecret(secret)
+	}
+	if err != nil {
+		log.Warn("failed to create webhook", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	subscription, err := webhooks.CreateSubscription(u, eventTypes, sec)
+	if err != nil {
+		log.Warn("failed to create webhook", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	err = s.uof.Execute(ctx, func(store Store) error {
+		return store.Webhooks().SaveSubscription(ctx, subscription)
+	})
+	if err != nil {
+		log.Warn("failed to save webhook", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	log.Info("webhook created", slog.String("id", subscription.ID().String()))
+	return subscription, nil
+}
+
+func (s *WebhookServiceHandler) ListWebhooks(ctx context.Context) ([]*webhooks.Subscription, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting webhooks")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to get webhooks", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var subscriptions []*webhooks.Subscription
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		subscriptions, err = store.Webhooks().ListSubscriptions(ctx)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get webhooks", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteWebhook отключает подписку, недоставленные события уходят в dead-letter при следующей попытке
+func (s *WebhookServiceHandler) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("deleting webhook", slog.String("id", id.String()))
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to delete webhook", slog.String("error", err.Error()))
+		return err
+	}
+
+	err := s.uof.Execute(ctx, func(store Store) error {
+		repo := store.Webhooks()
+		subscription, err := repo.GetSubscription(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = subscription.Disable(); err != nil {
+			return err
+		}
+		return repo.SaveSubscription(ctx, subscription)
+	})
+	if err != nil {
+		log.Warn("failed to delete webhook", slog.String("error", err.Error()))
+		return err
+	}
+
+	log.Info("webhook deleted")
+	return nil
+}
+
+func (s *WebhookServiceHandler) ListDeliveries(ctx context.Context, filter webhooks.DeliveryFilter) ([]*webhooks.Delivery, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting webhook deliveries")
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to get webhook deliveries", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if filter.Limit <= 0 || filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	var deliveries []*webhooks.Delivery
+	err := s.uof.Execute(ctx, func(store Store) error {
+		var err error
+		deliveries, err = store.Webhooks().ListDeliveries(ctx, filter)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get webhook deliveries", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (s *WebhookServiceHandler) ListDeliveryAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*webhooks.Attempt, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("getting webhook delivery attempts", slog.String("delivery_id", deliveryID.String()))
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to get webhook delivery attempts", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var attempts []*webhooks.Attempt
+	err := s.uof.Execute(ctx, func(store Store) error {
+		repo := store.Webhooks()
+		if _, err := repo.GetDelivery(ctx, deliveryID); err != nil {
+			return err
+		}
+		var err error
+		attempts, err = repo.ListAttempts(ctx, deliveryID)
+		return err
+	})
+	if err != nil {
+		log.Warn("failed to get webhook delivery attempts", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// RetryDelivery повторно ставит в очередь доставку из dead-letter
+func (s *WebhookServiceHandler) RetryDelivery(ctx context.Context, deliveryID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log)
+	log.Info("retrying webhook delivery", slog.String("delivery_id", deliveryID.String()))
+
+	if err := requirePlatformAdmin(ctx); err != nil {
+		log.Warn("failed to retry webhook delivery", slog.String("error", err.Error()))
+		return err
+	}
+
+	err := s.uof.Execute(ctx, func(store Store) error {
+		repo := store.Webhooks()
+		delivery, err := repo.GetDelivery(ctx, deliveryID)
+		if err != nil {
+			return err
+		}
+		subscription, err := repo.GetSubscription(ctx, delivery.SubscriptionID())
+		if err != nil {
+			return err
+		}
+		if !subscription.IsActive() {
+			return webhooks.ErrSubscriptionNotActive
+		}
+		if err = delivery.Redeliver(time.Now().UTC()); err != nil {
+			return err
+		}
+		return repo.SaveDelivery(ctx, delivery)
+	})
+	if err != nil {
+		log.Warn("failed to retry webhook delivery", slog.String("error", err.Error()))
+		return err
+	}
+
+	log.Info("webhook delivery scheduled")
+	return nil
+}
+
+// enqueueWebhooks создает доставки события для всех подходящих подписок
+func enqueueWebhooks(ctx context.Context, repo webhooks.Repository, event webhooks.Event) error {
+	subscriptions, err := repo.ListSubscriptionsByEvent(ctx, event.Type())
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		if err = repo.SaveDelivery(ctx, webhooks.CreateDelivery(subscription, event)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
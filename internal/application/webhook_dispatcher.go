@@ -0,0 +1,187 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+// WebhookDispatcher периодически отправляет доставки, время которых подошло
+type WebhookDispatcher struct {
+	uof       UnitOfWork
+	sender    webhooks.Sender
+	policy    webhooks.RetryPolicy
+	interval  time.Duration
+	batchSize int
+	lease     time.Duration
+	log       *slog.Logger
+}
+
+func NewWebhookDispatcher(
+	uof UnitOfWork,
+	sender webhooks.Sender,
+	policy webhooks.RetryPolicy,
+	interval time.Duration,
+	batchSize int,
+	lease time.Duration,
+	log *slog.Logger,
+) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		uof:       uof,
+		sender:    sender,
+		policy:    policy,
+		interval:  interval,
+		batchSize: batchSize,
+		lease:     lease,
+		log:       log,
+	}
+}
+
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	d.log.Info("webhook dispatcher started", slog.Duration("interval", d.interval))
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			d.log.Info("webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			n, err := d.DispatchDue(ctx)
+			if err != nil {
+				d.log.Error("failed to dispatch webhooks", slog.String("error", err.Error()))
+				continue
+			}
+			if n > 0 {
+				d.log.Info("webhooks dispatched", slog.Int("count", n))
+			}
+		}
+	}
+}
+
+// claimedDelivery доставка, закрепленная за этим экземпляром на время отправки
+type claimedDelivery struct {
+	delivery     *webhooks.Delivery
+	subscription *webhooks.Subscription
+}
+
+// DispatchDue отправляет одну пачку доставок. Доставки закрепляются короткой транзакцией,
+// отправка идет вне транзакции, а результат каждой доставки сохраняется отдельно,
+// поэтому медленный получатель не держит блокировки, а ошибка одной доставки не откатывает остальные
+func (d *WebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	claimed, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var processed int
+	var errs []error
+	for _, c := range claimed {
+		if err = d.deliver(ctx, c.subscription, c.delivery); err != nil {
+			// доставку подберут снова по истечении lease
+			d.log.Error("failed to save webhook delivery result",
+				slog.String("delivery_id", c.delivery.ID().String()),
+				slog.String("error", err.Error()),
+			)
+			errs = append(errs, err)
+			continue
+		}
+		processed++
+	}
+	return processed, errors.Join(errs...)
+}
+
+// claim выбирает доставки со SKIP LOCKED и сдвигает их следующую попытку на lease,
+// поэтому несколько экземпляров сервиса не отправят одну доставку одновременно
+func (d *WebhookDispatcher) claim(ctx context.Context) ([]claimedDelivery, error) {
+	var claimed []claimedDelivery
+	err := d.uof.Execute(ctx, func(store Store) error {
+		repo := store.Webhooks()
+		now := time.Now().UTC()
+		deliveries, err := repo.ListDueDeliveries(ctx, now, d.batchSize)
+		if err != nil {
+			return err
+		}
+		subscriptions := make(map[uuid.UUID]*webhooks.Subscription)
+		claimed = make([]claimedDelivery, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			subscription, ok := subscriptions[delivery.SubscriptionID()]
+			if !ok {
+				subscription, err = repo.GetSubscription(ctx, delivery.SubscriptionID())
+				if err != nil && !errors.Is(err, webhooks.ErrSubscriptionNotFound) {
+					return err
+				}
+				subscriptions[delivery.SubscriptionID()] = subscription
+			}
+			delivery.Claim(d.lease, now)
+			if err = repo.SaveDelivery(ctx, delivery); err != nil {
+				return err
+			}
+			claimed = append(claimed, claimedDelivery{delivery: delivery, subscription: subscription})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (d *WebhookDispatcher) deliver(
+	ctx context.Context,
+	subscription *webhooks.Subscription,
+	delivery *webhooks.Delivery,
+) error {
+	log := d.log.With(slog.String("delivery_id", delivery.ID().String()))
+
+	if subscription == nil || !subscription.IsActive() {
+		log.Warn("webhook subscription is not active, moving delivery to dead-letter")
+		delivery.MarkDead(webhooks.ErrSubscriptionNotActive.Error(), time.Now().UTC())
+		return d.uof.Execute(ctx, func(store Store) error {
+			return store.Webhooks().SaveDelivery(ctx, delivery)
+		})
+	}
+
+	started := time.Now()
+	statusCode, sendErr := d.sender.Send(ctx, subscription, delivery)
+	finished := time.Now().UTC()
+
+	var reason string
+	if sendErr != nil {
+		reason = sendErr.Error()
+	} else if statusCode < 200 || statusCode >= 300 {
+		reason = fmt.Sprintf("unexpected status code %d", statusCode)
+	}
+
+	attempt := webhooks.NewAttempt(
+		uuid.New(),
+		delivery.ID(),
+		delivery.Attempts()+1,
+		statusCode,
+		reason,
+		time.Since(started),
+		finished,
+	)
+
+	if reason == "" {
+		delivery.MarkSucceeded(finished)
+		log.Info("webhook delivered", slog.Int("status_code", statusCode))
+	} else {
+		delivery.MarkFailed(reason, d.policy, finished)
+		log.Warn("webhook delivery failed",
+			slog.String("error", reason),
+			slog.Int("attempt", delivery.Attempts()),
+			slog.String("status", delivery.Status().String()),
+		)
+	}
+
+	return d.uof.Execute(ctx, func(store Store) error {
+		if err := store.Webhooks().SaveAttempt(ctx, attempt); err != nil {
+			return err
+		}
+		return store.Webhooks().SaveDelivery(ctx, delivery)
+	})
+}
@@ -0,0 +1,172 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	mockwebhooks "github.com/LeoUraltsev/auth-service/internal/domain/webhooks/mocks"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func adminContext() context.Context {
+	return authverify.NewContext(context.Background(), &authverify.Principal{Role: "admin"})
+}
+
+func TestWebhookServiceHandler_CreateWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repository := mockwebhooks.NewMockRepository(ctrl)
+	repository.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).Return(nil)
+
+	service := NewWebhookService(testUnitOfWork{store: testStore{webhooks: repository}}, log)
+	subscription, err := service.CreateWebhook(adminContext(), "https://example.com/hook", []string{"user.created"}, "")
+
+	assert.NoError(t, err)
+	assert.True(t, subscription.IsActive())
+	assert.NotEmpty(t, subscription.Secret().String(), "secret should be generated")
+	assert.True(t, subscription.Matches(webhooks.EventUserCreated))
+	assert.False(t, subscription.Matches(webhooks.EventUserDeleted))
+}
+
+func TestWebhookServiceHandler_CreateWebhook_notAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repository := mockwebhooks.NewMockRepository(ctrl)
+
+	service := NewWebhookService(testUnitOfWork{store: testStore{webhooks: repository}}, log)
+	ctx := authverify.NewContext(context.Background(), &authverify.Principal{Role: "user"})
+	_, err := service.CreateWebhook(ctx, "https://example.com/hook", []string{"user.created"}, "")
+
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestWebhookServiceHandler_CreateWebhook_invalidEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repository := mockwebhooks.NewMockRepository(ctrl)
+
+	service := NewWebhookService(testUnitOfWork{store: testStore{webhooks: repository}}, log)
+	_, err := service.CreateWebhook(adminContext(), "https://example.com/hook", []string{"user.exploded"}, "")
+
+	assert.ErrorIs(t, err, webhooks.ErrEventTypeNotValid)
+}
+
+type senderFunc func(ctx context.Context, s *webhooks.Subscription, d *webhooks.Delivery) (int, error)
+
+func (f senderFunc) Send(ctx context.Context, s *webhooks.Subscription, d *webhooks.Delivery) (int, error) {
+	return f(ctx, s, d)
+}
+
+func TestWebhookDispatcher_DispatchDue(t *testing.T) {
+	url, _ := webhooks.NewURL("https://example.com/hook")
+	secret, _ := webhooks.NewSecret("0123456789abcdef")
+	subscription, _ := webhooks.CreateSubscription(url, []webhooks.EventType{webhooks.EventAll}, secret)
+	policy := webhooks.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour}
+
+	cases := []struct {
+		name       string
+		statusCode int
+		sendErr    error
+		attempts   int
+		wantStatus webhooks.DeliveryStatus
+	}{
+		{
+			name:       "delivered",
+			statusCode: 204,
+			wantStatus: webhooks.DeliverySucceeded,
+		},
+		{
+			name:       "retry scheduled",
+			statusCode: 500,
+			wantStatus: webhooks.DeliveryPending,
+		},
+		{
+			name:       "moved to dead-letter",
+			sendErr:    errors.New("connection refused"),
+			attempts:   1,
+			wantStatus: webhooks.DeliveryDead,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			now := time.Now().UTC()
+			delivery := webhooks.NewDelivery(
+				subscription.ID(), subscription.ID(), subscription.ID(), webhooks.EventUserCreated,
+				[]byte(`{}`), webhooks.DeliveryPending, tt.attempts, now, "", now, now,
+			)
+
+			repository := mockwebhooks.NewMockRepository(ctrl)
+			repository.EXPECT().ListDueDeliveries(gomock.Any(), gomock.Any(), 10).Return([]*webhooks.Delivery{delivery}, nil)
+			repository.EXPECT().GetSubscription(gomock.Any(), subscription.ID()).Return(subscription, nil)
+			repository.EXPECT().SaveAttempt(gomock.Any(), gomock.Any()).Return(nil)
+			// закрепление и результат
+			repository.EXPECT().SaveDelivery(gomock.Any(), delivery).Return(nil).Times(2)
+
+			sender := senderFunc(func(context.Context, *webhooks.Subscription, *webhooks.Delivery) (int, error) {
+				return tt.statusCode, tt.sendErr
+			})
+			dispatcher := NewWebhookDispatcher(testUnitOfWork{store: testStore{webhooks: repository}}, sender, policy, time.Second, 10, time.Minute, log)
+
+			n, err := dispatcher.DispatchDue(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.Equal(t, tt.wantStatus, delivery.Status())
+			assert.Equal(t, tt.attempts+1, delivery.Attempts())
+		})
+	}
+}
+
+// txUnitOfWork отмечает, выполняется ли код внутри транзакции
+type txUnitOfWork struct {
+	store testStore
+	inTx  *bool
+}
+
+func (u txUnitOfWork) Execute(_ context.Context, fn func(store Store) error) error {
+	*u.inTx = true
+	defer func() { *u.inTx = false }()
+	return fn(u.store)
+}
+
+func TestWebhookDispatcher_DispatchDue_resultsSavedSeparately(t *testing.T) {
+	url, _ := webhooks.NewURL("https://example.com/hook")
+	secret, _ := webhooks.NewSecret("0123456789abcdef")
+	subscription, _ := webhooks.CreateSubscription(url, []webhooks.EventType{webhooks.EventAll}, secret)
+	policy := webhooks.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	now := time.Now().UTC()
+	newDelivery := func() *webhooks.Delivery {
+		return webhooks.NewDelivery(
+			uuid.New(), subscription.ID(), uuid.New(), webhooks.EventUserCreated,
+			[]byte(`{}`), webhooks.DeliveryPending, 0, now, "", now, now,
+		)
+	}
+	delivered, failed := newDelivery(), newDelivery()
+
+	ctrl := gomock.NewController(t)
+	repository := mockwebhooks.NewMockRepository(ctrl)
+	repository.EXPECT().ListDueDeliveries(gomock.Any(), gomock.Any(), 10).Return([]*webhooks.Delivery{delivered, failed}, nil)
+	repository.EXPECT().GetSubscription(gomock.Any(), subscription.ID()).Return(subscription, nil)
+	repository.EXPECT().SaveAttempt(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	repository.EXPECT().SaveDelivery(gomock.Any(), delivered).Return(nil).Times(2)
+	gomock.InOrder(
+		repository.EXPECT().SaveDelivery(gomock.Any(), failed).Return(nil),
+		repository.EXPECT().SaveDelivery(gomock.Any(), failed).Return(errors.New("connection reset")),
+	)
+
+	var inTx bool
+	sender := senderFunc(func(context.Context, *webhooks.Subscription, *webhooks.Delivery) (int, error) {
+		assert.False(t, inTx, "webhook must be sent outside of a transaction")
+		return 204, nil
+	})
+	uof := txUnitOfWork{store: testStore{webhooks: repository}, inTx: &inTx}
+	dispatcher := NewWebhookDispatcher(uof, sender, policy, time.Second, 10, time.Minute, log)
+
+	n, err := dispatcher.DispatchDue(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, n, "saved result is not rolled back by a later failure")
+	assert.Equal(t, webhooks.DeliverySucceeded, delivered.Status())
+}
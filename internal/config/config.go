@@ -13,6 +13,32 @@ type Config struct {
 	GRPC     GRPCConfig     `yaml:"grpc"`
 	Postgres PostgresConfig `yaml:"postgres"`
 	JWT      JWTConfig      `yaml:"jwt"`
+	Webhooks WebhooksConfig `yaml:"webhooks"`
+	Watch    WatchConfig    `yaml:"watch"`
+	Audit    AuditConfig    `yaml:"audit"`
+	HTTP     HTTPConfig     `yaml:"http"`
+	OAuth    OAuthConfig    `yaml:"oauth"`
+	// Federation внешние OIDC провайдеры для входа
+	Federation FederationConfig `yaml:"federation"`
+	// ExtAuthz проверка запросов Envoy через фильтр ext_authz
+	ExtAuthz ExtAuthzConfig `yaml:"ext_authz"`
+	// ForwardAuth /auth/verify для nginx auth_request и Traefik ForwardAuth
+	ForwardAuth ForwardAuthConfig `yaml:"forward_auth"`
+	// Registration открытая регистрация или только по приглашениям
+	Registration RegistrationConfig `yaml:"registration"`
+	// Policy политики доступа на CEL, без policy.path не проверяются
+	Policy PolicyConfig `yaml:"policy"`
+	// Relations схема отношений для RelationshipService
+	Relations RelationsConfig `yaml:"relations"`
+	Groups    GroupsConfig    `yaml:"groups"`
+	// Retention срок хранения персональных данных удаленных пользователей
+	Retention RetentionConfig `yaml:"retention"`
+	Exports   ExportsConfig   `yaml:"exports"`
+	Mail      MailConfig      `yaml:"mail"`
+	// EmailChange смена email с подтверждением по ссылкам из писем
+	EmailChange EmailChangeConfig `yaml:"email_change"`
+	// Passwordless вход по ссылке или коду из письма
+	Passwordless PasswordlessConfig `yaml:"passwordless"`
 }
 
 type AppConfig struct {
@@ -30,6 +56,185 @@ type PostgresConfig struct {
 type JWTConfig struct {
 	Secret     string        `env:"JWT_SECRET" yaml:"secret"`
 	Expiration time.Duration `env:"JWT_EXPIRATION" yaml:"expiration"`
+	// SigningKeyPath PEM файл RSA ключа для ID токенов OIDC
+	SigningKeyPath string `env:"JWT_SIGNING_KEY_PATH" yaml:"signing_key_path"`
+	// AccessTokenAlg HS256 или RS256. Токены RS256 подписываются ключом ID токенов
+	// и проверяются другими сервисами по JWKS без общего секрета
+	AccessTokenAlg string `env:"JWT_ACCESS_TOKEN_ALG" env-default:"HS256" yaml:"access_token_alg"`
+}
+
+type WebhooksConfig struct {
+	PollInterval time.Duration `env:"WEBHOOKS_POLL_INTERVAL" env-default:"5s" yaml:"poll_interval"`
+	BatchSize    int           `env:"WEBHOOKS_BATCH_SIZE" env-default:"20" yaml:"batch_size"`
+	Timeout      time.Duration `env:"WEBHOOKS_TIMEOUT" env-default:"10s" yaml:"timeout"`
+	// Lease на сколько доставки пачки закрепляются за экземпляром, должен превышать batch_size * timeout
+	Lease       time.Duration `env:"WEBHOOKS_LEASE" env-default:"5m" yaml:"lease"`
+	MaxAttempts int           `env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"8" yaml:"max_attempts"`
+	BackoffBase time.Duration `env:"WEBHOOKS_BACKOFF_BASE" env-default:"30s" yaml:"backoff_base"`
+	BackoffMax  time.Duration `env:"WEBHOOKS_BACKOFF_MAX" env-default:"6h" yaml:"backoff_max"`
+}
+
+type WatchConfig struct {
+	PollInterval time.Duration `env:"WATCH_POLL_INTERVAL" env-default:"30s" yaml:"poll_interval"`
+	BatchSize    int           `env:"WATCH_BATCH_SIZE" env-default:"100" yaml:"batch_size"`
+}
+
+type AuditConfig struct {
+	// CheckpointKey base64 seed ключа ed25519, без него контрольные точки не создаются
+	CheckpointKey      string        `env:"AUDIT_CHECKPOINT_KEY" yaml:"checkpoint_key"`
+	CheckpointInterval time.Duration `env:"AUDIT_CHECKPOINT_INTERVAL" env-default:"1h" yaml:"checkpoint_interval"`
+}
+
+type HTTPConfig struct {
+	Address string `env:"HTTP_ADDRESS" env-default:"localhost:8080" yaml:"address"`
+}
+
+type OAuthConfig struct {
+	// Issuer внешний адрес HTTP сервера, попадает в iss токенов
+	Issuer          string        `env:"OAUTH_ISSUER" env-default:"http://localhost:8080" yaml:"issuer"`
+	CodeTTL         time.Duration `env:"OAUTH_CODE_TTL" env-default:"1m" yaml:"code_ttl"`
+	RefreshTokenTTL time.Duration `env:"OAUTH_REFRESH_TOKEN_TTL" env-default:"720h" yaml:"refresh_token_ttl"`
+}
+
+// Режимы регистрации
+const (
+	RegistrationOpen       = "open"
+	RegistrationInviteOnly = "invite_only"
+)
+
+type RegistrationConfig struct {
+	// Mode open или invite_only, в режиме invite_only CreateUser отклоняется
+	Mode          string        `env:"REGISTRATION_MODE" env-default:"open" yaml:"mode"`
+	InvitationTTL time.Duration `env:"REGISTRATION_INVITATION_TTL" env-default:"168h" yaml:"invitation_ttl"`
+}
+
+type PolicyConfig struct {
+	// Path YAML файл политик, перечитывается при изменении
+	Path           string        `env:"POLICY_PATH" yaml:"path"`
+	ReloadInterval time.Duration `env:"POLICY_RELOAD_INTERVAL" env-default:"10s" yaml:"reload_interval"`
+}
+
+type GroupsConfig struct {
+	// ClaimName claim токена с именами групп пользователя, пустой - группы в токен не попадают
+	ClaimName string `env:"GROUPS_CLAIM_NAME" yaml:"claim_name"`
+	// ClaimMaxGroups при большем числе групп claim не добавляется, группы запрашиваются через ListUserGroups
+	ClaimMaxGroups int `env:"GROUPS_CLAIM_MAX_GROUPS" env-default:"50" yaml:"claim_max_groups"`
+}
+
+type RetentionConfig struct {
+	// DeletedUsers через сколько после удаления данные пользователя стираются, 0 - не стираются
+	DeletedUsers time.Duration `env:"RETENTION_DELETED_USERS" env-default:"720h" yaml:"deleted_users"`
+	Interval     time.Duration `env:"RETENTION_INTERVAL" env-default:"1h" yaml:"interval"`
+	BatchSize    int           `env:"RETENTION_BATCH_SIZE" env-default:"100" yaml:"batch_size"`
+}
+
+type ExportsConfig struct {
+	// SyncMaxEvents при большем числе событий аудита архив собирается в фоне
+	SyncMaxEvents int `env:"EXPORTS_SYNC_MAX_EVENTS" env-default:"1000" yaml:"sync_max_events"`
+	// ArchiveTTL сколько готовый архив доступен для скачивания
+	ArchiveTTL   time.Duration `env:"EXPORTS_ARCHIVE_TTL" env-default:"24h" yaml:"archive_ttl"`
+	PollInterval time.Duration `env:"EXPORTS_POLL_INTERVAL" env-default:"10s" yaml:"poll_interval"`
+	BatchSize    int           `env:"EXPORTS_BATCH_SIZE" env-default:"10" yaml:"batch_size"`
+}
+
+type MailConfig struct {
+	From string `env:"MAIL_FROM" env-default:"no-reply@localhost" yaml:"from"`
+	// Dir письма сохраняются файлами в этот каталог
+	Dir string `env:"MAIL_DIR" env-default:"./mail" yaml:"dir"`
+}
+
+type EmailChangeConfig struct {
+	TTL time.Duration `env:"EMAIL_CHANGE_TTL" env-default:"24h" yaml:"ttl"`
+	// ConfirmURL и CancelURL страницы, на которые ведут ссылки из писем, токен добавляется параметром token
+	ConfirmURL string `env:"EMAIL_CHANGE_CONFIRM_URL" env-default:"http://localhost:3000/email/confirm" yaml:"confirm_url"`
+	CancelURL  string `env:"EMAIL_CHANGE_CANCEL_URL" env-default:"http://localhost:3000/email/cancel" yaml:"cancel_url"`
+}
+
+type PasswordlessConfig struct {
+	// Enabled включает вход без пароля во всех организациях
+	Enabled bool `env:"PASSWORDLESS_ENABLED" env-default:"false" yaml:"enabled"`
+	// Organizations slug организаций, где вход включен при выключенном Enabled
+	Organizations []string `env:"PASSWORDLESS_ORGANIZATIONS" yaml:"organizations"`
+	// TTL срок действия ссылки и кода
+	TTL         time.Duration `env:"PASSWORDLESS_TTL" env-default:"10m" yaml:"ttl"`
+	MaxAttempts int           `env:"PASSWORDLESS_MAX_ATTEMPTS" env-default:"5" yaml:"max_attempts"`
+	// LinkURL страница входа, токен добавляется параметром token
+	LinkURL string `env:"PASSWORDLESS_LINK_URL" env-default:"http://localhost:3000/login/link" yaml:"link_url"`
+}
+
+type RelationsConfig struct {
+	Namespaces []NamespaceConfig `yaml:"namespaces"`
+}
+
+// NamespaceConfig тип объектов, например document
+type NamespaceConfig struct {
+	Name      string           `yaml:"name"`
+	Relations []RelationConfig `yaml:"relations"`
+}
+
+type RelationConfig struct {
+	Name string `yaml:"name"`
+	// Computed отношения того же объекта, которые включаются в это: editor входит в viewer
+	Computed []string `yaml:"computed"`
+	// Inherit отношения объектов из tupleset: viewer папки из parent документа
+	Inherit []InheritConfig `yaml:"inherit"`
+}
+
+type InheritConfig struct {
+	Tupleset string `yaml:"tupleset"`
+	Relation string `yaml:"relation"`
+}
+
+type FederationConfig struct {
+	// LoginTTL сколько ждем возврата пользователя от провайдера
+	LoginTTL  time.Duration    `env:"FEDERATION_LOGIN_TTL" env-default:"10m" yaml:"login_ttl"`
+	Providers []ProviderConfig `yaml:"providers"`
+}
+
+type ProviderConfig struct {
+	// Name часть адреса callback: <oauth.issuer>/federated/<name>/callback
+	Name         string   `yaml:"name"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+	// EmailClaim, EmailVerifiedClaim и NameClaim имена claims в ID токене провайдера
+	EmailClaim         string `yaml:"email_claim"`
+	EmailVerifiedClaim string `yaml:"email_verified_claim"`
+	NameClaim          string `yaml:"name_claim"`
+}
+
+type ExtAuthzConfig struct {
+	// Enabled регистрирует envoy.service.auth.v3.Authorization на gRPC сервере
+	Enabled bool              `env:"EXT_AUTHZ_ENABLED" yaml:"enabled"`
+	Rules   []RouteRuleConfig `yaml:"rules"`
+}
+
+// RouteRuleConfig маршрут без правила требует любого действующего токена
+type RouteRuleConfig struct {
+	PathPrefix string   `yaml:"path_prefix"`
+	Methods    []string `yaml:"methods"`
+	// Public пропускает запросы без токена
+	Public bool `yaml:"public"`
+	// Roles пустой список пропускает любую роль
+	Roles []string `yaml:"roles"`
+}
+
+type ForwardAuthConfig struct {
+	CookieName string `env:"FORWARD_AUTH_COOKIE_NAME" env-default:"auth_session" yaml:"cookie_name"`
+	// CookieDomain общий родительский домен сервиса и приложений, например example.com
+	CookieDomain string `env:"FORWARD_AUTH_COOKIE_DOMAIN" yaml:"cookie_domain"`
+	// Rules для сайтов, которых нет в hosts
+	Rules []RouteRuleConfig `yaml:"rules"`
+	Hosts []HostConfig      `yaml:"hosts"`
+}
+
+type HostConfig struct {
+	// Host имя сайта или *.example.com для поддоменов
+	Host string `yaml:"host"`
+	// LoginRedirect браузер без сессии перенаправляется на /auth/login вместо 401
+	LoginRedirect bool              `yaml:"login_redirect"`
+	Rules         []RouteRuleConfig `yaml:"rules"`
 }
 
 func NewConfig(configPath string, dotEnvPath string) (*Config, error) {
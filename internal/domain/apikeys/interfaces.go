@@ -0,0 +1,14 @@
+package apikeys
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Save(ctx context.Context, key *APIKey) error
+	Get(ctx context.Context, id uuid.UUID) (*APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*APIKey, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,194 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNameRequired   = errors.New("api key name is required")
+	ErrNotFound       = errors.New("api key not found")
+	ErrKeyNotValid    = errors.New("api key is not valid")
+	ErrExpired        = errors.New("api key is expired")
+	ErrRevoked        = errors.New("api key is revoked")
+	ErrAlreadyRevoked = errors.New("api key is already revoked")
+	ErrExpiryInvalid  = errors.New("api key expiry must be in the future")
+)
+
+const (
+	// KeyPrefix по нему ключ отличается от JWT и находится сканерами секретов
+	KeyPrefix = "ak_"
+	// lookupSize длина части ключа, по которой он ищется в базе, в байтах до hex
+	lookupSize = 4
+	// LastUsedGranularity чаще этого время последнего использования не обновляется
+	LastUsedGranularity = time.Minute
+)
+
+// Credentials от чьего имени действует ключ, пустой Scope - все права пользователя.
+// TenantID организация владельца, ключ не действует в других организациях
+type Credentials struct {
+	KeyID    uuid.UUID
+	UserID   uuid.UUID
+	TenantID uuid.UUID
+	Role     users.Role
+	Scope    oauth.Scope
+}
+
+// APIKey ключ вида ak_<prefix>_<secret>, хранятся только prefix и hash всего ключа
+type APIKey struct {
+	id         uuid.UUID
+	userID     uuid.UUID
+	name       string
+	prefix     string
+	keyHash    string
+	scope      oauth.Scope
+	expiresAt  *time.Time
+	lastUsedAt *time.Time
+	revokedAt  *time.Time
+	createdAt  time.Time
+}
+
+func NewAPIKey(
+	id uuid.UUID,
+	userID uuid.UUID,
+	name string,
+	prefix string,
+	keyHash string,
+	scope oauth.Scope,
+	expiresAt *time.Time,
+	lastUsedAt *time.Time,
+	revokedAt *time.Time,
+	createdAt time.Time,
+) *APIKey {
+	return &APIKey{
+		id:         id,
+		userID:     userID,
+		name:       name,
+		prefix:     prefix,
+		keyHash:    keyHash,
+		scope:      scope,
+		expiresAt:  expiresAt,
+		lastUsedAt: lastUsedAt,
+		revokedAt:  revokedAt,
+		createdAt:  createdAt,
+	}
+}
+
+// CreateAPIKey возвращает ключ и его значение, значение показывается один раз.
+// expiresAt nil - ключ бессрочный
+func CreateAPIKey(userID uuid.UUID, name string, scope oauth.Scope, expiresAt *time.Time) (*APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrNameRequired
+	}
+	now := time.Now().UTC()
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return nil, "", ErrExpiryInvalid
+		}
+		e := expiresAt.UTC()
+		expiresAt = &e
+	}
+
+	lookup := make([]byte, lookupSize)
+	if _, err := rand.Read(lookup); err != nil {
+		return nil, "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	prefix := hex.EncodeToString(lookup)
+	key := KeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	k := NewAPIKey(uuid.New(), userID, name, prefix, oauth.HashToken(key), oauth.ParseScope(scope.String()), expiresAt, nil, nil, now)
+	return k, key, nil
+}
+
+// ParsePrefix достает из ключа часть для поиска
+func ParsePrefix(key string) (string, error) {
+	rest, ok := strings.CutPrefix(key, KeyPrefix)
+	size := hex.EncodedLen(lookupSize)
+	if !ok || len(rest) <= size+1 || rest[size] != '_' {
+		return "", ErrKeyNotValid
+	}
+	prefix := rest[:size]
+	if _, err := hex.DecodeString(prefix); err != nil {
+		return "", ErrKeyNotValid
+	}
+	return prefix, nil
+}
+
+func (k *APIKey) ID() uuid.UUID {
+	return k.id
+}
+func (k *APIKey) UserID() uuid.UUID {
+	return k.userID
+}
+func (k *APIKey) Name() string {
+	return k.name
+}
+func (k *APIKey) Prefix() string {
+	return k.prefix
+}
+func (k *APIKey) KeyHash() string {
+	return k.keyHash
+}
+func (k *APIKey) Scope() oauth.Scope {
+	return k.scope
+}
+func (k *APIKey) ExpiresAt() *time.Time {
+	return k.expiresAt
+}
+func (k *APIKey) LastUsedAt() *time.Time {
+	return k.lastUsedAt
+}
+func (k *APIKey) RevokedAt() *time.Time {
+	return k.revokedAt
+}
+func (k *APIKey) CreatedAt() time.Time {
+	return k.createdAt
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return k.revokedAt != nil
+}
+
+// Verify проверяет значение ключа, затем отзыв и срок действия
+func (k *APIKey) Verify(key string, at time.Time) error {
+	if subtle.ConstantTimeCompare([]byte(k.keyHash), []byte(oauth.HashToken(key))) != 1 {
+		return ErrKeyNotValid
+	}
+	if k.IsRevoked() {
+		return ErrRevoked
+	}
+	if k.expiresAt != nil && !at.Before(*k.expiresAt) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// Touch отмечает использование ключа, false - время обновлялось недавно и сохранять не нужно
+func (k *APIKey) Touch(at time.Time) bool {
+	if k.lastUsedAt != nil && at.Sub(*k.lastUsedAt) < LastUsedGranularity {
+		return false
+	}
+	k.lastUsedAt = &at
+	return true
+}
+
+func (k *APIKey) Revoke(at time.Time) error {
+	if k.IsRevoked() {
+		return ErrAlreadyRevoked
+	}
+	k.revokedAt = &at
+	return nil
+}
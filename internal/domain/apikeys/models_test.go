@@ -0,0 +1,89 @@
+package apikeys
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	cases := []struct {
+		name      string
+		keyName   string
+		expiresAt *time.Time
+		wantErr   error
+	}{
+		{name: "no expiry", keyName: "ci"},
+		{name: "with expiry", keyName: "ci", expiresAt: &future},
+		{name: "empty name", keyName: " ", wantErr: ErrNameRequired},
+		{name: "expiry in past", keyName: "ci", expiresAt: &past, wantErr: ErrExpiryInvalid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			key, value, err := CreateAPIKey(uuid.New(), tt.keyName, oauth.Scope{"users.read"}, tt.expiresAt)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			prefix, err := ParsePrefix(value)
+			require.NoError(t, err)
+			assert.Equal(t, key.Prefix(), prefix)
+			assert.NotContains(t, key.KeyHash(), value)
+			assert.NoError(t, key.Verify(value, time.Now().UTC()))
+		})
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	cases := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{name: "valid", key: "ak_0a1b2c3d_secret"},
+		{name: "jwt", key: "eyJhbGciOiJIUzI1NiJ9.e30.sig", wantErr: ErrKeyNotValid},
+		{name: "no secret", key: "ak_0a1b2c3d_", wantErr: ErrKeyNotValid},
+		{name: "not hex", key: "ak_zzzzzzzz_secret", wantErr: ErrKeyNotValid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePrefix(tt.key)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAPIKey_Verify(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	key, value, err := CreateAPIKey(uuid.New(), "ci", nil, &expiresAt)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	assert.ErrorIs(t, key.Verify(value+"x", now), ErrKeyNotValid)
+	assert.ErrorIs(t, key.Verify(value, expiresAt.Add(time.Second)), ErrExpired)
+
+	require.NoError(t, key.Revoke(now))
+	assert.ErrorIs(t, key.Revoke(now), ErrAlreadyRevoked)
+	assert.ErrorIs(t, key.Verify(value, now), ErrRevoked)
+}
+
+func TestAPIKey_Touch(t *testing.T) {
+	key, _, err := CreateAPIKey(uuid.New(), "ci", nil, nil)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	assert.True(t, key.Touch(now))
+	assert.False(t, key.Touch(now.Add(LastUsedGranularity/2)))
+	assert.True(t, key.Touch(now.Add(LastUsedGranularity)))
+	assert.Equal(t, now.Add(LastUsedGranularity), *key.LastUsedAt())
+}
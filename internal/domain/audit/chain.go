@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ErrChainBroken        = errors.New("audit chain is broken")
+	ErrCheckpointNotValid = errors.New("audit checkpoint is not valid")
+)
+
+// Checkpoint подписанный снимок головы цепочки. Даже пересчитав все hash после правки,
+// злоумышленник не сможет подделать подпись уже выданных контрольных точек
+type Checkpoint struct {
+	id        uuid.UUID
+	seq       int64
+	hash      string
+	signature []byte
+	keyID     string
+	createdAt time.Time
+}
+
+type Signer interface {
+	KeyID() string
+	Sign(message []byte) ([]byte, error)
+	Verify(keyID string, message []byte, signature []byte) bool
+}
+
+func NewCheckpoint(
+	id uuid.UUID,
+	seq int64,
+	hash string,
+	signature []byte,
+	keyID string,
+	createdAt time.Time,
+) *Checkpoint {
+	return &Checkpoint{
+		id:        id,
+		seq:       seq,
+		hash:      hash,
+		signature: signature,
+		keyID:     keyID,
+		createdAt: createdAt,
+	}
+}
+
+func CreateCheckpoint(head *Event, signer Signer) (*Checkpoint, error) {
+	c := &Checkpoint{
+		id:        uuid.New(),
+		seq:       head.Seq(),
+		hash:      head.Hash(),
+		keyID:     signer.KeyID(),
+		createdAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	signature, err := signer.Sign(c.Message())
+	if err != nil {
+		return nil, err
+	}
+	c.signature = signature
+	return c, nil
+}
+
+func (c *Checkpoint) ID() uuid.UUID {
+	return c.id
+}
+func (c *Checkpoint) Seq() int64 {
+	return c.seq
+}
+func (c *Checkpoint) Hash() string {
+	return c.hash
+}
+func (c *Checkpoint) Signature() []byte {
+	return c.signature
+}
+func (c *Checkpoint) KeyID() string {
+	return c.keyID
+}
+func (c *Checkpoint) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+// Message подписываемое представление контрольной точки
+func (c *Checkpoint) Message() []byte {
+	return []byte(fmt.Sprintf("audit-checkpoint:%d:%s:%d", c.seq, c.hash, c.createdAt.UnixMicro()))
+}
+
+// Break первая найденная ошибка цепочки
+type Break struct {
+	Seq     int64
+	EventID uuid.UUID
+	Reason  string
+}
+
+type VerifyResult struct {
+	CheckedEvents      int64
+	CheckedCheckpoints int64
+	LastSeq            int64
+	LastHash           string
+	Break              *Break
+}
+
+func (r VerifyResult) Valid() bool {
+	return r.Break == nil
+}
+
+// ChainVerifier проверяет записи по порядку seq. Записи без hash допускаются только в начале журнала,
+// они появились до включения цепочки
+type ChainVerifier struct {
+	checkpoints map[int64]*Checkpoint
+	signer      Signer
+	started     bool
+	result      VerifyResult
+}
+
+// NewChainVerifier если signer nil, подписи контрольных точек не проверяются,
+// но hash в них все равно сверяется с цепочкой
+func NewChainVerifier(checkpoints []*Checkpoint, signer Signer) *ChainVerifier {
+	m := make(map[int64]*Checkpoint, len(checkpoints))
+	for _, c := range checkpoints {
+		m[c.Seq()] = c
+	}
+	return &ChainVerifier{
+		checkpoints: m,
+		signer:      signer,
+	}
+}
+
+// Next возвращает false после первой ошибки, дальнейшая проверка не имеет смысла
+func (v *ChainVerifier) Next(e *Event) bool {
+	if v.result.Break != nil {
+		return false
+	}
+	v.result.CheckedEvents++
+
+	if !e.IsSealed() {
+		if v.started {
+			return v.fail(e, "event is not sealed")
+		}
+		v.result.LastSeq = e.Seq()
+		return v.checkpoint(e)
+	}
+
+	if e.PrevHash() != v.result.LastHash {
+		return v.fail(e, "previous hash mismatch, preceding events were removed or reordered")
+	}
+	if e.ComputeHash() != e.Hash() {
+		return v.fail(e, "hash mismatch, event was modified")
+	}
+
+	v.started = true
+	v.result.LastSeq = e.Seq()
+	v.result.LastHash = e.Hash()
+	return v.checkpoint(e)
+}
+
+// Finish проверяет контрольные точки, которые указывают за конец журнала
+func (v *ChainVerifier) Finish() VerifyResult {
+	if v.result.Break == nil {
+		for seq, c := range v.checkpoints {
+			v.result.Break = &Break{
+				Seq:    seq,
+				Reason: fmt.Sprintf("checkpoint %s refers to a missing event, log was truncated", c.ID()),
+			}
+			break
+		}
+	}
+	return v.result
+}
+
+func (v *ChainVerifier) checkpoint(e *Event) bool {
+	c, ok := v.checkpoints[e.Seq()]
+	if !ok {
+		return true
+	}
+	delete(v.checkpoints, e.Seq())
+	v.result.CheckedCheckpoints++
+
+	if c.Hash() != e.Hash() {
+		return v.fail(e, fmt.Sprintf("hash differs from checkpoint %s", c.ID()))
+	}
+	if v.signer != nil && !v.signer.Verify(c.KeyID(), c.Message(), c.Signature()) {
+		return v.fail(e, fmt.Sprintf("checkpoint %s signature is not valid", c.ID()))
+	}
+	return true
+}
+
+func (v *ChainVerifier) fail(e *Event, reason string) bool {
+	v.result.Break = &Break{
+		Seq:     e.Seq(),
+		EventID: e.ID(),
+		Reason:  reason,
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testSigner struct {
+	key []byte
+}
+
+func (s testSigner) KeyID() string {
+	return "test"
+}
+
+func (s testSigner) Sign(message []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(message)
+	return mac.Sum(nil), nil
+}
+
+func (s testSigner) Verify(keyID string, message []byte, signature []byte) bool {
+	expected, _ := s.Sign(message)
+	return keyID == s.KeyID() && hmac.Equal(expected, signature)
+}
+
+func testChain(t *testing.T, n int) []*Event {
+	chain := make([]*Event, 0, n)
+	var prevHash string
+	for i := 1; i <= n; i++ {
+		e, err := CreateEvent(ActionLogin, uuid.New(), TargetUser, uuid.NewString(), "req", "10.0.0.1", OutcomeSuccess, "")
+		require.NoError(t, err)
+		require.NoError(t, e.Seal(prevHash))
+		e.SetSeq(int64(i))
+		prevHash = e.Hash()
+		chain = append(chain, e)
+	}
+	return chain
+}
+
+func verify(chain []*Event, checkpoints []*Checkpoint, signer Signer) VerifyResult {
+	v := NewChainVerifier(checkpoints, signer)
+	for _, e := range chain {
+		if !v.Next(e) {
+			break
+		}
+	}
+	return v.Finish()
+}
+
+func TestEvent_Seal(t *testing.T) {
+	e := testChain(t, 1)[0]
+	assert.Empty(t, e.PrevHash())
+	assert.Len(t, e.Hash(), 64)
+	assert.ErrorIs(t, e.Seal(""), ErrAlreadySealed)
+
+	// запись, прочитанная из хранилища, дает тот же hash
+	restored, err := NewEvent(e.Seq(), e.PrevHash(), e.Hash(), e.ID(), e.OccurredAt(), e.Action(), e.ActorID(),
+		e.TargetType(), e.TargetID(), e.RequestID(), e.PeerIP(), e.Outcome(), e.Reason())
+	require.NoError(t, err)
+	assert.Equal(t, e.Hash(), restored.ComputeHash())
+}
+
+func TestChainVerifier(t *testing.T) {
+	signer := testSigner{key: []byte("secret")}
+
+	cases := []struct {
+		name      string
+		tamper    func(chain []*Event) ([]*Event, []*Checkpoint)
+		wantValid bool
+		wantSeq   int64
+	}{
+		{
+			name: "valid",
+			tamper: func(chain []*Event) ([]*Event, []*Checkpoint) {
+				c, _ := CreateCheckpoint(chain[2], signer)
+				return chain, []*Checkpoint{c}
+			},
+			wantValid: true,
+		},
+		{
+			name: "legacy events before chain",
+			tamper: func(chain []*Event) ([]*Event, []*Checkpoint) {
+				legacy, _ := NewEvent(0, "", "", uuid.New(), time.Now().UTC(), ActionLogin, uuid.Nil, TargetUser, "", "", "", OutcomeSuccess, "")
+				return append([]*Event{legacy}, chain...), nil
+			},
+			wantValid: true,
+		},
+		{
+			name: "modified event",
+			tamper: func(chain []*Event) ([]*Event, []*Checkpoint) {
+				e := chain[1]
+				chain[1], _ = NewEvent(e.Seq(), e.PrevHash(), e.Hash(), e.ID(), e.OccurredAt(), e.Action(), e.ActorID(),
+					e.TargetType(), e.TargetID(), e.RequestID(), e.PeerIP(), OutcomeFailure, e.Reason())
+				return chain, nil
+			},
+			wantSeq: 2,
+		},
+		{
+			name: "removed event",
+			tamper: func(chain []*Event) ([]*Event, []*Checkpoint) {
+				return append(chain[:1], chain[2:]...), nil
+			},
+			wantSeq: 3,
+		},
+		{
+			name: "unsealed event inside chain",
+			tamper: func(chain []*Event) ([]*Event, []*Checkpoint) {
+				e := chain[2]
+				chain[2], _ = NewEvent(e.Seq(), "", "", e.ID(), e.OccurredAt(), e.Action(), e.ActorID(),
+					e.TargetType(), e.TargetID(), e.RequestID(), e.PeerIP(), e.Outcome(), e.Reason())
+				return chain, nil
+			},
+			wantSeq: 3,
+		},
+		{
+			name: "truncated tail",
+			tamper: func(chain []*Event) ([]*Event, []*Checkpoint) {
+				c, _ := CreateCheckpoint(chain[3], signer)
+				return chain[:3], []*Checkpoint{c}
+			},
+			wantSeq: 4,
+		},
+		{
+			name: "forged checkpoint",
+			tamper: func(chain []*Event) ([]*Event, []*Checkpoint) {
+				c, _ := CreateCheckpoint(chain[1], testSigner{key: []byte("other")})
+				return chain, []*Checkpoint{c}
+			},
+			wantSeq: 2,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, checkpoints := tt.tamper(testChain(t, 4))
+			res := verify(chain, checkpoints, signer)
+			assert.Equal(t, tt.wantValid, res.Valid())
+			if !tt.wantValid {
+				require.NotNil(t, res.Break)
+				assert.Equal(t, tt.wantSeq, res.Break.Seq)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package audit
+
+import "context"
+
+type Repository interface {
+	// Append запечатывает событие hash последней записи цепочки, вставки сериализуются
+	Append(ctx context.Context, event *Event) error
+	List(ctx context.Context, filter Filter) ([]*Event, error)
+	// ListChain записи с seq больше afterSeq по возрастанию seq
+	ListChain(ctx context.Context, afterSeq int64, limit int) ([]*Event, error)
+	// Head последняя запечатанная запись, nil если цепочка пуста
+	Head(ctx context.Context) (*Event, error)
+	SaveCheckpoint(ctx context.Context, checkpoint *Checkpoint) error
+	LastCheckpoint(ctx context.Context) (*Checkpoint, error)
+	ListCheckpoints(ctx context.Context) ([]*Checkpoint, error)
+}
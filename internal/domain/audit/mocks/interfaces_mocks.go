@@ -0,0 +1,145 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./interfaces.go -destination=./mocks/interfaces_mocks.go
+//
+
+// Package mock_audit is a generated GoMock package.
+package mock_audit
+
+import (
+	context "context"
+	reflect "reflect"
+
+	audit "github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockRepository) Append(ctx context.Context, event *audit.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockRepositoryMockRecorder) Append(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockRepository)(nil).Append), ctx, event)
+}
+
+// Head mocks base method.
+func (m *MockRepository) Head(ctx context.Context) (*audit.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Head", ctx)
+	ret0, _ := ret[0].(*audit.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Head indicates an expected call of Head.
+func (mr *MockRepositoryMockRecorder) Head(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Head", reflect.TypeOf((*MockRepository)(nil).Head), ctx)
+}
+
+// LastCheckpoint mocks base method.
+func (m *MockRepository) LastCheckpoint(ctx context.Context) (*audit.Checkpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastCheckpoint", ctx)
+	ret0, _ := ret[0].(*audit.Checkpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastCheckpoint indicates an expected call of LastCheckpoint.
+func (mr *MockRepositoryMockRecorder) LastCheckpoint(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastCheckpoint", reflect.TypeOf((*MockRepository)(nil).LastCheckpoint), ctx)
+}
+
+// List mocks base method.
+func (m *MockRepository) List(ctx context.Context, filter audit.Filter) ([]*audit.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].([]*audit.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockRepositoryMockRecorder) List(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRepository)(nil).List), ctx, filter)
+}
+
+// ListChain mocks base method.
+func (m *MockRepository) ListChain(ctx context.Context, afterSeq int64, limit int) ([]*audit.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChain", ctx, afterSeq, limit)
+	ret0, _ := ret[0].([]*audit.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChain indicates an expected call of ListChain.
+func (mr *MockRepositoryMockRecorder) ListChain(ctx, afterSeq, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChain", reflect.TypeOf((*MockRepository)(nil).ListChain), ctx, afterSeq, limit)
+}
+
+// ListCheckpoints mocks base method.
+func (m *MockRepository) ListCheckpoints(ctx context.Context) ([]*audit.Checkpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCheckpoints", ctx)
+	ret0, _ := ret[0].([]*audit.Checkpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCheckpoints indicates an expected call of ListCheckpoints.
+func (mr *MockRepositoryMockRecorder) ListCheckpoints(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCheckpoints", reflect.TypeOf((*MockRepository)(nil).ListCheckpoints), ctx)
+}
+
+// SaveCheckpoint mocks base method.
+func (m *MockRepository) SaveCheckpoint(ctx context.Context, checkpoint *audit.Checkpoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCheckpoint", ctx, checkpoint)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCheckpoint indicates an expected call of SaveCheckpoint.
+func (mr *MockRepositoryMockRecorder) SaveCheckpoint(ctx, checkpoint any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCheckpoint", reflect.TypeOf((*MockRepository)(nil).SaveCheckpoint), ctx, checkpoint)
+}
@@ -0,0 +1,306 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ErrActionNotValid  = errors.New("audit action is not valid")
+	ErrOutcomeNotValid = errors.New("audit outcome is not valid")
+	ErrAlreadySealed   = errors.New("audit event is already sealed")
+)
+
+type Action string
+
+const (
+	ActionLogin            Action = "user.login"
+	ActionUserCreated      Action = "user.created"
+	ActionUserUpdated      Action = "user.updated"
+	ActionUserDeleted      Action = "user.deleted"
+	ActionPasswordChanged  Action = "user.password_changed"
+	ActionTokenRevoked     Action = "token.revoked"
+	ActionIdentityLinked   Action = "identity.linked"
+	ActionIdentityUnlinked Action = "identity.unlinked"
+
+	ActionUserSuspended   Action = "user.suspended"
+	ActionUserReactivated Action = "user.reactivated"
+	ActionSessionsRevoked Action = "user.sessions_revoked"
+	ActionPasswordReset   Action = "user.password_reset"
+	ActionEmailReassigned Action = "user.email_reassigned"
+	ActionUserErased      Action = "user.erased"
+	ActionDataExported    Action = "user.data_exported"
+
+	ActionEmailChangeRequested Action = "user.email_change_requested"
+	ActionEmailChanged         Action = "user.email_changed"
+	ActionEmailChangeCancelled Action = "user.email_change_cancelled"
+
+	ActionPasswordlessRequested Action = "user.passwordless_requested"
+
+	ActionServiceAccountCreated  Action = "service_account.created"
+	ActionServiceAccountRotated  Action = "service_account.secret_rotated"
+	ActionServiceAccountDisabled Action = "service_account.disabled"
+
+	ActionAPIKeyCreated Action = "api_key.created"
+	ActionAPIKeyRevoked Action = "api_key.revoked"
+
+	ActionOrganizationCreated Action = "organization.created"
+	ActionOrganizationUpdated Action = "organization.updated"
+	ActionOrganizationDeleted Action = "organization.deleted"
+
+	ActionInvitationCreated  Action = "invitation.created"
+	ActionInvitationRevoked  Action = "invitation.revoked"
+	ActionInvitationAccepted Action = "invitation.accepted"
+
+	ActionGroupCreated       Action = "group.created"
+	ActionGroupUpdated       Action = "group.updated"
+	ActionGroupDeleted       Action = "group.deleted"
+	ActionGroupMemberAdded   Action = "group.member_added"
+	ActionGroupMemberRemoved Action = "group.member_removed"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+type TargetType string
+
+const (
+	TargetUser           TargetType = "user"
+	TargetToken          TargetType = "token"
+	TargetServiceAccount TargetType = "service_account"
+	TargetAPIKey         TargetType = "api_key"
+	TargetOrganization   TargetType = "organization"
+	TargetInvitation     TargetType = "invitation"
+	TargetGroup          TargetType = "group"
+)
+
+// Event запись журнала аудита, после сохранения не изменяется.
+// hash покрывает поля записи и hash предыдущей записи, поэтому правка или удаление
+// любой записи ломает цепочку начиная с нее
+type Event struct {
+	seq        int64
+	prevHash   string
+	hash       string
+	id         uuid.UUID
+	occurredAt time.Time
+	action     Action
+	actorID    uuid.UUID
+	targetType TargetType
+	targetID   string
+	requestID  string
+	peerIP     string
+	outcome    Outcome
+	reason     string
+}
+
+type Filter struct {
+	ActorID  uuid.UUID
+	TargetID string
+	Action   Action
+	Limit    int
+	Offset   int
+}
+
+func NewEvent(
+	seq int64,
+	prevHash string,
+	hash string,
+	id uuid.UUID,
+	occurredAt time.Time,
+	action Action,
+	actorID uuid.UUID,
+	targetType TargetType,
+	targetID string,
+	requestID string,
+	peerIP string,
+	outcome Outcome,
+	reason string,
+) (*Event, error) {
+	if err := action.validate(); err != nil {
+		return nil, err
+	}
+	if err := outcome.validate(); err != nil {
+		return nil, err
+	}
+	return &Event{
+		seq:        seq,
+		prevHash:   prevHash,
+		hash:       hash,
+		id:         id,
+		occurredAt: occurredAt,
+		action:     action,
+		actorID:    actorID,
+		targetType: targetType,
+		targetID:   targetID,
+		requestID:  requestID,
+		peerIP:     peerIP,
+		outcome:    outcome,
+		reason:     reason,
+	}, nil
+}
+
+// CreateEvent новая запись, seq и hash назначаются при сохранении
+func CreateEvent(
+	action Action,
+	actorID uuid.UUID,
+	targetType TargetType,
+	targetID string,
+	requestID string,
+	peerIP string,
+	outcome Outcome,
+	reason string,
+) (*Event, error) {
+	// timestamp в postgres хранит микросекунды, иначе hash не сойдется после чтения
+	occurredAt := time.Now().UTC().Truncate(time.Microsecond)
+	return NewEvent(0, "", "", uuid.New(), occurredAt, action, actorID, targetType, targetID, requestID, peerIP, outcome, reason)
+}
+
+func (e *Event) Seq() int64 {
+	return e.seq
+}
+func (e *Event) PrevHash() string {
+	return e.prevHash
+}
+func (e *Event) Hash() string {
+	return e.hash
+}
+func (e *Event) IsSealed() bool {
+	return e.hash != ""
+}
+func (e *Event) ID() uuid.UUID {
+	return e.id
+}
+func (e *Event) OccurredAt() time.Time {
+	return e.occurredAt
+}
+func (e *Event) Action() Action {
+	return e.action
+}
+
+// ActorID uuid.Nil если действие выполнено анонимно, например неудачный вход
+func (e *Event) ActorID() uuid.UUID {
+	return e.actorID
+}
+func (e *Event) TargetType() TargetType {
+	return e.targetType
+}
+func (e *Event) TargetID() string {
+	return e.targetID
+}
+func (e *Event) RequestID() string {
+	return e.requestID
+}
+func (e *Event) PeerIP() string {
+	return e.peerIP
+}
+func (e *Event) Outcome() Outcome {
+	return e.outcome
+}
+func (e *Event) Reason() string {
+	return e.reason
+}
+
+// Seal связывает запись с предыдущей, для первой записи цепочки prevHash пустой
+func (e *Event) Seal(prevHash string) error {
+	if e.IsSealed() {
+		return ErrAlreadySealed
+	}
+	e.prevHash = prevHash
+	e.hash = e.ComputeHash()
+	return nil
+}
+
+func (e *Event) SetSeq(seq int64) {
+	e.seq = seq
+}
+
+// ComputeHash sha256 от prevHash и полей записи, каждое поле с префиксом длины
+func (e *Event) ComputeHash() string {
+	var actorID string
+	if e.actorID != uuid.Nil {
+		actorID = e.actorID.String()
+	}
+	fields := []string{
+		e.prevHash,
+		e.id.String(),
+		e.occurredAt.UTC().Format(time.RFC3339Nano),
+		e.action.String(),
+		actorID,
+		e.targetType.String(),
+		e.targetID,
+		e.requestID,
+		e.peerIP,
+		e.outcome.String(),
+		e.reason,
+	}
+	h := sha256.New()
+	var size [8]byte
+	for _, f := range fields {
+		binary.BigEndian.PutUint64(size[:], uint64(len(f)))
+		h.Write(size[:])
+		h.Write([]byte(f))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func NewAction(action string) (Action, error) {
+	a := Action(action)
+	if err := a.validate(); err != nil {
+		return "", err
+	}
+	return a, nil
+}
+
+func (a Action) validate() error {
+	switch a {
+	case ActionLogin, ActionUserCreated, ActionUserUpdated, ActionUserDeleted, ActionPasswordChanged, ActionTokenRevoked,
+		ActionIdentityLinked, ActionIdentityUnlinked,
+		ActionUserSuspended, ActionUserReactivated, ActionSessionsRevoked, ActionPasswordReset, ActionEmailReassigned, ActionUserErased,
+		ActionDataExported, ActionEmailChangeRequested, ActionEmailChanged, ActionEmailChangeCancelled, ActionPasswordlessRequested,
+		ActionServiceAccountCreated, ActionServiceAccountRotated, ActionServiceAccountDisabled,
+		ActionAPIKeyCreated, ActionAPIKeyRevoked,
+		ActionOrganizationCreated, ActionOrganizationUpdated, ActionOrganizationDeleted,
+		ActionInvitationCreated, ActionInvitationRevoked, ActionInvitationAccepted,
+		ActionGroupCreated, ActionGroupUpdated, ActionGroupDeleted, ActionGroupMemberAdded, ActionGroupMemberRemoved:
+		return nil
+	default:
+		return ErrActionNotValid
+	}
+}
+
+func (a Action) String() string {
+	return string(a)
+}
+
+func NewOutcome(outcome string) (Outcome, error) {
+	o := Outcome(outcome)
+	if err := o.validate(); err != nil {
+		return "", err
+	}
+	return o, nil
+}
+
+func (o Outcome) validate() error {
+	switch o {
+	case OutcomeSuccess, OutcomeFailure:
+		return nil
+	default:
+		return ErrOutcomeNotValid
+	}
+}
+
+func (o Outcome) String() string {
+	return string(o)
+}
+
+func (t TargetType) String() string {
+	return string(t)
+}
@@ -0,0 +1,19 @@
+package changes
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Append(ctx context.Context, change *Change) error
+	ListSince(ctx context.Context, position int64, limit int) ([]*Change, error)
+	LastPosition(ctx context.Context) (int64, error)
+	// ScrubUser стирает имя и email пользователя в прошлых изменениях, позиции сохраняются
+	ScrubUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// Notifier сигналит подписчикам о появлении новых изменений, сами изменения читаются из Repository
+type Notifier interface {
+	Subscribe() (notifications <-chan struct{}, unsubscribe func())
+}
@@ -0,0 +1,119 @@
+package changes
+
+import (
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"time"
+)
+
+var ErrTypeNotValid = errors.New("change type is not valid")
+
+type Type string
+
+const (
+	TypeCreated Type = "created"
+	TypeUpdated Type = "updated"
+	TypeDeleted Type = "deleted"
+)
+
+// Change снимок пользователя после изменения, position монотонно растет и служит курсором для клиентов
+type Change struct {
+	position   int64
+	changeType Type
+	userID     uuid.UUID
+	name       string
+	email      string
+	role       string
+	isActive   bool
+	occurredAt time.Time
+}
+
+func NewChange(
+	position int64,
+	changeType Type,
+	userID uuid.UUID,
+	name string,
+	email string,
+	role string,
+	isActive bool,
+	occurredAt time.Time,
+) (*Change, error) {
+	if err := changeType.validate(); err != nil {
+		return nil, err
+	}
+	return &Change{
+		position:   position,
+		changeType: changeType,
+		userID:     userID,
+		name:       name,
+		email:      email,
+		role:       role,
+		isActive:   isActive,
+		occurredAt: occurredAt,
+	}, nil
+}
+
+// CreateUserChange позиция назначается хранилищем при записи
+func CreateUserChange(changeType Type, user *users.User) (*Change, error) {
+	return NewChange(
+		0,
+		changeType,
+		user.ID(),
+		user.Name().String(),
+		user.Email().String(),
+		user.Role().String(),
+		user.IsActive(),
+		time.Now().UTC(),
+	)
+}
+
+func (c *Change) Position() int64 {
+	return c.position
+}
+func (c *Change) Type() Type {
+	return c.changeType
+}
+func (c *Change) UserID() uuid.UUID {
+	return c.userID
+}
+func (c *Change) Name() string {
+	return c.name
+}
+func (c *Change) Email() string {
+	return c.email
+}
+func (c *Change) Role() string {
+	return c.role
+}
+func (c *Change) IsActive() bool {
+	return c.isActive
+}
+func (c *Change) OccurredAt() time.Time {
+	return c.occurredAt
+}
+
+func (c *Change) SetPosition(position int64) {
+	c.position = position
+}
+
+func NewType(changeType string) (Type, error) {
+	t := Type(changeType)
+	if err := t.validate(); err != nil {
+		return "", err
+	}
+	return t, nil
+}
+
+func (t Type) validate() error {
+	switch t {
+	case TypeCreated, TypeUpdated, TypeDeleted:
+		return nil
+	default:
+		return ErrTypeNotValid
+	}
+}
+
+func (t Type) String() string {
+	return string(t)
+}
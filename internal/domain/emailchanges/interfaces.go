@@ -0,0 +1,18 @@
+package emailchanges
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Repository interface {
+	Save(ctx context.Context, change *Change) error
+	// GetByConfirmHash блокирует строку до конца транзакции, чтобы токен нельзя было применить дважды
+	GetByConfirmHash(ctx context.Context, confirmHash string) (*Change, error)
+	// GetByCancelHash блокирует строку до конца транзакции
+	GetByCancelHash(ctx context.Context, cancelHash string) (*Change, error)
+	// CancelPending отменяет незавершенные запросы пользователя, действует только последний
+	CancelPending(ctx context.Context, userID uuid.UUID, at time.Time) error
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./interfaces.go -destination=./mocks/interfaces_mocks.go
+//
+
+// Package mock_emailchanges is a generated GoMock package.
+package mock_emailchanges
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	emailchanges "github.com/LeoUraltsev/auth-service/internal/domain/emailchanges"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CancelPending mocks base method.
+func (m *MockRepository) CancelPending(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPending", ctx, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelPending indicates an expected call of CancelPending.
+func (mr *MockRepositoryMockRecorder) CancelPending(ctx, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPending", reflect.TypeOf((*MockRepository)(nil).CancelPending), ctx, userID, at)
+}
+
+// DeleteByUser mocks base method.
+func (m *MockRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockRepositoryMockRecorder) DeleteByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockRepository)(nil).DeleteByUser), ctx, userID)
+}
+
+// GetByCancelHash mocks base method.
+func (m *MockRepository) GetByCancelHash(ctx context.Context, cancelHash string) (*emailchanges.Change, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCancelHash", ctx, cancelHash)
+	ret0, _ := ret[0].(*emailchanges.Change)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCancelHash indicates an expected call of GetByCancelHash.
+func (mr *MockRepositoryMockRecorder) GetByCancelHash(ctx, cancelHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCancelHash", reflect.TypeOf((*MockRepository)(nil).GetByCancelHash), ctx, cancelHash)
+}
+
+// GetByConfirmHash mocks base method.
+func (m *MockRepository) GetByConfirmHash(ctx context.Context, confirmHash string) (*emailchanges.Change, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByConfirmHash", ctx, confirmHash)
+	ret0, _ := ret[0].(*emailchanges.Change)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByConfirmHash indicates an expected call of GetByConfirmHash.
+func (mr *MockRepositoryMockRecorder) GetByConfirmHash(ctx, confirmHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByConfirmHash", reflect.TypeOf((*MockRepository)(nil).GetByConfirmHash), ctx, confirmHash)
+}
+
+// Save mocks base method.
+func (m *MockRepository) Save(ctx context.Context, change *emailchanges.Change) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockRepositoryMockRecorder) Save(ctx, change any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, change)
+}
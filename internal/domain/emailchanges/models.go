@@ -0,0 +1,206 @@
+package emailchanges
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("email change not found")
+	ErrTokenNotValid = errors.New("email change token is not valid")
+	ErrExpired       = errors.New("email change is expired")
+	ErrNotPending    = errors.New("email change is already confirmed or cancelled")
+	ErrTTLInvalid    = errors.New("email change ttl must be positive")
+	ErrSameEmail     = errors.New("new email matches the current one")
+	// ErrStale адрес пользователя изменился другим способом после запроса
+	ErrStale = errors.New("email change is stale")
+)
+
+const (
+	// ConfirmPrefix токен подтверждения из письма на новый адрес
+	ConfirmPrefix = "ecc_"
+	// CancelPrefix токен отмены из письма на старый адрес
+	CancelPrefix = "ecx_"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusConfirmed Status = "confirmed"
+	StatusCancelled Status = "cancelled"
+	StatusExpired   Status = "expired"
+)
+
+// Change запрос на смену email. Новый адрес применяется только после подтверждения с него,
+// владелец старого адреса может отменить смену. Токены одноразовые, хранятся только их hash
+type Change struct {
+	id          uuid.UUID
+	tenantID    uuid.UUID
+	userID      uuid.UUID
+	oldEmail    users.Email
+	newEmail    users.Email
+	confirmHash string
+	cancelHash  string
+	expiresAt   time.Time
+	confirmedAt *time.Time
+	cancelledAt *time.Time
+	createdAt   time.Time
+}
+
+// Tokens значения токенов, показываются только в письмах
+type Tokens struct {
+	Confirm string
+	Cancel  string
+}
+
+func NewChange(
+	id uuid.UUID,
+	tenantID uuid.UUID,
+	userID uuid.UUID,
+	oldEmail users.Email,
+	newEmail users.Email,
+	confirmHash string,
+	cancelHash string,
+	expiresAt time.Time,
+	confirmedAt *time.Time,
+	cancelledAt *time.Time,
+	createdAt time.Time,
+) *Change {
+	return &Change{
+		id:          id,
+		tenantID:    tenantID,
+		userID:      userID,
+		oldEmail:    oldEmail,
+		newEmail:    newEmail,
+		confirmHash: confirmHash,
+		cancelHash:  cancelHash,
+		expiresAt:   expiresAt,
+		confirmedAt: confirmedAt,
+		cancelledAt: cancelledAt,
+		createdAt:   createdAt,
+	}
+}
+
+func CreateChange(u *users.User, newEmail users.Email, ttl time.Duration) (*Change, Tokens, error) {
+	if u.Email() == newEmail {
+		return nil, Tokens{}, ErrSameEmail
+	}
+	if ttl <= 0 {
+		return nil, Tokens{}, ErrTTLInvalid
+	}
+	confirm, err := newToken(ConfirmPrefix)
+	if err != nil {
+		return nil, Tokens{}, err
+	}
+	cancel, err := newToken(CancelPrefix)
+	if err != nil {
+		return nil, Tokens{}, err
+	}
+
+	now := time.Now().UTC()
+	c := NewChange(uuid.New(), u.TenantID(), u.ID(), u.Email(), newEmail, HashToken(confirm), HashToken(cancel), now.Add(ttl), nil, nil, now)
+	return c, Tokens{Confirm: confirm, Cancel: cancel}, nil
+}
+
+func newToken(prefix string) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashToken по hash токена ищется запрос
+func HashToken(token string) string {
+	return oauth.HashToken(token)
+}
+
+// ValidToken токен похож на токен с префиксом prefix, проверяется до обращения к базе
+func ValidToken(token string, prefix string) error {
+	if !strings.HasPrefix(token, prefix) || len(token) == len(prefix) {
+		return ErrTokenNotValid
+	}
+	return nil
+}
+
+func (c *Change) ID() uuid.UUID {
+	return c.id
+}
+func (c *Change) TenantID() uuid.UUID {
+	return c.tenantID
+}
+func (c *Change) UserID() uuid.UUID {
+	return c.userID
+}
+func (c *Change) OldEmail() users.Email {
+	return c.oldEmail
+}
+func (c *Change) NewEmail() users.Email {
+	return c.newEmail
+}
+func (c *Change) ConfirmHash() string {
+	return c.confirmHash
+}
+func (c *Change) CancelHash() string {
+	return c.cancelHash
+}
+func (c *Change) ExpiresAt() time.Time {
+	return c.expiresAt
+}
+func (c *Change) ConfirmedAt() *time.Time {
+	return c.confirmedAt
+}
+func (c *Change) CancelledAt() *time.Time {
+	return c.cancelledAt
+}
+func (c *Change) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+func (c *Change) Status(at time.Time) Status {
+	switch {
+	case c.confirmedAt != nil:
+		return StatusConfirmed
+	case c.cancelledAt != nil:
+		return StatusCancelled
+	case !at.Before(c.expiresAt):
+		return StatusExpired
+	default:
+		return StatusPending
+	}
+}
+
+// Confirm адрес пользователя меняет вызывающий, проверив уникальность нового адреса
+func (c *Change) Confirm(at time.Time) error {
+	if err := c.pending(at); err != nil {
+		return err
+	}
+	c.confirmedAt = &at
+	return nil
+}
+
+func (c *Change) Cancel(at time.Time) error {
+	if err := c.pending(at); err != nil {
+		return err
+	}
+	c.cancelledAt = &at
+	return nil
+}
+
+func (c *Change) pending(at time.Time) error {
+	switch c.Status(at) {
+	case StatusPending:
+		return nil
+	case StatusExpired:
+		return ErrExpired
+	default:
+		return ErrNotPending
+	}
+}
@@ -0,0 +1,65 @@
+package emailchanges
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testUser(t *testing.T) *users.User {
+	email, _ := users.NewEmail("old@example.com")
+	password, _ := users.NewPassword([]byte("hash"))
+	u, err := users.CreateUser(uuid.New(), "user", email, password)
+	require.NoError(t, err)
+	return u
+}
+
+func TestCreateChange(t *testing.T) {
+	u := testUser(t)
+	newEmail, _ := users.NewEmail("new@example.com")
+
+	_, _, err := CreateChange(u, u.Email(), time.Hour)
+	assert.ErrorIs(t, err, ErrSameEmail)
+	_, _, err = CreateChange(u, newEmail, 0)
+	assert.ErrorIs(t, err, ErrTTLInvalid)
+
+	change, tokens, err := CreateChange(u, newEmail, time.Hour)
+	require.NoError(t, err)
+	assert.NoError(t, ValidToken(tokens.Confirm, ConfirmPrefix))
+	assert.NoError(t, ValidToken(tokens.Cancel, CancelPrefix))
+	assert.ErrorIs(t, ValidToken(tokens.Cancel, ConfirmPrefix), ErrTokenNotValid)
+	assert.Equal(t, HashToken(tokens.Confirm), change.ConfirmHash())
+	assert.Equal(t, HashToken(tokens.Cancel), change.CancelHash())
+	assert.Equal(t, u.Email(), change.OldEmail())
+	assert.Equal(t, newEmail, change.NewEmail())
+	assert.Equal(t, u.TenantID(), change.TenantID())
+	assert.Equal(t, StatusPending, change.Status(time.Now().UTC()))
+}
+
+func TestChange_Confirm(t *testing.T) {
+	newEmail, _ := users.NewEmail("new@example.com")
+	change, _, err := CreateChange(testUser(t), newEmail, time.Hour)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	assert.ErrorIs(t, change.Confirm(now.Add(2*time.Hour)), ErrExpired)
+
+	require.NoError(t, change.Confirm(now))
+	assert.Equal(t, StatusConfirmed, change.Status(now))
+	assert.ErrorIs(t, change.Confirm(now), ErrNotPending)
+	assert.ErrorIs(t, change.Cancel(now), ErrNotPending)
+}
+
+func TestChange_Cancel(t *testing.T) {
+	newEmail, _ := users.NewEmail("new@example.com")
+	change, _, err := CreateChange(testUser(t), newEmail, time.Hour)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	require.NoError(t, change.Cancel(now))
+	assert.Equal(t, StatusCancelled, change.Status(now))
+	assert.ErrorIs(t, change.Confirm(now), ErrNotPending)
+}
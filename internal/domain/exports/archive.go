@@ -0,0 +1,206 @@
+package exports
+
+import (
+	"encoding/json"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"slices"
+	"time"
+)
+
+// Data все, что сервис хранит о пользователе. Секреты (хеши паролей, токенов и ключей) в архив не попадают
+type Data struct {
+	User          *users.User
+	Identities    []*identity.Identity
+	RefreshTokens []*oauth.RefreshToken
+	APIKeys       []*apikeys.APIKey
+	AuditEvents   []*audit.Event
+}
+
+type profile struct {
+	ID              string    `json:"id"`
+	TenantID        string    `json:"tenant_id"`
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	Role            string    `json:"role"`
+	Status          string    `json:"status"`
+	StatusChangedAt time.Time `json:"status_changed_at"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type linkedIdentity struct {
+	ID        string    `json:"id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type session struct {
+	ID        string     `json:"id"`
+	ClientID  string     `json:"client_id"`
+	Scope     []string   `json:"scope"`
+	AuthTime  time.Time  `json:"auth_time"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type apiKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scope      []string   `json:"scope"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type auditEvent struct {
+	ID         string    `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Action     string    `json:"action"`
+	ActorID    string    `json:"actor_id,omitempty"`
+	TargetType string    `json:"target_type,omitempty"`
+	TargetID   string    `json:"target_id,omitempty"`
+	PeerIP     string    `json:"peer_ip,omitempty"`
+	Outcome    string    `json:"outcome"`
+}
+
+// consent согласие на доступ клиента к данным, отдельно не хранится и выводится из выданных refresh токенов
+type consent struct {
+	ClientID  string    `json:"client_id"`
+	Scope     []string  `json:"scope"`
+	GrantedAt time.Time `json:"granted_at"`
+	Active    bool      `json:"active"`
+}
+
+type archive struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Profile     profile          `json:"profile"`
+	Identities  []linkedIdentity `json:"identities"`
+	Sessions    []session        `json:"sessions"`
+	APIKeys     []apiKey         `json:"api_keys"`
+	AuditEvents []auditEvent     `json:"audit_events"`
+	Consents    []consent        `json:"consents"`
+}
+
+// BuildArchive собирает JSON архив данных пользователя на момент at
+func BuildArchive(data Data, at time.Time) ([]byte, error) {
+	u := data.User
+	a := archive{
+		GeneratedAt: at,
+		Profile: profile{
+			ID:              u.ID().String(),
+			TenantID:        u.TenantID().String(),
+			Name:            u.Name().String(),
+			Email:           u.Email().String(),
+			Role:            u.Role().String(),
+			Status:          u.Status().String(),
+			StatusChangedAt: u.StatusChangedAt(),
+			CreatedAt:       u.CreatedAt(),
+			UpdatedAt:       u.UpdatedAt(),
+		},
+		Identities:  make([]linkedIdentity, 0, len(data.Identities)),
+		Sessions:    make([]session, 0, len(data.RefreshTokens)),
+		APIKeys:     make([]apiKey, 0, len(data.APIKeys)),
+		AuditEvents: make([]auditEvent, 0, len(data.AuditEvents)),
+		Consents:    consents(data.RefreshTokens, at),
+	}
+	for _, i := range data.Identities {
+		a.Identities = append(a.Identities, linkedIdentity{
+			ID:        i.ID().String(),
+			Provider:  i.Provider(),
+			Subject:   i.Subject(),
+			Email:     i.Email(),
+			CreatedAt: i.CreatedAt(),
+		})
+	}
+	for _, t := range data.RefreshTokens {
+		a.Sessions = append(a.Sessions, session{
+			ID:        t.ID().String(),
+			ClientID:  t.ClientID(),
+			Scope:     scope(t.Scope()),
+			AuthTime:  t.Auth().Time,
+			ExpiresAt: t.ExpiresAt(),
+			RevokedAt: t.RevokedAt(),
+			CreatedAt: t.CreatedAt(),
+		})
+	}
+	for _, k := range data.APIKeys {
+		a.APIKeys = append(a.APIKeys, apiKey{
+			ID:         k.ID().String(),
+			Name:       k.Name(),
+			Prefix:     k.Prefix(),
+			Scope:      scope(k.Scope()),
+			ExpiresAt:  k.ExpiresAt(),
+			LastUsedAt: k.LastUsedAt(),
+			RevokedAt:  k.RevokedAt(),
+			CreatedAt:  k.CreatedAt(),
+		})
+	}
+	for _, e := range data.AuditEvents {
+		var actorID string
+		if e.ActorID() != uuid.Nil {
+			actorID = e.ActorID().String()
+		}
+		a.AuditEvents = append(a.AuditEvents, auditEvent{
+			ID:         e.ID().String(),
+			OccurredAt: e.OccurredAt(),
+			Action:     e.Action().String(),
+			ActorID:    actorID,
+			TargetType: e.TargetType().String(),
+			TargetID:   e.TargetID(),
+			PeerIP:     e.PeerIP(),
+			Outcome:    e.Outcome().String(),
+		})
+	}
+	slices.SortStableFunc(a.AuditEvents, func(x, y auditEvent) int {
+		return x.OccurredAt.Compare(y.OccurredAt)
+	})
+	return json.Marshal(a)
+}
+
+// consents по одному на клиента: объединение выданных scope, действует пока есть живой refresh токен
+func consents(tokens []*oauth.RefreshToken, at time.Time) []consent {
+	res := make([]consent, 0)
+	index := make(map[string]int)
+	for _, t := range tokens {
+		active := t.RevokedAt() == nil && at.Before(t.ExpiresAt())
+		i, ok := index[t.ClientID()]
+		if !ok {
+			index[t.ClientID()] = len(res)
+			res = append(res, consent{
+				ClientID:  t.ClientID(),
+				Scope:     scope(t.Scope()),
+				GrantedAt: t.CreatedAt(),
+				Active:    active,
+			})
+			continue
+		}
+		c := &res[i]
+		for _, s := range t.Scope() {
+			if !slices.Contains(c.Scope, s) {
+				c.Scope = append(c.Scope, s)
+			}
+		}
+		if t.CreatedAt().Before(c.GrantedAt) {
+			c.GrantedAt = t.CreatedAt()
+		}
+		c.Active = c.Active || active
+	}
+	return res
+}
+
+func scope(s oauth.Scope) []string {
+	if s == nil {
+		return []string{}
+	}
+	return slices.Clone(s)
+}
@@ -0,0 +1,73 @@
+package exports
+
+import (
+	"encoding/json"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBuildArchive(t *testing.T) {
+	email, _ := users.NewEmail("user@gmail.com")
+	password, _ := users.NewPassword([]byte("password-hash"))
+	u, err := users.CreateUser(uuid.New(), "user", email, password)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	revokedAt := now.Add(-time.Minute)
+	tokens := []*oauth.RefreshToken{
+		oauth.NewRefreshToken(uuid.New(), "token-hash-1", uuid.New(), "app", u.ID(), oauth.Scope{"openid"},
+			oauth.Authentication{Time: now}, now.Add(time.Hour), &revokedAt, now.Add(-2*time.Hour)),
+		oauth.NewRefreshToken(uuid.New(), "token-hash-2", uuid.New(), "app", u.ID(), oauth.Scope{"openid", "email"},
+			oauth.Authentication{Time: now}, now.Add(time.Hour), nil, now.Add(-time.Hour)),
+		oauth.NewRefreshToken(uuid.New(), "token-hash-3", uuid.New(), "cli", u.ID(), oauth.Scope{"profile"},
+			oauth.Authentication{Time: now}, now.Add(-time.Second), nil, now.Add(-3*time.Hour)),
+	}
+	key, _, err := apikeys.CreateAPIKey(u.ID(), "ci", oauth.Scope{"users:read"}, nil)
+	require.NoError(t, err)
+	older, err := audit.NewEvent(1, "", "", uuid.New(), now.Add(-time.Hour), audit.ActionLogin,
+		u.ID(), audit.TargetUser, u.ID().String(), "", "", audit.OutcomeSuccess, "")
+	require.NoError(t, err)
+	newer, err := audit.NewEvent(2, "", "", uuid.New(), now, audit.ActionUserSuspended,
+		uuid.New(), audit.TargetUser, u.ID().String(), "", "", audit.OutcomeSuccess, "")
+	require.NoError(t, err)
+
+	data := Data{
+		User:          u,
+		Identities:    []*identity.Identity{identity.NewIdentity(uuid.New(), u.ID(), "google", "sub-1", "user@gmail.com", now)},
+		RefreshTokens: tokens,
+		APIKeys:       []*apikeys.APIKey{key},
+		AuditEvents:   []*audit.Event{newer, older},
+	}
+	raw, err := BuildArchive(data, now)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(raw), "password-hash")
+	assert.NotContains(t, string(raw), "token-hash")
+	assert.NotContains(t, string(raw), key.KeyHash())
+
+	var got archive
+	require.NoError(t, json.Unmarshal(raw, &got))
+	assert.Equal(t, u.ID().String(), got.Profile.ID)
+	assert.Equal(t, "user@gmail.com", got.Profile.Email)
+	assert.Len(t, got.Identities, 1)
+	assert.Len(t, got.Sessions, 3)
+	assert.Equal(t, key.Prefix(), got.APIKeys[0].Prefix)
+	require.Len(t, got.AuditEvents, 2)
+	assert.Equal(t, older.ID().String(), got.AuditEvents[0].ID, "events are ordered by time")
+
+	require.Len(t, got.Consents, 2)
+	assert.Equal(t, "app", got.Consents[0].ClientID)
+	assert.ElementsMatch(t, []string{"openid", "email"}, got.Consents[0].Scope)
+	assert.True(t, got.Consents[0].Active)
+	assert.True(t, got.Consents[0].GrantedAt.Equal(tokens[0].CreatedAt()))
+	assert.Equal(t, "cli", got.Consents[1].ClientID)
+	assert.False(t, got.Consents[1].Active, "expired token does not keep consent active")
+}
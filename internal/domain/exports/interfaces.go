@@ -0,0 +1,17 @@
+package exports
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Repository interface {
+	Save(ctx context.Context, job *Job) error
+	Get(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) (*Job, error)
+	// ListPending блокирует задачи до конца транзакции и пропускает занятые другими экземплярами
+	ListPending(ctx context.Context, limit int) ([]*Job, error)
+	// DeleteExpired удаляет задачи, архивы которых истекли к моменту at
+	DeleteExpired(ctx context.Context, at time.Time) (int64, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,153 @@
+package exports
+
+import (
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("export job not found")
+	ErrNotPending = errors.New("export job is already finished")
+	ErrTTLInvalid = errors.New("export archive ttl must be positive")
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Job выгрузка данных пользователя, которая собирается в фоне. Готовый архив хранится до expiresAt
+type Job struct {
+	id          uuid.UUID
+	tenantID    uuid.UUID
+	userID      uuid.UUID
+	requestedBy uuid.UUID
+	status      Status
+	archive     []byte
+	failure     string
+	createdAt   time.Time
+	completedAt *time.Time
+	expiresAt   *time.Time
+}
+
+func NewJob(
+	id uuid.UUID,
+	tenantID uuid.UUID,
+	userID uuid.UUID,
+	requestedBy uuid.UUID,
+	status Status,
+	archive []byte,
+	failure string,
+	createdAt time.Time,
+	completedAt *time.Time,
+	expiresAt *time.Time,
+) (*Job, error) {
+	if err := status.validate(); err != nil {
+		return nil, err
+	}
+	return &Job{
+		id:          id,
+		tenantID:    tenantID,
+		userID:      userID,
+		requestedBy: requestedBy,
+		status:      status,
+		archive:     archive,
+		failure:     failure,
+		createdAt:   createdAt,
+		completedAt: completedAt,
+		expiresAt:   expiresAt,
+	}, nil
+}
+
+// CreateJob requestedBy сам пользователь или администратор организации
+func CreateJob(tenantID uuid.UUID, userID uuid.UUID, requestedBy uuid.UUID) (*Job, error) {
+	if tenantID == uuid.Nil {
+		return nil, users.ErrTenantRequired
+	}
+	return NewJob(uuid.New(), tenantID, userID, requestedBy, StatusPending, nil, "", time.Now().UTC(), nil, nil)
+}
+
+func (j *Job) ID() uuid.UUID {
+	return j.id
+}
+func (j *Job) TenantID() uuid.UUID {
+	return j.tenantID
+}
+func (j *Job) UserID() uuid.UUID {
+	return j.userID
+}
+func (j *Job) RequestedBy() uuid.UUID {
+	return j.requestedBy
+}
+func (j *Job) Status() Status {
+	return j.status
+}
+
+// Archive JSON архив, пустой пока задача не завершена
+func (j *Job) Archive() []byte {
+	return j.archive
+}
+func (j *Job) Failure() string {
+	return j.failure
+}
+func (j *Job) CreatedAt() time.Time {
+	return j.createdAt
+}
+func (j *Job) CompletedAt() *time.Time {
+	return j.completedAt
+}
+func (j *Job) ExpiresAt() *time.Time {
+	return j.expiresAt
+}
+
+// Complete архив содержит персональные данные и хранится только ttl
+func (j *Job) Complete(archive []byte, ttl time.Duration, at time.Time) error {
+	if j.status != StatusPending {
+		return ErrNotPending
+	}
+	if ttl <= 0 {
+		return ErrTTLInvalid
+	}
+	expiresAt := at.Add(ttl)
+	j.status = StatusCompleted
+	j.archive = archive
+	j.completedAt = &at
+	j.expiresAt = &expiresAt
+	return nil
+}
+
+func (j *Job) Fail(reason string, at time.Time) error {
+	if j.status != StatusPending {
+		return ErrNotPending
+	}
+	j.status = StatusFailed
+	j.failure = reason
+	j.completedAt = &at
+	return nil
+}
+
+func NewStatus(status string) (Status, error) {
+	s := Status(status)
+	if err := s.validate(); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+func (s Status) validate() error {
+	switch s {
+	case StatusPending, StatusCompleted, StatusFailed:
+		return nil
+	default:
+		return errors.New("export job status is not valid")
+	}
+}
+
+func (s Status) String() string {
+	return string(s)
+}
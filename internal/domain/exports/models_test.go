@@ -0,0 +1,57 @@
+package exports
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCreateJob(t *testing.T) {
+	_, err := CreateJob(uuid.Nil, uuid.New(), uuid.New())
+	assert.ErrorIs(t, err, users.ErrTenantRequired)
+
+	job, err := CreateJob(uuid.New(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, job.Status())
+	assert.Nil(t, job.Archive())
+	assert.Nil(t, job.ExpiresAt())
+}
+
+func TestJob_Complete(t *testing.T) {
+	job, err := CreateJob(uuid.New(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	assert.ErrorIs(t, job.Complete([]byte("{}"), 0, now), ErrTTLInvalid)
+
+	require.NoError(t, job.Complete([]byte("{}"), time.Hour, now))
+	assert.Equal(t, StatusCompleted, job.Status())
+	assert.Equal(t, []byte("{}"), job.Archive())
+	assert.Equal(t, now.Add(time.Hour), *job.ExpiresAt())
+	assert.ErrorIs(t, job.Complete([]byte("{}"), time.Hour, now), ErrNotPending)
+	assert.ErrorIs(t, job.Fail("boom", now), ErrNotPending)
+}
+
+func TestJob_Fail(t *testing.T) {
+	job, err := CreateJob(uuid.New(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	require.NoError(t, job.Fail("user not found", now))
+	assert.Equal(t, StatusFailed, job.Status())
+	assert.Equal(t, "user not found", job.Failure())
+	assert.Equal(t, now, *job.CompletedAt())
+	assert.Nil(t, job.ExpiresAt())
+}
+
+func TestNewStatus(t *testing.T) {
+	s, err := NewStatus("completed")
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, s)
+
+	_, err = NewStatus("running")
+	assert.Error(t, err)
+}
@@ -0,0 +1,242 @@
+package gateway
+
+import (
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrPathPrefixNotValid = errors.New("route path prefix is not valid")
+	ErrMethodNotValid     = errors.New("route method is not valid")
+	ErrHostNotValid       = errors.New("host is not valid")
+	ErrPathNotValid       = errors.New("request path is not valid")
+	ErrUnauthenticated    = errors.New("route requires authentication")
+	ErrForbidden          = errors.New("role is not allowed for route")
+)
+
+// Заголовки, которые прокси добавляет к пропущенному запросу. Пришедшие от клиента значения удаляются
+const (
+	HeaderUserID        = "x-user-id"
+	HeaderUserRole      = "x-user-role"
+	HeaderPrincipalType = "x-principal-type"
+)
+
+// Rule доступ к маршрутам за прокси. Пустой Methods подходит для любого метода,
+// пустой Roles пропускает любого аутентифицированного
+type Rule struct {
+	pathPrefix string
+	methods    []string
+	public     bool
+	roles      []users.Role
+}
+
+func NewRule(pathPrefix string, methods []string, public bool, roles []string) (Rule, error) {
+	if !strings.HasPrefix(pathPrefix, "/") {
+		return Rule{}, ErrPathPrefixNotValid
+	}
+	r := Rule{
+		pathPrefix: pathPrefix,
+		public:     public,
+	}
+	for _, m := range methods {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m == "" {
+			return Rule{}, ErrMethodNotValid
+		}
+		r.methods = append(r.methods, m)
+	}
+	for _, role := range roles {
+		rl, err := users.NewRole(role)
+		if err != nil {
+			return Rule{}, err
+		}
+		r.roles = append(r.roles, rl)
+	}
+	return r, nil
+}
+
+func (r Rule) PathPrefix() string {
+	return r.pathPrefix
+}
+func (r Rule) Methods() []string {
+	return r.methods
+}
+func (r Rule) IsPublic() bool {
+	return r.public
+}
+func (r Rule) Roles() []users.Role {
+	return r.roles
+}
+
+// Authorize authenticated false, если токена нет или он не прошел проверку
+func (r Rule) Authorize(authenticated bool, role users.Role) error {
+	if r.public {
+		return nil
+	}
+	if !authenticated {
+		return ErrUnauthenticated
+	}
+	if len(r.roles) > 0 && !slices.Contains(r.roles, role) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (r Rule) matches(method string, path string) bool {
+	if len(r.methods) > 0 && !slices.Contains(r.methods, method) {
+		return false
+	}
+	if !strings.HasPrefix(path, r.pathPrefix) {
+		return false
+	}
+	// /api не должен совпадать с /apiv2
+	return len(path) == len(r.pathPrefix) ||
+		strings.HasSuffix(r.pathPrefix, "/") ||
+		path[len(r.pathPrefix)] == '/'
+}
+
+// Rules выбирается правило с самым длинным совпавшим префиксом
+type Rules []Rule
+
+// Match маршрут без правила требует аутентификации с любой ролью.
+// ErrPathNotValid, если путь не декодируется, такой запрос не пропускается
+func (rs Rules) Match(method string, path string) (Rule, error) {
+	path, err := normalizePath(path)
+	if err != nil {
+		return Rule{}, err
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	method = strings.ToUpper(method)
+
+	best := -1
+	for i, r := range rs {
+		if !r.matches(method, path) {
+			continue
+		}
+		if best < 0 || len(r.pathPrefix) > len(rs[best].pathPrefix) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Rule{pathPrefix: "/"}, nil
+	}
+	return rs[best], nil
+}
+
+// normalizePath путь в том виде, в каком его разберет сервис за прокси: без query, с раскрытым
+// percent-encoding и без точечных сегментов, иначе /public/../admin совпал бы с правилом /public.
+// Завершающий слеш сохраняется для правил вида /docs/
+func normalizePath(raw string) (string, error) {
+	if i := strings.IndexAny(raw, "?#"); i >= 0 {
+		raw = raw[:i]
+	}
+	if raw == "" {
+		return "/", nil
+	}
+	decoded, err := url.PathUnescape(raw)
+	if err != nil || !strings.HasPrefix(decoded, "/") || strings.ContainsRune(decoded, 0) {
+		return "", ErrPathNotValid
+	}
+	cleaned := path.Clean(decoded)
+	if strings.HasSuffix(decoded, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned, nil
+}
+
+// Host правила одного сайта за прокси. Name точное имя или *.example.com для поддоменов
+type Host struct {
+	name          string
+	loginRedirect bool
+	rules         Rules
+}
+
+func NewHost(name string, loginRedirect bool, rules Rules) (Host, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	domain := strings.TrimPrefix(name, "*.")
+	if domain == "" || strings.ContainsAny(domain, "*/:") {
+		return Host{}, ErrHostNotValid
+	}
+	return Host{
+		name:          name,
+		loginRedirect: loginRedirect,
+		rules:         rules,
+	}, nil
+}
+
+func (h Host) Name() string {
+	return h.name
+}
+
+// LoginRedirect браузер без сессии перенаправляется на страницу входа вместо 401
+func (h Host) LoginRedirect() bool {
+	return h.loginRedirect
+}
+func (h Host) Rules() Rules {
+	return h.rules
+}
+
+func (h Host) matches(host string) bool {
+	if suffix, ok := strings.CutPrefix(h.name, "*"); ok {
+		return strings.HasSuffix(host, suffix)
+	}
+	return host == h.name
+}
+
+// Hosts точное имя важнее шаблона, из шаблонов выбирается самый длинный
+type Hosts struct {
+	hosts    []Host
+	fallback Rules
+}
+
+// NewHosts fallback правила для сайтов, которых нет в hosts
+func NewHosts(hosts []Host, fallback Rules) Hosts {
+	return Hosts{
+		hosts:    hosts,
+		fallback: fallback,
+	}
+}
+
+func (hs Hosts) Match(host string) Host {
+	if h, ok := hs.lookup(host); ok {
+		return h
+	}
+	return Host{rules: hs.fallback}
+}
+
+// Known сайт есть в конфиге, на него можно вернуть пользователя после входа
+func (hs Hosts) Known(host string) bool {
+	_, ok := hs.lookup(host)
+	return ok
+}
+
+func (hs Hosts) lookup(host string) (Host, bool) {
+	host = strings.ToLower(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	best := -1
+	for i, h := range hs.hosts {
+		if !h.matches(host) {
+			continue
+		}
+		if h.name == host {
+			return h, true
+		}
+		if best < 0 || len(h.name) > len(hs.hosts[best].name) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Host{}, false
+	}
+	return hs.hosts[best], true
+}
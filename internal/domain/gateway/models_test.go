@@ -0,0 +1,124 @@
+package gateway
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewRule(t *testing.T) {
+	cases := []struct {
+		name    string
+		prefix  string
+		methods []string
+		roles   []string
+		wantErr error
+	}{
+		{name: "ok", prefix: "/api", methods: []string{"get", "POST"}, roles: []string{"admin"}},
+		{name: "relative prefix", prefix: "api", wantErr: ErrPathPrefixNotValid},
+		{name: "empty method", prefix: "/api", methods: []string{" "}, wantErr: ErrMethodNotValid},
+		{name: "unknown role", prefix: "/api", roles: []string{"root"}, wantErr: users.ErrRoleNotValid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRule(tt.prefix, tt.methods, false, tt.roles)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"GET", "POST"}, r.Methods())
+		})
+	}
+}
+
+func TestRules_Match(t *testing.T) {
+	health, err := NewRule("/health", nil, true, nil)
+	require.NoError(t, err)
+	admin, err := NewRule("/api/admin", nil, false, []string{"admin"})
+	require.NoError(t, err)
+	api, err := NewRule("/api", []string{"GET"}, false, nil)
+	require.NoError(t, err)
+	rules := Rules{health, api, admin}
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		want   string
+	}{
+		{name: "exact", method: "GET", path: "/health", want: "/health"},
+		{name: "query", method: "GET", path: "/health?full=1", want: "/health"},
+		{name: "longest prefix", method: "GET", path: "/api/admin/users", want: "/api/admin"},
+		{name: "segment boundary", method: "GET", path: "/apiv2", want: "/"},
+		{name: "method mismatch", method: "POST", path: "/api/users", want: "/"},
+		{name: "lowercase method", method: "get", path: "/api/users", want: "/api"},
+		{name: "dot segments", method: "GET", path: "/health/../api/admin/users", want: "/api/admin"},
+		{name: "encoded dot segments", method: "GET", path: "/health/%2e%2e/api/admin", want: "/api/admin"},
+		{name: "encoded slash", method: "GET", path: "/health%2F..%2Fapi%2Fadmin", want: "/api/admin"},
+		{name: "double slash", method: "GET", path: "//api//admin", want: "/api/admin"},
+		{name: "traversal above root", method: "GET", path: "/../../health", want: "/health"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := rules.Match(tt.method, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule.PathPrefix())
+		})
+	}
+}
+
+func TestRules_Match_pathNotValid(t *testing.T) {
+	public, err := NewRule("/public", nil, true, nil)
+	require.NoError(t, err)
+	docs, err := NewRule("/docs/", nil, true, nil)
+	require.NoError(t, err)
+	rules := Rules{public, docs}
+
+	for _, path := range []string{"/public/%zz", "/public/%", "public/x", "/public/%00"} {
+		_, err = rules.Match("GET", path)
+		assert.ErrorIs(t, err, ErrPathNotValid, path)
+	}
+
+	rule, err := rules.Match("GET", "/docs/./")
+	require.NoError(t, err)
+	assert.Equal(t, "/docs/", rule.PathPrefix(), "trailing slash is kept")
+}
+
+func TestRule_Authorize(t *testing.T) {
+	public, err := NewRule("/health", nil, true, nil)
+	require.NoError(t, err)
+	admin, err := NewRule("/admin", nil, false, []string{"admin"})
+	require.NoError(t, err)
+	fallback, err := Rules{}.Match("GET", "/")
+	require.NoError(t, err)
+
+	assert.NoError(t, public.Authorize(false, ""))
+	assert.ErrorIs(t, fallback.Authorize(false, ""), ErrUnauthenticated)
+	assert.NoError(t, fallback.Authorize(true, users.RoleUser))
+	assert.ErrorIs(t, admin.Authorize(true, users.RoleUser), ErrForbidden)
+	assert.NoError(t, admin.Authorize(true, users.RoleAdmin))
+}
+
+func TestHosts_Match(t *testing.T) {
+	app, err := NewHost("app.example.com", true, nil)
+	require.NoError(t, err)
+	wildcard, err := NewHost("*.example.com", false, nil)
+	require.NoError(t, err)
+	hosts := NewHosts([]Host{wildcard, app}, nil)
+
+	assert.Equal(t, "app.example.com", hosts.Match("App.Example.com:8443").Name())
+	assert.Equal(t, "*.example.com", hosts.Match("docs.example.com").Name())
+	assert.Equal(t, "", hosts.Match("example.org").Name())
+	assert.True(t, hosts.Known("docs.example.com"))
+	assert.False(t, hosts.Known("example.com"))
+	assert.False(t, hosts.Known("evil.com"))
+
+	for _, name := range []string{"", "*", "*.", "a.*.com", "example.com/path"} {
+		_, err = NewHost(name, false, nil)
+		assert.ErrorIs(t, err, ErrHostNotValid, name)
+	}
+}
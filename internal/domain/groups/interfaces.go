@@ -0,0 +1,30 @@
+package groups
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// ParentLister группы, в которые член входит напрямую
+type ParentLister interface {
+	ListParents(ctx context.Context, tenantID uuid.UUID, member Member) ([]uuid.UUID, error)
+}
+
+// Repository все запросы ограничены организацией
+type Repository interface {
+	ParentLister
+	Save(ctx context.Context, group *Group) error
+	Get(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) (*Group, error)
+	GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*Group, error)
+	List(ctx context.Context, tenantID uuid.UUID, limit int, offset int) ([]*Group, error)
+	ListByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*Group, error)
+	// Delete удаляет группу вместе с ее участием в других группах
+	Delete(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) error
+	// LockMembership сериализует изменение вложенности групп организации до конца транзакции,
+	// иначе две параллельные транзакции добавят A в B и B в A, и каждая не увидит цикл
+	LockMembership(ctx context.Context, tenantID uuid.UUID) error
+	// AddMember повторное добавление не ошибка
+	AddMember(ctx context.Context, groupID uuid.UUID, member Member) error
+	RemoveMember(ctx context.Context, groupID uuid.UUID, member Member) error
+	ListMembers(ctx context.Context, groupID uuid.UUID, limit int, offset int) ([]Member, error)
+}
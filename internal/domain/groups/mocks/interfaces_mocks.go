@@ -0,0 +1,242 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./interfaces.go -destination=./mocks/interfaces_mocks.go
+//
+
+// Package mock_groups is a generated GoMock package.
+package mock_groups
+
+import (
+	context "context"
+	reflect "reflect"
+
+	groups "github.com/LeoUraltsev/auth-service/internal/domain/groups"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockParentLister is a mock of ParentLister interface.
+type MockParentLister struct {
+	ctrl     *gomock.Controller
+	recorder *MockParentListerMockRecorder
+	isgomock struct{}
+}
+
+// MockParentListerMockRecorder is the mock recorder for MockParentLister.
+type MockParentListerMockRecorder struct {
+	mock *MockParentLister
+}
+
+// NewMockParentLister creates a new mock instance.
+func NewMockParentLister(ctrl *gomock.Controller) *MockParentLister {
+	mock := &MockParentLister{ctrl: ctrl}
+	mock.recorder = &MockParentListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockParentLister) EXPECT() *MockParentListerMockRecorder {
+	return m.recorder
+}
+
+// ListParents mocks base method.
+func (m *MockParentLister) ListParents(ctx context.Context, tenantID uuid.UUID, member groups.Member) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListParents", ctx, tenantID, member)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListParents indicates an expected call of ListParents.
+func (mr *MockParentListerMockRecorder) ListParents(ctx, tenantID, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListParents", reflect.TypeOf((*MockParentLister)(nil).ListParents), ctx, tenantID, member)
+}
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddMember mocks base method.
+func (m *MockRepository) AddMember(ctx context.Context, groupID uuid.UUID, member groups.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMember", ctx, groupID, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMember indicates an expected call of AddMember.
+func (mr *MockRepositoryMockRecorder) AddMember(ctx, groupID, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMember", reflect.TypeOf((*MockRepository)(nil).AddMember), ctx, groupID, member)
+}
+
+// Delete mocks base method.
+func (m *MockRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, tenantID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRepositoryMockRecorder) Delete(ctx, tenantID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), ctx, tenantID, id)
+}
+
+// Get mocks base method.
+func (m *MockRepository) Get(ctx context.Context, tenantID, id uuid.UUID) (*groups.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, tenantID, id)
+	ret0, _ := ret[0].(*groups.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRepositoryMockRecorder) Get(ctx, tenantID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRepository)(nil).Get), ctx, tenantID, id)
+}
+
+// GetByName mocks base method.
+func (m *MockRepository) GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*groups.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByName", ctx, tenantID, name)
+	ret0, _ := ret[0].(*groups.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByName indicates an expected call of GetByName.
+func (mr *MockRepositoryMockRecorder) GetByName(ctx, tenantID, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByName", reflect.TypeOf((*MockRepository)(nil).GetByName), ctx, tenantID, name)
+}
+
+// List mocks base method.
+func (m *MockRepository) List(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*groups.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, tenantID, limit, offset)
+	ret0, _ := ret[0].([]*groups.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockRepositoryMockRecorder) List(ctx, tenantID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRepository)(nil).List), ctx, tenantID, limit, offset)
+}
+
+// ListByIDs mocks base method.
+func (m *MockRepository) ListByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*groups.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByIDs", ctx, tenantID, ids)
+	ret0, _ := ret[0].([]*groups.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByIDs indicates an expected call of ListByIDs.
+func (mr *MockRepositoryMockRecorder) ListByIDs(ctx, tenantID, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByIDs", reflect.TypeOf((*MockRepository)(nil).ListByIDs), ctx, tenantID, ids)
+}
+
+// ListMembers mocks base method.
+func (m *MockRepository) ListMembers(ctx context.Context, groupID uuid.UUID, limit, offset int) ([]groups.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMembers", ctx, groupID, limit, offset)
+	ret0, _ := ret[0].([]groups.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMembers indicates an expected call of ListMembers.
+func (mr *MockRepositoryMockRecorder) ListMembers(ctx, groupID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMembers", reflect.TypeOf((*MockRepository)(nil).ListMembers), ctx, groupID, limit, offset)
+}
+
+// ListParents mocks base method.
+func (m *MockRepository) ListParents(ctx context.Context, tenantID uuid.UUID, member groups.Member) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListParents", ctx, tenantID, member)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListParents indicates an expected call of ListParents.
+func (mr *MockRepositoryMockRecorder) ListParents(ctx, tenantID, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListParents", reflect.TypeOf((*MockRepository)(nil).ListParents), ctx, tenantID, member)
+}
+
+// LockMembership mocks base method.
+func (m *MockRepository) LockMembership(ctx context.Context, tenantID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockMembership", ctx, tenantID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockMembership indicates an expected call of LockMembership.
+func (mr *MockRepositoryMockRecorder) LockMembership(ctx, tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockMembership", reflect.TypeOf((*MockRepository)(nil).LockMembership), ctx, tenantID)
+}
+
+// RemoveMember mocks base method.
+func (m *MockRepository) RemoveMember(ctx context.Context, groupID uuid.UUID, member groups.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, groupID, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockRepositoryMockRecorder) RemoveMember(ctx, groupID, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockRepository)(nil).RemoveMember), ctx, groupID, member)
+}
+
+// Save mocks base method.
+func (m *MockRepository) Save(ctx context.Context, group *groups.Group) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockRepositoryMockRecorder) Save(ctx, group any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, group)
+}
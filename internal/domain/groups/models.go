@@ -0,0 +1,168 @@
+package groups
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrNotFound           = errors.New("group not found")
+	ErrNameRequired       = errors.New("group name is required")
+	ErrNameTooLong        = errors.New("group name is too long")
+	ErrNameAlreadyExists  = errors.New("group name already exists")
+	ErrMemberTypeNotValid = errors.New("group member type is not valid")
+	ErrMemberNotFound     = errors.New("group member not found")
+	ErrCycle              = errors.New("group membership would create a cycle")
+)
+
+// MaxNameLength имена групп попадают в токены, поэтому короткие
+const MaxNameLength = 64
+
+// Group группа пользователей организации, может входить в другие группы
+type Group struct {
+	id          uuid.UUID
+	tenantID    uuid.UUID
+	name        string
+	description string
+	createdAt   time.Time
+	updatedAt   time.Time
+}
+
+func NewGroup(id uuid.UUID, tenantID uuid.UUID, name string, description string, createdAt time.Time, updatedAt time.Time) *Group {
+	return &Group{
+		id:          id,
+		tenantID:    tenantID,
+		name:        name,
+		description: description,
+		createdAt:   createdAt,
+		updatedAt:   updatedAt,
+	}
+}
+
+func CreateGroup(tenantID uuid.UUID, name string, description string) (*Group, error) {
+	name, err := NewName(name)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	return NewGroup(uuid.New(), tenantID, name, strings.TrimSpace(description), now, now), nil
+}
+
+func NewName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrNameRequired
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return "", ErrNameTooLong
+	}
+	return name, nil
+}
+
+func (g *Group) ID() uuid.UUID {
+	return g.id
+}
+func (g *Group) TenantID() uuid.UUID {
+	return g.tenantID
+}
+func (g *Group) Name() string {
+	return g.name
+}
+func (g *Group) Description() string {
+	return g.description
+}
+func (g *Group) CreatedAt() time.Time {
+	return g.createdAt
+}
+func (g *Group) UpdatedAt() time.Time {
+	return g.updatedAt
+}
+
+// Update пустые значения не меняются
+func (g *Group) Update(name string, description string) error {
+	if strings.TrimSpace(name) != "" {
+		n, err := NewName(name)
+		if err != nil {
+			return err
+		}
+		g.name = n
+	}
+	if strings.TrimSpace(description) != "" {
+		g.description = strings.TrimSpace(description)
+	}
+	g.updatedAt = time.Now().UTC()
+	return nil
+}
+
+type MemberType string
+
+const (
+	MemberUser  MemberType = "user"
+	MemberGroup MemberType = "group"
+)
+
+func NewMemberType(memberType string) (MemberType, error) {
+	t := MemberType(memberType)
+	switch t {
+	case MemberUser, MemberGroup:
+		return t, nil
+	default:
+		return "", ErrMemberTypeNotValid
+	}
+}
+
+func (t MemberType) String() string {
+	return string(t)
+}
+
+// Member пользователь или вложенная группа
+type Member struct {
+	Type MemberType
+	ID   uuid.UUID
+}
+
+// Ancestors все группы, в которые член входит напрямую или через вложенные группы.
+// Каждая группа обходится один раз, поэтому циклы в данных не зацикливают обход
+func Ancestors(ctx context.Context, r ParentLister, tenantID uuid.UUID, member Member) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool)
+	res := make([]uuid.UUID, 0)
+	queue := []Member{member}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		parents, err := r.ListParents(ctx, tenantID, current)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range parents {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			res = append(res, id)
+			queue = append(queue, Member{Type: MemberGroup, ID: id})
+		}
+	}
+	return res, nil
+}
+
+// CheckCycle группа child не может войти в groupID, если groupID уже входит в child
+func CheckCycle(ctx context.Context, r ParentLister, tenantID uuid.UUID, groupID uuid.UUID, child uuid.UUID) error {
+	if groupID == child {
+		return ErrCycle
+	}
+	ancestors, err := Ancestors(ctx, r, tenantID, Member{Type: MemberGroup, ID: groupID})
+	if err != nil {
+		return err
+	}
+	for _, id := range ancestors {
+		if id == child {
+			return ErrCycle
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package groups
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+type memoryParents map[Member][]uuid.UUID
+
+func (m memoryParents) ListParents(_ context.Context, _ uuid.UUID, member Member) ([]uuid.UUID, error) {
+	return m[member], nil
+}
+
+func TestCreateGroup(t *testing.T) {
+	cases := []struct {
+		name    string
+		group   string
+		wantErr error
+	}{
+		{name: "ok", group: " developers "},
+		{name: "empty", group: "  ", wantErr: ErrNameRequired},
+		{name: "too long", group: strings.Repeat("a", MaxNameLength+1), wantErr: ErrNameTooLong},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			group, err := CreateGroup(uuid.New(), tt.group, "")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, strings.TrimSpace(tt.group), group.Name())
+		})
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	user := Member{Type: MemberUser, ID: uuid.New()}
+	dev, backend, engineering, all := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	parents := memoryParents{
+		user:                                 {backend},
+		{Type: MemberGroup, ID: backend}:     {dev},
+		{Type: MemberGroup, ID: dev}:         {engineering, all},
+		{Type: MemberGroup, ID: engineering}: {all},
+		// цикл в данных не зацикливает обход
+		{Type: MemberGroup, ID: all}: {dev},
+	}
+
+	ids, err := Ancestors(context.Background(), parents, uuid.New(), user)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{backend, dev, engineering, all}, ids)
+}
+
+func TestCheckCycle(t *testing.T) {
+	dev, backend, ops := uuid.New(), uuid.New(), uuid.New()
+	// backend входит в dev
+	parents := memoryParents{
+		{Type: MemberGroup, ID: backend}: {dev},
+	}
+
+	assert.ErrorIs(t, CheckCycle(context.Background(), parents, uuid.New(), dev, dev), ErrCycle)
+	assert.ErrorIs(t, CheckCycle(context.Background(), parents, uuid.New(), backend, dev), ErrCycle)
+	assert.NoError(t, CheckCycle(context.Background(), parents, uuid.New(), dev, ops))
+	assert.NoError(t, CheckCycle(context.Background(), parents, uuid.New(), ops, backend))
+}
@@ -0,0 +1,25 @@
+package identity
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Save(ctx context.Context, identity *Identity) error
+	GetBySubject(ctx context.Context, provider string, subject string) (*Identity, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*Identity, error)
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+	SaveLogin(ctx context.Context, login *Login) error
+	// TakeLogin возвращает и удаляет вход, state используется один раз
+	TakeLogin(ctx context.Context, stateHash string) (*Login, error)
+}
+
+// Provider внешний OIDC провайдер
+type Provider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error)
+	// Exchange обменивает код на ID токен, проверяет его и возвращает сопоставленные claims
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (Claims, error)
+}
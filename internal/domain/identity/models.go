@@ -0,0 +1,179 @@
+package identity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+var (
+	ErrIdentityNotFound  = errors.New("identity not found")
+	ErrProviderNotFound  = errors.New("identity provider not found")
+	ErrSubjectRequired   = errors.New("identity subject is required")
+	ErrEmailNotVerified  = errors.New("identity provider email is not verified")
+	ErrLoginNotFound     = errors.New("federated login not found")
+	ErrLoginExpired      = errors.New("federated login expired")
+	ErrIDTokenNotValid   = errors.New("id token is not valid")
+	ErrProviderRejected  = errors.New("identity provider rejected login")
+	ErrProviderNotUsable = errors.New("identity provider is not available")
+)
+
+// ACRFederated вход через внешнего провайдера
+const ACRFederated = "fed"
+
+// Claims данные пользователя из ID токена провайдера после сопоставления claims
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Identity учетная запись у внешнего провайдера, привязанная к пользователю
+type Identity struct {
+	id        uuid.UUID
+	userID    uuid.UUID
+	provider  string
+	subject   string
+	email     string
+	createdAt time.Time
+}
+
+func NewIdentity(id uuid.UUID, userID uuid.UUID, provider string, subject string, email string, createdAt time.Time) *Identity {
+	return &Identity{
+		id:        id,
+		userID:    userID,
+		provider:  provider,
+		subject:   subject,
+		email:     email,
+		createdAt: createdAt,
+	}
+}
+
+func CreateIdentity(userID uuid.UUID, provider string, claims Claims) (*Identity, error) {
+	if strings.TrimSpace(claims.Subject) == "" {
+		return nil, ErrSubjectRequired
+	}
+	return NewIdentity(uuid.New(), userID, provider, claims.Subject, claims.Email, time.Now().UTC()), nil
+}
+
+func (i *Identity) ID() uuid.UUID {
+	return i.id
+}
+func (i *Identity) UserID() uuid.UUID {
+	return i.userID
+}
+func (i *Identity) Provider() string {
+	return i.provider
+}
+func (i *Identity) Subject() string {
+	return i.subject
+}
+func (i *Identity) Email() string {
+	return i.email
+}
+func (i *Identity) CreatedAt() time.Time {
+	return i.createdAt
+}
+
+// Login незавершенный вход через провайдера: живет от перехода к провайдеру до callback
+// и хранит исходный запрос /authorize, по которому после входа выдается код
+type Login struct {
+	stateHash    string
+	provider     string
+	nonce        string
+	codeVerifier string
+	request      oauth.AuthorizationRequest
+	expiresAt    time.Time
+	createdAt    time.Time
+}
+
+func NewLogin(
+	stateHash string,
+	provider string,
+	nonce string,
+	codeVerifier string,
+	request oauth.AuthorizationRequest,
+	expiresAt time.Time,
+	createdAt time.Time,
+) *Login {
+	return &Login{
+		stateHash:    stateHash,
+		provider:     provider,
+		nonce:        nonce,
+		codeVerifier: codeVerifier,
+		request:      request,
+		expiresAt:    expiresAt,
+		createdAt:    createdAt,
+	}
+}
+
+// CreateLogin возвращает вход и state для провайдера, в базе хранится только hash state
+func CreateLogin(provider string, request oauth.AuthorizationRequest, ttl time.Duration) (*Login, string, error) {
+	state, err := randomString()
+	if err != nil {
+		return nil, "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return nil, "", err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now().UTC()
+	return NewLogin(oauth.HashToken(state), provider, nonce, verifier, request, now.Add(ttl), now), state, nil
+}
+
+func (l *Login) StateHash() string {
+	return l.stateHash
+}
+func (l *Login) Provider() string {
+	return l.provider
+}
+func (l *Login) Nonce() string {
+	return l.nonce
+}
+func (l *Login) CodeVerifier() string {
+	return l.codeVerifier
+}
+func (l *Login) Request() oauth.AuthorizationRequest {
+	return l.request
+}
+func (l *Login) ExpiresAt() time.Time {
+	return l.expiresAt
+}
+func (l *Login) CreatedAt() time.Time {
+	return l.createdAt
+}
+
+// CodeChallenge PKCE S256 для запроса к провайдеру
+func (l *Login) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(l.codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Check callback должен прийти от того же провайдера, к которому ушел пользователь
+func (l *Login) Check(provider string, at time.Time) error {
+	if l.provider != provider {
+		return ErrLoginNotFound
+	}
+	if at.After(l.expiresAt) {
+		return ErrLoginExpired
+	}
+	return nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,17 @@
+package invitations
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Repository interface {
+	Save(ctx context.Context, invitation *Invitation) error
+	Get(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) (*Invitation, error)
+	GetByCodeHash(ctx context.Context, codeHash string) (*Invitation, error)
+	// ListPending приглашения организации, которые еще можно принять на момент at
+	ListPending(ctx context.Context, tenantID uuid.UUID, at time.Time, limit int, offset int) ([]*Invitation, error)
+	// ScrubAccepted стирает email в приглашениях, которые принял пользователь
+	ScrubAccepted(ctx context.Context, userID uuid.UUID) error
+}
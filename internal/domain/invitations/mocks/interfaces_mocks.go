@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./interfaces.go -destination=./mocks/interfaces_mocks.go
+//
+
+// Package mock_invitations is a generated GoMock package.
+package mock_invitations
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	invitations "github.com/LeoUraltsev/auth-service/internal/domain/invitations"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockRepository) Get(ctx context.Context, tenantID, id uuid.UUID) (*invitations.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, tenantID, id)
+	ret0, _ := ret[0].(*invitations.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRepositoryMockRecorder) Get(ctx, tenantID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRepository)(nil).Get), ctx, tenantID, id)
+}
+
+// GetByCodeHash mocks base method.
+func (m *MockRepository) GetByCodeHash(ctx context.Context, codeHash string) (*invitations.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCodeHash", ctx, codeHash)
+	ret0, _ := ret[0].(*invitations.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCodeHash indicates an expected call of GetByCodeHash.
+func (mr *MockRepositoryMockRecorder) GetByCodeHash(ctx, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCodeHash", reflect.TypeOf((*MockRepository)(nil).GetByCodeHash), ctx, codeHash)
+}
+
+// ListPending mocks base method.
+func (m *MockRepository) ListPending(ctx context.Context, tenantID uuid.UUID, at time.Time, limit, offset int) ([]*invitations.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPending", ctx, tenantID, at, limit, offset)
+	ret0, _ := ret[0].([]*invitations.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPending indicates an expected call of ListPending.
+func (mr *MockRepositoryMockRecorder) ListPending(ctx, tenantID, at, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPending", reflect.TypeOf((*MockRepository)(nil).ListPending), ctx, tenantID, at, limit, offset)
+}
+
+// Save mocks base method.
+func (m *MockRepository) Save(ctx context.Context, invitation *invitations.Invitation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, invitation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockRepositoryMockRecorder) Save(ctx, invitation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, invitation)
+}
+
+// ScrubAccepted mocks base method.
+func (m *MockRepository) ScrubAccepted(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScrubAccepted", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScrubAccepted indicates an expected call of ScrubAccepted.
+func (mr *MockRepositoryMockRecorder) ScrubAccepted(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScrubAccepted", reflect.TypeOf((*MockRepository)(nil).ScrubAccepted), ctx, userID)
+}
@@ -0,0 +1,180 @@
+package invitations
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("invitation not found")
+	ErrCodeNotValid = errors.New("invitation code is not valid")
+	ErrExpired      = errors.New("invitation is expired")
+	ErrNotPending   = errors.New("invitation is already accepted or revoked")
+	ErrTTLInvalid   = errors.New("invitation ttl must be positive")
+)
+
+// CodePrefix по нему код приглашения отличается от других секретов
+const CodePrefix = "inv_"
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusAccepted Status = "accepted"
+	StatusRevoked  Status = "revoked"
+	StatusExpired  Status = "expired"
+)
+
+// Invitation приглашение в организацию. Код одноразовый, хранится только его hash
+type Invitation struct {
+	id         uuid.UUID
+	tenantID   uuid.UUID
+	email      users.Email
+	role       users.Role
+	codeHash   string
+	invitedBy  uuid.UUID
+	expiresAt  time.Time
+	acceptedAt *time.Time
+	acceptedBy uuid.UUID
+	revokedAt  *time.Time
+	createdAt  time.Time
+}
+
+func NewInvitation(
+	id uuid.UUID,
+	tenantID uuid.UUID,
+	email users.Email,
+	role users.Role,
+	codeHash string,
+	invitedBy uuid.UUID,
+	expiresAt time.Time,
+	acceptedAt *time.Time,
+	acceptedBy uuid.UUID,
+	revokedAt *time.Time,
+	createdAt time.Time,
+) *Invitation {
+	return &Invitation{
+		id:         id,
+		tenantID:   tenantID,
+		email:      email,
+		role:       role,
+		codeHash:   codeHash,
+		invitedBy:  invitedBy,
+		expiresAt:  expiresAt,
+		acceptedAt: acceptedAt,
+		acceptedBy: acceptedBy,
+		revokedAt:  revokedAt,
+		createdAt:  createdAt,
+	}
+}
+
+// CreateInvitation возвращает приглашение и код, код показывается один раз
+func CreateInvitation(tenantID uuid.UUID, email users.Email, role users.Role, invitedBy uuid.UUID, ttl time.Duration) (*Invitation, string, error) {
+	if tenantID == uuid.Nil {
+		return nil, "", users.ErrTenantRequired
+	}
+	if _, err := users.NewRole(role.String()); err != nil {
+		return nil, "", err
+	}
+	if ttl <= 0 {
+		return nil, "", ErrTTLInvalid
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	code := CodePrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now().UTC()
+	i := NewInvitation(uuid.New(), tenantID, email, role, HashCode(code), invitedBy, now.Add(ttl), nil, uuid.Nil, nil, now)
+	return i, code, nil
+}
+
+// HashCode по hash кода ищется приглашение
+func HashCode(code string) string {
+	return oauth.HashToken(code)
+}
+
+// ValidCode код похож на код приглашения, проверяется до обращения к базе
+func ValidCode(code string) error {
+	if !strings.HasPrefix(code, CodePrefix) || len(code) == len(CodePrefix) {
+		return ErrCodeNotValid
+	}
+	return nil
+}
+
+func (i *Invitation) ID() uuid.UUID {
+	return i.id
+}
+func (i *Invitation) TenantID() uuid.UUID {
+	return i.tenantID
+}
+func (i *Invitation) Email() users.Email {
+	return i.email
+}
+func (i *Invitation) Role() users.Role {
+	return i.role
+}
+func (i *Invitation) CodeHash() string {
+	return i.codeHash
+}
+func (i *Invitation) InvitedBy() uuid.UUID {
+	return i.invitedBy
+}
+func (i *Invitation) ExpiresAt() time.Time {
+	return i.expiresAt
+}
+func (i *Invitation) AcceptedAt() *time.Time {
+	return i.acceptedAt
+}
+func (i *Invitation) AcceptedBy() uuid.UUID {
+	return i.acceptedBy
+}
+func (i *Invitation) RevokedAt() *time.Time {
+	return i.revokedAt
+}
+func (i *Invitation) CreatedAt() time.Time {
+	return i.createdAt
+}
+
+func (i *Invitation) Status(at time.Time) Status {
+	switch {
+	case i.acceptedAt != nil:
+		return StatusAccepted
+	case i.revokedAt != nil:
+		return StatusRevoked
+	case !at.Before(i.expiresAt):
+		return StatusExpired
+	default:
+		return StatusPending
+	}
+}
+
+// Accept отмечает приглашение принятым пользователем userID, второй раз код не сработает
+func (i *Invitation) Accept(userID uuid.UUID, at time.Time) error {
+	switch i.Status(at) {
+	case StatusPending:
+	case StatusExpired:
+		return ErrExpired
+	default:
+		return ErrNotPending
+	}
+	i.acceptedAt = &at
+	i.acceptedBy = userID
+	return nil
+}
+
+func (i *Invitation) Revoke(at time.Time) error {
+	if i.acceptedAt != nil || i.revokedAt != nil {
+		return ErrNotPending
+	}
+	i.revokedAt = &at
+	return nil
+}
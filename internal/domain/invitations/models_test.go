@@ -0,0 +1,78 @@
+package invitations
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateInvitation(t *testing.T) {
+	email, _ := users.NewEmail("new@example.com")
+	tenantID := uuid.New()
+
+	cases := []struct {
+		name     string
+		tenantID uuid.UUID
+		role     users.Role
+		ttl      time.Duration
+		wantErr  error
+	}{
+		{name: "ok", tenantID: tenantID, role: users.RoleAdmin, ttl: time.Hour},
+		{name: "without tenant", role: users.RoleUser, ttl: time.Hour, wantErr: users.ErrTenantRequired},
+		{name: "unknown role", tenantID: tenantID, role: "root", ttl: time.Hour, wantErr: users.ErrRoleNotValid},
+		{name: "zero ttl", tenantID: tenantID, role: users.RoleUser, wantErr: ErrTTLInvalid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			invitation, code, err := CreateInvitation(tt.tenantID, email, tt.role, uuid.New(), tt.ttl)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(code, CodePrefix))
+			assert.NoError(t, ValidCode(code))
+			assert.Equal(t, HashCode(code), invitation.CodeHash())
+			assert.Equal(t, StatusPending, invitation.Status(time.Now().UTC()))
+		})
+	}
+}
+
+func TestInvitation_Accept(t *testing.T) {
+	email, _ := users.NewEmail("new@example.com")
+	invitation, _, err := CreateInvitation(uuid.New(), email, users.RoleUser, uuid.New(), time.Hour)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	assert.ErrorIs(t, invitation.Accept(uuid.New(), now.Add(2*time.Hour)), ErrExpired)
+
+	userID := uuid.New()
+	require.NoError(t, invitation.Accept(userID, now))
+	assert.Equal(t, StatusAccepted, invitation.Status(now))
+	assert.Equal(t, userID, invitation.AcceptedBy())
+	assert.ErrorIs(t, invitation.Accept(uuid.New(), now), ErrNotPending)
+	assert.ErrorIs(t, invitation.Revoke(now), ErrNotPending)
+}
+
+func TestInvitation_Revoke(t *testing.T) {
+	email, _ := users.NewEmail("new@example.com")
+	invitation, _, err := CreateInvitation(uuid.New(), email, users.RoleUser, uuid.New(), time.Hour)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	require.NoError(t, invitation.Revoke(now))
+	assert.Equal(t, StatusRevoked, invitation.Status(now))
+	assert.ErrorIs(t, invitation.Accept(uuid.New(), now), ErrNotPending)
+}
+
+func TestValidCode(t *testing.T) {
+	assert.ErrorIs(t, ValidCode(""), ErrCodeNotValid)
+	assert.ErrorIs(t, ValidCode(CodePrefix), ErrCodeNotValid)
+	assert.ErrorIs(t, ValidCode("ak_abc"), ErrCodeNotValid)
+	assert.NoError(t, ValidCode(CodePrefix+"abc"))
+}
@@ -0,0 +1,8 @@
+package mail
+
+import "context"
+
+// Sender доставляет письма, реализация выбирается конфигом
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
@@ -0,0 +1,10 @@
+package mail
+
+import "github.com/LeoUraltsev/auth-service/internal/domain/users"
+
+// Message письмо пользователю, тело - простой текст
+type Message struct {
+	To      users.Email
+	Subject string
+	Body    string
+}
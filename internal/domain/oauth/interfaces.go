@@ -0,0 +1,59 @@
+package oauth
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Repository interface {
+	SaveClient(ctx context.Context, client *Client) error
+	GetClient(ctx context.Context, clientID string) (*Client, error)
+	ListClients(ctx context.Context, limit int, offset int) ([]*Client, error)
+	// DeleteClient удаляет клиента вместе с его кодами и refresh токенами
+	DeleteClient(ctx context.Context, clientID string) error
+
+	SaveCode(ctx context.Context, code *AuthorizationCode) error
+	// GetCode блокирует строку до конца транзакции, чтобы код нельзя было обменять дважды
+	GetCode(ctx context.Context, codeHash string) (*AuthorizationCode, error)
+
+	SaveRefreshToken(ctx context.Context, token *RefreshToken) error
+	// GetRefreshToken блокирует строку до конца транзакции
+	GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// ListUserRefreshTokens все refresh токены пользователя, включая отозванные
+	ListUserRefreshTokens(ctx context.Context, userID uuid.UUID) ([]*RefreshToken, error)
+	RevokeRefreshTokens(ctx context.Context, familyID uuid.UUID, at time.Time) error
+	// RevokeUserRefreshTokens отзывает refresh токены всех входов пользователя
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, at time.Time) error
+	// DeleteUserTokens удаляет коды и refresh токены пользователя
+	DeleteUserTokens(ctx context.Context, userID uuid.UUID) error
+	// IsFamilyRevoked true, если у семейства есть refresh токены и все они отозваны
+	IsFamilyRevoked(ctx context.Context, familyID uuid.UUID) (bool, error)
+}
+
+// AccessGrant на кого и кому выдается токен доступа. Для client_credentials UserID пустой
+type AccessGrant struct {
+	UserID uuid.UUID
+	// TenantID организация пользователя, у токенов клиентов и сервисных аккаунтов пустой
+	TenantID uuid.UUID
+	Role     users.Role
+	ClientID string
+	Scope    Scope
+	// Principal тип владельца токена, пустой считается пользователем
+	Principal users.PrincipalType
+	// SessionID семейство refresh токенов входа, по нему проверяется отзыв
+	SessionID uuid.UUID
+	// Groups имена групп пользователя, nil - без claim групп
+	Groups []string
+}
+
+type TokenIssuer interface {
+	IssueAccessToken(grant AccessGrant) (string, time.Duration, error)
+	IssueIDToken(token IDToken) (string, error)
+}
+
+// TokenVerifier проверяет подпись и срок действия токена доступа
+type TokenVerifier interface {
+	VerifyAccessToken(token string) (AccessToken, error)
+}
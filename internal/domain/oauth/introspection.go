@@ -0,0 +1,61 @@
+package oauth
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TokenTypeBearer = "Bearer"
+	TokenTypeAPIKey = "ApiKey"
+)
+
+// AccessToken содержимое токена доступа после проверки подписи и срока действия
+type AccessToken struct {
+	ID        string
+	Subject   string
+	UserID    uuid.UUID
+	Role      users.Role
+	ClientID  string
+	Scope     Scope
+	Principal users.PrincipalType
+	// SessionID uuid.Nil у токенов без refresh токенов: Login, client_credentials
+	SessionID uuid.UUID
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Introspection ответ на вопрос, действует ли токен и чей он (RFC 7662).
+// У недействующего токена заполнено только Active
+type Introspection struct {
+	Active    bool
+	TokenID   string
+	TokenType string
+	Subject   string
+	ClientID  string
+	Scope     Scope
+	Principal users.PrincipalType
+	SessionID string
+	IssuedAt  time.Time
+	// ExpiresAt нулевой у бессрочных API ключей
+	ExpiresAt time.Time
+}
+
+func NewIntrospection(token AccessToken) Introspection {
+	res := Introspection{
+		Active:    true,
+		TokenID:   token.ID,
+		TokenType: TokenTypeBearer,
+		Subject:   token.Subject,
+		ClientID:  token.ClientID,
+		Scope:     token.Scope,
+		Principal: token.Principal,
+		IssuedAt:  token.IssuedAt,
+		ExpiresAt: token.ExpiresAt,
+	}
+	if token.SessionID != uuid.Nil {
+		res.SessionID = token.SessionID.String()
+	}
+	return res
+}
@@ -0,0 +1,326 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./interfaces.go -destination=./mocks/interfaces_mocks.go
+//
+
+// Package mock_oauth is a generated GoMock package.
+package mock_oauth
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	oauth "github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteClient mocks base method.
+func (m *MockRepository) DeleteClient(ctx context.Context, clientID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteClient", ctx, clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteClient indicates an expected call of DeleteClient.
+func (mr *MockRepositoryMockRecorder) DeleteClient(ctx, clientID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteClient", reflect.TypeOf((*MockRepository)(nil).DeleteClient), ctx, clientID)
+}
+
+// DeleteUserTokens mocks base method.
+func (m *MockRepository) DeleteUserTokens(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserTokens", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserTokens indicates an expected call of DeleteUserTokens.
+func (mr *MockRepositoryMockRecorder) DeleteUserTokens(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserTokens", reflect.TypeOf((*MockRepository)(nil).DeleteUserTokens), ctx, userID)
+}
+
+// GetClient mocks base method.
+func (m *MockRepository) GetClient(ctx context.Context, clientID string) (*oauth.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClient", ctx, clientID)
+	ret0, _ := ret[0].(*oauth.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClient indicates an expected call of GetClient.
+func (mr *MockRepositoryMockRecorder) GetClient(ctx, clientID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClient", reflect.TypeOf((*MockRepository)(nil).GetClient), ctx, clientID)
+}
+
+// GetCode mocks base method.
+func (m *MockRepository) GetCode(ctx context.Context, codeHash string) (*oauth.AuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCode", ctx, codeHash)
+	ret0, _ := ret[0].(*oauth.AuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCode indicates an expected call of GetCode.
+func (mr *MockRepositoryMockRecorder) GetCode(ctx, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCode", reflect.TypeOf((*MockRepository)(nil).GetCode), ctx, codeHash)
+}
+
+// GetRefreshToken mocks base method.
+func (m *MockRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*oauth.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshToken", ctx, tokenHash)
+	ret0, _ := ret[0].(*oauth.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshToken indicates an expected call of GetRefreshToken.
+func (mr *MockRepositoryMockRecorder) GetRefreshToken(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshToken", reflect.TypeOf((*MockRepository)(nil).GetRefreshToken), ctx, tokenHash)
+}
+
+// IsFamilyRevoked mocks base method.
+func (m *MockRepository) IsFamilyRevoked(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsFamilyRevoked", ctx, familyID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsFamilyRevoked indicates an expected call of IsFamilyRevoked.
+func (mr *MockRepositoryMockRecorder) IsFamilyRevoked(ctx, familyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsFamilyRevoked", reflect.TypeOf((*MockRepository)(nil).IsFamilyRevoked), ctx, familyID)
+}
+
+// ListClients mocks base method.
+func (m *MockRepository) ListClients(ctx context.Context, limit, offset int) ([]*oauth.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClients", ctx, limit, offset)
+	ret0, _ := ret[0].([]*oauth.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClients indicates an expected call of ListClients.
+func (mr *MockRepositoryMockRecorder) ListClients(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClients", reflect.TypeOf((*MockRepository)(nil).ListClients), ctx, limit, offset)
+}
+
+// ListUserRefreshTokens mocks base method.
+func (m *MockRepository) ListUserRefreshTokens(ctx context.Context, userID uuid.UUID) ([]*oauth.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserRefreshTokens", ctx, userID)
+	ret0, _ := ret[0].([]*oauth.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserRefreshTokens indicates an expected call of ListUserRefreshTokens.
+func (mr *MockRepositoryMockRecorder) ListUserRefreshTokens(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserRefreshTokens", reflect.TypeOf((*MockRepository)(nil).ListUserRefreshTokens), ctx, userID)
+}
+
+// RevokeRefreshTokens mocks base method.
+func (m *MockRepository) RevokeRefreshTokens(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokens", ctx, familyID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokens indicates an expected call of RevokeRefreshTokens.
+func (mr *MockRepositoryMockRecorder) RevokeRefreshTokens(ctx, familyID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokens", reflect.TypeOf((*MockRepository)(nil).RevokeRefreshTokens), ctx, familyID, at)
+}
+
+// RevokeUserRefreshTokens mocks base method.
+func (m *MockRepository) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserRefreshTokens", ctx, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserRefreshTokens indicates an expected call of RevokeUserRefreshTokens.
+func (mr *MockRepositoryMockRecorder) RevokeUserRefreshTokens(ctx, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserRefreshTokens", reflect.TypeOf((*MockRepository)(nil).RevokeUserRefreshTokens), ctx, userID, at)
+}
+
+// SaveClient mocks base method.
+func (m *MockRepository) SaveClient(ctx context.Context, client *oauth.Client) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveClient", ctx, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveClient indicates an expected call of SaveClient.
+func (mr *MockRepositoryMockRecorder) SaveClient(ctx, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveClient", reflect.TypeOf((*MockRepository)(nil).SaveClient), ctx, client)
+}
+
+// SaveCode mocks base method.
+func (m *MockRepository) SaveCode(ctx context.Context, code *oauth.AuthorizationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCode indicates an expected call of SaveCode.
+func (mr *MockRepositoryMockRecorder) SaveCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCode", reflect.TypeOf((*MockRepository)(nil).SaveCode), ctx, code)
+}
+
+// SaveRefreshToken mocks base method.
+func (m *MockRepository) SaveRefreshToken(ctx context.Context, token *oauth.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRefreshToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRefreshToken indicates an expected call of SaveRefreshToken.
+func (mr *MockRepositoryMockRecorder) SaveRefreshToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRefreshToken", reflect.TypeOf((*MockRepository)(nil).SaveRefreshToken), ctx, token)
+}
+
+// MockTokenIssuer is a mock of TokenIssuer interface.
+type MockTokenIssuer struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenIssuerMockRecorder
+	isgomock struct{}
+}
+
+// MockTokenIssuerMockRecorder is the mock recorder for MockTokenIssuer.
+type MockTokenIssuerMockRecorder struct {
+	mock *MockTokenIssuer
+}
+
+// NewMockTokenIssuer creates a new mock instance.
+func NewMockTokenIssuer(ctrl *gomock.Controller) *MockTokenIssuer {
+	mock := &MockTokenIssuer{ctrl: ctrl}
+	mock.recorder = &MockTokenIssuerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenIssuer) EXPECT() *MockTokenIssuerMockRecorder {
+	return m.recorder
+}
+
+// IssueAccessToken mocks base method.
+func (m *MockTokenIssuer) IssueAccessToken(grant oauth.AccessGrant) (string, time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueAccessToken", grant)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(time.Duration)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// IssueAccessToken indicates an expected call of IssueAccessToken.
+func (mr *MockTokenIssuerMockRecorder) IssueAccessToken(grant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueAccessToken", reflect.TypeOf((*MockTokenIssuer)(nil).IssueAccessToken), grant)
+}
+
+// IssueIDToken mocks base method.
+func (m *MockTokenIssuer) IssueIDToken(token oauth.IDToken) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueIDToken", token)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueIDToken indicates an expected call of IssueIDToken.
+func (mr *MockTokenIssuerMockRecorder) IssueIDToken(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueIDToken", reflect.TypeOf((*MockTokenIssuer)(nil).IssueIDToken), token)
+}
+
+// MockTokenVerifier is a mock of TokenVerifier interface.
+type MockTokenVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenVerifierMockRecorder
+	isgomock struct{}
+}
+
+// MockTokenVerifierMockRecorder is the mock recorder for MockTokenVerifier.
+type MockTokenVerifierMockRecorder struct {
+	mock *MockTokenVerifier
+}
+
+// NewMockTokenVerifier creates a new mock instance.
+func NewMockTokenVerifier(ctrl *gomock.Controller) *MockTokenVerifier {
+	mock := &MockTokenVerifier{ctrl: ctrl}
+	mock.recorder = &MockTokenVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenVerifier) EXPECT() *MockTokenVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifyAccessToken mocks base method.
+func (m *MockTokenVerifier) VerifyAccessToken(token string) (oauth.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAccessToken", token)
+	ret0, _ := ret[0].(oauth.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAccessToken indicates an expected call of VerifyAccessToken.
+func (mr *MockTokenVerifierMockRecorder) VerifyAccessToken(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAccessToken", reflect.TypeOf((*MockTokenVerifier)(nil).VerifyAccessToken), token)
+}
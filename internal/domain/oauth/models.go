@@ -0,0 +1,590 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidRequest          = errors.New("invalid request")
+	ErrClientNameRequired      = errors.New("oauth client name is required")
+	ErrClientNotFound          = errors.New("oauth client not found")
+	ErrClientAuthFailed        = errors.New("oauth client authentication failed")
+	ErrRedirectURINotValid     = errors.New("redirect uri is not valid")
+	ErrRedirectURINotAllowed   = errors.New("redirect uri is not registered for client")
+	ErrGrantTypeNotValid       = errors.New("grant type is not valid")
+	ErrGrantTypeNotAllowed     = errors.New("grant type is not allowed for client")
+	ErrResponseTypeNotValid    = errors.New("response type is not supported")
+	ErrScopeNotAllowed         = errors.New("scope is not allowed for client")
+	ErrCodeChallengeRequired   = errors.New("code challenge is required")
+	ErrCodeChallengeMethod     = errors.New("code challenge method must be S256")
+	ErrCodeNotFound            = errors.New("authorization code not found")
+	ErrCodeExpired             = errors.New("authorization code expired")
+	ErrCodeUsed                = errors.New("authorization code already used")
+	ErrCodeVerifierMismatch    = errors.New("code verifier does not match challenge")
+	ErrRedirectURIMismatch     = errors.New("redirect uri does not match authorization request")
+	ErrRefreshTokenNotFound    = errors.New("refresh token not found")
+	ErrRefreshTokenExpired     = errors.New("refresh token expired")
+	ErrRefreshTokenRevoked     = errors.New("refresh token revoked")
+	ErrAccessDenied            = errors.New("access denied")
+	ErrUserNotActive           = errors.New("user is not active")
+	ErrPublicClientCredentials = errors.New("public client can not use client credentials")
+)
+
+const (
+	ResponseTypeCode = "code"
+
+	CodeChallengeS256 = "S256"
+
+	clientSecretPrefix = "cs_"
+)
+
+type GrantType string
+
+const (
+	GrantAuthorizationCode GrantType = "authorization_code"
+	GrantRefreshToken      GrantType = "refresh_token"
+	GrantClientCredentials GrantType = "client_credentials"
+)
+
+// Scope набор разрешений, в запросах передается строкой через пробел
+type Scope []string
+
+type Client struct {
+	id           uuid.UUID
+	clientID     string
+	secretHash   string
+	name         string
+	redirectURIs []string
+	grantTypes   []GrantType
+	scope        Scope
+	confidential bool
+	createdAt    time.Time
+}
+
+// AuthorizationRequest параметры запроса на /authorize
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce OIDC, возвращается в ID токене без изменений
+	Nonce string
+}
+
+// TokenRequest параметры запроса на /token, используемые поля зависят от GrantType
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+}
+
+type TokenResponse struct {
+	AccessToken  string
+	ExpiresIn    time.Duration
+	RefreshToken string
+	Scope        Scope
+	// IDToken выдается, если в scope есть openid
+	IDToken string
+}
+
+// AuthorizationCode хранится только hash кода, сам код уходит клиенту через redirect
+type AuthorizationCode struct {
+	codeHash            string
+	clientID            string
+	userID              uuid.UUID
+	redirectURI         string
+	scope               Scope
+	codeChallenge       string
+	codeChallengeMethod string
+	nonce               string
+	auth                Authentication
+	familyID            uuid.UUID
+	expiresAt           time.Time
+	usedAt              *time.Time
+	createdAt           time.Time
+}
+
+// RefreshToken при обновлении заменяется новым из того же семейства.
+// Повторное использование замененного токена отзывает все семейство
+type RefreshToken struct {
+	id        uuid.UUID
+	tokenHash string
+	familyID  uuid.UUID
+	clientID  string
+	userID    uuid.UUID
+	scope     Scope
+	auth      Authentication
+	expiresAt time.Time
+	revokedAt *time.Time
+	createdAt time.Time
+}
+
+func NewClient(
+	id uuid.UUID,
+	clientID string,
+	secretHash string,
+	name string,
+	redirectURIs []string,
+	grantTypes []GrantType,
+	scope Scope,
+	confidential bool,
+	createdAt time.Time,
+) (*Client, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, ErrClientNameRequired
+	}
+	if len(grantTypes) == 0 {
+		return nil, ErrGrantTypeNotValid
+	}
+	for _, g := range grantTypes {
+		if err := g.validate(); err != nil {
+			return nil, err
+		}
+		if g == GrantClientCredentials && !confidential {
+			return nil, ErrPublicClientCredentials
+		}
+	}
+	if slices.Contains(grantTypes, GrantAuthorizationCode) && len(redirectURIs) == 0 {
+		return nil, ErrRedirectURINotValid
+	}
+	for _, uri := range redirectURIs {
+		if err := validateRedirectURI(uri); err != nil {
+			return nil, err
+		}
+	}
+	return &Client{
+		id:           id,
+		clientID:     clientID,
+		secretHash:   secretHash,
+		name:         name,
+		redirectURIs: redirectURIs,
+		grantTypes:   grantTypes,
+		scope:        scope,
+		confidential: confidential,
+		createdAt:    createdAt,
+	}, nil
+}
+
+// CreateClient регистрирует клиента. Для конфиденциального клиента возвращается секрет,
+// он показывается один раз, хранится только hash
+func CreateClient(
+	name string,
+	redirectURIs []string,
+	grantTypes []GrantType,
+	scope Scope,
+	confidential bool,
+) (*Client, string, error) {
+	var secret, secretHash string
+	if confidential {
+		raw, err := randomToken(32)
+		if err != nil {
+			return nil, "", err
+		}
+		secret = clientSecretPrefix + raw
+		secretHash = HashToken(secret)
+	}
+	id := uuid.New()
+	client, err := NewClient(id, id.String(), secretHash, name, redirectURIs, grantTypes, scope, confidential, time.Now().UTC())
+	if err != nil {
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+func (c *Client) ID() uuid.UUID {
+	return c.id
+}
+func (c *Client) ClientID() string {
+	return c.clientID
+}
+func (c *Client) SecretHash() string {
+	return c.secretHash
+}
+func (c *Client) Name() string {
+	return c.name
+}
+func (c *Client) RedirectURIs() []string {
+	return c.redirectURIs
+}
+func (c *Client) GrantTypes() []GrantType {
+	return c.grantTypes
+}
+func (c *Client) Scope() Scope {
+	return c.scope
+}
+func (c *Client) IsConfidential() bool {
+	return c.confidential
+}
+func (c *Client) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+// Authenticate публичный клиент секрета не имеет, конфиденциальный обязан его передать
+func (c *Client) Authenticate(secret string) error {
+	if !c.confidential {
+		if secret != "" {
+			return ErrClientAuthFailed
+		}
+		return nil
+	}
+	if secret == "" || subtle.ConstantTimeCompare([]byte(HashToken(secret)), []byte(c.secretHash)) != 1 {
+		return ErrClientAuthFailed
+	}
+	return nil
+}
+
+func (c *Client) AllowsGrant(grant GrantType) error {
+	if !slices.Contains(c.grantTypes, grant) {
+		return ErrGrantTypeNotAllowed
+	}
+	return nil
+}
+
+// CheckRedirectURI сравнение точное, без нормализации и подстановок
+func (c *Client) CheckRedirectURI(uri string) error {
+	if !slices.Contains(c.redirectURIs, uri) {
+		return ErrRedirectURINotAllowed
+	}
+	return nil
+}
+
+// ResolveScope пустой запрос означает все разрешения клиента
+func (c *Client) ResolveScope(requested Scope) (Scope, error) {
+	if len(requested) == 0 {
+		return c.scope, nil
+	}
+	if !c.scope.Contains(requested) {
+		return nil, ErrScopeNotAllowed
+	}
+	return requested, nil
+}
+
+// ValidateAuthorization проверяет запрос на /authorize. PKCE обязателен для всех клиентов
+func (c *Client) ValidateAuthorization(req AuthorizationRequest) (Scope, error) {
+	if err := c.CheckRedirectURI(req.RedirectURI); err != nil {
+		return nil, err
+	}
+	if req.ResponseType != ResponseTypeCode {
+		return nil, ErrResponseTypeNotValid
+	}
+	if err := c.AllowsGrant(GrantAuthorizationCode); err != nil {
+		return nil, err
+	}
+	if req.CodeChallenge == "" {
+		return nil, ErrCodeChallengeRequired
+	}
+	if req.CodeChallengeMethod != CodeChallengeS256 {
+		return nil, ErrCodeChallengeMethod
+	}
+	return c.ResolveScope(ParseScope(req.Scope))
+}
+
+func NewAuthorizationCode(
+	codeHash string,
+	clientID string,
+	userID uuid.UUID,
+	redirectURI string,
+	scope Scope,
+	codeChallenge string,
+	codeChallengeMethod string,
+	nonce string,
+	auth Authentication,
+	familyID uuid.UUID,
+	expiresAt time.Time,
+	usedAt *time.Time,
+	createdAt time.Time,
+) *AuthorizationCode {
+	return &AuthorizationCode{
+		codeHash:            codeHash,
+		clientID:            clientID,
+		userID:              userID,
+		redirectURI:         redirectURI,
+		scope:               scope,
+		codeChallenge:       codeChallenge,
+		codeChallengeMethod: codeChallengeMethod,
+		nonce:               nonce,
+		auth:                auth,
+		familyID:            familyID,
+		expiresAt:           expiresAt,
+		usedAt:              usedAt,
+		createdAt:           createdAt,
+	}
+}
+
+// CreateAuthorizationCode возвращает код и его запись, запрос должен быть уже проверен клиентом
+func CreateAuthorizationCode(req AuthorizationRequest, userID uuid.UUID, scope Scope, auth Authentication, ttl time.Duration) (*AuthorizationCode, string, error) {
+	code, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now().UTC()
+	return NewAuthorizationCode(
+		HashToken(code),
+		req.ClientID,
+		userID,
+		req.RedirectURI,
+		scope,
+		req.CodeChallenge,
+		req.CodeChallengeMethod,
+		req.Nonce,
+		auth,
+		uuid.New(),
+		now.Add(ttl),
+		nil,
+		now,
+	), code, nil
+}
+
+func (a *AuthorizationCode) CodeHash() string {
+	return a.codeHash
+}
+func (a *AuthorizationCode) ClientID() string {
+	return a.clientID
+}
+func (a *AuthorizationCode) UserID() uuid.UUID {
+	return a.userID
+}
+func (a *AuthorizationCode) RedirectURI() string {
+	return a.redirectURI
+}
+func (a *AuthorizationCode) Scope() Scope {
+	return a.scope
+}
+func (a *AuthorizationCode) CodeChallenge() string {
+	return a.codeChallenge
+}
+func (a *AuthorizationCode) CodeChallengeMethod() string {
+	return a.codeChallengeMethod
+}
+func (a *AuthorizationCode) Nonce() string {
+	return a.nonce
+}
+func (a *AuthorizationCode) Auth() Authentication {
+	return a.auth
+}
+func (a *AuthorizationCode) FamilyID() uuid.UUID {
+	return a.familyID
+}
+func (a *AuthorizationCode) ExpiresAt() time.Time {
+	return a.expiresAt
+}
+func (a *AuthorizationCode) UsedAt() *time.Time {
+	return a.usedAt
+}
+func (a *AuthorizationCode) CreatedAt() time.Time {
+	return a.createdAt
+}
+
+// Redeem помечает код использованным. Ошибка ErrCodeUsed означает повторное предъявление,
+// выданные по коду токены нужно отозвать
+func (a *AuthorizationCode) Redeem(clientID string, redirectURI string, codeVerifier string, at time.Time) error {
+	if a.usedAt != nil {
+		return ErrCodeUsed
+	}
+	if a.clientID != clientID {
+		return ErrCodeNotFound
+	}
+	if at.After(a.expiresAt) {
+		return ErrCodeExpired
+	}
+	if a.redirectURI != redirectURI {
+		return ErrRedirectURIMismatch
+	}
+	if !VerifyCodeChallenge(codeVerifier, a.codeChallenge) {
+		return ErrCodeVerifierMismatch
+	}
+	a.usedAt = &at
+	return nil
+}
+
+func NewRefreshToken(
+	id uuid.UUID,
+	tokenHash string,
+	familyID uuid.UUID,
+	clientID string,
+	userID uuid.UUID,
+	scope Scope,
+	auth Authentication,
+	expiresAt time.Time,
+	revokedAt *time.Time,
+	createdAt time.Time,
+) *RefreshToken {
+	return &RefreshToken{
+		id:        id,
+		tokenHash: tokenHash,
+		familyID:  familyID,
+		clientID:  clientID,
+		userID:    userID,
+		scope:     scope,
+		auth:      auth,
+		expiresAt: expiresAt,
+		revokedAt: revokedAt,
+		createdAt: createdAt,
+	}
+}
+
+// CreateRefreshToken auth переносится из исходного входа, чтобы auth_time в ID токенах не менялся
+func CreateRefreshToken(familyID uuid.UUID, clientID string, userID uuid.UUID, scope Scope, auth Authentication, ttl time.Duration) (*RefreshToken, string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now().UTC()
+	return NewRefreshToken(uuid.New(), HashToken(token), familyID, clientID, userID, scope, auth, now.Add(ttl), nil, now), token, nil
+}
+
+func (r *RefreshToken) ID() uuid.UUID {
+	return r.id
+}
+func (r *RefreshToken) TokenHash() string {
+	return r.tokenHash
+}
+func (r *RefreshToken) FamilyID() uuid.UUID {
+	return r.familyID
+}
+func (r *RefreshToken) ClientID() string {
+	return r.clientID
+}
+func (r *RefreshToken) UserID() uuid.UUID {
+	return r.userID
+}
+func (r *RefreshToken) Scope() Scope {
+	return r.scope
+}
+func (r *RefreshToken) Auth() Authentication {
+	return r.auth
+}
+func (r *RefreshToken) ExpiresAt() time.Time {
+	return r.expiresAt
+}
+func (r *RefreshToken) RevokedAt() *time.Time {
+	return r.revokedAt
+}
+func (r *RefreshToken) CreatedAt() time.Time {
+	return r.createdAt
+}
+
+// Use проверяет, что токен можно обменять. ErrRefreshTokenRevoked означает повторное использование
+func (r *RefreshToken) Use(clientID string, at time.Time) error {
+	if r.clientID != clientID {
+		return ErrRefreshTokenNotFound
+	}
+	if r.revokedAt != nil {
+		return ErrRefreshTokenRevoked
+	}
+	if at.After(r.expiresAt) {
+		return ErrRefreshTokenExpired
+	}
+	return nil
+}
+
+func (r *RefreshToken) Revoke(at time.Time) {
+	if r.revokedAt == nil {
+		r.revokedAt = &at
+	}
+}
+
+func NewGrantType(grant string) (GrantType, error) {
+	g := GrantType(grant)
+	if err := g.validate(); err != nil {
+		return "", err
+	}
+	return g, nil
+}
+
+func (g GrantType) validate() error {
+	switch g {
+	case GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials:
+		return nil
+	default:
+		return ErrGrantTypeNotValid
+	}
+}
+
+func (g GrantType) String() string {
+	return string(g)
+}
+
+func ParseScope(scope string) Scope {
+	fields := strings.Fields(scope)
+	if len(fields) == 0 {
+		return nil
+	}
+	res := make(Scope, 0, len(fields))
+	for _, f := range fields {
+		if !slices.Contains(res, f) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+func (s Scope) Contains(other Scope) bool {
+	for _, o := range other {
+		if !slices.Contains(s, o) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Scope) String() string {
+	return strings.Join(s, " ")
+}
+
+// VerifyCodeChallenge S256: base64url(sha256(verifier)) без выравнивания
+func VerifyCodeChallenge(verifier string, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// HashToken коды, refresh токены и секреты клиентов случайные и длинные, поэтому достаточно sha256
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateRedirectURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || u.Fragment != "" {
+		return ErrRedirectURINotValid
+	}
+	// http разрешен только для локальной разработки, остальные схемы нужны мобильным приложениям
+	if u.Scheme == "http" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1" {
+		return ErrRedirectURINotValid
+	}
+	if (u.Scheme == "http" || u.Scheme == "https") && u.Host == "" {
+		return ErrRedirectURINotValid
+	}
+	return nil
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
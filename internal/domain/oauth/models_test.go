@@ -0,0 +1,257 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testVerifier = "dBjftJeZ4CVP-mJ92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func testChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestNewClient(t *testing.T) {
+	cases := []struct {
+		name         string
+		redirectURIs []string
+		grantTypes   []GrantType
+		confidential bool
+		wantErr      error
+	}{
+		{
+			name:         "public spa",
+			redirectURIs: []string{"https://app.example.com/callback"},
+			grantTypes:   []GrantType{GrantAuthorizationCode, GrantRefreshToken},
+		},
+		{
+			name:         "mobile custom scheme",
+			redirectURIs: []string{"com.example.app:/oauth"},
+			grantTypes:   []GrantType{GrantAuthorizationCode},
+		},
+		{
+			name:         "confidential machine client",
+			grantTypes:   []GrantType{GrantClientCredentials},
+			confidential: true,
+		},
+		{
+			name:       "public client credentials",
+			grantTypes: []GrantType{GrantClientCredentials},
+			wantErr:    ErrPublicClientCredentials,
+		},
+		{
+			name:       "code without redirect",
+			grantTypes: []GrantType{GrantAuthorizationCode},
+			wantErr:    ErrRedirectURINotValid,
+		},
+		{
+			name:         "plain http outside localhost",
+			redirectURIs: []string{"http://app.example.com/callback"},
+			grantTypes:   []GrantType{GrantAuthorizationCode},
+			wantErr:      ErrRedirectURINotValid,
+		},
+		{
+			name:         "redirect with fragment",
+			redirectURIs: []string{"https://app.example.com/callback#x"},
+			grantTypes:   []GrantType{GrantAuthorizationCode},
+			wantErr:      ErrRedirectURINotValid,
+		},
+		{
+			name:       "unknown grant",
+			grantTypes: []GrantType{"password"},
+			wantErr:    ErrGrantTypeNotValid,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			client, secret, err := CreateClient("app", tt.redirectURIs, tt.grantTypes, Scope{"profile"}, tt.confidential)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.confidential {
+				assert.True(t, strings.HasPrefix(secret, clientSecretPrefix))
+				assert.NoError(t, client.Authenticate(secret))
+				assert.ErrorIs(t, client.Authenticate("wrong"), ErrClientAuthFailed)
+				assert.ErrorIs(t, client.Authenticate(""), ErrClientAuthFailed)
+			} else {
+				assert.Empty(t, secret)
+				assert.NoError(t, client.Authenticate(""))
+			}
+		})
+	}
+}
+
+func TestClient_ValidateAuthorization(t *testing.T) {
+	client, _, err := CreateClient("app", []string{"https://app.example.com/cb"}, []GrantType{GrantAuthorizationCode}, Scope{"profile", "email"}, false)
+	require.NoError(t, err)
+
+	valid := AuthorizationRequest{
+		ResponseType:        ResponseTypeCode,
+		ClientID:            client.ClientID(),
+		RedirectURI:         "https://app.example.com/cb",
+		CodeChallenge:       testChallenge(testVerifier),
+		CodeChallengeMethod: CodeChallengeS256,
+	}
+
+	cases := []struct {
+		name      string
+		modify    func(r *AuthorizationRequest)
+		wantScope Scope
+		wantErr   error
+	}{
+		{
+			name:      "default scope",
+			modify:    func(r *AuthorizationRequest) {},
+			wantScope: Scope{"profile", "email"},
+		},
+		{
+			name:      "narrowed scope",
+			modify:    func(r *AuthorizationRequest) { r.Scope = "email" },
+			wantScope: Scope{"email"},
+		},
+		{
+			name:    "unknown scope",
+			modify:  func(r *AuthorizationRequest) { r.Scope = "admin" },
+			wantErr: ErrScopeNotAllowed,
+		},
+		{
+			name:    "redirect not registered",
+			modify:  func(r *AuthorizationRequest) { r.RedirectURI = "https://evil.example.com/cb" },
+			wantErr: ErrRedirectURINotAllowed,
+		},
+		{
+			name:    "token response type",
+			modify:  func(r *AuthorizationRequest) { r.ResponseType = "token" },
+			wantErr: ErrResponseTypeNotValid,
+		},
+		{
+			name:    "missing pkce",
+			modify:  func(r *AuthorizationRequest) { r.CodeChallenge = "" },
+			wantErr: ErrCodeChallengeRequired,
+		},
+		{
+			name:    "plain pkce",
+			modify:  func(r *AuthorizationRequest) { r.CodeChallengeMethod = "plain" },
+			wantErr: ErrCodeChallengeMethod,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			scope, err := client.ValidateAuthorization(req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantScope, scope)
+		})
+	}
+}
+
+func TestAuthorizationCode_Redeem(t *testing.T) {
+	req := AuthorizationRequest{
+		ClientID:            "client",
+		RedirectURI:         "https://app.example.com/cb",
+		CodeChallenge:       testChallenge(testVerifier),
+		CodeChallengeMethod: CodeChallengeS256,
+	}
+	now := time.Now().UTC()
+
+	cases := []struct {
+		name        string
+		clientID    string
+		redirectURI string
+		verifier    string
+		at          time.Time
+		wantErr     error
+	}{
+		{
+			name:        "valid",
+			clientID:    "client",
+			redirectURI: req.RedirectURI,
+			verifier:    testVerifier,
+			at:          now,
+		},
+		{
+			name:        "other client",
+			clientID:    "other",
+			redirectURI: req.RedirectURI,
+			verifier:    testVerifier,
+			at:          now,
+			wantErr:     ErrCodeNotFound,
+		},
+		{
+			name:        "wrong verifier",
+			clientID:    "client",
+			redirectURI: req.RedirectURI,
+			verifier:    strings.Repeat("a", 43),
+			at:          now,
+			wantErr:     ErrCodeVerifierMismatch,
+		},
+		{
+			name:        "redirect mismatch",
+			clientID:    "client",
+			redirectURI: "https://app.example.com/other",
+			verifier:    testVerifier,
+			at:          now,
+			wantErr:     ErrRedirectURIMismatch,
+		},
+		{
+			name:        "expired",
+			clientID:    "client",
+			redirectURI: req.RedirectURI,
+			verifier:    testVerifier,
+			at:          now.Add(2 * time.Minute),
+			wantErr:     ErrCodeExpired,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			code, raw, err := CreateAuthorizationCode(req, uuid.New(), Scope{"profile"}, Authentication{}, time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, HashToken(raw), code.CodeHash())
+
+			err = code.Redeem(tt.clientID, tt.redirectURI, tt.verifier, tt.at)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.ErrorIs(t, code.Redeem(tt.clientID, tt.redirectURI, tt.verifier, tt.at), ErrCodeUsed)
+		})
+	}
+}
+
+func TestRefreshToken_Use(t *testing.T) {
+	token, _, err := CreateRefreshToken(uuid.New(), "client", uuid.New(), Scope{"profile"}, Authentication{}, time.Hour)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	assert.NoError(t, token.Use("client", now))
+	assert.ErrorIs(t, token.Use("other", now), ErrRefreshTokenNotFound)
+	assert.ErrorIs(t, token.Use("client", now.Add(2*time.Hour)), ErrRefreshTokenExpired)
+
+	token.Revoke(now)
+	assert.ErrorIs(t, token.Use("client", now), ErrRefreshTokenRevoked)
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	assert.True(t, VerifyCodeChallenge(testVerifier, testChallenge(testVerifier)))
+	assert.False(t, VerifyCodeChallenge(testVerifier, testChallenge(strings.Repeat("a", 43))))
+	// verifier короче 43 символов запрещен RFC 7636
+	assert.False(t, VerifyCodeChallenge("short", testChallenge("short")))
+}
@@ -0,0 +1,97 @@
+package oauth
+
+import (
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"slices"
+	"time"
+)
+
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+
+	// ACRPassword вход по паролю, других способов пока нет
+	ACRPassword = "pwd"
+)
+
+// Authentication когда и как пользователь подтвердил личность, попадает в auth_time и acr
+type Authentication struct {
+	Time time.Time
+	ACR  string
+}
+
+// UserInfo claims пользователя, отфильтрованные по выданным scope
+type UserInfo struct {
+	Subject       string
+	Name          string
+	Email         string
+	EmailVerified bool
+	scope         Scope
+}
+
+// IDToken содержимое ID токена OIDC
+type IDToken struct {
+	Audience string
+	Nonce    string
+	Auth     Authentication
+	UserInfo UserInfo
+}
+
+// NewUserInfo sub есть всегда, name при scope profile, email и email_verified при scope email
+func NewUserInfo(user *users.User, scope Scope) UserInfo {
+	info := UserInfo{
+		Subject: user.ID().String(),
+		scope:   scope,
+	}
+	if info.HasProfile() {
+		info.Name = user.Name().String()
+	}
+	if info.HasEmail() {
+		info.Email = user.Email().String()
+		// сервис пока не подтверждает адреса
+		info.EmailVerified = false
+	}
+	return info
+}
+
+func (u UserInfo) HasProfile() bool {
+	return slices.Contains(u.scope, ScopeProfile)
+}
+
+func (u UserInfo) HasEmail() bool {
+	return slices.Contains(u.scope, ScopeEmail)
+}
+
+// Claims представление для JWT и /userinfo, claims вне выданных scope не попадают в ответ
+func (u UserInfo) Claims() map[string]any {
+	claims := map[string]any{"sub": u.Subject}
+	if u.HasProfile() {
+		claims["name"] = u.Name
+	}
+	if u.HasEmail() {
+		claims["email"] = u.Email
+		claims["email_verified"] = u.EmailVerified
+	}
+	return claims
+}
+
+// RequireOpenID /userinfo доступен только токенам со scope openid
+func RequireOpenID(scope Scope) error {
+	if !slices.Contains(scope, ScopeOpenID) {
+		return ErrInsufficientScope
+	}
+	return nil
+}
+
+func NewIDToken(user *users.User, clientID string, nonce string, auth Authentication, scope Scope) IDToken {
+	return IDToken{
+		Audience: clientID,
+		Nonce:    nonce,
+		Auth:     auth,
+		UserInfo: NewUserInfo(user, scope),
+	}
+}
@@ -0,0 +1,14 @@
+package organizations
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Save(ctx context.Context, organization *Organization) error
+	Get(ctx context.Context, id uuid.UUID) (*Organization, error)
+	GetBySlug(ctx context.Context, slug string) (*Organization, error)
+	List(ctx context.Context, limit int, offset int) ([]*Organization, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
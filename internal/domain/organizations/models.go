@@ -0,0 +1,108 @@
+package organizations
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSlugNotValid         = errors.New("organization slug is not valid")
+	ErrSlugAlreadyExists    = errors.New("organization slug already exists")
+	ErrNameRequired         = errors.New("organization name is required")
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrOrganizationNotEmpty = errors.New("organization has users")
+	ErrDefaultOrganization  = errors.New("default organization cannot be changed")
+)
+
+// DefaultID организация платформы. В ней пользователи, созданные до организаций,
+// а ее администраторы управляют остальными организациями
+var DefaultID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+const DefaultSlug = "default"
+
+// validSlug slug попадает в заголовок x-organization и в адреса, поэтому только a-z, 0-9 и дефис
+var validSlug = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Organization тенант, пользователи и их email изолированы внутри организации
+type Organization struct {
+	id        uuid.UUID
+	slug      string
+	name      string
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func NewOrganization(id uuid.UUID, slug string, name string, createdAt time.Time, updatedAt time.Time) *Organization {
+	return &Organization{
+		id:        id,
+		slug:      slug,
+		name:      name,
+		createdAt: createdAt,
+		updatedAt: updatedAt,
+	}
+}
+
+func CreateOrganization(slug string, name string) (*Organization, error) {
+	slug, err := NewSlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrNameRequired
+	}
+	now := time.Now().UTC()
+	return NewOrganization(uuid.New(), slug, name, now, now), nil
+}
+
+// NewSlug приводит slug к нижнему регистру и проверяет его
+func NewSlug(slug string) (string, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !validSlug.MatchString(slug) {
+		return "", ErrSlugNotValid
+	}
+	return slug, nil
+}
+
+func (o *Organization) ID() uuid.UUID {
+	return o.id
+}
+func (o *Organization) Slug() string {
+	return o.slug
+}
+func (o *Organization) Name() string {
+	return o.name
+}
+func (o *Organization) CreatedAt() time.Time {
+	return o.createdAt
+}
+func (o *Organization) UpdatedAt() time.Time {
+	return o.updatedAt
+}
+
+func (o *Organization) IsDefault() bool {
+	return o.id == DefaultID
+}
+
+// Update пустые значения не меняются. slug организации по умолчанию не меняется,
+// на него могут ссылаться клиенты, которые не знают об организациях
+func (o *Organization) Update(slug string, name string) error {
+	if slug != "" {
+		s, err := NewSlug(slug)
+		if err != nil {
+			return err
+		}
+		if s != o.slug && o.IsDefault() {
+			return ErrDefaultOrganization
+		}
+		o.slug = s
+	}
+	if strings.TrimSpace(name) != "" {
+		o.name = strings.TrimSpace(name)
+	}
+	o.updatedAt = time.Now().UTC()
+	return nil
+}
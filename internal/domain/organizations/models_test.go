@@ -0,0 +1,64 @@
+package organizations
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewSlug(t *testing.T) {
+	cases := []struct {
+		name    string
+		slug    string
+		want    string
+		wantErr error
+	}{
+		{name: "ok", slug: "acme", want: "acme"},
+		{name: "upper case", slug: " Acme-Corp ", want: "acme-corp"},
+		{name: "empty", slug: "", wantErr: ErrSlugNotValid},
+		{name: "leading dash", slug: "-acme", wantErr: ErrSlugNotValid},
+		{name: "trailing dash", slug: "acme-", wantErr: ErrSlugNotValid},
+		{name: "underscore", slug: "acme_corp", wantErr: ErrSlugNotValid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSlug(tt.slug)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCreateOrganization(t *testing.T) {
+	_, err := CreateOrganization("acme", " ")
+	assert.ErrorIs(t, err, ErrNameRequired)
+
+	organization, err := CreateOrganization("ACME", "Acme Corp")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", organization.Slug())
+	assert.False(t, organization.IsDefault())
+}
+
+func TestOrganization_Update(t *testing.T) {
+	organization, err := CreateOrganization("acme", "Acme Corp")
+	require.NoError(t, err)
+
+	require.NoError(t, organization.Update("", "Acme"))
+	assert.Equal(t, "acme", organization.Slug())
+	assert.Equal(t, "Acme", organization.Name())
+
+	require.NoError(t, organization.Update("acme-corp", ""))
+	assert.Equal(t, "acme-corp", organization.Slug())
+	assert.ErrorIs(t, organization.Update("acme_corp", ""), ErrSlugNotValid)
+
+	now := time.Now().UTC()
+	platform := NewOrganization(DefaultID, DefaultSlug, "Platform", now, now)
+	assert.ErrorIs(t, platform.Update("platform", ""), ErrDefaultOrganization)
+	assert.NoError(t, platform.Update(DefaultSlug, "Platform Inc"))
+}
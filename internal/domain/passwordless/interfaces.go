@@ -0,0 +1,15 @@
+package passwordless
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Save(ctx context.Context, challenge *Challenge) error
+	// GetByLinkHash блокирует строку до конца транзакции, чтобы ссылку нельзя было применить дважды
+	GetByLinkHash(ctx context.Context, linkHash string) (*Challenge, error)
+	// GetLatest последний вход пользователя, блокирует строку до конца транзакции
+	GetLatest(ctx context.Context, userID uuid.UUID) (*Challenge, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./interfaces.go -destination=./mocks/interfaces_mocks.go
+//
+
+// Package mock_passwordless is a generated GoMock package.
+package mock_passwordless
+
+import (
+	context "context"
+	reflect "reflect"
+
+	passwordless "github.com/LeoUraltsev/auth-service/internal/domain/passwordless"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteByUser mocks base method.
+func (m *MockRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockRepositoryMockRecorder) DeleteByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockRepository)(nil).DeleteByUser), ctx, userID)
+}
+
+// GetByLinkHash mocks base method.
+func (m *MockRepository) GetByLinkHash(ctx context.Context, linkHash string) (*passwordless.Challenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByLinkHash", ctx, linkHash)
+	ret0, _ := ret[0].(*passwordless.Challenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByLinkHash indicates an expected call of GetByLinkHash.
+func (mr *MockRepositoryMockRecorder) GetByLinkHash(ctx, linkHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLinkHash", reflect.TypeOf((*MockRepository)(nil).GetByLinkHash), ctx, linkHash)
+}
+
+// GetLatest mocks base method.
+func (m *MockRepository) GetLatest(ctx context.Context, userID uuid.UUID) (*passwordless.Challenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatest", ctx, userID)
+	ret0, _ := ret[0].(*passwordless.Challenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatest indicates an expected call of GetLatest.
+func (mr *MockRepositoryMockRecorder) GetLatest(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatest", reflect.TypeOf((*MockRepository)(nil).GetLatest), ctx, userID)
+}
+
+// Save mocks base method.
+func (m *MockRepository) Save(ctx context.Context, challenge *passwordless.Challenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, challenge)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockRepositoryMockRecorder) Save(ctx, challenge any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, challenge)
+}
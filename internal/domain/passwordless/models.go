@@ -0,0 +1,225 @@
+package passwordless
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound        = errors.New("passwordless login not found")
+	ErrDisabled        = errors.New("passwordless login is disabled")
+	ErrLinkNotValid    = errors.New("passwordless link is not valid")
+	ErrCodeNotValid    = errors.New("passwordless code is not valid")
+	ErrExpired         = errors.New("passwordless login is expired")
+	ErrConsumed        = errors.New("passwordless login is already used")
+	ErrTooManyAttempts = errors.New("too many passwordless code attempts")
+	ErrTTLInvalid      = errors.New("passwordless login ttl must be positive")
+	ErrAttemptsInvalid = errors.New("passwordless login attempts must be positive")
+)
+
+const (
+	// LinkPrefix по нему токен ссылки отличается от других секретов
+	LinkPrefix = "pwl_"
+	CodeLength = 6
+)
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusConsumed Status = "consumed"
+	StatusExpired  Status = "expired"
+)
+
+// Challenge вход без пароля: ссылка и код из письма, сработать может что-то одно и один раз.
+// Хранятся только hash, hash кода солится id входа
+type Challenge struct {
+	id          uuid.UUID
+	tenantID    uuid.UUID
+	userID      uuid.UUID
+	linkHash    string
+	codeHash    string
+	attempts    int
+	maxAttempts int
+	expiresAt   time.Time
+	consumedAt  *time.Time
+	createdAt   time.Time
+}
+
+// Secrets значения ссылки и кода, показываются только в письме
+type Secrets struct {
+	Link string
+	Code string
+}
+
+func NewChallenge(
+	id uuid.UUID,
+	tenantID uuid.UUID,
+	userID uuid.UUID,
+	linkHash string,
+	codeHash string,
+	attempts int,
+	maxAttempts int,
+	expiresAt time.Time,
+	consumedAt *time.Time,
+	createdAt time.Time,
+) *Challenge {
+	return &Challenge{
+		id:          id,
+		tenantID:    tenantID,
+		userID:      userID,
+		linkHash:    linkHash,
+		codeHash:    codeHash,
+		attempts:    attempts,
+		maxAttempts: maxAttempts,
+		expiresAt:   expiresAt,
+		consumedAt:  consumedAt,
+		createdAt:   createdAt,
+	}
+}
+
+func CreateChallenge(u *users.User, ttl time.Duration, maxAttempts int) (*Challenge, Secrets, error) {
+	if ttl <= 0 {
+		return nil, Secrets{}, ErrTTLInvalid
+	}
+	if maxAttempts <= 0 {
+		return nil, Secrets{}, ErrAttemptsInvalid
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, Secrets{}, err
+	}
+	link := LinkPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return nil, Secrets{}, err
+	}
+	code := fmt.Sprintf("%0*d", CodeLength, n.Int64())
+
+	id := uuid.New()
+	now := time.Now().UTC()
+	c := NewChallenge(id, u.TenantID(), u.ID(), HashLink(link), hashCode(id, code), 0, maxAttempts, now.Add(ttl), nil, now)
+	return c, Secrets{Link: link, Code: code}, nil
+}
+
+// HashLink по hash токена ссылки ищется вход
+func HashLink(link string) string {
+	return oauth.HashToken(link)
+}
+
+func hashCode(id uuid.UUID, code string) string {
+	return oauth.HashToken(id.String() + ":" + code)
+}
+
+// ValidLink токен похож на токен ссылки, проверяется до обращения к базе
+func ValidLink(link string) error {
+	if !strings.HasPrefix(link, LinkPrefix) || len(link) == len(LinkPrefix) {
+		return ErrLinkNotValid
+	}
+	return nil
+}
+
+func (c *Challenge) ID() uuid.UUID {
+	return c.id
+}
+func (c *Challenge) TenantID() uuid.UUID {
+	return c.tenantID
+}
+func (c *Challenge) UserID() uuid.UUID {
+	return c.userID
+}
+func (c *Challenge) LinkHash() string {
+	return c.linkHash
+}
+func (c *Challenge) CodeHash() string {
+	return c.codeHash
+}
+func (c *Challenge) Attempts() int {
+	return c.attempts
+}
+func (c *Challenge) MaxAttempts() int {
+	return c.maxAttempts
+}
+func (c *Challenge) ExpiresAt() time.Time {
+	return c.expiresAt
+}
+func (c *Challenge) ConsumedAt() *time.Time {
+	return c.consumedAt
+}
+func (c *Challenge) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+func (c *Challenge) Status(at time.Time) Status {
+	switch {
+	case c.consumedAt != nil:
+		return StatusConsumed
+	case !at.Before(c.expiresAt):
+		return StatusExpired
+	default:
+		return StatusPending
+	}
+}
+
+// ConsumeLink вход по ссылке, hash ссылки уже совпал при поиске
+func (c *Challenge) ConsumeLink(at time.Time) error {
+	if err := c.pending(at); err != nil {
+		return err
+	}
+	c.consumedAt = &at
+	return nil
+}
+
+// ConsumeCode каждая попытка считается, после maxAttempts неверных кодов вход больше не принимается.
+// Вызывающий сохраняет вход и при ошибке, иначе счетчик попыток не растет
+func (c *Challenge) ConsumeCode(code string, at time.Time) error {
+	if err := c.pending(at); err != nil {
+		return err
+	}
+	if c.attempts >= c.maxAttempts {
+		return ErrTooManyAttempts
+	}
+	c.attempts++
+	if subtle.ConstantTimeCompare([]byte(hashCode(c.id, code)), []byte(c.codeHash)) != 1 {
+		return ErrCodeNotValid
+	}
+	c.consumedAt = &at
+	return nil
+}
+
+// ContinueAttempts новый вход, запрошенный пока предыдущий еще действует, продолжает его счетчик неверных кодов,
+// иначе повторные запросы обнуляли бы попытки и код можно было бы перебирать без ограничения
+func (c *Challenge) ContinueAttempts(previous *Challenge, at time.Time) {
+	if previous.Status(at) == StatusPending {
+		c.attempts = max(c.attempts, previous.attempts)
+	}
+}
+
+// Revoke новый вход отменяет предыдущий
+func (c *Challenge) Revoke(at time.Time) error {
+	if err := c.pending(at); err != nil {
+		return err
+	}
+	c.consumedAt = &at
+	return nil
+}
+
+func (c *Challenge) pending(at time.Time) error {
+	switch c.Status(at) {
+	case StatusPending:
+		return nil
+	case StatusExpired:
+		return ErrExpired
+	default:
+		return ErrConsumed
+	}
+}
@@ -0,0 +1,110 @@
+package passwordless
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func testUser(t *testing.T) *users.User {
+	email, _ := users.NewEmail("user@example.com")
+	password, _ := users.NewPassword([]byte("hash"))
+	u, err := users.CreateUser(uuid.New(), "user", email, password)
+	require.NoError(t, err)
+	return u
+}
+
+func TestCreateChallenge(t *testing.T) {
+	u := testUser(t)
+
+	_, _, err := CreateChallenge(u, 0, 5)
+	assert.ErrorIs(t, err, ErrTTLInvalid)
+	_, _, err = CreateChallenge(u, time.Minute, 0)
+	assert.ErrorIs(t, err, ErrAttemptsInvalid)
+
+	c, secrets, err := CreateChallenge(u, 10*time.Minute, 5)
+	require.NoError(t, err)
+	assert.NoError(t, ValidLink(secrets.Link))
+	assert.ErrorIs(t, ValidLink("ecc_"+secrets.Link[len(LinkPrefix):]), ErrLinkNotValid)
+	assert.ErrorIs(t, ValidLink(LinkPrefix), ErrLinkNotValid)
+	assert.Regexp(t, regexp.MustCompile(`^\d{6}$`), secrets.Code)
+	assert.Equal(t, HashLink(secrets.Link), c.LinkHash())
+	assert.NotContains(t, c.CodeHash(), secrets.Code)
+	assert.Equal(t, u.ID(), c.UserID())
+	assert.Equal(t, u.TenantID(), c.TenantID())
+	assert.Equal(t, StatusPending, c.Status(time.Now().UTC()))
+}
+
+func TestChallenge_ConsumeLink(t *testing.T) {
+	c, _, err := CreateChallenge(testUser(t), 10*time.Minute, 5)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	assert.ErrorIs(t, c.ConsumeLink(now.Add(time.Hour)), ErrExpired)
+
+	require.NoError(t, c.ConsumeLink(now))
+	assert.Equal(t, StatusConsumed, c.Status(now))
+	assert.ErrorIs(t, c.ConsumeLink(now), ErrConsumed)
+}
+
+func TestChallenge_ConsumeCode(t *testing.T) {
+	c, secrets, err := CreateChallenge(testUser(t), 10*time.Minute, 5)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	assert.ErrorIs(t, c.ConsumeCode("000000x", now), ErrCodeNotValid)
+	assert.Equal(t, 1, c.Attempts())
+
+	require.NoError(t, c.ConsumeCode(secrets.Code, now))
+	assert.Equal(t, StatusConsumed, c.Status(now))
+	assert.ErrorIs(t, c.ConsumeCode(secrets.Code, now), ErrConsumed, "code works once")
+	assert.ErrorIs(t, c.ConsumeLink(now), ErrConsumed, "link is spent with the code")
+}
+
+func TestChallenge_ConsumeCode_tooManyAttempts(t *testing.T) {
+	c, secrets, err := CreateChallenge(testUser(t), 10*time.Minute, 3)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	for range 3 {
+		assert.ErrorIs(t, c.ConsumeCode("wrong", now), ErrCodeNotValid)
+	}
+	assert.ErrorIs(t, c.ConsumeCode(secrets.Code, now), ErrTooManyAttempts)
+	assert.Equal(t, 3, c.Attempts())
+	assert.Equal(t, StatusPending, c.Status(now), "link still works until expiry")
+}
+
+func TestChallenge_ContinueAttempts(t *testing.T) {
+	u := testUser(t)
+	now := time.Now().UTC()
+	previous, _, err := CreateChallenge(u, 10*time.Minute, 3)
+	require.NoError(t, err)
+	for range 3 {
+		assert.ErrorIs(t, previous.ConsumeCode("wrong", now), ErrCodeNotValid)
+	}
+
+	c, secrets, err := CreateChallenge(u, 10*time.Minute, 3)
+	require.NoError(t, err)
+	c.ContinueAttempts(previous, now)
+	assert.Equal(t, 3, c.Attempts())
+	assert.ErrorIs(t, c.ConsumeCode(secrets.Code, now), ErrTooManyAttempts)
+
+	fresh, _, err := CreateChallenge(u, 10*time.Minute, 3)
+	require.NoError(t, err)
+	fresh.ContinueAttempts(previous, now.Add(time.Hour))
+	assert.Zero(t, fresh.Attempts(), "expired login does not count")
+}
+
+func TestChallenge_Revoke(t *testing.T) {
+	c, secrets, err := CreateChallenge(testUser(t), 10*time.Minute, 5)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	require.NoError(t, c.Revoke(now))
+	assert.ErrorIs(t, c.ConsumeCode(secrets.Code, now), ErrConsumed)
+	assert.ErrorIs(t, c.Revoke(now), ErrConsumed)
+}
@@ -0,0 +1,18 @@
+package relations
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// Repository записи отношений по организациям. Ревизия общая для всех организаций:
+// каждая запись или удаление в любой из них увеличивает ее
+type Repository interface {
+	Write(ctx context.Context, tenantID uuid.UUID, tuples []Tuple) (Revision, error)
+	Delete(ctx context.Context, tenantID uuid.UUID, tuples []Tuple) (Revision, error)
+	Read(ctx context.Context, tenantID uuid.UUID, object Object, relation string) ([]Tuple, error)
+	// ListObjectIDs id объектов типа, у которых есть записи, по возрастанию после afterID
+	ListObjectIDs(ctx context.Context, tenantID uuid.UUID, objectType string, afterID string, limit int) ([]string, error)
+	// Revision последняя общая ревизия, не ревизия отдельной организации
+	Revision(ctx context.Context) (Revision, error)
+}
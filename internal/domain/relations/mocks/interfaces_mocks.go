@@ -0,0 +1,118 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./interfaces.go -destination=./mocks/interfaces_mocks.go
+//
+
+// Package mock_relations is a generated GoMock package.
+package mock_relations
+
+import (
+	context "context"
+	reflect "reflect"
+
+	relations "github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockRepository) Delete(ctx context.Context, tenantID uuid.UUID, tuples []relations.Tuple) (relations.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, tenantID, tuples)
+	ret0, _ := ret[0].(relations.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRepositoryMockRecorder) Delete(ctx, tenantID, tuples any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), ctx, tenantID, tuples)
+}
+
+// ListObjectIDs mocks base method.
+func (m *MockRepository) ListObjectIDs(ctx context.Context, tenantID uuid.UUID, objectType, afterID string, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListObjectIDs", ctx, tenantID, objectType, afterID, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListObjectIDs indicates an expected call of ListObjectIDs.
+func (mr *MockRepositoryMockRecorder) ListObjectIDs(ctx, tenantID, objectType, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListObjectIDs", reflect.TypeOf((*MockRepository)(nil).ListObjectIDs), ctx, tenantID, objectType, afterID, limit)
+}
+
+// Read mocks base method.
+func (m *MockRepository) Read(ctx context.Context, tenantID uuid.UUID, object relations.Object, relation string) ([]relations.Tuple, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Read", ctx, tenantID, object, relation)
+	ret0, _ := ret[0].([]relations.Tuple)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Read indicates an expected call of Read.
+func (mr *MockRepositoryMockRecorder) Read(ctx, tenantID, object, relation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockRepository)(nil).Read), ctx, tenantID, object, relation)
+}
+
+// Revision mocks base method.
+func (m *MockRepository) Revision(ctx context.Context) (relations.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revision", ctx)
+	ret0, _ := ret[0].(relations.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revision indicates an expected call of Revision.
+func (mr *MockRepositoryMockRecorder) Revision(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revision", reflect.TypeOf((*MockRepository)(nil).Revision), ctx)
+}
+
+// Write mocks base method.
+func (m *MockRepository) Write(ctx context.Context, tenantID uuid.UUID, tuples []relations.Tuple) (relations.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Write", ctx, tenantID, tuples)
+	ret0, _ := ret[0].(relations.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Write indicates an expected call of Write.
+func (mr *MockRepositoryMockRecorder) Write(ctx, tenantID, tuples any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockRepository)(nil).Write), ctx, tenantID, tuples)
+}
@@ -0,0 +1,156 @@
+package relations
+
+import (
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrObjectNotValid     = errors.New("relation object is not valid")
+	ErrSubjectNotValid    = errors.New("relation subject is not valid")
+	ErrNamespaceNotFound  = errors.New("relation namespace not found")
+	ErrRelationNotValid   = errors.New("relation name is not valid")
+	ErrRelationNotFound   = errors.New("relation not found in namespace")
+	ErrSchemaNotValid     = errors.New("relation schema is not valid")
+	ErrTuplesRequired     = errors.New("relationships are required")
+	ErrTooManyTuples      = errors.New("too many relationships in one request")
+	ErrTokenNotValid      = errors.New("consistency token is not valid")
+	ErrRevisionNotReached = errors.New("store has not reached consistency token revision")
+	ErrDepthExceeded      = errors.New("relation check depth exceeded")
+)
+
+// MaxWriteTuples столько записей меняется за один вызов
+const MaxWriteTuples = 1000
+
+// validName имена типов и отношений
+var validName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// validID id объекта, без разделителей записи отношения
+var validID = regexp.MustCompile(`^[A-Za-z0-9_\-.|/=+]{1,256}$`)
+
+// Object объект отношения, записывается как type:id, например document:readme
+type Object struct {
+	Type string
+	ID   string
+}
+
+func NewObject(objectType string, id string) (Object, error) {
+	if !validName.MatchString(objectType) || !validID.MatchString(id) {
+		return Object{}, ErrObjectNotValid
+	}
+	return Object{Type: objectType, ID: id}, nil
+}
+
+func ParseObject(s string) (Object, error) {
+	objectType, id, ok := strings.Cut(s, ":")
+	if !ok {
+		return Object{}, ErrObjectNotValid
+	}
+	return NewObject(objectType, id)
+}
+
+func (o Object) String() string {
+	return o.Type + ":" + o.ID
+}
+
+// Subject объект (user:alice) или множество субъектов с отношением к объекту (group:eng#member)
+type Subject struct {
+	Object
+	Relation string
+}
+
+func ParseSubject(s string) (Subject, error) {
+	object, relation, userset := strings.Cut(s, "#")
+	o, err := ParseObject(object)
+	if err != nil {
+		return Subject{}, ErrSubjectNotValid
+	}
+	if userset && !validName.MatchString(relation) {
+		return Subject{}, ErrSubjectNotValid
+	}
+	return Subject{Object: o, Relation: relation}, nil
+}
+
+// IsUserset субъект задан множеством type:id#relation
+func (s Subject) IsUserset() bool {
+	return s.Relation != ""
+}
+
+func (s Subject) String() string {
+	if s.IsUserset() {
+		return s.Object.String() + "#" + s.Relation
+	}
+	return s.Object.String()
+}
+
+// Tuple запись отношения object#relation@subject
+type Tuple struct {
+	Object   Object
+	Relation string
+	Subject  Subject
+}
+
+func NewTuple(object string, relation string, subject string) (Tuple, error) {
+	o, err := ParseObject(object)
+	if err != nil {
+		return Tuple{}, err
+	}
+	if !validName.MatchString(relation) {
+		return Tuple{}, ErrRelationNotValid
+	}
+	s, err := ParseSubject(subject)
+	if err != nil {
+		return Tuple{}, err
+	}
+	return Tuple{Object: o, Relation: relation, Subject: s}, nil
+}
+
+// ParseTuple разбирает запись вида document:readme#editor@user:alice
+func ParseTuple(s string) (Tuple, error) {
+	objectRelation, subject, ok := strings.Cut(s, "@")
+	if !ok {
+		return Tuple{}, ErrSubjectNotValid
+	}
+	object, relation, ok := strings.Cut(objectRelation, "#")
+	if !ok {
+		return Tuple{}, ErrRelationNotValid
+	}
+	return NewTuple(object, relation, subject)
+}
+
+func (t Tuple) String() string {
+	return t.Object.String() + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+// Revision номер изменения хранилища отношений, растет с каждой записью
+type Revision int64
+
+const tokenPrefix = "rev."
+
+// Token токен согласованности. Клиент передает его в Check, чтобы прочитать свои записи
+func (r Revision) Token() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(tokenPrefix + strconv.FormatInt(int64(r), 10)))
+}
+
+// ParseToken пустой токен - ревизия 0, читать последнее состояние
+func ParseToken(token string) (Revision, error) {
+	if token == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrTokenNotValid
+	}
+	rest, ok := strings.CutPrefix(string(data), tokenPrefix)
+	if !ok {
+		return 0, ErrTokenNotValid
+	}
+	r, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil || r < 0 {
+		return 0, ErrTokenNotValid
+	}
+	return Revision(r), nil
+}
@@ -0,0 +1,67 @@
+package relations
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseTuple(t *testing.T) {
+	cases := []struct {
+		name    string
+		tuple   string
+		want    Tuple
+		wantErr error
+	}{
+		{
+			name:  "user",
+			tuple: "document:readme#editor@user:alice",
+			want: Tuple{
+				Object:   Object{Type: "document", ID: "readme"},
+				Relation: "editor",
+				Subject:  Subject{Object: Object{Type: "user", ID: "alice"}},
+			},
+		},
+		{
+			name:  "userset",
+			tuple: "document:readme#viewer@group:dev#member",
+			want: Tuple{
+				Object:   Object{Type: "document", ID: "readme"},
+				Relation: "viewer",
+				Subject:  Subject{Object: Object{Type: "group", ID: "dev"}, Relation: "member"},
+			},
+		},
+		{name: "without subject", tuple: "document:readme#viewer", wantErr: ErrSubjectNotValid},
+		{name: "without relation", tuple: "document:readme@user:alice", wantErr: ErrRelationNotValid},
+		{name: "without object id", tuple: "document#viewer@user:alice", wantErr: ErrObjectNotValid},
+		{name: "bad subject", tuple: "document:readme#viewer@alice", wantErr: ErrSubjectNotValid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tuple, err := ParseTuple(tt.tuple)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tuple)
+			assert.Equal(t, tt.tuple, tuple.String())
+		})
+	}
+}
+
+func TestRevisionToken(t *testing.T) {
+	revision, err := ParseToken(Revision(42).Token())
+	require.NoError(t, err)
+	assert.Equal(t, Revision(42), revision)
+
+	revision, err = ParseToken("")
+	require.NoError(t, err)
+	assert.Equal(t, Revision(0), revision)
+
+	_, err = ParseToken("not a token")
+	assert.ErrorIs(t, err, ErrTokenNotValid)
+	_, err = ParseToken(Revision(-1).Token())
+	assert.ErrorIs(t, err, ErrTokenNotValid)
+}
@@ -0,0 +1,240 @@
+package relations
+
+import (
+	"context"
+	"fmt"
+)
+
+// MaxDepth глубина вложенности отношений при проверке, защищает от слишком длинных цепочек
+const MaxDepth = 25
+
+// Inherit отношение наследуется от объектов, связанных через Tupleset:
+// viewer документа - это viewer папки из отношения parent
+type Inherit struct {
+	Tupleset string
+	Relation string
+}
+
+// Relation кроме прямых записей включает Computed отношения того же объекта и наследуемые Inherit
+type Relation struct {
+	Name     string
+	Computed []string
+	Inherit  []Inherit
+}
+
+// Namespace тип объектов и его отношения
+type Namespace struct {
+	Name      string
+	Relations []Relation
+}
+
+type Schema struct {
+	namespaces map[string]map[string]Relation
+}
+
+// NewSchema проверяет, что все упомянутые отношения объявлены
+func NewSchema(namespaces []Namespace) (*Schema, error) {
+	s := &Schema{namespaces: make(map[string]map[string]Relation, len(namespaces))}
+	for _, ns := range namespaces {
+		if !validName.MatchString(ns.Name) {
+			return nil, fmt.Errorf("%w: namespace %q", ErrSchemaNotValid, ns.Name)
+		}
+		if _, ok := s.namespaces[ns.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate namespace %q", ErrSchemaNotValid, ns.Name)
+		}
+		relations := make(map[string]Relation, len(ns.Relations))
+		for _, r := range ns.Relations {
+			if !validName.MatchString(r.Name) {
+				return nil, fmt.Errorf("%w: relation %s#%s", ErrSchemaNotValid, ns.Name, r.Name)
+			}
+			if _, ok := relations[r.Name]; ok {
+				return nil, fmt.Errorf("%w: duplicate relation %s#%s", ErrSchemaNotValid, ns.Name, r.Name)
+			}
+			relations[r.Name] = r
+		}
+		s.namespaces[ns.Name] = relations
+	}
+
+	for name, relations := range s.namespaces {
+		for _, r := range relations {
+			for _, c := range r.Computed {
+				if _, ok := relations[c]; !ok {
+					return nil, fmt.Errorf("%w: %s#%s computes unknown relation %q", ErrSchemaNotValid, name, r.Name, c)
+				}
+			}
+			for _, in := range r.Inherit {
+				if _, ok := relations[in.Tupleset]; !ok {
+					return nil, fmt.Errorf("%w: %s#%s inherits through unknown relation %q", ErrSchemaNotValid, name, r.Name, in.Tupleset)
+				}
+				if !validName.MatchString(in.Relation) {
+					return nil, fmt.Errorf("%w: %s#%s inherits relation %q", ErrSchemaNotValid, name, r.Name, in.Relation)
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *Schema) relation(objectType string, relation string) (Relation, error) {
+	relations, ok := s.namespaces[objectType]
+	if !ok {
+		return Relation{}, ErrNamespaceNotFound
+	}
+	r, ok := relations[relation]
+	if !ok {
+		return Relation{}, ErrRelationNotFound
+	}
+	return r, nil
+}
+
+// Validate запись ссылается на объявленные типы и отношения
+func (s *Schema) Validate(t Tuple) error {
+	if _, err := s.relation(t.Object.Type, t.Relation); err != nil {
+		return err
+	}
+	if _, ok := s.namespaces[t.Subject.Type]; !ok {
+		return ErrNamespaceNotFound
+	}
+	if t.Subject.IsUserset() {
+		if _, err := s.relation(t.Subject.Type, t.Subject.Relation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateRelation отношение объявлено для типа
+func (s *Schema) ValidateRelation(objectType string, relation string) error {
+	_, err := s.relation(objectType, relation)
+	return err
+}
+
+// Reader прямые записи object#relation на одной ревизии хранилища
+type Reader interface {
+	Read(ctx context.Context, object Object, relation string) ([]Tuple, error)
+}
+
+// Check есть ли у subject отношение relation к object с учетом вложенных множеств,
+// Computed и Inherit. Циклы в данных не приводят к бесконечной проверке
+func (s *Schema) Check(ctx context.Context, r Reader, object Object, relation string, subject Subject) (bool, error) {
+	return s.check(ctx, r, object, relation, subject, map[string]bool{}, 0)
+}
+
+func (s *Schema) check(ctx context.Context, r Reader, object Object, relation string, subject Subject, path map[string]bool, depth int) (bool, error) {
+	rel, err := s.relation(object.Type, relation)
+	if err != nil {
+		return false, err
+	}
+	if depth > MaxDepth {
+		return false, ErrDepthExceeded
+	}
+	key := object.String() + "#" + relation
+	if path[key] {
+		return false, nil
+	}
+	path[key] = true
+	defer delete(path, key)
+
+	tuples, err := r.Read(ctx, object, relation)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range tuples {
+		if t.Subject == subject {
+			return true, nil
+		}
+	}
+	for _, t := range tuples {
+		if !t.Subject.IsUserset() {
+			continue
+		}
+		ok, err := s.check(ctx, r, t.Subject.Object, t.Subject.Relation, subject, path, depth+1)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	for _, c := range rel.Computed {
+		ok, err := s.check(ctx, r, object, c, subject, path, depth+1)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	for _, in := range rel.Inherit {
+		parents, err := r.Read(ctx, object, in.Tupleset)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range parents {
+			if p.Subject.IsUserset() || s.ValidateRelation(p.Subject.Type, in.Relation) != nil {
+				continue
+			}
+			ok, err := s.check(ctx, r, p.Subject.Object, in.Relation, subject, path, depth+1)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// Node дерево Expand: прямые субъекты отношения и поддеревья Computed и Inherit.
+// Множества среди Subjects не раскрываются, клиент раскрывает их отдельным Expand
+type Node struct {
+	Object   Object
+	Relation string
+	Subjects []Subject
+	Children []*Node
+}
+
+func (s *Schema) Expand(ctx context.Context, r Reader, object Object, relation string) (*Node, error) {
+	return s.expand(ctx, r, object, relation, map[string]bool{}, 0)
+}
+
+func (s *Schema) expand(ctx context.Context, r Reader, object Object, relation string, path map[string]bool, depth int) (*Node, error) {
+	rel, err := s.relation(object.Type, relation)
+	if err != nil {
+		return nil, err
+	}
+	if depth > MaxDepth {
+		return nil, ErrDepthExceeded
+	}
+	node := &Node{Object: object, Relation: relation}
+	key := object.String() + "#" + relation
+	if path[key] {
+		return node, nil
+	}
+	path[key] = true
+	defer delete(path, key)
+
+	tuples, err := r.Read(ctx, object, relation)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tuples {
+		node.Subjects = append(node.Subjects, t.Subject)
+	}
+	for _, c := range rel.Computed {
+		child, err := s.expand(ctx, r, object, c, path, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+	for _, in := range rel.Inherit {
+		parents, err := r.Read(ctx, object, in.Tupleset)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range parents {
+			if p.Subject.IsUserset() || s.ValidateRelation(p.Subject.Type, in.Relation) != nil {
+				continue
+			}
+			child, err := s.expand(ctx, r, p.Subject.Object, in.Relation, path, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+	}
+	return node, nil
+}
@@ -0,0 +1,161 @@
+package relations
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+type memoryReader []Tuple
+
+func (m memoryReader) Read(_ context.Context, object Object, relation string) ([]Tuple, error) {
+	var res []Tuple
+	for _, t := range m {
+		if t.Object == object && t.Relation == relation {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func testSchema(t *testing.T) *Schema {
+	schema, err := NewSchema([]Namespace{
+		{Name: "user"},
+		{Name: "group", Relations: []Relation{{Name: "member"}}},
+		{Name: "folder", Relations: []Relation{
+			{Name: "owner"},
+			{Name: "viewer", Computed: []string{"owner"}},
+		}},
+		{Name: "document", Relations: []Relation{
+			{Name: "parent"},
+			{Name: "owner"},
+			{Name: "editor", Computed: []string{"owner"}},
+			{Name: "viewer", Computed: []string{"editor"}, Inherit: []Inherit{{Tupleset: "parent", Relation: "viewer"}}},
+		}},
+	})
+	require.NoError(t, err)
+	return schema
+}
+
+func tuples(t *testing.T, list ...string) memoryReader {
+	res := make(memoryReader, 0, len(list))
+	for _, s := range list {
+		tuple, err := ParseTuple(s)
+		require.NoError(t, err)
+		res = append(res, tuple)
+	}
+	return res
+}
+
+func TestNewSchema(t *testing.T) {
+	cases := []struct {
+		name       string
+		namespaces []Namespace
+	}{
+		{name: "unknown computed", namespaces: []Namespace{{Name: "doc", Relations: []Relation{{Name: "viewer", Computed: []string{"owner"}}}}}},
+		{name: "unknown tupleset", namespaces: []Namespace{{Name: "doc", Relations: []Relation{{Name: "viewer", Inherit: []Inherit{{Tupleset: "parent", Relation: "viewer"}}}}}}},
+		{name: "duplicate namespace", namespaces: []Namespace{{Name: "doc"}, {Name: "doc"}}},
+		{name: "bad name", namespaces: []Namespace{{Name: "Doc Type"}}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSchema(tt.namespaces)
+			assert.ErrorIs(t, err, ErrSchemaNotValid)
+		})
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema := testSchema(t)
+
+	assert.NoError(t, schema.Validate(tuples(t, "document:a#viewer@group:dev#member")[0]))
+	assert.ErrorIs(t, schema.Validate(tuples(t, "project:a#viewer@user:alice")[0]), ErrNamespaceNotFound)
+	assert.ErrorIs(t, schema.Validate(tuples(t, "document:a#admin@user:alice")[0]), ErrRelationNotFound)
+	assert.ErrorIs(t, schema.Validate(tuples(t, "document:a#viewer@team:dev")[0]), ErrNamespaceNotFound)
+	assert.ErrorIs(t, schema.Validate(tuples(t, "document:a#viewer@group:dev#admin")[0]), ErrRelationNotFound)
+}
+
+func TestSchema_Check(t *testing.T) {
+	schema := testSchema(t)
+	reader := tuples(t,
+		"document:readme#owner@user:alice",
+		"document:readme#editor@group:dev#member",
+		"document:readme#parent@folder:docs",
+		"folder:docs#owner@user:carol",
+		"group:dev#member@user:bob",
+		"group:dev#member@group:ops#member",
+		"group:ops#member@user:dave",
+		// цикл между группами не должен зацикливать проверку
+		"group:ops#member@group:dev#member",
+	)
+
+	cases := []struct {
+		name     string
+		relation string
+		subject  string
+		want     bool
+	}{
+		{name: "direct", relation: "owner", subject: "user:alice", want: true},
+		{name: "computed", relation: "viewer", subject: "user:alice", want: true},
+		{name: "userset", relation: "editor", subject: "user:bob", want: true},
+		{name: "nested userset", relation: "viewer", subject: "user:dave", want: true},
+		{name: "inherited", relation: "viewer", subject: "user:carol", want: true},
+		{name: "inherited does not grant editor", relation: "editor", subject: "user:carol", want: false},
+		{name: "userset subject", relation: "editor", subject: "group:dev#member", want: true},
+		{name: "unknown user", relation: "viewer", subject: "user:eve", want: false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, err := ParseSubject(tt.subject)
+			require.NoError(t, err)
+			ok, err := schema.Check(context.Background(), reader, Object{Type: "document", ID: "readme"}, tt.relation, subject)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ok)
+		})
+	}
+
+	_, err := schema.Check(context.Background(), reader, Object{Type: "document", ID: "readme"}, "admin", Subject{Object: Object{Type: "user", ID: "alice"}})
+	assert.ErrorIs(t, err, ErrRelationNotFound)
+}
+
+func TestSchema_Check_DepthExceeded(t *testing.T) {
+	schema := testSchema(t)
+	var list []string
+	for i := 0; i <= MaxDepth+1; i++ {
+		list = append(list, "group:g"+strconv.Itoa(i)+"#member@group:g"+strconv.Itoa(i+1)+"#member")
+	}
+	reader := tuples(t, list...)
+
+	_, err := schema.Check(context.Background(), reader, Object{Type: "group", ID: "g0"}, "member", Subject{Object: Object{Type: "user", ID: "alice"}})
+	assert.ErrorIs(t, err, ErrDepthExceeded)
+}
+
+func TestSchema_Expand(t *testing.T) {
+	schema := testSchema(t)
+	reader := tuples(t,
+		"document:readme#owner@user:alice",
+		"document:readme#editor@group:dev#member",
+		"document:readme#parent@folder:docs",
+		"folder:docs#owner@user:carol",
+	)
+
+	node, err := schema.Expand(context.Background(), reader, Object{Type: "document", ID: "readme"}, "viewer")
+	require.NoError(t, err)
+	assert.Empty(t, node.Subjects)
+	require.Len(t, node.Children, 2)
+
+	editor := node.Children[0]
+	assert.Equal(t, "editor", editor.Relation)
+	assert.Equal(t, "group:dev#member", editor.Subjects[0].String())
+	require.Len(t, editor.Children, 1)
+	assert.Equal(t, "user:alice", editor.Children[0].Subjects[0].String())
+
+	folder := node.Children[1]
+	assert.Equal(t, Object{Type: "folder", ID: "docs"}, folder.Object)
+	require.Len(t, folder.Children, 1)
+	assert.Equal(t, "user:carol", folder.Children[0].Subjects[0].String())
+}
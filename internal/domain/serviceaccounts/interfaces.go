@@ -0,0 +1,14 @@
+package serviceaccounts
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	// Save сохраняет аккаунт вместе с секретами
+	Save(ctx context.Context, account *ServiceAccount) error
+	Get(ctx context.Context, id uuid.UUID) (*ServiceAccount, error)
+	GetByClientID(ctx context.Context, clientID string) (*ServiceAccount, error)
+	List(ctx context.Context, limit int, offset int) ([]*ServiceAccount, error)
+}
@@ -0,0 +1,236 @@
+package serviceaccounts
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNameRequired       = errors.New("service account name is required")
+	ErrNotFound           = errors.New("service account not found")
+	ErrAuthFailed         = errors.New("service account authentication failed")
+	ErrDisabled           = errors.New("service account is disabled")
+	ErrAlreadyDisabled    = errors.New("service account is already disabled")
+	ErrTooManySecrets     = errors.New("service account has too many active secrets")
+	ErrGracePeriodInvalid = errors.New("secret grace period is not valid")
+)
+
+const (
+	// ClientIDPrefix отличает сервисные аккаунты от клиентов OAuth в /token
+	ClientIDPrefix = "sa_"
+	secretPrefix   = "sas_"
+
+	// MaxActiveSecrets сколько секретов может действовать одновременно во время ротации
+	MaxActiveSecrets = 5
+	// MaxGracePeriod сколько старый секрет может жить после ротации
+	MaxGracePeriod = 30 * 24 * time.Hour
+)
+
+// Secret хранится только hash, сам секрет показывается один раз
+type Secret struct {
+	id         uuid.UUID
+	secretHash string
+	expiresAt  *time.Time
+	createdAt  time.Time
+}
+
+func NewSecret(id uuid.UUID, secretHash string, expiresAt *time.Time, createdAt time.Time) Secret {
+	return Secret{
+		id:         id,
+		secretHash: secretHash,
+		expiresAt:  expiresAt,
+		createdAt:  createdAt,
+	}
+}
+
+func (s Secret) ID() uuid.UUID {
+	return s.id
+}
+func (s Secret) SecretHash() string {
+	return s.secretHash
+}
+func (s Secret) ExpiresAt() *time.Time {
+	return s.expiresAt
+}
+func (s Secret) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+func (s Secret) IsActive(at time.Time) bool {
+	return s.expiresAt == nil || at.Before(*s.expiresAt)
+}
+
+// ServiceAccount машинный принципал для фоновых задач и сервисов
+type ServiceAccount struct {
+	id         uuid.UUID
+	clientID   string
+	name       string
+	role       users.Role
+	secrets    []Secret
+	disabledAt *time.Time
+	createdAt  time.Time
+}
+
+func NewServiceAccount(
+	id uuid.UUID,
+	clientID string,
+	name string,
+	role users.Role,
+	secrets []Secret,
+	disabledAt *time.Time,
+	createdAt time.Time,
+) *ServiceAccount {
+	return &ServiceAccount{
+		id:         id,
+		clientID:   clientID,
+		name:       name,
+		role:       role,
+		secrets:    secrets,
+		disabledAt: disabledAt,
+		createdAt:  createdAt,
+	}
+}
+
+// CreateServiceAccount возвращает аккаунт и его первый секрет
+func CreateServiceAccount(name string, role users.Role) (*ServiceAccount, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrNameRequired
+	}
+	role, err := users.NewRole(role.String())
+	if err != nil {
+		return nil, "", err
+	}
+	clientID, err := randomString(16)
+	if err != nil {
+		return nil, "", err
+	}
+	sa := NewServiceAccount(uuid.New(), ClientIDPrefix+clientID, name, role, nil, nil, time.Now().UTC())
+	secret, err := sa.addSecret(sa.createdAt)
+	if err != nil {
+		return nil, "", err
+	}
+	return sa, secret, nil
+}
+
+func IsClientID(clientID string) bool {
+	return strings.HasPrefix(clientID, ClientIDPrefix)
+}
+
+func (s *ServiceAccount) ID() uuid.UUID {
+	return s.id
+}
+func (s *ServiceAccount) ClientID() string {
+	return s.clientID
+}
+func (s *ServiceAccount) Name() string {
+	return s.name
+}
+func (s *ServiceAccount) Role() users.Role {
+	return s.role
+}
+func (s *ServiceAccount) Secrets() []Secret {
+	return s.secrets
+}
+func (s *ServiceAccount) DisabledAt() *time.Time {
+	return s.disabledAt
+}
+func (s *ServiceAccount) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+func (s *ServiceAccount) IsDisabled() bool {
+	return s.disabledAt != nil
+}
+
+// ActiveSecrets секреты, которыми можно войти в момент at
+func (s *ServiceAccount) ActiveSecrets(at time.Time) []Secret {
+	res := make([]Secret, 0, len(s.secrets))
+	for _, secret := range s.secrets {
+		if secret.IsActive(at) {
+			res = append(res, secret)
+		}
+	}
+	return res
+}
+
+// Authenticate сравнивает секрет со всеми действующими, чтобы во время ротации работали и старый, и новый
+func (s *ServiceAccount) Authenticate(secret string, at time.Time) error {
+	hash := oauth.HashToken(secret)
+	matched := false
+	for _, active := range s.ActiveSecrets(at) {
+		if subtle.ConstantTimeCompare([]byte(active.secretHash), []byte(hash)) == 1 {
+			matched = true
+		}
+	}
+	if !matched {
+		return ErrAuthFailed
+	}
+	if s.IsDisabled() {
+		return ErrDisabled
+	}
+	return nil
+}
+
+// RotateSecret выпускает новый секрет, действующие секреты истекают через gracePeriod.
+// gracePeriod 0 отзывает старые секреты сразу
+func (s *ServiceAccount) RotateSecret(gracePeriod time.Duration) (string, error) {
+	if s.IsDisabled() {
+		return "", ErrDisabled
+	}
+	if gracePeriod < 0 || gracePeriod > MaxGracePeriod {
+		return "", ErrGracePeriodInvalid
+	}
+	now := time.Now().UTC()
+	if gracePeriod > 0 && len(s.ActiveSecrets(now)) >= MaxActiveSecrets {
+		return "", ErrTooManySecrets
+	}
+	expiresAt := now.Add(gracePeriod)
+	for i, secret := range s.secrets {
+		if !secret.IsActive(now) {
+			continue
+		}
+		if secret.expiresAt == nil || secret.expiresAt.After(expiresAt) {
+			s.secrets[i].expiresAt = &expiresAt
+		}
+	}
+	return s.addSecret(now)
+}
+
+// Disable выданные токены доступа продолжают действовать до истечения, новые не выдаются
+func (s *ServiceAccount) Disable() error {
+	if s.IsDisabled() {
+		return ErrAlreadyDisabled
+	}
+	now := time.Now().UTC()
+	s.disabledAt = &now
+	return nil
+}
+
+func (s *ServiceAccount) addSecret(at time.Time) (string, error) {
+	if len(s.ActiveSecrets(at)) >= MaxActiveSecrets {
+		return "", ErrTooManySecrets
+	}
+	raw, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+	secret := secretPrefix + raw
+	s.secrets = append(s.secrets, NewSecret(uuid.New(), oauth.HashToken(secret), nil, at))
+	return secret, nil
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,88 @@
+package serviceaccounts
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateServiceAccount(t *testing.T) {
+	cases := []struct {
+		name    string
+		saName  string
+		role    users.Role
+		wantErr error
+	}{
+		{name: "ok", saName: "billing", role: users.RoleUser},
+		{name: "empty name", saName: "  ", role: users.RoleUser, wantErr: ErrNameRequired},
+		{name: "unknown role", saName: "billing", role: "root", wantErr: users.ErrRoleNotValid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			account, secret, err := CreateServiceAccount(tt.saName, tt.role)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, IsClientID(account.ClientID()))
+			assert.True(t, strings.HasPrefix(secret, secretPrefix))
+			assert.NoError(t, account.Authenticate(secret, time.Now().UTC()))
+			assert.ErrorIs(t, account.Authenticate("sas_wrong", time.Now().UTC()), ErrAuthFailed)
+		})
+	}
+}
+
+func TestServiceAccount_RotateSecret(t *testing.T) {
+	account, old, err := CreateServiceAccount("billing", users.RoleUser)
+	require.NoError(t, err)
+
+	_, err = account.RotateSecret(-time.Second)
+	assert.ErrorIs(t, err, ErrGracePeriodInvalid)
+
+	secret, err := account.RotateSecret(time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	assert.NoError(t, account.Authenticate(old, now))
+	assert.NoError(t, account.Authenticate(secret, now))
+	assert.Len(t, account.ActiveSecrets(now), 2)
+
+	later := now.Add(2 * time.Hour)
+	assert.ErrorIs(t, account.Authenticate(old, later), ErrAuthFailed)
+	assert.NoError(t, account.Authenticate(secret, later))
+
+	// без grace period старые секреты отзываются сразу
+	next, err := account.RotateSecret(0)
+	require.NoError(t, err)
+	now = time.Now().UTC()
+	assert.ErrorIs(t, account.Authenticate(secret, now), ErrAuthFailed)
+	assert.NoError(t, account.Authenticate(next, now))
+}
+
+func TestServiceAccount_RotateSecretLimit(t *testing.T) {
+	account, _, err := CreateServiceAccount("billing", users.RoleUser)
+	require.NoError(t, err)
+
+	for i := 1; i < MaxActiveSecrets; i++ {
+		_, err = account.RotateSecret(time.Hour)
+		require.NoError(t, err)
+	}
+	_, err = account.RotateSecret(time.Hour)
+	assert.ErrorIs(t, err, ErrTooManySecrets)
+}
+
+func TestServiceAccount_Disable(t *testing.T) {
+	account, secret, err := CreateServiceAccount("billing", users.RoleUser)
+	require.NoError(t, err)
+
+	require.NoError(t, account.Disable())
+	assert.ErrorIs(t, account.Disable(), ErrAlreadyDisabled)
+	assert.ErrorIs(t, account.Authenticate(secret, time.Now().UTC()), ErrDisabled)
+	_, err = account.RotateSecret(time.Hour)
+	assert.ErrorIs(t, err, ErrDisabled)
+}
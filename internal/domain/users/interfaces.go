@@ -3,6 +3,7 @@ package users
 import (
 	"context"
 	"github.com/google/uuid"
+	"time"
 )
 
 type PasswordHasher interface {
@@ -13,14 +14,19 @@ type PasswordVerifier interface {
 	Verify(passwordHash []byte, password []byte) (bool, error)
 }
 
+// UserRepository все запросы ограничены тенантом, Save берет его из пользователя
 type UserRepository interface {
 	Save(ctx context.Context, user *User) error
-	Get(ctx context.Context, id uuid.UUID) (*User, error)
-	GetByEmail(ctx context.Context, email Email) (*User, error)
-	GetAll(ctx context.Context) ([]*User, error)
-	ExistsByEmail(ctx context.Context, email Email) (bool, error)
+	// Get tenantID может быть AnyTenant
+	Get(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) (*User, error)
+	GetByEmail(ctx context.Context, tenantID uuid.UUID, email Email) (*User, error)
+	GetAll(ctx context.Context, tenantID uuid.UUID) ([]*User, error)
+	ExistsByEmail(ctx context.Context, tenantID uuid.UUID, email Email) (bool, error)
+	// ListDeletedBefore удаленные до before пользователи всех тенантов, для удаления персональных данных
+	ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*User, error)
 }
 
 type TokenGenerator interface {
-	GenerateToken(userID uuid.UUID) (string, error)
+	// GenerateToken groups nil, если группы не попадают в токен
+	GenerateToken(userID uuid.UUID, tenantID uuid.UUID, role Role, groups []string) (string, error)
 }
@@ -12,6 +12,7 @@ package mock_users
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	users "github.com/LeoUraltsev/auth-service/internal/domain/users"
 	uuid "github.com/google/uuid"
@@ -121,63 +122,78 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 }
 
 // ExistsByEmail mocks base method.
-func (m *MockUserRepository) ExistsByEmail(ctx context.Context, email users.Email) (bool, error) {
+func (m *MockUserRepository) ExistsByEmail(ctx context.Context, tenantID uuid.UUID, email users.Email) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExistsByEmail", ctx, email)
+	ret := m.ctrl.Call(m, "ExistsByEmail", ctx, tenantID, email)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ExistsByEmail indicates an expected call of ExistsByEmail.
-func (mr *MockUserRepositoryMockRecorder) ExistsByEmail(ctx, email any) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) ExistsByEmail(ctx, tenantID, email any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistsByEmail", reflect.TypeOf((*MockUserRepository)(nil).ExistsByEmail), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistsByEmail", reflect.TypeOf((*MockUserRepository)(nil).ExistsByEmail), ctx, tenantID, email)
 }
 
 // Get mocks base method.
-func (m *MockUserRepository) Get(ctx context.Context, id uuid.UUID) (*users.User, error) {
+func (m *MockUserRepository) Get(ctx context.Context, tenantID, id uuid.UUID) (*users.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret := m.ctrl.Call(m, "Get", ctx, tenantID, id)
 	ret0, _ := ret[0].(*users.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockUserRepositoryMockRecorder) Get(ctx, id any) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) Get(ctx, tenantID, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserRepository)(nil).Get), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserRepository)(nil).Get), ctx, tenantID, id)
 }
 
 // GetAll mocks base method.
-func (m *MockUserRepository) GetAll(ctx context.Context) ([]*users.User, error) {
+func (m *MockUserRepository) GetAll(ctx context.Context, tenantID uuid.UUID) ([]*users.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", ctx)
+	ret := m.ctrl.Call(m, "GetAll", ctx, tenantID)
 	ret0, _ := ret[0].([]*users.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockUserRepositoryMockRecorder) GetAll(ctx any) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) GetAll(ctx, tenantID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockUserRepository)(nil).GetAll), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockUserRepository)(nil).GetAll), ctx, tenantID)
 }
 
 // GetByEmail mocks base method.
-func (m *MockUserRepository) GetByEmail(ctx context.Context, email users.Email) (*users.User, error) {
+func (m *MockUserRepository) GetByEmail(ctx context.Context, tenantID uuid.UUID, email users.Email) (*users.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByEmail", ctx, email)
+	ret := m.ctrl.Call(m, "GetByEmail", ctx, tenantID, email)
 	ret0, _ := ret[0].(*users.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByEmail indicates an expected call of GetByEmail.
-func (mr *MockUserRepositoryMockRecorder) GetByEmail(ctx, email any) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) GetByEmail(ctx, tenantID, email any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetByEmail), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetByEmail), ctx, tenantID, email)
+}
+
+// ListDeletedBefore mocks base method.
+func (m *MockUserRepository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*users.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeletedBefore", ctx, before, limit)
+	ret0, _ := ret[0].([]*users.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeletedBefore indicates an expected call of ListDeletedBefore.
+func (mr *MockUserRepositoryMockRecorder) ListDeletedBefore(ctx, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeletedBefore", reflect.TypeOf((*MockUserRepository)(nil).ListDeletedBefore), ctx, before, limit)
 }
 
 // Save mocks base method.
@@ -219,16 +235,16 @@ func (m *MockTokenGenerator) EXPECT() *MockTokenGeneratorMockRecorder {
 }
 
 // GenerateToken mocks base method.
-func (m *MockTokenGenerator) GenerateToken(userID uuid.UUID) (string, error) {
+func (m *MockTokenGenerator) GenerateToken(userID, tenantID uuid.UUID, role users.Role, groups []string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateToken", userID)
+	ret := m.ctrl.Call(m, "GenerateToken", userID, tenantID, role, groups)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateToken indicates an expected call of GenerateToken.
-func (mr *MockTokenGeneratorMockRecorder) GenerateToken(userID any) *gomock.Call {
+func (mr *MockTokenGeneratorMockRecorder) GenerateToken(userID, tenantID, role, groups any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateToken), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateToken), userID, tenantID, role, groups)
 }
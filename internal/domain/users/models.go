@@ -14,11 +14,31 @@ var (
 	ErrPasswordRequired   = errors.New("passwordHash is required")
 	ErrPasswordTooShort   = errors.New("password is too short")
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrRoleNotValid       = errors.New("role is not valid")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrTenantRequired     = errors.New("tenant is required")
+
+	ErrUserNotActive          = errors.New("user is not active")
+	ErrUserNotVerified        = errors.New("user is not verified")
+	ErrUserLocked             = errors.New("user is locked")
+	ErrUserAlreadyActive      = errors.New("user is already active")
+	ErrUserSuspended          = errors.New("user is suspended")
+	ErrSuspendReasonRequired  = errors.New("suspension reason is required")
+	ErrSuspendUntilNotValid   = errors.New("suspension end must be in the future")
+	ErrPasswordChangeRequired = errors.New("password change required")
+	// ErrPasswordChangeNotRequired обмен временного пароля, когда он не выдавался
+	ErrPasswordChangeNotRequired = errors.New("password change is not required")
+	ErrPasswordNotChanged        = errors.New("new password must differ from the current one")
 )
 
+// AnyTenant для поиска по id из записи, которая сама привязана к пользователю:
+// refresh токен, API ключ, связанная учетная запись. Запросы клиентов всегда ограничены своим тенантом
+var AnyTenant = uuid.Nil
+
 var validEmail = regexp.MustCompile("^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+.[a-zA-Z]{2,}$")
 
 type Name string
+type Role string
 type Email struct {
 	value string
 }
@@ -27,23 +47,59 @@ type Password struct {
 }
 type User struct {
 	id           uuid.UUID
+	tenantID     uuid.UUID
 	name         Name
 	email        Email
 	passwordHash Password
-	isActive     bool
-	createdAt    time.Time
-	updatedAt    time.Time
+	role         Role
+	status       Status
+	// statusChangedAt время последнего перехода статуса
+	statusChangedAt time.Time
+	access          Access
+	createdAt       time.Time
+	updatedAt       time.Time
+}
+
+// Suspension блокировка администратором, без Until действует до ReactivateUser
+type Suspension struct {
+	Reason string
+	Since  time.Time
+	Until  time.Time
 }
 
+// ActiveAt блокировка с истекшим Until больше не действует
+func (s *Suspension) ActiveAt(at time.Time) bool {
+	return s != nil && (s.Until.IsZero() || at.Before(s.Until))
+}
+
+// Access ограничения входа, которые задает администратор
+type Access struct {
+	Suspension *Suspension
+	// PasswordChangeRequired временный пароль, Login отклоняется до его замены
+	PasswordChangeRequired bool
+	// SessionsRevokedAt токены, выданные раньше, считаются отозванными
+	SessionsRevokedAt time.Time
+}
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
 func NewUser(
 	id uuid.UUID,
+	tenantID uuid.UUID,
 	name Name,
 	email Email,
 	passwordHash Password,
-	isActive bool,
+	role Role,
+	status Status,
 	createdAt time.Time,
 	updatedAt time.Time,
 ) (*User, error) {
+	if tenantID == uuid.Nil {
+		return nil, ErrTenantRequired
+	}
 	if err := email.validate(); err != nil {
 		return nil, err
 	}
@@ -53,29 +109,42 @@ func NewUser(
 	if err := passwordHash.validate(); err != nil {
 		return nil, err
 	}
+	if err := role.validate(); err != nil {
+		return nil, err
+	}
+	if err := status.validate(); err != nil {
+		return nil, err
+	}
 	return &User{
 		id:           id,
+		tenantID:     tenantID,
 		name:         name,
 		email:        email,
 		passwordHash: passwordHash,
-		isActive:     isActive,
+		role:         role,
+		status:       status,
 		createdAt:    createdAt,
 		updatedAt:    updatedAt,
 	}, nil
 }
 
+// CreateUser tenantID организация, email уникален только внутри нее
 func CreateUser(
+	tenantID uuid.UUID,
 	name Name,
 	email Email,
 	password Password,
 ) (*User, error) {
 	id := uuid.New()
-	return NewUser(id, name, email, password, true, time.Now().UTC(), time.Now().UTC())
+	return NewUser(id, tenantID, name, email, password, RoleUser, StatusActive, time.Now().UTC(), time.Now().UTC())
 }
 
 func (u *User) ID() uuid.UUID {
 	return u.id
 }
+func (u *User) TenantID() uuid.UUID {
+	return u.tenantID
+}
 func (u *User) Name() Name {
 	return u.name
 }
@@ -85,8 +154,61 @@ func (u *User) Email() Email {
 func (u *User) Password() Password {
 	return u.passwordHash
 }
-func (u *User) IsActive() bool {
-	return u.isActive
+func (u *User) Role() Role {
+	return u.role
+}
+func (u *User) IsAdmin() bool {
+	return u.role == RoleAdmin
+}
+func (u *User) Access() Access {
+	return u.access
+}
+
+// WithAccess восстанавливает ограничения входа из хранилища
+func (u *User) WithAccess(access Access) *User {
+	u.access = access
+	return u
+}
+
+// TokenRevoked токен выдан до принудительного выхода. iat в токене с точностью до секунды
+func (u *User) TokenRevoked(issuedAt time.Time) bool {
+	revokedAt := u.access.SessionsRevokedAt
+	return !revokedAt.IsZero() && issuedAt.Before(revokedAt.Truncate(time.Second))
+}
+
+// ForceLogout отзывает все выданные пользователю токены
+func (u *User) ForceLogout(at time.Time) {
+	u.access.SessionsRevokedAt = at
+	u.updatedAt = at
+}
+
+// SetTemporaryPassword пароль от администратора, пользователь обязан сменить его при следующем входе
+func (u *User) SetTemporaryPassword(password Password, at time.Time) error {
+	if u.IsDeleted() || u.IsErased() {
+		return ErrUserNotActive
+	}
+	if err := password.validate(); err != nil {
+		return err
+	}
+	u.passwordHash = password
+	u.access.PasswordChangeRequired = true
+	u.access.SessionsRevokedAt = at
+	u.updatedAt = at
+	return nil
+}
+
+// ReassignEmail смена email администратором, сессии со старым email завершаются
+func (u *User) ReassignEmail(email Email, at time.Time) error {
+	if u.IsDeleted() || u.IsErased() {
+		return ErrUserNotActive
+	}
+	if err := email.validate(); err != nil {
+		return err
+	}
+	u.email = email
+	u.access.SessionsRevokedAt = at
+	u.updatedAt = at
+	return nil
 }
 
 func (u *User) CreatedAt() time.Time {
@@ -112,12 +234,16 @@ func (u *User) UpdatePassword(password Password) error {
 		return err
 	}
 	u.passwordHash = password
+	u.access.PasswordChangeRequired = false
 	u.updatedAt = time.Now().UTC()
 	return nil
 }
 
-func (u *User) Delete() error {
-	u.isActive = false
+func (u *User) UpdateRole(role Role) error {
+	if err := role.validate(); err != nil {
+		return err
+	}
+	u.role = role
 	u.updatedAt = time.Now().UTC()
 	return nil
 }
@@ -171,6 +297,27 @@ func (p Password) Hash() []byte {
 	return p.hash
 }
 
+func NewRole(role string) (Role, error) {
+	r := Role(role)
+	if err := r.validate(); err != nil {
+		return "", err
+	}
+	return r, nil
+}
+
+func (r Role) validate() error {
+	switch r {
+	case RoleUser, RoleAdmin:
+		return nil
+	default:
+		return ErrRoleNotValid
+	}
+}
+
+func (r Role) String() string {
+	return string(r)
+}
+
 func NewName(name string) (Name, error) {
 	n := Name(name)
 	if err := n.validate(); err != nil {
@@ -191,6 +338,9 @@ func (n Name) String() string {
 }
 
 func (u *User) UpdateName(name Name) error {
+	if err := name.validate(); err != nil {
+		return err
+	}
 	u.name = name
 	u.updatedAt = time.Now().UTC()
 	return nil
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"reflect"
@@ -67,7 +68,7 @@ func TestCreateUser(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := CreateUser(tt.args.name, tt.args.email, tt.args.password)
+			_, err := CreateUser(uuid.New(), tt.args.name, tt.args.email, tt.args.password)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CreateUser() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -299,16 +300,19 @@ func TestNewPassword(t *testing.T) {
 func TestNewUser(t *testing.T) {
 	type args struct {
 		id           uuid.UUID
+		tenantID     uuid.UUID
 		name         Name
 		email        Email
 		passwordHash Password
-		isActive     bool
+		role         Role
+		status       Status
 		createdAt    time.Time
 		updatedAt    time.Time
 	}
 
 	timeNow := time.Now()
 	uuidNew := uuid.New()
+	tenantNew := uuid.New()
 	emailNew := Email{value: "successemail@gmail.com"}
 	passwordNew := Password{[]byte("password")}
 	tests := []struct {
@@ -321,28 +325,63 @@ func TestNewUser(t *testing.T) {
 			name: "success",
 			args: args{
 				id:           uuidNew,
+				tenantID:     tenantNew,
 				name:         "Leonard",
 				email:        emailNew,
 				passwordHash: passwordNew,
-				isActive:     true,
+				role:         RoleUser,
+				status:       StatusActive,
 				createdAt:    timeNow,
 				updatedAt:    timeNow,
 			},
 			want: &User{
 				id:           uuidNew,
+				tenantID:     tenantNew,
 				name:         "Leonard",
 				email:        emailNew,
 				passwordHash: passwordNew,
-				isActive:     true,
+				role:         RoleUser,
+				status:       StatusActive,
 				createdAt:    timeNow,
 				updatedAt:    timeNow,
 			},
 			wantErr: false,
 		},
+		{
+			name: "without tenant",
+			args: args{
+				id:           uuidNew,
+				name:         "Leonard",
+				email:        emailNew,
+				passwordHash: passwordNew,
+				role:         RoleUser,
+				status:       StatusActive,
+				createdAt:    timeNow,
+				updatedAt:    timeNow,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "unknown role",
+			args: args{
+				id:           uuidNew,
+				tenantID:     tenantNew,
+				name:         "Leonard",
+				email:        emailNew,
+				passwordHash: passwordNew,
+				role:         Role("root"),
+				status:       StatusActive,
+				createdAt:    timeNow,
+				updatedAt:    timeNow,
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewUser(tt.args.id, tt.args.name, tt.args.email, tt.args.passwordHash, tt.args.isActive, tt.args.createdAt, tt.args.updatedAt)
+			got, err := NewUser(tt.args.id, tt.args.tenantID, tt.args.name, tt.args.email, tt.args.passwordHash, tt.args.role, tt.args.status, tt.args.createdAt, tt.args.updatedAt)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewUser() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -403,7 +442,7 @@ func TestUser_CreatedAt(t *testing.T) {
 		name         Name
 		email        Email
 		passwordHash Password
-		isActive     bool
+		status       Status
 		createdAt    time.Time
 		updatedAt    time.Time
 	}
@@ -420,7 +459,7 @@ func TestUser_CreatedAt(t *testing.T) {
 				name:         "Leonard",
 				email:        Email{value: "success@gmail.com"},
 				passwordHash: Password{[]byte("password")},
-				isActive:     true,
+				status:       StatusActive,
 				createdAt:    successCreatedTime,
 				updatedAt:    time.Now().UTC(),
 			},
@@ -434,7 +473,7 @@ func TestUser_CreatedAt(t *testing.T) {
 				name:         tt.fields.name,
 				email:        tt.fields.email,
 				passwordHash: tt.fields.passwordHash,
-				isActive:     tt.fields.isActive,
+				status:       tt.fields.status,
 				createdAt:    tt.fields.createdAt,
 				updatedAt:    tt.fields.updatedAt,
 			}
@@ -451,7 +490,7 @@ func TestUser_Email(t *testing.T) {
 		name         Name
 		email        Email
 		passwordHash Password
-		isActive     bool
+		status       Status
 		createdAt    time.Time
 		updatedAt    time.Time
 	}
@@ -475,7 +514,7 @@ func TestUser_Email(t *testing.T) {
 				name:         tt.fields.name,
 				email:        tt.fields.email,
 				passwordHash: tt.fields.passwordHash,
-				isActive:     tt.fields.isActive,
+				status:       tt.fields.status,
 				createdAt:    tt.fields.createdAt,
 				updatedAt:    tt.fields.updatedAt,
 			}
@@ -492,7 +531,7 @@ func TestUser_ID(t *testing.T) {
 		name         Name
 		email        Email
 		passwordHash Password
-		isActive     bool
+		status       Status
 		createdAt    time.Time
 		updatedAt    time.Time
 	}
@@ -517,7 +556,7 @@ func TestUser_ID(t *testing.T) {
 				name:         tt.fields.name,
 				email:        tt.fields.email,
 				passwordHash: tt.fields.passwordHash,
-				isActive:     tt.fields.isActive,
+				status:       tt.fields.status,
 				createdAt:    tt.fields.createdAt,
 				updatedAt:    tt.fields.updatedAt,
 			}
@@ -534,7 +573,7 @@ func TestUser_IsActive(t *testing.T) {
 		name         Name
 		email        Email
 		passwordHash Password
-		isActive     bool
+		status       Status
 		createdAt    time.Time
 		updatedAt    time.Time
 	}
@@ -546,10 +585,17 @@ func TestUser_IsActive(t *testing.T) {
 		{
 			name: "success",
 			fields: fields{
-				isActive: true,
+				status: StatusActive,
 			},
 			want: true,
 		},
+		{
+			name: "suspended",
+			fields: fields{
+				status: StatusSuspended,
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -558,7 +604,7 @@ func TestUser_IsActive(t *testing.T) {
 				name:         tt.fields.name,
 				email:        tt.fields.email,
 				passwordHash: tt.fields.passwordHash,
-				isActive:     tt.fields.isActive,
+				status:       tt.fields.status,
 				createdAt:    tt.fields.createdAt,
 				updatedAt:    tt.fields.updatedAt,
 			}
@@ -575,7 +621,7 @@ func TestUser_Name(t *testing.T) {
 		name         Name
 		email        Email
 		passwordHash Password
-		isActive     bool
+		status       Status
 		createdAt    time.Time
 		updatedAt    time.Time
 	}
@@ -599,7 +645,7 @@ func TestUser_Name(t *testing.T) {
 				name:         tt.fields.name,
 				email:        tt.fields.email,
 				passwordHash: tt.fields.passwordHash,
-				isActive:     tt.fields.isActive,
+				status:       tt.fields.status,
 				createdAt:    tt.fields.createdAt,
 				updatedAt:    tt.fields.updatedAt,
 			}
@@ -616,7 +662,7 @@ func TestUser_Password(t *testing.T) {
 		name         Name
 		email        Email
 		passwordHash Password
-		isActive     bool
+		status       Status
 		createdAt    time.Time
 		updatedAt    time.Time
 	}
@@ -640,7 +686,7 @@ func TestUser_Password(t *testing.T) {
 				name:         tt.fields.name,
 				email:        tt.fields.email,
 				passwordHash: tt.fields.passwordHash,
-				isActive:     tt.fields.isActive,
+				status:       tt.fields.status,
 				createdAt:    tt.fields.createdAt,
 				updatedAt:    tt.fields.updatedAt,
 			}
@@ -657,7 +703,7 @@ func TestUser_UpdateEmail(t *testing.T) {
 		name         Name
 		email        Email
 		passwordHash Password
-		isActive     bool
+		status       Status
 		createdAt    time.Time
 		updatedAt    time.Time
 	}
@@ -682,7 +728,7 @@ func TestUser_UpdateEmail(t *testing.T) {
 				passwordHash: Password{
 					[]byte("password"),
 				},
-				isActive:  true,
+				status:    StatusActive,
 				createdAt: time.Now(),
 				updatedAt: time.Now(),
 			},
@@ -704,7 +750,7 @@ func TestUser_UpdateEmail(t *testing.T) {
 				passwordHash: Password{
 					[]byte("password"),
 				},
-				isActive:  true,
+				status:    StatusActive,
 				createdAt: time.Now(),
 				updatedAt: time.Now(),
 			},
@@ -721,7 +767,7 @@ func TestUser_UpdateEmail(t *testing.T) {
 				name:         tt.fields.name,
 				email:        tt.fields.email,
 				passwordHash: tt.fields.passwordHash,
-				isActive:     tt.fields.isActive,
+				status:       tt.fields.status,
 				createdAt:    tt.fields.createdAt,
 				updatedAt:    tt.fields.updatedAt,
 			}
@@ -744,7 +790,7 @@ func TestUser_UpdatePassword(t *testing.T) {
 		name         Name
 		email        Email
 		passwordHash Password
-		isActive     bool
+		status       Status
 		createdAt    time.Time
 		updatedAt    time.Time
 	}
@@ -768,7 +814,7 @@ func TestUser_UpdatePassword(t *testing.T) {
 				passwordHash: Password{
 					[]byte("password"),
 				},
-				isActive:  true,
+				status:    StatusActive,
 				createdAt: time.Now(),
 				updatedAt: time.Now(),
 			},
@@ -790,7 +836,7 @@ func TestUser_UpdatePassword(t *testing.T) {
 				passwordHash: Password{
 					[]byte("password"),
 				},
-				isActive:  true,
+				status:    StatusActive,
 				createdAt: time.Now(),
 				updatedAt: time.Now(),
 			},
@@ -809,7 +855,7 @@ func TestUser_UpdatePassword(t *testing.T) {
 				name:         tt.fields.name,
 				email:        tt.fields.email,
 				passwordHash: tt.fields.passwordHash,
-				isActive:     tt.fields.isActive,
+				status:       tt.fields.status,
 				createdAt:    tt.fields.createdAt,
 				updatedAt:    tt.fields.updatedAt,
 			}
@@ -826,7 +872,7 @@ func TestUser_UpdatedAt(t *testing.T) {
 		name         Name
 		email        Email
 		passwordHash Password
-		isActive     bool
+		status       Status
 		createdAt    time.Time
 		updatedAt    time.Time
 	}
@@ -845,7 +891,7 @@ func TestUser_UpdatedAt(t *testing.T) {
 				name:         "Leonard",
 				email:        Email{value: "success@gmail.com"},
 				passwordHash: Password{[]byte("password")},
-				isActive:     true,
+				status:       StatusActive,
 				createdAt:    time.Now().UTC(),
 				updatedAt:    successUpdatedAt,
 			},
@@ -859,7 +905,7 @@ func TestUser_UpdatedAt(t *testing.T) {
 				name:         tt.fields.name,
 				email:        tt.fields.email,
 				passwordHash: tt.fields.passwordHash,
-				isActive:     tt.fields.isActive,
+				status:       tt.fields.status,
 				createdAt:    tt.fields.createdAt,
 				updatedAt:    tt.fields.updatedAt,
 			}
@@ -869,3 +915,111 @@ func TestUser_UpdatedAt(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_Suspend(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name    string
+		reason  string
+		until   time.Time
+		deleted bool
+		wantErr error
+	}{
+		{name: "indefinitely", reason: "spam"},
+		{name: "until", reason: "spam", until: now.Add(time.Hour)},
+		{name: "reason required", reason: "  ", wantErr: ErrSuspendReasonRequired},
+		{name: "until in past", reason: "spam", until: now.Add(-time.Hour), wantErr: ErrSuspendUntilNotValid},
+		{name: "deleted", reason: "spam", deleted: true, wantErr: ErrStatusTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := CreateUser(uuid.New(), "user", Email{value: "user@gmail.com"}, Password{hash: []byte("hash")})
+			if tt.deleted {
+				_ = u.Delete(now)
+			}
+			err := u.Suspend(tt.reason, tt.until, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Suspend() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !errors.Is(u.CanAuthenticate(now), ErrUserSuspended) {
+				t.Errorf("CanAuthenticate() must reject suspended user")
+			}
+			if !u.TokenRevoked(now.Add(-time.Second)) {
+				t.Errorf("TokenRevoked() must revoke tokens issued before suspension")
+			}
+			if !tt.until.IsZero() && u.CanAuthenticate(tt.until.Add(time.Second)) != nil {
+				t.Errorf("CanAuthenticate() must allow user after suspension end")
+			}
+		})
+	}
+}
+
+func TestUser_Reactivate(t *testing.T) {
+	now := time.Now().UTC()
+	u, _ := CreateUser(uuid.New(), "user", Email{value: "user@gmail.com"}, Password{hash: []byte("hash")})
+	if err := u.Reactivate(now); !errors.Is(err, ErrUserAlreadyActive) {
+		t.Fatalf("Reactivate() error = %v, want %v", err, ErrUserAlreadyActive)
+	}
+
+	_ = u.Suspend("spam", time.Time{}, now)
+	if err := u.Reactivate(now); err != nil {
+		t.Fatalf("Reactivate() error = %v", err)
+	}
+	if err := u.CanAuthenticate(now); err != nil {
+		t.Errorf("CanAuthenticate() error = %v", err)
+	}
+
+	_ = u.Delete(now)
+	if err := u.Reactivate(now); err != nil {
+		t.Fatalf("Reactivate() error = %v", err)
+	}
+	if !u.IsActive() {
+		t.Errorf("Reactivate() must restore deleted user")
+	}
+}
+
+func TestUser_SetTemporaryPassword(t *testing.T) {
+	now := time.Now().UTC()
+	u, _ := CreateUser(uuid.New(), "user", Email{value: "user@gmail.com"}, Password{hash: []byte("hash")})
+	if err := u.SetTemporaryPassword(Password{hash: []byte("temporary")}, now); err != nil {
+		t.Fatalf("SetTemporaryPassword() error = %v", err)
+	}
+	if !u.Access().PasswordChangeRequired {
+		t.Fatalf("SetTemporaryPassword() must require password change")
+	}
+	if err := u.UpdatePassword(Password{hash: []byte("new")}); err != nil {
+		t.Fatalf("UpdatePassword() error = %v", err)
+	}
+	if u.Access().PasswordChangeRequired {
+		t.Errorf("UpdatePassword() must clear password change requirement")
+	}
+}
+
+func TestUser_TokenRevoked(t *testing.T) {
+	revokedAt := time.Date(2025, 1, 1, 12, 0, 0, 500, time.UTC)
+	u, _ := CreateUser(uuid.New(), "user", Email{value: "user@gmail.com"}, Password{hash: []byte("hash")})
+	if u.TokenRevoked(revokedAt) {
+		t.Fatalf("TokenRevoked() without force logout")
+	}
+	u.ForceLogout(revokedAt)
+	if !u.TokenRevoked(revokedAt.Add(-time.Second)) {
+		t.Errorf("TokenRevoked() token issued before logout")
+	}
+	// iat без долей секунды, токен из той же секунды остается действительным
+	if u.TokenRevoked(revokedAt.Truncate(time.Second)) {
+		t.Errorf("TokenRevoked() token issued at logout second")
+	}
+}
+
+func TestUser_UpdateName(t *testing.T) {
+	u := &User{name: "user"}
+	if err := u.UpdateName(""); !errors.Is(err, ErrNameRequired) {
+		t.Fatalf("UpdateName() error = %v, want %v", err, ErrNameRequired)
+	}
+	if u.Name() != "user" {
+		t.Errorf("UpdateName() changed name on error: %v", u.Name())
+	}
+}
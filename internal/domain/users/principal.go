@@ -0,0 +1,16 @@
+package users
+
+// PrincipalType кто стоит за токеном доступа
+type PrincipalType string
+
+const (
+	PrincipalUser PrincipalType = "user"
+	// PrincipalServiceAccount сервисный аккаунт, токен выдан по client_credentials
+	PrincipalServiceAccount PrincipalType = "service_account"
+	// PrincipalClient клиент OAuth без пользователя
+	PrincipalClient PrincipalType = "client"
+)
+
+func (p PrincipalType) String() string {
+	return string(p)
+}
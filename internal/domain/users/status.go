@@ -0,0 +1,238 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrStatusNotValid = errors.New("user status is not valid")
+	// ErrStatusTransition общая причина всех TransitionError для errors.Is
+	ErrStatusTransition = errors.New("user status transition is not allowed")
+)
+
+// Status жизненный цикл учетной записи, меняется только переходами User
+type Status string
+
+const (
+	// StatusPending учетная запись создана, но еще не подтверждена
+	StatusPending   Status = "pending"
+	StatusActive    Status = "active"
+	StatusSuspended Status = "suspended"
+	// StatusLocked блокировка по соображениям безопасности, без причины и срока
+	StatusLocked  Status = "locked"
+	StatusDeleted Status = "deleted"
+	// StatusErased персональные данные удалены, запись осталась только ради id
+	StatusErased Status = "erased"
+)
+
+type Transition string
+
+const (
+	TransitionVerify    Transition = "verify"
+	TransitionSuspend   Transition = "suspend"
+	TransitionUnsuspend Transition = "unsuspend"
+	TransitionLock      Transition = "lock"
+	TransitionUnlock    Transition = "unlock"
+	TransitionDelete    Transition = "delete"
+	TransitionRestore   Transition = "restore"
+	TransitionErase     Transition = "erase"
+)
+
+type transitionRule struct {
+	from []Status
+	to   Status
+}
+
+// transitions повторная блокировка разрешена, чтобы поменять причину и срок
+var transitions = map[Transition]transitionRule{
+	TransitionVerify:    {from: []Status{StatusPending}, to: StatusActive},
+	TransitionSuspend:   {from: []Status{StatusActive, StatusSuspended}, to: StatusSuspended},
+	TransitionUnsuspend: {from: []Status{StatusSuspended}, to: StatusActive},
+	TransitionLock:      {from: []Status{StatusActive}, to: StatusLocked},
+	TransitionUnlock:    {from: []Status{StatusLocked}, to: StatusActive},
+	TransitionDelete:    {from: []Status{StatusPending, StatusActive, StatusSuspended, StatusLocked}, to: StatusDeleted},
+	TransitionRestore:   {from: []Status{StatusDeleted}, to: StatusActive},
+	TransitionErase:     {from: []Status{StatusPending, StatusActive, StatusSuspended, StatusLocked, StatusDeleted}, to: StatusErased},
+}
+
+// TransitionError переход не разрешен из текущего статуса
+type TransitionError struct {
+	Transition Transition
+	From       Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("user status transition %s is not allowed from %s", e.Transition, e.From)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrStatusTransition
+}
+
+func NewStatus(status string) (Status, error) {
+	s := Status(status)
+	if err := s.validate(); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+func (s Status) validate() error {
+	switch s {
+	case StatusPending, StatusActive, StatusSuspended, StatusLocked, StatusDeleted, StatusErased:
+		return nil
+	default:
+		return ErrStatusNotValid
+	}
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+func (u *User) Status() Status {
+	return u.status
+}
+func (u *User) StatusChangedAt() time.Time {
+	return u.statusChangedAt
+}
+
+// WithStatusChangedAt восстанавливает время последнего перехода из хранилища
+func (u *User) WithStatusChangedAt(at time.Time) *User {
+	u.statusChangedAt = at
+	return u
+}
+
+// IsActive статус active. Для проверки входа используется CanAuthenticate,
+// она учитывает истекший срок блокировки
+func (u *User) IsActive() bool {
+	return u.status == StatusActive
+}
+func (u *User) IsDeleted() bool {
+	return u.status == StatusDeleted
+}
+func (u *User) IsErased() bool {
+	return u.status == StatusErased
+}
+
+// IsSuspended блокировка с истекшим сроком больше не действует, хотя статус остается suspended
+func (u *User) IsSuspended(at time.Time) bool {
+	return u.status == StatusSuspended && (u.access.Suspension == nil || u.access.Suspension.ActiveAt(at))
+}
+
+// CanAuthenticate вход и выдача токенов разрешены только активному пользователю
+func (u *User) CanAuthenticate(at time.Time) error {
+	switch u.status {
+	case StatusActive:
+		return nil
+	case StatusPending:
+		return ErrUserNotVerified
+	case StatusLocked:
+		return ErrUserLocked
+	case StatusSuspended:
+		if u.IsSuspended(at) {
+			return ErrUserSuspended
+		}
+		return nil
+	default:
+		return ErrUserNotActive
+	}
+}
+
+func (u *User) transition(t Transition, at time.Time) error {
+	rule := transitions[t]
+	if !slices.Contains(rule.from, u.status) {
+		return &TransitionError{Transition: t, From: u.status}
+	}
+	u.status = rule.to
+	u.statusChangedAt = at
+	u.updatedAt = at
+	return nil
+}
+
+func (u *User) Verify(at time.Time) error {
+	return u.transition(TransitionVerify, at)
+}
+
+// Suspend блокирует вход и завершает сессии, until нулевой для бессрочной блокировки
+func (u *User) Suspend(reason string, until time.Time, at time.Time) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrSuspendReasonRequired
+	}
+	if !until.IsZero() && !until.After(at) {
+		return ErrSuspendUntilNotValid
+	}
+	if err := u.transition(TransitionSuspend, at); err != nil {
+		return err
+	}
+	u.access.Suspension = &Suspension{Reason: reason, Since: at, Until: until}
+	u.access.SessionsRevokedAt = at
+	return nil
+}
+
+func (u *User) Unsuspend(at time.Time) error {
+	if err := u.transition(TransitionUnsuspend, at); err != nil {
+		return err
+	}
+	u.access.Suspension = nil
+	return nil
+}
+
+// Lock блокирует вход и завершает сессии
+func (u *User) Lock(at time.Time) error {
+	if err := u.transition(TransitionLock, at); err != nil {
+		return err
+	}
+	u.access.SessionsRevokedAt = at
+	return nil
+}
+
+func (u *User) Unlock(at time.Time) error {
+	return u.transition(TransitionUnlock, at)
+}
+
+// Delete мягкое удаление, учетную запись можно вернуть через Restore
+func (u *User) Delete(at time.Time) error {
+	return u.transition(TransitionDelete, at)
+}
+
+// Restore возвращает удаленного пользователя активным, прежняя блокировка не восстанавливается
+func (u *User) Restore(at time.Time) error {
+	if err := u.transition(TransitionRestore, at); err != nil {
+		return err
+	}
+	u.access.Suspension = nil
+	return nil
+}
+
+// Reactivate возвращает пользователя в active подходящим переходом: unsuspend, unlock или restore
+func (u *User) Reactivate(at time.Time) error {
+	switch u.status {
+	case StatusActive:
+		return ErrUserAlreadyActive
+	case StatusSuspended:
+		return u.Unsuspend(at)
+	case StatusLocked:
+		return u.Unlock(at)
+	default:
+		return u.Restore(at)
+	}
+}
+
+// Erase право на забвение: имя, email, пароль и ограничения входа удаляются без возможности восстановления,
+// id остается, чтобы не ломать ссылки из аудита и других записей
+func (u *User) Erase(at time.Time) error {
+	if err := u.transition(TransitionErase, at); err != nil {
+		return err
+	}
+	u.name = ""
+	u.email = Email{}
+	u.passwordHash = Password{}
+	u.access = Access{}
+	return nil
+}
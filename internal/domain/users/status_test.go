@@ -0,0 +1,134 @@
+package users
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUser_transitions(t *testing.T) {
+	apply := map[Transition]func(u *User, at time.Time) error{
+		TransitionVerify: (*User).Verify,
+		TransitionSuspend: func(u *User, at time.Time) error {
+			return u.Suspend("spam", time.Time{}, at)
+		},
+		TransitionUnsuspend: (*User).Unsuspend,
+		TransitionLock:      (*User).Lock,
+		TransitionUnlock:    (*User).Unlock,
+		TransitionDelete:    (*User).Delete,
+		TransitionRestore:   (*User).Restore,
+		TransitionErase:     (*User).Erase,
+	}
+	tests := []struct {
+		transition Transition
+		from       Status
+		want       Status
+		wantErr    bool
+	}{
+		{transition: TransitionVerify, from: StatusPending, want: StatusActive},
+		{transition: TransitionVerify, from: StatusActive, wantErr: true},
+		{transition: TransitionSuspend, from: StatusActive, want: StatusSuspended},
+		{transition: TransitionSuspend, from: StatusSuspended, want: StatusSuspended},
+		{transition: TransitionSuspend, from: StatusPending, wantErr: true},
+		{transition: TransitionSuspend, from: StatusDeleted, wantErr: true},
+		{transition: TransitionUnsuspend, from: StatusSuspended, want: StatusActive},
+		{transition: TransitionUnsuspend, from: StatusLocked, wantErr: true},
+		{transition: TransitionLock, from: StatusActive, want: StatusLocked},
+		{transition: TransitionLock, from: StatusLocked, wantErr: true},
+		{transition: TransitionUnlock, from: StatusLocked, want: StatusActive},
+		{transition: TransitionUnlock, from: StatusSuspended, wantErr: true},
+		{transition: TransitionDelete, from: StatusLocked, want: StatusDeleted},
+		{transition: TransitionDelete, from: StatusDeleted, wantErr: true},
+		{transition: TransitionRestore, from: StatusDeleted, want: StatusActive},
+		{transition: TransitionRestore, from: StatusActive, wantErr: true},
+		{transition: TransitionRestore, from: StatusErased, wantErr: true},
+		{transition: TransitionErase, from: StatusDeleted, want: StatusErased},
+		{transition: TransitionErase, from: StatusActive, want: StatusErased},
+		{transition: TransitionErase, from: StatusErased, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.transition)+" from "+string(tt.from), func(t *testing.T) {
+			at := time.Now().UTC()
+			u := &User{status: tt.from}
+			err := apply[tt.transition](u, at)
+			if tt.wantErr {
+				var transitionErr *TransitionError
+				if !errors.As(err, &transitionErr) || !errors.Is(err, ErrStatusTransition) {
+					t.Fatalf("%s error = %v, want TransitionError", tt.transition, err)
+				}
+				if transitionErr.Transition != tt.transition || transitionErr.From != tt.from {
+					t.Errorf("TransitionError = %+v", transitionErr)
+				}
+				if u.Status() != tt.from {
+					t.Errorf("Status() = %v, must stay %v", u.Status(), tt.from)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s error = %v", tt.transition, err)
+			}
+			if u.Status() != tt.want {
+				t.Errorf("Status() = %v, want %v", u.Status(), tt.want)
+			}
+			if !u.StatusChangedAt().Equal(at) {
+				t.Errorf("StatusChangedAt() = %v, want %v", u.StatusChangedAt(), at)
+			}
+		})
+	}
+}
+
+func TestUser_CanAuthenticate(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name       string
+		status     Status
+		suspension *Suspension
+		wantErr    error
+	}{
+		{name: "active", status: StatusActive},
+		{name: "pending", status: StatusPending, wantErr: ErrUserNotVerified},
+		{name: "locked", status: StatusLocked, wantErr: ErrUserLocked},
+		{name: "deleted", status: StatusDeleted, wantErr: ErrUserNotActive},
+		{name: "suspended", status: StatusSuspended, suspension: &Suspension{Reason: "spam"}, wantErr: ErrUserSuspended},
+		{name: "suspension expired", status: StatusSuspended, suspension: &Suspension{Reason: "spam", Until: now.Add(-time.Minute)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &User{status: tt.status, access: Access{Suspension: tt.suspension}}
+			if err := u.CanAuthenticate(now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CanAuthenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewStatus(t *testing.T) {
+	if _, err := NewStatus("blocked"); !errors.Is(err, ErrStatusNotValid) {
+		t.Errorf("NewStatus() error = %v, want %v", err, ErrStatusNotValid)
+	}
+	if s, err := NewStatus("locked"); err != nil || s != StatusLocked {
+		t.Errorf("NewStatus() = %v, %v", s, err)
+	}
+}
+
+func TestUser_Erase(t *testing.T) {
+	u := &User{
+		status:       StatusDeleted,
+		name:         "user",
+		email:        Email{value: "user@gmail.com"},
+		passwordHash: Password{hash: []byte("hash")},
+		access:       Access{PasswordChangeRequired: true},
+	}
+	if err := u.Erase(time.Now().UTC()); err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+	if u.Name() != "" || u.Email().String() != "" || len(u.Password().Hash()) != 0 {
+		t.Errorf("Erase() left personal data: %q %q", u.Name(), u.Email())
+	}
+	if u.Access().PasswordChangeRequired {
+		t.Errorf("Erase() left access restrictions")
+	}
+	if !errors.Is(u.CanAuthenticate(time.Now()), ErrUserNotActive) {
+		t.Errorf("CanAuthenticate() must reject erased user")
+	}
+}
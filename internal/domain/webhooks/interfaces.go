@@ -0,0 +1,26 @@
+package webhooks
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Repository interface {
+	SaveSubscription(ctx context.Context, subscription *Subscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]*Subscription, error)
+	ListSubscriptionsByEvent(ctx context.Context, eventType EventType) ([]*Subscription, error)
+	SaveDelivery(ctx context.Context, delivery *Delivery) error
+	GetDelivery(ctx context.Context, id uuid.UUID) (*Delivery, error)
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*Delivery, error)
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*Delivery, error)
+	SaveAttempt(ctx context.Context, attempt *Attempt) error
+	ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*Attempt, error)
+	// ScrubUserDeliveries стирает имя и email пользователя в телах доставок
+	ScrubUserDeliveries(ctx context.Context, userID uuid.UUID) error
+}
+
+type Sender interface {
+	Send(ctx context.Context, subscription *Subscription, delivery *Delivery) (statusCode int, err error)
+}
@@ -0,0 +1,244 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./interfaces.go -destination=./mocks/interfaces_mocks.go
+//
+
+// Package mock_webhooks is a generated GoMock package.
+package mock_webhooks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	webhooks "github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetDelivery mocks base method.
+func (m *MockRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*webhooks.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelivery", ctx, id)
+	ret0, _ := ret[0].(*webhooks.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelivery indicates an expected call of GetDelivery.
+func (mr *MockRepositoryMockRecorder) GetDelivery(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelivery", reflect.TypeOf((*MockRepository)(nil).GetDelivery), ctx, id)
+}
+
+// GetSubscription mocks base method.
+func (m *MockRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscription", ctx, id)
+	ret0, _ := ret[0].(*webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscription indicates an expected call of GetSubscription.
+func (mr *MockRepositoryMockRecorder) GetSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscription", reflect.TypeOf((*MockRepository)(nil).GetSubscription), ctx, id)
+}
+
+// ListAttempts mocks base method.
+func (m *MockRepository) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*webhooks.Attempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAttempts", ctx, deliveryID)
+	ret0, _ := ret[0].([]*webhooks.Attempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAttempts indicates an expected call of ListAttempts.
+func (mr *MockRepositoryMockRecorder) ListAttempts(ctx, deliveryID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAttempts", reflect.TypeOf((*MockRepository)(nil).ListAttempts), ctx, deliveryID)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockRepository) ListDeliveries(ctx context.Context, filter webhooks.DeliveryFilter) ([]*webhooks.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", ctx, filter)
+	ret0, _ := ret[0].([]*webhooks.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockRepositoryMockRecorder) ListDeliveries(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockRepository)(nil).ListDeliveries), ctx, filter)
+}
+
+// ListDueDeliveries mocks base method.
+func (m *MockRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*webhooks.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueDeliveries", ctx, now, limit)
+	ret0, _ := ret[0].([]*webhooks.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueDeliveries indicates an expected call of ListDueDeliveries.
+func (mr *MockRepositoryMockRecorder) ListDueDeliveries(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueDeliveries", reflect.TypeOf((*MockRepository)(nil).ListDueDeliveries), ctx, now, limit)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockRepository) ListSubscriptions(ctx context.Context) ([]*webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", ctx)
+	ret0, _ := ret[0].([]*webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockRepositoryMockRecorder) ListSubscriptions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockRepository)(nil).ListSubscriptions), ctx)
+}
+
+// ListSubscriptionsByEvent mocks base method.
+func (m *MockRepository) ListSubscriptionsByEvent(ctx context.Context, eventType webhooks.EventType) ([]*webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptionsByEvent", ctx, eventType)
+	ret0, _ := ret[0].([]*webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptionsByEvent indicates an expected call of ListSubscriptionsByEvent.
+func (mr *MockRepositoryMockRecorder) ListSubscriptionsByEvent(ctx, eventType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptionsByEvent", reflect.TypeOf((*MockRepository)(nil).ListSubscriptionsByEvent), ctx, eventType)
+}
+
+// SaveAttempt mocks base method.
+func (m *MockRepository) SaveAttempt(ctx context.Context, attempt *webhooks.Attempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAttempt", ctx, attempt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAttempt indicates an expected call of SaveAttempt.
+func (mr *MockRepositoryMockRecorder) SaveAttempt(ctx, attempt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAttempt", reflect.TypeOf((*MockRepository)(nil).SaveAttempt), ctx, attempt)
+}
+
+// SaveDelivery mocks base method.
+func (m *MockRepository) SaveDelivery(ctx context.Context, delivery *webhooks.Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDelivery indicates an expected call of SaveDelivery.
+func (mr *MockRepositoryMockRecorder) SaveDelivery(ctx, delivery any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDelivery", reflect.TypeOf((*MockRepository)(nil).SaveDelivery), ctx, delivery)
+}
+
+// SaveSubscription mocks base method.
+func (m *MockRepository) SaveSubscription(ctx context.Context, subscription *webhooks.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSubscription", ctx, subscription)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSubscription indicates an expected call of SaveSubscription.
+func (mr *MockRepositoryMockRecorder) SaveSubscription(ctx, subscription any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSubscription", reflect.TypeOf((*MockRepository)(nil).SaveSubscription), ctx, subscription)
+}
+
+// ScrubUserDeliveries mocks base method.
+func (m *MockRepository) ScrubUserDeliveries(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScrubUserDeliveries", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScrubUserDeliveries indicates an expected call of ScrubUserDeliveries.
+func (mr *MockRepositoryMockRecorder) ScrubUserDeliveries(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScrubUserDeliveries", reflect.TypeOf((*MockRepository)(nil).ScrubUserDeliveries), ctx, userID)
+}
+
+// MockSender is a mock of Sender interface.
+type MockSender struct {
+	ctrl     *gomock.Controller
+	recorder *MockSenderMockRecorder
+	isgomock struct{}
+}
+
+// MockSenderMockRecorder is the mock recorder for MockSender.
+type MockSenderMockRecorder struct {
+	mock *MockSender
+}
+
+// NewMockSender creates a new mock instance.
+func NewMockSender(ctrl *gomock.Controller) *MockSender {
+	mock := &MockSender{ctrl: ctrl}
+	mock.recorder = &MockSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSender) EXPECT() *MockSenderMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockSender) Send(ctx context.Context, subscription *webhooks.Subscription, delivery *webhooks.Delivery) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, subscription, delivery)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockSenderMockRecorder) Send(ctx, subscription, delivery any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockSender)(nil).Send), ctx, subscription, delivery)
+}
@@ -0,0 +1,526 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/google/uuid"
+	"net/url"
+	"time"
+)
+
+var (
+	ErrURLNotValid           = errors.New("webhook url is not valid")
+	ErrEventsRequired        = errors.New("webhook events are required")
+	ErrEventTypeNotValid     = errors.New("webhook event type is not valid")
+	ErrSecretTooShort        = errors.New("webhook secret is too short")
+	ErrSubscriptionNotFound  = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound      = errors.New("webhook delivery not found")
+	ErrDeliveryNotDead       = errors.New("webhook delivery is not in dead-letter state")
+	ErrSubscriptionNotActive = errors.New("webhook subscription is not active")
+)
+
+const minSecretLength = 16
+
+type EventType string
+
+const (
+	EventUserCreated EventType = "user.created"
+	EventUserUpdated EventType = "user.updated"
+	EventUserDeleted EventType = "user.deleted"
+
+	// EventAll подписка на все события
+	EventAll EventType = "*"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+type URL struct {
+	value string
+}
+
+type Secret struct {
+	value string
+}
+
+type Subscription struct {
+	id        uuid.UUID
+	url       URL
+	events    []EventType
+	secret    Secret
+	isActive  bool
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+type Event struct {
+	id         uuid.UUID
+	eventType  EventType
+	payload    []byte
+	occurredAt time.Time
+}
+
+type Delivery struct {
+	id             uuid.UUID
+	subscriptionID uuid.UUID
+	eventID        uuid.UUID
+	eventType      EventType
+	payload        []byte
+	status         DeliveryStatus
+	attempts       int
+	nextAttemptAt  time.Time
+	lastError      string
+	createdAt      time.Time
+	updatedAt      time.Time
+}
+
+type Attempt struct {
+	id         uuid.UUID
+	deliveryID uuid.UUID
+	number     int
+	statusCode int
+	err        string
+	duration   time.Duration
+	createdAt  time.Time
+}
+
+// RetryPolicy экспоненциальная задержка между попытками доставки
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+type DeliveryFilter struct {
+	SubscriptionID uuid.UUID
+	Status         DeliveryStatus
+	Limit          int
+	Offset         int
+}
+
+func NewSubscription(
+	id uuid.UUID,
+	url URL,
+	events []EventType,
+	secret Secret,
+	isActive bool,
+	createdAt time.Time,
+	updatedAt time.Time,
+) (*Subscription, error) {
+	if err := url.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateEvents(events); err != nil {
+		return nil, err
+	}
+	if err := secret.validate(); err != nil {
+		return nil, err
+	}
+	return &Subscription{
+		id:        id,
+		url:       url,
+		events:    events,
+		secret:    secret,
+		isActive:  isActive,
+		createdAt: createdAt,
+		updatedAt: updatedAt,
+	}, nil
+}
+
+func CreateSubscription(url URL, events []EventType, secret Secret) (*Subscription, error) {
+	now := time.Now().UTC()
+	return NewSubscription(uuid.New(), url, events, secret, true, now, now)
+}
+
+func (s *Subscription) ID() uuid.UUID {
+	return s.id
+}
+func (s *Subscription) URL() URL {
+	return s.url
+}
+func (s *Subscription) Events() []EventType {
+	return s.events
+}
+func (s *Subscription) Secret() Secret {
+	return s.secret
+}
+func (s *Subscription) IsActive() bool {
+	return s.isActive
+}
+func (s *Subscription) CreatedAt() time.Time {
+	return s.createdAt
+}
+func (s *Subscription) UpdatedAt() time.Time {
+	return s.updatedAt
+}
+
+func (s *Subscription) Matches(eventType EventType) bool {
+	if !s.isActive {
+		return false
+	}
+	for _, e := range s.events {
+		if e == EventAll || e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Subscription) Disable() error {
+	s.isActive = false
+	s.updatedAt = time.Now().UTC()
+	return nil
+}
+
+func NewURL(rawURL string) (URL, error) {
+	u := URL{value: rawURL}
+	if err := u.validate(); err != nil {
+		return URL{}, err
+	}
+	return u, nil
+}
+
+func (u URL) validate() error {
+	parsed, err := url.Parse(u.value)
+	if err != nil {
+		return ErrURLNotValid
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return ErrURLNotValid
+	}
+	if parsed.Host == "" {
+		return ErrURLNotValid
+	}
+	return nil
+}
+
+func (u URL) String() string {
+	return u.value
+}
+
+func NewSecret(secret string) (Secret, error) {
+	s := Secret{value: secret}
+	if err := s.validate(); err != nil {
+		return Secret{}, err
+	}
+	return s, nil
+}
+
+// GenerateSecret создает случайный секрет для подписи доставок
+func GenerateSecret() (Secret, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Secret{}, err
+	}
+	return Secret{value: "whsec_" + hex.EncodeToString(b)}, nil
+}
+
+func (s Secret) validate() error {
+	if len(s.value) < minSecretLength {
+		return ErrSecretTooShort
+	}
+	return nil
+}
+
+func (s Secret) String() string {
+	return s.value
+}
+
+func NewEventType(eventType string) (EventType, error) {
+	e := EventType(eventType)
+	if err := e.validate(); err != nil {
+		return "", err
+	}
+	return e, nil
+}
+
+func (e EventType) validate() error {
+	switch e {
+	case EventUserCreated, EventUserUpdated, EventUserDeleted, EventAll:
+		return nil
+	default:
+		return ErrEventTypeNotValid
+	}
+}
+
+func (e EventType) String() string {
+	return string(e)
+}
+
+func validateEvents(events []EventType) error {
+	if len(events) == 0 {
+		return ErrEventsRequired
+	}
+	for _, e := range events {
+		if err := e.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewDeliveryStatus(status string) (DeliveryStatus, error) {
+	s := DeliveryStatus(status)
+	switch s {
+	case DeliveryPending, DeliverySucceeded, DeliveryDead:
+		return s, nil
+	default:
+		return "", errors.New("delivery status is not valid")
+	}
+}
+
+func (s DeliveryStatus) String() string {
+	return string(s)
+}
+
+type userEventData struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	IsActive bool   `json:"is_active"`
+}
+
+type eventBody struct {
+	ID         string        `json:"id"`
+	Type       string        `json:"type"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	Data       userEventData `json:"data"`
+}
+
+func NewUserEvent(eventType EventType, user *users.User) (Event, error) {
+	if eventType == EventAll {
+		return Event{}, ErrEventTypeNotValid
+	}
+	if err := eventType.validate(); err != nil {
+		return Event{}, err
+	}
+	e := Event{
+		id:         uuid.New(),
+		eventType:  eventType,
+		occurredAt: time.Now().UTC(),
+	}
+	payload, err := json.Marshal(eventBody{
+		ID:         e.id.String(),
+		Type:       eventType.String(),
+		OccurredAt: e.occurredAt,
+		Data: userEventData{
+			ID:       user.ID().String(),
+			Name:     user.Name().String(),
+			Email:    user.Email().String(),
+			Role:     user.Role().String(),
+			IsActive: user.IsActive(),
+		},
+	})
+	if err != nil {
+		return Event{}, err
+	}
+	e.payload = payload
+	return e, nil
+}
+
+func (e Event) ID() uuid.UUID {
+	return e.id
+}
+func (e Event) Type() EventType {
+	return e.eventType
+}
+func (e Event) Payload() []byte {
+	return e.payload
+}
+func (e Event) OccurredAt() time.Time {
+	return e.occurredAt
+}
+
+func NewDelivery(
+	id uuid.UUID,
+	subscriptionID uuid.UUID,
+	eventID uuid.UUID,
+	eventType EventType,
+	payload []byte,
+	status DeliveryStatus,
+	attempts int,
+	nextAttemptAt time.Time,
+	lastError string,
+	createdAt time.Time,
+	updatedAt time.Time,
+) *Delivery {
+	return &Delivery{
+		id:             id,
+		subscriptionID: subscriptionID,
+		eventID:        eventID,
+		eventType:      eventType,
+		payload:        payload,
+		status:         status,
+		attempts:       attempts,
+		nextAttemptAt:  nextAttemptAt,
+		lastError:      lastError,
+		createdAt:      createdAt,
+		updatedAt:      updatedAt,
+	}
+}
+
+// CreateDelivery ставит событие в очередь на отправку подписчику
+func CreateDelivery(subscription *Subscription, event Event) *Delivery {
+	now := time.Now().UTC()
+	return NewDelivery(
+		uuid.New(),
+		subscription.ID(),
+		event.ID(),
+		event.Type(),
+		event.Payload(),
+		DeliveryPending,
+		0,
+		now,
+		"",
+		now,
+		now,
+	)
+}
+
+func (d *Delivery) ID() uuid.UUID {
+	return d.id
+}
+func (d *Delivery) SubscriptionID() uuid.UUID {
+	return d.subscriptionID
+}
+func (d *Delivery) EventID() uuid.UUID {
+	return d.eventID
+}
+func (d *Delivery) EventType() EventType {
+	return d.eventType
+}
+func (d *Delivery) Payload() []byte {
+	return d.payload
+}
+func (d *Delivery) Status() DeliveryStatus {
+	return d.status
+}
+func (d *Delivery) Attempts() int {
+	return d.attempts
+}
+func (d *Delivery) NextAttemptAt() time.Time {
+	return d.nextAttemptAt
+}
+func (d *Delivery) LastError() string {
+	return d.lastError
+}
+func (d *Delivery) CreatedAt() time.Time {
+	return d.createdAt
+}
+func (d *Delivery) UpdatedAt() time.Time {
+	return d.updatedAt
+}
+
+// Claim откладывает доставку на время отправки, чтобы ее не выбрали повторно. Если экземпляр
+// упадет до сохранения результата, доставка снова станет доступна по истечении lease
+func (d *Delivery) Claim(lease time.Duration, at time.Time) {
+	d.nextAttemptAt = at.Add(lease)
+	d.updatedAt = at
+}
+
+func (d *Delivery) MarkSucceeded(at time.Time) {
+	d.attempts++
+	d.status = DeliverySucceeded
+	d.lastError = ""
+	d.updatedAt = at
+}
+
+// MarkFailed планирует следующую попытку, либо переводит доставку в dead-letter
+// если попытки исчерпаны
+func (d *Delivery) MarkFailed(reason string, policy RetryPolicy, at time.Time) {
+	d.attempts++
+	d.lastError = reason
+	d.updatedAt = at
+	if d.attempts >= policy.MaxAttempts {
+		d.status = DeliveryDead
+		return
+	}
+	d.nextAttemptAt = at.Add(policy.Backoff(d.attempts))
+}
+
+// MarkDead переводит доставку в dead-letter без повторных попыток
+func (d *Delivery) MarkDead(reason string, at time.Time) {
+	d.status = DeliveryDead
+	d.lastError = reason
+	d.updatedAt = at
+}
+
+// Redeliver возвращает доставку из dead-letter в очередь
+func (d *Delivery) Redeliver(at time.Time) error {
+	if d.status != DeliveryDead {
+		return ErrDeliveryNotDead
+	}
+	d.status = DeliveryPending
+	d.attempts = 0
+	d.nextAttemptAt = at
+	d.updatedAt = at
+	return nil
+}
+
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+func NewAttempt(
+	id uuid.UUID,
+	deliveryID uuid.UUID,
+	number int,
+	statusCode int,
+	err string,
+	duration time.Duration,
+	createdAt time.Time,
+) *Attempt {
+	return &Attempt{
+		id:         id,
+		deliveryID: deliveryID,
+		number:     number,
+		statusCode: statusCode,
+		err:        err,
+		duration:   duration,
+		createdAt:  createdAt,
+	}
+}
+
+func (a *Attempt) ID() uuid.UUID {
+	return a.id
+}
+func (a *Attempt) DeliveryID() uuid.UUID {
+	return a.deliveryID
+}
+func (a *Attempt) Number() int {
+	return a.number
+}
+func (a *Attempt) StatusCode() int {
+	return a.statusCode
+}
+func (a *Attempt) Error() string {
+	return a.err
+}
+func (a *Attempt) Duration() time.Duration {
+	return a.duration
+}
+func (a *Attempt) CreatedAt() time.Time {
+	return a.createdAt
+}
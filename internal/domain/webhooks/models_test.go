@@ -0,0 +1,115 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{name: "https", url: "https://example.com/hook", wantErr: false},
+		{name: "http", url: "http://localhost:8080/hook", wantErr: false},
+		{name: "empty", url: "", wantErr: true},
+		{name: "no scheme", url: "example.com/hook", wantErr: true},
+		{name: "unsupported scheme", url: "ftp://example.com/hook", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 30 * time.Second},
+		{attempt: 2, want: time.Minute},
+		{attempt: 3, want: 2 * time.Minute},
+		{attempt: 4, want: 4 * time.Minute},
+		{attempt: 5, want: 5 * time.Minute},
+		{attempt: 50, want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, policy.Backoff(tt.attempt), "attempt %d", tt.attempt)
+	}
+}
+
+func TestDelivery_MarkFailed(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	now := time.Now().UTC()
+	d := NewDelivery([16]byte{1}, [16]byte{2}, [16]byte{3}, EventUserCreated, nil, DeliveryPending, 0, now, "", now, now)
+
+	d.MarkFailed("status 500", policy, now)
+	assert.Equal(t, DeliveryPending, d.Status())
+	assert.Equal(t, now.Add(time.Minute), d.NextAttemptAt())
+
+	d.MarkFailed("status 500", policy, now)
+	assert.Equal(t, DeliveryDead, d.Status())
+	assert.Equal(t, 2, d.Attempts())
+
+	assert.NoError(t, d.Redeliver(now))
+	assert.Equal(t, DeliveryPending, d.Status())
+	assert.Equal(t, 0, d.Attempts())
+	assert.ErrorIs(t, d.Redeliver(now), ErrDeliveryNotDead)
+}
+
+func TestDelivery_Claim(t *testing.T) {
+	now := time.Now().UTC()
+	d := NewDelivery([16]byte{1}, [16]byte{2}, [16]byte{3}, EventUserCreated, nil, DeliveryPending, 0, now, "", now, now)
+
+	d.Claim(5*time.Minute, now)
+	assert.Equal(t, DeliveryPending, d.Status())
+	assert.Equal(t, now.Add(5*time.Minute), d.NextAttemptAt())
+	assert.Equal(t, 0, d.Attempts())
+}
+
+func TestSubscription_Matches(t *testing.T) {
+	url, _ := NewURL("https://example.com/hook")
+	secret, _ := NewSecret("0123456789abcdef")
+
+	s, err := CreateSubscription(url, []EventType{EventUserDeleted}, secret)
+	assert.NoError(t, err)
+	assert.True(t, s.Matches(EventUserDeleted))
+	assert.False(t, s.Matches(EventUserCreated))
+
+	assert.NoError(t, s.Disable())
+	assert.False(t, s.Matches(EventUserDeleted))
+
+	_, err = CreateSubscription(url, nil, secret)
+	assert.ErrorIs(t, err, ErrEventsRequired)
+}
+
+func TestNewUserEvent(t *testing.T) {
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hash"))
+	user, _ := users.CreateUser(organizations.DefaultID, "user", email, pass)
+
+	event, err := NewUserEvent(EventUserCreated, user)
+	assert.NoError(t, err)
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(event.Payload(), &body))
+	assert.Equal(t, "user.created", body["type"])
+	assert.Equal(t, event.ID().String(), body["id"])
+	data := body["data"].(map[string]any)
+	assert.Equal(t, user.ID().String(), data["id"])
+	assert.NotContains(t, data, "password")
+
+	_, err = NewUserEvent(EventAll, user)
+	assert.Error(t, err)
+}
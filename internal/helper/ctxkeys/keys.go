@@ -0,0 +1,14 @@
+// Package ctxkeys ключи значений запроса в контексте. Владелец токена хранится отдельно, см. authverify.FromContext
+package ctxkeys
+
+// key неэкспортируемый тип, поэтому ключи не совпадут со строками или ключами других пакетов
+type key int
+
+const (
+	// RequestID uuid.UUID
+	RequestID key = iota
+	// PeerIP string
+	PeerIP
+	// Organization string, id или slug организации из заголовка x-organization для запросов без токена
+	Organization
+)
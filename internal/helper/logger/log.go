@@ -2,21 +2,21 @@ package logger
 
 import (
 	"context"
-	"github.com/LeoUraltsev/auth-service/internal/infrastructure/interceptors"
+	"github.com/LeoUraltsev/auth-service/internal/helper/ctxkeys"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
 	"github.com/google/uuid"
 	"log/slog"
 )
 
 func LogWithContext(ctx context.Context, log *slog.Logger) *slog.Logger {
 	l := log
-	reqID, ok := ctx.Value(interceptors.KeyCtxRequestID).(uuid.UUID)
+	reqID, ok := ctx.Value(ctxkeys.RequestID).(uuid.UUID)
 	if ok {
 		l = log.With("request_id", reqID.String())
 	}
 
-	userID, ok := ctx.Value(interceptors.KeyCtxUserID).(uuid.UUID)
-	if ok {
-		l = log.With("user_id", userID.String())
+	if p, ok := authverify.FromContext(ctx); ok && p.UserID != uuid.Nil {
+		l = l.With("user_id", p.UserID.String())
 	}
 
 	return l
@@ -0,0 +1,52 @@
+package auditsign
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+var ErrKeyNotValid = errors.New("audit checkpoint key must be base64 encoded 32 byte ed25519 seed")
+
+// Ed25519Signer подписывает контрольные точки аудита. Проверить подпись можно одним публичным ключом,
+// поэтому аудитору не нужен доступ к секрету
+type Ed25519Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+func NewEd25519Signer(seed string) (*Ed25519Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil || len(raw) != ed25519.SeedSize {
+		return nil, ErrKeyNotValid
+	}
+	key := ed25519.NewKeyFromSeed(raw)
+	sum := sha256.Sum256(key.Public().(ed25519.PublicKey))
+	return &Ed25519Signer{
+		key:   key,
+		keyID: hex.EncodeToString(sum[:8]),
+	}, nil
+}
+
+// KeyID первые 8 байт sha256 публичного ключа
+func (s *Ed25519Signer) KeyID() string {
+	return s.keyID
+}
+
+func (s *Ed25519Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+func (s *Ed25519Signer) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, message), nil
+}
+
+// Verify подпись другим ключом считается недействительной, смена ключа ломает проверку старых точек
+func (s *Ed25519Signer) Verify(keyID string, message []byte, signature []byte) bool {
+	if keyID != s.keyID {
+		return false
+	}
+	return ed25519.Verify(s.key.Public().(ed25519.PublicKey), message, signature)
+}
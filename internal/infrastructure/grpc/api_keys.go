@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+	"time"
+)
+
+type apiKeyGRPCApi struct {
+	authapi.UnimplementedAPIKeyServiceServer
+	service application.APIKeyService
+	log     *slog.Logger
+}
+
+func RegisterAPIKeys(gRPC *grpc.Server, service application.APIKeyService, log *slog.Logger) {
+	authapi.RegisterAPIKeyServiceServer(gRPC, &apiKeyGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *apiKeyGRPCApi) CreateAPIKey(ctx context.Context, request *authapi.CreateAPIKeyRequest) (*authapi.CreateAPIKeyResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("creating api key")
+
+	var expiresAt *time.Time
+	if request.ExpiresAt != nil {
+		t := request.ExpiresAt.AsTime()
+		expiresAt = &t
+	}
+
+	key, value, err := a.service.CreateAPIKey(ctx, request.Name, request.Scopes, expiresAt)
+	if err != nil {
+		log.Error("failed to create api key", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to create api key")
+	}
+
+	return &authapi.CreateAPIKeyResponse{
+		ApiKey: apiKeyToProto(key),
+		Key:    value,
+	}, nil
+}
+
+func (a *apiKeyGRPCApi) ListAPIKeys(ctx context.Context, _ *authapi.ListAPIKeysRequest) (*authapi.ListAPIKeysResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting api keys")
+
+	keys, err := a.service.ListAPIKeys(ctx)
+	if err != nil {
+		log.Error("failed to get api keys", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get api keys")
+	}
+
+	res := make([]*authapi.APIKey, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, apiKeyToProto(k))
+	}
+	return &authapi.ListAPIKeysResponse{ApiKeys: res}, nil
+}
+
+func (a *apiKeyGRPCApi) RevokeAPIKey(ctx context.Context, request *authapi.RevokeAPIKeyRequest) (*authapi.RevokeAPIKeyResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse api key id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect api key id")
+	}
+
+	if err = a.service.RevokeAPIKey(ctx, id); err != nil {
+		log.Error("failed to revoke api key", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to revoke api key")
+	}
+	log.Info("api key revoked")
+	return &authapi.RevokeAPIKeyResponse{}, nil
+}
+
+func apiKeyToProto(k *apikeys.APIKey) *authapi.APIKey {
+	return &authapi.APIKey{
+		Id:         k.ID().String(),
+		Name:       k.Name(),
+		Prefix:     k.Prefix(),
+		Scopes:     k.Scope(),
+		ExpiresAt:  optionalTimestamp(k.ExpiresAt()),
+		LastUsedAt: optionalTimestamp(k.LastUsedAt()),
+		RevokedAt:  optionalTimestamp(k.RevokedAt()),
+		CreatedAt:  timestamppb.New(k.CreatedAt()),
+	}
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
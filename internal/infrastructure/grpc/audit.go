@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+)
+
+type auditGRPCApi struct {
+	authapi.UnimplementedAuditServiceServer
+	service application.AuditService
+	log     *slog.Logger
+}
+
+func RegisterAudit(gRPC *grpc.Server, service application.AuditService, log *slog.Logger) {
+	authapi.RegisterAuditServiceServer(gRPC, &auditGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *auditGRPCApi) ListAuditEvents(ctx context.Context, request *authapi.ListAuditEventsRequest) (*authapi.ListAuditEventsResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting audit events")
+
+	filter := audit.Filter{
+		TargetID: request.TargetId,
+		Limit:    int(request.Limit),
+		Offset:   int(request.Offset),
+	}
+	if request.ActorId != "" {
+		id, err := uuid.Parse(request.ActorId)
+		if err != nil {
+			log.Error("failed to parse actor id", slog.String("error", err.Error()))
+			return nil, status.Error(codes.InvalidArgument, "incorrect actor id")
+		}
+		filter.ActorID = id
+	}
+	if request.Action != "" {
+		action, err := audit.NewAction(request.Action)
+		if err != nil {
+			log.Error("failed to parse action", slog.String("error", err.Error()))
+			return nil, status.Error(codes.InvalidArgument, "incorrect action")
+		}
+		filter.Action = action
+	}
+
+	events, err := a.service.ListAuditEvents(ctx, filter)
+	if err != nil {
+		log.Error("failed to get audit events", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get audit events")
+	}
+
+	res := make([]*authapi.AuditEvent, 0, len(events))
+	for _, e := range events {
+		res = append(res, auditEventToProto(e))
+	}
+	return &authapi.ListAuditEventsResponse{Events: res}, nil
+}
+
+func (a *auditGRPCApi) VerifyAuditChain(ctx context.Context, _ *authapi.VerifyAuditChainRequest) (*authapi.VerifyAuditChainResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("verifying audit chain")
+
+	res, err := a.service.VerifyAuditChain(ctx)
+	if err != nil {
+		log.Error("failed to verify audit chain", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to verify audit chain")
+	}
+
+	resp := &authapi.VerifyAuditChainResponse{
+		Valid:              res.Valid(),
+		CheckedEvents:      res.CheckedEvents,
+		CheckedCheckpoints: res.CheckedCheckpoints,
+		LastSeq:            res.LastSeq,
+		LastHash:           res.LastHash,
+	}
+	if res.Break != nil {
+		var eventID string
+		if res.Break.EventID != uuid.Nil {
+			eventID = res.Break.EventID.String()
+		}
+		resp.FirstBreak = &authapi.AuditChainBreak{
+			Seq:     res.Break.Seq,
+			EventId: eventID,
+			Reason:  res.Break.Reason,
+		}
+	}
+	return resp, nil
+}
+
+func auditEventToProto(e *audit.Event) *authapi.AuditEvent {
+	var actorID string
+	if e.ActorID() != uuid.Nil {
+		actorID = e.ActorID().String()
+	}
+	return &authapi.AuditEvent{
+		Id:         e.ID().String(),
+		OccurredAt: timestamppb.New(e.OccurredAt()),
+		Action:     e.Action().String(),
+		ActorId:    actorID,
+		TargetType: e.TargetType().String(),
+		TargetId:   e.TargetID(),
+		RequestId:  e.RequestID(),
+		PeerIp:     e.PeerIP(),
+		Outcome:    e.Outcome().String(),
+		Reason:     e.Reason(),
+		Seq:        e.Seq(),
+		PrevHash:   e.PrevHash(),
+		Hash:       e.Hash(),
+	}
+}
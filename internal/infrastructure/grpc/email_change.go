@@ -0,0 +1,65 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"google.golang.org/grpc"
+	"log/slog"
+)
+
+type emailChangeGRPCApi struct {
+	authapi.UnimplementedEmailChangeServiceServer
+	service application.EmailChangeService
+	log     *slog.Logger
+}
+
+func RegisterEmailChange(gRPC *grpc.Server, service application.EmailChangeService, log *slog.Logger) {
+	authapi.RegisterEmailChangeServiceServer(gRPC, &emailChangeGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *emailChangeGRPCApi) RequestEmailChange(
+	ctx context.Context,
+	request *authapi.RequestEmailChangeRequest,
+) (*authapi.RequestEmailChangeResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("requesting email change")
+
+	if err := a.service.RequestEmailChange(ctx, request.Email); err != nil {
+		log.Error("failed to request email change", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to request email change")
+	}
+	return &authapi.RequestEmailChangeResponse{}, nil
+}
+
+func (a *emailChangeGRPCApi) ConfirmEmailChange(
+	ctx context.Context,
+	request *authapi.ConfirmEmailChangeRequest,
+) (*authapi.ConfirmEmailChangeResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("confirming email change")
+
+	if err := a.service.ConfirmEmailChange(ctx, request.Token); err != nil {
+		log.Error("failed to confirm email change", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to confirm email change")
+	}
+	return &authapi.ConfirmEmailChangeResponse{}, nil
+}
+
+func (a *emailChangeGRPCApi) CancelEmailChange(
+	ctx context.Context,
+	request *authapi.CancelEmailChangeRequest,
+) (*authapi.CancelEmailChangeResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("cancelling email change")
+
+	if err := a.service.CancelEmailChange(ctx, request.Token); err != nil {
+		log.Error("failed to cancel email change", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to cancel email change")
+	}
+	return &authapi.CancelEmailChangeResponse{}, nil
+}
@@ -0,0 +1,121 @@
+package grpc
+
+import (
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/emailchanges"
+	"github.com/LeoUraltsev/auth-service/internal/domain/exports"
+	"github.com/LeoUraltsev/auth-service/internal/domain/groups"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/domain/invitations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/passwordless"
+	"github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusFromError переводит ошибки приложения в gRPC коды, msg уходит клиенту для внутренних ошибок
+func statusFromError(err error, msg string) error {
+	switch {
+	case errors.Is(err, application.ErrPermissionDenied),
+		errors.Is(err, application.ErrRegistrationClosed),
+		errors.Is(err, users.ErrUserSuspended),
+		errors.Is(err, users.ErrUserLocked),
+		errors.Is(err, oauth.ErrInsufficientScope):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, webhooks.ErrSubscriptionNotFound),
+		errors.Is(err, webhooks.ErrDeliveryNotFound),
+		errors.Is(err, oauth.ErrClientNotFound),
+		errors.Is(err, identity.ErrIdentityNotFound),
+		errors.Is(err, serviceaccounts.ErrNotFound),
+		errors.Is(err, apikeys.ErrNotFound),
+		errors.Is(err, organizations.ErrOrganizationNotFound),
+		errors.Is(err, invitations.ErrNotFound),
+		errors.Is(err, relations.ErrNamespaceNotFound),
+		errors.Is(err, relations.ErrRelationNotFound),
+		errors.Is(err, groups.ErrNotFound),
+		errors.Is(err, groups.ErrMemberNotFound),
+		errors.Is(err, exports.ErrNotFound),
+		errors.Is(err, emailchanges.ErrNotFound),
+		errors.Is(err, users.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, webhooks.ErrURLNotValid),
+		errors.Is(err, webhooks.ErrEventsRequired),
+		errors.Is(err, webhooks.ErrEventTypeNotValid),
+		errors.Is(err, webhooks.ErrSecretTooShort),
+		errors.Is(err, oauth.ErrClientNameRequired),
+		errors.Is(err, oauth.ErrRedirectURINotValid),
+		errors.Is(err, oauth.ErrGrantTypeNotValid),
+		errors.Is(err, oauth.ErrPublicClientCredentials),
+		errors.Is(err, serviceaccounts.ErrNameRequired),
+		errors.Is(err, serviceaccounts.ErrGracePeriodInvalid),
+		errors.Is(err, users.ErrRoleNotValid),
+		errors.Is(err, apikeys.ErrNameRequired),
+		errors.Is(err, apikeys.ErrExpiryInvalid),
+		errors.Is(err, users.ErrEmailNotValid),
+		errors.Is(err, users.ErrNameRequired),
+		errors.Is(err, users.ErrPasswordRequired),
+		errors.Is(err, users.ErrPasswordTooShort),
+		errors.Is(err, users.ErrTenantRequired),
+		errors.Is(err, organizations.ErrSlugNotValid),
+		errors.Is(err, organizations.ErrNameRequired),
+		errors.Is(err, invitations.ErrCodeNotValid),
+		errors.Is(err, relations.ErrObjectNotValid),
+		errors.Is(err, relations.ErrSubjectNotValid),
+		errors.Is(err, relations.ErrRelationNotValid),
+		errors.Is(err, relations.ErrTuplesRequired),
+		errors.Is(err, relations.ErrTooManyTuples),
+		errors.Is(err, relations.ErrTokenNotValid),
+		errors.Is(err, groups.ErrNameRequired),
+		errors.Is(err, groups.ErrNameTooLong),
+		errors.Is(err, groups.ErrMemberTypeNotValid),
+		errors.Is(err, users.ErrSuspendReasonRequired),
+		errors.Is(err, users.ErrSuspendUntilNotValid),
+		errors.Is(err, users.ErrPasswordNotChanged),
+		errors.Is(err, emailchanges.ErrTokenNotValid),
+		errors.Is(err, emailchanges.ErrSameEmail):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, users.ErrEmailAlreadyExists),
+		errors.Is(err, organizations.ErrSlugAlreadyExists),
+		errors.Is(err, groups.ErrNameAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, oauth.ErrUserNotActive),
+		errors.Is(err, passwordless.ErrLinkNotValid),
+		errors.Is(err, users.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, webhooks.ErrDeliveryNotDead),
+		errors.Is(err, webhooks.ErrSubscriptionNotActive),
+		errors.Is(err, serviceaccounts.ErrDisabled),
+		errors.Is(err, serviceaccounts.ErrAlreadyDisabled),
+		errors.Is(err, serviceaccounts.ErrTooManySecrets),
+		errors.Is(err, apikeys.ErrAlreadyRevoked),
+		errors.Is(err, organizations.ErrOrganizationNotEmpty),
+		errors.Is(err, organizations.ErrDefaultOrganization),
+		errors.Is(err, invitations.ErrExpired),
+		errors.Is(err, invitations.ErrNotPending),
+		errors.Is(err, relations.ErrRevisionNotReached),
+		errors.Is(err, relations.ErrDepthExceeded),
+		errors.Is(err, groups.ErrCycle),
+		errors.Is(err, users.ErrUserNotActive),
+		errors.Is(err, users.ErrUserAlreadyActive),
+		errors.Is(err, users.ErrUserNotVerified),
+		errors.Is(err, users.ErrStatusTransition),
+		errors.Is(err, users.ErrPasswordChangeRequired),
+		errors.Is(err, users.ErrPasswordChangeNotRequired),
+		errors.Is(err, emailchanges.ErrExpired),
+		errors.Is(err, emailchanges.ErrNotPending),
+		errors.Is(err, emailchanges.ErrStale),
+		errors.Is(err, passwordless.ErrDisabled),
+		errors.Is(err, passwordless.ErrExpired),
+		errors.Is(err, passwordless.ErrConsumed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, msg)
+	}
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/exports"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+)
+
+type dataExportGRPCApi struct {
+	authapi.UnimplementedDataExportServiceServer
+	service application.DataExportService
+	log     *slog.Logger
+}
+
+func RegisterDataExports(gRPC *grpc.Server, service application.DataExportService, log *slog.Logger) {
+	authapi.RegisterDataExportServiceServer(gRPC, &dataExportGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *dataExportGRPCApi) ExportMyData(ctx context.Context, _ *authapi.ExportMyDataRequest) (*authapi.ExportMyDataResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("exporting user data")
+
+	job, err := a.service.ExportMyData(ctx)
+	if err != nil {
+		log.Error("failed to export user data", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to export user data")
+	}
+	return &authapi.ExportMyDataResponse{Job: exportJobToProto(job)}, nil
+}
+
+func (a *dataExportGRPCApi) ExportUserData(ctx context.Context, request *authapi.ExportUserDataRequest) (*authapi.ExportUserDataResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("exporting user data")
+
+	id, err := uuid.Parse(request.UserId)
+	if err != nil {
+		log.Error("failed to parse user id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect user id")
+	}
+
+	job, err := a.service.ExportUserData(ctx, id)
+	if err != nil {
+		log.Error("failed to export user data", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to export user data")
+	}
+	return &authapi.ExportUserDataResponse{Job: exportJobToProto(job)}, nil
+}
+
+func (a *dataExportGRPCApi) GetExportJob(ctx context.Context, request *authapi.GetExportJobRequest) (*authapi.GetExportJobResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse export job id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect export job id")
+	}
+
+	job, err := a.service.GetExportJob(ctx, id)
+	if err != nil {
+		log.Error("failed to get export job", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get export job")
+	}
+	return &authapi.GetExportJobResponse{Job: exportJobToProto(job)}, nil
+}
+
+func exportJobToProto(j *exports.Job) *authapi.ExportJob {
+	return &authapi.ExportJob{
+		Id:          j.ID().String(),
+		UserId:      j.UserID().String(),
+		Status:      j.Status().String(),
+		Archive:     j.Archive(),
+		Failure:     j.Failure(),
+		CreatedAt:   timestamppb.New(j.CreatedAt()),
+		CompletedAt: optionalTimestamp(j.CompletedAt()),
+		ExpiresAt:   optionalTimestamp(j.ExpiresAt()),
+	}
+}
@@ -0,0 +1,123 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/gateway"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/interceptors"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/google/uuid"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"log/slog"
+	"strings"
+)
+
+type extAuthzGRPCApi struct {
+	authv3.UnimplementedAuthorizationServer
+	verifier interceptors.TokenVerifier
+	sessions interceptors.SessionVerifier
+	rules    gateway.Rules
+	log      *slog.Logger
+}
+
+// RegisterExtAuthz envoy.service.auth.v3.Authorization для фильтра ext_authz
+func RegisterExtAuthz(
+	gRPC *grpc.Server,
+	verifier interceptors.TokenVerifier,
+	sessions interceptors.SessionVerifier,
+	rules gateway.Rules,
+	log *slog.Logger,
+) {
+	authv3.RegisterAuthorizationServer(gRPC, &extAuthzGRPCApi{
+		verifier: verifier,
+		sessions: sessions,
+		rules:    rules,
+		log:      log,
+	})
+}
+
+func (a *extAuthzGRPCApi) Check(ctx context.Context, request *authv3.CheckRequest) (*authv3.CheckResponse, error) {
+	httpReq := request.GetAttributes().GetRequest().GetHttp()
+	log := logger.LogWithContext(ctx, a.log).With(
+		slog.String("http_method", httpReq.GetMethod()),
+		slog.String("http_path", httpReq.GetPath()),
+	)
+	log.Info("checking proxied request")
+
+	rule, err := a.rules.Match(httpReq.GetMethod(), httpReq.GetPath())
+	if err != nil {
+		log.Warn("proxied request denied", slog.String("error", err.Error()))
+		return deniedCheckResponse(err), nil
+	}
+
+	var (
+		userID        uuid.UUID
+		role          users.Role
+		principal     users.PrincipalType
+		authenticated bool
+	)
+	// Envoy передает имена заголовков в нижнем регистре
+	if token, ok := strings.CutPrefix(httpReq.GetHeaders()["authorization"], "Bearer "); ok && token != "" {
+		claims, err := a.verifier.ValidateToken(token)
+		if err == nil {
+			// отозванный или заблокированный токен проксируется как анонимный запрос
+			err = interceptors.VerifySession(ctx, a.sessions, claims)
+		}
+		if err != nil {
+			log.Warn("invalid token", slog.String("error", err.Error()))
+		} else {
+			userID, role, principal, authenticated = claims.UserID, users.Role(claims.Role), claims.Principal(), true
+		}
+	}
+
+	if err := rule.Authorize(authenticated, role); err != nil {
+		log.Warn("proxied request denied", slog.String("error", err.Error()))
+		return deniedCheckResponse(err), nil
+	}
+
+	log.Info("proxied request allowed")
+	res := &authv3.OkHttpResponse{
+		HeadersToRemove: []string{gateway.HeaderUserID, gateway.HeaderUserRole, gateway.HeaderPrincipalType},
+	}
+	if authenticated {
+		// у токенов client_credentials клиента OAuth нет пользователя
+		if userID != uuid.Nil {
+			res.Headers = append(res.Headers, checkHeader(gateway.HeaderUserID, userID.String()))
+		}
+		if role != "" {
+			res.Headers = append(res.Headers, checkHeader(gateway.HeaderUserRole, role.String()))
+		}
+		res.Headers = append(res.Headers, checkHeader(gateway.HeaderPrincipalType, principal.String()))
+	}
+	return &authv3.CheckResponse{
+		Status:       &rpcstatus.Status{Code: int32(codes.OK)},
+		HttpResponse: &authv3.CheckResponse_OkResponse{OkResponse: res},
+	}, nil
+}
+
+func deniedCheckResponse(err error) *authv3.CheckResponse {
+	code, httpCode := codes.PermissionDenied, typev3.StatusCode_Forbidden
+	res := &authv3.DeniedHttpResponse{Body: err.Error()}
+	if errors.Is(err, gateway.ErrUnauthenticated) {
+		code, httpCode = codes.Unauthenticated, typev3.StatusCode_Unauthorized
+		res.Headers = []*corev3.HeaderValueOption{checkHeader("www-authenticate", "Bearer")}
+	}
+	res.Status = &typev3.HttpStatus{Code: httpCode}
+	return &authv3.CheckResponse{
+		Status:       &rpcstatus.Status{Code: int32(code), Message: err.Error()},
+		HttpResponse: &authv3.CheckResponse_DeniedResponse{DeniedResponse: res},
+	}
+}
+
+func checkHeader(key string, value string) *corev3.HeaderValueOption {
+	return &corev3.HeaderValueOption{
+		Header:       &corev3.HeaderValue{Key: key, Value: value},
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+	}
+}
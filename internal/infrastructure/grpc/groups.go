@@ -0,0 +1,217 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/groups"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+)
+
+type groupGRPCApi struct {
+	authapi.UnimplementedGroupServiceServer
+	service application.GroupService
+	log     *slog.Logger
+}
+
+func RegisterGroups(gRPC *grpc.Server, service application.GroupService, log *slog.Logger) {
+	authapi.RegisterGroupServiceServer(gRPC, &groupGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *groupGRPCApi) CreateGroup(ctx context.Context, request *authapi.CreateGroupRequest) (*authapi.CreateGroupResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("creating group")
+
+	group, err := a.service.CreateGroup(ctx, request.Name, request.Description)
+	if err != nil {
+		log.Error("failed to create group", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to create group")
+	}
+	return &authapi.CreateGroupResponse{Group: groupToProto(group)}, nil
+}
+
+func (a *groupGRPCApi) GetGroup(ctx context.Context, request *authapi.GetGroupRequest) (*authapi.GetGroupResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting group")
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse group id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect group id")
+	}
+
+	group, err := a.service.GetGroup(ctx, id)
+	if err != nil {
+		log.Error("failed to get group", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get group")
+	}
+	return &authapi.GetGroupResponse{Group: groupToProto(group)}, nil
+}
+
+func (a *groupGRPCApi) ListGroups(ctx context.Context, request *authapi.ListGroupsRequest) (*authapi.ListGroupsResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting groups")
+
+	list, err := a.service.ListGroups(ctx, int(request.Limit), int(request.Offset))
+	if err != nil {
+		log.Error("failed to get groups", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get groups")
+	}
+	return &authapi.ListGroupsResponse{Groups: groupsToProto(list)}, nil
+}
+
+func (a *groupGRPCApi) UpdateGroup(ctx context.Context, request *authapi.UpdateGroupRequest) (*authapi.UpdateGroupResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("updating group")
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse group id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect group id")
+	}
+
+	group, err := a.service.UpdateGroup(ctx, id, request.Name, request.Description)
+	if err != nil {
+		log.Error("failed to update group", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to update group")
+	}
+	return &authapi.UpdateGroupResponse{Group: groupToProto(group)}, nil
+}
+
+func (a *groupGRPCApi) DeleteGroup(ctx context.Context, request *authapi.DeleteGroupRequest) (*authapi.DeleteGroupResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse group id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect group id")
+	}
+
+	if err = a.service.DeleteGroup(ctx, id); err != nil {
+		log.Error("failed to delete group", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to delete group")
+	}
+	log.Info("group deleted")
+	return &authapi.DeleteGroupResponse{}, nil
+}
+
+func (a *groupGRPCApi) AddGroupMember(ctx context.Context, request *authapi.AddGroupMemberRequest) (*authapi.AddGroupMemberResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	groupID, member, err := groupMemberFromProto(request.GroupId, request.Member)
+	if err != nil {
+		log.Error("failed to parse group member", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if err = a.service.AddGroupMember(ctx, groupID, member); err != nil {
+		log.Error("failed to add group member", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to add group member")
+	}
+	return &authapi.AddGroupMemberResponse{}, nil
+}
+
+func (a *groupGRPCApi) RemoveGroupMember(ctx context.Context, request *authapi.RemoveGroupMemberRequest) (*authapi.RemoveGroupMemberResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	groupID, member, err := groupMemberFromProto(request.GroupId, request.Member)
+	if err != nil {
+		log.Error("failed to parse group member", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if err = a.service.RemoveGroupMember(ctx, groupID, member); err != nil {
+		log.Error("failed to remove group member", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to remove group member")
+	}
+	return &authapi.RemoveGroupMemberResponse{}, nil
+}
+
+func (a *groupGRPCApi) ListGroupMembers(ctx context.Context, request *authapi.ListGroupMembersRequest) (*authapi.ListGroupMembersResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting group members")
+
+	groupID, err := uuid.Parse(request.GroupId)
+	if err != nil {
+		log.Error("failed to parse group id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect group id")
+	}
+
+	list, err := a.service.ListGroupMembers(ctx, groupID, int(request.Limit), int(request.Offset))
+	if err != nil {
+		log.Error("failed to get group members", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get group members")
+	}
+
+	res := make([]*authapi.GroupMember, 0, len(list))
+	for _, m := range list {
+		res = append(res, &authapi.GroupMember{Type: m.Type.String(), Id: m.ID.String()})
+	}
+	return &authapi.ListGroupMembersResponse{Members: res}, nil
+}
+
+func (a *groupGRPCApi) ListUserGroups(ctx context.Context, request *authapi.ListUserGroupsRequest) (*authapi.ListUserGroupsResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting user groups")
+
+	userID, err := uuid.Parse(request.UserId)
+	if err != nil {
+		log.Error("failed to parse user id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect user id")
+	}
+
+	list, err := a.service.ListUserGroups(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user groups", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get user groups")
+	}
+	return &authapi.ListUserGroupsResponse{Groups: groupsToProto(list)}, nil
+}
+
+// groupMemberFromProto ошибка уже переведена в gRPC статус
+func groupMemberFromProto(groupID string, member *authapi.GroupMember) (uuid.UUID, groups.Member, error) {
+	id, err := uuid.Parse(groupID)
+	if err != nil {
+		return uuid.Nil, groups.Member{}, status.Error(codes.InvalidArgument, "incorrect group id")
+	}
+	if member == nil {
+		return uuid.Nil, groups.Member{}, status.Error(codes.InvalidArgument, "member is required")
+	}
+	memberType, err := groups.NewMemberType(member.Type)
+	if err != nil {
+		return uuid.Nil, groups.Member{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	memberID, err := uuid.Parse(member.Id)
+	if err != nil {
+		return uuid.Nil, groups.Member{}, status.Error(codes.InvalidArgument, "incorrect member id")
+	}
+	return id, groups.Member{Type: memberType, ID: memberID}, nil
+}
+
+func groupsToProto(list []*groups.Group) []*authapi.Group {
+	res := make([]*authapi.Group, 0, len(list))
+	for _, group := range list {
+		res = append(res, groupToProto(group))
+	}
+	return res
+}
+
+func groupToProto(group *groups.Group) *authapi.Group {
+	return &authapi.Group{
+		Id:             group.ID().String(),
+		OrganizationId: group.TenantID().String(),
+		Name:           group.Name(),
+		Description:    group.Description(),
+		CreatedAt:      timestamppb.New(group.CreatedAt()),
+		UpdatedAt:      timestamppb.New(group.UpdatedAt()),
+	}
+}
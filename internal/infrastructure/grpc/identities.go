@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+)
+
+type identityGRPCApi struct {
+	authapi.UnimplementedIdentityServiceServer
+	service application.FederationService
+	log     *slog.Logger
+}
+
+func RegisterIdentities(gRPC *grpc.Server, service application.FederationService, log *slog.Logger) {
+	authapi.RegisterIdentityServiceServer(gRPC, &identityGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *identityGRPCApi) ListIdentities(ctx context.Context, request *authapi.ListIdentitiesRequest) (*authapi.ListIdentitiesResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting identities")
+
+	userID, err := optionalUUID(request.UserId)
+	if err != nil {
+		log.Error("failed to parse user id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect user id")
+	}
+
+	identities, err := a.service.ListIdentities(ctx, userID)
+	if err != nil {
+		log.Error("failed to get identities", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get identities")
+	}
+
+	res := make([]*authapi.Identity, 0, len(identities))
+	for _, i := range identities {
+		res = append(res, identityToProto(i))
+	}
+	return &authapi.ListIdentitiesResponse{Identities: res}, nil
+}
+
+func (a *identityGRPCApi) UnlinkIdentity(ctx context.Context, request *authapi.UnlinkIdentityRequest) (*authapi.UnlinkIdentityResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("unlinking identity")
+
+	userID, err := optionalUUID(request.UserId)
+	if err != nil {
+		log.Error("failed to parse user id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect user id")
+	}
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse identity id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect identity id")
+	}
+
+	if err = a.service.UnlinkIdentity(ctx, userID, id); err != nil {
+		log.Error("failed to unlink identity", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to unlink identity")
+	}
+	return &authapi.UnlinkIdentityResponse{}, nil
+}
+
+// optionalUUID пустая строка означает uuid.Nil
+func optionalUUID(s string) (uuid.UUID, error) {
+	if s == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(s)
+}
+
+func identityToProto(i *identity.Identity) *authapi.Identity {
+	return &authapi.Identity{
+		Id:        i.ID().String(),
+		Provider:  i.Provider(),
+		Subject:   i.Subject(),
+		Email:     i.Email(),
+		CreatedAt: timestamppb.New(i.CreatedAt()),
+	}
+}
@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+)
+
+type introspectionGRPCApi struct {
+	authapi.UnimplementedTokenIntrospectionServiceServer
+	service application.IntrospectionService
+	log     *slog.Logger
+}
+
+func RegisterIntrospection(gRPC *grpc.Server, service application.IntrospectionService, log *slog.Logger) {
+	authapi.RegisterTokenIntrospectionServiceServer(gRPC, &introspectionGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *introspectionGRPCApi) IntrospectToken(ctx context.Context, request *authapi.IntrospectTokenRequest) (*authapi.IntrospectTokenResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("introspecting token")
+
+	if request.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	res, err := a.service.IntrospectToken(ctx, request.Token)
+	if err != nil {
+		log.Error("failed to introspect token", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to introspect token")
+	}
+	return introspectionToProto(res), nil
+}
+
+func introspectionToProto(i oauth.Introspection) *authapi.IntrospectTokenResponse {
+	if !i.Active {
+		return &authapi.IntrospectTokenResponse{}
+	}
+	res := &authapi.IntrospectTokenResponse{
+		Active:        true,
+		TokenType:     i.TokenType,
+		Subject:       i.Subject,
+		ClientId:      i.ClientID,
+		Scopes:        i.Scope,
+		PrincipalType: i.Principal.String(),
+		SessionId:     i.SessionID,
+		TokenId:       i.TokenID,
+	}
+	if !i.IssuedAt.IsZero() {
+		res.IssuedAt = timestamppb.New(i.IssuedAt)
+	}
+	if !i.ExpiresAt.IsZero() {
+		res.ExpiresAt = timestamppb.New(i.ExpiresAt)
+	}
+	return res
+}
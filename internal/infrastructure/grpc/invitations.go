@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/invitations"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+	"time"
+)
+
+type invitationGRPCApi struct {
+	authapi.UnimplementedInvitationServiceServer
+	service application.InvitationService
+	log     *slog.Logger
+}
+
+func RegisterInvitations(gRPC *grpc.Server, service application.InvitationService, log *slog.Logger) {
+	authapi.RegisterInvitationServiceServer(gRPC, &invitationGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *invitationGRPCApi) CreateInvitation(ctx context.Context, request *authapi.CreateInvitationRequest) (*authapi.CreateInvitationResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("creating invitation")
+
+	invitation, code, err := a.service.CreateInvitation(ctx, request.Email, request.Role)
+	if err != nil {
+		log.Error("failed to create invitation", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to create invitation")
+	}
+	return &authapi.CreateInvitationResponse{
+		Invitation: invitationToProto(invitation),
+		Code:       code,
+	}, nil
+}
+
+func (a *invitationGRPCApi) ListInvitations(ctx context.Context, request *authapi.ListInvitationsRequest) (*authapi.ListInvitationsResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting invitations")
+
+	list, err := a.service.ListInvitations(ctx, int(request.Limit), int(request.Offset))
+	if err != nil {
+		log.Error("failed to get invitations", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get invitations")
+	}
+
+	res := make([]*authapi.Invitation, 0, len(list))
+	for _, invitation := range list {
+		res = append(res, invitationToProto(invitation))
+	}
+	return &authapi.ListInvitationsResponse{Invitations: res}, nil
+}
+
+func (a *invitationGRPCApi) RevokeInvitation(ctx context.Context, request *authapi.RevokeInvitationRequest) (*authapi.RevokeInvitationResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse invitation id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect invitation id")
+	}
+
+	if err = a.service.RevokeInvitation(ctx, id); err != nil {
+		log.Error("failed to revoke invitation", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to revoke invitation")
+	}
+	log.Info("invitation revoked")
+	return &authapi.RevokeInvitationResponse{}, nil
+}
+
+func (a *invitationGRPCApi) AcceptInvitation(ctx context.Context, request *authapi.AcceptInvitationRequest) (*authapi.AcceptInvitationResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("accepting invitation")
+
+	userID, err := a.service.AcceptInvitation(ctx, request.Code, request.Name, request.Password)
+	if err != nil {
+		log.Error("failed to accept invitation", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to accept invitation")
+	}
+	return &authapi.AcceptInvitationResponse{UserId: userID.String()}, nil
+}
+
+func invitationToProto(invitation *invitations.Invitation) *authapi.Invitation {
+	return &authapi.Invitation{
+		Id:             invitation.ID().String(),
+		OrganizationId: invitation.TenantID().String(),
+		Email:          invitation.Email().String(),
+		Role:           invitation.Role().String(),
+		Status:         string(invitation.Status(time.Now().UTC())),
+		InvitedBy:      invitation.InvitedBy().String(),
+		ExpiresAt:      timestamppb.New(invitation.ExpiresAt()),
+		CreatedAt:      timestamppb.New(invitation.CreatedAt()),
+	}
+}
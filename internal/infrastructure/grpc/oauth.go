@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+)
+
+type oauthClientGRPCApi struct {
+	authapi.UnimplementedOAuthClientServiceServer
+	service application.OAuthService
+	log     *slog.Logger
+}
+
+func RegisterOAuthClients(gRPC *grpc.Server, service application.OAuthService, log *slog.Logger) {
+	authapi.RegisterOAuthClientServiceServer(gRPC, &oauthClientGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *oauthClientGRPCApi) CreateOAuthClient(ctx context.Context, request *authapi.CreateOAuthClientRequest) (*authapi.CreateOAuthClientResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("creating oauth client")
+
+	client, secret, err := a.service.CreateClient(
+		ctx,
+		request.Name,
+		request.RedirectUris,
+		request.GrantTypes,
+		request.Scopes,
+		request.Confidential,
+	)
+	if err != nil {
+		log.Error("failed to create oauth client", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to create oauth client")
+	}
+
+	return &authapi.CreateOAuthClientResponse{
+		Client:       oauthClientToProto(client),
+		ClientSecret: secret,
+	}, nil
+}
+
+func (a *oauthClientGRPCApi) ListOAuthClients(ctx context.Context, request *authapi.ListOAuthClientsRequest) (*authapi.ListOAuthClientsResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting oauth clients")
+
+	clients, err := a.service.ListClients(ctx, int(request.Limit), int(request.Offset))
+	if err != nil {
+		log.Error("failed to get oauth clients", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get oauth clients")
+	}
+
+	res := make([]*authapi.OAuthClient, 0, len(clients))
+	for _, c := range clients {
+		res = append(res, oauthClientToProto(c))
+	}
+	return &authapi.ListOAuthClientsResponse{Clients: res}, nil
+}
+
+func (a *oauthClientGRPCApi) DeleteOAuthClient(ctx context.Context, request *authapi.DeleteOAuthClientRequest) (*authapi.DeleteOAuthClientResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	if err := a.service.DeleteClient(ctx, request.ClientId); err != nil {
+		log.Error("failed to delete oauth client", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to delete oauth client")
+	}
+	log.Info("oauth client deleted")
+	return &authapi.DeleteOAuthClientResponse{Success: true}, nil
+}
+
+func oauthClientToProto(c *oauth.Client) *authapi.OAuthClient {
+	grantTypes := make([]string, 0, len(c.GrantTypes()))
+	for _, g := range c.GrantTypes() {
+		grantTypes = append(grantTypes, g.String())
+	}
+	return &authapi.OAuthClient{
+		ClientId:     c.ClientID(),
+		Name:         c.Name(),
+		RedirectUris: c.RedirectURIs(),
+		GrantTypes:   grantTypes,
+		Scopes:       c.Scope(),
+		Confidential: c.IsConfidential(),
+		CreatedAt:    timestamppb.New(c.CreatedAt()),
+	}
+}
@@ -0,0 +1,44 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"google.golang.org/grpc"
+	"log/slog"
+)
+
+type oidcGRPCApi struct {
+	authapi.UnimplementedOIDCServiceServer
+	service application.OAuthService
+	log     *slog.Logger
+}
+
+func RegisterOIDC(gRPC *grpc.Server, service application.OAuthService, log *slog.Logger) {
+	authapi.RegisterOIDCServiceServer(gRPC, &oidcGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *oidcGRPCApi) GetUserInfo(ctx context.Context, _ *authapi.GetUserInfoRequest) (*authapi.GetUserInfoResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting user info")
+
+	info, err := a.service.UserInfo(ctx)
+	if err != nil {
+		log.Warn("failed to get user info", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get user info")
+	}
+
+	res := &authapi.GetUserInfoResponse{Sub: info.Subject}
+	if info.HasProfile() {
+		res.Name = &info.Name
+	}
+	if info.HasEmail() {
+		res.Email = &info.Email
+		res.EmailVerified = &info.EmailVerified
+	}
+	return res, nil
+}
@@ -0,0 +1,140 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/helper/ctxkeys"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+)
+
+type organizationGRPCApi struct {
+	authapi.UnimplementedOrganizationServiceServer
+	service     application.OrganizationService
+	userService application.UserService
+	log         *slog.Logger
+}
+
+func RegisterOrganizations(gRPC *grpc.Server, service application.OrganizationService, userService application.UserService, log *slog.Logger) {
+	authapi.RegisterOrganizationServiceServer(gRPC, &organizationGRPCApi{
+		service:     service,
+		userService: userService,
+		log:         log,
+	})
+}
+
+func (a *organizationGRPCApi) CreateOrganization(ctx context.Context, request *authapi.CreateOrganizationRequest) (*authapi.CreateOrganizationResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("creating organization")
+
+	organization, err := a.service.CreateOrganization(ctx, request.Slug, request.Name)
+	if err != nil {
+		log.Error("failed to create organization", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to create organization")
+	}
+	return &authapi.CreateOrganizationResponse{Organization: organizationToProto(organization)}, nil
+}
+
+func (a *organizationGRPCApi) GetOrganization(ctx context.Context, request *authapi.GetOrganizationRequest) (*authapi.GetOrganizationResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting organization")
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse organization id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect organization id")
+	}
+
+	organization, err := a.service.GetOrganization(ctx, id)
+	if err != nil {
+		log.Error("failed to get organization", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get organization")
+	}
+	return &authapi.GetOrganizationResponse{Organization: organizationToProto(organization)}, nil
+}
+
+func (a *organizationGRPCApi) ListOrganizations(ctx context.Context, request *authapi.ListOrganizationsRequest) (*authapi.ListOrganizationsResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting organizations")
+
+	list, err := a.service.ListOrganizations(ctx, int(request.Limit), int(request.Offset))
+	if err != nil {
+		log.Error("failed to get organizations", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get organizations")
+	}
+
+	res := make([]*authapi.Organization, 0, len(list))
+	for _, organization := range list {
+		res = append(res, organizationToProto(organization))
+	}
+	return &authapi.ListOrganizationsResponse{Organizations: res}, nil
+}
+
+func (a *organizationGRPCApi) UpdateOrganization(ctx context.Context, request *authapi.UpdateOrganizationRequest) (*authapi.UpdateOrganizationResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("updating organization")
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse organization id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect organization id")
+	}
+
+	organization, err := a.service.UpdateOrganization(ctx, id, request.Slug, request.Name)
+	if err != nil {
+		log.Error("failed to update organization", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to update organization")
+	}
+	return &authapi.UpdateOrganizationResponse{Organization: organizationToProto(organization)}, nil
+}
+
+func (a *organizationGRPCApi) DeleteOrganization(ctx context.Context, request *authapi.DeleteOrganizationRequest) (*authapi.DeleteOrganizationResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse organization id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect organization id")
+	}
+
+	if err = a.service.DeleteOrganization(ctx, id); err != nil {
+		log.Error("failed to delete organization", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to delete organization")
+	}
+	log.Info("organization deleted")
+	return &authapi.DeleteOrganizationResponse{}, nil
+}
+
+// Login организация из поля запроса важнее заголовка x-organization
+func (a *organizationGRPCApi) Login(ctx context.Context, request *authapi.OrganizationLoginRequest) (*authapi.OrganizationLoginResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("logging in")
+
+	if request.Organization != "" {
+		ctx = context.WithValue(ctx, ctxkeys.Organization, request.Organization)
+	}
+	token, err := a.userService.Login(ctx, request.Email, request.Password)
+	if err != nil {
+		log.Error("failed to login", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to login")
+	}
+	log.Info("success login")
+	return &authapi.OrganizationLoginResponse{Token: token}, nil
+}
+
+func organizationToProto(organization *organizations.Organization) *authapi.Organization {
+	return &authapi.Organization{
+		Id:        organization.ID().String(),
+		Slug:      organization.Slug(),
+		Name:      organization.Name(),
+		CreatedAt: timestamppb.New(organization.CreatedAt()),
+		UpdatedAt: timestamppb.New(organization.UpdatedAt()),
+	}
+}
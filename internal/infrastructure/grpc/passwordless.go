@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"google.golang.org/grpc"
+	"log/slog"
+)
+
+type passwordlessGRPCApi struct {
+	authapi.UnimplementedPasswordlessServiceServer
+	service application.PasswordlessService
+	log     *slog.Logger
+}
+
+func RegisterPasswordless(gRPC *grpc.Server, service application.PasswordlessService, log *slog.Logger) {
+	authapi.RegisterPasswordlessServiceServer(gRPC, &passwordlessGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *passwordlessGRPCApi) StartPasswordlessLogin(
+	ctx context.Context,
+	request *authapi.StartPasswordlessLoginRequest,
+) (*authapi.StartPasswordlessLoginResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("starting passwordless login")
+
+	if err := a.service.StartPasswordlessLogin(ctx, request.Email); err != nil {
+		log.Error("failed to start passwordless login", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to start passwordless login")
+	}
+	return &authapi.StartPasswordlessLoginResponse{}, nil
+}
+
+func (a *passwordlessGRPCApi) CompletePasswordlessLogin(
+	ctx context.Context,
+	request *authapi.CompletePasswordlessLoginRequest,
+) (*authapi.CompletePasswordlessLoginResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("completing passwordless login")
+
+	token, err := a.service.CompletePasswordlessLogin(ctx, request.Link, request.Email, request.Code)
+	if err != nil {
+		log.Error("failed to complete passwordless login", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to complete passwordless login")
+	}
+	return &authapi.CompletePasswordlessLoginResponse{Token: token}, nil
+}
@@ -0,0 +1,38 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"google.golang.org/grpc"
+	"log/slog"
+)
+
+type passwordGRPCApi struct {
+	authapi.UnimplementedPasswordServiceServer
+	service application.UserService
+	log     *slog.Logger
+}
+
+func RegisterPasswords(gRPC *grpc.Server, service application.UserService, log *slog.Logger) {
+	authapi.RegisterPasswordServiceServer(gRPC, &passwordGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *passwordGRPCApi) ChangeTemporaryPassword(
+	ctx context.Context,
+	request *authapi.ChangeTemporaryPasswordRequest,
+) (*authapi.ChangeTemporaryPasswordResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("changing temporary password")
+
+	token, err := a.service.ChangeTemporaryPassword(ctx, request.Email, request.TemporaryPassword, request.NewPassword)
+	if err != nil {
+		log.Error("failed to change temporary password", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to change temporary password")
+	}
+	return &authapi.ChangeTemporaryPasswordResponse{Token: token}, nil
+}
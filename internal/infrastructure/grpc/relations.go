@@ -0,0 +1,139 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"google.golang.org/grpc"
+	"log/slog"
+)
+
+type relationshipGRPCApi struct {
+	authapi.UnimplementedRelationshipServiceServer
+	service application.RelationshipService
+	log     *slog.Logger
+}
+
+func RegisterRelationships(gRPC *grpc.Server, service application.RelationshipService, log *slog.Logger) {
+	authapi.RegisterRelationshipServiceServer(gRPC, &relationshipGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *relationshipGRPCApi) WriteRelationships(ctx context.Context, request *authapi.WriteRelationshipsRequest) (*authapi.WriteRelationshipsResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	tuples, err := tuplesFromProto(request.Relationships)
+	if err != nil {
+		log.Error("failed to parse relationships", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to write relationships")
+	}
+	revision, err := a.service.WriteRelationships(ctx, tuples)
+	if err != nil {
+		log.Error("failed to write relationships", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to write relationships")
+	}
+	log.Info("relationships written", slog.Int("count", len(tuples)))
+	return &authapi.WriteRelationshipsResponse{ConsistencyToken: revision.Token()}, nil
+}
+
+func (a *relationshipGRPCApi) DeleteRelationships(ctx context.Context, request *authapi.DeleteRelationshipsRequest) (*authapi.DeleteRelationshipsResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	tuples, err := tuplesFromProto(request.Relationships)
+	if err != nil {
+		log.Error("failed to parse relationships", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to delete relationships")
+	}
+	revision, err := a.service.DeleteRelationships(ctx, tuples)
+	if err != nil {
+		log.Error("failed to delete relationships", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to delete relationships")
+	}
+	log.Info("relationships deleted", slog.Int("count", len(tuples)))
+	return &authapi.DeleteRelationshipsResponse{ConsistencyToken: revision.Token()}, nil
+}
+
+func (a *relationshipGRPCApi) Check(ctx context.Context, request *authapi.CheckRequest) (*authapi.CheckResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	object, err := relations.ParseObject(request.Object)
+	if err != nil {
+		return nil, statusFromError(err, "failed to check relationship")
+	}
+	subject, err := relations.ParseSubject(request.Subject)
+	if err != nil {
+		return nil, statusFromError(err, "failed to check relationship")
+	}
+
+	allowed, revision, err := a.service.Check(ctx, object, request.Relation, subject, request.ConsistencyToken)
+	if err != nil {
+		log.Error("failed to check relationship", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to check relationship")
+	}
+	return &authapi.CheckResponse{Allowed: allowed, ConsistencyToken: revision.Token()}, nil
+}
+
+func (a *relationshipGRPCApi) Expand(ctx context.Context, request *authapi.ExpandRequest) (*authapi.ExpandResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	object, err := relations.ParseObject(request.Object)
+	if err != nil {
+		return nil, statusFromError(err, "failed to expand relationship")
+	}
+
+	node, revision, err := a.service.Expand(ctx, object, request.Relation, request.ConsistencyToken)
+	if err != nil {
+		log.Error("failed to expand relationship", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to expand relationship")
+	}
+	return &authapi.ExpandResponse{Root: nodeToProto(node), ConsistencyToken: revision.Token()}, nil
+}
+
+func (a *relationshipGRPCApi) LookupResources(ctx context.Context, request *authapi.LookupResourcesRequest) (*authapi.LookupResourcesResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	subject, err := relations.ParseSubject(request.Subject)
+	if err != nil {
+		return nil, statusFromError(err, "failed to lookup resources")
+	}
+
+	ids, revision, err := a.service.LookupResources(ctx, request.ObjectType, request.Relation, subject, request.ConsistencyToken, int(request.Limit))
+	if err != nil {
+		log.Error("failed to lookup resources", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to lookup resources")
+	}
+	return &authapi.LookupResourcesResponse{ObjectIds: ids, ConsistencyToken: revision.Token()}, nil
+}
+
+func tuplesFromProto(list []*authapi.Relationship) ([]relations.Tuple, error) {
+	tuples := make([]relations.Tuple, 0, len(list))
+	for _, r := range list {
+		tuple, err := relations.NewTuple(r.Object, r.Relation, r.Subject)
+		if err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, tuple)
+	}
+	return tuples, nil
+}
+
+func nodeToProto(node *relations.Node) *authapi.ExpandNode {
+	subjects := make([]string, 0, len(node.Subjects))
+	for _, s := range node.Subjects {
+		subjects = append(subjects, s.String())
+	}
+	children := make([]*authapi.ExpandNode, 0, len(node.Children))
+	for _, child := range node.Children {
+		children = append(children, nodeToProto(child))
+	}
+	return &authapi.ExpandNode{
+		Object:   node.Object.String(),
+		Relation: node.Relation,
+		Subjects: subjects,
+		Children: children,
+	}
+}
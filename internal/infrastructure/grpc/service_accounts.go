@@ -0,0 +1,110 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+	"time"
+)
+
+type serviceAccountGRPCApi struct {
+	authapi.UnimplementedServiceAccountServiceServer
+	service application.ServiceAccountService
+	log     *slog.Logger
+}
+
+func RegisterServiceAccounts(gRPC *grpc.Server, service application.ServiceAccountService, log *slog.Logger) {
+	authapi.RegisterServiceAccountServiceServer(gRPC, &serviceAccountGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *serviceAccountGRPCApi) CreateServiceAccount(ctx context.Context, request *authapi.CreateServiceAccountRequest) (*authapi.CreateServiceAccountResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("creating service account")
+
+	account, secret, err := a.service.CreateServiceAccount(ctx, request.Name, request.Role)
+	if err != nil {
+		log.Error("failed to create service account", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to create service account")
+	}
+
+	return &authapi.CreateServiceAccountResponse{
+		ServiceAccount: serviceAccountToProto(account),
+		ClientSecret:   secret,
+	}, nil
+}
+
+func (a *serviceAccountGRPCApi) ListServiceAccounts(ctx context.Context, request *authapi.ListServiceAccountsRequest) (*authapi.ListServiceAccountsResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting service accounts")
+
+	accounts, err := a.service.ListServiceAccounts(ctx, int(request.Limit), int(request.Offset))
+	if err != nil {
+		log.Error("failed to get service accounts", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get service accounts")
+	}
+
+	res := make([]*authapi.ServiceAccount, 0, len(accounts))
+	for _, account := range accounts {
+		res = append(res, serviceAccountToProto(account))
+	}
+	return &authapi.ListServiceAccountsResponse{ServiceAccounts: res}, nil
+}
+
+func (a *serviceAccountGRPCApi) RotateServiceAccountSecret(ctx context.Context, request *authapi.RotateServiceAccountSecretRequest) (*authapi.RotateServiceAccountSecretResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("rotating service account secret")
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse service account id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect service account id")
+	}
+
+	gracePeriod := time.Duration(request.GracePeriodSeconds) * time.Second
+	secret, err := a.service.RotateServiceAccountSecret(ctx, id, gracePeriod)
+	if err != nil {
+		log.Error("failed to rotate service account secret", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to rotate service account secret")
+	}
+	return &authapi.RotateServiceAccountSecretResponse{ClientSecret: secret}, nil
+}
+
+func (a *serviceAccountGRPCApi) DisableServiceAccount(ctx context.Context, request *authapi.DisableServiceAccountRequest) (*authapi.DisableServiceAccountResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse service account id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect service account id")
+	}
+
+	if err = a.service.DisableServiceAccount(ctx, id); err != nil {
+		log.Error("failed to disable service account", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to disable service account")
+	}
+	log.Info("service account disabled")
+	return &authapi.DisableServiceAccountResponse{}, nil
+}
+
+func serviceAccountToProto(account *serviceaccounts.ServiceAccount) *authapi.ServiceAccount {
+	return &authapi.ServiceAccount{
+		Id:            account.ID().String(),
+		ClientId:      account.ClientID(),
+		Name:          account.Name(),
+		Role:          account.Role().String(),
+		ActiveSecrets: int32(len(account.ActiveSecrets(time.Now().UTC()))),
+		Disabled:      account.IsDisabled(),
+		CreatedAt:     timestamppb.New(account.CreatedAt()),
+	}
+}
@@ -0,0 +1,133 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"time"
+)
+
+type userAdminGRPCApi struct {
+	authapi.UnimplementedUserAdminServiceServer
+	service application.UserAdminService
+	log     *slog.Logger
+}
+
+func RegisterUserAdmin(gRPC *grpc.Server, service application.UserAdminService, log *slog.Logger) {
+	authapi.RegisterUserAdminServiceServer(gRPC, &userAdminGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *userAdminGRPCApi) SuspendUser(ctx context.Context, request *authapi.SuspendUserRequest) (*authapi.SuspendUserResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("suspending user")
+
+	id, err := uuid.Parse(request.UserId)
+	if err != nil {
+		log.Error("failed to parse user id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect user id")
+	}
+	var until time.Time
+	if request.Until != nil {
+		until = request.Until.AsTime()
+	}
+
+	if err = a.service.SuspendUser(ctx, id, request.Reason, until); err != nil {
+		log.Error("failed to suspend user", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to suspend user")
+	}
+	return &authapi.SuspendUserResponse{}, nil
+}
+
+func (a *userAdminGRPCApi) ReactivateUser(ctx context.Context, request *authapi.ReactivateUserRequest) (*authapi.ReactivateUserResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("reactivating user")
+
+	id, err := uuid.Parse(request.UserId)
+	if err != nil {
+		log.Error("failed to parse user id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect user id")
+	}
+
+	if err = a.service.ReactivateUser(ctx, id); err != nil {
+		log.Error("failed to reactivate user", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to reactivate user")
+	}
+	return &authapi.ReactivateUserResponse{}, nil
+}
+
+func (a *userAdminGRPCApi) ForceLogout(ctx context.Context, request *authapi.ForceLogoutRequest) (*authapi.ForceLogoutResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("revoking user sessions")
+
+	id, err := uuid.Parse(request.UserId)
+	if err != nil {
+		log.Error("failed to parse user id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect user id")
+	}
+
+	if err = a.service.ForceLogout(ctx, id); err != nil {
+		log.Error("failed to revoke user sessions", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to revoke user sessions")
+	}
+	return &authapi.ForceLogoutResponse{}, nil
+}
+
+func (a *userAdminGRPCApi) SetTemporaryPassword(ctx context.Context, request *authapi.SetTemporaryPasswordRequest) (*authapi.SetTemporaryPasswordResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("setting temporary password")
+
+	id, err := uuid.Parse(request.UserId)
+	if err != nil {
+		log.Error("failed to parse user id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect user id")
+	}
+
+	if err = a.service.SetTemporaryPassword(ctx, id, request.Password); err != nil {
+		log.Error("failed to set temporary password", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to set temporary password")
+	}
+	return &authapi.SetTemporaryPasswordResponse{}, nil
+}
+
+func (a *userAdminGRPCApi) ReassignEmail(ctx context.Context, request *authapi.ReassignEmailRequest) (*authapi.ReassignEmailResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("reassigning user email")
+
+	id, err := uuid.Parse(request.UserId)
+	if err != nil {
+		log.Error("failed to parse user id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect user id")
+	}
+
+	if err = a.service.ReassignEmail(ctx, id, request.Email); err != nil {
+		log.Error("failed to reassign user email", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to reassign user email")
+	}
+	return &authapi.ReassignEmailResponse{}, nil
+}
+
+func (a *userAdminGRPCApi) EraseUser(ctx context.Context, request *authapi.EraseUserRequest) (*authapi.EraseUserResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("erasing user")
+
+	id, err := uuid.Parse(request.UserId)
+	if err != nil {
+		log.Error("failed to parse user id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect user id")
+	}
+
+	if err = a.service.EraseUser(ctx, id); err != nil {
+		log.Error("failed to erase user", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to erase user")
+	}
+	return &authapi.EraseUserResponse{}, nil
+}
@@ -30,8 +30,6 @@ func Register(gRPC *grpc.Server, service application.UserService, log *slog.Logg
 	})
 }
 
-//todo: разные типы ошибок (невалидные данные, пользователь уже есть, внутренняя ошибка)
-
 func (a *userGRPCApi) CreateUser(ctx context.Context, request *auth1.CreateUserRequest) (*auth1.CreateUserResponse, error) {
 	log := logger.LogWithContext(ctx, a.log)
 
@@ -40,7 +38,7 @@ func (a *userGRPCApi) CreateUser(ctx context.Context, request *auth1.CreateUserR
 	id, err := a.service.CreateUser(ctx, request.Name, request.Email, request.Password)
 	if err != nil {
 		log.Error("failed to create user", slog.String("error", err.Error()))
-		return nil, status.Error(codes.Internal, "failed to create user")
+		return nil, statusFromError(err, "failed to create user")
 	}
 
 	return &auth1.CreateUserResponse{Id: id.String()}, nil
@@ -54,12 +52,12 @@ func (a *userGRPCApi) GetUser(ctx context.Context, request *auth1.GetUserRequest
 	id, err := uuid.Parse(request.Id)
 	if err != nil {
 		log.Error("failed to parse uuid", slog.String("error", err.Error()))
-		return nil, status.Error(codes.Internal, "incorrect id")
+		return nil, status.Error(codes.InvalidArgument, "incorrect id")
 	}
 	user, err := a.service.GetUser(ctx, id)
 	if err != nil {
 		log.Error("failed to get user", slog.String("error", err.Error()))
-		return nil, status.Error(codes.Internal, "failed to get user")
+		return nil, statusFromError(err, "failed to get user")
 	}
 
 	//todo: пароль не должен возвращаться
@@ -84,7 +82,7 @@ func (a *userGRPCApi) GetListUsers(ctx context.Context, request *auth1.GetListUs
 	usrs, err := a.service.GetListUsers(ctx)
 	if err != nil {
 		log.Error("failed to get users", slog.String("error", err.Error()))
-		return nil, status.Error(codes.Internal, "failed to get users")
+		return nil, statusFromError(err, "failed to get users")
 	}
 	res := make([]*auth1.User, 0, len(usrs))
 	for _, usr := range usrs {
@@ -114,12 +112,12 @@ func (a *userGRPCApi) UpdateUser(ctx context.Context, request *auth1.UpdateUserR
 	id, err := uuid.Parse(request.Id)
 	if err != nil {
 		log.Error("failed to parse user id", slog.String("error", err.Error()))
-		return nil, status.Error(codes.Internal, "incorrect id")
+		return nil, status.Error(codes.InvalidArgument, "incorrect id")
 	}
 	err = a.service.UpdateUser(ctx, id, request.Name, request.Email, request.Password)
 	if err != nil {
 		log.Error("failed to update user", slog.String("error", err.Error()))
-		return nil, status.Error(codes.Internal, "failed to update user")
+		return nil, statusFromError(err, "failed to update user")
 	}
 	log.Info("user updated")
 	return &auth1.UpdateUserResponse{}, nil
@@ -132,12 +130,12 @@ func (a *userGRPCApi) DeleteUser(ctx context.Context, request *auth1.DeleteUserR
 	id, err := uuid.Parse(request.Id)
 	if err != nil {
 		log.Error("failed to parse user id", slog.String("error", err.Error()))
-		return nil, status.Error(codes.Internal, "incorrect id")
+		return nil, status.Error(codes.InvalidArgument, "incorrect id")
 	}
 	err = a.service.DeleteUser(ctx, id)
 	if err != nil {
 		log.Error("failed to delete user", slog.String("error", err.Error()))
-		return nil, status.Error(codes.Internal, "failed to delete user")
+		return nil, statusFromError(err, "failed to delete user")
 	}
 	log.Info("user deleted")
 	return &auth1.DeleteUserResponse{Success: true}, nil
@@ -149,7 +147,7 @@ func (a *userGRPCApi) Login(ctx context.Context, request *auth1.LoginRequest) (*
 	token, err := a.service.Login(ctx, request.Email, request.Password)
 	if err != nil {
 		log.Error("failed to login", slog.String("error", err.Error()))
-		return nil, status.Error(codes.Internal, "failed to login")
+		return nil, statusFromError(err, "failed to login")
 	}
 	log.Info("success login")
 	return &auth1.LoginResponse{Token: token}, nil
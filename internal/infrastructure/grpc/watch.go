@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+)
+
+type watchGRPCApi struct {
+	authapi.UnimplementedUserWatchServiceServer
+	service application.WatchService
+	log     *slog.Logger
+}
+
+func RegisterWatch(gRPC *grpc.Server, service application.WatchService, log *slog.Logger) {
+	authapi.RegisterUserWatchServiceServer(gRPC, &watchGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+var changeTypes = map[changes.Type]authapi.UserChange_Type{
+	changes.TypeCreated: authapi.UserChange_TYPE_CREATED,
+	changes.TypeUpdated: authapi.UserChange_TYPE_UPDATED,
+	changes.TypeDeleted: authapi.UserChange_TYPE_DELETED,
+}
+
+func (a *watchGRPCApi) WatchUsers(request *authapi.WatchUsersRequest, stream grpc.ServerStreamingServer[authapi.UserChange]) error {
+	ctx := stream.Context()
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("watching users", slog.Int64("from_position", request.FromPosition))
+
+	err := a.service.WatchUsers(ctx, request.FromPosition, func(change *changes.Change) error {
+		return stream.Send(&authapi.UserChange{
+			Position:   change.Position(),
+			Type:       changeTypes[change.Type()],
+			UserId:     change.UserID().String(),
+			Name:       change.Name(),
+			Email:      change.Email(),
+			Role:       change.Role(),
+			IsActive:   change.IsActive(),
+			OccurredAt: timestamppb.New(change.OccurredAt()),
+		})
+	})
+	if err != nil {
+		log.Error("failed to watch users", slog.String("error", err.Error()))
+		return statusFromError(err, "failed to watch users")
+	}
+	return nil
+}
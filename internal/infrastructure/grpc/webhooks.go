@@ -0,0 +1,186 @@
+package grpc
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+)
+
+type webhookGRPCApi struct {
+	authapi.UnimplementedWebhookServiceServer
+	service application.WebhookService
+	log     *slog.Logger
+}
+
+func RegisterWebhooks(gRPC *grpc.Server, service application.WebhookService, log *slog.Logger) {
+	authapi.RegisterWebhookServiceServer(gRPC, &webhookGRPCApi{
+		service: service,
+		log:     log,
+	})
+}
+
+func (a *webhookGRPCApi) CreateWebhook(ctx context.Context, request *authapi.CreateWebhookRequest) (*authapi.CreateWebhookResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("creating webhook")
+
+	subscription, err := a.service.CreateWebhook(ctx, request.Url, request.Events, request.Secret)
+	if err != nil {
+		log.Error("failed to create webhook", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to create webhook")
+	}
+
+	return &authapi.CreateWebhookResponse{
+		Webhook: webhookToProto(subscription),
+		Secret:  subscription.Secret().String(),
+	}, nil
+}
+
+func (a *webhookGRPCApi) ListWebhooks(ctx context.Context, _ *authapi.ListWebhooksRequest) (*authapi.ListWebhooksResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting webhooks")
+
+	subscriptions, err := a.service.ListWebhooks(ctx)
+	if err != nil {
+		log.Error("failed to get webhooks", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get webhooks")
+	}
+
+	res := make([]*authapi.Webhook, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		res = append(res, webhookToProto(s))
+	}
+	return &authapi.ListWebhooksResponse{Webhooks: res}, nil
+}
+
+func (a *webhookGRPCApi) DeleteWebhook(ctx context.Context, request *authapi.DeleteWebhookRequest) (*authapi.DeleteWebhookResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		log.Error("failed to parse webhook id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect id")
+	}
+
+	if err = a.service.DeleteWebhook(ctx, id); err != nil {
+		log.Error("failed to delete webhook", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to delete webhook")
+	}
+	log.Info("webhook deleted")
+	return &authapi.DeleteWebhookResponse{Success: true}, nil
+}
+
+func (a *webhookGRPCApi) ListWebhookDeliveries(ctx context.Context, request *authapi.ListWebhookDeliveriesRequest) (*authapi.ListWebhookDeliveriesResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	log.Info("getting webhook deliveries")
+
+	filter := webhooks.DeliveryFilter{
+		Limit:  int(request.Limit),
+		Offset: int(request.Offset),
+	}
+	if request.WebhookId != "" {
+		id, err := uuid.Parse(request.WebhookId)
+		if err != nil {
+			log.Error("failed to parse webhook id", slog.String("error", err.Error()))
+			return nil, status.Error(codes.InvalidArgument, "incorrect webhook id")
+		}
+		filter.SubscriptionID = id
+	}
+	if request.Status != "" {
+		st, err := webhooks.NewDeliveryStatus(request.Status)
+		if err != nil {
+			log.Error("failed to parse delivery status", slog.String("error", err.Error()))
+			return nil, status.Error(codes.InvalidArgument, "incorrect status")
+		}
+		filter.Status = st
+	}
+
+	deliveries, err := a.service.ListDeliveries(ctx, filter)
+	if err != nil {
+		log.Error("failed to get webhook deliveries", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get webhook deliveries")
+	}
+
+	res := make([]*authapi.WebhookDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		res = append(res, &authapi.WebhookDelivery{
+			Id:            d.ID().String(),
+			WebhookId:     d.SubscriptionID().String(),
+			EventId:       d.EventID().String(),
+			EventType:     d.EventType().String(),
+			Status:        d.Status().String(),
+			Attempts:      int32(d.Attempts()),
+			NextAttemptAt: timestamppb.New(d.NextAttemptAt()),
+			LastError:     d.LastError(),
+			CreatedAt:     timestamppb.New(d.CreatedAt()),
+			UpdatedAt:     timestamppb.New(d.UpdatedAt()),
+		})
+	}
+	return &authapi.ListWebhookDeliveriesResponse{Deliveries: res}, nil
+}
+
+func (a *webhookGRPCApi) ListWebhookDeliveryAttempts(ctx context.Context, request *authapi.ListWebhookDeliveryAttemptsRequest) (*authapi.ListWebhookDeliveryAttemptsResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	id, err := uuid.Parse(request.DeliveryId)
+	if err != nil {
+		log.Error("failed to parse delivery id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect delivery id")
+	}
+
+	attempts, err := a.service.ListDeliveryAttempts(ctx, id)
+	if err != nil {
+		log.Error("failed to get webhook delivery attempts", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to get webhook delivery attempts")
+	}
+
+	res := make([]*authapi.WebhookDeliveryAttempt, 0, len(attempts))
+	for _, at := range attempts {
+		res = append(res, &authapi.WebhookDeliveryAttempt{
+			Id:         at.ID().String(),
+			DeliveryId: at.DeliveryID().String(),
+			Number:     int32(at.Number()),
+			StatusCode: int32(at.StatusCode()),
+			Error:      at.Error(),
+			DurationMs: at.Duration().Milliseconds(),
+			CreatedAt:  timestamppb.New(at.CreatedAt()),
+		})
+	}
+	return &authapi.ListWebhookDeliveryAttemptsResponse{Attempts: res}, nil
+}
+
+func (a *webhookGRPCApi) RetryWebhookDelivery(ctx context.Context, request *authapi.RetryWebhookDeliveryRequest) (*authapi.RetryWebhookDeliveryResponse, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	id, err := uuid.Parse(request.DeliveryId)
+	if err != nil {
+		log.Error("failed to parse delivery id", slog.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, "incorrect delivery id")
+	}
+
+	if err = a.service.RetryDelivery(ctx, id); err != nil {
+		log.Error("failed to retry webhook delivery", slog.String("error", err.Error()))
+		return nil, statusFromError(err, "failed to retry webhook delivery")
+	}
+	log.Info("webhook delivery scheduled")
+	return &authapi.RetryWebhookDeliveryResponse{Success: true}, nil
+}
+
+func webhookToProto(s *webhooks.Subscription) *authapi.Webhook {
+	events := make([]string, 0, len(s.Events()))
+	for _, e := range s.Events() {
+		events = append(events, e.String())
+	}
+	return &authapi.Webhook{
+		Id:        s.ID().String(),
+		Url:       s.URL().String(),
+		Events:    events,
+		IsActive:  s.IsActive(),
+		CreatedAt: timestamppb.New(s.CreatedAt()),
+		UpdatedAt: timestamppb.New(s.UpdatedAt()),
+	}
+}
@@ -0,0 +1,106 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+const federatedStateCookie = "federated_state"
+
+// federatedStart уводит пользователя к провайдеру, исходный запрос /authorize сохраняется до возврата
+func (h *oauthHandler) federatedStart(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+	req := authorizationRequest(r.URL.Query())
+
+	redirectURL, state, err := h.federation.StartLogin(ctx, r.PathValue("provider"), req)
+	if err != nil {
+		log.Warn("failed to start federated login", slog.String("error", err.Error()))
+		h.authorizeError(w, r, req, err)
+		return
+	}
+
+	// state привязывается к браузеру, иначе чужой callback можно подсунуть жертве (login CSRF)
+	http.SetCookie(w, &http.Cookie{
+		Name:     federatedStateCookie,
+		Value:    state,
+		Path:     "/federated",
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+}
+
+// federatedCallback завершает вход у провайдера и возвращает код клиенту по исходному запросу
+func (h *oauthHandler) federatedCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+	query := r.URL.Query()
+	state := query.Get("state")
+
+	cookie, err := r.Cookie(federatedStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		log.Warn("federated state mismatch")
+		h.errorPage(w, http.StatusForbidden, "invalid_request", "state mismatch")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     federatedStateCookie,
+		Path:     "/federated",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecure(r),
+	})
+
+	// при ошибке провайдер присылает error вместо code, вход все равно нужно забрать
+	code := query.Get("code")
+	if query.Get("error") != "" {
+		log.Warn("identity provider returned error", slog.String("error", query.Get("error")))
+		code = ""
+	}
+
+	req, authCode, err := h.federation.CompleteLogin(ctx, r.PathValue("provider"), state, code)
+	if err != nil {
+		log.Warn("failed to complete federated login", slog.String("error", err.Error()))
+		h.authorizeError(w, r, req, err)
+		return
+	}
+
+	params := url.Values{"code": {authCode}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	redirect(w, r, req.RedirectURI, params)
+}
+
+// providerLinks ссылки на вход через провайдеров с параметрами текущего запроса /authorize
+func (h *oauthHandler) providerLinks(req oauth.AuthorizationRequest) []providerLink {
+	if h.federation == nil {
+		return nil
+	}
+	query := url.Values{
+		"response_type":         {req.ResponseType},
+		"client_id":             {req.ClientID},
+		"redirect_uri":          {req.RedirectURI},
+		"scope":                 {req.Scope},
+		"state":                 {req.State},
+		"code_challenge":        {req.CodeChallenge},
+		"code_challenge_method": {req.CodeChallengeMethod},
+		"nonce":                 {req.Nonce},
+	}.Encode()
+
+	providers := h.federation.Providers()
+	links := make([]providerLink, 0, len(providers))
+	for _, name := range providers {
+		links = append(links, providerLink{
+			Name: name,
+			URL:  "/federated/" + url.PathEscape(name) + "/start?" + query,
+		})
+	}
+	return links
+}
@@ -0,0 +1,72 @@
+package httpapi
+
+import (
+	mockapplication "github.com/LeoUraltsev/auth-service/internal/application/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestOAuth_federatedLogin(t *testing.T) {
+	req := oauth.AuthorizationRequest{
+		ResponseType: oauth.ResponseTypeCode,
+		ClientID:     "client",
+		RedirectURI:  "https://app.example.com/cb",
+		State:        "client-state",
+	}
+
+	ctrl := gomock.NewController(t)
+	federation := mockapplication.NewMockFederationService(ctrl)
+	federation.EXPECT().
+		StartLogin(gomock.Any(), "corp", gomock.Any()).
+		Return("https://idp.example.com/authorize?state=idp-state", "idp-state", nil)
+	federation.EXPECT().
+		CompleteLogin(gomock.Any(), "corp", "idp-state", "idp-code").
+		Return(req, "code", nil)
+
+	mux := http.NewServeMux()
+	RegisterOAuth(mux, mockapplication.NewMockOAuthService(ctrl), federation, log)
+	server := httptest.NewServer(RequestContext(log, mux))
+	t.Cleanup(server.Close)
+	client := &http.Client{CheckRedirect: noRedirect}
+
+	resp, err := client.Get(server.URL + "/federated/corp/start?client_id=client")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "https://idp.example.com/authorize?state=idp-state", resp.Header.Get("Location"))
+	var stateCookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == federatedStateCookie {
+			stateCookie = c
+		}
+	}
+	require.NotNil(t, stateCookie)
+
+	t.Run("state not bound to browser", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/federated/corp/callback?state=idp-state&code=idp-code")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("callback", func(t *testing.T) {
+		callback, _ := http.NewRequest(http.MethodGet, server.URL+"/federated/corp/callback?state=idp-state&code=idp-code", nil)
+		callback.AddCookie(stateCookie)
+		resp, err := client.Do(callback)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+
+		location, err := url.Parse(resp.Header.Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "app.example.com", location.Host)
+		assert.Equal(t, "code", location.Query().Get("code"))
+		assert.Equal(t, "client-state", location.Query().Get("state"))
+	})
+}
@@ -0,0 +1,310 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/gateway"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/interceptors"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/jwt"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const loginCSRFCookie = "login_csrf"
+
+// SessionCookie cookie браузерной сессии forward-auth. Domain общий родительский домен
+// сервиса и приложений, иначе cookie не дойдет до приложений
+type SessionCookie struct {
+	Name   string
+	Domain string
+}
+
+type forwardAuthHandler struct {
+	service  application.OAuthService
+	verifier interceptors.TokenVerifier
+	sessions interceptors.SessionVerifier
+	hosts    gateway.Hosts
+	cookie   SessionCookie
+	issuer   string
+	log      *slog.Logger
+}
+
+type loginPage struct {
+	CSRFToken string
+	Redirect  string
+	Email     string
+	Error     string
+}
+
+// RegisterForwardAuth /auth/verify для nginx auth_request и Traefik ForwardAuth, страница входа для браузеров
+func RegisterForwardAuth(
+	mux *http.ServeMux,
+	service application.OAuthService,
+	verifier interceptors.TokenVerifier,
+	sessions interceptors.SessionVerifier,
+	hosts gateway.Hosts,
+	cookie SessionCookie,
+	issuer string,
+	log *slog.Logger,
+) {
+	h := &forwardAuthHandler{
+		service:  service,
+		verifier: verifier,
+		sessions: sessions,
+		hosts:    hosts,
+		cookie:   cookie,
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		log:      log,
+	}
+	// прокси повторяет метод исходного запроса
+	mux.HandleFunc("/auth/verify", h.verify)
+	mux.HandleFunc("GET /auth/login", h.loginPage)
+	mux.HandleFunc("POST /auth/login", h.login)
+	mux.HandleFunc("POST /auth/logout", h.logout)
+}
+
+// verify 200 с заголовками пользователя, 401 без действующего токена и 403 при неподходящей роли.
+// Браузер без сессии на сайте с login_redirect перенаправляется на страницу входа
+func (h *forwardAuthHandler) verify(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+	method, original, err := originalRequest(r)
+	if err != nil {
+		log.Warn("proxied request denied", slog.String("error", err.Error()))
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	log = log.With(
+		slog.String("http_method", method),
+		slog.String("http_host", original.Host),
+		slog.String("http_path", original.Path),
+	)
+
+	host := h.hosts.Match(original.Host)
+	// правила сверяются с путем, декодированным один раз, как его увидит приложение
+	rule, err := host.Rules().Match(method, original.EscapedPath())
+	if err != nil {
+		log.Warn("proxied request denied", slog.String("error", err.Error()))
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	claims := h.authenticate(r, log)
+	var role users.Role
+	if claims != nil {
+		role = users.Role(claims.Role)
+	}
+
+	if err := rule.Authorize(claims != nil, role); err != nil {
+		log.Warn("proxied request denied", slog.String("error", err.Error()))
+		w.Header().Set("Cache-Control", "no-store")
+		if errors.Is(err, gateway.ErrForbidden) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if host.LoginRedirect() && acceptsHTML(r) {
+			http.Redirect(w, r, h.issuer+"/auth/login?"+url.Values{"rd": {original.String()}}.Encode(), http.StatusFound)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if claims != nil {
+		// у токенов client_credentials клиента OAuth нет пользователя
+		if claims.UserID != uuid.Nil {
+			w.Header().Set(gateway.HeaderUserID, claims.UserID.String())
+		}
+		if claims.Role != "" {
+			w.Header().Set(gateway.HeaderUserRole, claims.Role)
+		}
+		w.Header().Set(gateway.HeaderPrincipalType, claims.Principal().String())
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// authenticate заголовок Authorization важнее cookie, nil если токена нет, он не прошел проверку
+// или отозван принудительным выходом
+func (h *forwardAuthHandler) authenticate(r *http.Request, log *slog.Logger) *jwt.AuthClaims {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		cookie, err := r.Cookie(h.cookie.Name)
+		if err != nil || cookie.Value == "" {
+			return nil
+		}
+		token = cookie.Value
+	}
+	claims, err := h.verifier.ValidateToken(token)
+	if err == nil {
+		err = interceptors.VerifySession(r.Context(), h.sessions, claims)
+	}
+	if err != nil {
+		log.Warn("invalid token", slog.String("error", err.Error()))
+		return nil
+	}
+	return claims
+}
+
+func (h *forwardAuthHandler) loginPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+
+	rd := r.URL.Query().Get("rd")
+	if !h.allowedRedirect(rd) {
+		log.Warn("login redirect is not allowed", slog.String("rd", rd))
+		renderPage(w, h.log, http.StatusBadRequest, "error.html", errorPage{Code: "invalid_request", Description: "redirect is not allowed"})
+		return
+	}
+
+	csrf, err := randomString()
+	if err != nil {
+		log.Error("failed to generate csrf token", slog.String("error", err.Error()))
+		renderPage(w, h.log, http.StatusInternalServerError, "error.html", errorPage{Code: "server_error", Description: "internal error"})
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCSRFCookie,
+		Value:    csrf,
+		Path:     "/auth",
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	renderPage(w, h.log, http.StatusOK, "login.html", loginPage{CSRFToken: csrf, Redirect: rd})
+}
+
+// login выдает токен доступа в cookie сессии и возвращает пользователя на исходный адрес
+func (h *forwardAuthHandler) login(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+
+	if err := r.ParseForm(); err != nil {
+		renderPage(w, h.log, http.StatusBadRequest, "error.html", errorPage{Code: "invalid_request", Description: "malformed form"})
+		return
+	}
+	cookie, err := r.Cookie(loginCSRFCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.PostForm.Get("csrf_token"))) != 1 {
+		log.Warn("csrf token mismatch on login")
+		renderPage(w, h.log, http.StatusForbidden, "error.html", errorPage{Code: "invalid_request", Description: "csrf token mismatch"})
+		return
+	}
+	rd := r.PostForm.Get("rd")
+	if !h.allowedRedirect(rd) {
+		log.Warn("login redirect is not allowed", slog.String("rd", rd))
+		renderPage(w, h.log, http.StatusBadRequest, "error.html", errorPage{Code: "invalid_request", Description: "redirect is not allowed"})
+		return
+	}
+
+	email := r.PostForm.Get("email")
+	res, err := h.service.SessionLogin(ctx, email, r.PostForm.Get("password"))
+	if errors.Is(err, users.ErrInvalidCredentials) {
+		renderPage(w, h.log, http.StatusUnauthorized, "login.html", loginPage{
+			CSRFToken: cookie.Value,
+			Redirect:  rd,
+			Email:     email,
+			Error:     "Неверный email или пароль",
+		})
+		return
+	}
+	if err != nil {
+		log.Error("failed to login", slog.String("error", err.Error()))
+		renderPage(w, h.log, http.StatusInternalServerError, "error.html", errorPage{Code: "server_error", Description: "internal error"})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.cookie.Name,
+		Value:    res.AccessToken,
+		Path:     "/",
+		Domain:   h.cookie.Domain,
+		MaxAge:   int(res.ExpiresIn.Seconds()),
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, rd, http.StatusFound)
+}
+
+// logout токен не отзывается, он истечет сам, браузер только забывает cookie
+func (h *forwardAuthHandler) logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.cookie.Name,
+		Path:     "/",
+		Domain:   h.cookie.Domain,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecure(r),
+	})
+	if rd := r.URL.Query().Get("rd"); h.allowedRedirect(rd) {
+		http.Redirect(w, r, rd, http.StatusFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowedRedirect после входа можно вернуться только на сайт из конфига, иначе открытый редирект
+func (h *forwardAuthHandler) allowedRedirect(rd string) bool {
+	u, err := url.Parse(rd)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.User != nil {
+		return false
+	}
+	return h.hosts.Known(u.Host)
+}
+
+// originalRequest метод и адрес проксируемого запроса. Traefik передает X-Forwarded-*,
+// nginx - заголовки из proxy_set_header, обычно X-Original-URL или X-Original-URI
+func originalRequest(r *http.Request) (string, *url.URL, error) {
+	method := firstHeader(r, "X-Forwarded-Method", "X-Original-Method")
+	if method == "" {
+		method = r.Method
+	}
+	if raw := r.Header.Get("X-Original-URL"); raw != "" {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", nil, gateway.ErrPathNotValid
+		}
+		if u.Host != "" {
+			return method, u, nil
+		}
+	}
+
+	u := &url.URL{Scheme: "http", Host: r.Host, Path: "/"}
+	if isSecure(r) {
+		u.Scheme = "https"
+	}
+	if host := r.Header.Get("X-Forwarded-Host"); host != "" {
+		u.Host = host
+	}
+	// путь, который не разбирается, не подменяется на /, иначе запрос попал бы под правило корня
+	if raw := firstHeader(r, "X-Forwarded-Uri", "X-Original-URI"); raw != "" {
+		uri, err := url.ParseRequestURI(raw)
+		if err != nil {
+			return "", nil, gateway.ErrPathNotValid
+		}
+		u.Path, u.RawPath, u.RawQuery = uri.Path, uri.RawPath, uri.RawQuery
+	}
+	return method, u, nil
+}
+
+func firstHeader(r *http.Request, names ...string) string {
+	for _, name := range names {
+		if v := r.Header.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func acceptsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
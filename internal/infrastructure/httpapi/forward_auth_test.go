@@ -0,0 +1,201 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	mockapplication "github.com/LeoUraltsev/auth-service/internal/application/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/gateway"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sessionsFunc func(token oauth.AccessToken) error
+
+func (f sessionsFunc) VerifySession(_ context.Context, token oauth.AccessToken) error {
+	return f(token)
+}
+
+func newForwardAuthMux(t *testing.T, service *mockapplication.MockOAuthService) *http.ServeMux {
+	userID, loggedOutID := uuid.New(), uuid.New()
+	verifier := verifierFunc(func(token string) (*jwt.AuthClaims, error) {
+		switch token {
+		case "user-token":
+			return &jwt.AuthClaims{UserID: userID, Role: "user"}, nil
+		case "admin-token":
+			return &jwt.AuthClaims{UserID: userID, Role: "admin"}, nil
+		case "logged-out-token":
+			return &jwt.AuthClaims{UserID: loggedOutID, Role: "user"}, nil
+		}
+		return nil, errors.New("token not valid")
+	})
+	sessions := sessionsFunc(func(token oauth.AccessToken) error {
+		if token.UserID == loggedOutID {
+			return application.ErrTokenNotActive
+		}
+		return nil
+	})
+
+	admin, err := gateway.NewRule("/admin", nil, false, []string{"admin"})
+	require.NoError(t, err)
+	public, err := gateway.NewRule("/public", nil, true, nil)
+	require.NoError(t, err)
+	app, err := gateway.NewHost("app.example.com", true, gateway.Rules{admin, public})
+	require.NoError(t, err)
+	api, err := gateway.NewHost("api.example.com", false, nil)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	RegisterForwardAuth(mux, service, verifier, sessions, gateway.NewHosts([]gateway.Host{app, api}, nil),
+		SessionCookie{Name: "auth_session", Domain: "example.com"}, "https://auth.example.com/", log)
+	return mux
+}
+
+func TestForwardAuth_verify(t *testing.T) {
+	cases := []struct {
+		name         string
+		host         string
+		uri          string
+		bearer       string
+		cookie       string
+		html         bool
+		wantStatus   int
+		wantRole     string
+		wantLocation string
+	}{
+		{name: "bearer", host: "api.example.com", uri: "/orders", bearer: "user-token", wantStatus: http.StatusOK, wantRole: "user"},
+		{name: "session cookie", host: "app.example.com", uri: "/", cookie: "user-token", wantStatus: http.StatusOK, wantRole: "user"},
+		{name: "public", host: "app.example.com", uri: "/public/logo.png", wantStatus: http.StatusOK},
+		{name: "no token", host: "api.example.com", uri: "/orders", html: true, wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", host: "api.example.com", uri: "/orders", bearer: "broken", wantStatus: http.StatusUnauthorized},
+		{name: "revoked token", host: "api.example.com", uri: "/orders", bearer: "logged-out-token", wantStatus: http.StatusUnauthorized},
+		{name: "role", host: "app.example.com", uri: "/admin", cookie: "user-token", wantStatus: http.StatusForbidden},
+		{name: "admin", host: "app.example.com", uri: "/admin", cookie: "admin-token", wantStatus: http.StatusOK, wantRole: "admin"},
+		{name: "dot segments", host: "app.example.com", uri: "/public/../admin", cookie: "user-token", wantStatus: http.StatusForbidden},
+		{name: "encoded dot segments", host: "app.example.com", uri: "/public/%2e%2e/admin", cookie: "user-token", wantStatus: http.StatusForbidden},
+		{name: "decoded once", host: "app.example.com", uri: "/public/%252e%252e/admin", wantStatus: http.StatusOK},
+		{name: "path not valid", host: "app.example.com", uri: "/admin/%zz", wantStatus: http.StatusBadRequest},
+		{
+			name:         "login redirect",
+			host:         "app.example.com",
+			uri:          "/reports?month=5",
+			html:         true,
+			wantStatus:   http.StatusFound,
+			wantLocation: "https://auth.example.com/auth/login?rd=" + url.QueryEscape("https://app.example.com/reports?month=5"),
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := newForwardAuthMux(t, mockapplication.NewMockOAuthService(gomock.NewController(t)))
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/verify", nil)
+			req.Header.Set("X-Forwarded-Proto", "https")
+			req.Header.Set("X-Forwarded-Host", tt.host)
+			req.Header.Set("X-Forwarded-Uri", tt.uri)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "auth_session", Value: tt.cookie})
+			}
+			if tt.html {
+				req.Header.Set("Accept", "text/html,application/xhtml+xml")
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantRole, rec.Header().Get(gateway.HeaderUserRole))
+			assert.Equal(t, tt.wantRole != "", rec.Header().Get(gateway.HeaderUserID) != "")
+			assert.Equal(t, tt.wantLocation, rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestForwardAuth_verify_nginx(t *testing.T) {
+	mux := newForwardAuthMux(t, mockapplication.NewMockOAuthService(gomock.NewController(t)))
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/verify", nil)
+	req.Header.Set("X-Original-URL", "https://app.example.com/admin/users")
+	req.Header.Set("Authorization", "Bearer user-token")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestForwardAuth_verify_nginxTraversal(t *testing.T) {
+	mux := newForwardAuthMux(t, mockapplication.NewMockOAuthService(gomock.NewController(t)))
+
+	for _, original := range []string{"https://app.example.com/public/../admin", "https://app.example.com/public/%2E%2E/admin"} {
+		req := httptest.NewRequest(http.MethodGet, "/auth/verify", nil)
+		req.Header.Set("X-Original-URL", original)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, original)
+	}
+}
+
+func TestForwardAuth_login(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := mockapplication.NewMockOAuthService(ctrl)
+	service.EXPECT().
+		SessionLogin(gomock.Any(), "user@example.com", "password").
+		Return(&oauth.TokenResponse{AccessToken: "user-token", ExpiresIn: time.Hour}, nil)
+	mux := newForwardAuthMux(t, service)
+	rd := "https://app.example.com/reports"
+
+	t.Run("foreign redirect", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/auth/login?rd="+url.QueryEscape("https://evil.com/"), nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	page := httptest.NewRecorder()
+	mux.ServeHTTP(page, httptest.NewRequest(http.MethodGet, "/auth/login?rd="+url.QueryEscape(rd), nil))
+	require.Equal(t, http.StatusOK, page.Code)
+	var csrf *http.Cookie
+	for _, c := range page.Result().Cookies() {
+		if c.Name == loginCSRFCookie {
+			csrf = c
+		}
+	}
+	require.NotNil(t, csrf)
+
+	form := url.Values{
+		"email":      {"user@example.com"},
+		"password":   {"password"},
+		"csrf_token": {csrf.Value},
+		"rd":         {rd},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(csrf)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, rd, rec.Header().Get("Location"))
+	var session *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "auth_session" {
+			session = c
+		}
+	}
+	require.NotNil(t, session)
+	assert.Equal(t, "user-token", session.Value)
+	assert.Equal(t, "example.com", session.Domain)
+	assert.True(t, session.HttpOnly)
+}
@@ -0,0 +1,83 @@
+package httpapi
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"log/slog"
+	"net/http"
+)
+
+type introspectionHandler struct {
+	service application.IntrospectionService
+	log     *slog.Logger
+}
+
+// introspectionResponse RFC 7662 2.2, sid и principal_type - расширения сервиса
+type introspectionResponse struct {
+	Active        bool   `json:"active"`
+	Scope         string `json:"scope,omitempty"`
+	ClientID      string `json:"client_id,omitempty"`
+	TokenType     string `json:"token_type,omitempty"`
+	Exp           int64  `json:"exp,omitempty"`
+	Iat           int64  `json:"iat,omitempty"`
+	Sub           string `json:"sub,omitempty"`
+	Jti           string `json:"jti,omitempty"`
+	SessionID     string `json:"sid,omitempty"`
+	PrincipalType string `json:"principal_type,omitempty"`
+}
+
+func RegisterIntrospection(mux *http.ServeMux, service application.IntrospectionService, log *slog.Logger) {
+	h := &introspectionHandler{
+		service: service,
+		log:     log,
+	}
+	mux.HandleFunc("POST /introspect", h.introspect)
+}
+
+func (h *introspectionHandler) introspect(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+
+	if err := r.ParseForm(); err != nil {
+		jsonError(w, r, oauth.ErrInvalidRequest)
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		jsonError(w, r, oauth.ErrInvalidRequest)
+		return
+	}
+	clientID, clientSecret := clientCredentials(r)
+
+	res, err := h.service.Introspect(ctx, clientID, clientSecret, token)
+	if err != nil {
+		log.Warn("failed to introspect token", slog.String("error", err.Error()))
+		jsonError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, introspectionToResponse(res))
+}
+
+func introspectionToResponse(i oauth.Introspection) introspectionResponse {
+	if !i.Active {
+		return introspectionResponse{}
+	}
+	res := introspectionResponse{
+		Active:        true,
+		Scope:         i.Scope.String(),
+		ClientID:      i.ClientID,
+		TokenType:     i.TokenType,
+		Sub:           i.Subject,
+		Jti:           i.TokenID,
+		SessionID:     i.SessionID,
+		PrincipalType: i.Principal.String(),
+	}
+	if !i.IssuedAt.IsZero() {
+		res.Iat = i.IssuedAt.Unix()
+	}
+	if !i.ExpiresAt.IsZero() {
+		res.Exp = i.ExpiresAt.Unix()
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+	"encoding/json"
+	mockapplication "github.com/LeoUraltsev/auth-service/internal/application/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntrospection_introspect(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	cases := []struct {
+		name       string
+		result     oauth.Introspection
+		serviceErr error
+		wantStatus int
+		want       map[string]any
+	}{
+		{
+			name: "active",
+			result: oauth.Introspection{
+				Active:    true,
+				TokenType: oauth.TokenTypeBearer,
+				Subject:   "user-id",
+				ClientID:  "app",
+				Scope:     oauth.Scope{"openid", "email"},
+				Principal: users.PrincipalUser,
+				SessionID: "session-id",
+				ExpiresAt: exp,
+			},
+			wantStatus: http.StatusOK,
+			want: map[string]any{
+				"active":         true,
+				"token_type":     "Bearer",
+				"sub":            "user-id",
+				"client_id":      "app",
+				"scope":          "openid email",
+				"principal_type": "user",
+				"sid":            "session-id",
+				"exp":            float64(exp.Unix()),
+			},
+		},
+		{
+			name:       "inactive",
+			wantStatus: http.StatusOK,
+			want:       map[string]any{"active": false},
+		},
+		{
+			name:       "client authentication failed",
+			serviceErr: oauth.ErrClientAuthFailed,
+			wantStatus: http.StatusUnauthorized,
+			want:       map[string]any{"error": "invalid_client", "error_description": oauth.ErrClientAuthFailed.Error()},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mockapplication.NewMockIntrospectionService(ctrl)
+			service.EXPECT().Introspect(gomock.Any(), "sa_worker", "sas_secret", "access-token").Return(tt.result, tt.serviceErr)
+
+			mux := http.NewServeMux()
+			RegisterIntrospection(mux, service, log)
+
+			form := url.Values{"token": {"access-token"}, "token_type_hint": {"access_token"}}
+			req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.SetBasicAuth("sa_worker", "sas_secret")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.want, body)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package httpapi
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/helper/ctxkeys"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/interceptors"
+	"github.com/google/uuid"
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+// RequestContext кладет в контекст request_id и IP клиента, как интерсептор RequestID для gRPC
+func RequestContext(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := uuid.New()
+		log.With("request_id", requestID).Info("new request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		ctx := context.WithValue(r.Context(), ctxkeys.RequestID, requestID)
+		ctx = context.WithValue(ctx, ctxkeys.PeerIP, remoteIP(r))
+		if org := r.Header.Get(interceptors.HeaderOrganization); org != "" {
+			ctx = context.WithValue(ctx, ctxkeys.Organization, org)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,406 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+const csrfCookie = "oauth_csrf"
+
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+var templates = template.Must(template.ParseFS(templatesFS, "templates/*.html"))
+
+type oauthHandler struct {
+	service    application.OAuthService
+	federation application.FederationService
+	log        *slog.Logger
+}
+
+type authorizePage struct {
+	ClientName string
+	Scope      oauth.Scope
+	Request    oauth.AuthorizationRequest
+	CSRFToken  string
+	Email      string
+	Error      string
+	// Providers ссылки на вход через внешних провайдеров с тем же запросом
+	Providers []providerLink
+}
+
+type providerLink struct {
+	Name string
+	URL  string
+}
+
+type errorPage struct {
+	Code        string
+	Description string
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// RegisterOAuth federation может быть nil, тогда вход только по паролю
+func RegisterOAuth(mux *http.ServeMux, service application.OAuthService, federation application.FederationService, log *slog.Logger) {
+	h := &oauthHandler{
+		service:    service,
+		federation: federation,
+		log:        log,
+	}
+	mux.HandleFunc("GET /authorize", h.authorizePage)
+	mux.HandleFunc("POST /authorize", h.authorize)
+	mux.HandleFunc("POST /token", h.token)
+	mux.HandleFunc("POST /revoke", h.revoke)
+	if federation != nil {
+		mux.HandleFunc("GET /federated/{provider}/start", h.federatedStart)
+		mux.HandleFunc("GET /federated/{provider}/callback", h.federatedCallback)
+	}
+}
+
+// authorizePage проверяет запрос и показывает страницу входа и согласия
+func (h *oauthHandler) authorizePage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+	req := authorizationRequest(r.URL.Query())
+
+	client, scope, err := h.service.ValidateAuthorization(ctx, req)
+	if err != nil {
+		log.Warn("invalid authorization request", slog.String("error", err.Error()))
+		h.authorizeError(w, r, req, err)
+		return
+	}
+
+	csrf, err := randomString()
+	if err != nil {
+		log.Error("failed to generate csrf token", slog.String("error", err.Error()))
+		h.errorPage(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    csrf,
+		Path:     "/authorize",
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	h.render(w, http.StatusOK, "authorize.html", authorizePage{
+		ClientName: client.Name(),
+		Scope:      scope,
+		Request:    req,
+		CSRFToken:  csrf,
+		Providers:  h.providerLinks(req),
+	})
+}
+
+// authorize обрабатывает форму: при согласии выдает код, при отказе возвращает access_denied
+func (h *oauthHandler) authorize(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+
+	if err := r.ParseForm(); err != nil {
+		h.errorPage(w, http.StatusBadRequest, "invalid_request", "malformed form")
+		return
+	}
+	req := authorizationRequest(r.PostForm)
+
+	cookie, err := r.Cookie(csrfCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.PostForm.Get("csrf_token"))) != 1 {
+		log.Warn("csrf token mismatch on authorize")
+		h.errorPage(w, http.StatusForbidden, "invalid_request", "csrf token mismatch")
+		return
+	}
+
+	client, scope, err := h.service.ValidateAuthorization(ctx, req)
+	if err != nil {
+		h.authorizeError(w, r, req, err)
+		return
+	}
+
+	if r.PostForm.Get("action") != "allow" {
+		log.Info("authorization denied by user", slog.String("client_id", req.ClientID))
+		h.authorizeError(w, r, req, oauth.ErrAccessDenied)
+		return
+	}
+
+	email := r.PostForm.Get("email")
+	code, err := h.service.Authorize(ctx, req, email, r.PostForm.Get("password"))
+	if errors.Is(err, users.ErrInvalidCredentials) {
+		h.render(w, http.StatusUnauthorized, "authorize.html", authorizePage{
+			ClientName: client.Name(),
+			Scope:      scope,
+			Request:    req,
+			CSRFToken:  cookie.Value,
+			Email:      email,
+			Error:      "Неверный email или пароль",
+			Providers:  h.providerLinks(req),
+		})
+		return
+	}
+	if err != nil {
+		h.authorizeError(w, r, req, err)
+		return
+	}
+
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	redirect(w, r, req.RedirectURI, params)
+}
+
+func (h *oauthHandler) token(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+
+	if err := r.ParseForm(); err != nil {
+		jsonError(w, r, oauth.ErrInvalidRequest)
+		return
+	}
+	clientID, clientSecret := clientCredentials(r)
+
+	res, err := h.service.Token(ctx, oauth.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		Scope:        r.PostForm.Get("scope"),
+	})
+	if err != nil {
+		log.Warn("failed to issue token", slog.String("error", err.Error()))
+		jsonError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken:  res.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(res.ExpiresIn.Seconds()),
+		RefreshToken: res.RefreshToken,
+		Scope:        res.Scope.String(),
+		IDToken:      res.IDToken,
+	})
+}
+
+func (h *oauthHandler) revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+
+	if err := r.ParseForm(); err != nil {
+		jsonError(w, r, oauth.ErrInvalidRequest)
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		jsonError(w, r, oauth.ErrInvalidRequest)
+		return
+	}
+	clientID, clientSecret := clientCredentials(r)
+
+	if err := h.service.Revoke(ctx, clientID, clientSecret, token); err != nil {
+		log.Warn("failed to revoke token", slog.String("error", err.Error()))
+		jsonError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// authorizeError ошибки клиента и redirect_uri показываются на странице, перенаправлять на
+// непроверенный адрес нельзя. Остальные ошибки возвращаются клиенту через redirect_uri
+func (h *oauthHandler) authorizeError(w http.ResponseWriter, r *http.Request, req oauth.AuthorizationRequest, err error) {
+	code, status := errorCode(err)
+	description := err.Error()
+	if status == http.StatusInternalServerError {
+		description = "internal error"
+	}
+
+	if req.RedirectURI == "" ||
+		errors.Is(err, oauth.ErrClientNotFound) ||
+		errors.Is(err, oauth.ErrRedirectURINotAllowed) ||
+		errors.Is(err, identity.ErrProviderNotFound) ||
+		status == http.StatusInternalServerError {
+		h.errorPage(w, status, code, description)
+		return
+	}
+
+	params := url.Values{
+		"error":             {code},
+		"error_description": {description},
+	}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	redirect(w, r, req.RedirectURI, params)
+}
+
+func (h *oauthHandler) errorPage(w http.ResponseWriter, status int, code string, description string) {
+	h.render(w, status, "error.html", errorPage{Code: code, Description: description})
+}
+
+func (h *oauthHandler) render(w http.ResponseWriter, status int, name string, data any) {
+	renderPage(w, h.log, status, name, data)
+}
+
+func renderPage(w http.ResponseWriter, log *slog.Logger, status int, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	// страницу входа нельзя встраивать во фрейм, иначе возможен clickjacking
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	w.WriteHeader(status)
+	if err := templates.ExecuteTemplate(w, name, data); err != nil {
+		log.Error("failed to render template", slog.String("template", name), slog.String("error", err.Error()))
+	}
+}
+
+func jsonError(w http.ResponseWriter, r *http.Request, err error) {
+	code, status := errorCode(err)
+	description := err.Error()
+	if status == http.StatusInternalServerError {
+		description = "internal error"
+	}
+	if status == http.StatusUnauthorized {
+		if _, _, ok := r.BasicAuth(); ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+	}
+	writeJSON(w, status, errorResponse{Error: code, ErrorDescription: description})
+}
+
+// errorCode коды ошибок из RFC 6749
+func errorCode(err error) (string, int) {
+	switch {
+	case errors.Is(err, oauth.ErrClientAuthFailed),
+		errors.Is(err, oauth.ErrClientNotFound):
+		return "invalid_client", http.StatusUnauthorized
+	case errors.Is(err, oauth.ErrGrantTypeNotValid):
+		return "unsupported_grant_type", http.StatusBadRequest
+	case errors.Is(err, oauth.ErrResponseTypeNotValid):
+		return "unsupported_response_type", http.StatusBadRequest
+	case errors.Is(err, oauth.ErrGrantTypeNotAllowed),
+		errors.Is(err, oauth.ErrPublicClientCredentials):
+		return "unauthorized_client", http.StatusBadRequest
+	case errors.Is(err, oauth.ErrScopeNotAllowed):
+		return "invalid_scope", http.StatusBadRequest
+	case errors.Is(err, oauth.ErrCodeNotFound),
+		errors.Is(err, oauth.ErrCodeExpired),
+		errors.Is(err, oauth.ErrCodeUsed),
+		errors.Is(err, oauth.ErrCodeVerifierMismatch),
+		errors.Is(err, oauth.ErrRedirectURIMismatch),
+		errors.Is(err, oauth.ErrRefreshTokenNotFound),
+		errors.Is(err, oauth.ErrRefreshTokenExpired),
+		errors.Is(err, oauth.ErrRefreshTokenRevoked),
+		errors.Is(err, oauth.ErrUserNotActive),
+		errors.Is(err, users.ErrPasswordChangeRequired):
+		return "invalid_grant", http.StatusBadRequest
+	case errors.Is(err, oauth.ErrAccessDenied),
+		errors.Is(err, identity.ErrProviderRejected),
+		errors.Is(err, identity.ErrEmailNotVerified):
+		return "access_denied", http.StatusForbidden
+	case errors.Is(err, identity.ErrProviderNotUsable),
+		errors.Is(err, identity.ErrIDTokenNotValid):
+		return "temporarily_unavailable", http.StatusServiceUnavailable
+	case errors.Is(err, oauth.ErrInvalidRequest),
+		errors.Is(err, oauth.ErrRedirectURINotAllowed),
+		errors.Is(err, oauth.ErrCodeChallengeRequired),
+		errors.Is(err, oauth.ErrCodeChallengeMethod),
+		errors.Is(err, identity.ErrProviderNotFound),
+		errors.Is(err, identity.ErrLoginNotFound),
+		errors.Is(err, identity.ErrLoginExpired):
+		return "invalid_request", http.StatusBadRequest
+	default:
+		return "server_error", http.StatusInternalServerError
+	}
+}
+
+func authorizationRequest(values url.Values) oauth.AuthorizationRequest {
+	return oauth.AuthorizationRequest{
+		ResponseType:        values.Get("response_type"),
+		ClientID:            values.Get("client_id"),
+		RedirectURI:         values.Get("redirect_uri"),
+		Scope:               values.Get("scope"),
+		State:               values.Get("state"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+		Nonce:               values.Get("nonce"),
+	}
+}
+
+// clientCredentials HTTP Basic (client_secret_basic) или поля формы (client_secret_post)
+func clientCredentials(r *http.Request) (string, string) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		// RFC 6749 2.3.1: значения кодируются application/x-www-form-urlencoded
+		if v, err := url.QueryUnescape(id); err == nil {
+			id = v
+		}
+		if v, err := url.QueryUnescape(secret); err == nil {
+			secret = v
+		}
+		return id, secret
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+func redirect(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect uri", http.StatusBadRequest)
+		return
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func isSecure(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
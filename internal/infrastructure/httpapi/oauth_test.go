@@ -0,0 +1,143 @@
+package httpapi
+
+import (
+	"encoding/json"
+	mockapplication "github.com/LeoUraltsev/auth-service/internal/application/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+var log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+func newTestServer(t *testing.T, service *mockapplication.MockOAuthService) *httptest.Server {
+	mux := http.NewServeMux()
+	RegisterOAuth(mux, service, nil, log)
+	server := httptest.NewServer(RequestContext(log, mux))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func noRedirect(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+func TestOAuth_token_error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := mockapplication.NewMockOAuthService(ctrl)
+	service.EXPECT().
+		Token(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, req oauth.TokenRequest) (*oauth.TokenResponse, error) {
+			assert.Equal(t, "client", req.ClientID)
+			assert.Equal(t, "s3cr:et", req.ClientSecret)
+			return nil, oauth.ErrClientAuthFailed
+		})
+	server := newTestServer(t, service)
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/token", strings.NewReader("grant_type=client_credentials"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("client", url.QueryEscape("s3cr:et"))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+	var body errorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "invalid_client", body.Error)
+}
+
+func TestOAuth_authorize(t *testing.T) {
+	client, _, err := oauth.CreateClient("app", []string{"https://app.example.com/cb"}, []oauth.GrantType{oauth.GrantAuthorizationCode}, nil, false)
+	require.NoError(t, err)
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {client.ClientID()},
+		"redirect_uri":          {"https://app.example.com/cb"},
+		"state":                 {"xyz"},
+		"code_challenge":        {"challenge"},
+		"code_challenge_method": {"S256"},
+	}
+
+	t.Run("unknown client is not redirected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mockapplication.NewMockOAuthService(ctrl)
+		service.EXPECT().ValidateAuthorization(gomock.Any(), gomock.Any()).Return(nil, nil, oauth.ErrClientNotFound)
+		server := newTestServer(t, service)
+
+		httpClient := &http.Client{CheckRedirect: noRedirect}
+		resp, err := httpClient.Get(server.URL + "/authorize?" + query.Encode())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"))
+	})
+
+	t.Run("login and consent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mockapplication.NewMockOAuthService(ctrl)
+		service.EXPECT().ValidateAuthorization(gomock.Any(), gomock.Any()).Return(client, oauth.Scope{}, nil).Times(2)
+		service.EXPECT().Authorize(gomock.Any(), gomock.Any(), "user@example.com", "password").Return("the-code", nil)
+		server := newTestServer(t, service)
+
+		httpClient := &http.Client{CheckRedirect: noRedirect}
+		page, err := httpClient.Get(server.URL + "/authorize?" + query.Encode())
+		require.NoError(t, err)
+		page.Body.Close()
+		require.Equal(t, http.StatusOK, page.StatusCode)
+		assert.Equal(t, "DENY", page.Header.Get("X-Frame-Options"))
+
+		var csrf string
+		for _, c := range page.Cookies() {
+			if c.Name == csrfCookie {
+				csrf = c.Value
+			}
+		}
+		require.NotEmpty(t, csrf)
+
+		form := url.Values{}
+		for k, v := range query {
+			form[k] = v
+		}
+		form.Set("csrf_token", csrf)
+		form.Set("email", "user@example.com")
+		form.Set("password", "password")
+		form.Set("action", "allow")
+
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/authorize", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: csrfCookie, Value: csrf})
+		resp, err := httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		location, err := url.Parse(resp.Header.Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "app.example.com", location.Host)
+		assert.Equal(t, "the-code", location.Query().Get("code"))
+		assert.Equal(t, "xyz", location.Query().Get("state"))
+	})
+
+	t.Run("csrf mismatch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mockapplication.NewMockOAuthService(ctrl)
+		server := newTestServer(t, service)
+
+		form := url.Values{"csrf_token": {"forged"}, "action": {"allow"}}
+		resp, err := http.PostForm(server.URL+"/authorize", form)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
@@ -0,0 +1,144 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/interceptors"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/jwt"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type KeySet interface {
+	JWKS() jwt.JWKS
+}
+
+type oidcHandler struct {
+	service  application.OAuthService
+	verifier interceptors.TokenVerifier
+	keys     KeySet
+	issuer   string
+	log      *slog.Logger
+}
+
+// discovery документ OpenID Provider Metadata
+type discovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+func RegisterOIDC(
+	mux *http.ServeMux,
+	service application.OAuthService,
+	verifier interceptors.TokenVerifier,
+	keys KeySet,
+	issuer string,
+	log *slog.Logger,
+) {
+	h := &oidcHandler{
+		service:  service,
+		verifier: verifier,
+		keys:     keys,
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		log:      log,
+	}
+	mux.HandleFunc("GET /userinfo", h.userInfo)
+	mux.HandleFunc("POST /userinfo", h.userInfo)
+	mux.HandleFunc("GET /.well-known/openid-configuration", h.discovery)
+	mux.HandleFunc("GET /.well-known/jwks.json", h.jwks)
+}
+
+// userInfo claims владельца токена доступа (OIDC Core 5.3)
+func (h *oidcHandler) userInfo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.LogWithContext(ctx, h.log)
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	claims, err := h.verifier.ValidateToken(token)
+	if err != nil {
+		log.Warn("invalid userinfo token", slog.String("error", err.Error()))
+		bearerError(w, http.StatusUnauthorized, "invalid_token")
+		return
+	}
+
+	info, err := h.service.UserInfo(withClaims(ctx, claims))
+	switch {
+	case errors.Is(err, oauth.ErrInsufficientScope):
+		bearerError(w, http.StatusForbidden, "insufficient_scope")
+		return
+	case errors.Is(err, oauth.ErrUserNotActive):
+		bearerError(w, http.StatusUnauthorized, "invalid_token")
+		return
+	case err != nil:
+		log.Error("failed to get user info", slog.String("error", err.Error()))
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "server_error", ErrorDescription: "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, info.Claims())
+}
+
+func (h *oidcHandler) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, discovery{
+		Issuer:                 h.issuer,
+		AuthorizationEndpoint:  h.issuer + "/authorize",
+		TokenEndpoint:          h.issuer + "/token",
+		UserInfoEndpoint:       h.issuer + "/userinfo",
+		RevocationEndpoint:     h.issuer + "/revoke",
+		IntrospectionEndpoint:  h.issuer + "/introspect",
+		JWKSURI:                h.issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported: []string{"code"},
+		GrantTypesSupported: []string{
+			oauth.GrantAuthorizationCode.String(),
+			oauth.GrantRefreshToken.String(),
+			oauth.GrantClientCredentials.String(),
+		},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		ScopesSupported:                   []string{oauth.ScopeOpenID, oauth.ScopeProfile, oauth.ScopeEmail},
+		ClaimsSupported:                   []string{"sub", "name", "email", "email_verified", "auth_time", "acr", "nonce"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	})
+}
+
+func (h *oidcHandler) jwks(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(h.keys.JWKS())
+}
+
+// withClaims кладет в контекст те же значения, что интерсептор Auth для gRPC
+func withClaims(ctx context.Context, claims *jwt.AuthClaims) context.Context {
+	return authverify.NewContext(ctx, claims.AuthPrincipal())
+}
+
+// bearerError ошибки из RFC 6750
+func bearerError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="`+code+`"`)
+	writeJSON(w, status, errorResponse{Error: code})
+}
@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	mockapplication "github.com/LeoUraltsev/auth-service/internal/application/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/jwt"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type verifierFunc func(token string) (*jwt.AuthClaims, error)
+
+func (f verifierFunc) ValidateToken(token string) (*jwt.AuthClaims, error) {
+	return f(token)
+}
+
+type keySet struct{}
+
+func (keySet) JWKS() jwt.JWKS {
+	return jwt.JWKS{Keys: []jwt.JWK{}}
+}
+
+func TestOIDC_userInfo(t *testing.T) {
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	user, _ := users.CreateUser(organizations.DefaultID, "name", email, pass)
+
+	verifier := verifierFunc(func(token string) (*jwt.AuthClaims, error) {
+		if token != "valid" {
+			return nil, errors.New("token not valid")
+		}
+		return &jwt.AuthClaims{UserID: user.ID(), Role: "user", Scope: "openid email"}, nil
+	})
+
+	cases := []struct {
+		name       string
+		token      string
+		serviceErr error
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "valid",
+			token:      "valid",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid token",
+			token:      "broken",
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "invalid_token",
+		},
+		{
+			name:       "insufficient scope",
+			token:      "valid",
+			serviceErr: oauth.ErrInsufficientScope,
+			wantStatus: http.StatusForbidden,
+			wantError:  "insufficient_scope",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mockapplication.NewMockOAuthService(ctrl)
+			if tt.token == "valid" {
+				service.EXPECT().
+					UserInfo(gomock.Any()).
+					DoAndReturn(func(ctx context.Context) (oauth.UserInfo, error) {
+						p, ok := authverify.FromContext(ctx)
+						require.True(t, ok)
+						assert.Equal(t, user.ID(), p.UserID)
+						assert.Equal(t, []string{"openid", "email"}, p.Scope)
+						if tt.serviceErr != nil {
+							return oauth.UserInfo{}, tt.serviceErr
+						}
+						return oauth.NewUserInfo(user, oauth.Scope{"openid", "email"}), nil
+					})
+			}
+
+			mux := http.NewServeMux()
+			RegisterOIDC(mux, service, verifier, keySet{}, "https://auth.example.com", log)
+			server := httptest.NewServer(RequestContext(log, mux))
+			t.Cleanup(server.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/userinfo", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			if tt.wantError != "" {
+				assert.Contains(t, resp.Header.Get("WWW-Authenticate"), tt.wantError)
+				assert.Equal(t, tt.wantError, body["error"])
+				return
+			}
+			assert.Equal(t, user.ID().String(), body["sub"])
+			assert.Equal(t, "user@example.com", body["email"])
+			assert.NotContains(t, body, "name")
+		})
+	}
+}
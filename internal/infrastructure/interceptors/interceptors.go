@@ -2,51 +2,86 @@ package interceptors
 
 import (
 	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/ctxkeys"
 	"github.com/LeoUraltsev/auth-service/internal/infrastructure/jwt"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"log/slog"
+	"net"
 	"strings"
 )
 
-var (
-	KeyCtxRequestID = "request_id"
-	KeyCtxUserID    = "user_id"
-)
+// HeaderOrganization организация для Login и CreateUser, у запросов с токеном организация берется из токена
+const HeaderOrganization = "x-organization"
 
 type TokenVerifier interface {
 	ValidateToken(token string) (*jwt.AuthClaims, error)
 }
 
+// APIKeyVerifier проверяет личные ключи из заголовка "authorization: ApiKey <key>"
+type APIKeyVerifier interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (apikeys.Credentials, error)
+}
+
+// SessionVerifier сверяет токен с хранилищем после проверки подписи: владелец еще может входить,
+// а токен не отозван принудительным выходом. application.ErrTokenNotActive, если токен больше не действует
+type SessionVerifier interface {
+	VerifySession(ctx context.Context, token oauth.AccessToken) error
+}
+
 type Interceptors struct {
-	log           *slog.Logger
-	tokenVerifier TokenVerifier
+	log            *slog.Logger
+	tokenVerifier  TokenVerifier
+	apiKeyVerifier APIKeyVerifier
+	sessions       SessionVerifier
+	policy         PolicyEvaluator
 }
 
-func New(log *slog.Logger, verifier TokenVerifier) *Interceptors {
+func New(log *slog.Logger, verifier TokenVerifier, apiKeyVerifier APIKeyVerifier, sessions SessionVerifier) *Interceptors {
 	return &Interceptors{
-		log:           log,
-		tokenVerifier: verifier,
+		log:            log,
+		tokenVerifier:  verifier,
+		apiKeyVerifier: apiKeyVerifier,
+		sessions:       sessions,
 	}
 }
 
+// VerifySession проверяет по хранилищу токен, подпись и срок которого уже проверены
+func VerifySession(ctx context.Context, sessions SessionVerifier, claims *jwt.AuthClaims) error {
+	token, err := claims.AccessToken()
+	if err != nil {
+		return errors.Join(application.ErrTokenNotActive, err)
+	}
+	return sessions.VerifySession(ctx, token)
+}
+
 func (i *Interceptors) RequestID(
 	ctx context.Context,
 	req any,
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
+	return handler(i.withRequestID(ctx, info.FullMethod), req)
+}
 
-	requestID := uuid.New()
-	log := i.log.With("request_id", requestID)
-	log.Info("new call", slog.String("method", info.FullMethod))
-	ctx = context.WithValue(ctx, KeyCtxRequestID, requestID)
-
-	return handler(ctx, req)
-
+func (i *Interceptors) RequestIDStream(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx := i.withRequestID(ss.Context(), info.FullMethod)
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
 }
 
 func (i *Interceptors) Auth(
@@ -55,11 +90,56 @@ func (i *Interceptors) Auth(
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
-	if info.FullMethod == "/auth.UserService/Login" || info.FullMethod == "/auth.UserService/CreateUser" {
+	if info.FullMethod == "/auth.UserService/Login" || info.FullMethod == "/auth.UserService/CreateUser" ||
+		info.FullMethod == "/auth.OrganizationService/Login" || info.FullMethod == "/auth.InvitationService/AcceptInvitation" ||
+		info.FullMethod == "/auth.PasswordService/ChangeTemporaryPassword" ||
+		info.FullMethod == "/auth.EmailChangeService/ConfirmEmailChange" || info.FullMethod == "/auth.EmailChangeService/CancelEmailChange" ||
+		info.FullMethod == "/auth.PasswordlessService/StartPasswordlessLogin" || info.FullMethod == "/auth.PasswordlessService/CompletePasswordlessLogin" {
+		return handler(ctx, req)
+	}
+	// Envoy вызывает Check без своего токена, проверяется токен из проксируемого запроса
+	if info.FullMethod == "/envoy.service.auth.v3.Authorization/Check" {
 		return handler(ctx, req)
 	}
-	log := i.log.With("method", info.FullMethod)
-	id, ok := ctx.Value(KeyCtxRequestID).(uuid.UUID)
+	ctx, err := i.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (i *Interceptors) AuthStream(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := i.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+func (i *Interceptors) withRequestID(ctx context.Context, method string) context.Context {
+	requestID := uuid.New()
+	log := i.log.With("request_id", requestID)
+	log.Info("new call", slog.String("method", method))
+	ctx = context.WithValue(ctx, ctxkeys.RequestID, requestID)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ctx = context.WithValue(ctx, ctxkeys.PeerIP, peerIP(p.Addr))
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if org := md.Get(HeaderOrganization); len(org) > 0 && org[0] != "" {
+			ctx = context.WithValue(ctx, ctxkeys.Organization, org[0])
+		}
+	}
+	return ctx
+}
+
+func (i *Interceptors) authenticate(ctx context.Context, method string) (context.Context, error) {
+	log := i.log.With("method", method)
+	id, ok := ctx.Value(ctxkeys.RequestID).(uuid.UUID)
 	if !ok {
 		log.Warn("context value for key 'request_id' not found")
 	}
@@ -84,6 +164,10 @@ func (i *Interceptors) Auth(
 		return nil, status.Error(codes.Unauthenticated, "no token found")
 	}
 
+	if key, ok := strings.CutPrefix(a[0], "ApiKey "); ok {
+		return i.authenticateAPIKey(ctx, log, method, key)
+	}
+
 	token := strings.TrimPrefix(a[0], "Bearer ")
 
 	claims, err := i.tokenVerifier.ValidateToken(token)
@@ -92,7 +176,60 @@ func (i *Interceptors) Auth(
 		return nil, status.Error(codes.Unauthenticated, "no token found")
 	}
 
-	ctx = context.WithValue(ctx, KeyCtxUserID, claims.UserID)
+	if err = VerifySession(ctx, i.sessions, claims); err != nil {
+		if errors.Is(err, application.ErrTokenNotActive) {
+			log.Warn("token is not active", slog.String("userID", claims.UserID.String()))
+			return nil, status.Error(codes.Unauthenticated, "token is not active")
+		}
+		log.Error("failed to verify session", slog.String("error", err.Error()))
+		return nil, status.Error(codes.Internal, "internal error")
+	}
 
-	return handler(ctx, req)
+	return i.withPrincipal(ctx, log, method, claims.AuthPrincipal())
+}
+
+// authenticateAPIKey ключ действует от имени пользователя, scope ключа ограничивает его права (methodScopes)
+func (i *Interceptors) authenticateAPIKey(ctx context.Context, log *slog.Logger, method string, key string) (context.Context, error) {
+	creds, err := i.apiKeyVerifier.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		log.Warn("invalid api key", slog.String("error", err.Error()))
+		return nil, status.Error(codes.Unauthenticated, "invalid api key")
+	}
+
+	// без организации ключа запрос попал бы в организацию из заголовка x-organization
+	return i.withPrincipal(ctx, log, method, &authverify.Principal{
+		Subject:  creds.UserID.String(),
+		UserID:   creds.UserID,
+		TenantID: creds.TenantID,
+		Role:     creds.Role.String(),
+		Type:     users.PrincipalUser.String(),
+		Scope:    creds.Scope,
+	})
+}
+
+// withPrincipal учетные данные со scope вызывают только методы, которые он покрывает
+func (i *Interceptors) withPrincipal(ctx context.Context, log *slog.Logger, method string, p *authverify.Principal) (context.Context, error) {
+	if !allowedScope(method, p) {
+		log.Warn("insufficient scope", slog.String("scope", strings.Join(p.Scope, " ")))
+		return nil, status.Error(codes.PermissionDenied, "insufficient scope")
+	}
+	return authverify.NewContext(ctx, p), nil
+}
+
+func peerIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// serverStream подменяет контекст потока, grpc.ServerStream не позволяет сделать это иначе
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
 }
@@ -0,0 +1,120 @@
+package interceptors
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/jwt"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+var log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+type verifierFunc func(token string) (*jwt.AuthClaims, error)
+
+func (f verifierFunc) ValidateToken(token string) (*jwt.AuthClaims, error) {
+	return f(token)
+}
+
+type sessionsFunc func(token oauth.AccessToken) error
+
+func (f sessionsFunc) VerifySession(_ context.Context, token oauth.AccessToken) error {
+	return f(token)
+}
+
+type apiKeyFunc func(key string) (apikeys.Credentials, error)
+
+func (f apiKeyFunc) AuthenticateAPIKey(_ context.Context, key string) (apikeys.Credentials, error) {
+	return f(key)
+}
+
+// call проходит RequestID и Auth, как в сервере, и возвращает principal, с которым вызван обработчик
+func call(t *testing.T, i *Interceptors, method string, md metadata.MD) (*authverify.Principal, error) {
+	t.Helper()
+	var principal *authverify.Principal
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	handler := func(ctx context.Context, _ any) (any, error) {
+		principal, _ = authverify.FromContext(ctx)
+		return nil, nil
+	}
+	auth := func(ctx context.Context, req any) (any, error) {
+		return i.Auth(ctx, req, info, handler)
+	}
+	_, err := i.RequestID(metadata.NewIncomingContext(context.Background(), md), nil, info, auth)
+	return principal, err
+}
+
+func TestInterceptors_Auth_apiKeyTenant(t *testing.T) {
+	creds := apikeys.Credentials{KeyID: uuid.New(), UserID: uuid.New(), TenantID: uuid.New(), Role: users.RoleAdmin}
+	i := New(log, nil, apiKeyFunc(func(key string) (apikeys.Credentials, error) {
+		require.Equal(t, "ak_key", key)
+		return creds, nil
+	}), nil)
+
+	md := metadata.Pairs("authorization", "ApiKey ak_key", HeaderOrganization, uuid.NewString())
+	p, err := call(t, i, "/auth.UserService/GetUser", md)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, creds.TenantID, p.TenantID, "organization of the key wins over x-organization")
+	assert.Equal(t, creds.UserID, p.UserID)
+}
+
+func TestInterceptors_Auth_apiKeyScope(t *testing.T) {
+	i := New(log, nil, apiKeyFunc(func(string) (apikeys.Credentials, error) {
+		return apikeys.Credentials{UserID: uuid.New(), TenantID: uuid.New(), Role: users.RoleAdmin, Scope: oauth.Scope{"users.read"}}, nil
+	}), nil)
+	md := metadata.Pairs("authorization", "ApiKey ak_key")
+
+	_, err := call(t, i, "/auth.UserService/GetUser", md)
+	assert.NoError(t, err)
+	for _, method := range []string{"/auth.UserService/UpdateUser", "/auth.UserAdminService/EraseUser", "/auth.TokenIntrospectionService/IntrospectToken"} {
+		_, err = call(t, i, method, md)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), method)
+	}
+}
+
+func TestInterceptors_Auth_apiKeyWithoutScope(t *testing.T) {
+	i := New(log, nil, apiKeyFunc(func(string) (apikeys.Credentials, error) {
+		return apikeys.Credentials{UserID: uuid.New(), TenantID: uuid.New(), Role: users.RoleUser}, nil
+	}), nil)
+
+	_, err := call(t, i, "/auth.UserService/UpdateUser", metadata.Pairs("authorization", "ApiKey ak_key"))
+	assert.NoError(t, err, "key without scopes has all rights of the user")
+}
+
+// TestInterceptors_Auth_oauthClientScope токен стороннего клиента действует только в пределах выданного scope
+func TestInterceptors_Auth_oauthClientScope(t *testing.T) {
+	userID := uuid.New()
+	verifier := verifierFunc(func(token string) (*jwt.AuthClaims, error) {
+		claims := &jwt.AuthClaims{UserID: userID, TenantID: uuid.New(), Role: users.RoleAdmin.String(), ClientID: "app"}
+		if token == "profile" {
+			claims.Scope = "openid profile"
+		}
+		return claims, nil
+	})
+	active := sessionsFunc(func(oauth.AccessToken) error {
+		return nil
+	})
+	i := New(log, verifier, nil, active)
+
+	p, err := call(t, i, "/auth.OIDCService/GetUserInfo", metadata.Pairs("authorization", "Bearer profile"))
+	require.NoError(t, err)
+	assert.Equal(t, "app", p.ClientID)
+	for _, method := range []string{"/auth.UserService/UpdateUser", "/auth.UserService/DeleteUser", "/auth.UserAdminService/ForceLogout"} {
+		_, err = call(t, i, method, metadata.Pairs("authorization", "Bearer profile"))
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), method)
+	}
+	_, err = call(t, i, "/auth.UserService/GetUser", metadata.Pairs("authorization", "Bearer no-scope"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "client token without scope")
+}
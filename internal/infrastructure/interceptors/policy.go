@@ -0,0 +1,58 @@
+package interceptors
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/policy"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PolicyEvaluator политики доступа поверх ролей, проверяются после Auth
+type PolicyEvaluator interface {
+	Evaluate(ctx context.Context, method string, principal *authverify.Principal, request any) policy.Decision
+}
+
+// WithPolicy без политик Policy и PolicyStream пропускают все вызовы
+func (i *Interceptors) WithPolicy(evaluator PolicyEvaluator) *Interceptors {
+	i.policy = evaluator
+	return i
+}
+
+func (i *Interceptors) Policy(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := i.authorize(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// PolicyStream сообщения потока приходят позже, поэтому request в условии пустой
+func (i *Interceptors) PolicyStream(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := i.authorize(ss.Context(), info.FullMethod, nil); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (i *Interceptors) authorize(ctx context.Context, method string, req any) error {
+	if i.policy == nil {
+		return nil
+	}
+	p, _ := authverify.FromContext(ctx)
+	d := i.policy.Evaluate(ctx, method, p, req)
+	if !d.Allowed && !d.DryRun {
+		return status.Error(codes.PermissionDenied, "denied by policy")
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package interceptors
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+)
+
+// methodScopes scope, без которого учетные данные со scope и токены клиентов OAuth не вызовут метод.
+// Методов вне списка они не вызывают. Токен Login, ключ без scopes и токены сервисных аккаунтов проверяются только ролью
+var methodScopes = map[string]string{
+	"/auth.UserService/GetUser":         "users.read",
+	"/auth.UserService/GetListUsers":    "users.read",
+	"/auth.UserService/UpdateUser":      "users.write",
+	"/auth.UserService/DeleteUser":      "users.write",
+	"/auth.UserWatchService/WatchUsers": "users.read",
+
+	"/auth.UserAdminService/SuspendUser":          "users.admin",
+	"/auth.UserAdminService/ReactivateUser":       "users.admin",
+	"/auth.UserAdminService/ForceLogout":          "users.admin",
+	"/auth.UserAdminService/SetTemporaryPassword": "users.admin",
+	"/auth.UserAdminService/ReassignEmail":        "users.admin",
+	"/auth.UserAdminService/EraseUser":            "users.admin",
+
+	"/auth.EmailChangeService/RequestEmailChange": "users.write",
+	"/auth.OIDCService/GetUserInfo":               oauth.ScopeOpenID,
+
+	"/auth.APIKeyService/CreateAPIKey": "api_keys.write",
+	"/auth.APIKeyService/ListAPIKeys":  "api_keys.read",
+	"/auth.APIKeyService/RevokeAPIKey": "api_keys.write",
+
+	"/auth.IdentityService/ListIdentities": "identities.read",
+	"/auth.IdentityService/UnlinkIdentity": "identities.write",
+
+	"/auth.DataExportService/ExportMyData":   "exports.write",
+	"/auth.DataExportService/ExportUserData": "exports.write",
+	"/auth.DataExportService/GetExportJob":   "exports.read",
+
+	"/auth.GroupService/CreateGroup":       "groups.write",
+	"/auth.GroupService/GetGroup":          "groups.read",
+	"/auth.GroupService/ListGroups":        "groups.read",
+	"/auth.GroupService/UpdateGroup":       "groups.write",
+	"/auth.GroupService/DeleteGroup":       "groups.write",
+	"/auth.GroupService/AddGroupMember":    "groups.write",
+	"/auth.GroupService/RemoveGroupMember": "groups.write",
+	"/auth.GroupService/ListGroupMembers":  "groups.read",
+	"/auth.GroupService/ListUserGroups":    "groups.read",
+
+	"/auth.RelationshipService/WriteRelationships":  "relations.write",
+	"/auth.RelationshipService/DeleteRelationships": "relations.write",
+	"/auth.RelationshipService/Check":               "relations.read",
+	"/auth.RelationshipService/Expand":              "relations.read",
+	"/auth.RelationshipService/LookupResources":     "relations.read",
+
+	"/auth.InvitationService/CreateInvitation": "invitations.write",
+	"/auth.InvitationService/ListInvitations":  "invitations.read",
+	"/auth.InvitationService/RevokeInvitation": "invitations.write",
+
+	"/auth.OrganizationService/CreateOrganization": "organizations.write",
+	"/auth.OrganizationService/GetOrganization":    "organizations.read",
+	"/auth.OrganizationService/ListOrganizations":  "organizations.read",
+	"/auth.OrganizationService/UpdateOrganization": "organizations.write",
+	"/auth.OrganizationService/DeleteOrganization": "organizations.write",
+
+	"/auth.AuditService/ListAuditEvents":  "audit.read",
+	"/auth.AuditService/VerifyAuditChain": "audit.read",
+
+	"/auth.WebhookService/CreateWebhook":               "webhooks.write",
+	"/auth.WebhookService/ListWebhooks":                "webhooks.read",
+	"/auth.WebhookService/DeleteWebhook":               "webhooks.write",
+	"/auth.WebhookService/ListWebhookDeliveries":       "webhooks.read",
+	"/auth.WebhookService/ListWebhookDeliveryAttempts": "webhooks.read",
+	"/auth.WebhookService/RetryWebhookDelivery":        "webhooks.write",
+
+	"/auth.OAuthClientService/CreateOAuthClient": "oauth_clients.write",
+	"/auth.OAuthClientService/ListOAuthClients":  "oauth_clients.read",
+	"/auth.OAuthClientService/DeleteOAuthClient": "oauth_clients.write",
+
+	"/auth.ServiceAccountService/CreateServiceAccount":       "service_accounts.write",
+	"/auth.ServiceAccountService/ListServiceAccounts":        "service_accounts.read",
+	"/auth.ServiceAccountService/RotateServiceAccountSecret": "service_accounts.write",
+	"/auth.ServiceAccountService/DisableServiceAccount":      "service_accounts.write",
+}
+
+// scoped права пользователя ограничены scope учетных данных. Токен, выданный клиенту OAuth от имени пользователя,
+// ограничен всегда: без scope он не вызывает ни одного метода
+func scoped(p *authverify.Principal) bool {
+	return p.Type == users.PrincipalUser.String() && (p.ClientID != "" || len(p.Scope) > 0)
+}
+
+// allowedScope учетные данные без ограничений проходят, со scope - только если он покрывает метод
+func allowedScope(method string, p *authverify.Principal) bool {
+	if !scoped(p) {
+		return true
+	}
+	scope, ok := methodScopes[method]
+	return ok && p.HasScope(scope)
+}
@@ -0,0 +1,96 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+)
+
+var ErrKeyNotValid = errors.New("rsa private key not valid")
+
+// SigningKey ключ RS256 для ID токенов, публичная часть отдается в JWKS
+type SigningKey struct {
+	id  string
+	key *rsa.PrivateKey
+}
+
+// JWK публичный ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewSigningKey(key *rsa.PrivateKey) (*SigningKey, error) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+	return &SigningKey{
+		id:  base64.RawURLEncoding.EncodeToString(sum[:8]),
+		key: key,
+	}, nil
+}
+
+// LoadSigningKey читает PEM файл с ключом в формате PKCS#1 или PKCS#8
+func LoadSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrKeyNotValid
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewSigningKey(key)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, ErrKeyNotValid
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrKeyNotValid
+	}
+	return NewSigningKey(key)
+}
+
+// GenerateSigningKey временный ключ, токены, подписанные им, не проверяются после перезапуска
+func GenerateSigningKey() (*SigningKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigningKey(key)
+}
+
+func (k *SigningKey) ID() string {
+	return k.id
+}
+
+func (k *SigningKey) JWK() JWK {
+	pub := k.key.PublicKey
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: k.id,
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
@@ -1,22 +1,108 @@
 package jwt
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/LeoUraltsev/auth-service/internal/config"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"log/slog"
 	"time"
 )
 
+// Алгоритмы подписи токенов доступа
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+// TypeAccessToken заголовок typ токенов доступа RS256
+const TypeAccessToken = "at+jwt"
+
 type AuthClaims struct {
 	*jwt.RegisteredClaims
 	UserID uuid.UUID `json:"user_id"`
+	// TenantID организация пользователя, в старых токенах пустой
+	TenantID uuid.UUID `json:"tenant_id"`
+	Role     string    `json:"role"`
+	// ClientID и Scope заполнены у токенов, выданных через OAuth
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// PrincipalType user, service_account или client, в старых токенах пустой
+	PrincipalType string `json:"principal_type,omitempty"`
+	// SessionID семейство refresh токенов, заполнен у токенов, выданных по коду авторизации
+	SessionID string `json:"sid,omitempty"`
+	// Groups записываются в claim с именем GroupsClaim, если оно задано
+	Groups      []string `json:"-"`
+	GroupsClaim string   `json:"-"`
+}
+
+// reservedClaims имена, которые нельзя занять claim групп
+var reservedClaims = map[string]bool{
+	"iss": true, "sub": true, "aud": true, "exp": true, "nbf": true, "iat": true, "jti": true,
+	"user_id": true, "tenant_id": true, "role": true, "client_id": true, "scope": true, "principal_type": true, "sid": true,
+}
+
+// ValidateGroupsClaim имя claim групп не должно совпадать с остальными claims токена доступа
+func ValidateGroupsClaim(name string) error {
+	if reservedClaims[name] {
+		return fmt.Errorf("groups claim %q is reserved", name)
+	}
+	return nil
+}
+
+func (c *AuthClaims) MarshalJSON() ([]byte, error) {
+	type claims AuthClaims
+	data, err := json.Marshal((*claims)(c))
+	if err != nil || c.GroupsClaim == "" || c.Groups == nil {
+		return data, err
+	}
+	var m map[string]any
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	m[c.GroupsClaim] = c.Groups
+	return json.Marshal(m)
+}
+
+// Principal тип владельца токена, токены без principal_type выданы пользователям
+func (c *AuthClaims) Principal() users.PrincipalType {
+	if c.PrincipalType == "" {
+		return users.PrincipalUser
+	}
+	return users.PrincipalType(c.PrincipalType)
+}
+
+// AuthPrincipal владелец токена для контекста запроса, в том же виде, что у сервисов с authverify
+func (c *AuthClaims) AuthPrincipal() *authverify.Principal {
+	p := &authverify.Principal{
+		UserID:    c.UserID,
+		TenantID:  c.TenantID,
+		Role:      c.Role,
+		Type:      c.Principal().String(),
+		ClientID:  c.ClientID,
+		Scope:     oauth.ParseScope(c.Scope),
+		SessionID: c.SessionID,
+	}
+	if c.RegisteredClaims != nil {
+		p.Subject = c.Subject
+		if c.ExpiresAt != nil {
+			p.ExpiresAt = c.ExpiresAt.Time
+		}
+	}
+	if p.Subject == "" && p.UserID != uuid.Nil {
+		p.Subject = p.UserID.String()
+	}
+	return p
 }
 
 type Token struct {
 	log *slog.Logger
 	cfg *config.Config
+	key *SigningKey
 }
 
 func NewToken(log *slog.Logger, cfg *config.Config) *Token {
@@ -26,16 +112,29 @@ func NewToken(log *slog.Logger, cfg *config.Config) *Token {
 	}
 }
 
-func (t *Token) GenerateToken(userID uuid.UUID) (string, error) {
+// WithSigningKey ключ для ID токенов и токенов доступа RS256, без него IssueIDToken возвращает ошибку
+func (t *Token) WithSigningKey(key *SigningKey) *Token {
+	t.key = key
+	return t
+}
+
+func (t *Token) GenerateToken(userID uuid.UUID, tenantID uuid.UUID, role users.Role, groups []string) (string, error) {
 	log := t.log
 	log.Info("Generating token")
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &AuthClaims{
+	now := time.Now().UTC()
+	signedString, err := t.signAccessToken(&AuthClaims{
 		RegisteredClaims: &jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(t.cfg.JWT.Expiration)),
+			Issuer:    t.cfg.OAuth.Issuer,
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.cfg.JWT.Expiration)),
 		},
-		UserID: userID,
+		UserID:        userID,
+		TenantID:      tenantID,
+		Role:          role.String(),
+		PrincipalType: users.PrincipalUser.String(),
+		Groups:        groups,
 	})
-	signedString, err := token.SignedString([]byte(t.cfg.JWT.Secret))
 	if err != nil {
 		log.Warn("Failed to sign token")
 		return "", err
@@ -44,13 +143,133 @@ func (t *Token) GenerateToken(userID uuid.UUID) (string, error) {
 	return signedString, err
 }
 
+// IssueAccessToken токен доступа для клиента OAuth, подписывается так же, как токены Login
+func (t *Token) IssueAccessToken(grant oauth.AccessGrant) (string, time.Duration, error) {
+	log := t.log.With(slog.String("client_id", grant.ClientID))
+	log.Info("Issuing access token")
+
+	subject := grant.ClientID
+	if grant.UserID != uuid.Nil {
+		subject = grant.UserID.String()
+	}
+	principal := grant.Principal
+	if principal == "" {
+		principal = users.PrincipalUser
+	}
+	var sessionID string
+	if grant.SessionID != uuid.Nil {
+		sessionID = grant.SessionID.String()
+	}
+	now := time.Now().UTC()
+	signedString, err := t.signAccessToken(&AuthClaims{
+		RegisteredClaims: &jwt.RegisteredClaims{
+			Issuer:    t.cfg.OAuth.Issuer,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{grant.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.cfg.JWT.Expiration)),
+			ID:        uuid.NewString(),
+		},
+		UserID:        grant.UserID,
+		TenantID:      grant.TenantID,
+		Role:          grant.Role.String(),
+		ClientID:      grant.ClientID,
+		Scope:         grant.Scope.String(),
+		PrincipalType: principal.String(),
+		SessionID:     sessionID,
+		Groups:        grant.Groups,
+	})
+	if err != nil {
+		log.Warn("Failed to sign access token")
+		return "", 0, err
+	}
+	return signedString, t.cfg.JWT.Expiration, nil
+}
+
+// signAccessToken HS256 общим секретом или RS256 ключом из JWKS, если так настроено.
+// У RS256 токенов доступа typ at+jwt (RFC 9068), по нему они отличаются от ID токенов с тем же ключом
+func (t *Token) signAccessToken(claims *AuthClaims) (string, error) {
+	t.groupsClaim(claims)
+	if t.cfg.JWT.AccessTokenAlg != AlgRS256 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(t.cfg.JWT.Secret))
+	}
+	if t.key == nil {
+		return "", ErrKeyNotValid
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = t.key.ID()
+	token.Header["typ"] = TypeAccessToken
+	return token.SignedString(t.key.key)
+}
+
+// groupsClaim группы попадают в токен, только если claim настроен и групп не больше groups.claim_max_groups,
+// иначе токен рос бы без ограничений
+func (t *Token) groupsClaim(claims *AuthClaims) {
+	if t.cfg.Groups.ClaimName == "" || claims.Groups == nil {
+		claims.Groups = nil
+		return
+	}
+	if len(claims.Groups) > t.cfg.Groups.ClaimMaxGroups {
+		t.log.Warn("too many groups for token claim, claim omitted",
+			slog.String("user_id", claims.UserID.String()), slog.Int("groups", len(claims.Groups)))
+		claims.Groups = nil
+		return
+	}
+	claims.GroupsClaim = t.cfg.Groups.ClaimName
+}
+
+// IssueIDToken ID токен OIDC, подписывается RS256, чтобы клиенты проверяли его по JWKS
+func (t *Token) IssueIDToken(idToken oauth.IDToken) (string, error) {
+	log := t.log.With(slog.String("client_id", idToken.Audience))
+	log.Info("Issuing id token")
+	if t.key == nil {
+		return "", ErrKeyNotValid
+	}
+
+	now := time.Now().UTC()
+	claims := jwt.MapClaims{
+		"iss": t.cfg.OAuth.Issuer,
+		"aud": idToken.Audience,
+		"iat": now.Unix(),
+		"exp": now.Add(t.cfg.JWT.Expiration).Unix(),
+	}
+	for name, value := range idToken.UserInfo.Claims() {
+		claims[name] = value
+	}
+	if !idToken.Auth.Time.IsZero() {
+		claims["auth_time"] = idToken.Auth.Time.Unix()
+	}
+	if idToken.Auth.ACR != "" {
+		claims["acr"] = idToken.Auth.ACR
+	}
+	if idToken.Nonce != "" {
+		claims["nonce"] = idToken.Nonce
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = t.key.ID()
+	signedString, err := token.SignedString(t.key.key)
+	if err != nil {
+		log.Warn("Failed to sign id token")
+		return "", err
+	}
+	return signedString, nil
+}
+
+// JWKS публичные ключи ID токенов
+func (t *Token) JWKS() JWKS {
+	if t.key == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return JWKS{Keys: []JWK{t.key.JWK()}}
+}
+
 func (t *Token) ValidateToken(token string) (*AuthClaims, error) {
 	tkn, err := jwt.ParseWithClaims(
 		token,
 		&AuthClaims{},
-		func(j *jwt.Token) (interface{}, error) {
-			return []byte(t.cfg.JWT.Secret), nil
-		},
+		t.accessTokenKey,
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256}),
 	)
 	if err != nil {
 		t.log.Warn("Failed to parse token", slog.String("err", err.Error()))
@@ -70,3 +289,60 @@ func (t *Token) ValidateToken(token string) (*AuthClaims, error) {
 
 	return claims, nil
 }
+
+// accessTokenKey HS256 токены принимаются всегда, чтобы смена алгоритма не разлогинила всех.
+// ID токены подписаны тем же RSA ключом и не должны приниматься как токены доступа
+func (t *Token) accessTokenKey(j *jwt.Token) (interface{}, error) {
+	if j.Method.Alg() == AlgHS256 {
+		return []byte(t.cfg.JWT.Secret), nil
+	}
+	if typ, _ := j.Header["typ"].(string); typ != TypeAccessToken {
+		return nil, fmt.Errorf("token type %q is not an access token", typ)
+	}
+	if kid, _ := j.Header["kid"].(string); t.key == nil || kid != t.key.ID() {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return &t.key.key.PublicKey, nil
+}
+
+// VerifyAccessToken проверяет токен так же, как ValidateToken, и переводит claims в доменный тип
+func (t *Token) VerifyAccessToken(token string) (oauth.AccessToken, error) {
+	claims, err := t.ValidateToken(token)
+	if err != nil {
+		return oauth.AccessToken{}, err
+	}
+	return claims.AccessToken()
+}
+
+// AccessToken claims проверенного токена в виде, независимом от формата
+func (c *AuthClaims) AccessToken() (oauth.AccessToken, error) {
+	res := oauth.AccessToken{
+		UserID:    c.UserID,
+		Role:      users.Role(c.Role),
+		ClientID:  c.ClientID,
+		Scope:     oauth.ParseScope(c.Scope),
+		Principal: c.Principal(),
+	}
+	if c.RegisteredClaims != nil {
+		res.ID = c.ID
+		res.Subject = c.Subject
+		if c.ExpiresAt != nil {
+			res.ExpiresAt = c.ExpiresAt.Time
+		}
+		// у токенов Login нет sub и iat
+		if c.IssuedAt != nil {
+			res.IssuedAt = c.IssuedAt.Time
+		}
+	}
+	if res.Subject == "" && c.UserID != uuid.Nil {
+		res.Subject = c.UserID.String()
+	}
+	if c.SessionID != "" {
+		sessionID, err := uuid.Parse(c.SessionID)
+		if err != nil {
+			return oauth.AccessToken{}, err
+		}
+		res.SessionID = sessionID
+	}
+	return res, nil
+}
@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"github.com/LeoUraltsev/auth-service/internal/domain/mail"
+	"github.com/google/uuid"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileSender сохраняет письма .eml файлами в каталог вместо отправки, для локальной разработки и тестовых стендов
+type FileSender struct {
+	dir  string
+	from string
+	log  *slog.Logger
+}
+
+func NewFileSender(dir string, from string, log *slog.Logger) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileSender{dir: dir, from: from, log: log}, nil
+}
+
+func (s *FileSender) Send(_ context.Context, message mail.Message) error {
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), uuid.NewString())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", message.To.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(message.Body)
+
+	// письма содержат одноразовые ссылки, читать их может только владелец процесса
+	if err := os.WriteFile(filepath.Join(s.dir, name), []byte(b.String()), 0o600); err != nil {
+		return err
+	}
+	s.log.Info("mail saved", slog.String("file", name))
+	return nil
+}
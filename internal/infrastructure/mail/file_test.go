@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/mail"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSender_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	sender, err := NewFileSender(dir, "no-reply@example.com", slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	to, _ := users.NewEmail("user@example.com")
+	require.NoError(t, sender.Send(context.Background(), mail.Message{To: to, Subject: "Hello", Body: "link"}))
+	require.NoError(t, sender.Send(context.Background(), mail.Message{To: to, Subject: "Again", Body: "link"}))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	info, err := files[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "From: no-reply@example.com\r\n")
+	assert.Contains(t, string(content), "To: user@example.com\r\n")
+	assert.Contains(t, string(content), "Subject: Hello\r\n")
+	assert.Contains(t, string(content), "\r\n\r\nlink")
+}
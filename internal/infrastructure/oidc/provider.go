@@ -0,0 +1,275 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/LeoUraltsev/auth-service/internal/config"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// keysRefreshInterval не чаще этого перечитываем JWKS при неизвестном kid
+const keysRefreshInterval = time.Minute
+
+// Metadata часть документа discovery, которая нужна для входа
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+// Provider внешний OIDC провайдер, discovery и JWKS загружаются при первом входе
+type Provider struct {
+	cfg         config.ProviderConfig
+	redirectURL string
+	client      *http.Client
+	log         *slog.Logger
+
+	mu            sync.Mutex
+	metadata      *Metadata
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewProvider(cfg config.ProviderConfig, redirectURL string, client *http.Client, log *slog.Logger) *Provider {
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.EmailClaim == "" {
+		cfg.EmailClaim = "email"
+	}
+	if cfg.EmailVerifiedClaim == "" {
+		cfg.EmailVerifiedClaim = "email_verified"
+	}
+	if cfg.NameClaim == "" {
+		cfg.NameClaim = "name"
+	}
+	return &Provider{
+		cfg:         cfg,
+		redirectURL: redirectURL,
+		client:      client,
+		log:         log.With(slog.String("provider", cfg.Name)),
+	}
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", identity.ErrProviderNotUsable, err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.redirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (p *Provider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (identity.Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return identity.Claims{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return identity.Claims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	var res tokenResponse
+	if err = p.do(req, &res); err != nil {
+		p.log.Warn("failed to exchange code", slog.String("error", err.Error()))
+		return identity.Claims{}, fmt.Errorf("%w: %v", identity.ErrProviderRejected, err)
+	}
+	if res.IDToken == "" {
+		return identity.Claims{}, fmt.Errorf("%w: id_token missing", identity.ErrIDTokenNotValid)
+	}
+	return p.verify(ctx, metadata, res.IDToken, nonce)
+}
+
+// verify проверяет подпись, iss, aud, exp и nonce ID токена
+func (p *Provider) verify(ctx context.Context, metadata *Metadata, idToken string, nonce string) (identity.Claims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims,
+		func(t *jwt.Token) (interface{}, error) {
+			kid, _ := t.Header["kid"].(string)
+			return p.key(ctx, metadata, kid)
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return identity.Claims{}, fmt.Errorf("%w: %v", identity.ErrIDTokenNotValid, err)
+	}
+
+	tokenNonce, _ := claims["nonce"].(string)
+	if subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return identity.Claims{}, fmt.Errorf("%w: nonce mismatch", identity.ErrIDTokenNotValid)
+	}
+	return p.mapClaims(claims), nil
+}
+
+// mapClaims достает данные пользователя по именам claims из конфига
+func (p *Provider) mapClaims(claims jwt.MapClaims) identity.Claims {
+	res := identity.Claims{}
+	res.Subject, _ = claims["sub"].(string)
+	res.Email, _ = claims[p.cfg.EmailClaim].(string)
+	res.Name, _ = claims[p.cfg.NameClaim].(string)
+	switch v := claims[p.cfg.EmailVerifiedClaim].(type) {
+	case bool:
+		res.EmailVerified = v
+	case string:
+		// некоторые провайдеры отдают email_verified строкой
+		res.EmailVerified = v == "true"
+	}
+	return res
+}
+
+func (p *Provider) discover(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var metadata Metadata
+	if err = p.do(req, &metadata); err != nil {
+		p.log.Warn("failed to load provider metadata", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%w: %v", identity.ErrProviderNotUsable, err)
+	}
+	// OIDC Discovery 4.3: issuer в документе должен совпадать с тем, у кого его запросили
+	if strings.TrimSuffix(metadata.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch %q", identity.ErrProviderNotUsable, metadata.Issuer)
+	}
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+func (p *Provider) key(ctx context.Context, metadata *Metadata, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadata.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = p.do(req, &set); err != nil {
+		p.log.Warn("failed to load provider keys", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		key, err := rsaPublicKey(k)
+		if err != nil {
+			p.log.Warn("skipping provider key", slog.String("kid", k.Kid), slog.String("error", err.Error()))
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return key, nil
+}
+
+func (p *Provider) do(req *http.Request, out any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: status %d: %s", req.Method, req.URL.Path, resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, out)
+}
+
+func rsaPublicKey(k jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -0,0 +1,208 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/LeoUraltsev/auth-service/internal/config"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+var log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+// mockIdP локальный OIDC провайдер: discovery, JWKS и token endpoint с заранее заданными claims
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+	// lastForm форма последнего запроса к token endpoint
+	lastForm url.Values
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp := &mockIdP{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Metadata{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []jsonWebKey{{
+			Kty: "RSA",
+			Kid: "k1",
+			N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "rp" || secret != "rp-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = r.ParseForm()
+		idp.lastForm = r.PostForm
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims)
+		token.Header["kid"] = "k1"
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		_ = json.NewEncoder(w).Encode(tokenResponse{IDToken: signed})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (m *mockIdP) provider(cfg config.ProviderConfig) *Provider {
+	cfg.Name = "corp"
+	cfg.Issuer = m.server.URL
+	cfg.ClientID = "rp"
+	cfg.ClientSecret = "rp-secret"
+	return NewProvider(cfg, "https://auth.example.com/federated/corp/callback", m.server.Client(), log)
+}
+
+func (m *mockIdP) validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            m.server.URL,
+		"sub":            "248289761001",
+		"aud":            "rp",
+		"exp":            now.Add(time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          "nonce",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane",
+	}
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	idp := newMockIdP(t)
+	p := idp.provider(config.ProviderConfig{})
+
+	raw, err := p.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	require.NoError(t, err)
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, idp.server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	q := u.Query()
+	assert.Equal(t, "rp", q.Get("client_id"))
+	assert.Equal(t, "state", q.Get("state"))
+	assert.Equal(t, "nonce", q.Get("nonce"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Equal(t, "openid email profile", q.Get("scope"))
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     config.ProviderConfig
+		modify  func(idp *mockIdP, claims jwt.MapClaims)
+		want    identity.Claims
+		wantErr error
+	}{
+		{
+			name: "valid",
+			want: identity.Claims{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true, Name: "Jane"},
+		},
+		{
+			name: "mapped claims",
+			cfg:  config.ProviderConfig{EmailClaim: "upn", NameClaim: "given_name"},
+			modify: func(_ *mockIdP, claims jwt.MapClaims) {
+				claims["upn"] = "jane@corp.example.com"
+				claims["given_name"] = "Jane D."
+				claims["email_verified"] = "true"
+			},
+			want: identity.Claims{Subject: "248289761001", Email: "jane@corp.example.com", EmailVerified: true, Name: "Jane D."},
+		},
+		{
+			name: "nonce mismatch",
+			modify: func(_ *mockIdP, claims jwt.MapClaims) {
+				claims["nonce"] = "other"
+			},
+			wantErr: identity.ErrIDTokenNotValid,
+		},
+		{
+			name: "wrong audience",
+			modify: func(_ *mockIdP, claims jwt.MapClaims) {
+				claims["aud"] = "someone-else"
+			},
+			wantErr: identity.ErrIDTokenNotValid,
+		},
+		{
+			name: "wrong issuer",
+			modify: func(_ *mockIdP, claims jwt.MapClaims) {
+				claims["iss"] = "https://evil.example.com"
+			},
+			wantErr: identity.ErrIDTokenNotValid,
+		},
+		{
+			name: "expired",
+			modify: func(_ *mockIdP, claims jwt.MapClaims) {
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			},
+			wantErr: identity.ErrIDTokenNotValid,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			idp.claims = idp.validClaims()
+			if tt.modify != nil {
+				tt.modify(idp, idp.claims)
+			}
+			p := idp.provider(tt.cfg)
+
+			claims, err := p.Exchange(context.Background(), "code", "verifier", "nonce")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, claims)
+			assert.Equal(t, "verifier", idp.lastForm.Get("code_verifier"))
+			assert.Equal(t, "code", idp.lastForm.Get("code"))
+		})
+	}
+}
+
+func TestProvider_Exchange_wrongKey(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.claims = idp.validClaims()
+	p := idp.provider(config.ProviderConfig{})
+
+	_, err := p.Exchange(context.Background(), "code", "verifier", "nonce")
+	require.NoError(t, err)
+
+	// токен с kid провайдера, но подписанный чужим ключом
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.validClaims())
+	forged.Header["kid"] = "k1"
+	signed, err := forged.SignedString(other)
+	require.NoError(t, err)
+
+	_, err = p.verify(context.Background(), p.metadata, signed, "nonce")
+	assert.ErrorIs(t, err, identity.ErrIDTokenNotValid)
+}
@@ -0,0 +1,313 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/cel-go/cel"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var ErrPolicyNotValid = errors.New("policy is not valid")
+
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+type Mode string
+
+const (
+	// ModeEnforce запрещенные вызовы отклоняются с PermissionDenied
+	ModeEnforce Mode = "enforce"
+	// ModeDryRun решения только пишутся в лог, вызовы не отклоняются
+	ModeDryRun Mode = "dry_run"
+)
+
+// File формат YAML файла политик
+type File struct {
+	Mode Mode `yaml:"mode"`
+	// Default решение, если ни одна политика не сработала, по умолчанию allow
+	Default  Effect       `yaml:"default"`
+	Policies []PolicySpec `yaml:"policies"`
+}
+
+type PolicySpec struct {
+	Name string `yaml:"name"`
+	// Methods полные имена RPC, /auth.UserService/* для всех методов сервиса, пустой список - все методы
+	Methods []string `yaml:"methods"`
+	Effect  Effect   `yaml:"effect"`
+	// Condition CEL выражение с типом bool над principal, method и request
+	Condition string `yaml:"condition"`
+}
+
+// Decision решение по вызову. DryRun - решение не применяется
+type Decision struct {
+	Allowed bool
+	// Policy имя сработавшей политики, пустое - решение по умолчанию
+	Policy string
+	DryRun bool
+}
+
+type policy struct {
+	name    string
+	methods []string
+	effect  Effect
+	program cel.Program
+}
+
+type policySet struct {
+	mode     Mode
+	allow    bool
+	policies []policy
+}
+
+// Engine вычисляет политики на CEL. Файл перечитывается при изменении, при ошибке в новом файле
+// продолжают действовать прежние политики
+type Engine struct {
+	path     string
+	interval time.Duration
+	env      *cel.Env
+	log      *slog.Logger
+
+	set     atomic.Pointer[policySet]
+	modTime time.Time
+}
+
+func NewEngine(path string, interval time.Duration, log *slog.Logger) (*Engine, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("principal", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("method", cel.StringType),
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	e := &Engine{
+		path:     path,
+		interval: interval,
+		env:      env,
+		log:      log,
+	}
+	if err = e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload читает и компилирует файл политик
+func (e *Engine) Reload() error {
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return err
+	}
+	var f File
+	if err = yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("%w: %w", ErrPolicyNotValid, err)
+	}
+	set, err := e.compile(f)
+	if err != nil {
+		return err
+	}
+	e.set.Store(set)
+	e.modTime = info.ModTime()
+	e.log.Info("policies loaded", slog.Int("count", len(set.policies)), slog.String("mode", string(set.mode)))
+	return nil
+}
+
+// Run перечитывает файл политик, когда меняется время его изменения
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(e.path)
+			if err != nil {
+				e.log.Warn("failed to check policies file", slog.String("error", err.Error()))
+				continue
+			}
+			if info.ModTime().Equal(e.modTime) {
+				continue
+			}
+			if err = e.Reload(); err != nil {
+				e.log.Error("failed to reload policies, keeping previous", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+func (e *Engine) compile(f File) (*policySet, error) {
+	set := &policySet{mode: f.Mode, allow: true}
+	switch f.Mode {
+	case "":
+		set.mode = ModeEnforce
+	case ModeEnforce, ModeDryRun:
+	default:
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrPolicyNotValid, f.Mode)
+	}
+	switch f.Default {
+	case "", EffectAllow:
+	case EffectDeny:
+		set.allow = false
+	default:
+		return nil, fmt.Errorf("%w: unknown default %q", ErrPolicyNotValid, f.Default)
+	}
+
+	for _, spec := range f.Policies {
+		if spec.Effect != EffectAllow && spec.Effect != EffectDeny {
+			return nil, fmt.Errorf("%w: policy %q: unknown effect %q", ErrPolicyNotValid, spec.Name, spec.Effect)
+		}
+		ast, issues := e.env.Compile(spec.Condition)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("%w: policy %q: %w", ErrPolicyNotValid, spec.Name, issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("%w: policy %q: condition must be bool", ErrPolicyNotValid, spec.Name)
+		}
+		program, err := e.env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("%w: policy %q: %w", ErrPolicyNotValid, spec.Name, err)
+		}
+		set.policies = append(set.policies, policy{
+			name:    spec.Name,
+			methods: spec.Methods,
+			effect:  spec.Effect,
+			program: program,
+		})
+	}
+	return set, nil
+}
+
+// Evaluate запрещающая политика важнее разрешающей. Ошибка вычисления запрещающей политики
+// считается срабатыванием, разрешающей - нет
+func (e *Engine) Evaluate(ctx context.Context, method string, principal *authverify.Principal, request any) Decision {
+	log := logger.LogWithContext(ctx, e.log).With(slog.String("method", method))
+	set := e.set.Load()
+	vars := map[string]any{
+		"principal": principalVars(principal),
+		"method":    method,
+		"request":   requestVars(request),
+	}
+
+	d := Decision{Allowed: set.allow, DryRun: set.mode == ModeDryRun}
+	allowed := ""
+	for _, p := range set.policies {
+		if !matchMethod(p.methods, method) {
+			continue
+		}
+		matched, err := evaluate(p.program, vars)
+		if err != nil {
+			log.Warn("failed to evaluate policy", slog.String("policy", p.name), slog.String("error", err.Error()))
+			matched = p.effect == EffectDeny
+		}
+		if !matched {
+			continue
+		}
+		if p.effect == EffectDeny {
+			d.Allowed, d.Policy = false, p.name
+			allowed = ""
+			break
+		}
+		if allowed == "" {
+			allowed = p.name
+		}
+	}
+	if allowed != "" {
+		d.Allowed, d.Policy = true, allowed
+	}
+
+	switch {
+	case d.DryRun:
+		log.Info("policy decision", slog.Bool("allowed", d.Allowed), slog.String("policy", d.Policy), slog.Bool("dry_run", true))
+	case !d.Allowed:
+		log.Warn("denied by policy", slog.String("policy", d.Policy))
+	}
+	return d
+}
+
+func evaluate(program cel.Program, vars map[string]any) (bool, error) {
+	out, _, err := program.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("condition returned %s", out.Type())
+	}
+	return matched, nil
+}
+
+func matchMethod(methods []string, method string) bool {
+	if len(methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		if prefix, ok := strings.CutSuffix(m, "*"); ok && strings.HasPrefix(method, prefix) {
+			return true
+		}
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// principalVars у вызовов без токена все поля пустые, authenticated = false
+func principalVars(p *authverify.Principal) map[string]any {
+	if p == nil {
+		p = &authverify.Principal{}
+	}
+	vars := map[string]any{
+		"authenticated": p.Subject != "" || p.UserID != uuid.Nil,
+		"sub":           p.Subject,
+		"user_id":       "",
+		"tenant_id":     "",
+		"role":          p.Role,
+		"type":          p.Type,
+		"client_id":     p.ClientID,
+		"scope":         append([]string{}, p.Scope...),
+		"session_id":    p.SessionID,
+	}
+	if p.UserID != uuid.Nil {
+		vars["user_id"] = p.UserID.String()
+	}
+	if p.TenantID != uuid.Nil {
+		vars["tenant_id"] = p.TenantID.String()
+	}
+	return vars
+}
+
+// requestVars поля запроса с именами из proto, незаполненные поля тоже присутствуют
+func requestVars(request any) map[string]any {
+	vars := map[string]any{}
+	msg, ok := request.(proto.Message)
+	if !ok || msg == nil {
+		return vars
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		return vars
+	}
+	_ = json.Unmarshal(data, &vars)
+	return vars
+}
@@ -0,0 +1,152 @@
+package policy
+
+import (
+	"context"
+	authapi "github.com/LeoUraltsev/auth-service/gen/go/auth"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+const policies = `
+mode: enforce
+policies:
+  - name: support-read-only
+    methods: ["/auth.InvitationService/*"]
+    effect: deny
+    condition: '"support" in principal.scope && method != "/auth.InvitationService/ListInvitations"'
+  - name: no-admin-invitations
+    methods: ["/auth.InvitationService/CreateInvitation"]
+    effect: deny
+    condition: request.role == "admin" && principal.role != "admin"
+  - name: missing-field
+    methods: ["/auth.OrganizationService/GetOrganization"]
+    effect: deny
+    condition: request.unknown == "x"
+`
+
+func writePolicies(t *testing.T, path string, data string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	writePolicies(t, path, policies)
+	engine, err := NewEngine(path, time.Second, log)
+	require.NoError(t, err)
+
+	support := &authverify.Principal{UserID: uuid.New(), Role: "user", Scope: []string{"support"}}
+	user := &authverify.Principal{UserID: uuid.New(), Role: "user"}
+	admin := &authverify.Principal{UserID: uuid.New(), Role: "admin"}
+
+	cases := []struct {
+		name       string
+		method     string
+		principal  *authverify.Principal
+		request    any
+		wantAllow  bool
+		wantPolicy string
+	}{
+		{name: "support list", method: "/auth.InvitationService/ListInvitations", principal: support, request: &authapi.ListInvitationsRequest{}, wantAllow: true},
+		{name: "support revoke", method: "/auth.InvitationService/RevokeInvitation", principal: support, request: &authapi.RevokeInvitationRequest{}, wantPolicy: "support-read-only"},
+		{name: "user invites admin", method: "/auth.InvitationService/CreateInvitation", principal: user, request: &authapi.CreateInvitationRequest{Role: "admin"}, wantPolicy: "no-admin-invitations"},
+		{name: "user invites user", method: "/auth.InvitationService/CreateInvitation", principal: user, request: &authapi.CreateInvitationRequest{Role: "user"}, wantAllow: true},
+		{name: "admin invites admin", method: "/auth.InvitationService/CreateInvitation", principal: admin, request: &authapi.CreateInvitationRequest{Role: "admin"}, wantAllow: true},
+		{name: "without token", method: "/auth.InvitationService/AcceptInvitation", request: &authapi.AcceptInvitationRequest{}, wantAllow: true},
+		{name: "deny on error", method: "/auth.OrganizationService/GetOrganization", principal: admin, request: &authapi.GetOrganizationRequest{}, wantPolicy: "missing-field"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			d := engine.Evaluate(context.Background(), tt.method, tt.principal, tt.request)
+			assert.Equal(t, tt.wantAllow, d.Allowed)
+			assert.Equal(t, tt.wantPolicy, d.Policy)
+			assert.False(t, d.DryRun)
+		})
+	}
+}
+
+func TestEngine_defaultDeny(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	writePolicies(t, path, `
+mode: dry_run
+default: deny
+policies:
+  - name: admins
+    effect: allow
+    condition: principal.role == "admin"
+`)
+	engine, err := NewEngine(path, time.Second, log)
+	require.NoError(t, err)
+
+	d := engine.Evaluate(context.Background(), "/auth.UserService/GetUser", &authverify.Principal{Role: "user"}, nil)
+	assert.False(t, d.Allowed)
+	assert.True(t, d.DryRun)
+
+	d = engine.Evaluate(context.Background(), "/auth.UserService/GetUser", &authverify.Principal{Role: "admin"}, nil)
+	assert.True(t, d.Allowed)
+	assert.Equal(t, "admins", d.Policy)
+}
+
+func TestNewEngine_notValid(t *testing.T) {
+	cases := map[string]string{
+		"syntax":   "policies:\n  - name: broken\n    effect: deny\n    condition: principal.role ==\n",
+		"not bool": "policies:\n  - name: string\n    effect: deny\n    condition: principal.role\n",
+		"effect":   "policies:\n  - name: effect\n    effect: maybe\n    condition: 'true'\n",
+		"mode":     "mode: audit\n",
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policies.yaml")
+			writePolicies(t, path, data)
+			_, err := NewEngine(path, time.Second, log)
+			assert.ErrorIs(t, err, ErrPolicyNotValid)
+		})
+	}
+}
+
+func TestEngine_Run_reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	writePolicies(t, path, "mode: enforce\n")
+	engine, err := NewEngine(path, 10*time.Millisecond, log)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	denied := func() bool {
+		return !engine.Evaluate(context.Background(), "/auth.UserService/GetUser", nil, nil).Allowed
+	}
+
+	writePolicies(t, path, "default: deny\n")
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	assert.Eventually(t, denied, time.Second, 10*time.Millisecond)
+
+	// файл с ошибкой не применяется, действуют прежние политики
+	writePolicies(t, path, "default: maybe\n")
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, denied())
+}
+
+func TestNewEngine_example(t *testing.T) {
+	_, err := NewEngine(filepath.Join("..", "..", "..", "config", "policies.example.yaml"), time.Second, log)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,100 @@
+package pgnotify
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const reconnectDelay = 2 * time.Second
+
+// Listener держит отдельное соединение с LISTEN на канал и раздает сигналы подписчикам.
+// Сигналы не накапливаются: подписчику достаточно знать, что появились новые данные
+type Listener struct {
+	dsn     string
+	channel string
+	log     *slog.Logger
+
+	mu     sync.Mutex
+	nextID int
+	subs   map[int]chan struct{}
+}
+
+func NewListener(dsn string, channel string, log *slog.Logger) *Listener {
+	return &Listener{
+		dsn:     dsn,
+		channel: channel,
+		log:     log.With(slog.String("channel", channel)),
+		subs:    make(map[int]chan struct{}),
+	}
+}
+
+func (l *Listener) Subscribe() (<-chan struct{}, func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	id := l.nextID
+	l.nextID++
+	ch := make(chan struct{}, 1)
+	l.subs[id] = ch
+
+	return ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.subs, id)
+	}
+}
+
+// Run слушает канал до отмены контекста, при обрыве соединения переподключается
+func (l *Listener) Run(ctx context.Context) {
+	l.log.Info("notification listener started")
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			l.log.Info("notification listener stopped")
+			return
+		}
+		l.log.Warn("notification listener disconnected", slog.String("error", err.Error()))
+		// после переподключения часть уведомлений могла потеряться, будим подписчиков
+		l.broadcast()
+
+		select {
+		case <-ctx.Done():
+			l.log.Info("notification listener stopped")
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		if _, err = conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		l.broadcast()
+	}
+}
+
+func (l *Listener) broadcast() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, ch := range l.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+type APIKeysStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type APIKey struct {
+	id         string
+	userID     string
+	name       string
+	prefix     string
+	keyHash    string
+	scope      []string
+	expiresAt  *time.Time
+	lastUsedAt *time.Time
+	revokedAt  *time.Time
+	createdAt  time.Time
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scope, expires_at, last_used_at, revoked_at, created_at`
+
+func NewAPIKeysStorage(tx pgx.Tx, log *slog.Logger) *APIKeysStorage {
+	return &APIKeysStorage{tx: tx, log: log}
+}
+
+func (a *APIKeysStorage) Save(ctx context.Context, key *apikeys.APIKey) error {
+	log := logger.LogWithContext(ctx, a.log)
+	scope := []string(key.Scope())
+	if scope == nil {
+		scope = []string{}
+	}
+
+	query := `INSERT INTO api_keys (` + apiKeyColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (id) DO UPDATE
+		SET last_used_at = EXCLUDED.last_used_at,
+		    revoked_at = EXCLUDED.revoked_at;`
+	_, err := a.tx.Exec(ctx, query,
+		key.ID().String(), key.UserID().String(), key.Name(), key.Prefix(), key.KeyHash(), scope,
+		key.ExpiresAt(), key.LastUsedAt(), key.RevokedAt(), key.CreatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save api key", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (a *APIKeysStorage) Get(ctx context.Context, id uuid.UUID) (*apikeys.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1;`
+	return a.get(ctx, query, id.String())
+}
+
+func (a *APIKeysStorage) GetByPrefix(ctx context.Context, prefix string) (*apikeys.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1;`
+	return a.get(ctx, query, prefix)
+}
+
+func (a *APIKeysStorage) ListByUser(ctx context.Context, userID uuid.UUID) ([]*apikeys.APIKey, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at;`
+	rows, err := a.tx.Query(ctx, query, userID.String())
+	if err != nil {
+		log.Error("failed to get api keys", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*apikeys.APIKey, 0)
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			log.Error("failed to scan api key", slog.String("error", err.Error()))
+			return nil, err
+		}
+		res = append(res, apiKeyToDomain(k))
+	}
+	return res, rows.Err()
+}
+
+func (a *APIKeysStorage) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, a.log)
+	tag, err := a.tx.Exec(ctx, `DELETE FROM api_keys WHERE user_id = $1;`, userID.String())
+	if err != nil {
+		log.Error("failed to delete user api keys", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("user api keys deleted", slog.String("user_id", userID.String()), slog.Int64("count", tag.RowsAffected()))
+	return nil
+}
+
+func (a *APIKeysStorage) get(ctx context.Context, query string, arg string) (*apikeys.APIKey, error) {
+	log := logger.LogWithContext(ctx, a.log)
+	k, err := scanAPIKey(a.tx.QueryRow(ctx, query, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apikeys.ErrNotFound
+	}
+	if err != nil {
+		log.Error("failed to get api key", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return apiKeyToDomain(k), nil
+}
+
+func scanAPIKey(row pgx.Row) (APIKey, error) {
+	var k APIKey
+	err := row.Scan(
+		&k.id, &k.userID, &k.name, &k.prefix, &k.keyHash, &k.scope,
+		&k.expiresAt, &k.lastUsedAt, &k.revokedAt, &k.createdAt,
+	)
+	return k, err
+}
+
+func apiKeyToDomain(k APIKey) *apikeys.APIKey {
+	return apikeys.NewAPIKey(
+		uuid.MustParse(k.id),
+		uuid.MustParse(k.userID),
+		k.name,
+		k.prefix,
+		k.keyHash,
+		oauth.Scope(k.scope),
+		k.expiresAt,
+		k.lastUsedAt,
+		k.revokedAt,
+		k.createdAt,
+	)
+}
@@ -0,0 +1,347 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+// auditLockKey ключ advisory lock, сериализующего вставки в цепочку аудита
+const auditLockKey = 7270002
+
+type AuditStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type AuditEvent struct {
+	seq        int64
+	prevHash   *string
+	hash       *string
+	id         string
+	occurredAt time.Time
+	action     string
+	actorID    *string
+	targetType string
+	targetID   *string
+	requestID  *string
+	peerIP     *string
+	outcome    string
+	reason     string
+}
+
+type AuditCheckpoint struct {
+	id        string
+	seq       int64
+	hash      string
+	signature []byte
+	keyID     string
+	createdAt time.Time
+}
+
+const auditEventColumns = `seq, prev_hash, hash, id, occurred_at, action, actor_id, target_type, target_id, request_id, peer_ip, outcome, reason`
+
+func NewAuditStorage(tx pgx.Tx, log *slog.Logger) *AuditStorage {
+	return &AuditStorage{tx: tx, log: log}
+}
+
+func (a *AuditStorage) Append(ctx context.Context, event *audit.Event) error {
+	log := logger.LogWithContext(ctx, a.log)
+
+	// блокировка держится до конца транзакции, иначе две вставки сошлются на один prev_hash
+	if _, err := a.tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1);`, auditLockKey); err != nil {
+		log.Error("failed to lock audit chain", slog.String("error", err.Error()))
+		return err
+	}
+
+	var prevHash string
+	head, err := a.Head(ctx)
+	if err != nil {
+		return err
+	}
+	if head != nil {
+		prevHash = head.Hash()
+	}
+	if err = event.Seal(prevHash); err != nil {
+		log.Error("failed to seal audit event", slog.String("error", err.Error()))
+		return err
+	}
+
+	e := auditEventToStorage(event)
+	query := `INSERT INTO audit_events (prev_hash, hash, id, occurred_at, action, actor_id, target_type, target_id, request_id, peer_ip, outcome, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING seq;`
+	var seq int64
+	err = a.tx.QueryRow(ctx, query,
+		e.prevHash, e.hash, e.id, e.occurredAt, e.action, e.actorID, e.targetType, e.targetID, e.requestID, e.peerIP, e.outcome, e.reason,
+	).Scan(&seq)
+	if err != nil {
+		log.Error("failed to append audit event", slog.String("error", err.Error()))
+		return err
+	}
+	event.SetSeq(seq)
+	log.Debug("audit event appended", slog.String("action", e.action), slog.String("outcome", e.outcome))
+	return nil
+}
+
+func (a *AuditStorage) List(ctx context.Context, filter audit.Filter) ([]*audit.Event, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	var actorID, targetID, action *string
+	if filter.ActorID != uuid.Nil {
+		v := filter.ActorID.String()
+		actorID = &v
+	}
+	if filter.TargetID != "" {
+		targetID = &filter.TargetID
+	}
+	if filter.Action != "" {
+		v := filter.Action.String()
+		action = &v
+	}
+
+	query := `SELECT ` + auditEventColumns + `
+		FROM audit_events
+		WHERE ($1::text IS NULL OR actor_id = $1)
+		  AND ($2::text IS NULL OR target_id = $2)
+		  AND ($3::text IS NULL OR action = $3)
+		ORDER BY seq DESC
+		LIMIT $4 OFFSET $5;`
+	rows, err := a.tx.Query(ctx, query, actorID, targetID, action, filter.Limit, filter.Offset)
+	if err != nil {
+		log.Error("failed to get audit events", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*audit.Event, 0)
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			log.Error("failed to scan audit event", slog.String("error", err.Error()))
+			return nil, err
+		}
+		event, err := auditEventToDomain(e)
+		if err != nil {
+			log.Error("failed to convert audit event to domain", slog.String("error", err.Error()))
+			continue
+		}
+		res = append(res, event)
+	}
+	return res, rows.Err()
+}
+
+func (a *AuditStorage) ListChain(ctx context.Context, afterSeq int64, limit int) ([]*audit.Event, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	query := `SELECT ` + auditEventColumns + `
+		FROM audit_events
+		WHERE seq > $1
+		ORDER BY seq
+		LIMIT $2;`
+	rows, err := a.tx.Query(ctx, query, afterSeq, limit)
+	if err != nil {
+		log.Error("failed to get audit chain", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*audit.Event, 0, limit)
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			log.Error("failed to scan audit event", slog.String("error", err.Error()))
+			return nil, err
+		}
+		// запись, которую не удалось разобрать, не пропускается: для проверки цепочки это разрыв
+		event, err := auditEventToDomain(e)
+		if err != nil {
+			log.Error("failed to convert audit event to domain", slog.String("error", err.Error()))
+			return nil, err
+		}
+		res = append(res, event)
+	}
+	return res, rows.Err()
+}
+
+func (a *AuditStorage) Head(ctx context.Context) (*audit.Event, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	query := `SELECT ` + auditEventColumns + `
+		FROM audit_events
+		WHERE hash IS NOT NULL
+		ORDER BY seq DESC
+		LIMIT 1;`
+	e, err := scanAuditEvent(a.tx.QueryRow(ctx, query))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Error("failed to get audit chain head", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return auditEventToDomain(e)
+}
+
+func (a *AuditStorage) SaveCheckpoint(ctx context.Context, checkpoint *audit.Checkpoint) error {
+	log := logger.LogWithContext(ctx, a.log)
+
+	query := `INSERT INTO audit_checkpoints (id, seq, hash, signature, key_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (seq) DO NOTHING;`
+	_, err := a.tx.Exec(ctx, query,
+		checkpoint.ID().String(),
+		checkpoint.Seq(),
+		checkpoint.Hash(),
+		checkpoint.Signature(),
+		checkpoint.KeyID(),
+		checkpoint.CreatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save audit checkpoint", slog.String("error", err.Error()))
+		return err
+	}
+	log.Debug("audit checkpoint saved", slog.Int64("seq", checkpoint.Seq()))
+	return nil
+}
+
+func (a *AuditStorage) LastCheckpoint(ctx context.Context) (*audit.Checkpoint, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	query := `SELECT id, seq, hash, signature, key_id, created_at
+		FROM audit_checkpoints
+		ORDER BY seq DESC
+		LIMIT 1;`
+	c, err := scanAuditCheckpoint(a.tx.QueryRow(ctx, query))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Error("failed to get last audit checkpoint", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return auditCheckpointToDomain(c), nil
+}
+
+func (a *AuditStorage) ListCheckpoints(ctx context.Context) ([]*audit.Checkpoint, error) {
+	log := logger.LogWithContext(ctx, a.log)
+
+	query := `SELECT id, seq, hash, signature, key_id, created_at
+		FROM audit_checkpoints
+		ORDER BY seq;`
+	rows, err := a.tx.Query(ctx, query)
+	if err != nil {
+		log.Error("failed to get audit checkpoints", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*audit.Checkpoint, 0)
+	for rows.Next() {
+		c, err := scanAuditCheckpoint(rows)
+		if err != nil {
+			log.Error("failed to scan audit checkpoint", slog.String("error", err.Error()))
+			return nil, err
+		}
+		res = append(res, auditCheckpointToDomain(c))
+	}
+	return res, rows.Err()
+}
+
+func scanAuditEvent(row pgx.Row) (AuditEvent, error) {
+	var e AuditEvent
+	err := row.Scan(&e.seq, &e.prevHash, &e.hash, &e.id, &e.occurredAt, &e.action, &e.actorID, &e.targetType, &e.targetID, &e.requestID, &e.peerIP, &e.outcome, &e.reason)
+	return e, err
+}
+
+func scanAuditCheckpoint(row pgx.Row) (AuditCheckpoint, error) {
+	var c AuditCheckpoint
+	err := row.Scan(&c.id, &c.seq, &c.hash, &c.signature, &c.keyID, &c.createdAt)
+	return c, err
+}
+
+func auditCheckpointToDomain(c AuditCheckpoint) *audit.Checkpoint {
+	return audit.NewCheckpoint(
+		uuid.MustParse(c.id),
+		c.seq,
+		c.hash,
+		c.signature,
+		c.keyID,
+		c.createdAt,
+	)
+}
+
+func auditEventToStorage(e *audit.Event) AuditEvent {
+	return AuditEvent{
+		seq:        e.Seq(),
+		prevHash:   nullableString(e.PrevHash()),
+		hash:       nullableString(e.Hash()),
+		id:         e.ID().String(),
+		occurredAt: e.OccurredAt(),
+		action:     e.Action().String(),
+		actorID:    nullableUUID(e.ActorID()),
+		targetType: e.TargetType().String(),
+		targetID:   nullableString(e.TargetID()),
+		requestID:  nullableString(e.RequestID()),
+		peerIP:     nullableString(e.PeerIP()),
+		outcome:    e.Outcome().String(),
+		reason:     e.Reason(),
+	}
+}
+
+func auditEventToDomain(e AuditEvent) (*audit.Event, error) {
+	action, err := audit.NewAction(e.action)
+	if err != nil {
+		return nil, err
+	}
+	outcome, err := audit.NewOutcome(e.outcome)
+	if err != nil {
+		return nil, err
+	}
+	actorID := uuid.Nil
+	if e.actorID != nil {
+		actorID = uuid.MustParse(*e.actorID)
+	}
+	return audit.NewEvent(
+		e.seq,
+		stringOrEmpty(e.prevHash),
+		stringOrEmpty(e.hash),
+		uuid.MustParse(e.id),
+		e.occurredAt,
+		action,
+		actorID,
+		audit.TargetType(e.targetType),
+		stringOrEmpty(e.targetID),
+		stringOrEmpty(e.requestID),
+		stringOrEmpty(e.peerIP),
+		outcome,
+		e.reason,
+	)
+}
+
+func nullableUUID(id uuid.UUID) *string {
+	if id == uuid.Nil {
+		return nil
+	}
+	v := id.String()
+	return &v
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
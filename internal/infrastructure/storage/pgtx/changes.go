@@ -0,0 +1,138 @@
+package pgtx
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// ChangesChannel канал LISTEN/NOTIFY, в который сообщается позиция нового изменения
+const ChangesChannel = "user_changes"
+
+// changesLockKey ключ advisory lock, сериализующего запись в ленту изменений
+const changesLockKey = 7270001
+
+type ChangesStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type Change struct {
+	position   int64
+	changeType string
+	userID     string
+	name       string
+	email      string
+	role       string
+	isActive   bool
+	occurredAt time.Time
+}
+
+func NewChangesStorage(tx pgx.Tx, log *slog.Logger) *ChangesStorage {
+	return &ChangesStorage{tx: tx, log: log}
+}
+
+// Append записывает изменение и уведомляет слушателей после коммита.
+// Advisory lock держится до конца транзакции, поэтому позиции становятся видны строго по возрастанию
+// и читатель, продвинувший курсор, не пропустит изменение из более медленной транзакции
+func (c *ChangesStorage) Append(ctx context.Context, change *changes.Change) error {
+	log := logger.LogWithContext(ctx, c.log)
+
+	if _, err := c.tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1);`, changesLockKey); err != nil {
+		log.Error("failed to lock user changes", slog.String("error", err.Error()))
+		return err
+	}
+
+	query := `INSERT INTO user_changes (change_type, user_id, name, email, role, is_active, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING position;`
+	var position int64
+	err := c.tx.QueryRow(ctx, query,
+		change.Type().String(),
+		change.UserID().String(),
+		change.Name(),
+		change.Email(),
+		change.Role(),
+		change.IsActive(),
+		change.OccurredAt(),
+	).Scan(&position)
+	if err != nil {
+		log.Error("failed to append user change", slog.String("error", err.Error()))
+		return err
+	}
+
+	if _, err = c.tx.Exec(ctx, `SELECT pg_notify($1, $2);`, ChangesChannel, strconv.FormatInt(position, 10)); err != nil {
+		log.Error("failed to notify user change", slog.String("error", err.Error()))
+		return err
+	}
+
+	change.SetPosition(position)
+	log.Debug("user change appended", slog.Int64("position", position))
+	return nil
+}
+
+func (c *ChangesStorage) ListSince(ctx context.Context, position int64, limit int) ([]*changes.Change, error) {
+	log := logger.LogWithContext(ctx, c.log)
+	query := `SELECT position, change_type, user_id, name, email, role, is_active, occurred_at
+		FROM user_changes WHERE position > $1 ORDER BY position LIMIT $2;`
+	rows, err := c.tx.Query(ctx, query, position, limit)
+	if err != nil {
+		log.Error("failed to get user changes", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*changes.Change, 0)
+	for rows.Next() {
+		var ch Change
+		err = rows.Scan(&ch.position, &ch.changeType, &ch.userID, &ch.name, &ch.email, &ch.role, &ch.isActive, &ch.occurredAt)
+		if err != nil {
+			log.Error("failed to scan user change", slog.String("error", err.Error()))
+			return nil, err
+		}
+		changeType, err := changes.NewType(ch.changeType)
+		if err != nil {
+			return nil, err
+		}
+		dChange, err := changes.NewChange(
+			ch.position,
+			changeType,
+			uuid.MustParse(ch.userID),
+			ch.name,
+			ch.email,
+			ch.role,
+			ch.isActive,
+			ch.occurredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, dChange)
+	}
+	return res, rows.Err()
+}
+
+func (c *ChangesStorage) LastPosition(ctx context.Context) (int64, error) {
+	log := logger.LogWithContext(ctx, c.log)
+	var position int64
+	err := c.tx.QueryRow(ctx, `SELECT COALESCE(MAX(position), 0) FROM user_changes;`).Scan(&position)
+	if err != nil {
+		log.Error("failed to get last user change position", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return position, nil
+}
+
+func (c *ChangesStorage) ScrubUser(ctx context.Context, userID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, c.log)
+	_, err := c.tx.Exec(ctx, `UPDATE user_changes SET name = '', email = '' WHERE user_id = $1;`, userID.String())
+	if err != nil {
+		log.Error("failed to scrub user changes", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
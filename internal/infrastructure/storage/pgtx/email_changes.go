@@ -0,0 +1,124 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/emailchanges"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+type EmailChangesStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type EmailChange struct {
+	id          string
+	tenantID    string
+	userID      string
+	oldEmail    string
+	newEmail    string
+	confirmHash string
+	cancelHash  string
+	expiresAt   time.Time
+	confirmedAt *time.Time
+	cancelledAt *time.Time
+	createdAt   time.Time
+}
+
+const emailChangeColumns = `id, tenant_id, user_id, old_email, new_email, confirm_hash, cancel_hash, expires_at, confirmed_at, cancelled_at, created_at`
+
+func NewEmailChangesStorage(tx pgx.Tx, log *slog.Logger) *EmailChangesStorage {
+	return &EmailChangesStorage{tx: tx, log: log}
+}
+
+func (s *EmailChangesStorage) Save(ctx context.Context, change *emailchanges.Change) error {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `INSERT INTO email_changes (` + emailChangeColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (id) DO UPDATE
+		SET confirmed_at = EXCLUDED.confirmed_at,
+		    cancelled_at = EXCLUDED.cancelled_at;`
+	_, err := s.tx.Exec(ctx, query,
+		change.ID().String(), change.TenantID().String(), change.UserID().String(),
+		change.OldEmail().String(), change.NewEmail().String(), change.ConfirmHash(), change.CancelHash(),
+		change.ExpiresAt(), change.ConfirmedAt(), change.CancelledAt(), change.CreatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save email change", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *EmailChangesStorage) GetByConfirmHash(ctx context.Context, confirmHash string) (*emailchanges.Change, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE confirm_hash = $1 FOR UPDATE;`
+	return s.get(ctx, query, confirmHash)
+}
+
+func (s *EmailChangesStorage) GetByCancelHash(ctx context.Context, cancelHash string) (*emailchanges.Change, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE cancel_hash = $1 FOR UPDATE;`
+	return s.get(ctx, query, cancelHash)
+}
+
+func (s *EmailChangesStorage) CancelPending(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `UPDATE email_changes SET cancelled_at = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL AND cancelled_at IS NULL AND expires_at > $2;`
+	if _, err := s.tx.Exec(ctx, query, userID.String(), at); err != nil {
+		log.Error("failed to cancel pending email changes", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *EmailChangesStorage) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log)
+	if _, err := s.tx.Exec(ctx, `DELETE FROM email_changes WHERE user_id = $1;`, userID.String()); err != nil {
+		log.Error("failed to delete user email changes", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *EmailChangesStorage) get(ctx context.Context, query string, args ...any) (*emailchanges.Change, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	var c EmailChange
+	err := s.tx.QueryRow(ctx, query, args...).Scan(
+		&c.id, &c.tenantID, &c.userID, &c.oldEmail, &c.newEmail, &c.confirmHash, &c.cancelHash,
+		&c.expiresAt, &c.confirmedAt, &c.cancelledAt, &c.createdAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, emailchanges.ErrNotFound
+	}
+	if err != nil {
+		log.Error("failed to get email change", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	oldEmail, err := users.NewEmail(c.oldEmail)
+	if err != nil {
+		return nil, err
+	}
+	newEmail, err := users.NewEmail(c.newEmail)
+	if err != nil {
+		return nil, err
+	}
+	return emailchanges.NewChange(
+		uuid.MustParse(c.id),
+		uuid.MustParse(c.tenantID),
+		uuid.MustParse(c.userID),
+		oldEmail,
+		newEmail,
+		c.confirmHash,
+		c.cancelHash,
+		c.expiresAt,
+		c.confirmedAt,
+		c.cancelledAt,
+		c.createdAt,
+	), nil
+}
@@ -0,0 +1,148 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/exports"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+type ExportsStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type ExportJob struct {
+	id          string
+	tenantID    string
+	userID      string
+	requestedBy string
+	status      string
+	archive     []byte
+	failure     string
+	createdAt   time.Time
+	completedAt *time.Time
+	expiresAt   *time.Time
+}
+
+const exportJobColumns = `id, tenant_id, user_id, requested_by, status, archive, failure, created_at, completed_at, expires_at`
+
+func NewExportsStorage(tx pgx.Tx, log *slog.Logger) *ExportsStorage {
+	return &ExportsStorage{tx: tx, log: log}
+}
+
+func (s *ExportsStorage) Save(ctx context.Context, job *exports.Job) error {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `INSERT INTO data_exports (` + exportJobColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    archive = EXCLUDED.archive,
+		    failure = EXCLUDED.failure,
+		    completed_at = EXCLUDED.completed_at,
+		    expires_at = EXCLUDED.expires_at;`
+	_, err := s.tx.Exec(ctx, query,
+		job.ID().String(), job.TenantID().String(), job.UserID().String(), job.RequestedBy().String(),
+		job.Status().String(), job.Archive(), job.Failure(), job.CreatedAt(), job.CompletedAt(), job.ExpiresAt(),
+	)
+	if err != nil {
+		log.Error("failed to save export job", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *ExportsStorage) Get(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) (*exports.Job, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `SELECT ` + exportJobColumns + ` FROM data_exports WHERE id = $1 AND tenant_id = $2;`
+	j, err := scanExportJob(s.tx.QueryRow(ctx, query, id.String(), tenantID.String()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, exports.ErrNotFound
+	}
+	if err != nil {
+		log.Error("failed to get export job", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return exportJobToDomain(j)
+}
+
+func (s *ExportsStorage) ListPending(ctx context.Context, limit int) ([]*exports.Job, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `SELECT ` + exportJobColumns + ` FROM data_exports
+		WHERE status = $1
+		ORDER BY created_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED;`
+	rows, err := s.tx.Query(ctx, query, exports.StatusPending.String(), limit)
+	if err != nil {
+		log.Error("failed to get export jobs", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*exports.Job, 0)
+	for rows.Next() {
+		j, err := scanExportJob(rows)
+		if err != nil {
+			log.Error("failed to scan export job", slog.String("error", err.Error()))
+			return nil, err
+		}
+		job, err := exportJobToDomain(j)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, job)
+	}
+	return res, rows.Err()
+}
+
+func (s *ExportsStorage) DeleteExpired(ctx context.Context, at time.Time) (int64, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	tag, err := s.tx.Exec(ctx, `DELETE FROM data_exports WHERE expires_at <= $1;`, at)
+	if err != nil {
+		log.Error("failed to delete expired export jobs", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (s *ExportsStorage) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log)
+	_, err := s.tx.Exec(ctx, `DELETE FROM data_exports WHERE user_id = $1;`, userID.String())
+	if err != nil {
+		log.Error("failed to delete user export jobs", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func scanExportJob(row pgx.Row) (ExportJob, error) {
+	var j ExportJob
+	err := row.Scan(
+		&j.id, &j.tenantID, &j.userID, &j.requestedBy, &j.status, &j.archive, &j.failure,
+		&j.createdAt, &j.completedAt, &j.expiresAt,
+	)
+	return j, err
+}
+
+func exportJobToDomain(j ExportJob) (*exports.Job, error) {
+	status, err := exports.NewStatus(j.status)
+	if err != nil {
+		return nil, err
+	}
+	return exports.NewJob(
+		uuid.MustParse(j.id),
+		uuid.MustParse(j.tenantID),
+		uuid.MustParse(j.userID),
+		uuid.MustParse(j.requestedBy),
+		status,
+		j.archive,
+		j.failure,
+		j.createdAt,
+		j.completedAt,
+		j.expiresAt,
+	)
+}
@@ -0,0 +1,248 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/groups"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+// groupsLockKey первый ключ advisory lock вложенности групп, второй - hash организации
+const groupsLockKey = 7270003
+
+type GroupsStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type Group struct {
+	id          string
+	tenantID    string
+	name        string
+	description string
+	createdAt   time.Time
+	updatedAt   time.Time
+}
+
+const groupColumns = `id, tenant_id, name, description, created_at, updated_at`
+
+func NewGroupsStorage(tx pgx.Tx, log *slog.Logger) *GroupsStorage {
+	return &GroupsStorage{tx: tx, log: log}
+}
+
+func (s *GroupsStorage) Save(ctx context.Context, group *groups.Group) error {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `INSERT INTO groups (` + groupColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (id) DO UPDATE
+		SET name = EXCLUDED.name,
+		    description = EXCLUDED.description,
+		    updated_at = EXCLUDED.updated_at;`
+	_, err := s.tx.Exec(ctx, query,
+		group.ID().String(), group.TenantID().String(), group.Name(), group.Description(), group.CreatedAt(), group.UpdatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save group", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *GroupsStorage) Get(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) (*groups.Group, error) {
+	query := `SELECT ` + groupColumns + ` FROM groups WHERE id = $1 AND tenant_id = $2;`
+	return s.get(ctx, query, id.String(), tenantID.String())
+}
+
+func (s *GroupsStorage) GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*groups.Group, error) {
+	query := `SELECT ` + groupColumns + ` FROM groups WHERE name = $1 AND tenant_id = $2;`
+	return s.get(ctx, query, name, tenantID.String())
+}
+
+func (s *GroupsStorage) List(ctx context.Context, tenantID uuid.UUID, limit int, offset int) ([]*groups.Group, error) {
+	query := `SELECT ` + groupColumns + ` FROM groups WHERE tenant_id = $1 ORDER BY name, id LIMIT $2 OFFSET $3;`
+	return s.list(ctx, query, tenantID.String(), limit, offset)
+}
+
+func (s *GroupsStorage) ListByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*groups.Group, error) {
+	list := make([]string, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, id.String())
+	}
+	query := `SELECT ` + groupColumns + ` FROM groups WHERE tenant_id = $1 AND id = ANY($2) ORDER BY name, id;`
+	return s.list(ctx, query, tenantID.String(), list)
+}
+
+func (s *GroupsStorage) Delete(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log)
+	tag, err := s.tx.Exec(ctx, `DELETE FROM groups WHERE id = $1 AND tenant_id = $2;`, id.String(), tenantID.String())
+	if err != nil {
+		log.Error("failed to delete group", slog.String("error", err.Error()))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return groups.ErrNotFound
+	}
+	// членство самой группы в других группах внешним ключом не покрыто
+	_, err = s.tx.Exec(ctx, `DELETE FROM group_members WHERE member_type = $1 AND member_id = $2;`,
+		groups.MemberGroup.String(), id.String())
+	if err != nil {
+		log.Error("failed to delete group memberships", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *GroupsStorage) LockMembership(ctx context.Context, tenantID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log)
+	if _, err := s.tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2));`, groupsLockKey, tenantID.String()); err != nil {
+		log.Error("failed to lock group membership", slog.String("tenant_id", tenantID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *GroupsStorage) AddMember(ctx context.Context, groupID uuid.UUID, member groups.Member) error {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `INSERT INTO group_members (group_id, tenant_id, member_type, member_id, created_at)
+		SELECT id, tenant_id, $2, $3, $4 FROM groups WHERE id = $1
+		ON CONFLICT DO NOTHING;`
+	_, err := s.tx.Exec(ctx, query, groupID.String(), member.Type.String(), member.ID.String(), time.Now().UTC())
+	if err != nil {
+		log.Error("failed to add group member", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *GroupsStorage) RemoveMember(ctx context.Context, groupID uuid.UUID, member groups.Member) error {
+	log := logger.LogWithContext(ctx, s.log)
+	tag, err := s.tx.Exec(ctx, `DELETE FROM group_members WHERE group_id = $1 AND member_type = $2 AND member_id = $3;`,
+		groupID.String(), member.Type.String(), member.ID.String())
+	if err != nil {
+		log.Error("failed to remove group member", slog.String("error", err.Error()))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return groups.ErrMemberNotFound
+	}
+	return nil
+}
+
+func (s *GroupsStorage) ListMembers(ctx context.Context, groupID uuid.UUID, limit int, offset int) ([]groups.Member, error) {
+	query := `SELECT member_type, member_id FROM group_members
+		WHERE group_id = $1 ORDER BY member_type, member_id LIMIT $2 OFFSET $3;`
+	return s.members(ctx, query, groupID.String(), limit, offset)
+}
+
+func (s *GroupsStorage) ListParents(ctx context.Context, tenantID uuid.UUID, member groups.Member) ([]uuid.UUID, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `SELECT group_id FROM group_members WHERE tenant_id = $1 AND member_type = $2 AND member_id = $3;`
+	rows, err := s.tx.Query(ctx, query, tenantID.String(), member.Type.String(), member.ID.String())
+	if err != nil {
+		log.Error("failed to get parent groups", slog.String("error", err.Error()))
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		log.Error("failed to scan parent group", slog.String("error", err.Error()))
+		return nil, err
+	}
+	res := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, parsed)
+	}
+	return res, nil
+}
+
+func (s *GroupsStorage) members(ctx context.Context, query string, args ...any) ([]groups.Member, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	rows, err := s.tx.Query(ctx, query, args...)
+	if err != nil {
+		log.Error("failed to get group members", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]groups.Member, 0)
+	for rows.Next() {
+		var memberType, memberID string
+		if err = rows.Scan(&memberType, &memberID); err != nil {
+			log.Error("failed to scan group member", slog.String("error", err.Error()))
+			return nil, err
+		}
+		t, err := groups.NewMemberType(memberType)
+		if err != nil {
+			return nil, err
+		}
+		id, err := uuid.Parse(memberID)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, groups.Member{Type: t, ID: id})
+	}
+	return res, rows.Err()
+}
+
+func (s *GroupsStorage) list(ctx context.Context, query string, args ...any) ([]*groups.Group, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	rows, err := s.tx.Query(ctx, query, args...)
+	if err != nil {
+		log.Error("failed to get groups", slog.String("error", err.Error()))
+		return nil, err
+	}
+	list, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Group, error) {
+		return scanGroup(row)
+	})
+	if err != nil {
+		log.Error("failed to scan group", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	res := make([]*groups.Group, 0, len(list))
+	for _, g := range list {
+		group, err := groupToDomain(g)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, group)
+	}
+	return res, nil
+}
+
+func (s *GroupsStorage) get(ctx context.Context, query string, args ...any) (*groups.Group, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	g, err := scanGroup(s.tx.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, groups.ErrNotFound
+	}
+	if err != nil {
+		log.Error("failed to get group", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return groupToDomain(g)
+}
+
+func scanGroup(row pgx.Row) (Group, error) {
+	var g Group
+	err := row.Scan(&g.id, &g.tenantID, &g.name, &g.description, &g.createdAt, &g.updatedAt)
+	return g, err
+}
+
+func groupToDomain(g Group) (*groups.Group, error) {
+	id, err := uuid.Parse(g.id)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := uuid.Parse(g.tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return groups.NewGroup(id, tenantID, g.name, g.description, g.createdAt, g.updatedAt), nil
+}
@@ -0,0 +1,210 @@
+package pgtx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+type IdentitiesStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type Identity struct {
+	id        string
+	userID    string
+	provider  string
+	subject   string
+	email     string
+	createdAt time.Time
+}
+
+// FederatedLoginRequest исходный запрос /authorize, хранится в jsonb
+type FederatedLoginRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Nonce               string `json:"nonce"`
+}
+
+const identityColumns = `id, user_id, provider, subject, email, created_at`
+
+func NewIdentitiesStorage(tx pgx.Tx, log *slog.Logger) *IdentitiesStorage {
+	return &IdentitiesStorage{tx: tx, log: log}
+}
+
+func (i *IdentitiesStorage) Save(ctx context.Context, ident *identity.Identity) error {
+	log := logger.LogWithContext(ctx, i.log)
+	query := `INSERT INTO user_identities (` + identityColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (id) DO UPDATE
+		SET email = EXCLUDED.email;`
+	_, err := i.tx.Exec(ctx, query,
+		ident.ID().String(), ident.UserID().String(), ident.Provider(), ident.Subject(), ident.Email(), ident.CreatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save identity", slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("identity saved", slog.String("provider", ident.Provider()))
+	return nil
+}
+
+func (i *IdentitiesStorage) GetBySubject(ctx context.Context, provider string, subject string) (*identity.Identity, error) {
+	log := logger.LogWithContext(ctx, i.log)
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE provider = $1 AND subject = $2;`
+	ident, err := scanIdentity(i.tx.QueryRow(ctx, query, provider, subject))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, identity.ErrIdentityNotFound
+	}
+	if err != nil {
+		log.Error("failed to get identity", slog.String("provider", provider), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return identityToDomain(ident), nil
+}
+
+func (i *IdentitiesStorage) ListByUser(ctx context.Context, userID uuid.UUID) ([]*identity.Identity, error) {
+	log := logger.LogWithContext(ctx, i.log)
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE user_id = $1 ORDER BY created_at;`
+	rows, err := i.tx.Query(ctx, query, userID.String())
+	if err != nil {
+		log.Error("failed to get identities", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*identity.Identity, 0)
+	for rows.Next() {
+		ident, err := scanIdentity(rows)
+		if err != nil {
+			log.Error("failed to scan identity", slog.String("error", err.Error()))
+			return nil, err
+		}
+		res = append(res, identityToDomain(ident))
+	}
+	return res, rows.Err()
+}
+
+func (i *IdentitiesStorage) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, i.log)
+	tag, err := i.tx.Exec(ctx, `DELETE FROM user_identities WHERE id = $1 AND user_id = $2;`, id.String(), userID.String())
+	if err != nil {
+		log.Error("failed to delete identity", slog.String("id", id.String()), slog.String("error", err.Error()))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return identity.ErrIdentityNotFound
+	}
+	log.Info("identity deleted", slog.String("id", id.String()))
+	return nil
+}
+
+func (i *IdentitiesStorage) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, i.log)
+	tag, err := i.tx.Exec(ctx, `DELETE FROM user_identities WHERE user_id = $1;`, userID.String())
+	if err != nil {
+		log.Error("failed to delete user identities", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("user identities deleted", slog.String("user_id", userID.String()), slog.Int64("count", tag.RowsAffected()))
+	return nil
+}
+
+func (i *IdentitiesStorage) SaveLogin(ctx context.Context, login *identity.Login) error {
+	log := logger.LogWithContext(ctx, i.log)
+	req := login.Request()
+	request, err := json.Marshal(FederatedLoginRequest{
+		ResponseType:        req.ResponseType,
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+	})
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO federated_logins (state_hash, provider, nonce, code_verifier, request, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`
+	_, err = i.tx.Exec(ctx, query,
+		login.StateHash(), login.Provider(), login.Nonce(), login.CodeVerifier(), request, login.ExpiresAt(), login.CreatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save federated login", slog.String("error", err.Error()))
+		return err
+	}
+	// просроченные входы никто не забирает, чистим их при создании новых
+	if _, err = i.tx.Exec(ctx, `DELETE FROM federated_logins WHERE expires_at < $1;`, login.CreatedAt()); err != nil {
+		log.Warn("failed to delete expired federated logins", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (i *IdentitiesStorage) TakeLogin(ctx context.Context, stateHash string) (*identity.Login, error) {
+	log := logger.LogWithContext(ctx, i.log)
+	query := `DELETE FROM federated_logins WHERE state_hash = $1
+		RETURNING state_hash, provider, nonce, code_verifier, request, expires_at, created_at;`
+
+	var (
+		hash, provider, nonce, verifier string
+		request                         []byte
+		expiresAt, createdAt            time.Time
+	)
+	err := i.tx.QueryRow(ctx, query, stateHash).Scan(&hash, &provider, &nonce, &verifier, &request, &expiresAt, &createdAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, identity.ErrLoginNotFound
+	}
+	if err != nil {
+		log.Error("failed to take federated login", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var req FederatedLoginRequest
+	if err = json.Unmarshal(request, &req); err != nil {
+		log.Error("failed to decode federated login request", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return identity.NewLogin(hash, provider, nonce, verifier, oauth.AuthorizationRequest{
+		ResponseType:        req.ResponseType,
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+	}, expiresAt, createdAt), nil
+}
+
+func scanIdentity(row pgx.Row) (Identity, error) {
+	var i Identity
+	err := row.Scan(&i.id, &i.userID, &i.provider, &i.subject, &i.email, &i.createdAt)
+	return i, err
+}
+
+func identityToDomain(i Identity) *identity.Identity {
+	return identity.NewIdentity(
+		uuid.MustParse(i.id),
+		uuid.MustParse(i.userID),
+		i.provider,
+		i.subject,
+		i.email,
+		i.createdAt,
+	)
+}
@@ -0,0 +1,161 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/invitations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+type InvitationsStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type Invitation struct {
+	id         string
+	tenantID   string
+	email      string
+	role       string
+	codeHash   string
+	invitedBy  string
+	expiresAt  time.Time
+	acceptedAt *time.Time
+	acceptedBy *string
+	revokedAt  *time.Time
+	createdAt  time.Time
+}
+
+const invitationColumns = `id, tenant_id, email, role, code_hash, invited_by, expires_at, accepted_at, accepted_by, revoked_at, created_at`
+
+func NewInvitationsStorage(tx pgx.Tx, log *slog.Logger) *InvitationsStorage {
+	return &InvitationsStorage{tx: tx, log: log}
+}
+
+func (s *InvitationsStorage) Save(ctx context.Context, invitation *invitations.Invitation) error {
+	log := logger.LogWithContext(ctx, s.log)
+	var acceptedBy *string
+	if invitation.AcceptedBy() != uuid.Nil {
+		id := invitation.AcceptedBy().String()
+		acceptedBy = &id
+	}
+
+	query := `INSERT INTO invitations (` + invitationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (id) DO UPDATE
+		SET accepted_at = EXCLUDED.accepted_at,
+		    accepted_by = EXCLUDED.accepted_by,
+		    revoked_at = EXCLUDED.revoked_at;`
+	_, err := s.tx.Exec(ctx, query,
+		invitation.ID().String(), invitation.TenantID().String(), invitation.Email().String(), invitation.Role().String(),
+		invitation.CodeHash(), invitation.InvitedBy().String(), invitation.ExpiresAt(),
+		invitation.AcceptedAt(), acceptedBy, invitation.RevokedAt(), invitation.CreatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save invitation", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *InvitationsStorage) Get(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) (*invitations.Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM invitations WHERE id = $1 AND tenant_id = $2;`
+	return s.get(ctx, query, id.String(), tenantID.String())
+}
+
+func (s *InvitationsStorage) GetByCodeHash(ctx context.Context, codeHash string) (*invitations.Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM invitations WHERE code_hash = $1;`
+	return s.get(ctx, query, codeHash)
+}
+
+func (s *InvitationsStorage) ListPending(ctx context.Context, tenantID uuid.UUID, at time.Time, limit int, offset int) ([]*invitations.Invitation, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `SELECT ` + invitationColumns + ` FROM invitations
+		WHERE tenant_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY created_at, id LIMIT $3 OFFSET $4;`
+	rows, err := s.tx.Query(ctx, query, tenantID.String(), at, limit, offset)
+	if err != nil {
+		log.Error("failed to get invitations", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*invitations.Invitation, 0)
+	for rows.Next() {
+		i, err := scanInvitation(rows)
+		if err != nil {
+			log.Error("failed to scan invitation", slog.String("error", err.Error()))
+			return nil, err
+		}
+		invitation, err := invitationToDomain(i)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, invitation)
+	}
+	return res, rows.Err()
+}
+
+func (s *InvitationsStorage) ScrubAccepted(ctx context.Context, userID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log)
+	_, err := s.tx.Exec(ctx, `UPDATE invitations SET email = '' WHERE accepted_by = $1;`, userID.String())
+	if err != nil {
+		log.Error("failed to scrub accepted invitations", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *InvitationsStorage) get(ctx context.Context, query string, args ...any) (*invitations.Invitation, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	i, err := scanInvitation(s.tx.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, invitations.ErrNotFound
+	}
+	if err != nil {
+		log.Error("failed to get invitation", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return invitationToDomain(i)
+}
+
+func scanInvitation(row pgx.Row) (Invitation, error) {
+	var i Invitation
+	err := row.Scan(
+		&i.id, &i.tenantID, &i.email, &i.role, &i.codeHash, &i.invitedBy,
+		&i.expiresAt, &i.acceptedAt, &i.acceptedBy, &i.revokedAt, &i.createdAt,
+	)
+	return i, err
+}
+
+func invitationToDomain(i Invitation) (*invitations.Invitation, error) {
+	email, err := users.NewEmail(i.email)
+	if err != nil {
+		return nil, err
+	}
+	role, err := users.NewRole(i.role)
+	if err != nil {
+		return nil, err
+	}
+	acceptedBy := uuid.Nil
+	if i.acceptedBy != nil {
+		acceptedBy = uuid.MustParse(*i.acceptedBy)
+	}
+	return invitations.NewInvitation(
+		uuid.MustParse(i.id),
+		uuid.MustParse(i.tenantID),
+		email,
+		role,
+		i.codeHash,
+		uuid.MustParse(i.invitedBy),
+		i.expiresAt,
+		i.acceptedAt,
+		acceptedBy,
+		i.revokedAt,
+		i.createdAt,
+	), nil
+}
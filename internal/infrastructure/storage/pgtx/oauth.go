@@ -0,0 +1,441 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+type OAuthStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type OAuthClient struct {
+	id           string
+	clientID     string
+	secretHash   string
+	name         string
+	redirectURIs []string
+	grantTypes   []string
+	scope        []string
+	confidential bool
+	createdAt    time.Time
+}
+
+type OAuthCode struct {
+	codeHash            string
+	clientID            string
+	userID              string
+	redirectURI         string
+	scope               []string
+	codeChallenge       string
+	codeChallengeMethod string
+	nonce               string
+	authTime            *time.Time
+	acr                 string
+	familyID            string
+	expiresAt           time.Time
+	usedAt              *time.Time
+	createdAt           time.Time
+}
+
+type OAuthRefreshToken struct {
+	id        string
+	tokenHash string
+	familyID  string
+	clientID  string
+	userID    string
+	scope     []string
+	authTime  *time.Time
+	acr       string
+	expiresAt time.Time
+	revokedAt *time.Time
+	createdAt time.Time
+}
+
+const (
+	oauthClientColumns       = `id, client_id, secret_hash, name, redirect_uris, grant_types, scope, confidential, created_at`
+	oauthCodeColumns         = `code_hash, client_id, user_id, redirect_uri, scope, code_challenge, code_challenge_method, nonce, auth_time, acr, family_id, expires_at, used_at, created_at`
+	oauthRefreshTokenColumns = `id, token_hash, family_id, client_id, user_id, scope, auth_time, acr, expires_at, revoked_at, created_at`
+)
+
+func NewOAuthStorage(tx pgx.Tx, log *slog.Logger) *OAuthStorage {
+	return &OAuthStorage{tx: tx, log: log}
+}
+
+func (o *OAuthStorage) SaveClient(ctx context.Context, client *oauth.Client) error {
+	log := logger.LogWithContext(ctx, o.log)
+	c := oauthClientToStorage(client)
+
+	query := `INSERT INTO oauth_clients (` + oauthClientColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (id) DO UPDATE
+		SET secret_hash = EXCLUDED.secret_hash,
+		    name = EXCLUDED.name,
+		    redirect_uris = EXCLUDED.redirect_uris,
+		    grant_types = EXCLUDED.grant_types,
+		    scope = EXCLUDED.scope;`
+	_, err := o.tx.Exec(ctx, query,
+		c.id, c.clientID, c.secretHash, c.name, c.redirectURIs, c.grantTypes, c.scope, c.confidential, c.createdAt,
+	)
+	if err != nil {
+		log.Error("failed to save oauth client", slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("oauth client saved", slog.String("client_id", c.clientID))
+	return nil
+}
+
+func (o *OAuthStorage) GetClient(ctx context.Context, clientID string) (*oauth.Client, error) {
+	log := logger.LogWithContext(ctx, o.log)
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE client_id = $1;`
+	c, err := scanOAuthClient(o.tx.QueryRow(ctx, query, clientID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, oauth.ErrClientNotFound
+	}
+	if err != nil {
+		log.Error("failed to get oauth client", slog.String("client_id", clientID), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return oauthClientToDomain(c)
+}
+
+func (o *OAuthStorage) ListClients(ctx context.Context, limit int, offset int) ([]*oauth.Client, error) {
+	log := logger.LogWithContext(ctx, o.log)
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients ORDER BY created_at LIMIT $1 OFFSET $2;`
+	rows, err := o.tx.Query(ctx, query, limit, offset)
+	if err != nil {
+		log.Error("failed to get oauth clients", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*oauth.Client, 0)
+	for rows.Next() {
+		c, err := scanOAuthClient(rows)
+		if err != nil {
+			log.Error("failed to scan oauth client", slog.String("error", err.Error()))
+			return nil, err
+		}
+		client, err := oauthClientToDomain(c)
+		if err != nil {
+			log.Error("failed to convert oauth client to domain", slog.String("error", err.Error()))
+			continue
+		}
+		res = append(res, client)
+	}
+	return res, rows.Err()
+}
+
+func (o *OAuthStorage) DeleteClient(ctx context.Context, clientID string) error {
+	log := logger.LogWithContext(ctx, o.log)
+	tag, err := o.tx.Exec(ctx, `DELETE FROM oauth_clients WHERE client_id = $1;`, clientID)
+	if err != nil {
+		log.Error("failed to delete oauth client", slog.String("client_id", clientID), slog.String("error", err.Error()))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return oauth.ErrClientNotFound
+	}
+	log.Info("oauth client deleted", slog.String("client_id", clientID))
+	return nil
+}
+
+func (o *OAuthStorage) SaveCode(ctx context.Context, code *oauth.AuthorizationCode) error {
+	log := logger.LogWithContext(ctx, o.log)
+	c := oauthCodeToStorage(code)
+
+	query := `INSERT INTO oauth_codes (` + oauthCodeColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) ON CONFLICT (code_hash) DO UPDATE
+		SET used_at = EXCLUDED.used_at;`
+	_, err := o.tx.Exec(ctx, query,
+		c.codeHash, c.clientID, c.userID, c.redirectURI, c.scope, c.codeChallenge, c.codeChallengeMethod,
+		c.nonce, c.authTime, c.acr, c.familyID, c.expiresAt, c.usedAt, c.createdAt,
+	)
+	if err != nil {
+		log.Error("failed to save authorization code", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (o *OAuthStorage) GetCode(ctx context.Context, codeHash string) (*oauth.AuthorizationCode, error) {
+	log := logger.LogWithContext(ctx, o.log)
+	query := `SELECT ` + oauthCodeColumns + ` FROM oauth_codes WHERE code_hash = $1 FOR UPDATE;`
+
+	var c OAuthCode
+	err := o.tx.QueryRow(ctx, query, codeHash).Scan(
+		&c.codeHash, &c.clientID, &c.userID, &c.redirectURI, &c.scope, &c.codeChallenge, &c.codeChallengeMethod,
+		&c.nonce, &c.authTime, &c.acr, &c.familyID, &c.expiresAt, &c.usedAt, &c.createdAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, oauth.ErrCodeNotFound
+	}
+	if err != nil {
+		log.Error("failed to get authorization code", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return oauth.NewAuthorizationCode(
+		c.codeHash,
+		c.clientID,
+		uuid.MustParse(c.userID),
+		c.redirectURI,
+		c.scope,
+		c.codeChallenge,
+		c.codeChallengeMethod,
+		c.nonce,
+		authToDomain(c.authTime, c.acr),
+		uuid.MustParse(c.familyID),
+		c.expiresAt,
+		c.usedAt,
+		c.createdAt,
+	), nil
+}
+
+func (o *OAuthStorage) SaveRefreshToken(ctx context.Context, token *oauth.RefreshToken) error {
+	log := logger.LogWithContext(ctx, o.log)
+	t := oauthRefreshTokenToStorage(token)
+
+	query := `INSERT INTO oauth_refresh_tokens (` + oauthRefreshTokenColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (id) DO UPDATE
+		SET revoked_at = EXCLUDED.revoked_at;`
+	_, err := o.tx.Exec(ctx, query,
+		t.id, t.tokenHash, t.familyID, t.clientID, t.userID, t.scope, t.authTime, t.acr, t.expiresAt, t.revokedAt, t.createdAt,
+	)
+	if err != nil {
+		log.Error("failed to save refresh token", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (o *OAuthStorage) GetRefreshToken(ctx context.Context, tokenHash string) (*oauth.RefreshToken, error) {
+	log := logger.LogWithContext(ctx, o.log)
+	query := `SELECT ` + oauthRefreshTokenColumns + ` FROM oauth_refresh_tokens WHERE token_hash = $1 FOR UPDATE;`
+
+	t, err := scanOAuthRefreshToken(o.tx.QueryRow(ctx, query, tokenHash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, oauth.ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		log.Error("failed to get refresh token", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return oauthRefreshTokenToDomain(t), nil
+}
+
+func (o *OAuthStorage) ListUserRefreshTokens(ctx context.Context, userID uuid.UUID) ([]*oauth.RefreshToken, error) {
+	log := logger.LogWithContext(ctx, o.log)
+	query := `SELECT ` + oauthRefreshTokenColumns + ` FROM oauth_refresh_tokens WHERE user_id = $1 ORDER BY created_at, id;`
+	rows, err := o.tx.Query(ctx, query, userID.String())
+	if err != nil {
+		log.Error("failed to get user refresh tokens", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*oauth.RefreshToken, 0)
+	for rows.Next() {
+		t, err := scanOAuthRefreshToken(rows)
+		if err != nil {
+			log.Error("failed to scan refresh token", slog.String("error", err.Error()))
+			return nil, err
+		}
+		res = append(res, oauthRefreshTokenToDomain(t))
+	}
+	return res, rows.Err()
+}
+
+func (o *OAuthStorage) RevokeRefreshTokens(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	log := logger.LogWithContext(ctx, o.log)
+	query := `UPDATE oauth_refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL;`
+	tag, err := o.tx.Exec(ctx, query, familyID.String(), at)
+	if err != nil {
+		log.Error("failed to revoke refresh tokens", slog.String("family_id", familyID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("refresh tokens revoked", slog.String("family_id", familyID.String()), slog.Int64("count", tag.RowsAffected()))
+	return nil
+}
+
+func (o *OAuthStorage) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	log := logger.LogWithContext(ctx, o.log)
+	query := `UPDATE oauth_refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL;`
+	tag, err := o.tx.Exec(ctx, query, userID.String(), at)
+	if err != nil {
+		log.Error("failed to revoke user refresh tokens", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("user refresh tokens revoked", slog.String("user_id", userID.String()), slog.Int64("count", tag.RowsAffected()))
+	return nil
+}
+
+func (o *OAuthStorage) DeleteUserTokens(ctx context.Context, userID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, o.log)
+	if _, err := o.tx.Exec(ctx, `DELETE FROM oauth_codes WHERE user_id = $1;`, userID.String()); err != nil {
+		log.Error("failed to delete user codes", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	tag, err := o.tx.Exec(ctx, `DELETE FROM oauth_refresh_tokens WHERE user_id = $1;`, userID.String())
+	if err != nil {
+		log.Error("failed to delete user refresh tokens", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("user tokens deleted", slog.String("user_id", userID.String()), slog.Int64("count", tag.RowsAffected()))
+	return nil
+}
+
+func (o *OAuthStorage) IsFamilyRevoked(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	log := logger.LogWithContext(ctx, o.log)
+	// при ротации старый токен отзывается, а новый остается, поэтому семейство отозвано,
+	// только если не осталось ни одного неотозванного токена
+	query := `SELECT count(*) > 0 AND count(*) FILTER (WHERE revoked_at IS NULL) = 0
+		FROM oauth_refresh_tokens WHERE family_id = $1;`
+	var revoked bool
+	if err := o.tx.QueryRow(ctx, query, familyID.String()).Scan(&revoked); err != nil {
+		log.Error("failed to check refresh token family", slog.String("family_id", familyID.String()), slog.String("error", err.Error()))
+		return false, err
+	}
+	return revoked, nil
+}
+
+func scanOAuthRefreshToken(row pgx.Row) (OAuthRefreshToken, error) {
+	var t OAuthRefreshToken
+	err := row.Scan(
+		&t.id, &t.tokenHash, &t.familyID, &t.clientID, &t.userID, &t.scope, &t.authTime, &t.acr, &t.expiresAt, &t.revokedAt, &t.createdAt,
+	)
+	return t, err
+}
+
+func oauthRefreshTokenToDomain(t OAuthRefreshToken) *oauth.RefreshToken {
+	return oauth.NewRefreshToken(
+		uuid.MustParse(t.id),
+		t.tokenHash,
+		uuid.MustParse(t.familyID),
+		t.clientID,
+		uuid.MustParse(t.userID),
+		t.scope,
+		authToDomain(t.authTime, t.acr),
+		t.expiresAt,
+		t.revokedAt,
+		t.createdAt,
+	)
+}
+
+func scanOAuthClient(row pgx.Row) (OAuthClient, error) {
+	var c OAuthClient
+	err := row.Scan(&c.id, &c.clientID, &c.secretHash, &c.name, &c.redirectURIs, &c.grantTypes, &c.scope, &c.confidential, &c.createdAt)
+	return c, err
+}
+
+func oauthClientToStorage(c *oauth.Client) OAuthClient {
+	grantTypes := make([]string, 0, len(c.GrantTypes()))
+	for _, g := range c.GrantTypes() {
+		grantTypes = append(grantTypes, g.String())
+	}
+	redirectURIs := c.RedirectURIs()
+	if redirectURIs == nil {
+		redirectURIs = []string{}
+	}
+	scope := c.Scope()
+	if scope == nil {
+		scope = oauth.Scope{}
+	}
+	return OAuthClient{
+		id:           c.ID().String(),
+		clientID:     c.ClientID(),
+		secretHash:   c.SecretHash(),
+		name:         c.Name(),
+		redirectURIs: redirectURIs,
+		grantTypes:   grantTypes,
+		scope:        scope,
+		confidential: c.IsConfidential(),
+		createdAt:    c.CreatedAt(),
+	}
+}
+
+func oauthClientToDomain(c OAuthClient) (*oauth.Client, error) {
+	grantTypes := make([]oauth.GrantType, 0, len(c.grantTypes))
+	for _, g := range c.grantTypes {
+		grant, err := oauth.NewGrantType(g)
+		if err != nil {
+			return nil, err
+		}
+		grantTypes = append(grantTypes, grant)
+	}
+	return oauth.NewClient(
+		uuid.MustParse(c.id),
+		c.clientID,
+		c.secretHash,
+		c.name,
+		c.redirectURIs,
+		grantTypes,
+		c.scope,
+		c.confidential,
+		c.createdAt,
+	)
+}
+
+func oauthCodeToStorage(c *oauth.AuthorizationCode) OAuthCode {
+	scope := c.Scope()
+	if scope == nil {
+		scope = oauth.Scope{}
+	}
+	return OAuthCode{
+		codeHash:            c.CodeHash(),
+		clientID:            c.ClientID(),
+		userID:              c.UserID().String(),
+		redirectURI:         c.RedirectURI(),
+		scope:               scope,
+		codeChallenge:       c.CodeChallenge(),
+		codeChallengeMethod: c.CodeChallengeMethod(),
+		nonce:               c.Nonce(),
+		authTime:            authTimeToStorage(c.Auth()),
+		acr:                 c.Auth().ACR,
+		familyID:            c.FamilyID().String(),
+		expiresAt:           c.ExpiresAt(),
+		usedAt:              c.UsedAt(),
+		createdAt:           c.CreatedAt(),
+	}
+}
+
+func oauthRefreshTokenToStorage(t *oauth.RefreshToken) OAuthRefreshToken {
+	scope := t.Scope()
+	if scope == nil {
+		scope = oauth.Scope{}
+	}
+	return OAuthRefreshToken{
+		id:        t.ID().String(),
+		tokenHash: t.TokenHash(),
+		familyID:  t.FamilyID().String(),
+		clientID:  t.ClientID(),
+		userID:    t.UserID().String(),
+		scope:     scope,
+		authTime:  authTimeToStorage(t.Auth()),
+		acr:       t.Auth().ACR,
+		expiresAt: t.ExpiresAt(),
+		revokedAt: t.RevokedAt(),
+		createdAt: t.CreatedAt(),
+	}
+}
+
+// authTimeToStorage у кодов и токенов, выданных до OIDC, времени входа нет
+func authTimeToStorage(auth oauth.Authentication) *time.Time {
+	if auth.Time.IsZero() {
+		return nil
+	}
+	return &auth.Time
+}
+
+func authToDomain(authTime *time.Time, acr string) oauth.Authentication {
+	auth := oauth.Authentication{ACR: acr}
+	if authTime != nil {
+		auth.Time = *authTime
+	}
+	return auth
+}
@@ -0,0 +1,126 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+type OrganizationsStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type Organization struct {
+	id        string
+	slug      string
+	name      string
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+const organizationColumns = `id, slug, name, created_at, updated_at`
+
+func NewOrganizationsStorage(tx pgx.Tx, log *slog.Logger) *OrganizationsStorage {
+	return &OrganizationsStorage{tx: tx, log: log}
+}
+
+func (s *OrganizationsStorage) Save(ctx context.Context, organization *organizations.Organization) error {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `INSERT INTO organizations (` + organizationColumns + `)
+		VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO UPDATE
+		SET slug = EXCLUDED.slug,
+		    name = EXCLUDED.name,
+		    updated_at = EXCLUDED.updated_at;`
+	_, err := s.tx.Exec(ctx, query,
+		organization.ID().String(), organization.Slug(), organization.Name(), organization.CreatedAt(), organization.UpdatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save organization", slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("organization saved", slog.String("slug", organization.Slug()))
+	return nil
+}
+
+func (s *OrganizationsStorage) Get(ctx context.Context, id uuid.UUID) (*organizations.Organization, error) {
+	query := `SELECT ` + organizationColumns + ` FROM organizations WHERE id = $1;`
+	return s.get(ctx, query, id.String())
+}
+
+func (s *OrganizationsStorage) GetBySlug(ctx context.Context, slug string) (*organizations.Organization, error) {
+	query := `SELECT ` + organizationColumns + ` FROM organizations WHERE slug = $1;`
+	return s.get(ctx, query, slug)
+}
+
+func (s *OrganizationsStorage) List(ctx context.Context, limit int, offset int) ([]*organizations.Organization, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `SELECT ` + organizationColumns + ` FROM organizations ORDER BY created_at, id LIMIT $1 OFFSET $2;`
+	rows, err := s.tx.Query(ctx, query, limit, offset)
+	if err != nil {
+		log.Error("failed to get organizations", slog.String("error", err.Error()))
+		return nil, err
+	}
+	list, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Organization, error) {
+		return scanOrganization(row)
+	})
+	if err != nil {
+		log.Error("failed to scan organization", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	res := make([]*organizations.Organization, 0, len(list))
+	for _, o := range list {
+		organization, err := organizationToDomain(o)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, organization)
+	}
+	return res, nil
+}
+
+func (s *OrganizationsStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log)
+	tag, err := s.tx.Exec(ctx, `DELETE FROM organizations WHERE id = $1;`, id.String())
+	if err != nil {
+		log.Error("failed to delete organization", slog.String("error", err.Error()))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return organizations.ErrOrganizationNotFound
+	}
+	return nil
+}
+
+func (s *OrganizationsStorage) get(ctx context.Context, query string, arg string) (*organizations.Organization, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	o, err := scanOrganization(s.tx.QueryRow(ctx, query, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, organizations.ErrOrganizationNotFound
+	}
+	if err != nil {
+		log.Error("failed to get organization", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return organizationToDomain(o)
+}
+
+func scanOrganization(row pgx.Row) (Organization, error) {
+	var o Organization
+	err := row.Scan(&o.id, &o.slug, &o.name, &o.createdAt, &o.updatedAt)
+	return o, err
+}
+
+func organizationToDomain(o Organization) (*organizations.Organization, error) {
+	id, err := uuid.Parse(o.id)
+	if err != nil {
+		return nil, err
+	}
+	return organizations.NewOrganization(id, o.slug, o.name, o.createdAt, o.updatedAt), nil
+}
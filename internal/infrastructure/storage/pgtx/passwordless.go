@@ -0,0 +1,102 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/passwordless"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+type PasswordlessStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type PasswordlessChallenge struct {
+	id          string
+	tenantID    string
+	userID      string
+	linkHash    string
+	codeHash    string
+	attempts    int
+	maxAttempts int
+	expiresAt   time.Time
+	consumedAt  *time.Time
+	createdAt   time.Time
+}
+
+const passwordlessColumns = `id, tenant_id, user_id, link_hash, code_hash, attempts, max_attempts, expires_at, consumed_at, created_at`
+
+func NewPasswordlessStorage(tx pgx.Tx, log *slog.Logger) *PasswordlessStorage {
+	return &PasswordlessStorage{tx: tx, log: log}
+}
+
+func (s *PasswordlessStorage) Save(ctx context.Context, challenge *passwordless.Challenge) error {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `INSERT INTO passwordless_challenges (` + passwordlessColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (id) DO UPDATE
+		SET attempts = EXCLUDED.attempts,
+		    consumed_at = EXCLUDED.consumed_at;`
+	_, err := s.tx.Exec(ctx, query,
+		challenge.ID().String(), challenge.TenantID().String(), challenge.UserID().String(),
+		challenge.LinkHash(), challenge.CodeHash(), challenge.Attempts(), challenge.MaxAttempts(),
+		challenge.ExpiresAt(), challenge.ConsumedAt(), challenge.CreatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save passwordless challenge", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *PasswordlessStorage) GetByLinkHash(ctx context.Context, linkHash string) (*passwordless.Challenge, error) {
+	query := `SELECT ` + passwordlessColumns + ` FROM passwordless_challenges WHERE link_hash = $1 FOR UPDATE;`
+	return s.get(ctx, query, linkHash)
+}
+
+func (s *PasswordlessStorage) GetLatest(ctx context.Context, userID uuid.UUID) (*passwordless.Challenge, error) {
+	query := `SELECT ` + passwordlessColumns + ` FROM passwordless_challenges
+		WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1 FOR UPDATE;`
+	return s.get(ctx, query, userID.String())
+}
+
+func (s *PasswordlessStorage) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, s.log)
+	if _, err := s.tx.Exec(ctx, `DELETE FROM passwordless_challenges WHERE user_id = $1;`, userID.String()); err != nil {
+		log.Error("failed to delete user passwordless challenges", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *PasswordlessStorage) get(ctx context.Context, query string, args ...any) (*passwordless.Challenge, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	var c PasswordlessChallenge
+	err := s.tx.QueryRow(ctx, query, args...).Scan(
+		&c.id, &c.tenantID, &c.userID, &c.linkHash, &c.codeHash, &c.attempts, &c.maxAttempts,
+		&c.expiresAt, &c.consumedAt, &c.createdAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, passwordless.ErrNotFound
+	}
+	if err != nil {
+		log.Error("failed to get passwordless challenge", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return passwordless.NewChallenge(
+		uuid.MustParse(c.id),
+		uuid.MustParse(c.tenantID),
+		uuid.MustParse(c.userID),
+		c.linkHash,
+		c.codeHash,
+		c.attempts,
+		c.maxAttempts,
+		c.expiresAt,
+		c.consumedAt,
+		c.createdAt,
+	), nil
+}
@@ -0,0 +1,120 @@
+package pgtx
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+)
+
+type RelationsStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+func NewRelationsStorage(tx pgx.Tx, log *slog.Logger) *RelationsStorage {
+	return &RelationsStorage{tx: tx, log: log}
+}
+
+func (s *RelationsStorage) Write(ctx context.Context, tenantID uuid.UUID, tuples []relations.Tuple) (relations.Revision, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	revision, err := s.nextRevision(ctx)
+	if err != nil {
+		log.Error("failed to write relationships", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	query := `INSERT INTO relation_tuples
+		(tenant_id, object_type, object_id, relation, subject_type, subject_id, subject_relation, created_revision)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT DO NOTHING;`
+	batch := &pgx.Batch{}
+	for _, t := range tuples {
+		batch.Queue(query, tenantID.String(), t.Object.Type, t.Object.ID, t.Relation,
+			t.Subject.Type, t.Subject.ID, t.Subject.Relation, int64(revision))
+	}
+	if err = s.tx.SendBatch(ctx, batch).Close(); err != nil {
+		log.Error("failed to write relationships", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return revision, nil
+}
+
+func (s *RelationsStorage) Delete(ctx context.Context, tenantID uuid.UUID, tuples []relations.Tuple) (relations.Revision, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	revision, err := s.nextRevision(ctx)
+	if err != nil {
+		log.Error("failed to delete relationships", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	query := `DELETE FROM relation_tuples
+		WHERE tenant_id = $1 AND object_type = $2 AND object_id = $3 AND relation = $4
+		  AND subject_type = $5 AND subject_id = $6 AND subject_relation = $7;`
+	batch := &pgx.Batch{}
+	for _, t := range tuples {
+		batch.Queue(query, tenantID.String(), t.Object.Type, t.Object.ID, t.Relation,
+			t.Subject.Type, t.Subject.ID, t.Subject.Relation)
+	}
+	if err = s.tx.SendBatch(ctx, batch).Close(); err != nil {
+		log.Error("failed to delete relationships", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return revision, nil
+}
+
+func (s *RelationsStorage) Read(ctx context.Context, tenantID uuid.UUID, object relations.Object, relation string) ([]relations.Tuple, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `SELECT subject_type, subject_id, subject_relation FROM relation_tuples
+		WHERE tenant_id = $1 AND object_type = $2 AND object_id = $3 AND relation = $4
+		ORDER BY subject_type, subject_id, subject_relation;`
+	rows, err := s.tx.Query(ctx, query, tenantID.String(), object.Type, object.ID, relation)
+	if err != nil {
+		log.Error("failed to read relationships", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]relations.Tuple, 0)
+	for rows.Next() {
+		t := relations.Tuple{Object: object, Relation: relation}
+		if err = rows.Scan(&t.Subject.Type, &t.Subject.ID, &t.Subject.Relation); err != nil {
+			log.Error("failed to scan relationship", slog.String("error", err.Error()))
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, rows.Err()
+}
+
+func (s *RelationsStorage) ListObjectIDs(ctx context.Context, tenantID uuid.UUID, objectType string, afterID string, limit int) ([]string, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `SELECT DISTINCT object_id FROM relation_tuples
+		WHERE tenant_id = $1 AND object_type = $2 AND object_id > $3
+		ORDER BY object_id LIMIT $4;`
+	rows, err := s.tx.Query(ctx, query, tenantID.String(), objectType, afterID, limit)
+	if err != nil {
+		log.Error("failed to list relation objects", slog.String("error", err.Error()))
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		log.Error("failed to scan relation object", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *RelationsStorage) Revision(ctx context.Context) (relations.Revision, error) {
+	var revision int64
+	err := s.tx.QueryRow(ctx, `SELECT revision FROM relation_revision;`).Scan(&revision)
+	return relations.Revision(revision), err
+}
+
+// nextRevision блокирует строку ревизии до конца транзакции, поэтому записи получают ревизии в порядке фиксации
+func (s *RelationsStorage) nextRevision(ctx context.Context) (relations.Revision, error) {
+	var revision int64
+	err := s.tx.QueryRow(ctx, `UPDATE relation_revision SET revision = revision + 1 RETURNING revision;`).Scan(&revision)
+	return relations.Revision(revision), err
+}
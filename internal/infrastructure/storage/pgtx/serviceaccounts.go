@@ -0,0 +1,163 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+type ServiceAccountsStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type ServiceAccount struct {
+	id         string
+	clientID   string
+	name       string
+	role       string
+	disabledAt *time.Time
+	createdAt  time.Time
+}
+
+const (
+	serviceAccountColumns       = `id, client_id, name, role, disabled_at, created_at`
+	serviceAccountSecretColumns = `id, secret_hash, expires_at, created_at`
+)
+
+func NewServiceAccountsStorage(tx pgx.Tx, log *slog.Logger) *ServiceAccountsStorage {
+	return &ServiceAccountsStorage{tx: tx, log: log}
+}
+
+func (s *ServiceAccountsStorage) Save(ctx context.Context, account *serviceaccounts.ServiceAccount) error {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `INSERT INTO service_accounts (` + serviceAccountColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (id) DO UPDATE
+		SET name = EXCLUDED.name,
+		    role = EXCLUDED.role,
+		    disabled_at = EXCLUDED.disabled_at;`
+	_, err := s.tx.Exec(ctx, query,
+		account.ID().String(), account.ClientID(), account.Name(), account.Role().String(), account.DisabledAt(), account.CreatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save service account", slog.String("error", err.Error()))
+		return err
+	}
+
+	// секреты не удаляются, у истекших только проставляется expires_at
+	secretQuery := `INSERT INTO service_account_secrets (account_id, ` + serviceAccountSecretColumns + `)
+		VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO UPDATE
+		SET expires_at = EXCLUDED.expires_at;`
+	for _, secret := range account.Secrets() {
+		_, err = s.tx.Exec(ctx, secretQuery,
+			account.ID().String(), secret.ID().String(), secret.SecretHash(), secret.ExpiresAt(), secret.CreatedAt(),
+		)
+		if err != nil {
+			log.Error("failed to save service account secret", slog.String("error", err.Error()))
+			return err
+		}
+	}
+	log.Info("service account saved", slog.String("client_id", account.ClientID()))
+	return nil
+}
+
+func (s *ServiceAccountsStorage) Get(ctx context.Context, id uuid.UUID) (*serviceaccounts.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE id = $1;`
+	return s.get(ctx, query, id.String())
+}
+
+func (s *ServiceAccountsStorage) GetByClientID(ctx context.Context, clientID string) (*serviceaccounts.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE client_id = $1;`
+	return s.get(ctx, query, clientID)
+}
+
+func (s *ServiceAccountsStorage) List(ctx context.Context, limit int, offset int) ([]*serviceaccounts.ServiceAccount, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts ORDER BY created_at LIMIT $1 OFFSET $2;`
+	rows, err := s.tx.Query(ctx, query, limit, offset)
+	if err != nil {
+		log.Error("failed to get service accounts", slog.String("error", err.Error()))
+		return nil, err
+	}
+	accounts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ServiceAccount, error) {
+		return scanServiceAccount(row)
+	})
+	if err != nil {
+		log.Error("failed to scan service account", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	res := make([]*serviceaccounts.ServiceAccount, 0, len(accounts))
+	for _, a := range accounts {
+		account, err := s.withSecrets(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, account)
+	}
+	return res, nil
+}
+
+func (s *ServiceAccountsStorage) get(ctx context.Context, query string, arg string) (*serviceaccounts.ServiceAccount, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	a, err := scanServiceAccount(s.tx.QueryRow(ctx, query, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, serviceaccounts.ErrNotFound
+	}
+	if err != nil {
+		log.Error("failed to get service account", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return s.withSecrets(ctx, a)
+}
+
+func (s *ServiceAccountsStorage) withSecrets(ctx context.Context, a ServiceAccount) (*serviceaccounts.ServiceAccount, error) {
+	log := logger.LogWithContext(ctx, s.log)
+	query := `SELECT ` + serviceAccountSecretColumns + ` FROM service_account_secrets
+		WHERE account_id = $1 ORDER BY created_at;`
+	rows, err := s.tx.Query(ctx, query, a.id)
+	if err != nil {
+		log.Error("failed to get service account secrets", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	secrets := make([]serviceaccounts.Secret, 0)
+	for rows.Next() {
+		var (
+			id, hash  string
+			expiresAt *time.Time
+			createdAt time.Time
+		)
+		if err = rows.Scan(&id, &hash, &expiresAt, &createdAt); err != nil {
+			log.Error("failed to scan service account secret", slog.String("error", err.Error()))
+			return nil, err
+		}
+		secrets = append(secrets, serviceaccounts.NewSecret(uuid.MustParse(id), hash, expiresAt, createdAt))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return serviceaccounts.NewServiceAccount(
+		uuid.MustParse(a.id),
+		a.clientID,
+		a.name,
+		users.Role(a.role),
+		secrets,
+		a.disabledAt,
+		a.createdAt,
+	), nil
+}
+
+func scanServiceAccount(row pgx.Row) (ServiceAccount, error) {
+	var a ServiceAccount
+	err := row.Scan(&a.id, &a.clientID, &a.name, &a.role, &a.disabledAt, &a.createdAt)
+	return a, err
+}
@@ -0,0 +1,120 @@
+package pgtx
+
+import (
+	"github.com/LeoUraltsev/auth-service/internal/domain/apikeys"
+	"github.com/LeoUraltsev/auth-service/internal/domain/audit"
+	"github.com/LeoUraltsev/auth-service/internal/domain/changes"
+	"github.com/LeoUraltsev/auth-service/internal/domain/emailchanges"
+	"github.com/LeoUraltsev/auth-service/internal/domain/exports"
+	"github.com/LeoUraltsev/auth-service/internal/domain/groups"
+	"github.com/LeoUraltsev/auth-service/internal/domain/identity"
+	"github.com/LeoUraltsev/auth-service/internal/domain/invitations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/passwordless"
+	"github.com/LeoUraltsev/auth-service/internal/domain/relations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/serviceaccounts"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+)
+
+// Store набор репозиториев поверх одной транзакции
+type Store struct {
+	users      *UsersStorage
+	webhooks   *WebhooksStorage
+	changes    *ChangesStorage
+	audit      *AuditStorage
+	oauth      *OAuthStorage
+	identities *IdentitiesStorage
+	accounts   *ServiceAccountsStorage
+	apiKeys    *APIKeysStorage
+	orgs       *OrganizationsStorage
+	invites    *InvitationsStorage
+	relations  *RelationsStorage
+	groups     *GroupsStorage
+	exports    *ExportsStorage
+	emails     *EmailChangesStorage
+	logins     *PasswordlessStorage
+}
+
+func NewStore(tx pgx.Tx, log *slog.Logger) *Store {
+	return &Store{
+		users:      NewUsersStorage(tx, log),
+		webhooks:   NewWebhooksStorage(tx, log),
+		changes:    NewChangesStorage(tx, log),
+		audit:      NewAuditStorage(tx, log),
+		oauth:      NewOAuthStorage(tx, log),
+		identities: NewIdentitiesStorage(tx, log),
+		accounts:   NewServiceAccountsStorage(tx, log),
+		apiKeys:    NewAPIKeysStorage(tx, log),
+		orgs:       NewOrganizationsStorage(tx, log),
+		invites:    NewInvitationsStorage(tx, log),
+		relations:  NewRelationsStorage(tx, log),
+		groups:     NewGroupsStorage(tx, log),
+		exports:    NewExportsStorage(tx, log),
+		emails:     NewEmailChangesStorage(tx, log),
+		logins:     NewPasswordlessStorage(tx, log),
+	}
+}
+
+func (s *Store) Users() users.UserRepository {
+	return s.users
+}
+
+func (s *Store) Webhooks() webhooks.Repository {
+	return s.webhooks
+}
+
+func (s *Store) Changes() changes.Repository {
+	return s.changes
+}
+
+func (s *Store) Audit() audit.Repository {
+	return s.audit
+}
+
+func (s *Store) OAuth() oauth.Repository {
+	return s.oauth
+}
+
+func (s *Store) Identities() identity.Repository {
+	return s.identities
+}
+
+func (s *Store) ServiceAccounts() serviceaccounts.Repository {
+	return s.accounts
+}
+
+func (s *Store) APIKeys() apikeys.Repository {
+	return s.apiKeys
+}
+
+func (s *Store) Organizations() organizations.Repository {
+	return s.orgs
+}
+
+func (s *Store) Invitations() invitations.Repository {
+	return s.invites
+}
+
+func (s *Store) Relations() relations.Repository {
+	return s.relations
+}
+
+func (s *Store) Groups() groups.Repository {
+	return s.groups
+}
+
+func (s *Store) Exports() exports.Repository {
+	return s.exports
+}
+
+func (s *Store) EmailChanges() emailchanges.Repository {
+	return s.emails
+}
+
+func (s *Store) Passwordless() passwordless.Repository {
+	return s.logins
+}
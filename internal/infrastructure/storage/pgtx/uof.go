@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	pg "github.com/LeoUraltsev/auth-service/internal/app/postgres"
-	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/application"
 	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
 	"github.com/jackc/pgx/v5"
 	"log/slog"
@@ -22,7 +22,7 @@ func NewStorageUnitOfWork(pg *pg.Postgres, log *slog.Logger) *StorageUnitOfWork
 	}
 }
 
-func (s *StorageUnitOfWork) Execute(ctx context.Context, fn func(repository users.UserRepository) error) error {
+func (s *StorageUnitOfWork) Execute(ctx context.Context, fn func(store application.Store) error) error {
 	log := logger.LogWithContext(ctx, s.log)
 	log.Info("starting transaction")
 	tx, err := s.pg.Pool.Begin(ctx)
@@ -41,9 +41,9 @@ func (s *StorageUnitOfWork) Execute(ctx context.Context, fn func(repository user
 		log.Info("transaction rolled back")
 	}()
 
-	repo := NewUsersStorage(tx, log)
+	store := NewStore(tx, log)
 
-	if err = fn(repo); err != nil {
+	if err = fn(store); err != nil {
 		return err
 	}
 
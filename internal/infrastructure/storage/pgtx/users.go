@@ -2,6 +2,7 @@ package pgtx
 
 import (
 	"context"
+	"errors"
 	"github.com/LeoUraltsev/auth-service/internal/domain/users"
 	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
 	"github.com/google/uuid"
@@ -17,14 +18,28 @@ type UsersStorage struct {
 
 type User struct {
 	id           string
+	tenantID     string
 	name         string
 	email        string
 	passwordHash []byte
-	isActive     bool
-	createdAt    time.Time
-	updatedAt    time.Time
+	role         string
+	status       string
+	// statusChangedAt пустой у пользователей, созданных до появления статусов
+	statusChangedAt *time.Time
+	// suspendedAt заполнен у заблокированного пользователя
+	suspendedReason        *string
+	suspendedAt            *time.Time
+	suspendedUntil         *time.Time
+	passwordChangeRequired bool
+	sessionsRevokedAt      *time.Time
+	createdAt              time.Time
+	updatedAt              time.Time
 }
 
+const userColumns = `id, tenant_id, name, email, password_hash, role, status, status_changed_at,
+	suspended_reason, suspended_at, suspended_until, password_change_required, sessions_revoked_at,
+	created_at, updated_at`
+
 func NewUsersStorage(tx pgx.Tx, log *slog.Logger) *UsersStorage {
 	return &UsersStorage{tx: tx, log: log}
 }
@@ -36,17 +51,29 @@ func (u *UsersStorage) Save(ctx context.Context, user *users.User) error {
 	log.Info("saving user to postgres")
 	us := mapperToStorage(user)
 
-	query := `INSERT INTO users (id, name, email, password_hash, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (id) DO UPDATE 
+	// пользователь не переходит между тенантами, чужая запись с тем же id не перезаписывается
+	query := `INSERT INTO users (` + userColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) ON CONFLICT (id) DO UPDATE 
 		SET name = EXCLUDED.name, 
 		    email = EXCLUDED.email, 
 		    password_hash = EXCLUDED.password_hash, 
-		    is_active = EXCLUDED.is_active, 
-		    updated_at = EXCLUDED.updated_at;
+		    role = EXCLUDED.role, 
+		    status = EXCLUDED.status,
+		    status_changed_at = EXCLUDED.status_changed_at,
+		    suspended_reason = EXCLUDED.suspended_reason,
+		    suspended_at = EXCLUDED.suspended_at,
+		    suspended_until = EXCLUDED.suspended_until,
+		    password_change_required = EXCLUDED.password_change_required,
+		    sessions_revoked_at = EXCLUDED.sessions_revoked_at,
+		    updated_at = EXCLUDED.updated_at
+		WHERE users.tenant_id = EXCLUDED.tenant_id;
 		`
 	log.Debug("query to save user", slog.String("query", query))
 
-	_, err := u.tx.Exec(ctx, query, &us.id, &us.name, &us.email, &us.passwordHash, &us.isActive, &us.createdAt, &us.updatedAt)
+	// у стертого пользователя имя, email и пароль пустые, в таблице остаются NULL
+	_, err := u.tx.Exec(ctx, query, &us.id, &us.tenantID, nullableString(us.name), nullableString(us.email), &us.passwordHash, &us.role, &us.status, &us.statusChangedAt,
+		&us.suspendedReason, &us.suspendedAt, &us.suspendedUntil, &us.passwordChangeRequired, &us.sessionsRevokedAt,
+		&us.createdAt, &us.updatedAt)
 	if err != nil {
 		//todo: доп проверка на ошибку уникальности
 		log.Error("failed to save user to db ", slog.String("error", err.Error()))
@@ -58,19 +85,13 @@ func (u *UsersStorage) Save(ctx context.Context, user *users.User) error {
 
 //todo: пагинация, возможно сортировка
 
-func (u *UsersStorage) Get(ctx context.Context, id uuid.UUID) (*users.User, error) {
+func (u *UsersStorage) Get(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) (*users.User, error) {
 	log := logger.LogWithContext(ctx, u.log)
-	query := `SELECT id, name, email, password_hash, is_active, created_at, updated_at FROM users WHERE id = $1;`
-	var user User
-	err := u.tx.QueryRow(ctx, query, id).Scan(
-		&user.id,
-		&user.name,
-		&user.email,
-		&user.passwordHash,
-		&user.isActive,
-		&user.createdAt,
-		&user.updatedAt,
-	)
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1 AND ($2 = '' OR tenant_id = $2) AND status <> 'erased';`
+	user, err := scanUser(u.tx.QueryRow(ctx, query, id, tenantFilter(tenantID)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, users.ErrUserNotFound
+	}
 	if err != nil {
 		log.Error("failed to get user by id ", slog.String("id", id.String()))
 		return nil, err
@@ -85,26 +106,17 @@ func (u *UsersStorage) Get(ctx context.Context, id uuid.UUID) (*users.User, erro
 	return dUser, nil
 }
 
-func (u *UsersStorage) GetAll(ctx context.Context) ([]*users.User, error) {
+func (u *UsersStorage) GetAll(ctx context.Context, tenantID uuid.UUID) ([]*users.User, error) {
 	log := logger.LogWithContext(ctx, u.log)
-	query := `SELECT id, name, email, password_hash, is_active, created_at, updated_at FROM users;`
-	rows, err := u.tx.Query(ctx, query)
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id = $1 AND status <> 'erased';`
+	rows, err := u.tx.Query(ctx, query, tenantID.String())
 	if err != nil {
 		log.Error("failed to get all users", slog.String("error", err.Error()))
 		return nil, err
 	}
 	usersList := make([]User, 0)
 	for rows.Next() {
-		var user User
-		err = rows.Scan(
-			&user.id,
-			&user.name,
-			&user.email,
-			&user.passwordHash,
-			&user.isActive,
-			&user.createdAt,
-			&user.updatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			log.Error("failed to get all users", slog.String("error", err.Error()))
 			return nil, err
@@ -124,12 +136,13 @@ func (u *UsersStorage) GetAll(ctx context.Context) ([]*users.User, error) {
 	return dUsers, nil
 }
 
-func (u *UsersStorage) ExistsByEmail(ctx context.Context, email users.Email) (bool, error) {
+func (u *UsersStorage) ExistsByEmail(ctx context.Context, tenantID uuid.UUID, email users.Email) (bool, error) {
 	log := logger.LogWithContext(ctx, u.log)
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND is_active = TRUE);`
+	// условие совпадает с уникальным индексом users_tenant_email_idx
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE tenant_id = $1 AND email = $2 AND status NOT IN ('deleted', 'erased'));`
 	log.Debug("query to check if user exists", slog.String("query", query))
 	var exists bool
-	err := u.tx.QueryRow(ctx, query, email).Scan(&exists)
+	err := u.tx.QueryRow(ctx, query, tenantID.String(), email).Scan(&exists)
 	if err != nil {
 		log.Error("failed to check if user exists by email", slog.String("email", email.String()))
 		return false, err
@@ -138,12 +151,44 @@ func (u *UsersStorage) ExistsByEmail(ctx context.Context, email users.Email) (bo
 	return exists, nil
 }
 
-func (u *UsersStorage) GetByEmail(ctx context.Context, email users.Email) (*users.User, error) {
+func (u *UsersStorage) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*users.User, error) {
+	log := logger.LogWithContext(ctx, u.log)
+	query := `SELECT ` + userColumns + ` FROM users
+		WHERE status = 'deleted' AND status_changed_at < $1
+		ORDER BY status_changed_at LIMIT $2;`
+	rows, err := u.tx.Query(ctx, query, before, limit)
+	if err != nil {
+		log.Error("failed to list deleted users", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]*users.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.Error("failed to list deleted users", slog.String("error", err.Error()))
+			return nil, err
+		}
+		dUser, err := mapperToDomain(user)
+		if err != nil {
+			log.Error("failed to convert user to domain", slog.String("error", err.Error()))
+			return nil, err
+		}
+		list = append(list, dUser)
+	}
+	return list, rows.Err()
+}
+
+func (u *UsersStorage) GetByEmail(ctx context.Context, tenantID uuid.UUID, email users.Email) (*users.User, error) {
 	log := logger.LogWithContext(ctx, u.log)
 	log.Info("attempting to get user by email", slog.String("email", email.String()))
-	query := `SELECT id, name, email, password_hash, is_active, created_at, updated_at FROM users where email = $1;`
-	var usr User
-	err := u.tx.QueryRow(ctx, query, email).Scan(&usr.id, &usr.name, &usr.email, &usr.passwordHash, &usr.isActive, &usr.createdAt, &usr.updatedAt)
+	// email удаленного пользователя мог занять новый, действующая запись важнее
+	query := `SELECT ` + userColumns + ` FROM users where tenant_id = $1 AND email = $2
+		ORDER BY status = 'deleted' LIMIT 1;`
+	usr, err := scanUser(u.tx.QueryRow(ctx, query, tenantID.String(), email))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, users.ErrUserNotFound
+	}
 	if err != nil {
 		log.Error("failed to get user by email", slog.String("email", email.String()))
 		return nil, err
@@ -153,20 +198,64 @@ func (u *UsersStorage) GetByEmail(ctx context.Context, email users.Email) (*user
 	return mapperToDomain(usr)
 }
 
+func scanUser(row pgx.Row) (User, error) {
+	var u User
+	err := row.Scan(
+		&u.id,
+		&u.tenantID,
+		&u.name,
+		&u.email,
+		&u.passwordHash,
+		&u.role,
+		&u.status,
+		&u.statusChangedAt,
+		&u.suspendedReason,
+		&u.suspendedAt,
+		&u.suspendedUntil,
+		&u.passwordChangeRequired,
+		&u.sessionsRevokedAt,
+		&u.createdAt,
+		&u.updatedAt,
+	)
+	return u, err
+}
+
 func mapperToStorage(u *users.User) User {
-	return User{
-		id:           u.ID().String(),
-		name:         u.Name().String(),
-		email:        u.Email().String(),
-		passwordHash: u.Password().Hash(),
-		isActive:     u.IsActive(),
-		createdAt:    u.CreatedAt(),
-		updatedAt:    u.UpdatedAt(),
+	access := u.Access()
+	res := User{
+		id:                     u.ID().String(),
+		tenantID:               u.TenantID().String(),
+		name:                   u.Name().String(),
+		email:                  u.Email().String(),
+		passwordHash:           u.Password().Hash(),
+		role:                   u.Role().String(),
+		status:                 u.Status().String(),
+		passwordChangeRequired: access.PasswordChangeRequired,
+		createdAt:              u.CreatedAt(),
+		updatedAt:              u.UpdatedAt(),
 	}
+	if s := access.Suspension; s != nil {
+		res.suspendedReason = &s.Reason
+		res.suspendedAt = &s.Since
+		if !s.Until.IsZero() {
+			res.suspendedUntil = &s.Until
+		}
+	}
+	if at := u.StatusChangedAt(); !at.IsZero() {
+		res.statusChangedAt = &at
+	}
+	if !access.SessionsRevokedAt.IsZero() {
+		res.sessionsRevokedAt = &access.SessionsRevokedAt
+	}
+	return res
 }
 
 func mapperToDomain(u User) (*users.User, error) {
 	id := uuid.MustParse(u.id)
+	tenantID, err := uuid.Parse(u.tenantID)
+	if err != nil {
+		return nil, err
+	}
 	name, err := users.NewName(u.name)
 	if err != nil {
 		return nil, err
@@ -179,18 +268,52 @@ func mapperToDomain(u User) (*users.User, error) {
 	if err != nil {
 		return nil, err
 	}
+	role, err := users.NewRole(u.role)
+	if err != nil {
+		return nil, err
+	}
+	status, err := users.NewStatus(u.status)
+	if err != nil {
+		return nil, err
+	}
 	user, err := users.NewUser(
 		id,
+		tenantID,
 		name,
 		email,
 		passwordHash,
-		u.isActive,
+		role,
+		status,
 		u.createdAt,
 		u.updatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
-	return user, nil
 
+	access := users.Access{PasswordChangeRequired: u.passwordChangeRequired}
+	if u.suspendedAt != nil {
+		access.Suspension = &users.Suspension{Since: *u.suspendedAt}
+		if u.suspendedReason != nil {
+			access.Suspension.Reason = *u.suspendedReason
+		}
+		if u.suspendedUntil != nil {
+			access.Suspension.Until = *u.suspendedUntil
+		}
+	}
+	if u.sessionsRevokedAt != nil {
+		access.SessionsRevokedAt = *u.sessionsRevokedAt
+	}
+	if u.statusChangedAt != nil {
+		user.WithStatusChangedAt(*u.statusChangedAt)
+	}
+	return user.WithAccess(access), nil
+}
+
+// tenantFilter пустая строка для AnyTenant снимает условие по тенанту
+func tenantFilter(tenantID uuid.UUID) string {
+	if tenantID == users.AnyTenant {
+		return ""
+	}
+	return tenantID.String()
 }
@@ -0,0 +1,391 @@
+package pgtx
+
+import (
+	"context"
+	"errors"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	"github.com/LeoUraltsev/auth-service/internal/helper/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+type WebhooksStorage struct {
+	tx  pgx.Tx
+	log *slog.Logger
+}
+
+type Subscription struct {
+	id        string
+	url       string
+	events    []string
+	secret    string
+	isActive  bool
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+type Delivery struct {
+	id             string
+	subscriptionID string
+	eventID        string
+	eventType      string
+	payload        string
+	status         string
+	attempts       int
+	nextAttemptAt  time.Time
+	lastError      string
+	createdAt      time.Time
+	updatedAt      time.Time
+}
+
+type Attempt struct {
+	id         string
+	deliveryID string
+	number     int
+	statusCode int
+	err        string
+	durationMs int64
+	createdAt  time.Time
+}
+
+const (
+	subscriptionColumns = `id, url, events, secret, is_active, created_at, updated_at`
+	deliveryColumns     = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at`
+)
+
+func NewWebhooksStorage(tx pgx.Tx, log *slog.Logger) *WebhooksStorage {
+	return &WebhooksStorage{tx: tx, log: log}
+}
+
+func (w *WebhooksStorage) SaveSubscription(ctx context.Context, subscription *webhooks.Subscription) error {
+	log := logger.LogWithContext(ctx, w.log)
+	s := subscriptionToStorage(subscription)
+
+	query := `INSERT INTO webhook_subscriptions (id, url, events, secret, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (id) DO UPDATE
+		SET url = EXCLUDED.url,
+		    events = EXCLUDED.events,
+		    secret = EXCLUDED.secret,
+		    is_active = EXCLUDED.is_active,
+		    updated_at = EXCLUDED.updated_at;`
+
+	_, err := w.tx.Exec(ctx, query, s.id, s.url, s.events, s.secret, s.isActive, s.createdAt, s.updatedAt)
+	if err != nil {
+		log.Error("failed to save webhook subscription", slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("webhook subscription saved", slog.String("id", s.id))
+	return nil
+}
+
+func (w *WebhooksStorage) GetSubscription(ctx context.Context, id uuid.UUID) (*webhooks.Subscription, error) {
+	log := logger.LogWithContext(ctx, w.log)
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1;`
+	s, err := scanSubscription(w.tx.QueryRow(ctx, query, id.String()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, webhooks.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		log.Error("failed to get webhook subscription", slog.String("id", id.String()), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return subscriptionToDomain(s)
+}
+
+func (w *WebhooksStorage) ListSubscriptions(ctx context.Context) ([]*webhooks.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions ORDER BY created_at;`
+	return w.querySubscriptions(ctx, query)
+}
+
+func (w *WebhooksStorage) ListSubscriptionsByEvent(ctx context.Context, eventType webhooks.EventType) ([]*webhooks.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions
+		WHERE is_active = TRUE AND ($1 = ANY(events) OR $2 = ANY(events));`
+	return w.querySubscriptions(ctx, query, eventType.String(), webhooks.EventAll.String())
+}
+
+func (w *WebhooksStorage) querySubscriptions(ctx context.Context, query string, args ...any) ([]*webhooks.Subscription, error) {
+	log := logger.LogWithContext(ctx, w.log)
+	rows, err := w.tx.Query(ctx, query, args...)
+	if err != nil {
+		log.Error("failed to get webhook subscriptions", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*webhooks.Subscription, 0)
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			log.Error("failed to scan webhook subscription", slog.String("error", err.Error()))
+			return nil, err
+		}
+		subscription, err := subscriptionToDomain(s)
+		if err != nil {
+			log.Error("failed to convert webhook subscription to domain", slog.String("error", err.Error()))
+			continue
+		}
+		res = append(res, subscription)
+	}
+	return res, rows.Err()
+}
+
+func (w *WebhooksStorage) SaveDelivery(ctx context.Context, delivery *webhooks.Delivery) error {
+	log := logger.LogWithContext(ctx, w.log)
+	d := deliveryToStorage(delivery)
+
+	query := `INSERT INTO webhook_deliveries (` + deliveryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    attempts = EXCLUDED.attempts,
+		    next_attempt_at = EXCLUDED.next_attempt_at,
+		    last_error = EXCLUDED.last_error,
+		    updated_at = EXCLUDED.updated_at;`
+
+	_, err := w.tx.Exec(ctx, query,
+		d.id, d.subscriptionID, d.eventID, d.eventType, d.payload, d.status,
+		d.attempts, d.nextAttemptAt, d.lastError, d.createdAt, d.updatedAt,
+	)
+	if err != nil {
+		log.Error("failed to save webhook delivery", slog.String("error", err.Error()))
+		return err
+	}
+	log.Debug("webhook delivery saved", slog.String("id", d.id), slog.String("status", d.status))
+	return nil
+}
+
+func (w *WebhooksStorage) GetDelivery(ctx context.Context, id uuid.UUID) (*webhooks.Delivery, error) {
+	log := logger.LogWithContext(ctx, w.log)
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1;`
+	d, err := scanDelivery(w.tx.QueryRow(ctx, query, id.String()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, webhooks.ErrDeliveryNotFound
+	}
+	if err != nil {
+		log.Error("failed to get webhook delivery", slog.String("id", id.String()), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return deliveryToDomain(d)
+}
+
+func (w *WebhooksStorage) ListDeliveries(ctx context.Context, filter webhooks.DeliveryFilter) ([]*webhooks.Delivery, error) {
+	var subscriptionID *string
+	if filter.SubscriptionID != uuid.Nil {
+		id := filter.SubscriptionID.String()
+		subscriptionID = &id
+	}
+	var status *string
+	if filter.Status != "" {
+		st := filter.Status.String()
+		status = &st
+	}
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+		WHERE ($1::text IS NULL OR subscription_id = $1) AND ($2::text IS NULL OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4;`
+	return w.queryDeliveries(ctx, query, subscriptionID, status, filter.Limit, filter.Offset)
+}
+
+// ListDueDeliveries блокирует выбранные строки до конца транзакции, занятые другими транзакциями пропускаются
+func (w *WebhooksStorage) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*webhooks.Delivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED;`
+	return w.queryDeliveries(ctx, query, webhooks.DeliveryPending.String(), now, limit)
+}
+
+func (w *WebhooksStorage) queryDeliveries(ctx context.Context, query string, args ...any) ([]*webhooks.Delivery, error) {
+	log := logger.LogWithContext(ctx, w.log)
+	rows, err := w.tx.Query(ctx, query, args...)
+	if err != nil {
+		log.Error("failed to get webhook deliveries", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*webhooks.Delivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			log.Error("failed to scan webhook delivery", slog.String("error", err.Error()))
+			return nil, err
+		}
+		delivery, err := deliveryToDomain(d)
+		if err != nil {
+			log.Error("failed to convert webhook delivery to domain", slog.String("error", err.Error()))
+			continue
+		}
+		res = append(res, delivery)
+	}
+	return res, rows.Err()
+}
+
+func (w *WebhooksStorage) SaveAttempt(ctx context.Context, attempt *webhooks.Attempt) error {
+	log := logger.LogWithContext(ctx, w.log)
+	query := `INSERT INTO webhook_delivery_attempts (id, delivery_id, number, status_code, error, duration_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`
+	_, err := w.tx.Exec(ctx, query,
+		attempt.ID().String(),
+		attempt.DeliveryID().String(),
+		attempt.Number(),
+		attempt.StatusCode(),
+		attempt.Error(),
+		attempt.Duration().Milliseconds(),
+		attempt.CreatedAt(),
+	)
+	if err != nil {
+		log.Error("failed to save webhook delivery attempt", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (w *WebhooksStorage) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*webhooks.Attempt, error) {
+	log := logger.LogWithContext(ctx, w.log)
+	query := `SELECT id, delivery_id, number, status_code, error, duration_ms, created_at
+		FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY number;`
+	rows, err := w.tx.Query(ctx, query, deliveryID.String())
+	if err != nil {
+		log.Error("failed to get webhook delivery attempts", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*webhooks.Attempt, 0)
+	for rows.Next() {
+		var a Attempt
+		err = rows.Scan(&a.id, &a.deliveryID, &a.number, &a.statusCode, &a.err, &a.durationMs, &a.createdAt)
+		if err != nil {
+			log.Error("failed to scan webhook delivery attempt", slog.String("error", err.Error()))
+			return nil, err
+		}
+		res = append(res, webhooks.NewAttempt(
+			uuid.MustParse(a.id),
+			uuid.MustParse(a.deliveryID),
+			a.number,
+			a.statusCode,
+			a.err,
+			time.Duration(a.durationMs)*time.Millisecond,
+			a.createdAt,
+		))
+	}
+	return res, rows.Err()
+}
+
+// ScrubUserDeliveries тело доставки хранится текстом, данные пользователя лежат в data
+func (w *WebhooksStorage) ScrubUserDeliveries(ctx context.Context, userID uuid.UUID) error {
+	log := logger.LogWithContext(ctx, w.log)
+	query := `UPDATE webhook_deliveries
+		SET payload = jsonb_set(jsonb_set(payload::jsonb, '{data,name}', '""'), '{data,email}', '""')::text
+		WHERE event_type LIKE 'user.%' AND payload::jsonb #>> '{data,id}' = $1;`
+	tag, err := w.tx.Exec(ctx, query, userID.String())
+	if err != nil {
+		log.Error("failed to scrub user webhook deliveries", slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("user webhook deliveries scrubbed", slog.String("user_id", userID.String()), slog.Int64("count", tag.RowsAffected()))
+	return nil
+}
+
+func scanSubscription(row pgx.Row) (Subscription, error) {
+	var s Subscription
+	err := row.Scan(&s.id, &s.url, &s.events, &s.secret, &s.isActive, &s.createdAt, &s.updatedAt)
+	return s, err
+}
+
+func scanDelivery(row pgx.Row) (Delivery, error) {
+	var d Delivery
+	err := row.Scan(
+		&d.id,
+		&d.subscriptionID,
+		&d.eventID,
+		&d.eventType,
+		&d.payload,
+		&d.status,
+		&d.attempts,
+		&d.nextAttemptAt,
+		&d.lastError,
+		&d.createdAt,
+		&d.updatedAt,
+	)
+	return d, err
+}
+
+func subscriptionToStorage(s *webhooks.Subscription) Subscription {
+	events := make([]string, 0, len(s.Events()))
+	for _, e := range s.Events() {
+		events = append(events, e.String())
+	}
+	return Subscription{
+		id:        s.ID().String(),
+		url:       s.URL().String(),
+		events:    events,
+		secret:    s.Secret().String(),
+		isActive:  s.IsActive(),
+		createdAt: s.CreatedAt(),
+		updatedAt: s.UpdatedAt(),
+	}
+}
+
+func subscriptionToDomain(s Subscription) (*webhooks.Subscription, error) {
+	u, err := webhooks.NewURL(s.url)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]webhooks.EventType, 0, len(s.events))
+	for _, e := range s.events {
+		eventType, err := webhooks.NewEventType(e)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, eventType)
+	}
+	secret, err := webhooks.NewSecret(s.secret)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks.NewSubscription(uuid.MustParse(s.id), u, events, secret, s.isActive, s.createdAt, s.updatedAt)
+}
+
+func deliveryToStorage(d *webhooks.Delivery) Delivery {
+	return Delivery{
+		id:             d.ID().String(),
+		subscriptionID: d.SubscriptionID().String(),
+		eventID:        d.EventID().String(),
+		eventType:      d.EventType().String(),
+		payload:        string(d.Payload()),
+		status:         d.Status().String(),
+		attempts:       d.Attempts(),
+		nextAttemptAt:  d.NextAttemptAt(),
+		lastError:      d.LastError(),
+		createdAt:      d.CreatedAt(),
+		updatedAt:      d.UpdatedAt(),
+	}
+}
+
+func deliveryToDomain(d Delivery) (*webhooks.Delivery, error) {
+	eventType, err := webhooks.NewEventType(d.eventType)
+	if err != nil {
+		return nil, err
+	}
+	status, err := webhooks.NewDeliveryStatus(d.status)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks.NewDelivery(
+		uuid.MustParse(d.id),
+		uuid.MustParse(d.subscriptionID),
+		uuid.MustParse(d.eventID),
+		eventType,
+		[]byte(d.payload),
+		status,
+		d.attempts,
+		d.nextAttemptAt,
+		d.lastError,
+		d.createdAt,
+		d.updatedAt,
+	), nil
+}
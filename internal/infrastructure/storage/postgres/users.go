@@ -17,9 +17,11 @@ type UsersStorage struct {
 
 type User struct {
 	id           string
+	tenantID     string
 	name         string
 	email        string
 	passwordHash []byte
+	role         string
 	isActive     bool
 	createdAt    time.Time
 	updatedAt    time.Time
@@ -36,17 +38,20 @@ func (u *UsersStorage) Save(ctx context.Context, user *users.User) error {
 	log.Info("saving user to postgres")
 	us := mapperToStorage(user)
 
-	query := `INSERT INTO users (id, name, email, password_hash, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (id) DO UPDATE 
+	// пользователь не переходит между тенантами, чужая запись с тем же id не перезаписывается
+	query := `INSERT INTO users (id, tenant_id, name, email, password_hash, role, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (id) DO UPDATE 
 		SET name = EXCLUDED.name, 
 		    email = EXCLUDED.email, 
 		    password_hash = EXCLUDED.password_hash, 
+		    role = EXCLUDED.role, 
 		    is_active = EXCLUDED.is_active, 
-		    updated_at = EXCLUDED.updated_at;
+		    updated_at = EXCLUDED.updated_at
+		WHERE users.tenant_id = EXCLUDED.tenant_id;
 		`
 	log.Debug("query to save user", slog.String("query", query))
 
-	_, err := u.db.Pool.Exec(ctx, query, &us.id, &us.name, &us.email, &us.passwordHash, &us.isActive, &us.createdAt, &us.updatedAt)
+	_, err := u.db.Pool.Exec(ctx, query, &us.id, &us.tenantID, &us.name, &us.email, &us.passwordHash, &us.role, &us.isActive, &us.createdAt, &us.updatedAt)
 	if err != nil {
 		//todo: доп проверка на ошибку уникальности
 		log.Error("failed to save user to db ", slog.String("error", err.Error()))
@@ -58,15 +63,17 @@ func (u *UsersStorage) Save(ctx context.Context, user *users.User) error {
 
 //todo: пагинация, возможно сортировка
 
-func (u *UsersStorage) Get(ctx context.Context, id uuid.UUID) (*users.User, error) {
+func (u *UsersStorage) Get(ctx context.Context, tenantID uuid.UUID, id uuid.UUID) (*users.User, error) {
 	log := logger.LogWithContext(ctx, u.log)
-	query := `SELECT id, name, email, password_hash, is_active, created_at, updated_at FROM users WHERE id = $1;`
+	query := `SELECT id, tenant_id, name, email, password_hash, role, is_active, created_at, updated_at FROM users WHERE id = $1 AND ($2 = '' OR tenant_id = $2);`
 	var user User
-	err := u.db.Pool.QueryRow(ctx, query, id).Scan(
+	err := u.db.Pool.QueryRow(ctx, query, id, tenantFilter(tenantID)).Scan(
 		&user.id,
+		&user.tenantID,
 		&user.name,
 		&user.email,
 		&user.passwordHash,
+		&user.role,
 		&user.isActive,
 		&user.createdAt,
 		&user.updatedAt,
@@ -85,10 +92,10 @@ func (u *UsersStorage) Get(ctx context.Context, id uuid.UUID) (*users.User, erro
 	return dUser, nil
 }
 
-func (u *UsersStorage) GetAll(ctx context.Context) ([]*users.User, error) {
+func (u *UsersStorage) GetAll(ctx context.Context, tenantID uuid.UUID) ([]*users.User, error) {
 	log := logger.LogWithContext(ctx, u.log)
-	query := `SELECT id, name, email, password_hash, is_active, created_at, updated_at FROM users;`
-	rows, err := u.db.Pool.Query(ctx, query)
+	query := `SELECT id, tenant_id, name, email, password_hash, role, is_active, created_at, updated_at FROM users WHERE tenant_id = $1;`
+	rows, err := u.db.Pool.Query(ctx, query, tenantID.String())
 	if err != nil {
 		log.Error("failed to get all users", slog.String("error", err.Error()))
 		return nil, err
@@ -98,9 +105,11 @@ func (u *UsersStorage) GetAll(ctx context.Context) ([]*users.User, error) {
 		var user User
 		err = rows.Scan(
 			&user.id,
+			&user.tenantID,
 			&user.name,
 			&user.email,
 			&user.passwordHash,
+			&user.role,
 			&user.isActive,
 			&user.createdAt,
 			&user.updatedAt,
@@ -124,12 +133,12 @@ func (u *UsersStorage) GetAll(ctx context.Context) ([]*users.User, error) {
 	return dUsers, nil
 }
 
-func (u *UsersStorage) ExistsByEmail(ctx context.Context, email users.Email) (bool, error) {
+func (u *UsersStorage) ExistsByEmail(ctx context.Context, tenantID uuid.UUID, email users.Email) (bool, error) {
 	log := logger.LogWithContext(ctx, u.log)
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND is_active = TRUE);`
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE tenant_id = $1 AND email = $2 AND is_active = TRUE);`
 	log.Debug("query to check if user exists", slog.String("query", query))
 	var exists bool
-	err := u.db.Pool.QueryRow(ctx, query, email).Scan(&exists)
+	err := u.db.Pool.QueryRow(ctx, query, tenantID.String(), email).Scan(&exists)
 	if err != nil {
 		log.Error("failed to check if user exists by email", slog.String("email", email.String()))
 		return false, err
@@ -138,12 +147,12 @@ func (u *UsersStorage) ExistsByEmail(ctx context.Context, email users.Email) (bo
 	return exists, nil
 }
 
-func (u *UsersStorage) GetByEmail(ctx context.Context, email users.Email) (*users.User, error) {
+func (u *UsersStorage) GetByEmail(ctx context.Context, tenantID uuid.UUID, email users.Email) (*users.User, error) {
 	log := logger.LogWithContext(ctx, u.log)
 	log.Info("attempting to get user by email", slog.String("email", email.String()))
-	query := `SELECT id, name, email, password_hash, is_active, created_at, updated_at FROM users where email = $1;`
+	query := `SELECT id, tenant_id, name, email, password_hash, role, is_active, created_at, updated_at FROM users where tenant_id = $1 AND email = $2;`
 	var usr User
-	err := u.db.Pool.QueryRow(ctx, query, email).Scan(&usr.id, &usr.name, &usr.email, &usr.passwordHash, &usr.isActive, &usr.createdAt, &usr.updatedAt)
+	err := u.db.Pool.QueryRow(ctx, query, tenantID.String(), email).Scan(&usr.id, &usr.tenantID, &usr.name, &usr.email, &usr.passwordHash, &usr.role, &usr.isActive, &usr.createdAt, &usr.updatedAt)
 	if err != nil {
 		log.Error("failed to get user by email", slog.String("email", email.String()))
 		return nil, err
@@ -156,9 +165,11 @@ func (u *UsersStorage) GetByEmail(ctx context.Context, email users.Email) (*user
 func mapperToStorage(u *users.User) User {
 	return User{
 		id:           u.ID().String(),
+		tenantID:     u.TenantID().String(),
 		name:         u.Name().String(),
 		email:        u.Email().String(),
 		passwordHash: u.Password().Hash(),
+		role:         u.Role().String(),
 		isActive:     u.IsActive(),
 		createdAt:    u.CreatedAt(),
 		updatedAt:    u.UpdatedAt(),
@@ -167,6 +178,10 @@ func mapperToStorage(u *users.User) User {
 
 func mapperToDomain(u User) (*users.User, error) {
 	id := uuid.MustParse(u.id)
+	tenantID, err := uuid.Parse(u.tenantID)
+	if err != nil {
+		return nil, err
+	}
 	name, err := users.NewName(u.name)
 	if err != nil {
 		return nil, err
@@ -179,12 +194,22 @@ func mapperToDomain(u User) (*users.User, error) {
 	if err != nil {
 		return nil, err
 	}
+	role, err := users.NewRole(u.role)
+	if err != nil {
+		return nil, err
+	}
+	status := users.StatusDeleted
+	if u.isActive {
+		status = users.StatusActive
+	}
 	user, err := users.NewUser(
 		id,
+		tenantID,
 		name,
 		email,
 		passwordHash,
-		u.isActive,
+		role,
+		status,
 		u.createdAt,
 		u.updatedAt,
 	)
@@ -194,3 +219,11 @@ func mapperToDomain(u User) (*users.User, error) {
 	return user, nil
 
 }
+
+// tenantFilter пустая строка для AnyTenant снимает условие по тенанту
+func tenantFilter(tenantID uuid.UUID) string {
+	if tenantID == users.AnyTenant {
+		return ""
+	}
+	return tenantID.String()
+}
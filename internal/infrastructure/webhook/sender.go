@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+type HTTPSender struct {
+	client *http.Client
+	log    *slog.Logger
+}
+
+func NewHTTPSender(log *slog.Logger, timeout time.Duration) *HTTPSender {
+	return &HTTPSender{
+		client: &http.Client{Timeout: timeout},
+		log:    log,
+	}
+}
+
+// Send отправляет событие POST запросом, тело подписывается HMAC-SHA256 от "<timestamp>.<body>"
+func (s *HTTPSender) Send(ctx context.Context, subscription *webhooks.Subscription, delivery *webhooks.Delivery) (int, error) {
+	body := delivery.Payload()
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL().String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "auth-service-webhooks")
+	req.Header.Set(HeaderID, delivery.ID().String())
+	req.Header.Set(HeaderEvent, delivery.EventType().String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign([]byte(subscription.Secret().String()), timestamp, body))
+
+	s.log.Debug("sending webhook", slog.String("delivery_id", delivery.ID().String()), slog.String("url", subscription.URL().String()))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
+
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/domain/webhooks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHTTPSender_Send(t *testing.T) {
+	const secretValue = "0123456789abcdef"
+	payload := []byte(`{"type":"user.created"}`)
+
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	url, _ := webhooks.NewURL(srv.URL)
+	secret, _ := webhooks.NewSecret(secretValue)
+	subscription, _ := webhooks.CreateSubscription(url, []webhooks.EventType{webhooks.EventAll}, secret)
+	now := time.Now().UTC()
+	delivery := webhooks.NewDelivery(uuid.New(), subscription.ID(), uuid.New(), webhooks.EventUserCreated, payload, webhooks.DeliveryPending, 0, now, "", now, now)
+
+	sender := NewHTTPSender(slog.New(slog.NewTextHandler(io.Discard, nil)), time.Second)
+	code, err := sender.Send(context.Background(), subscription, delivery)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, payload, gotBody)
+	assert.Equal(t, delivery.ID().String(), got.Header.Get(HeaderID))
+	assert.Equal(t, "user.created", got.Header.Get(HeaderEvent))
+
+	ts, err := strconv.ParseInt(got.Header.Get(HeaderTimestamp), 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256="+Sign([]byte(secretValue), ts, payload), got.Header.Get(HeaderSignature))
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163",
+		Sign([]byte("secret"), 1700000000, []byte("{}")),
+	)
+}
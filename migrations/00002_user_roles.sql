@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users add column if not exists role TEXT not null default 'user';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table users drop column if exists role;
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists webhook_subscriptions (
+  id TEXT primary key,
+  url TEXT not null,
+  events TEXT[] not null,
+  secret TEXT not null,
+  is_active boolean not null,
+  created_at timestamp not null,
+  updated_at timestamp not null
+);
+
+create table if not exists webhook_deliveries (
+  id TEXT primary key,
+  subscription_id TEXT not null references webhook_subscriptions (id),
+  event_id TEXT not null,
+  event_type TEXT not null,
+  payload TEXT not null,
+  status TEXT not null,
+  attempts integer not null,
+  next_attempt_at timestamp not null,
+  last_error TEXT not null default '',
+  created_at timestamp not null,
+  updated_at timestamp not null
+);
+
+create index if not exists webhook_deliveries_due_idx
+  on webhook_deliveries (next_attempt_at) where status = 'pending';
+create index if not exists webhook_deliveries_subscription_idx
+  on webhook_deliveries (subscription_id, created_at);
+
+create table if not exists webhook_delivery_attempts (
+  id TEXT primary key,
+  delivery_id TEXT not null references webhook_deliveries (id) on delete cascade,
+  number integer not null,
+  status_code integer not null,
+  error TEXT not null default '',
+  duration_ms bigint not null,
+  created_at timestamp not null
+);
+
+create index if not exists webhook_delivery_attempts_delivery_idx
+  on webhook_delivery_attempts (delivery_id, number);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists webhook_delivery_attempts;
+drop table if exists webhook_deliveries;
+drop table if exists webhook_subscriptions;
+-- +goose StatementEnd
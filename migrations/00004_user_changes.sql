@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists user_changes (
+  position bigserial primary key,
+  change_type TEXT not null,
+  user_id TEXT not null,
+  name TEXT not null,
+  email TEXT not null,
+  role TEXT not null,
+  is_active boolean not null,
+  occurred_at timestamp not null
+);
+
+create index if not exists user_changes_user_idx on user_changes (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists user_changes;
+-- +goose StatementEnd
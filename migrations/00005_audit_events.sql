@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists audit_events (
+  seq bigserial primary key,
+  id TEXT not null unique,
+  occurred_at timestamp not null,
+  action TEXT not null,
+  actor_id TEXT,
+  target_type TEXT not null,
+  target_id TEXT,
+  request_id TEXT,
+  peer_ip TEXT,
+  outcome TEXT not null,
+  reason TEXT not null default ''
+);
+
+create index if not exists audit_events_actor_idx on audit_events (actor_id, seq);
+create index if not exists audit_events_target_idx on audit_events (target_id, seq);
+
+create or replace function audit_events_append_only() returns trigger as $$
+begin
+  raise exception 'audit_events is append-only';
+end;
+$$ language plpgsql;
+
+create trigger audit_events_append_only
+  before update or delete on audit_events
+  for each row execute function audit_events_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger if exists audit_events_append_only on audit_events;
+drop function if exists audit_events_append_only();
+drop table if exists audit_events;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- записи, созданные до миграции, остаются без hash и не входят в цепочку
+alter table audit_events add column if not exists prev_hash TEXT;
+alter table audit_events add column if not exists hash TEXT;
+
+create table if not exists audit_checkpoints (
+  id TEXT primary key,
+  seq BIGINT not null unique,
+  hash TEXT not null,
+  signature BYTEA not null,
+  key_id TEXT not null,
+  created_at timestamp not null
+);
+
+create or replace function audit_checkpoints_append_only() returns trigger as $$
+begin
+  raise exception 'audit_checkpoints is append-only';
+end;
+$$ language plpgsql;
+
+create trigger audit_checkpoints_append_only
+  before update or delete on audit_checkpoints
+  for each row execute function audit_checkpoints_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger if exists audit_checkpoints_append_only on audit_checkpoints;
+drop function if exists audit_checkpoints_append_only();
+drop table if exists audit_checkpoints;
+alter table audit_events drop column if exists hash;
+alter table audit_events drop column if exists prev_hash;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists oauth_clients (
+  id TEXT primary key,
+  client_id TEXT not null unique,
+  secret_hash TEXT not null default '',
+  name TEXT not null,
+  redirect_uris TEXT[] not null,
+  grant_types TEXT[] not null,
+  scope TEXT[] not null,
+  confidential boolean not null,
+  created_at timestamp not null
+);
+
+create table if not exists oauth_codes (
+  code_hash TEXT primary key,
+  client_id TEXT not null references oauth_clients (client_id) on delete cascade,
+  user_id TEXT not null,
+  redirect_uri TEXT not null,
+  scope TEXT[] not null,
+  code_challenge TEXT not null,
+  code_challenge_method TEXT not null,
+  family_id TEXT not null,
+  expires_at timestamp not null,
+  used_at timestamp,
+  created_at timestamp not null
+);
+
+create table if not exists oauth_refresh_tokens (
+  id TEXT primary key,
+  token_hash TEXT not null unique,
+  family_id TEXT not null,
+  client_id TEXT not null references oauth_clients (client_id) on delete cascade,
+  user_id TEXT not null,
+  scope TEXT[] not null,
+  expires_at timestamp not null,
+  revoked_at timestamp,
+  created_at timestamp not null
+);
+
+create index if not exists oauth_refresh_tokens_family_idx on oauth_refresh_tokens (family_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists oauth_refresh_tokens;
+drop table if exists oauth_codes;
+drop table if exists oauth_clients;
+-- +goose StatementEnd
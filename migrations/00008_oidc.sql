@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table oauth_codes
+  add column if not exists nonce TEXT not null default '',
+  add column if not exists auth_time timestamp,
+  add column if not exists acr TEXT not null default '';
+
+alter table oauth_refresh_tokens
+  add column if not exists auth_time timestamp,
+  add column if not exists acr TEXT not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table oauth_refresh_tokens
+  drop column if exists acr,
+  drop column if exists auth_time;
+
+alter table oauth_codes
+  drop column if exists acr,
+  drop column if exists auth_time,
+  drop column if exists nonce;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists user_identities (
+  id TEXT primary key,
+  user_id TEXT not null references users (id) on delete cascade,
+  provider TEXT not null,
+  subject TEXT not null,
+  email TEXT not null default '',
+  created_at timestamp not null,
+  unique (provider, subject)
+);
+
+create index if not exists user_identities_user_idx on user_identities (user_id);
+
+create table if not exists federated_logins (
+  state_hash TEXT primary key,
+  provider TEXT not null,
+  nonce TEXT not null,
+  code_verifier TEXT not null,
+  request JSONB not null,
+  expires_at timestamp not null,
+  created_at timestamp not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists federated_logins;
+drop table if exists user_identities;
+-- +goose StatementEnd
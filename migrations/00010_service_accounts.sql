@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists service_accounts (
+  id TEXT primary key,
+  client_id TEXT not null unique,
+  name TEXT not null,
+  role TEXT not null,
+  disabled_at timestamp,
+  created_at timestamp not null
+);
+
+create table if not exists service_account_secrets (
+  id TEXT primary key,
+  account_id TEXT not null references service_accounts (id) on delete cascade,
+  secret_hash TEXT not null,
+  expires_at timestamp,
+  created_at timestamp not null
+);
+
+create index if not exists service_account_secrets_account_idx on service_account_secrets (account_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists service_account_secrets;
+drop table if exists service_accounts;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists api_keys (
+  id TEXT primary key,
+  user_id TEXT not null references users (id) on delete cascade,
+  name TEXT not null,
+  prefix TEXT not null unique,
+  key_hash TEXT not null,
+  scope TEXT[] not null,
+  expires_at timestamp,
+  last_used_at timestamp,
+  revoked_at timestamp,
+  created_at timestamp not null
+);
+
+create index if not exists api_keys_user_idx on api_keys (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists organizations (
+  id TEXT primary key,
+  slug TEXT not null unique,
+  name TEXT not null,
+  created_at timestamp not null,
+  updated_at timestamp not null
+);
+
+-- организация по умолчанию, в нее попадают все существующие пользователи
+insert into organizations (id, slug, name, created_at, updated_at)
+values ('00000000-0000-0000-0000-000000000001', 'default', 'Default', now(), now())
+on conflict (id) do nothing;
+
+alter table users add column if not exists tenant_id TEXT not null
+  default '00000000-0000-0000-0000-000000000001' references organizations (id);
+alter table users alter column tenant_id drop default;
+
+-- email уникален внутри организации
+alter table users drop constraint if exists users_email_key;
+create unique index if not exists users_tenant_email_idx on users (tenant_id, email);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists users_tenant_email_idx;
+alter table users add constraint users_email_key unique (email);
+alter table users drop column if exists tenant_id;
+drop table if exists organizations;
+-- +goose StatementEnd
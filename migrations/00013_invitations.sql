@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists invitations (
+  id TEXT primary key,
+  tenant_id TEXT not null references organizations (id) on delete cascade,
+  email TEXT not null,
+  role TEXT not null,
+  code_hash TEXT not null unique,
+  invited_by TEXT not null,
+  expires_at timestamp not null,
+  accepted_at timestamp,
+  accepted_by TEXT,
+  revoked_at timestamp,
+  created_at timestamp not null
+);
+
+create index if not exists invitations_tenant_idx on invitations (tenant_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists invitations;
+-- +goose StatementEnd
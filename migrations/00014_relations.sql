@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists relation_tuples (
+  tenant_id TEXT not null references organizations (id) on delete cascade,
+  object_type TEXT not null,
+  object_id TEXT not null,
+  relation TEXT not null,
+  subject_type TEXT not null,
+  subject_id TEXT not null,
+  -- subject_relation пустой у субъекта-объекта, заполнен у множества type:id#relation
+  subject_relation TEXT not null default '',
+  created_revision bigint not null,
+  primary key (tenant_id, object_type, object_id, relation, subject_type, subject_id, subject_relation)
+);
+
+create index if not exists relation_tuples_subject_idx
+  on relation_tuples (tenant_id, subject_type, subject_id, subject_relation);
+
+-- relation_revision одна строка, блокировка строки упорядочивает записи
+create table if not exists relation_revision (
+  id boolean primary key default true check (id),
+  revision bigint not null
+);
+
+insert into relation_revision (id, revision) values (true, 0) on conflict (id) do nothing;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists relation_revision;
+drop table if exists relation_tuples;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists groups (
+  id TEXT primary key,
+  tenant_id TEXT not null references organizations (id) on delete cascade,
+  name TEXT not null,
+  description TEXT not null default '',
+  created_at timestamp not null,
+  updated_at timestamp not null,
+  unique (tenant_id, name)
+);
+
+-- group_members member_id - id пользователя или вложенной группы
+create table if not exists group_members (
+  group_id TEXT not null references groups (id) on delete cascade,
+  tenant_id TEXT not null,
+  member_type TEXT not null,
+  member_id TEXT not null,
+  created_at timestamp not null,
+  primary key (group_id, member_type, member_id)
+);
+
+create index if not exists group_members_member_idx on group_members (tenant_id, member_type, member_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists group_members;
+drop table if exists groups;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+  add column if not exists suspended_reason TEXT,
+  add column if not exists suspended_at timestamp,
+  add column if not exists suspended_until timestamp,
+  add column if not exists password_change_required boolean not null default false,
+  add column if not exists sessions_revoked_at timestamp;
+
+create index if not exists oauth_refresh_tokens_user_idx on oauth_refresh_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists oauth_refresh_tokens_user_idx;
+alter table users
+  drop column if exists sessions_revoked_at,
+  drop column if exists password_change_required,
+  drop column if exists suspended_until,
+  drop column if exists suspended_at,
+  drop column if exists suspended_reason;
+-- +goose StatementEnd
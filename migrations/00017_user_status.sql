@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+  add column if not exists status TEXT not null default 'active',
+  add column if not exists status_changed_at timestamp;
+
+update users set status = case
+  when not coalesce(is_active, false) then 'deleted'
+  when suspended_at is not null then 'suspended'
+  else 'active'
+end;
+
+alter table users drop column if exists is_active;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table users add column if not exists is_active boolean;
+
+-- pending и locked не различались, такие пользователи возвращаются активными
+update users set is_active = status <> 'deleted';
+
+alter table users
+  drop column if exists status_changed_at,
+  drop column if exists status;
+-- +goose StatementEnd
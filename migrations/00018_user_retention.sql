@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- до появления статусов время удаления не сохранялось, ближайшее известное - updated_at
+update users set status_changed_at = updated_at where status_changed_at is null and status = 'deleted';
+
+create index if not exists users_deleted_idx on users (status_changed_at) where status = 'deleted';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists users_deleted_idx;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists data_exports (
+  id TEXT primary key,
+  tenant_id TEXT not null references organizations (id) on delete cascade,
+  user_id TEXT not null,
+  requested_by TEXT not null,
+  status TEXT not null,
+  archive BYTEA,
+  failure TEXT not null default '',
+  created_at timestamp not null,
+  completed_at timestamp,
+  expires_at timestamp
+);
+
+create index if not exists data_exports_pending_idx on data_exports (created_at) where status = 'pending';
+create index if not exists data_exports_expires_idx on data_exports (expires_at) where expires_at is not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists data_exports;
+-- +goose StatementEnd
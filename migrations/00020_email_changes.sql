@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists email_changes (
+  id TEXT primary key,
+  tenant_id TEXT not null references organizations (id) on delete cascade,
+  user_id TEXT not null,
+  old_email TEXT not null,
+  new_email TEXT not null,
+  confirm_hash TEXT not null unique,
+  cancel_hash TEXT not null unique,
+  expires_at timestamp not null,
+  confirmed_at timestamp,
+  cancelled_at timestamp,
+  created_at timestamp not null
+);
+
+create index if not exists email_changes_user_idx on email_changes (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists email_changes;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists passwordless_challenges (
+  id TEXT primary key,
+  tenant_id TEXT not null references organizations (id) on delete cascade,
+  user_id TEXT not null,
+  link_hash TEXT not null unique,
+  code_hash TEXT not null,
+  attempts INTEGER not null default 0,
+  max_attempts INTEGER not null,
+  expires_at timestamp not null,
+  consumed_at timestamp,
+  created_at timestamp not null
+);
+
+create index if not exists passwordless_challenges_user_idx on passwordless_challenges (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists passwordless_challenges;
+-- +goose StatementEnd
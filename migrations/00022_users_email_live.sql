@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- email удаленного пользователя может занять новый, у стертых email пустой
+drop index if exists users_tenant_email_idx;
+create unique index if not exists users_tenant_email_idx on users (tenant_id, email) where status not in ('deleted', 'erased');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists users_tenant_email_idx;
+create unique index if not exists users_tenant_email_idx on users (tenant_id, email);
+-- +goose StatementEnd
@@ -0,0 +1,270 @@
+// Package authclient клиент UserService для сервисов, которые ходят в auth-service по gRPC
+package authclient
+
+import (
+	"context"
+	"errors"
+	auth1 "github.com/LeoUraltsev/proto/gen/go/auth"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+type User struct {
+	ID        uuid.UUID
+	Name      string
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// RetryPolicy повторы вызовов, на которые сервер ответил Unavailable.
+// Задержка растет вдвое с каждой попыткой и не превышает MaxDelay
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if d <= 0 || d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+type options struct {
+	tokens   TokenSource
+	email    string
+	password string
+	retry    RetryPolicy
+	dialOpts []grpc.DialOption
+}
+
+type Option func(o *options)
+
+// WithTokenSource токен для всех вызовов кроме Login и CreateUser
+func WithTokenSource(tokens TokenSource) Option {
+	return func(o *options) {
+		o.tokens = tokens
+	}
+}
+
+// WithCredentials клиент сам входит через Login и входит заново, когда токен истекает
+func WithCredentials(email string, password string) Option {
+	return func(o *options) {
+		o.email = email
+		o.password = password
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+// WithDialOptions для New, по умолчанию соединение без TLS
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+type Client struct {
+	conn   *grpc.ClientConn
+	users  auth1.UserServiceClient
+	tokens TokenSource
+	retry  RetryPolicy
+}
+
+// New открывает соединение с target, его закрывает Close
+func New(target string, opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	if len(o.dialOpts) == 0 {
+		o.dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	conn, err := grpc.NewClient(target, o.dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	c := newClient(conn, o)
+	c.conn = conn
+	return c, nil
+}
+
+// NewFromConn клиент поверх готового соединения, Close его не закрывает
+func NewFromConn(conn grpc.ClientConnInterface, opts ...Option) *Client {
+	return newClient(conn, newOptions(opts))
+}
+
+func newOptions(opts []Option) options {
+	o := options{retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func newClient(conn grpc.ClientConnInterface, o options) *Client {
+	c := &Client{
+		users:  auth1.NewUserServiceClient(conn),
+		tokens: o.tokens,
+		retry:  o.retry,
+	}
+	if o.email != "" {
+		c.tokens = newLoginTokenSource(c.users, o.email, o.password)
+	}
+	return c
+}
+
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+func (c *Client) Login(ctx context.Context, email string, password string) (string, error) {
+	var res *auth1.LoginResponse
+	err := c.invoke(ctx, false, func(ctx context.Context) error {
+		var err error
+		res, err = c.users.Login(ctx, &auth1.LoginRequest{Email: email, Password: password})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.Token, nil
+}
+
+func (c *Client) CreateUser(ctx context.Context, name string, email string, password string) (uuid.UUID, error) {
+	var res *auth1.CreateUserResponse
+	err := c.invoke(ctx, false, func(ctx context.Context) error {
+		var err error
+		res, err = c.users.CreateUser(ctx, &auth1.CreateUserRequest{Name: name, Email: email, Password: password})
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(res.Id)
+}
+
+func (c *Client) GetUser(ctx context.Context, id uuid.UUID) (*User, error) {
+	var res *auth1.GetUserResponse
+	err := c.invoke(ctx, true, func(ctx context.Context) error {
+		var err error
+		res, err = c.users.GetUser(ctx, &auth1.GetUserRequest{Id: id.String()})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userFromProto(res.User)
+}
+
+func (c *Client) GetListUsers(ctx context.Context, limit int, offset int) ([]*User, error) {
+	var res *auth1.GetListUserResponse
+	err := c.invoke(ctx, true, func(ctx context.Context) error {
+		var err error
+		res, err = c.users.GetListUsers(ctx, &auth1.GetListUserRequest{Limit: int32(limit), Offset: int32(offset)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*User, 0, len(res.Users))
+	for _, u := range res.Users {
+		usr, err := userFromProto(u)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, usr)
+	}
+	return list, nil
+}
+
+// UpdateUser пустые поля не меняются
+func (c *Client) UpdateUser(ctx context.Context, id uuid.UUID, name string, email string, password string) error {
+	return c.invoke(ctx, true, func(ctx context.Context) error {
+		_, err := c.users.UpdateUser(ctx, &auth1.UpdateUserRequest{Id: id.String(), Name: name, Email: email, Password: password})
+		return err
+	})
+}
+
+func (c *Client) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return c.invoke(ctx, true, func(ctx context.Context) error {
+		_, err := c.users.DeleteUser(ctx, &auth1.DeleteUserRequest{Id: id.String()})
+		return err
+	})
+}
+
+// invoke повторяет вызов с задержкой, пока сервер отвечает Unavailable
+func (c *Client) invoke(ctx context.Context, authenticated bool, call func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := c.callWithToken(ctx, authenticated, call)
+		if status.Code(err) != codes.Unavailable || attempt+1 >= c.retry.MaxAttempts {
+			return errorFromStatus(err)
+		}
+
+		timer := time.NewTimer(c.retry.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(errorFromStatus(err), ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// callWithToken добавляет токен и один раз повторяет вызов с новым токеном, если сервер отверг старый
+func (c *Client) callWithToken(ctx context.Context, authenticated bool, call func(ctx context.Context) error) error {
+	if !authenticated || c.tokens == nil {
+		return call(ctx)
+	}
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+	err = call(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token))
+	if status.Code(err) != codes.Unauthenticated {
+		return err
+	}
+
+	c.tokens.Invalidate()
+	refreshed, tokenErr := c.tokens.Token(ctx)
+	// источник без обновления вернет тот же токен, повтор бессмысленен
+	if tokenErr != nil || refreshed == token {
+		return err
+	}
+	return call(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+refreshed))
+}
+
+func userFromProto(u *auth1.User) (*User, error) {
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	id, err := uuid.Parse(u.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &User{
+		ID:        id,
+		Name:      u.Name,
+		Email:     u.Email,
+		CreatedAt: u.CreatedAt.AsTime(),
+		UpdatedAt: u.UpdatedAt.AsTime(),
+	}, nil
+}
@@ -0,0 +1,203 @@
+package authclient
+
+import (
+	"context"
+	"github.com/LeoUraltsev/auth-service/internal/application"
+	mockapplication "github.com/LeoUraltsev/auth-service/internal/application/mocks"
+	"github.com/LeoUraltsev/auth-service/internal/config"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	usergrpc "github.com/LeoUraltsev/auth-service/internal/infrastructure/grpc"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/interceptors"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"log/slog"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+func newTokens(secret string) *jwt.Token {
+	return jwt.NewToken(log, &config.Config{JWT: config.JWTConfig{Secret: secret, Expiration: time.Hour}})
+}
+
+type sessionsFunc func(token oauth.AccessToken) error
+
+func (f sessionsFunc) VerifySession(_ context.Context, token oauth.AccessToken) error {
+	return f(token)
+}
+
+// newTestConn настоящий gRPC сервер с интерсепторами сервиса в памяти процесса
+func newTestConn(t *testing.T, service application.UserService, extra ...grpc.UnaryServerInterceptor) *grpc.ClientConn {
+	active := sessionsFunc(func(oauth.AccessToken) error {
+		return nil
+	})
+	return newSessionsConn(t, service, active, extra...)
+}
+
+func newSessionsConn(
+	t *testing.T,
+	service application.UserService,
+	sessions interceptors.SessionVerifier,
+	extra ...grpc.UnaryServerInterceptor,
+) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	i := interceptors.New(log, newTokens("secret"), nil, sessions)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(append(extra, i.RequestID, i.Auth)...))
+	usergrpc.Register(server, service, log)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func newTestUser(t *testing.T) *users.User {
+	email, err := users.NewEmail("user@example.com")
+	require.NoError(t, err)
+	pass, err := users.NewPassword([]byte("hashpassword"))
+	require.NoError(t, err)
+	usr, err := users.CreateUser(organizations.DefaultID, "name", email, pass)
+	require.NoError(t, err)
+	return usr
+}
+
+func TestClient_credentials(t *testing.T) {
+	usr := newTestUser(t)
+	token, err := newTokens("secret").GenerateToken(usr.ID(), organizations.DefaultID, usr.Role(), nil)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	service := mockapplication.NewMockUserService(ctrl)
+	service.EXPECT().Login(gomock.Any(), "user@example.com", "password").Return(token, nil).Times(1)
+	service.EXPECT().GetUser(gomock.Any(), usr.ID()).Return(usr, nil).Times(2)
+
+	client := NewFromConn(newTestConn(t, service), WithCredentials("user@example.com", "password"))
+	for range 2 {
+		got, err := client.GetUser(context.Background(), usr.ID())
+		require.NoError(t, err)
+		assert.Equal(t, usr.ID(), got.ID)
+		assert.Equal(t, "user@example.com", got.Email)
+	}
+}
+
+func TestClient_refreshRejectedToken(t *testing.T) {
+	usr := newTestUser(t)
+	foreign, err := newTokens("other secret").GenerateToken(usr.ID(), organizations.DefaultID, usr.Role(), nil)
+	require.NoError(t, err)
+	valid, err := newTokens("secret").GenerateToken(usr.ID(), organizations.DefaultID, usr.Role(), nil)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	service := mockapplication.NewMockUserService(ctrl)
+	gomock.InOrder(
+		service.EXPECT().Login(gomock.Any(), "user@example.com", "password").Return(foreign, nil),
+		service.EXPECT().Login(gomock.Any(), "user@example.com", "password").Return(valid, nil),
+	)
+	service.EXPECT().DeleteUser(gomock.Any(), usr.ID()).Return(nil)
+
+	client := NewFromConn(newTestConn(t, service), WithCredentials("user@example.com", "password"))
+	require.NoError(t, client.DeleteUser(context.Background(), usr.ID()))
+}
+
+func TestClient_staticTokenRejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := mockapplication.NewMockUserService(ctrl)
+
+	client := NewFromConn(newTestConn(t, service), WithTokenSource(StaticToken("broken")))
+	_, err := client.GetListUsers(context.Background(), 10, 0)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestClient_tokenBeforeForceLogout(t *testing.T) {
+	usr := newTestUser(t)
+	token, err := newTokens("secret").GenerateToken(usr.ID(), organizations.DefaultID, usr.Role(), nil)
+	require.NoError(t, err)
+	usr.ForceLogout(time.Now().Add(time.Second))
+
+	sessions := sessionsFunc(func(token oauth.AccessToken) error {
+		if token.UserID != usr.ID() || usr.TokenRevoked(token.IssuedAt) {
+			return application.ErrTokenNotActive
+		}
+		return nil
+	})
+	service := mockapplication.NewMockUserService(gomock.NewController(t))
+
+	client := NewFromConn(newSessionsConn(t, service, sessions), WithTokenSource(StaticToken(token)))
+	_, err = client.GetUser(context.Background(), usr.ID())
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestClient_retryUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	unavailable := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if calls.Add(1) <= 2 {
+			return nil, status.Error(codes.Unavailable, "overloaded")
+		}
+		return handler(ctx, req)
+	}
+	id := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	service := mockapplication.NewMockUserService(ctrl)
+	service.EXPECT().CreateUser(gomock.Any(), "name", "user@example.com", "password").Return(id, nil)
+
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	client := NewFromConn(newTestConn(t, service, unavailable), WithRetryPolicy(policy))
+	got, err := client.CreateUser(context.Background(), "name", "user@example.com", "password")
+	require.NoError(t, err)
+	assert.Equal(t, id, got)
+	assert.Equal(t, int32(3), calls.Load())
+
+	calls.Store(0)
+	policy.MaxAttempts = 2
+	client = NewFromConn(newTestConn(t, service, unavailable), WithRetryPolicy(policy))
+	_, err = client.CreateUser(context.Background(), "name", "user@example.com", "password")
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestClient_errors(t *testing.T) {
+	usr := newTestUser(t)
+	token, err := newTokens("secret").GenerateToken(usr.ID(), organizations.DefaultID, usr.Role(), nil)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	service := mockapplication.NewMockUserService(ctrl)
+	service.EXPECT().Login(gomock.Any(), "user@example.com", "wrong").Return("", users.ErrInvalidCredentials)
+	service.EXPECT().CreateUser(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(uuid.Nil, users.ErrEmailAlreadyExists)
+	service.EXPECT().GetUser(gomock.Any(), gomock.Any()).Return(nil, users.ErrUserNotFound)
+
+	client := NewFromConn(newTestConn(t, service), WithTokenSource(StaticToken(token)))
+	ctx := context.Background()
+
+	_, err = client.Login(ctx, "user@example.com", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = client.CreateUser(ctx, "name", "user@example.com", "password")
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+	_, err = client.GetUser(ctx, uuid.New())
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
@@ -0,0 +1,68 @@
+package authclient
+
+import (
+	"errors"
+	"fmt"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Ошибки сервиса, совпадают со значениями из domain/users и сравниваются через errors.Is
+var (
+	ErrEmailNotValid      = users.ErrEmailNotValid
+	ErrEmailAlreadyExists = users.ErrEmailAlreadyExists
+	ErrNameRequired       = users.ErrNameRequired
+	ErrPasswordRequired   = users.ErrPasswordRequired
+	ErrPasswordTooShort   = users.ErrPasswordTooShort
+	ErrInvalidCredentials = users.ErrInvalidCredentials
+	ErrRoleNotValid       = users.ErrRoleNotValid
+	ErrUserNotFound       = users.ErrUserNotFound
+)
+
+// Ошибки без аналога в domain/users
+var (
+	ErrUnauthenticated  = errors.New("unauthenticated")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrUnavailable      = errors.New("auth service is unavailable")
+)
+
+var knownErrors = []error{
+	ErrEmailNotValid,
+	ErrEmailAlreadyExists,
+	ErrNameRequired,
+	ErrPasswordRequired,
+	ErrPasswordTooShort,
+	ErrInvalidCredentials,
+	ErrRoleNotValid,
+	ErrUserNotFound,
+}
+
+// errorFromStatus сервер отдает текст доменной ошибки в сообщении статуса, по нему ошибка восстанавливается.
+// Незнакомое сообщение переводится по коду, остальные ошибки возвращаются как есть
+func errorFromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.OK {
+		return err
+	}
+	for _, known := range knownErrors {
+		if st.Message() == known.Error() {
+			return known
+		}
+	}
+	switch st.Code() {
+	case codes.Unauthenticated:
+		return fmt.Errorf("%w: %s", ErrUnauthenticated, st.Message())
+	case codes.PermissionDenied:
+		return fmt.Errorf("%w: %s", ErrPermissionDenied, st.Message())
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w: %s", ErrInvalidArgument, st.Message())
+	case codes.NotFound:
+		return fmt.Errorf("%w: %s", ErrUserNotFound, st.Message())
+	case codes.Unavailable:
+		return fmt.Errorf("%w: %s", ErrUnavailable, st.Message())
+	default:
+		return err
+	}
+}
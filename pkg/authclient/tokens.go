@@ -0,0 +1,84 @@
+package authclient
+
+import (
+	"context"
+	auth1 "github.com/LeoUraltsev/proto/gen/go/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"sync"
+	"time"
+)
+
+// refreshMargin токен обновляется заранее, чтобы он не истек по дороге к серверу
+const refreshMargin = 30 * time.Second
+
+// TokenSource токен для заголовка authorization.
+// Invalidate вызывается, когда сервер отверг токен, следующий Token должен вернуть новый
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+	Invalidate()
+}
+
+type staticToken string
+
+// StaticToken готовый токен, например сервисного аккаунта. Обновить его клиент не может
+func StaticToken(token string) TokenSource {
+	return staticToken(token)
+}
+
+func (t staticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+func (t staticToken) Invalidate() {}
+
+// loginTokenSource входит через UserService/Login и входит заново, когда токен истекает или отвергнут
+type loginTokenSource struct {
+	users    auth1.UserServiceClient
+	email    string
+	password string
+	now      func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func newLoginTokenSource(users auth1.UserServiceClient, email string, password string) *loginTokenSource {
+	return &loginTokenSource{
+		users:    users,
+		email:    email,
+		password: password,
+		now:      time.Now,
+	}
+}
+
+func (s *loginTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && (s.expiresAt.IsZero() || s.now().Add(refreshMargin).Before(s.expiresAt)) {
+		return s.token, nil
+	}
+	res, err := s.users.Login(ctx, &auth1.LoginRequest{Email: s.email, Password: s.password})
+	if err != nil {
+		return "", err
+	}
+	s.token = res.Token
+	s.expiresAt = tokenExpiry(res.Token)
+	return s.token, nil
+}
+
+func (s *loginTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+// tokenExpiry подпись проверяет сервер, клиенту нужен только exp. Нулевое время, если exp нет
+func tokenExpiry(token string) time.Time {
+	claims := jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil || claims.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return claims.ExpiresAt.Time
+}
@@ -0,0 +1,8 @@
+package authverify
+
+import "time"
+
+// SetNow подменяет часы кэша JWKS в тестах
+func SetNow(v *Verifier, now func() time.Time) {
+	v.keys.now = now
+}
@@ -0,0 +1,64 @@
+package authverify
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"slices"
+)
+
+// UnaryServerInterceptor проверяет токен из метаданных "authorization: Bearer <token>"
+// и кладет Principal в контекст обработчика
+func (v *Verifier) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := v.authenticateGRPC(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (v *Verifier) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := v.authenticateGRPC(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (v *Verifier) authenticateGRPC(ctx context.Context, method string) (context.Context, error) {
+	if slices.Contains(v.cfg.PublicMethods, method) {
+		return ctx, nil
+	}
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if a := md.Get("authorization"); len(a) > 0 {
+			token = bearerToken(a[0])
+		}
+	}
+
+	p, err := v.Verify(ctx, token)
+	switch {
+	case errors.Is(err, ErrInsufficientScope):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return NewContext(ctx, p), nil
+}
+
+// serverStream подменяет контекст потока, grpc.ServerStream не позволяет сделать это иначе
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
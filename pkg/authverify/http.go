@@ -0,0 +1,30 @@
+package authverify
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Middleware проверяет токен из заголовка Authorization и кладет Principal в контекст запроса.
+// Ошибки по RFC 6750: 401 без токена или с недействительным токеном, 403 без нужных scope
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := v.Verify(r.Context(), bearerToken(r.Header.Get("Authorization")))
+		switch {
+		case errors.Is(err, ErrNoToken):
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		case errors.Is(err, ErrInsufficientScope):
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+strings.Join(v.cfg.RequiredScopes, " ")+`"`)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		case err != nil:
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), p)))
+	})
+}
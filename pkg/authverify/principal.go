@@ -0,0 +1,55 @@
+package authverify
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"slices"
+	"time"
+)
+
+// Типы владельцев токена, значения claim principal_type
+const (
+	TypeUser           = "user"
+	TypeServiceAccount = "service_account"
+	TypeClient         = "client"
+)
+
+// Principal владелец проверенного токена
+type Principal struct {
+	Subject string
+	// UserID uuid.Nil у токенов client_credentials, выданных клиенту, а не пользователю
+	UserID uuid.UUID
+	// TenantID организация пользователя, uuid.Nil у клиентов, сервисных аккаунтов и токенов до организаций
+	TenantID uuid.UUID
+	Role     string
+	// Type TypeUser, TypeServiceAccount или TypeClient
+	Type string
+	// ClientID и Scope заполнены у токенов, выданных через OAuth
+	ClientID  string
+	Scope     []string
+	SessionID string
+	ExpiresAt time.Time
+}
+
+// HasScope у токена есть все перечисленные scope
+func (p *Principal) HasScope(scope ...string) bool {
+	for _, s := range scope {
+		if !slices.Contains(p.Scope, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// contextKey неэкспортируемый тип, поэтому ключ не совпадет с ключами других пакетов
+type contextKey struct{}
+
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext владелец токена, который положили интерсепторы или Middleware
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,170 @@
+// Package authverify проверка токенов auth-service в других сервисах без обращения к нему:
+// по JWKS для токенов RS256 или по общему секрету для HS256
+package authverify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCacheTTL = time.Hour
+	defaultLeeway   = 30 * time.Second
+	// typeAccessToken typ токенов доступа RS256, ID токены подписаны тем же ключом, но без него
+	typeAccessToken = "at+jwt"
+)
+
+var (
+	ErrConfigNotValid    = errors.New("authverify: either JWKSURL or Secret must be set")
+	ErrNoToken           = errors.New("no token")
+	ErrTokenNotValid     = errors.New("token not valid")
+	ErrInsufficientScope = errors.New("insufficient scope")
+)
+
+type Config struct {
+	// JWKSURL адрес /.well-known/jwks.json auth-service, токены RS256
+	JWKSURL string
+	// Secret общий секрет JWT_SECRET, токены HS256. Задается либо он, либо JWKSURL
+	Secret []byte
+	// Issuer и Audience проверяются, если заданы. У токенов Login нет aud
+	Issuer   string
+	Audience string
+	// RequiredScopes scope, без которых токен отвергается. У токенов Login scope нет
+	RequiredScopes []string
+	// PublicMethods полные имена методов gRPC, которые вызываются без токена
+	PublicMethods []string
+	// CacheTTL сколько ключи JWKS считаются свежими, по умолчанию час
+	CacheTTL time.Duration
+	// Leeway допуск расхождения часов для exp, по умолчанию 30 секунд
+	Leeway     time.Duration
+	HTTPClient *http.Client
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	UserID        uuid.UUID `json:"user_id"`
+	TenantID      uuid.UUID `json:"tenant_id"`
+	Role          string    `json:"role"`
+	ClientID      string    `json:"client_id,omitempty"`
+	Scope         string    `json:"scope,omitempty"`
+	PrincipalType string    `json:"principal_type,omitempty"`
+	SessionID     string    `json:"sid,omitempty"`
+}
+
+type Verifier struct {
+	cfg    Config
+	keys   *keySet
+	parser *jwt.Parser
+}
+
+func New(cfg Config) (*Verifier, error) {
+	if (cfg.JWKSURL == "") == (len(cfg.Secret) == 0) {
+		return nil, ErrConfigNotValid
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = defaultLeeway
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	alg := jwt.SigningMethodHS256.Alg()
+	if cfg.JWKSURL != "" {
+		alg = jwt.SigningMethodRS256.Alg()
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{alg}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	v := &Verifier{
+		cfg:    cfg,
+		parser: jwt.NewParser(opts...),
+	}
+	if cfg.JWKSURL != "" {
+		v.keys = newKeySet(cfg.JWKSURL, cfg.HTTPClient, cfg.CacheTTL)
+	}
+	return v, nil
+}
+
+// Verify проверяет подпись, срок, issuer, audience и обязательные scope.
+// Ключи JWKS загружаются при первом токене и перечитываются, когда устарели или пришел неизвестный kid
+func (v *Verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	c := &claims{}
+	_, err := v.parser.ParseWithClaims(token, c, func(t *jwt.Token) (interface{}, error) {
+		if v.keys == nil {
+			return v.cfg.Secret, nil
+		}
+		if typ, _ := t.Header["typ"].(string); typ != typeAccessToken {
+			return nil, fmt.Errorf("token type %q is not an access token", typ)
+		}
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenNotValid, err)
+	}
+
+	p := principalFromClaims(c)
+	if !p.HasScope(v.cfg.RequiredScopes...) {
+		return nil, ErrInsufficientScope
+	}
+	return p, nil
+}
+
+func principalFromClaims(c *claims) *Principal {
+	p := &Principal{
+		Subject:   c.Subject,
+		UserID:    c.UserID,
+		TenantID:  c.TenantID,
+		Role:      c.Role,
+		Type:      c.PrincipalType,
+		ClientID:  c.ClientID,
+		Scope:     strings.Fields(c.Scope),
+		SessionID: c.SessionID,
+	}
+	// токены Login старых версий без principal_type и sub
+	if p.Type == "" {
+		p.Type = TypeUser
+	}
+	if p.UserID == uuid.Nil && p.Type != TypeClient {
+		if id, err := uuid.Parse(c.Subject); err == nil {
+			p.UserID = id
+		}
+	}
+	if p.Subject == "" && p.UserID != uuid.Nil {
+		p.Subject = p.UserID.String()
+	}
+	if c.ExpiresAt != nil {
+		p.ExpiresAt = c.ExpiresAt.Time
+	}
+	return p
+}
+
+// bearerToken значение заголовка authorization без схемы Bearer
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,287 @@
+package authverify_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/LeoUraltsev/auth-service/internal/config"
+	"github.com/LeoUraltsev/auth-service/internal/domain/oauth"
+	"github.com/LeoUraltsev/auth-service/internal/domain/organizations"
+	"github.com/LeoUraltsev/auth-service/internal/domain/users"
+	"github.com/LeoUraltsev/auth-service/internal/infrastructure/jwt"
+	"github.com/LeoUraltsev/auth-service/pkg/authverify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	secret = "14f982080eacd7e38bd7a74fc0519946"
+	issuer = "https://auth.example.com"
+)
+
+var log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+// newTokens токены выпускает тот же код, что в auth-service. Тесты во внешнем пакете:
+// internal/infrastructure/jwt сам импортирует authverify
+func newTokens(t *testing.T, alg string) *jwt.Token {
+	key, err := jwt.GenerateSigningKey()
+	require.NoError(t, err)
+	cfg := &config.Config{
+		JWT:   config.JWTConfig{Secret: secret, Expiration: time.Hour, AccessTokenAlg: alg},
+		OAuth: config.OAuthConfig{Issuer: issuer},
+	}
+	return jwt.NewToken(log, cfg).WithSigningKey(key)
+}
+
+// newJWKSServer считает запросы, чтобы проверить кэш
+func newJWKSServer(t *testing.T, tokens *jwt.Token) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		_ = json.NewEncoder(w).Encode(tokens.JWKS())
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func issue(t *testing.T, tokens *jwt.Token, grant oauth.AccessGrant) string {
+	token, _, err := tokens.IssueAccessToken(grant)
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerifier_Verify_secret(t *testing.T) {
+	tokens := newTokens(t, jwt.AlgHS256)
+	id := uuid.New()
+	sessionID := uuid.New()
+
+	v, err := authverify.New(authverify.Config{Secret: []byte(secret), Issuer: issuer, Audience: "orders"})
+	require.NoError(t, err)
+
+	p, err := v.Verify(context.Background(), issue(t, tokens, oauth.AccessGrant{
+		UserID:    id,
+		Role:      users.RoleAdmin,
+		ClientID:  "orders",
+		Scope:     oauth.Scope{"orders.read", "orders.write"},
+		SessionID: sessionID,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, id, p.UserID)
+	assert.Equal(t, id.String(), p.Subject)
+	assert.Equal(t, "admin", p.Role)
+	assert.Equal(t, authverify.TypeUser, p.Type)
+	assert.Equal(t, "orders", p.ClientID)
+	assert.Equal(t, []string{"orders.read", "orders.write"}, p.Scope)
+	assert.Equal(t, sessionID.String(), p.SessionID)
+	assert.False(t, p.ExpiresAt.IsZero())
+
+	_, err = v.Verify(context.Background(), issue(t, tokens, oauth.AccessGrant{UserID: id, ClientID: "billing"}))
+	assert.ErrorIs(t, err, authverify.ErrTokenNotValid, "foreign audience")
+
+	login, err := tokens.GenerateToken(id, organizations.DefaultID, users.RoleUser, nil)
+	require.NoError(t, err)
+	v, err = authverify.New(authverify.Config{Secret: []byte(secret), Issuer: issuer})
+	require.NoError(t, err)
+	p, err = v.Verify(context.Background(), login)
+	require.NoError(t, err)
+	assert.Equal(t, id, p.UserID)
+
+	v, err = authverify.New(authverify.Config{Secret: []byte("other secret")})
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), login)
+	assert.ErrorIs(t, err, authverify.ErrTokenNotValid)
+	_, err = v.Verify(context.Background(), "")
+	assert.ErrorIs(t, err, authverify.ErrNoToken)
+}
+
+func TestVerifier_Verify_jwks(t *testing.T) {
+	tokens := newTokens(t, jwt.AlgRS256)
+	server, calls := newJWKSServer(t, tokens)
+	id := uuid.New()
+
+	v, err := authverify.New(authverify.Config{JWKSURL: server.URL, Issuer: issuer})
+	require.NoError(t, err)
+
+	for range 3 {
+		p, err := v.Verify(context.Background(), issue(t, tokens, oauth.AccessGrant{UserID: id, ClientID: "orders"}))
+		require.NoError(t, err)
+		assert.Equal(t, id, p.UserID)
+	}
+	assert.Equal(t, int32(1), calls.Load(), "keys are cached")
+
+	// ID токен подписан тем же ключом, но не является токеном доступа
+	email, _ := users.NewEmail("user@example.com")
+	pass, _ := users.NewPassword([]byte("hashpassword"))
+	user, _ := users.CreateUser(organizations.DefaultID, "name", email, pass)
+	idToken, err := tokens.IssueIDToken(oauth.NewIDToken(user, "orders", "", oauth.Authentication{}, oauth.Scope{"openid"}))
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), idToken)
+	assert.ErrorIs(t, err, authverify.ErrTokenNotValid)
+
+	// токен HS256 с общим секретом не принимается, когда ключи берутся из JWKS
+	login, err := newTokens(t, jwt.AlgHS256).GenerateToken(id, organizations.DefaultID, users.RoleUser, nil)
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), login)
+	assert.ErrorIs(t, err, authverify.ErrTokenNotValid)
+
+	// ключ другого сервиса: JWKS перечитывается не чаще раза в минуту
+	foreign := issue(t, newTokens(t, jwt.AlgRS256), oauth.AccessGrant{UserID: id})
+	_, err = v.Verify(context.Background(), foreign)
+	assert.ErrorIs(t, err, authverify.ErrTokenNotValid)
+	assert.Equal(t, int32(1), calls.Load())
+	authverify.SetNow(v, func() time.Time { return time.Now().Add(2 * time.Minute) })
+	_, err = v.Verify(context.Background(), foreign)
+	assert.ErrorIs(t, err, authverify.ErrTokenNotValid)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestVerifier_Verify_staleKeys(t *testing.T) {
+	tokens := newTokens(t, jwt.AlgRS256)
+	server, _ := newJWKSServer(t, tokens)
+	token := issue(t, tokens, oauth.AccessGrant{UserID: uuid.New()})
+
+	v, err := authverify.New(authverify.Config{JWKSURL: server.URL, CacheTTL: time.Minute})
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), token)
+	require.NoError(t, err)
+
+	// auth-service недоступен, токены проверяются по ключам из кэша
+	server.Close()
+	authverify.SetNow(v, func() time.Time { return time.Now().Add(time.Hour) })
+	_, err = v.Verify(context.Background(), token)
+	assert.NoError(t, err)
+}
+
+func TestVerifier_Verify_requiredScopes(t *testing.T) {
+	tokens := newTokens(t, jwt.AlgHS256)
+	v, err := authverify.New(authverify.Config{Secret: []byte(secret), RequiredScopes: []string{"orders.read"}})
+	require.NoError(t, err)
+
+	_, err = v.Verify(context.Background(), issue(t, tokens, oauth.AccessGrant{Scope: oauth.Scope{"orders.read", "openid"}}))
+	assert.NoError(t, err)
+	_, err = v.Verify(context.Background(), issue(t, tokens, oauth.AccessGrant{Scope: oauth.Scope{"openid"}}))
+	assert.ErrorIs(t, err, authverify.ErrInsufficientScope)
+}
+
+func TestNew(t *testing.T) {
+	_, err := authverify.New(authverify.Config{})
+	assert.ErrorIs(t, err, authverify.ErrConfigNotValid)
+	_, err = authverify.New(authverify.Config{JWKSURL: "https://auth.example.com/.well-known/jwks.json", Secret: []byte(secret)})
+	assert.ErrorIs(t, err, authverify.ErrConfigNotValid)
+}
+
+func TestVerifier_UnaryServerInterceptor(t *testing.T) {
+	tokens := newTokens(t, jwt.AlgHS256)
+	id := uuid.New()
+	v, err := authverify.New(authverify.Config{
+		Secret:         []byte(secret),
+		RequiredScopes: []string{"orders.read"},
+		PublicMethods:  []string{"/orders.Orders/Health"},
+	})
+	require.NoError(t, err)
+	interceptor := v.UnaryServerInterceptor()
+
+	handler := func(ctx context.Context, _ any) (any, error) {
+		p, ok := authverify.FromContext(ctx)
+		if !ok {
+			return "anonymous", nil
+		}
+		return p.UserID.String(), nil
+	}
+	call := func(method string, token string) (any, error) {
+		ctx := context.Background()
+		if token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+		}
+		return interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	}
+
+	res, err := call("/orders.Orders/Get", issue(t, tokens, oauth.AccessGrant{UserID: id, Scope: oauth.Scope{"orders.read"}}))
+	require.NoError(t, err)
+	assert.Equal(t, id.String(), res)
+
+	_, err = call("/orders.Orders/Get", issue(t, tokens, oauth.AccessGrant{UserID: id}))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = call("/orders.Orders/Get", "")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	res, err = call("/orders.Orders/Health", "")
+	require.NoError(t, err)
+	assert.Equal(t, "anonymous", res)
+}
+
+func TestVerifier_Middleware(t *testing.T) {
+	tokens := newTokens(t, jwt.AlgHS256)
+	id := uuid.New()
+	v, err := authverify.New(authverify.Config{Secret: []byte(secret), RequiredScopes: []string{"orders.read"}})
+	require.NoError(t, err)
+	handler := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := authverify.FromContext(r.Context())
+		require.True(t, ok)
+		_, _ = w.Write([]byte(p.UserID.String()))
+	}))
+
+	cases := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantChallenge string
+	}{
+		{
+			name:          "ok",
+			authorization: "Bearer " + issue(t, tokens, oauth.AccessGrant{UserID: id, Scope: oauth.Scope{"orders.read"}}),
+			wantStatus:    http.StatusOK,
+		},
+		{name: "no token", wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "invalid token", authorization: "Bearer broken", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer error="invalid_token"`},
+		{
+			name:          "insufficient scope",
+			authorization: "Bearer " + issue(t, tokens, oauth.AccessGrant{UserID: id}),
+			wantStatus:    http.StatusForbidden,
+			wantChallenge: `Bearer error="insufficient_scope", scope="orders.read"`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantChallenge, rec.Header().Get("WWW-Authenticate"))
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, id.String(), rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	id := uuid.New()
+	// строковый ключ другого пакета не подменяет владельца токена
+	ctx := context.WithValue(context.Background(), "user_id", id)
+	_, ok := authverify.FromContext(ctx)
+	assert.False(t, ok)
+
+	ctx = authverify.NewContext(ctx, &authverify.Principal{UserID: id, Scope: []string{"a", "b"}})
+	p, ok := authverify.FromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, id, p.UserID)
+	assert.True(t, p.HasScope("b", "a"))
+	assert.False(t, p.HasScope("a", "c"))
+}